@@ -3,7 +3,9 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
 	"net/http"
 	"os"
@@ -62,14 +64,62 @@ func main() {
 		cfg.Safety.VMs.Denylist,
 	)
 
+	createPolicy := &docker.CreatePolicy{
+		AllowPrivileged:     cfg.Safety.DockerCreate.AllowPrivileged,
+		AllowHostNetwork:    cfg.Safety.DockerCreate.AllowHostNetwork,
+		AllowedCapabilities: cfg.Safety.DockerCreate.AllowedCapabilities,
+		AllowedDevices:      cfg.Safety.DockerCreate.AllowedDevices,
+		AllowedBindPaths:    cfg.Safety.DockerCreate.AllowedBindPaths,
+		MaxMemory:           int64(cfg.Safety.DockerCreate.MaxMemoryMB) * 1024 * 1024,
+		MaxCPUs:             cfg.Safety.DockerCreate.MaxCPUs,
+	}
+
+	execPolicy := &docker.ExecPolicy{
+		Timeout:   time.Duration(cfg.Safety.DockerExec.TimeoutSeconds) * time.Second,
+		MaxOutput: cfg.Safety.DockerExec.MaxOutputKB * 1024,
+	}
+	for _, r := range cfg.Safety.DockerExec.Rules {
+		execPolicy.Rules = append(execPolicy.Rules, docker.ExecRule{Containers: r.Containers, Commands: r.Commands})
+	}
+
 	dockerConfirm := safety.NewConfirmationTracker(docker.DestructiveTools)
 	vmConfirm := safety.NewConfirmationTracker(vm.DestructiveTools)
 
 	// Build resource managers.
-	dockerMgr, err := docker.NewDockerClientManager(cfg.Paths.DockerSocket)
+	dockerHost := cfg.Docker.Host
+	if dockerHost == "" {
+		dockerHost = "unix://" + cfg.Paths.DockerSocket
+	}
+	dockerMgr, err := docker.NewDockerClient(docker.ClientConfig{
+		Host:       dockerHost,
+		CACertFile: cfg.Docker.TLSCACert,
+		CertFile:   cfg.Docker.TLSCert,
+		KeyFile:    cfg.Docker.TLSKey,
+		APIVersion: cfg.Docker.APIVersion,
+	})
 	if err != nil {
 		log.Fatalf("failed to create Docker manager: %v", err)
 	}
+	pingCtx, cancelPing := context.WithTimeout(context.Background(), 5*time.Second)
+	log.Printf("Docker endpoint %s, API version %s", dockerMgr.Host(), dockerMgr.APIVersion(pingCtx))
+	cancelPing()
+
+	// Registry credentials: entries in the config take precedence over the
+	// host's docker login credentials.
+	registryCreds := docker.NewRegistryCredentials()
+	for _, a := range cfg.DockerRegistry.Auths {
+		registryCreds.Add(a.Registry, docker.RegistryAuth{Username: a.Username, Password: a.Password})
+	}
+	if path := cfg.DockerRegistry.ConfigFile; path != "" {
+		warnings, err := registryCreds.LoadDockerConfig(path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("warning: could not load registry credentials: %v", err)
+		}
+		for _, w := range warnings {
+			log.Printf("warning: %s", w)
+		}
+	}
+	dockerMgr.SetRegistryCredentials(registryCreds)
 
 	// VM manager: attempt real libvirt connection; fall back gracefully if
 	// the libvirt build tag is absent or the socket is unavailable.
@@ -80,6 +130,41 @@ func main() {
 		vmMgr = rawVMMgr
 	}
 
+	templateStore := docker.NewFileTemplateStore(cfg.Paths.DockerTemplates)
+
+	// Background Docker and VM monitoring runs until shutdown.
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+
+	// Record Docker events.
+	eventHistory := docker.NewEventHistory(docker.DefaultEventHistorySize)
+	eventsDone := make(chan struct{})
+	go func() {
+		defer close(eventsDone)
+		docker.NewEventWatcher(dockerMgr, eventHistory).Run(backgroundCtx)
+	}()
+
+	// Sample container resource usage.
+	statsInterval := time.Duration(cfg.DockerStats.IntervalSeconds) * time.Second
+	statsHistory := docker.NewStatsHistory(statsInterval, time.Duration(cfg.DockerStats.RetentionMinutes)*time.Minute)
+	statsDone := make(chan struct{})
+	go func() {
+		defer close(statsDone)
+		docker.NewStatsSampler(dockerMgr, statsHistory, statsInterval).Run(backgroundCtx)
+	}()
+
+	// Sample VM resource usage when libvirt is available.
+	vmStatsInterval := time.Duration(cfg.VMStats.IntervalSeconds) * time.Second
+	vmStatsHistory := vm.NewStatsHistory(vmStatsInterval, time.Duration(cfg.VMStats.RetentionMinutes)*time.Minute)
+	vmStatsDone := make(chan struct{})
+	if vmMgr != nil {
+		go func() {
+			defer close(vmStatsDone)
+			vm.NewStatsSampler(vmMgr, vmStatsHistory, vmStatsInterval).Run(backgroundCtx)
+		}()
+	} else {
+		close(vmStatsDone)
+	}
+
 	systemMon := system.NewFileSystemMonitor(
 		cfg.Paths.Proc,
 		cfg.Paths.Sys,
@@ -95,10 +180,23 @@ func main() {
 
 	// Register all tools.
 	var registrations []tools.Registration
-	registrations = append(registrations, docker.DockerTools(dockerMgr, dockerFilter, dockerConfirm, auditLogger)...)
+	registrations = append(registrations, docker.DockerTools(dockerMgr, dockerFilter, createPolicy, execPolicy, dockerConfirm, auditLogger)...)
+	registrations = append(registrations, docker.TemplateTools(dockerMgr, templateStore, dockerFilter, createPolicy, dockerConfirm, auditLogger)...)
+	registrations = append(registrations, docker.EventTools(eventHistory, dockerFilter, auditLogger)...)
+	registrations = append(registrations, docker.DiagnoseTools(dockerMgr, eventHistory, dockerFilter, auditLogger)...)
+	registrations = append(registrations, docker.StatsTools(statsHistory, dockerFilter, auditLogger)...)
+	registrations = append(registrations, docker.DiskUsageTools(dockerMgr, systemMon, dockerFilter, auditLogger)...)
+	registryClient := docker.NewHTTPRegistryClient(nil, registryCreds)
+	registryClient.SetInsecureRegistries(dockerMgr)
+	updateChecker := docker.NewUpdateChecker(registryClient, docker.DefaultUpdateCheckTTL)
+	registrations = append(registrations, docker.UpdateCheckTools(dockerMgr, updateChecker, dockerFilter, auditLogger)...)
+	registrations = append(registrations, docker.BulkTools(dockerMgr, cfg.Paths.DockerAutostart, dockerFilter, dockerConfirm, auditLogger)...)
+	registrations = append(registrations, docker.AutostartTools(dockerMgr, cfg.Paths.DockerAutostart, dockerFilter, auditLogger)...)
+	registrations = append(registrations, docker.ComposeTools(dockerMgr, cfg.Paths.ComposeProjects, dockerFilter, createPolicy, dockerConfirm, auditLogger)...)
 
 	if vmMgr != nil {
 		registrations = append(registrations, vm.VMTools(vmMgr, vmFilter, vmConfirm, auditLogger)...)
+		registrations = append(registrations, vm.StatsTools(vmMgr, vmStatsHistory, vmFilter, auditLogger)...)
 	}
 
 	registrations = append(registrations, system.SystemTools(systemMon, auditLogger)...)
@@ -169,6 +267,23 @@ func main() {
 	if err := httpSrv.Shutdown(ctx); err != nil {
 		log.Printf("graceful shutdown error: %v", err)
 	}
+
+	stopBackground()
+	select {
+	case <-eventsDone:
+	case <-ctx.Done():
+		log.Println("timed out waiting for the Docker event watcher to stop")
+	}
+	select {
+	case <-statsDone:
+	case <-ctx.Done():
+		log.Println("timed out waiting for the Docker stats sampler to stop")
+	}
+	select {
+	case <-vmStatsDone:
+	case <-ctx.Done():
+		log.Println("timed out waiting for the VM stats sampler to stop")
+	}
 	log.Println("server stopped")
 }
 
@@ -5,6 +5,7 @@ go 1.24.0
 require (
 	github.com/digitalocean/go-libvirt v0.0.0-20260127224054-f7013236e99a
 	github.com/mark3labs/mcp-go v0.44.0
+	golang.org/x/sys v0.40.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
@@ -18,5 +19,4 @@ require (
 	github.com/wk8/go-ordered-map/v2 v2.1.8 // indirect
 	github.com/yosida95/uritemplate/v3 v3.0.2 // indirect
 	golang.org/x/crypto v0.47.0 // indirect
-	golang.org/x/sys v0.40.0 // indirect
 )
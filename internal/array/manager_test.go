@@ -633,7 +633,7 @@ func Test_Tool_ArrayStart_Cases(t *testing.T) {
 			args := map[string]any{}
 			if tt.withToken {
 				// Get a real token by requesting confirmation first.
-				token := confirm.RequestConfirmation("array_start", "array", "Start the array")
+				token := confirm.RequestConfirmation("array_start", "array", "Start the array", nil)
 				args["confirmation_token"] = token
 			}
 
@@ -761,7 +761,7 @@ func Test_Tool_ArrayStop_Cases(t *testing.T) {
 
 			args := map[string]any{}
 			if tt.withToken {
-				token := confirm.RequestConfirmation("array_stop", "array", "Stop the array")
+				token := confirm.RequestConfirmation("array_stop", "array", "Stop the array", nil)
 				args["confirmation_token"] = token
 			}
 
@@ -930,7 +930,7 @@ func Test_Tool_ParityCheck_Cases(t *testing.T) {
 
 			args := map[string]any{"action": tt.action}
 			if tt.withToken {
-				token := confirm.RequestConfirmation("parity_check", "array", "Parity check")
+				token := confirm.RequestConfirmation("parity_check", "array", "Parity check", map[string]any{"action": tt.action})
 				args["confirmation_token"] = token
 			}
 
@@ -1008,7 +1008,7 @@ func Test_Tool_ParityCheck_ValidActions(t *testing.T) {
 			regs := ArrayTools(mgr, confirm, audit)
 			reg := findRegistration(t, regs, "parity_check")
 
-			token := confirm.RequestConfirmation("parity_check", "array", "Parity check")
+			token := confirm.RequestConfirmation("parity_check", "array", "Parity check", map[string]any{"action": action})
 			req := newCallToolRequest("parity_check", map[string]any{
 				"action":             action,
 				"confirmation_token": token,
@@ -1106,8 +1106,12 @@ func Test_AllHandlers_ReturnNilError(t *testing.T) {
 			if args == nil {
 				args = map[string]any{}
 			}
-			// Add a valid confirmation token.
-			token := confirm.RequestConfirmation(reg.Tool.Name, "test", "test")
+			// Add a valid confirmation token bound to the tool's arguments.
+			bound := make(map[string]any, len(args))
+			for k, v := range args {
+				bound[k] = v
+			}
+			token := confirm.RequestConfirmation(reg.Tool.Name, "array", "test", bound)
 			args["confirmation_token"] = token
 
 			req := newCallToolRequest(reg.Tool.Name, args)
@@ -1138,7 +1142,7 @@ func Test_ConfirmationToken_SingleUse(t *testing.T) {
 	reg := findRegistration(t, regs, "array_start")
 
 	// Get a token.
-	token := confirm.RequestConfirmation("array_start", "array", "Start the array")
+	token := confirm.RequestConfirmation("array_start", "array", "Start the array", nil)
 
 	// First use should succeed.
 	req1 := newCallToolRequest("array_start", map[string]any{"confirmation_token": token})
@@ -1223,7 +1227,7 @@ func Benchmark_ArrayStart_WithToken(b *testing.B) {
 	ctx := context.Background()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		token := confirm.RequestConfirmation("array_start", "array", "Start")
+		token := confirm.RequestConfirmation("array_start", "array", "Start", nil)
 		req := newCallToolRequest("array_start", map[string]any{"confirmation_token": token})
 		_, _ = reg.Handler(ctx, req)
 	}
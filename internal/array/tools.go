@@ -47,8 +47,8 @@ func arrayStart(mgr ArrayManager, confirm *safety.ConfirmationTracker, audit *sa
 		token := req.GetString("confirmation_token", "")
 		params := map[string]any{}
 
-		if !confirm.Confirm(token) {
-			return tools.ConfirmPrompt(confirm, toolName, "array", "Start the Unraid array"), nil
+		if !confirm.Confirm(token, toolName, "array", params) {
+			return tools.ConfirmPrompt(confirm, toolName, "array", "Start the Unraid array", params), nil
 		}
 
 		if err := mgr.Start(ctx); err != nil {
@@ -79,8 +79,8 @@ func arrayStop(mgr ArrayManager, confirm *safety.ConfirmationTracker, audit *saf
 		token := req.GetString("confirmation_token", "")
 		params := map[string]any{}
 
-		if !confirm.Confirm(token) {
-			return tools.ConfirmPrompt(confirm, toolName, "array", "Stop the Unraid array"), nil
+		if !confirm.Confirm(token, toolName, "array", params) {
+			return tools.ConfirmPrompt(confirm, toolName, "array", "Stop the Unraid array", params), nil
 		}
 
 		if err := mgr.Stop(ctx); err != nil {
@@ -125,8 +125,8 @@ func parityCheck(mgr ArrayManager, confirm *safety.ConfirmationTracker, audit *s
 			return tools.ErrorResult(errMsg), nil
 		}
 
-		if !confirm.Confirm(token) {
-			return tools.ConfirmPrompt(confirm, toolName, "array", fmt.Sprintf("Parity check: %s", action)), nil
+		if !confirm.Confirm(token, toolName, "array", params) {
+			return tools.ConfirmPrompt(confirm, toolName, "array", fmt.Sprintf("Parity check: %s", action), params), nil
 		}
 
 		msg, err := mgr.ParityCheck(ctx, action)
@@ -16,19 +16,51 @@ type ResourceFilter struct {
 	Denylist  []string `yaml:"denylist"`
 }
 
+// DockerCreatePolicy restricts the host-level settings that new containers
+// may request. The zero value denies privileged mode, host networking, added
+// capabilities, devices and bind mounts of host paths, and imposes no
+// resource caps.
+type DockerCreatePolicy struct {
+	AllowPrivileged     bool     `yaml:"allow_privileged"`
+	AllowHostNetwork    bool     `yaml:"allow_host_network"`
+	AllowedCapabilities []string `yaml:"allowed_capabilities"` // "*" allows any
+	AllowedDevices      []string `yaml:"allowed_devices"`      // glob patterns, e.g. /dev/dri/*
+	AllowedBindPaths    []string `yaml:"allowed_bind_paths"`   // host directories bind mounts may use, e.g. /mnt/user
+	MaxMemoryMB         int      `yaml:"max_memory_mb"`        // 0 = no cap
+	MaxCPUs             float64  `yaml:"max_cpus"`             // 0 = no cap
+}
+
+// DockerExecRule permits command patterns inside matching containers.
+type DockerExecRule struct {
+	Containers []string `yaml:"containers"` // container name glob patterns
+	Commands   []string `yaml:"commands"`   // one word per argument; "*" matches within one, e.g. "cat /config/*.log"
+}
+
+// DockerExecPolicy gates docker_exec. With no rules, exec is disabled.
+type DockerExecPolicy struct {
+	TimeoutSeconds int              `yaml:"timeout_seconds"` // 0 = 30
+	MaxOutputKB    int              `yaml:"max_output_kb"`   // 0 = 64
+	Rules          []DockerExecRule `yaml:"rules"`
+}
+
 // SafetyConfig groups resource filters for Docker containers and VMs.
 type SafetyConfig struct {
-	Docker ResourceFilter `yaml:"docker"`
-	VMs    ResourceFilter `yaml:"vms"`
+	Docker       ResourceFilter     `yaml:"docker"`
+	DockerCreate DockerCreatePolicy `yaml:"docker_create"`
+	DockerExec   DockerExecPolicy   `yaml:"docker_exec"`
+	VMs          ResourceFilter     `yaml:"vms"`
 }
 
 // PathsConfig holds filesystem paths used by the server.
 type PathsConfig struct {
-	Emhttp        string `yaml:"emhttp"`
-	Proc          string `yaml:"proc"`
-	Sys           string `yaml:"sys"`
-	DockerSocket  string `yaml:"docker_socket"`
-	LibvirtSocket string `yaml:"libvirt_socket"`
+	Emhttp          string `yaml:"emhttp"`
+	Proc            string `yaml:"proc"`
+	Sys             string `yaml:"sys"`
+	DockerSocket    string `yaml:"docker_socket"`
+	LibvirtSocket   string `yaml:"libvirt_socket"`
+	DockerTemplates string `yaml:"docker_templates"`
+	DockerAutostart string `yaml:"docker_autostart"`
+	ComposeProjects string `yaml:"compose_projects"`
 }
 
 // AuditConfig controls audit logging behaviour.
@@ -52,13 +84,58 @@ type GraphQLConfig struct {
 	Timeout int `yaml:"timeout"`
 }
 
+// DockerConfig selects the Docker daemon endpoint.
+type DockerConfig struct {
+	// Host is the daemon endpoint: unix:///path/to/docker.sock,
+	// tcp://host:2375 or https://host:2376, such as a docker socket proxy.
+	// Empty uses the socket at paths.docker_socket.
+	Host       string `yaml:"host"`
+	TLSCACert  string `yaml:"tls_ca_cert"` // PEM CA bundle that signed the daemon's certificate
+	TLSCert    string `yaml:"tls_cert"`    // PEM client certificate, for --tlsverify daemons
+	TLSKey     string `yaml:"tls_key"`
+	APIVersion string `yaml:"api_version"` // e.g. "1.43"; empty negotiates with the daemon
+}
+
+// DockerStatsConfig controls the background container stats sampler.
+type DockerStatsConfig struct {
+	IntervalSeconds  int `yaml:"interval_seconds"`  // 0 = 30
+	RetentionMinutes int `yaml:"retention_minutes"` // 0 = 60
+}
+
+// VMStatsConfig controls the background VM stats sampler.
+type VMStatsConfig struct {
+	IntervalSeconds  int `yaml:"interval_seconds"`  // 0 = 30
+	RetentionMinutes int `yaml:"retention_minutes"` // 0 = 60
+}
+
+// RegistryAuthConfig is a credential for one image registry.
+type RegistryAuthConfig struct {
+	Registry string `yaml:"registry"` // e.g. ghcr.io or registry.lan:5000; docker.io for Docker Hub
+	Username string `yaml:"username"`
+	Password string `yaml:"password"` // password or access token
+}
+
+// DockerRegistryConfig holds the credentials used to pull images from
+// private registries.
+type DockerRegistryConfig struct {
+	// ConfigFile is a docker config.json written by docker login. Its
+	// credentials are used for registries not listed in Auths; a missing
+	// file is ignored.
+	ConfigFile string               `yaml:"config_file"`
+	Auths      []RegistryAuthConfig `yaml:"auths"`
+}
+
 // Config is the top-level configuration structure for the unraid-mcp server.
 type Config struct {
-	Server  ServerConfig  `yaml:"server"`
-	Safety  SafetyConfig  `yaml:"safety"`
-	Paths   PathsConfig   `yaml:"paths"`
-	Audit   AuditConfig   `yaml:"audit"`
-	GraphQL GraphQLConfig `yaml:"graphql"`
+	Server         ServerConfig         `yaml:"server"`
+	Safety         SafetyConfig         `yaml:"safety"`
+	Paths          PathsConfig          `yaml:"paths"`
+	Audit          AuditConfig          `yaml:"audit"`
+	GraphQL        GraphQLConfig        `yaml:"graphql"`
+	Docker         DockerConfig         `yaml:"docker"`
+	DockerStats    DockerStatsConfig    `yaml:"docker_stats"`
+	DockerRegistry DockerRegistryConfig `yaml:"docker_registry"`
+	VMStats        VMStatsConfig        `yaml:"vm_stats"`
 }
 
 // LoadConfig reads and parses a YAML configuration file from the given path.
@@ -86,11 +163,14 @@ func DefaultConfig() *Config {
 			Port: 8080,
 		},
 		Paths: PathsConfig{
-			Emhttp:        "/host/emhttp",
-			Proc:          "/host/proc",
-			Sys:           "/host/sys",
-			DockerSocket:  "/var/run/docker.sock",
-			LibvirtSocket: "/var/run/libvirt/libvirt-sock",
+			Emhttp:          "/host/emhttp",
+			Proc:            "/host/proc",
+			Sys:             "/host/sys",
+			DockerSocket:    "/var/run/docker.sock",
+			LibvirtSocket:   "/var/run/libvirt/libvirt-sock",
+			DockerTemplates: "/host/templates-user",
+			DockerAutostart: "/host/unraid-autostart",
+			ComposeProjects: "/host/compose-projects",
 		},
 		Audit: AuditConfig{
 			Enabled: true,
@@ -100,6 +180,17 @@ func DefaultConfig() *Config {
 			URL:     "http://localhost/graphql",
 			Timeout: 30,
 		},
+		DockerStats: DockerStatsConfig{
+			IntervalSeconds:  30,
+			RetentionMinutes: 60,
+		},
+		DockerRegistry: DockerRegistryConfig{
+			ConfigFile: "/host/docker-config/config.json",
+		},
+		VMStats: VMStatsConfig{
+			IntervalSeconds:  30,
+			RetentionMinutes: 60,
+		},
 	}
 }
 
@@ -108,6 +199,7 @@ func DefaultConfig() *Config {
 //   - UNRAID_MCP_AUTH_TOKEN overrides cfg.Server.AuthToken
 //   - UNRAID_GRAPHQL_URL overrides cfg.GraphQL.URL
 //   - UNRAID_GRAPHQL_API_KEY overrides cfg.GraphQL.APIKey
+//   - DOCKER_HOST overrides cfg.Docker.Host
 func ApplyEnvOverrides(cfg *Config) {
 	if token := os.Getenv("UNRAID_MCP_AUTH_TOKEN"); token != "" {
 		cfg.Server.AuthToken = token
@@ -118,6 +210,9 @@ func ApplyEnvOverrides(cfg *Config) {
 	if key := os.Getenv("UNRAID_GRAPHQL_API_KEY"); key != "" {
 		cfg.GraphQL.APIKey = key
 	}
+	if host := os.Getenv("DOCKER_HOST"); host != "" {
+		cfg.Docker.Host = host
+	}
 }
 
 // EnsureAuthToken generates a random auth token and sets it on cfg if
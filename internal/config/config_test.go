@@ -71,6 +71,33 @@ func Test_LoadConfig_Cases(t *testing.T) {
 				if len(cfg.Safety.Docker.Denylist) != len(wantDockerDeny) {
 					t.Errorf("Safety.Docker.Denylist = %v, want %v", cfg.Safety.Docker.Denylist, wantDockerDeny)
 				}
+				// Safety - Docker create policy
+				dc := cfg.Safety.DockerCreate
+				if dc.AllowPrivileged || !dc.AllowHostNetwork {
+					t.Errorf("DockerCreate privileged/host = %v/%v, want false/true", dc.AllowPrivileged, dc.AllowHostNetwork)
+				}
+				if len(dc.AllowedCapabilities) != 1 || dc.AllowedCapabilities[0] != "NET_ADMIN" {
+					t.Errorf("DockerCreate.AllowedCapabilities = %v, want [NET_ADMIN]", dc.AllowedCapabilities)
+				}
+				if len(dc.AllowedDevices) != 1 || dc.AllowedDevices[0] != "/dev/dri/*" {
+					t.Errorf("DockerCreate.AllowedDevices = %v, want [/dev/dri/*]", dc.AllowedDevices)
+				}
+				if len(dc.AllowedBindPaths) != 1 || dc.AllowedBindPaths[0] != "/mnt/user" {
+					t.Errorf("DockerCreate.AllowedBindPaths = %v, want [/mnt/user]", dc.AllowedBindPaths)
+				}
+				if dc.MaxMemoryMB != 4096 || dc.MaxCPUs != 2.5 {
+					t.Errorf("DockerCreate limits = %dMB/%v CPUs, want 4096MB/2.5", dc.MaxMemoryMB, dc.MaxCPUs)
+				}
+				// Safety - Docker exec policy
+				de := cfg.Safety.DockerExec
+				if de.TimeoutSeconds != 10 || de.MaxOutputKB != 16 {
+					t.Errorf("DockerExec limits = %ds/%dKB, want 10s/16KB", de.TimeoutSeconds, de.MaxOutputKB)
+				}
+				if len(de.Rules) != 1 || len(de.Rules[0].Containers) != 1 || len(de.Rules[0].Commands) != 2 {
+					t.Errorf("DockerExec.Rules = %+v, want one rule with 1 container and 2 commands", de.Rules)
+				} else if de.Rules[0].Commands[1] != "cat /var/log/nginx/*" {
+					t.Errorf("DockerExec.Rules[0].Commands[1] = %q, want %q", de.Rules[0].Commands[1], "cat /var/log/nginx/*")
+				}
 				// Safety - VMs
 				if len(cfg.Safety.VMs.Allowlist) != 1 || cfg.Safety.VMs.Allowlist[0] != "windows-vm" {
 					t.Errorf("Safety.VMs.Allowlist = %v, want [windows-vm]", cfg.Safety.VMs.Allowlist)
@@ -114,6 +141,26 @@ func Test_LoadConfig_Cases(t *testing.T) {
 				if cfg.GraphQL.Timeout != 60 {
 					t.Errorf("GraphQL.Timeout = %d, want 60", cfg.GraphQL.Timeout)
 				}
+				// Docker endpoint
+				wantDocker := DockerConfig{Host: "https://docker.lan:2376", TLSCACert: "/certs/ca.pem", TLSCert: "/certs/cert.pem", TLSKey: "/certs/key.pem", APIVersion: "1.43"}
+				if cfg.Docker != wantDocker {
+					t.Errorf("Docker = %+v, want %+v", cfg.Docker, wantDocker)
+				}
+				// Docker stats
+				if cfg.DockerStats.IntervalSeconds != 15 || cfg.DockerStats.RetentionMinutes != 120 {
+					t.Errorf("DockerStats = %+v, want 15s interval and 120m retention", cfg.DockerStats)
+				}
+				// VM stats
+				if cfg.VMStats.IntervalSeconds != 60 || cfg.VMStats.RetentionMinutes != 180 {
+					t.Errorf("VMStats = %+v, want 60s interval and 180m retention", cfg.VMStats)
+				}
+				// Docker registry
+				if cfg.DockerRegistry.ConfigFile != "/custom/docker/config.json" {
+					t.Errorf("DockerRegistry.ConfigFile = %q, want %q", cfg.DockerRegistry.ConfigFile, "/custom/docker/config.json")
+				}
+				if len(cfg.DockerRegistry.Auths) != 1 || cfg.DockerRegistry.Auths[0] != (RegistryAuthConfig{Registry: "ghcr.io", Username: "octocat", Password: "ghp_test"}) {
+					t.Errorf("DockerRegistry.Auths = %+v, want one ghcr.io credential", cfg.DockerRegistry.Auths)
+				}
 			},
 		},
 		{
@@ -276,6 +323,33 @@ func Test_DefaultConfig_Values(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "docker templates path",
+			validate: func(t *testing.T, cfg *Config) {
+				t.Helper()
+				if cfg.Paths.DockerTemplates != "/host/templates-user" {
+					t.Errorf("Paths.DockerTemplates = %q, want %q", cfg.Paths.DockerTemplates, "/host/templates-user")
+				}
+			},
+		},
+		{
+			name: "docker autostart path",
+			validate: func(t *testing.T, cfg *Config) {
+				t.Helper()
+				if cfg.Paths.DockerAutostart != "/host/unraid-autostart" {
+					t.Errorf("Paths.DockerAutostart = %q, want %q", cfg.Paths.DockerAutostart, "/host/unraid-autostart")
+				}
+			},
+		},
+		{
+			name: "compose projects path",
+			validate: func(t *testing.T, cfg *Config) {
+				t.Helper()
+				if cfg.Paths.ComposeProjects != "/host/compose-projects" {
+					t.Errorf("Paths.ComposeProjects = %q, want %q", cfg.Paths.ComposeProjects, "/host/compose-projects")
+				}
+			},
+		},
 		{
 			name: "graphql url default",
 			validate: func(t *testing.T, cfg *Config) {
@@ -285,6 +359,42 @@ func Test_DefaultConfig_Values(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "docker stats defaults",
+			validate: func(t *testing.T, cfg *Config) {
+				t.Helper()
+				if cfg.DockerStats.IntervalSeconds != 30 || cfg.DockerStats.RetentionMinutes != 60 {
+					t.Errorf("DockerStats = %+v, want 30s interval and 60m retention", cfg.DockerStats)
+				}
+			},
+		},
+		{
+			name: "vm stats defaults",
+			validate: func(t *testing.T, cfg *Config) {
+				t.Helper()
+				if cfg.VMStats.IntervalSeconds != 30 || cfg.VMStats.RetentionMinutes != 60 {
+					t.Errorf("VMStats = %+v, want 30s interval and 60m retention", cfg.VMStats)
+				}
+			},
+		},
+		{
+			name: "docker host default is empty (use the socket path)",
+			validate: func(t *testing.T, cfg *Config) {
+				t.Helper()
+				if cfg.Docker.Host != "" || cfg.Docker.APIVersion != "" {
+					t.Errorf("Docker = %+v, want no host or pinned API version", cfg.Docker)
+				}
+			},
+		},
+		{
+			name: "docker registry config file default",
+			validate: func(t *testing.T, cfg *Config) {
+				t.Helper()
+				if cfg.DockerRegistry.ConfigFile != "/host/docker-config/config.json" {
+					t.Errorf("DockerRegistry.ConfigFile = %q, want %q", cfg.DockerRegistry.ConfigFile, "/host/docker-config/config.json")
+				}
+			},
+		},
 		{
 			name: "graphql timeout default is 30",
 			validate: func(t *testing.T, cfg *Config) {
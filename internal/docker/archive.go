@@ -0,0 +1,277 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ---------------------------------------------------------------------------
+// Container file access via the archive API
+// ---------------------------------------------------------------------------
+
+// Limits applied to container file access.
+const (
+	// MaxFileReadBytes is the largest file ReadContainerFile will return.
+	MaxFileReadBytes = 1024 * 1024
+	// MaxFileWriteBytes is the largest file WriteContainerFile will write.
+	MaxFileWriteBytes = 1024 * 1024
+	// maxListEntries caps the number of entries returned by ListContainerFiles.
+	maxListEntries = 1000
+	// maxListScanBytes caps how much of a directory archive ListContainerFiles
+	// reads. The archive API always returns the whole tree, file contents
+	// included, so a listing of a large directory is cut short.
+	maxListScanBytes = 64 * 1024 * 1024
+)
+
+// CleanContainerPath validates an absolute path inside a container and returns
+// it in canonical form. Relative paths and ".." components are rejected.
+func CleanContainerPath(p string) (string, error) {
+	if !strings.HasPrefix(p, "/") {
+		return "", fmt.Errorf("path %q must be absolute", p)
+	}
+	for _, part := range strings.Split(p, "/") {
+		if part == ".." {
+			return "", fmt.Errorf("path %q must not contain \"..\"", p)
+		}
+	}
+	if strings.ContainsRune(p, 0) {
+		return "", fmt.Errorf("path %q contains a NUL byte", p)
+	}
+	return path.Clean(p), nil
+}
+
+// IsText reports whether data looks like text: valid UTF-8 without NUL bytes.
+func IsText(data []byte) bool {
+	return utf8.Valid(data) && bytes.IndexByte(data, 0) < 0
+}
+
+// getArchive starts a GET /containers/{id}/archive request for p and returns
+// the open response. The caller must close the body.
+func (m *DockerClientManager) getArchive(ctx context.Context, id, p string) (*http.Response, error) {
+	resp, err := m.doStreamRequest(ctx, http.MethodGet, "/containers/"+id+"/archive?path="+url.QueryEscape(p), nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := readBody(resp)
+		// The daemon uses 404 for both a missing container and a missing path.
+		return nil, checkAPIError(resp.StatusCode, body, fmt.Sprintf("not found: %s in container %s", p, id))
+	}
+	return resp, nil
+}
+
+// fileInfoFromHeader converts a tar header into a ContainerFileInfo.
+func fileInfoFromHeader(h *tar.Header) ContainerFileInfo {
+	info := ContainerFileInfo{
+		Name:    path.Base(strings.TrimSuffix(h.Name, "/")),
+		Size:    h.Size,
+		Mode:    h.FileInfo().Mode().String(),
+		ModTime: h.ModTime,
+		IsDir:   h.Typeflag == tar.TypeDir,
+	}
+	if h.Typeflag == tar.TypeSymlink {
+		info.LinkTarget = h.Linkname
+	}
+	return info
+}
+
+// ReadContainerFile returns the contents of a regular file inside a
+// container. Files larger than maxBytes (or MaxFileReadBytes, whichever is
+// smaller) are rejected rather than truncated.
+func (m *DockerClientManager) ReadContainerFile(ctx context.Context, id, p string, maxBytes int64) (*ContainerFile, error) {
+	p, err := CleanContainerPath(p)
+	if err != nil {
+		return nil, err
+	}
+	if maxBytes <= 0 || maxBytes > MaxFileReadBytes {
+		maxBytes = MaxFileReadBytes
+	}
+
+	resp, err := m.getArchive(ctx, id, p)
+	if err != nil {
+		return nil, fmt.Errorf("docker: read file: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	tr := tar.NewReader(resp.Body)
+	h, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("docker: read file: read archive: %w", err)
+	}
+	info := fileInfoFromHeader(h)
+	switch h.Typeflag {
+	case tar.TypeReg:
+	case tar.TypeDir:
+		return nil, fmt.Errorf("%s is a directory", p)
+	case tar.TypeSymlink:
+		return nil, fmt.Errorf("%s is a symbolic link to %s", p, h.Linkname)
+	default:
+		return nil, fmt.Errorf("%s is not a regular file", p)
+	}
+	if h.Size > maxBytes {
+		return nil, fmt.Errorf("%s is %d bytes, larger than the %d byte limit", p, h.Size, maxBytes)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(tr, maxBytes))
+	if err != nil {
+		return nil, fmt.Errorf("docker: read file: read archive: %w", err)
+	}
+	return &ContainerFile{ContainerFileInfo: info, Path: p, Content: string(data)}, nil
+}
+
+// ListContainerFiles lists the direct children of a directory inside a
+// container. If dir names a file, the listing contains just that file.
+func (m *DockerClientManager) ListContainerFiles(ctx context.Context, id, dir string) (*ContainerDirListing, error) {
+	dir, err := CleanContainerPath(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := m.getArchive(ctx, id, dir)
+	if err != nil {
+		return nil, fmt.Errorf("docker: list files: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	listing := &ContainerDirListing{Path: dir, Entries: []ContainerFileInfo{}}
+	limited := &io.LimitedReader{R: resp.Body, N: maxListScanBytes}
+	tr := tar.NewReader(limited)
+
+	// The first entry is the requested path itself; children are named
+	// "<root>/<child>".
+	root, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("docker: list files: read archive: %w", err)
+	}
+	if root.Typeflag != tar.TypeDir {
+		listing.Entries = append(listing.Entries, fileInfoFromHeader(root))
+		return listing, nil
+	}
+	prefix := strings.TrimSuffix(root.Name, "/") + "/"
+
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if limited.N <= 0 {
+				listing.Truncated = true
+				break
+			}
+			return nil, fmt.Errorf("docker: list files: read archive: %w", err)
+		}
+		rel := strings.TrimSuffix(strings.TrimPrefix(h.Name, prefix), "/")
+		if rel == "" || strings.Contains(rel, "/") {
+			continue
+		}
+		if len(listing.Entries) >= maxListEntries {
+			listing.Truncated = true
+			break
+		}
+		listing.Entries = append(listing.Entries, fileInfoFromHeader(h))
+	}
+	return listing, nil
+}
+
+// WriteContainerFile creates or replaces a regular file inside a container.
+// When the file already exists, its mode and ownership are preserved;
+// otherwise it is created with mode 0644 owned by root. The parent directory
+// must already exist.
+func (m *DockerClientManager) WriteContainerFile(ctx context.Context, id, p string, content []byte) error {
+	p, err := CleanContainerPath(p)
+	if err != nil {
+		return err
+	}
+	if p == "/" {
+		return fmt.Errorf("path must name a file")
+	}
+	if len(content) > MaxFileWriteBytes {
+		return fmt.Errorf("content is %d bytes, larger than the %d byte limit", len(content), MaxFileWriteBytes)
+	}
+
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     path.Base(p),
+		Mode:     0o644,
+		Size:     int64(len(content)),
+		ModTime:  time.Now(),
+	}
+	existing, err := m.fileHeader(ctx, id, p)
+	if err != nil {
+		return fmt.Errorf("docker: write file: %w", err)
+	}
+	if existing != nil {
+		if existing.Typeflag != tar.TypeReg {
+			return fmt.Errorf("%s exists and is not a regular file", p)
+		}
+		header.Mode = existing.Mode
+		header.Uid = existing.Uid
+		header.Gid = existing.Gid
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("docker: write file: build archive: %w", err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		return fmt.Errorf("docker: write file: build archive: %w", err)
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("docker: write file: build archive: %w", err)
+	}
+
+	q := url.Values{}
+	q.Set("path", path.Dir(p))
+	q.Set("noOverwriteDirNonDir", "true")
+	resp, err := m.doRequest(ctx, http.MethodPut, "/containers/"+id+"/archive?"+q.Encode(), &buf)
+	if err != nil {
+		return fmt.Errorf("docker: write file: %w", err)
+	}
+	body, err := readBody(resp)
+	if err != nil {
+		return fmt.Errorf("docker: write file: %w", err)
+	}
+	if err := checkAPIError(resp.StatusCode, body, fmt.Sprintf("directory not found: %s in container %s", path.Dir(p), id)); err != nil {
+		return fmt.Errorf("docker: write file: %w", err)
+	}
+	return nil
+}
+
+// fileHeader returns the tar header of an existing path inside a container,
+// or nil if the path does not exist.
+func (m *DockerClientManager) fileHeader(ctx context.Context, id, p string) (*tar.Header, error) {
+	resp, err := m.doStreamRequest(ctx, http.MethodGet, "/containers/"+id+"/archive?path="+url.QueryEscape(p), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode == http.StatusNotFound {
+		// Distinguish a missing path from a missing container.
+		if _, err := m.inspectContainerRaw(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, checkAPIError(resp.StatusCode, body, "")
+	}
+	h, err := tar.NewReader(resp.Body).Next()
+	if err != nil {
+		return nil, fmt.Errorf("read archive: %w", err)
+	}
+	return h, nil
+}
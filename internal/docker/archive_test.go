@@ -0,0 +1,278 @@
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+)
+
+// tarEntry describes one entry of a test archive.
+type tarEntry struct {
+	name    string
+	content string
+	dir     bool
+	mode    int64
+	uid     int
+}
+
+// buildTar encodes entries as a tar stream, as returned by the archive API.
+func buildTar(t *testing.T, entries ...tarEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		h := &tar.Header{Name: e.name, Mode: e.mode, Uid: e.uid, ModTime: time.Unix(1700000000, 0)}
+		if h.Mode == 0 {
+			h.Mode = 0o644
+		}
+		if e.dir {
+			h.Typeflag = tar.TypeDir
+			h.Mode = 0o755
+		} else {
+			h.Typeflag = tar.TypeReg
+			h.Size = int64(len(e.content))
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatalf("write tar header: %v", err)
+		}
+		if _, err := tw.Write([]byte(e.content)); err != nil {
+			t.Fatalf("write tar content: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("close tar: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// newArchiveDaemon returns a fake daemon serving fixed archives by path.
+func newArchiveDaemon(t *testing.T, archives map[string][]byte) *DockerClientManager {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /containers/app/archive", func(w http.ResponseWriter, r *http.Request) {
+		data, ok := archives[r.URL.Query().Get("path")]
+		if !ok {
+			writeJSON(w, http.StatusNotFound, `{"message":"Could not find the file in container app"}`)
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-tar")
+		_, _ = w.Write(data)
+	})
+	return newFakeDaemon(t, mux)
+}
+
+func Test_ReadContainerFile(t *testing.T) {
+	mgr := newArchiveDaemon(t, map[string][]byte{
+		"/config/app.conf": buildTar(t, tarEntry{name: "app.conf", content: "port = 8080\n"}),
+		"/config":          buildTar(t, tarEntry{name: "config/", dir: true}),
+		"/config/big.log":  buildTar(t, tarEntry{name: "big.log", content: strings.Repeat("x", 100)}),
+	})
+
+	file, err := mgr.ReadContainerFile(context.Background(), "app", "/config//app.conf", 0)
+	if err != nil {
+		t.Fatalf("ReadContainerFile() error = %v", err)
+	}
+	if file.Path != "/config/app.conf" || file.Content != "port = 8080\n" || file.Mode != "-rw-r--r--" {
+		t.Errorf("file = %+v", file)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		maxBytes int64
+		wantErr  string
+	}{
+		{name: "directory", path: "/config", wantErr: "is a directory"},
+		{name: "too large", path: "/config/big.log", maxBytes: 10, wantErr: "larger than the 10 byte limit"},
+		{name: "missing", path: "/config/nope", wantErr: "not found: /config/nope"},
+		{name: "traversal", path: "/config/../etc/shadow", wantErr: `must not contain ".."`},
+		{name: "relative", path: "config/app.conf", wantErr: "must be absolute"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := mgr.ReadContainerFile(context.Background(), "app", tt.path, tt.maxBytes)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ReadContainerFile(%q) error = %v, want containing %q", tt.path, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_ListContainerFiles_DirectChildrenOnly(t *testing.T) {
+	mgr := newArchiveDaemon(t, map[string][]byte{
+		"/config": buildTar(t,
+			tarEntry{name: "config/", dir: true},
+			tarEntry{name: "config/app.conf", content: "a"},
+			tarEntry{name: "config/logs/", dir: true},
+			tarEntry{name: "config/logs/today.log", content: "nested"},
+		),
+		"/config/app.conf": buildTar(t, tarEntry{name: "app.conf", content: "a"}),
+	})
+
+	listing, err := mgr.ListContainerFiles(context.Background(), "app", "/config/")
+	if err != nil {
+		t.Fatalf("ListContainerFiles() error = %v", err)
+	}
+	if listing.Path != "/config" || listing.Truncated || len(listing.Entries) != 2 {
+		t.Fatalf("listing = %+v, want 2 entries for /config", listing)
+	}
+	if listing.Entries[0].Name != "app.conf" || listing.Entries[0].Size != 1 {
+		t.Errorf("Entries[0] = %+v, want app.conf", listing.Entries[0])
+	}
+	if listing.Entries[1].Name != "logs" || !listing.Entries[1].IsDir {
+		t.Errorf("Entries[1] = %+v, want logs directory", listing.Entries[1])
+	}
+
+	listing, err = mgr.ListContainerFiles(context.Background(), "app", "/config/app.conf")
+	if err != nil {
+		t.Fatalf("ListContainerFiles(file) error = %v", err)
+	}
+	if len(listing.Entries) != 1 || listing.Entries[0].Name != "app.conf" {
+		t.Errorf("listing = %+v, want the file itself", listing)
+	}
+}
+
+func Test_WriteContainerFile_PreservesModeAndOwner(t *testing.T) {
+	var gotPath string
+	var gotHeader *tar.Header
+	var gotContent string
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /containers/app/archive", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(buildTar(t, tarEntry{name: "app.conf", content: "old", mode: 0o600, uid: 99}))
+	})
+	mux.HandleFunc("PUT /containers/app/archive", func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Query().Get("path")
+		tr := tar.NewReader(r.Body)
+		h, err := tr.Next()
+		if err != nil {
+			t.Errorf("read uploaded tar: %v", err)
+			return
+		}
+		data, _ := io.ReadAll(tr)
+		gotHeader, gotContent = h, string(data)
+		w.WriteHeader(http.StatusOK)
+	})
+	mgr := newFakeDaemon(t, mux)
+
+	if err := mgr.WriteContainerFile(context.Background(), "app", "/config/app.conf", []byte("new\n")); err != nil {
+		t.Fatalf("WriteContainerFile() error = %v", err)
+	}
+	if gotPath != "/config" {
+		t.Errorf("PUT path = %q, want /config", gotPath)
+	}
+	if gotHeader == nil || gotHeader.Name != "app.conf" || gotHeader.Mode != 0o600 || gotHeader.Uid != 99 {
+		t.Errorf("uploaded header = %+v, want app.conf mode 0600 uid 99", gotHeader)
+	}
+	if gotContent != "new\n" {
+		t.Errorf("uploaded content = %q", gotContent)
+	}
+}
+
+func Test_WriteContainerFile_Rejects(t *testing.T) {
+	mgr := newArchiveDaemon(t, map[string][]byte{
+		"/config": buildTar(t, tarEntry{name: "config/", dir: true}),
+	})
+
+	tests := []struct {
+		name    string
+		path    string
+		content []byte
+		wantErr string
+	}{
+		{name: "directory", path: "/config", content: []byte("x"), wantErr: "not a regular file"},
+		{name: "root", path: "/", content: []byte("x"), wantErr: "must name a file"},
+		{name: "traversal", path: "/config/../../etc/passwd", content: []byte("x"), wantErr: `must not contain ".."`},
+		{name: "too large", path: "/config/big", content: make([]byte, MaxFileWriteBytes+1), wantErr: "larger than"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := mgr.WriteContainerFile(context.Background(), "app", tt.path, tt.content)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("WriteContainerFile(%q) error = %v, want containing %q", tt.path, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_IsText(t *testing.T) {
+	tests := []struct {
+		data string
+		want bool
+	}{
+		{"hello\n", true},
+		{"héllo", true},
+		{"", true},
+		{"a\x00b", false},
+		{"\xff\xfe", false},
+	}
+	for _, tt := range tests {
+		if got := IsText([]byte(tt.data)); got != tt.want {
+			t.Errorf("IsText(%q) = %v, want %v", tt.data, got, tt.want)
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// File tools
+// ---------------------------------------------------------------------------
+
+func Test_DockerTools_FileReadRejectsBinary(t *testing.T) {
+	mgr, regs := newToolsUnderTest(t)
+	mgr.AddFile("abc123", "/config/app.conf", "key=value\n")
+	mgr.AddFile("abc123", "/config/app.db", "SQLite\x00\x01")
+
+	text := callTool(t, regs, "docker_file_read", map[string]any{"id": "abc123", "path": "/config/app.conf"})
+	if !strings.Contains(text, `"Content": "key=value\n"`) {
+		t.Errorf("docker_file_read = %s, want content", text)
+	}
+	text = callTool(t, regs, "docker_file_read", map[string]any{"id": "abc123", "path": "/config/app.db"})
+	if !strings.Contains(text, "binary") {
+		t.Errorf("docker_file_read(binary) = %s, want binary error", text)
+	}
+}
+
+func Test_DockerTools_FileWriteTokenBoundToContent(t *testing.T) {
+	mgr, regs := newToolsUnderTest(t)
+	args := map[string]any{"id": "abc123", "path": "/config/app.conf", "content": "safe\n"}
+
+	text := callTool(t, regs, "docker_file_write", args)
+	token := extractToken(t, text)
+
+	// A token issued for one content must not authorize different content.
+	text = callTool(t, regs, "docker_file_write", map[string]any{
+		"id": "abc123", "path": "/config/app.conf", "content": "evil\n", "confirmation_token": token,
+	})
+	if !strings.Contains(text, "confirmation_token") {
+		t.Fatalf("write with mismatched content = %s, want a new confirmation prompt", text)
+	}
+
+	args["confirmation_token"] = extractToken(t, callTool(t, regs, "docker_file_write", args))
+	text = callTool(t, regs, "docker_file_write", args)
+	if !strings.Contains(text, "wrote 5 bytes") {
+		t.Fatalf("docker_file_write = %s, want success", text)
+	}
+	file, err := mgr.ReadContainerFile(context.Background(), "abc123", "/config/app.conf", MaxFileReadBytes)
+	if err != nil || file.Content != "safe\n" {
+		t.Errorf("file after write = %+v, %v", file, err)
+	}
+}
+
+func Test_DockerTools_FileToolsHonourFilter(t *testing.T) {
+	mgr := newPopulatedMock(t)
+	confirm := safety.NewConfirmationTracker(DestructiveTools)
+	regs := DockerTools(mgr, safety.NewFilter(nil, []string{"abc*"}), nil, nil, confirm, nil)
+
+	for _, name := range []string{"docker_file_read", "docker_file_list", "docker_file_write"} {
+		text := callTool(t, regs, name, map[string]any{"id": "abc123", "path": "/config", "content": "x"})
+		if !strings.Contains(text, "not allowed") {
+			t.Errorf("%s = %s, want not allowed", name, text)
+		}
+	}
+}
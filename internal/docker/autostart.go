@@ -0,0 +1,174 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Unraid autostart list
+// ---------------------------------------------------------------------------
+
+// autostartMu serializes read-modify-write cycles of the autostart file.
+var autostartMu sync.Mutex
+
+// AutostartChange describes a change to one container's autostart entry.
+type AutostartChange struct {
+	Enabled  bool
+	Wait     *time.Duration // nil keeps the current wait
+	Position int            // 1-based place in the start order; 0 keeps the current one, or appends
+}
+
+// AutostartStatus reports a container's autostart setting.
+type AutostartStatus struct {
+	Name        string
+	Enabled     bool
+	Position    int  // 1-based place in the start order; 0 when disabled
+	WaitSeconds int  // wait after starting it before starting the next container
+	Missing     bool // listed in the autostart file, but no such container exists
+}
+
+// ApplyAutostartChange returns entries with change applied to the named
+// container. entries is not modified.
+func ApplyAutostartChange(entries []AutostartEntry, name string, change AutostartChange) []AutostartEntry {
+	out := make([]AutostartEntry, 0, len(entries)+1)
+	entry, pos := AutostartEntry{Name: name}, -1
+	for i, e := range entries {
+		if e.Name == name {
+			entry, pos = e, i
+			continue
+		}
+		out = append(out, e)
+	}
+	if !change.Enabled {
+		return out
+	}
+
+	if change.Wait != nil {
+		entry.Wait = max(*change.Wait, 0)
+	}
+	switch {
+	case change.Position > 0:
+		pos = min(change.Position-1, len(out))
+	case pos < 0:
+		pos = len(out)
+	}
+	return slices.Insert(out, pos, entry)
+}
+
+// WriteAutostartFile writes entries to Unraid's autostart file in the format
+// ReadAutostartFile reads. The file is replaced atomically through a
+// temporary file in the same directory, and created with mode 0644 if it is
+// missing. A file bind-mounted on its own cannot be replaced, so if the
+// rename fails an existing file is rewritten in place instead.
+func WriteAutostartFile(path string, entries []AutostartEntry) error {
+	var b strings.Builder
+	for _, e := range entries {
+		b.WriteString(e.Name)
+		if secs := int(e.Wait / time.Second); secs > 0 {
+			b.WriteString(" " + strconv.Itoa(secs))
+		}
+		b.WriteString("\n")
+	}
+	data := []byte(b.String())
+
+	mode := fs.FileMode(0o644)
+	info, statErr := os.Stat(path)
+	if statErr == nil {
+		mode = info.Mode().Perm()
+	}
+	err := replaceFile(path, data, mode)
+	if err != nil && statErr == nil {
+		err = os.WriteFile(path, data, mode)
+	}
+	if err != nil {
+		return fmt.Errorf("write autostart file: %w", err)
+	}
+	return nil
+}
+
+// replaceFile atomically replaces path with a file holding data.
+func replaceFile(path string, data []byte, mode fs.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(f.Name()) }()
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Chmod(mode); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// UpdateAutostartFile applies change to the named container's entry in
+// Unraid's autostart file and returns the new entries.
+func UpdateAutostartFile(path, name string, change AutostartChange) ([]AutostartEntry, error) {
+	autostartMu.Lock()
+	defer autostartMu.Unlock()
+
+	entries, err := ReadAutostartFile(path)
+	if err != nil {
+		return nil, err
+	}
+	entries = ApplyAutostartChange(entries, name, change)
+	if err := WriteAutostartFile(path, entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// readAutostartEntries reads the autostart file without racing a concurrent
+// UpdateAutostartFile.
+func readAutostartEntries(path string) ([]AutostartEntry, error) {
+	autostartMu.Lock()
+	defer autostartMu.Unlock()
+	return ReadAutostartFile(path)
+}
+
+// AutostartStatuses reports the autostart setting of every named container:
+// enabled ones in start order, then disabled ones by name. Entries for
+// containers not in names are reported as missing.
+func AutostartStatuses(entries []AutostartEntry, names []string) []AutostartStatus {
+	exists := make(map[string]bool, len(names))
+	for _, n := range names {
+		exists[n] = true
+	}
+
+	out := make([]AutostartStatus, 0, len(names))
+	listed := make(map[string]bool, len(entries))
+	for i, e := range entries {
+		listed[e.Name] = true
+		out = append(out, AutostartStatus{
+			Name:        e.Name,
+			Enabled:     true,
+			Position:    i + 1,
+			WaitSeconds: int(e.Wait / time.Second),
+			Missing:     !exists[e.Name],
+		})
+	}
+	var disabled []AutostartStatus
+	for _, n := range names {
+		if !listed[n] {
+			disabled = append(disabled, AutostartStatus{Name: n})
+		}
+	}
+	sort.Slice(disabled, func(i, j int) bool { return disabled[i].Name < disabled[j].Name })
+	return append(out, disabled...)
+}
@@ -0,0 +1,172 @@
+package docker
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+)
+
+// ---------------------------------------------------------------------------
+// Editing the autostart list
+// ---------------------------------------------------------------------------
+
+func Test_ApplyAutostartChange(t *testing.T) {
+	entries := []AutostartEntry{{Name: "proxy"}, {Name: "db", Wait: 30 * time.Second}, {Name: "app"}}
+	wait := func(d time.Duration) *time.Duration { return &d }
+
+	tests := []struct {
+		name   string
+		target string
+		change AutostartChange
+		want   string
+	}{
+		{"enable appends", "plex", AutostartChange{Enabled: true}, "proxy,db:30s,app,plex"},
+		{"enable at position", "plex", AutostartChange{Enabled: true, Position: 1}, "plex,proxy,db:30s,app"},
+		{"position past the end appends", "plex", AutostartChange{Enabled: true, Position: 9}, "proxy,db:30s,app,plex"},
+		{"move keeps wait", "db", AutostartChange{Enabled: true, Position: 3}, "proxy,app,db:30s"},
+		{"set wait in place", "app", AutostartChange{Enabled: true, Wait: wait(10 * time.Second)}, "proxy,db:30s,app:10s"},
+		{"clear wait", "db", AutostartChange{Enabled: true, Wait: wait(0)}, "proxy,db,app"},
+		{"disable removes", "db", AutostartChange{}, "proxy,app"},
+		{"disable unlisted is a no-op", "plex", AutostartChange{}, "proxy,db:30s,app"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ApplyAutostartChange(entries, tt.target, tt.change)
+			var parts []string
+			for _, e := range got {
+				p := e.Name
+				if e.Wait > 0 {
+					p += ":" + e.Wait.String()
+				}
+				parts = append(parts, p)
+			}
+			if s := strings.Join(parts, ","); s != tt.want {
+				t.Errorf("ApplyAutostartChange() = %q, want %q", s, tt.want)
+			}
+		})
+	}
+	if len(entries) != 3 || entries[1].Name != "db" {
+		t.Errorf("input entries modified: %+v", entries)
+	}
+}
+
+func Test_WriteAutostartFile_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "unraid-autostart")
+	if err := os.WriteFile(path, []byte("old contents that are longer than the new ones\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	entries := []AutostartEntry{{Name: "proxy"}, {Name: "db", Wait: 30 * time.Second}}
+	if err := WriteAutostartFile(path, entries); err != nil {
+		t.Fatalf("WriteAutostartFile() error = %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "proxy\ndb 30\n" {
+		t.Errorf("file = %q, want %q", data, "proxy\ndb 30\n")
+	}
+	got, err := ReadAutostartFile(path)
+	if err != nil || len(got) != 2 || got[1] != entries[1] {
+		t.Errorf("ReadAutostartFile() = %+v, %v", got, err)
+	}
+
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("directory holds %d files after the write, want only the autostart file", len(entries))
+	}
+}
+
+func Test_UpdateAutostartFile_CreatesMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "unraid-autostart")
+	wait := 10 * time.Second
+	got, err := UpdateAutostartFile(path, "plex", AutostartChange{Enabled: true, Wait: &wait})
+	if err != nil || len(got) != 1 || got[0] != (AutostartEntry{Name: "plex", Wait: wait}) {
+		t.Fatalf("UpdateAutostartFile(missing) = %+v, %v", got, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o644 {
+		t.Errorf("mode = %v, want 0644", info.Mode().Perm())
+	}
+	if data, _ := os.ReadFile(path); string(data) != "plex 10\n" {
+		t.Errorf("file = %q", data)
+	}
+}
+
+func Test_AutostartStatuses(t *testing.T) {
+	entries := []AutostartEntry{{Name: "sonarr", Wait: 5 * time.Second}, {Name: "gone"}}
+	got := AutostartStatuses(entries, []string{"radarr", "sonarr", "plex"})
+	want := []AutostartStatus{
+		{Name: "sonarr", Enabled: true, Position: 1, WaitSeconds: 5},
+		{Name: "gone", Enabled: true, Position: 2, Missing: true},
+		{Name: "plex"},
+		{Name: "radarr"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("AutostartStatuses() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("AutostartStatuses()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Tools
+// ---------------------------------------------------------------------------
+
+func Test_AutostartTools(t *testing.T) {
+	mgr := newPopulatedMock(t)
+	path := filepath.Join(t.TempDir(), "unraid-autostart")
+	if err := os.WriteFile(path, []byte("sonarr 10\nradarr\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	regs := AutostartTools(mgr, path, safety.NewFilter(nil, []string{"radarr"}), nil)
+
+	text := callTool(t, regs, "docker_autostart", map[string]any{"name": "plex", "enabled": true, "position": float64(1), "wait_seconds": float64(20)})
+	var status AutostartStatus
+	if err := json.Unmarshal([]byte(text), &status); err != nil {
+		t.Fatalf("decode result: %v\n%s", err, text)
+	}
+	if status != (AutostartStatus{Name: "plex", Enabled: true, Position: 1, WaitSeconds: 20}) {
+		t.Errorf("docker_autostart = %+v", status)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "plex 20\nsonarr 10\nradarr\n" {
+		t.Errorf("autostart file = %q", data)
+	}
+
+	text = callTool(t, regs, "docker_autostart_list", nil)
+	var list []AutostartStatus
+	if err := json.Unmarshal([]byte(text), &list); err != nil {
+		t.Fatalf("decode result: %v\n%s", err, text)
+	}
+	if len(list) != 2 || list[0].Name != "plex" || list[1].Name != "sonarr" || list[1].Position != 2 {
+		t.Errorf("docker_autostart_list = %+v, want plex and sonarr without the denied radarr", list)
+	}
+
+	tests := []struct {
+		name string
+		args map[string]any
+		want string
+	}{
+		{"denied", map[string]any{"name": "radarr", "enabled": false}, "not allowed"},
+		{"unknown container", map[string]any{"name": "abc123", "enabled": true}, "no container named"},
+		{"negative position", map[string]any{"name": "plex", "enabled": true, "position": float64(-1)}, "invalid position"},
+		{"disable", map[string]any{"name": "sonarr", "enabled": false}, `"Enabled": false`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if text := callTool(t, regs, "docker_autostart", tt.args); !strings.Contains(text, tt.want) {
+				t.Errorf("docker_autostart = %s, want %q", text, tt.want)
+			}
+		})
+	}
+	if data, _ := os.ReadFile(path); string(data) != "plex 20\nradarr\n" {
+		t.Errorf("autostart file after disabling sonarr = %q", data)
+	}
+}
@@ -0,0 +1,143 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+	"github.com/jamesprial/unraid-mcp/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// AutostartTools returns the tool registrations for Unraid's container
+// autostart list, kept in the file at autostartPath.
+func AutostartTools(mgr DockerManager, autostartPath string, filter *safety.Filter, audit *safety.AuditLogger) []tools.Registration {
+	return []tools.Registration{
+		toolDockerAutostartList(mgr, autostartPath, filter, audit),
+		toolDockerAutostart(mgr, autostartPath, filter, audit),
+	}
+}
+
+func toolDockerAutostartList(mgr DockerManager, autostartPath string, filter *safety.Filter, audit *safety.AuditLogger) tools.Registration {
+	tool := mcp.NewTool("docker_autostart_list",
+		mcp.WithDescription("List which containers Unraid starts with the array, in start order with the wait after each, "+
+			"followed by the containers that do not autostart. Entries for containers that no longer exist are flagged as missing."),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		params := map[string]any{}
+
+		containers, err := mgr.ListContainers(ctx, true)
+		if err != nil {
+			tools.LogAudit(audit, "docker_autostart_list", params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+		entries, err := readAutostartEntries(autostartPath)
+		if err != nil {
+			tools.LogAudit(audit, "docker_autostart_list", params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+
+		names := make([]string, 0, len(containers))
+		for _, c := range containers {
+			names = append(names, c.Name)
+		}
+		// Denied containers are left out entirely, as in docker_list.
+		var statuses []AutostartStatus
+		for _, s := range AutostartStatuses(entries, names) {
+			if filter.IsAllowed(s.Name) {
+				statuses = append(statuses, s)
+			}
+		}
+
+		tools.LogAudit(audit, "docker_autostart_list", params, "ok", start)
+		return tools.JSONResult(statuses), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
+
+func toolDockerAutostart(mgr DockerManager, autostartPath string, filter *safety.Filter, audit *safety.AuditLogger) tools.Registration {
+	tool := mcp.NewTool("docker_autostart",
+		mcp.WithDescription("Enable or disable starting a container with the array in Unraid's autostart list, "+
+			"and set its place in the start order and the wait before the next container starts. "+
+			"The same list is edited by the Docker tab's autostart toggles."),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("Container name"),
+		),
+		mcp.WithBoolean("enabled",
+			mcp.Required(),
+			mcp.Description("true to start the container with the array, false to remove it from the list"),
+		),
+		mcp.WithNumber("position",
+			mcp.Description("1-based place in the start order (default: keep the current place, or append)"),
+		),
+		mcp.WithNumber("wait_seconds",
+			mcp.Description("Seconds to wait after starting it before starting the next container (default: keep the current wait)"),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		name := req.GetString("name", "")
+		change := AutostartChange{
+			Enabled:  req.GetBool("enabled", false),
+			Position: req.GetInt("position", 0),
+		}
+		params := map[string]any{"name": name, "enabled": change.Enabled}
+		if change.Position != 0 {
+			params["position"] = change.Position
+		}
+		if secs := req.GetInt("wait_seconds", -1); secs >= 0 {
+			wait := time.Duration(secs) * time.Second
+			change.Wait = &wait
+			params["wait_seconds"] = secs
+		}
+
+		if !filter.IsAllowed(name) {
+			tools.LogAudit(audit, "docker_autostart", params, "denied", start)
+			return tools.ErrorResult(fmt.Sprintf("access to container %q is not allowed", name)), nil
+		}
+		if change.Position < 0 {
+			return tools.ErrorResult(fmt.Sprintf("invalid position %d: want 1 or more", change.Position)), nil
+		}
+		// Only existing containers can be enabled; a stale entry can still
+		// be removed.
+		if change.Enabled {
+			containers, err := mgr.ListContainers(ctx, true)
+			if err != nil {
+				tools.LogAudit(audit, "docker_autostart", params, "error: "+err.Error(), start)
+				return tools.ErrorResult(err.Error()), nil
+			}
+			if !slices.ContainsFunc(containers, func(c Container) bool { return c.Name == name }) {
+				tools.LogAudit(audit, "docker_autostart", params, "error: not found", start)
+				return tools.ErrorResult(fmt.Sprintf("no container named %q (the autostart list needs the container name, not its ID)", name)), nil
+			}
+		}
+
+		entries, err := UpdateAutostartFile(autostartPath, name, change)
+		if err != nil {
+			tools.LogAudit(audit, "docker_autostart", params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+
+		status := AutostartStatus{Name: name}
+		for _, s := range AutostartStatuses(entries, []string{name}) {
+			if s.Name == name {
+				status = s
+				break
+			}
+		}
+
+		tools.LogAudit(audit, "docker_autostart", params, "ok", start)
+		return tools.JSONResult(status), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
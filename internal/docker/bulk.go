@@ -0,0 +1,444 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+)
+
+// ---------------------------------------------------------------------------
+// Bulk container operations
+// ---------------------------------------------------------------------------
+
+// Orders supported by bulk operations.
+const (
+	BulkOrderName         = "name"         // alphabetical
+	BulkOrderAutostart    = "autostart"    // Unraid's autostart order, honouring wait times on start
+	BulkOrderDependencies = "dependencies" // the depends_on label, dependencies first on start
+)
+
+// DependsOnLabel names the containers a container depends on, as a
+// comma-separated list of container names. The depends_on entries Docker
+// Compose records are honoured as well.
+const DependsOnLabel = "depends_on"
+
+// Bulk actions.
+const (
+	BulkStart   = "start"
+	BulkStop    = "stop"
+	BulkRestart = "restart"
+)
+
+// ContainerSelector selects containers for a bulk operation. Every criterion
+// that is set must match.
+type ContainerSelector struct {
+	Names   []string // glob patterns matched against the container name; any may match
+	Labels  []string // "key" or "key=value"; all must match
+	Image   string   // glob pattern matched against the image reference
+	Network string   // network name or ID the container is connected to
+	State   string   // container state, e.g. running or exited
+}
+
+// IsEmpty reports whether no criterion is set.
+func (s ContainerSelector) IsEmpty() bool {
+	return len(s.Names) == 0 && len(s.Labels) == 0 && s.Image == "" && s.Network == "" && s.State == ""
+}
+
+// BulkTarget is a container selected for a bulk operation.
+type BulkTarget struct {
+	ID        string
+	Name      string
+	State     string
+	DependsOn []string      // from the depends_on label
+	Wait      time.Duration // autostart wait after starting, for BulkOrderAutostart
+}
+
+// BulkItemResult is the outcome of a bulk operation on one container.
+type BulkItemResult struct {
+	Name    string
+	ID      string
+	Status  string // "ok", "error" or "skipped"
+	Message string
+}
+
+// BulkResult reports the per-container outcome of a bulk operation, in the
+// order the containers were processed.
+type BulkResult struct {
+	Action    string
+	Order     string
+	Succeeded int
+	Failed    int
+	Skipped   int
+	Results   []BulkItemResult
+}
+
+// SelectContainers returns the containers matching sel that filter allows,
+// sorted by name. Containers the filter denies are left out silently, as in
+// docker_list.
+func SelectContainers(ctx context.Context, mgr DockerManager, filter *safety.Filter, sel ContainerSelector) ([]BulkTarget, error) {
+	if sel.IsEmpty() {
+		return nil, fmt.Errorf("at least one selector (names, labels, image, network or state) is required")
+	}
+	labels := make(map[string]*string, len(sel.Labels))
+	for _, l := range sel.Labels {
+		key, value, hasValue := strings.Cut(l, "=")
+		if key == "" {
+			return nil, fmt.Errorf("invalid label selector %q (want key or key=value)", l)
+		}
+		if hasValue {
+			labels[key] = &value
+		} else {
+			labels[key] = nil
+		}
+	}
+
+	var onNetwork map[string]bool
+	if sel.Network != "" {
+		n, err := mgr.InspectNetwork(ctx, sel.Network)
+		if err != nil {
+			return nil, err
+		}
+		onNetwork = make(map[string]bool, len(n.Containers))
+		for _, id := range n.Containers {
+			onNetwork[id] = true
+		}
+	}
+
+	containers, err := mgr.ListContainers(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make([]BulkTarget, 0)
+	var targetLabels []map[string]string
+	for _, c := range containers {
+		if !filter.IsAllowed(c.Name) {
+			continue
+		}
+		if len(sel.Names) > 0 && !matchAnyGlob(sel.Names, c.Name) {
+			continue
+		}
+		if sel.Image != "" && !matchGlob(sel.Image, c.Image) {
+			continue
+		}
+		if sel.State != "" && c.State != sel.State {
+			continue
+		}
+		if onNetwork != nil && !onNetwork[c.ID] {
+			continue
+		}
+
+		detail, err := mgr.InspectContainer(ctx, c.ID)
+		if err != nil {
+			// Removed since it was listed.
+			continue
+		}
+		if !matchLabels(labels, detail.Config.Labels) {
+			continue
+		}
+		targets = append(targets, BulkTarget{
+			ID:        c.ID,
+			Name:      c.Name,
+			State:     c.State,
+			DependsOn: splitList(detail.Config.Labels[DependsOnLabel]),
+		})
+		targetLabels = append(targetLabels, detail.Config.Labels)
+	}
+	resolveComposeDependencies(targets, targetLabels)
+	sort.Slice(targets, func(i, j int) bool { return targets[i].Name < targets[j].Name })
+	return targets, nil
+}
+
+func matchGlob(pattern, s string) bool {
+	ok, err := filepath.Match(pattern, s)
+	return err == nil && ok
+}
+
+func matchAnyGlob(patterns []string, s string) bool {
+	for _, p := range patterns {
+		if matchGlob(p, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchLabels(want map[string]*string, have map[string]string) bool {
+	for key, value := range want {
+		got, ok := have[key]
+		if !ok || (value != nil && got != *value) {
+			return false
+		}
+	}
+	return true
+}
+
+// splitList splits a comma-separated list, dropping empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// ---------------------------------------------------------------------------
+// Ordering
+// ---------------------------------------------------------------------------
+
+// AutostartEntry is one line of Unraid's autostart file: a container that
+// starts with the array, and how long to wait before starting the next one.
+type AutostartEntry struct {
+	Name string
+	Wait time.Duration
+}
+
+// ReadAutostartFile parses Unraid's autostart file
+// (/var/lib/docker/unraid-autostart), which lists one container per line in
+// start order, optionally followed by a wait time in seconds. A missing file
+// is an empty list: Unraid only creates it once a container has autostart on.
+func ReadAutostartFile(path string) ([]AutostartEntry, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read autostart file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var entries []AutostartEntry
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		e := AutostartEntry{Name: fields[0]}
+		if len(fields) > 1 {
+			if secs, err := strconv.Atoi(fields[1]); err == nil && secs > 0 {
+				e.Wait = time.Duration(secs) * time.Second
+			}
+		}
+		entries = append(entries, e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read autostart file: %w", err)
+	}
+	return entries, nil
+}
+
+// OrderByAutostart sorts targets in autostart order and sets their wait
+// times. Containers not in the autostart list follow, by name.
+func OrderByAutostart(targets []BulkTarget, entries []AutostartEntry) []BulkTarget {
+	pos := make(map[string]int, len(entries))
+	wait := make(map[string]time.Duration, len(entries))
+	for i, e := range entries {
+		pos[e.Name] = i
+		wait[e.Name] = e.Wait
+	}
+	ordered := append([]BulkTarget(nil), targets...)
+	for i := range ordered {
+		ordered[i].Wait = wait[ordered[i].Name]
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		pi, iok := pos[ordered[i].Name]
+		pj, jok := pos[ordered[j].Name]
+		switch {
+		case iok && jok:
+			return pi < pj
+		case iok != jok:
+			return iok
+		}
+		return ordered[i].Name < ordered[j].Name
+	})
+	return ordered
+}
+
+// OrderByDependencies sorts targets so every container comes after the
+// containers it depends on. Dependencies outside the set are ignored; ties
+// are broken by name. A dependency cycle is an error.
+func OrderByDependencies(targets []BulkTarget) ([]BulkTarget, error) {
+	byName := make(map[string]BulkTarget, len(targets))
+	for _, t := range targets {
+		byName[t.Name] = t
+	}
+	pending := make(map[string]int, len(targets))
+	dependents := make(map[string][]string)
+	for _, t := range targets {
+		for _, dep := range t.DependsOn {
+			if _, ok := byName[dep]; ok && dep != t.Name {
+				pending[t.Name]++
+				dependents[dep] = append(dependents[dep], t.Name)
+			}
+		}
+	}
+
+	var ready []string
+	for _, t := range targets {
+		if pending[t.Name] == 0 {
+			ready = append(ready, t.Name)
+		}
+	}
+	ordered := make([]BulkTarget, 0, len(targets))
+	for len(ready) > 0 {
+		sort.Strings(ready)
+		name := ready[0]
+		ready = ready[1:]
+		ordered = append(ordered, byName[name])
+		for _, d := range dependents[name] {
+			pending[d]--
+			if pending[d] == 0 {
+				ready = append(ready, d)
+			}
+		}
+	}
+
+	if len(ordered) < len(targets) {
+		var cycle []string
+		for _, t := range targets {
+			if pending[t.Name] > 0 {
+				cycle = append(cycle, t.Name)
+			}
+		}
+		sort.Strings(cycle)
+		return nil, fmt.Errorf("dependency cycle among: %s", strings.Join(cycle, ", "))
+	}
+	return ordered, nil
+}
+
+// ---------------------------------------------------------------------------
+// Execution
+// ---------------------------------------------------------------------------
+
+// RunBulk applies action to targets, which must already be in start order.
+// Stops run in reverse order; a restart stops every target in reverse order
+// and then starts them in order, unless order is BulkOrderName, in which case
+// each container is restarted in turn. When a container fails, the containers
+// that depend on it (on start) or that it depends on (on stop) are skipped.
+// Autostart wait times are honoured after each start.
+func RunBulk(ctx context.Context, mgr DockerManager, action, order string, targets []BulkTarget, timeout int) *BulkResult {
+	r := &bulkRun{
+		ctx:        ctx,
+		mgr:        mgr,
+		timeout:    timeout,
+		result:     &BulkResult{Action: action, Order: order, Results: []BulkItemResult{}},
+		failed:     map[string]bool{},
+		dependents: map[string][]string{},
+	}
+	for _, t := range targets {
+		for _, dep := range t.DependsOn {
+			r.dependents[dep] = append(r.dependents[dep], t.Name)
+		}
+	}
+
+	switch {
+	case action == BulkStart:
+		r.startAll(targets)
+	case action == BulkStop:
+		r.stopAll(targets)
+	case action == BulkRestart && order == BulkOrderName:
+		for _, t := range targets {
+			r.record(t, "restarted", mgr.RestartContainer(ctx, t.ID, timeout))
+		}
+	case action == BulkRestart:
+		r.stopAll(targets)
+		// Start only what was stopped. A container that failed to stop is
+		// still running, so its dependents can start.
+		var stopped []BulkTarget
+		for _, t := range targets {
+			if !r.failed[t.Name] {
+				stopped = append(stopped, t)
+			}
+		}
+		r.failed = map[string]bool{}
+		r.startAll(stopped)
+	}
+	return r.result
+}
+
+// bulkRun holds the state of one RunBulk call.
+type bulkRun struct {
+	ctx        context.Context
+	mgr        DockerManager
+	timeout    int
+	result     *BulkResult
+	failed     map[string]bool     // containers that failed or were skipped
+	dependents map[string][]string // container -> containers depending on it
+}
+
+func (r *bulkRun) startAll(targets []BulkTarget) {
+	for i, t := range targets {
+		if r.ctx.Err() != nil {
+			r.skip(t, "cancelled")
+			continue
+		}
+		if dep := r.firstFailed(t.DependsOn); dep != "" {
+			r.skip(t, fmt.Sprintf("dependency %q did not start", dep))
+			continue
+		}
+		err := r.mgr.StartContainer(r.ctx, t.ID)
+		r.record(t, "started", err)
+		if err == nil && t.Wait > 0 && i < len(targets)-1 {
+			select {
+			case <-r.ctx.Done():
+			case <-time.After(t.Wait):
+			}
+		}
+	}
+}
+
+func (r *bulkRun) stopAll(targets []BulkTarget) {
+	for i := len(targets) - 1; i >= 0; i-- {
+		t := targets[i]
+		if r.ctx.Err() != nil {
+			r.skip(t, "cancelled")
+			continue
+		}
+		if dep := r.firstFailed(r.dependents[t.Name]); dep != "" {
+			r.skip(t, fmt.Sprintf("dependent %q did not stop", dep))
+			continue
+		}
+		r.record(t, "stopped", r.mgr.StopContainer(r.ctx, t.ID, r.timeout))
+	}
+}
+
+func (r *bulkRun) firstFailed(names []string) string {
+	for _, n := range names {
+		if r.failed[n] {
+			return n
+		}
+	}
+	return ""
+}
+
+func (r *bulkRun) record(t BulkTarget, done string, err error) {
+	item := BulkItemResult{Name: t.Name, ID: t.ID, Status: "ok", Message: done}
+	if err != nil {
+		item.Status, item.Message = "error", err.Error()
+		r.failed[t.Name] = true
+		r.result.Failed++
+	} else {
+		r.result.Succeeded++
+	}
+	r.result.Results = append(r.result.Results, item)
+}
+
+func (r *bulkRun) skip(t BulkTarget, reason string) {
+	r.failed[t.Name] = true
+	r.result.Skipped++
+	r.result.Results = append(r.result.Results, BulkItemResult{Name: t.Name, ID: t.ID, Status: "skipped", Message: reason})
+}
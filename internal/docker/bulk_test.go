@@ -0,0 +1,266 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+)
+
+// recordingManager records start and stop calls and fails those listed in
+// fail.
+type recordingManager struct {
+	*MockDockerManager
+	mu    sync.Mutex
+	calls []string
+	fail  map[string]bool
+}
+
+func (r *recordingManager) do(op, id string, fn func() error) error {
+	r.mu.Lock()
+	r.calls = append(r.calls, op+" "+id)
+	r.mu.Unlock()
+	if r.fail[op+" "+id] {
+		return fmt.Errorf("%s %s failed", op, id)
+	}
+	return fn()
+}
+
+func (r *recordingManager) StartContainer(ctx context.Context, id string) error {
+	return r.do("start", id, func() error { return r.MockDockerManager.StartContainer(ctx, id) })
+}
+
+func (r *recordingManager) StopContainer(ctx context.Context, id string, timeout int) error {
+	return r.do("stop", id, func() error { return r.MockDockerManager.StopContainer(ctx, id, timeout) })
+}
+
+// newStackMock returns a mock holding a small stack: db <- app <- proxy, plus
+// an unrelated container and one the filter denies.
+func newStackMock(t *testing.T) *recordingManager {
+	t.Helper()
+	m := NewMockDockerManager()
+	add := func(id, image, state string, labels map[string]string) {
+		m.AddContainer(&ContainerDetail{
+			Container: Container{ID: id, Name: id, Image: image, State: state},
+			Config:    ContainerConfig{Labels: labels},
+		})
+	}
+	add("db", "postgres:16", "running", map[string]string{"stack": "web"})
+	add("app", "ghcr.io/acme/app:1", "running", map[string]string{"stack": "web", DependsOnLabel: "db"})
+	add("proxy", "nginx:latest", "running", map[string]string{"stack": "web", DependsOnLabel: "app, db"})
+	add("plex", "plexinc/pms-docker", "running", nil)
+	add("secret", "postgres:16", "running", map[string]string{"stack": "web"})
+	return &recordingManager{MockDockerManager: m, fail: map[string]bool{}}
+}
+
+func targetNames(targets []BulkTarget) string {
+	names := make([]string, len(targets))
+	for i, t := range targets {
+		names[i] = t.Name
+	}
+	return strings.Join(names, ",")
+}
+
+// ---------------------------------------------------------------------------
+// Selection and ordering
+// ---------------------------------------------------------------------------
+
+func Test_SelectContainers(t *testing.T) {
+	mgr := newStackMock(t)
+	filter := safety.NewFilter(nil, []string{"secret"})
+
+	tests := []struct {
+		name    string
+		sel     ContainerSelector
+		want    string
+		wantErr string
+	}{
+		{name: "label", sel: ContainerSelector{Labels: []string{"stack=web"}}, want: "app,db,proxy"},
+		{name: "label key", sel: ContainerSelector{Labels: []string{DependsOnLabel}}, want: "app,proxy"},
+		{name: "name globs", sel: ContainerSelector{Names: []string{"p*"}}, want: "plex,proxy"},
+		{name: "image glob", sel: ContainerSelector{Image: "postgres:*"}, want: "db"},
+		{name: "combined", sel: ContainerSelector{Names: []string{"p*"}, Labels: []string{"stack"}}, want: "proxy"},
+		{name: "state", sel: ContainerSelector{State: "exited"}, want: ""},
+		{name: "empty selector", sel: ContainerSelector{}, wantErr: "at least one selector"},
+		{name: "bad label", sel: ContainerSelector{Labels: []string{"=x"}}, wantErr: "invalid label selector"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SelectContainers(context.Background(), mgr, filter, tt.sel)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("SelectContainers() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SelectContainers() error = %v", err)
+			}
+			if names := targetNames(got); names != tt.want {
+				t.Errorf("SelectContainers() = %q, want %q", names, tt.want)
+			}
+		})
+	}
+}
+
+func Test_OrderByDependencies(t *testing.T) {
+	targets := []BulkTarget{
+		{Name: "proxy", DependsOn: []string{"app", "db"}},
+		{Name: "app", DependsOn: []string{"db", "redis"}}, // redis is outside the set
+		{Name: "db"},
+		{Name: "cache"},
+	}
+	got, err := OrderByDependencies(targets)
+	if err != nil {
+		t.Fatalf("OrderByDependencies() error = %v", err)
+	}
+	if names := targetNames(got); names != "cache,db,app,proxy" {
+		t.Errorf("order = %q, want cache,db,app,proxy", names)
+	}
+
+	_, err = OrderByDependencies([]BulkTarget{
+		{Name: "a", DependsOn: []string{"b"}},
+		{Name: "b", DependsOn: []string{"a"}},
+		{Name: "c"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "dependency cycle among: a, b") {
+		t.Errorf("cycle error = %v", err)
+	}
+}
+
+func Test_ReadAutostartFile_AndOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "unraid-autostart")
+	if err := os.WriteFile(path, []byte("proxy\ndb 30\n\napp bogus\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := ReadAutostartFile(path)
+	if err != nil {
+		t.Fatalf("ReadAutostartFile() error = %v", err)
+	}
+	if len(entries) != 3 || entries[1] != (AutostartEntry{Name: "db", Wait: 30 * time.Second}) || entries[2].Wait != 0 {
+		t.Errorf("entries = %+v", entries)
+	}
+
+	got := OrderByAutostart([]BulkTarget{{Name: "app"}, {Name: "zeta"}, {Name: "db"}, {Name: "alpha"}, {Name: "proxy"}}, entries)
+	if names := targetNames(got); names != "proxy,db,app,alpha,zeta" {
+		t.Errorf("order = %q", names)
+	}
+	if got[1].Wait != 30*time.Second {
+		t.Errorf("db wait = %v, want 30s", got[1].Wait)
+	}
+
+	// Unraid only creates the file once a container has autostart on.
+	if got, err := ReadAutostartFile(filepath.Join(t.TempDir(), "missing")); err != nil || got != nil {
+		t.Errorf("ReadAutostartFile(missing) = %+v, %v; want an empty list", got, err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// RunBulk
+// ---------------------------------------------------------------------------
+
+func Test_RunBulk_SkipsDependentsOfFailures(t *testing.T) {
+	mgr := newStackMock(t)
+	mgr.fail["start app"] = true
+	targets := []BulkTarget{{ID: "db", Name: "db"}, {ID: "app", Name: "app", DependsOn: []string{"db"}}, {ID: "proxy", Name: "proxy", DependsOn: []string{"app"}}}
+
+	result := RunBulk(context.Background(), mgr, BulkStart, BulkOrderDependencies, targets, 10)
+	if result.Succeeded != 1 || result.Failed != 1 || result.Skipped != 1 {
+		t.Fatalf("result = %+v", result)
+	}
+	if r := result.Results[2]; r.Name != "proxy" || r.Status != "skipped" || !strings.Contains(r.Message, `"app"`) {
+		t.Errorf("proxy result = %+v", r)
+	}
+	if strings.Join(mgr.calls, ";") != "start db;start app" {
+		t.Errorf("calls = %v", mgr.calls)
+	}
+}
+
+func Test_RunBulk_RestartStopsInReverseThenStarts(t *testing.T) {
+	mgr := newStackMock(t)
+	mgr.fail["stop db"] = true
+	targets := []BulkTarget{{ID: "db", Name: "db"}, {ID: "app", Name: "app", DependsOn: []string{"db"}}}
+
+	result := RunBulk(context.Background(), mgr, BulkRestart, BulkOrderDependencies, targets, 10)
+	// db failed to stop, so it is still running and app can start again.
+	if got := strings.Join(mgr.calls, ";"); got != "stop app;stop db;start app" {
+		t.Errorf("calls = %q", got)
+	}
+	if result.Succeeded != 2 || result.Failed != 1 {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// docker_bulk tool
+// ---------------------------------------------------------------------------
+
+func Test_DockerBulkTool_TokenCoversResolvedSet(t *testing.T) {
+	mgr := newStackMock(t)
+	confirm := safety.NewConfirmationTracker(DestructiveTools)
+	regs := BulkTools(mgr, "", safety.NewFilter(nil, []string{"secret"}), confirm, nil)
+	args := map[string]any{"action": "stop", "labels": []any{"stack=web"}, "order": "dependencies"}
+
+	text := callTool(t, regs, "docker_bulk", args)
+	if !strings.Contains(text, "db, app, proxy") {
+		t.Fatalf("prompt = %s, want the resolved set in order", text)
+	}
+	token := extractToken(t, text)
+
+	// A new matching container invalidates the token.
+	mgr.AddContainer(&ContainerDetail{
+		Container: Container{ID: "worker", Name: "worker", State: "running"},
+		Config:    ContainerConfig{Labels: map[string]string{"stack": "web"}},
+	})
+	args["confirmation_token"] = token
+	text = callTool(t, regs, "docker_bulk", args)
+	if !strings.Contains(text, "confirmation_token") || len(mgr.calls) != 0 {
+		t.Fatalf("stale token = %s (calls %v), want a new prompt", text, mgr.calls)
+	}
+
+	args["confirmation_token"] = extractToken(t, text)
+	text = callTool(t, regs, "docker_bulk", args)
+	var result BulkResult
+	if err := json.Unmarshal([]byte(text), &result); err != nil {
+		t.Fatalf("decode result: %v\n%s", err, text)
+	}
+	if result.Succeeded != 4 {
+		t.Errorf("result = %+v", result)
+	}
+	if got := strings.Join(mgr.calls, ";"); got != "stop worker;stop proxy;stop app;stop db" {
+		t.Errorf("calls = %q", got)
+	}
+}
+
+func Test_DockerBulkTool_Validation(t *testing.T) {
+	mgr := newStackMock(t)
+	confirm := safety.NewConfirmationTracker(DestructiveTools)
+	regs := BulkTools(mgr, filepath.Join(t.TempDir(), "missing"), safety.NewFilter(nil, nil), confirm, nil)
+
+	tests := []struct {
+		name string
+		args map[string]any
+		want string
+	}{
+		{name: "bad action", args: map[string]any{"action": "pause", "names": []any{"*"}}, want: "invalid action"},
+		{name: "no selector", args: map[string]any{"action": "stop"}, want: "at least one selector"},
+		{name: "no match", args: map[string]any{"action": "stop", "names": []any{"nothing*"}}, want: "no containers match"},
+		{name: "bad order", args: map[string]any{"action": "stop", "names": []any{"*"}, "order": "random"}, want: "invalid order"},
+		// No autostart file means no container has autostart on: name order.
+		{name: "missing autostart file", args: map[string]any{"action": "start", "names": []any{"*"}, "order": "autostart"}, want: "in autostart order: app, db, plex"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if text := callTool(t, regs, "docker_bulk", tt.args); !strings.Contains(text, tt.want) {
+				t.Errorf("docker_bulk = %s, want containing %q", text, tt.want)
+			}
+		})
+	}
+}
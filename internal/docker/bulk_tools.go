@@ -0,0 +1,155 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+	"github.com/jamesprial/unraid-mcp/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// BulkTools returns the tool registrations for bulk container operations.
+// autostartPath is Unraid's autostart file, used for the autostart order.
+func BulkTools(
+	mgr DockerManager,
+	autostartPath string,
+	filter *safety.Filter,
+	confirm *safety.ConfirmationTracker,
+	audit *safety.AuditLogger,
+) []tools.Registration {
+	return []tools.Registration{
+		toolDockerBulk(mgr, autostartPath, filter, confirm, audit),
+	}
+}
+
+func toolDockerBulk(mgr DockerManager, autostartPath string, filter *safety.Filter, confirm *safety.ConfirmationTracker, audit *safety.AuditLogger) tools.Registration {
+	const toolName = "docker_bulk"
+
+	tool := mcp.NewTool(toolName,
+		mcp.WithDescription("Start, stop or restart every container matching a selector, in name, Unraid autostart or dependency order. "+
+			"Containers are selected by name glob, label, image glob, network and state; all given criteria must match. "+
+			"Stops run in reverse order. The confirmation prompt lists the resolved containers, and its token is only valid for exactly that set. "+
+			"Results are reported per container."),
+		mcp.WithString("action",
+			mcp.Required(),
+			mcp.Description("start, stop or restart"),
+		),
+		mcp.WithArray("names",
+			mcp.Description("Container name glob patterns, e.g. [\"*arr\", \"plex\"]; a container matching any is selected"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithArray("labels",
+			mcp.Description("Labels the containers must have, as key or key=value"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithString("image",
+			mcp.Description("Image glob pattern, e.g. lscr.io/linuxserver/*"),
+		),
+		mcp.WithString("network",
+			mcp.Description("Network name or ID the containers are connected to"),
+		),
+		mcp.WithString("state",
+			mcp.Description("Container state, e.g. running or exited"),
+		),
+		mcp.WithString("order",
+			mcp.Description("name (default); autostart: Unraid's autostart order, waiting the configured time after each start; "+
+				"dependencies: the comma-separated container names in each container's depends_on label, and the services in Compose's depends_on, start first"),
+		),
+		mcp.WithNumber("timeout",
+			mcp.Description("Seconds to wait for each container to stop before killing it (default: 10)"),
+		),
+		mcp.WithString("confirmation_token",
+			mcp.Description("Confirmation token returned by a prior call to this tool"),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		action := req.GetString("action", "")
+		sel := ContainerSelector{
+			Names:   req.GetStringSlice("names", nil),
+			Labels:  req.GetStringSlice("labels", nil),
+			Image:   req.GetString("image", ""),
+			Network: req.GetString("network", ""),
+			State:   req.GetString("state", ""),
+		}
+		order := req.GetString("order", BulkOrderName)
+		timeout := req.GetInt("timeout", 10)
+		if timeout == 0 {
+			timeout = 10
+		}
+		token := req.GetString("confirmation_token", "")
+		params := map[string]any{
+			"action":  action,
+			"names":   sel.Names,
+			"labels":  sel.Labels,
+			"image":   sel.Image,
+			"network": sel.Network,
+			"state":   sel.State,
+			"order":   order,
+			"timeout": timeout,
+		}
+
+		switch action {
+		case BulkStart, BulkStop, BulkRestart:
+		default:
+			return tools.ErrorResult(fmt.Sprintf("invalid action %q (want start, stop or restart)", action)), nil
+		}
+
+		targets, err := SelectContainers(ctx, mgr, filter, sel)
+		if err != nil {
+			tools.LogAudit(audit, toolName, params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+		if len(targets) == 0 {
+			return tools.ErrorResult("no containers match the selector"), nil
+		}
+
+		switch order {
+		case BulkOrderName:
+		case BulkOrderAutostart:
+			entries, err := ReadAutostartFile(autostartPath)
+			if err != nil {
+				return tools.ErrorResult(err.Error()), nil
+			}
+			targets = OrderByAutostart(targets, entries)
+		case BulkOrderDependencies:
+			if targets, err = OrderByDependencies(targets); err != nil {
+				return tools.ErrorResult(err.Error()), nil
+			}
+		default:
+			return tools.ErrorResult(fmt.Sprintf("invalid order %q (want name, autostart or dependencies)", order)), nil
+		}
+
+		// Bind the token to the resolved set, in order, so a container that
+		// starts matching between the prompt and the confirmation is not
+		// acted on without being shown.
+		names := make([]string, len(targets))
+		for i, t := range targets {
+			names[i] = t.Name
+		}
+		params["targets"] = names
+		resource := action + ":" + strings.Join(names, ",")
+
+		if !confirm.Confirm(token, toolName, resource, params) {
+			desc := fmt.Sprintf("This will %s %d containers in %s order: %s.", action, len(targets), order, strings.Join(names, ", "))
+			return tools.ConfirmPrompt(confirm, toolName, resource, desc, params), nil
+		}
+
+		result := RunBulk(ctx, mgr, action, order, targets, timeout)
+
+		outcome := "ok"
+		if result.Failed > 0 || result.Skipped > 0 {
+			outcome = fmt.Sprintf("partial: %d ok, %d failed, %d skipped", result.Succeeded, result.Failed, result.Skipped)
+		}
+		tools.LogAudit(audit, toolName, params, outcome, start)
+		return tools.JSONResult(result), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
@@ -0,0 +1,274 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"cmp"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Daemon endpoints and API version negotiation
+// ---------------------------------------------------------------------------
+
+// API versions the client can speak. Requests use the daemon's version,
+// capped at maxAPIVersion; defaultAPIVersion, the version the client was
+// written against, is used when the daemon does not report one.
+const (
+	defaultAPIVersion = "1.41"
+	maxAPIVersion     = "1.45"
+)
+
+// Default ports of the Docker daemon's TCP endpoints.
+const (
+	dockerPort    = "2375"
+	dockerTLSPort = "2376"
+)
+
+// ClientConfig describes how to reach a Docker daemon.
+type ClientConfig struct {
+	// Host is the daemon endpoint: unix:///var/run/docker.sock (or a bare
+	// socket path), tcp://host:2375, http://host:2375 or https://host:2376.
+	// A tcp:// host uses TLS when any certificate is configured.
+	Host string
+
+	CACertFile string // PEM CA bundle to verify the daemon; empty uses the system roots
+	CertFile   string // PEM client certificate, for daemons started with --tlsverify
+	KeyFile    string // PEM key of CertFile
+
+	// APIVersion pins the API version, e.g. "1.43". Empty negotiates it with
+	// the daemon on first use.
+	APIVersion string
+}
+
+// NewDockerClient creates a DockerClientManager for the daemon described by
+// cfg. It does not contact the daemon.
+func NewDockerClient(cfg ClientConfig) (*DockerClientManager, error) {
+	if cfg.Host == "" {
+		return nil, fmt.Errorf("docker: host is required")
+	}
+	if cfg.APIVersion != "" {
+		v := strings.TrimPrefix(cfg.APIVersion, "v")
+		if _, _, ok := parseAPIVersion(v); !ok {
+			return nil, fmt.Errorf("docker: invalid API version %q (want e.g. 1.43)", cfg.APIVersion)
+		}
+		cfg.APIVersion = v
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	var baseURL string
+	if path, ok := strings.CutPrefix(cfg.Host, "unix://"); ok || strings.HasPrefix(cfg.Host, "/") {
+		if !ok {
+			path = cfg.Host
+		}
+		if path == "" {
+			return nil, fmt.Errorf("docker: socket path is required")
+		}
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		}
+		// The host in the URL is ignored when using a Unix socket transport;
+		// Docker requires a non-empty hostname so we use "localhost".
+		baseURL = "http://localhost"
+	} else {
+		u, err := url.Parse(cfg.Host)
+		if err != nil || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			return nil, fmt.Errorf("docker: invalid host %q (want unix://, tcp://, http:// or https://)", cfg.Host)
+		}
+		useTLS := cfg.CACertFile != "" || cfg.CertFile != "" || cfg.KeyFile != ""
+		switch u.Scheme {
+		case "tcp":
+		case "http":
+			if useTLS {
+				return nil, fmt.Errorf("docker: host %q is plain HTTP but TLS certificates are configured; use https://", cfg.Host)
+			}
+		case "https":
+			useTLS = true
+		default:
+			return nil, fmt.Errorf("docker: unsupported host scheme %q (want unix, tcp, http or https)", u.Scheme)
+		}
+		scheme, port := "http", dockerPort
+		if useTLS {
+			scheme, port = "https", dockerTLSPort
+			tlsConfig, err := clientTLSConfig(cfg)
+			if err != nil {
+				return nil, err
+			}
+			transport.TLSClientConfig = tlsConfig
+		}
+		host := u.Host
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), port)
+		}
+		baseURL = scheme + "://" + host
+	}
+
+	return &DockerClientManager{
+		client:       &http.Client{Transport: transport, Timeout: 30 * time.Second},
+		streamClient: &http.Client{Transport: transport},
+		host:         cfg.Host,
+		baseURL:      baseURL,
+		apiVersion:   cfg.APIVersion,
+	}, nil
+}
+
+// clientTLSConfig loads the certificates named in cfg.
+func clientTLSConfig(cfg ClientConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.CACertFile != "" {
+		pem, err := os.ReadFile(cfg.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("docker: read CA certificate: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("docker: no certificates found in %s", cfg.CACertFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if (cfg.CertFile == "") != (cfg.KeyFile == "") {
+		return nil, fmt.Errorf("docker: a client certificate needs both a certificate and a key file")
+	}
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("docker: load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// Host returns the daemon endpoint the client was configured with.
+func (m *DockerClientManager) Host() string {
+	return m.host
+}
+
+// negotiationRetryDelay is how long APIVersion waits after failing to reach
+// the daemon before it tries to negotiate again.
+const negotiationRetryDelay = 5 * time.Second
+
+// APIVersion returns the API version requests use, negotiating it with the
+// daemon first if that has not happened yet. The daemon is asked without
+// holding the lock; callers that arrive meanwhile, or within
+// negotiationRetryDelay of a failed attempt, use the default version.
+func (m *DockerClientManager) APIVersion(ctx context.Context) string {
+	m.versionMu.Lock()
+	if m.apiVersion != "" {
+		defer m.versionMu.Unlock()
+		return m.apiVersion
+	}
+	if m.negotiating || time.Now().Before(m.nextNegotiable) {
+		m.versionMu.Unlock()
+		return defaultAPIVersion
+	}
+	m.negotiating = true
+	m.versionMu.Unlock()
+
+	serverVersion, reached, err := m.serverAPIVersion(ctx)
+
+	m.versionMu.Lock()
+	defer m.versionMu.Unlock()
+	m.negotiating = false
+	switch {
+	case err == nil:
+		// Like the docker CLI, speak the daemon's version unless it is newer
+		// than the client knows; older daemons reject newer versions.
+		m.apiVersion = serverVersion
+		if compareAPIVersions(serverVersion, maxAPIVersion) > 0 {
+			m.apiVersion = maxAPIVersion
+		}
+	case reached:
+		// The daemon, or a proxy in front of it, answered without a
+		// version; there is no point in asking again.
+		m.apiVersion = defaultAPIVersion
+	default:
+		// Unreachable for now: use the default and negotiate again later.
+		m.nextNegotiable = time.Now().Add(negotiationRetryDelay)
+		return defaultAPIVersion
+	}
+	return m.apiVersion
+}
+
+// serverAPIVersion asks the daemon for the newest API version it supports,
+// from the API-Version header of /_ping or, failing that, from /version.
+// reached reports whether the daemon answered at all.
+func (m *DockerClientManager) serverAPIVersion(ctx context.Context) (version string, reached bool, err error) {
+	get := func(path string) (*http.Response, []byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.baseURL+path, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		resp, err := m.client.Do(req)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer func() { _ = resp.Body.Close() }()
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		return resp, body, err
+	}
+
+	resp, _, err := get("/_ping")
+	if err != nil {
+		return "", false, fmt.Errorf("docker: ping: %w", err)
+	}
+	if v := resp.Header.Get("API-Version"); v != "" {
+		if _, _, ok := parseAPIVersion(v); ok {
+			return v, true, nil
+		}
+	}
+
+	resp, body, err := get("/version")
+	if err != nil {
+		return "", true, fmt.Errorf("docker: version: %w", err)
+	}
+	var info struct {
+		APIVersion string `json:"ApiVersion"`
+	}
+	if resp.StatusCode != http.StatusOK || json.Unmarshal(body, &info) != nil {
+		return "", true, fmt.Errorf("docker: version: unexpected status %d", resp.StatusCode)
+	}
+	if _, _, ok := parseAPIVersion(info.APIVersion); !ok {
+		return "", true, fmt.Errorf("docker: version: invalid API version %q", info.APIVersion)
+	}
+	return info.APIVersion, true, nil
+}
+
+// apiURL returns the URL of an API path under the negotiated version.
+func (m *DockerClientManager) apiURL(ctx context.Context, path string) string {
+	return m.baseURL + "/v" + m.APIVersion(ctx) + path
+}
+
+// parseAPIVersion parses an API version such as "1.43".
+func parseAPIVersion(v string) (major, minor int, ok bool) {
+	a, b, found := strings.Cut(v, ".")
+	if !found {
+		return 0, 0, false
+	}
+	major, err1 := strconv.Atoi(a)
+	minor, err2 := strconv.Atoi(b)
+	return major, minor, err1 == nil && err2 == nil && major >= 0 && minor >= 0
+}
+
+// compareAPIVersions returns -1, 0 or 1 as API version a is older than, the
+// same as or newer than b. Both must be valid.
+func compareAPIVersions(a, b string) int {
+	amaj, amin, _ := parseAPIVersion(a)
+	bmaj, bmin, _ := parseAPIVersion(b)
+	if c := cmp.Compare(amaj, bmaj); c != 0 {
+		return c
+	}
+	return cmp.Compare(amin, bmin)
+}
@@ -0,0 +1,384 @@
+package docker
+
+import (
+	"context"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Fake Docker daemon for exercising DockerClientManager
+// ---------------------------------------------------------------------------
+
+// newFakeDaemon starts an HTTP server on a temporary Unix socket that serves
+// handler, and returns a DockerClientManager connected to it. Request paths
+// seen by handler have the API version prefix stripped.
+func newFakeDaemon(t *testing.T, handler http.Handler) *DockerClientManager {
+	t.Helper()
+	sock := filepath.Join(t.TempDir(), "docker.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatalf("listen on %s: %v", sock, err)
+	}
+
+	srv := httptest.NewUnstartedServer(http.StripPrefix("/v"+defaultAPIVersion, handler))
+	srv.Listener = l
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	mgr, err := NewDockerClient(ClientConfig{Host: "unix://" + sock, APIVersion: defaultAPIVersion})
+	if err != nil {
+		t.Fatalf("NewDockerClient: %v", err)
+	}
+	return mgr
+}
+
+// writeJSON writes body with a JSON content type and the given status.
+func writeJSON(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(body))
+}
+
+// muxFrame encodes payload as a single multiplexed log frame.
+func muxFrame(stream byte, payload string) string {
+	n := len(payload)
+	header := []byte{stream, 0, 0, 0, byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)}
+	return string(header) + payload
+}
+
+// ---------------------------------------------------------------------------
+// GetContainerLogs against a fake daemon
+// ---------------------------------------------------------------------------
+
+func Test_DockerClientManager_GetContainerLogs_Cases(t *testing.T) {
+	tests := []struct {
+		name      string
+		tty       bool
+		body      string
+		opts      LogOptions
+		wantQuery map[string]string
+		want      []LogLine
+	}{
+		{
+			name: "multiplexed stream keeps stream types",
+			body: muxFrame(streamStdout, "hello\n") + muxFrame(streamStderr, "boom\n"),
+			opts: LogOptions{Stdout: true, Stderr: true},
+			wantQuery: map[string]string{
+				"stdout": "true", "stderr": "true", "tail": "all", "timestamps": "false",
+			},
+			want: []LogLine{
+				{Stream: "stdout", Text: "hello"},
+				{Stream: "stderr", Text: "boom"},
+			},
+		},
+		{
+			name: "tty stream is read raw",
+			tty:  true,
+			body: "line one\r\nline two\n",
+			opts: LogOptions{Stdout: true, Stderr: true, Tail: 5},
+			wantQuery: map[string]string{
+				"tail": "5",
+			},
+			want: []LogLine{
+				{Text: "line one"},
+				{Text: "line two"},
+			},
+		},
+		{
+			name: "stderr only with timestamps",
+			body: muxFrame(streamStderr, "2026-01-02T03:04:05.000000000Z panic: x\n"),
+			opts: LogOptions{Stderr: true, Timestamps: true},
+			wantQuery: map[string]string{
+				"stdout": "false", "stderr": "true", "timestamps": "true",
+			},
+			want: []LogLine{
+				{Stream: "stderr", Timestamp: "2026-01-02T03:04:05.000000000Z", Text: "panic: x"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /containers/web/json", func(w http.ResponseWriter, r *http.Request) {
+				tty := "false"
+				if tt.tty {
+					tty = "true"
+				}
+				writeJSON(w, http.StatusOK, `{"Id":"web","Name":"/web","Config":{"Tty":`+tty+`}}`)
+			})
+			mux.HandleFunc("GET /containers/web/logs", func(w http.ResponseWriter, r *http.Request) {
+				for k, v := range tt.wantQuery {
+					if got := r.URL.Query().Get(k); got != v {
+						t.Errorf("query %s = %q, want %q", k, got, v)
+					}
+				}
+				_, _ = w.Write([]byte(tt.body))
+			})
+			mgr := newFakeDaemon(t, mux)
+
+			logs, err := mgr.GetContainerLogs(t.Context(), "web", tt.opts)
+			if err != nil {
+				t.Fatalf("GetContainerLogs: %v", err)
+			}
+			if logs.TTY != tt.tty {
+				t.Errorf("TTY = %v, want %v", logs.TTY, tt.tty)
+			}
+			if len(logs.Lines) != len(tt.want) {
+				t.Fatalf("got %d lines %+v, want %d", len(logs.Lines), logs.Lines, len(tt.want))
+			}
+			for i := range tt.want {
+				if logs.Lines[i] != tt.want[i] {
+					t.Errorf("line %d = %+v, want %+v", i, logs.Lines[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func Test_DockerClientManager_GetContainerLogs_NotFound(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /containers/missing/json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusNotFound, `{"message":"No such container: missing"}`)
+	})
+	mgr := newFakeDaemon(t, mux)
+
+	_, err := mgr.GetContainerLogs(t.Context(), "missing", LogOptions{Stdout: true})
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("err = %v, want it to contain %q", err, "not found")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Endpoints and API version negotiation
+// ---------------------------------------------------------------------------
+
+// versionedDaemon serves /_ping and /version like a daemon reporting the
+// given API versions, and records the path of every other request.
+type versionedDaemon struct {
+	pingVersion    string // API-Version header of /_ping; empty omits it
+	versionVersion string // ApiVersion of /version; empty answers 404
+
+	mu    sync.Mutex
+	paths []string
+}
+
+func (d *versionedDaemon) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/_ping":
+		if d.pingVersion != "" {
+			w.Header().Set("API-Version", d.pingVersion)
+		}
+		_, _ = w.Write([]byte("OK"))
+	case "/version":
+		if d.versionVersion == "" {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(w, http.StatusOK, `{"Version":"27.0.0","ApiVersion":"`+d.versionVersion+`"}`)
+	default:
+		d.mu.Lock()
+		d.paths = append(d.paths, r.URL.Path)
+		d.mu.Unlock()
+		writeJSON(w, http.StatusOK, `[]`)
+	}
+}
+
+func (d *versionedDaemon) lastPath() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.paths) == 0 {
+		return ""
+	}
+	return d.paths[len(d.paths)-1]
+}
+
+func Test_DockerClient_NegotiatesAPIVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		daemon      *versionedDaemon
+		pinned      string
+		wantVersion string
+	}{
+		{name: "daemon version from ping", daemon: &versionedDaemon{pingVersion: "1.43"}, wantVersion: "1.43"},
+		{name: "newer daemon is capped", daemon: &versionedDaemon{pingVersion: "1.99"}, wantVersion: maxAPIVersion},
+		{name: "older daemon is spoken to at its version", daemon: &versionedDaemon{pingVersion: "1.40"}, wantVersion: "1.40"},
+		{name: "version endpoint without ping header", daemon: &versionedDaemon{versionVersion: "1.44"}, wantVersion: "1.44"},
+		{name: "no version reported", daemon: &versionedDaemon{}, wantVersion: defaultAPIVersion},
+		{name: "pinned version skips negotiation", daemon: &versionedDaemon{pingVersion: "1.45"}, pinned: "v1.42", wantVersion: "1.42"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(tt.daemon)
+			t.Cleanup(srv.Close)
+			mgr, err := NewDockerClient(ClientConfig{Host: "tcp://" + srv.Listener.Addr().String(), APIVersion: tt.pinned})
+			if err != nil {
+				t.Fatalf("NewDockerClient: %v", err)
+			}
+			if _, err := mgr.ListContainers(context.Background(), true); err != nil {
+				t.Fatalf("ListContainers: %v", err)
+			}
+			if got := tt.daemon.lastPath(); got != "/v"+tt.wantVersion+"/containers/json" {
+				t.Errorf("request path = %q, want API version %s", got, tt.wantVersion)
+			}
+			if got := mgr.APIVersion(context.Background()); got != tt.wantVersion {
+				t.Errorf("APIVersion = %q, want %q", got, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func Test_DockerClient_RenegotiatesAfterUnreachableDaemon(t *testing.T) {
+	daemon := &versionedDaemon{pingVersion: "1.43"}
+	srv := httptest.NewUnstartedServer(daemon)
+	addr := srv.Listener.Addr().String()
+	mgr, err := NewDockerClient(ClientConfig{Host: "tcp://" + addr})
+	if err != nil {
+		t.Fatalf("NewDockerClient: %v", err)
+	}
+
+	// Nothing accepts connections yet: the default is used for now.
+	_ = srv.Listener.Close()
+	if got := mgr.APIVersion(context.Background()); got != defaultAPIVersion {
+		t.Errorf("APIVersion while unreachable = %q, want %q", got, defaultAPIVersion)
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("cannot listen on %s again: %v", addr, err)
+	}
+	srv.Listener = l
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	// The daemon is not asked again straight after a failed attempt.
+	if got := mgr.APIVersion(context.Background()); got != defaultAPIVersion {
+		t.Errorf("APIVersion within the retry delay = %q, want %q", got, defaultAPIVersion)
+	}
+
+	mgr.versionMu.Lock()
+	mgr.nextNegotiable = time.Time{}
+	mgr.versionMu.Unlock()
+	if got := mgr.APIVersion(context.Background()); got != "1.43" {
+		t.Errorf("APIVersion once reachable = %q, want 1.43", got)
+	}
+}
+
+func Test_DockerClient_NegotiatesWithoutBlockingOtherCallers(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Header().Set("API-Version", "1.43")
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+	mgr, err := NewDockerClient(ClientConfig{Host: srv.URL})
+	if err != nil {
+		t.Fatalf("NewDockerClient: %v", err)
+	}
+
+	go mgr.APIVersion(context.Background())
+	for {
+		mgr.versionMu.Lock()
+		negotiating := mgr.negotiating
+		mgr.versionMu.Unlock()
+		if negotiating {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// A second caller uses the default rather than waiting for the daemon.
+	if got := mgr.APIVersion(context.Background()); got != defaultAPIVersion {
+		t.Errorf("APIVersion during negotiation = %q, want %q", got, defaultAPIVersion)
+	}
+}
+
+func Test_DockerClient_TLS(t *testing.T) {
+	daemon := &versionedDaemon{pingVersion: "1.43"}
+	srv := httptest.NewTLSServer(daemon)
+	t.Cleanup(srv.Close)
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	addr := srv.Listener.Addr().String()
+
+	// tcp:// with a CA certificate uses TLS, like https://.
+	for _, host := range []string{"https://" + addr, "tcp://" + addr} {
+		mgr, err := NewDockerClient(ClientConfig{Host: host, CACertFile: caFile})
+		if err != nil {
+			t.Fatalf("NewDockerClient(%s): %v", host, err)
+		}
+		if _, err := mgr.ListContainers(context.Background(), true); err != nil {
+			t.Errorf("%s: ListContainers: %v", host, err)
+		}
+	}
+
+	// Without the CA, the daemon's certificate is not trusted.
+	mgr, err := NewDockerClient(ClientConfig{Host: "https://" + addr})
+	if err != nil {
+		t.Fatalf("NewDockerClient: %v", err)
+	}
+	if _, err := mgr.ListContainers(context.Background(), true); err == nil || !strings.Contains(err.Error(), "certificate") {
+		t.Errorf("error = %v, want a certificate error", err)
+	}
+}
+
+func Test_NewDockerClient_Errors(t *testing.T) {
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		cfg     ClientConfig
+		wantErr string
+	}{
+		{name: "no host", cfg: ClientConfig{}, wantErr: "host is required"},
+		{name: "empty socket path", cfg: ClientConfig{Host: "unix://"}, wantErr: "socket path is required"},
+		{name: "unsupported scheme", cfg: ClientConfig{Host: "ssh://user@host"}, wantErr: "unsupported host scheme"},
+		{name: "path in host", cfg: ClientConfig{Host: "tcp://host:2375/docker"}, wantErr: "invalid host"},
+		{name: "plain http with certificates", cfg: ClientConfig{Host: "http://host:2375", CACertFile: notPEM}, wantErr: "use https"},
+		{name: "invalid CA file", cfg: ClientConfig{Host: "https://host", CACertFile: notPEM}, wantErr: "no certificates"},
+		{name: "missing CA file", cfg: ClientConfig{Host: "https://host", CACertFile: filepath.Join(dir, "missing.pem")}, wantErr: "read CA certificate"},
+		{name: "certificate without key", cfg: ClientConfig{Host: "https://host", CertFile: notPEM}, wantErr: "both a certificate and a key"},
+		{name: "invalid API version", cfg: ClientConfig{Host: "tcp://host", APIVersion: "latest"}, wantErr: "invalid API version"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewDockerClient(tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_NewDockerClient_DefaultPorts(t *testing.T) {
+	for host, want := range map[string]string{
+		"/var/run/docker.sock":        "http://localhost",
+		"unix:///var/run/docker.sock": "http://localhost",
+		"tcp://docker-proxy":          "http://docker-proxy:2375",
+		"http://10.0.0.2:2375":        "http://10.0.0.2:2375",
+		"https://docker.lan":          "https://docker.lan:2376",
+	} {
+		mgr, err := NewDockerClient(ClientConfig{Host: host})
+		if err != nil {
+			t.Fatalf("NewDockerClient(%s): %v", host, err)
+		}
+		if mgr.baseURL != want {
+			t.Errorf("%s: base URL = %q, want %q", host, mgr.baseURL, want)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+)
+
+// ---------------------------------------------------------------------------
+// Compose projects
+// ---------------------------------------------------------------------------
+
+// Labels Docker Compose (and the Unraid Compose Manager plugin, which runs it)
+// sets on the containers it creates.
+const (
+	composeProjectLabel     = "com.docker.compose.project"
+	composeServiceLabel     = "com.docker.compose.service"
+	composeDependsOnLabel   = "com.docker.compose.depends_on"
+	composeWorkingDirLabel  = "com.docker.compose.project.working_dir"
+	composeConfigFilesLabel = "com.docker.compose.project.config_files"
+	composeNumberLabel      = "com.docker.compose.container-number"
+	composeOneoffLabel      = "com.docker.compose.oneoff"
+)
+
+// ComposeProject is the status of a Compose project, derived from the labels
+// of its containers.
+type ComposeProject struct {
+	Name        string
+	Status      string // "running", "partial" or "stopped"
+	Running     int
+	Total       int
+	WorkingDir  string
+	ConfigFiles string
+	Services    []ComposeServiceStatus
+}
+
+// ComposeServiceStatus is the status of one container of a Compose project.
+type ComposeServiceStatus struct {
+	Service   string
+	Container string
+	ID        string
+	Image     string
+	State     string
+	Health    string
+	DependsOn []string // services this one depends on
+}
+
+// ListComposeProjects groups the containers filter allows by Compose project,
+// sorted by project name and then service.
+func ListComposeProjects(ctx context.Context, mgr DockerManager, filter *safety.Filter) ([]ComposeProject, error) {
+	containers, err := mgr.ListContainers(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]*ComposeProject)
+	for _, c := range containers {
+		if !filter.IsAllowed(c.Name) {
+			continue
+		}
+		detail, err := mgr.InspectContainer(ctx, c.ID)
+		if err != nil {
+			// Removed since it was listed.
+			continue
+		}
+		labels := detail.Config.Labels
+		name := labels[composeProjectLabel]
+		if name == "" {
+			continue
+		}
+		p := byName[name]
+		if p == nil {
+			p = &ComposeProject{
+				Name:        name,
+				WorkingDir:  labels[composeWorkingDirLabel],
+				ConfigFiles: labels[composeConfigFilesLabel],
+			}
+			byName[name] = p
+		}
+		p.Total++
+		if c.State == "running" {
+			p.Running++
+		}
+		p.Services = append(p.Services, ComposeServiceStatus{
+			Service:   labels[composeServiceLabel],
+			Container: c.Name,
+			ID:        c.ID,
+			Image:     c.Image,
+			State:     c.State,
+			Health:    c.Health,
+			DependsOn: parseComposeDependsOn(labels[composeDependsOnLabel]),
+		})
+	}
+
+	projects := make([]ComposeProject, 0, len(byName))
+	for _, p := range byName {
+		switch p.Running {
+		case p.Total:
+			p.Status = "running"
+		case 0:
+			p.Status = "stopped"
+		default:
+			p.Status = "partial"
+		}
+		sort.Slice(p.Services, func(i, j int) bool {
+			if p.Services[i].Service != p.Services[j].Service {
+				return p.Services[i].Service < p.Services[j].Service
+			}
+			return p.Services[i].Container < p.Services[j].Container
+		})
+		projects = append(projects, *p)
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].Name < projects[j].Name })
+	return projects, nil
+}
+
+// parseComposeDependsOn returns the service names in a
+// com.docker.compose.depends_on label, whose entries have the form
+// "service:condition:restart".
+func parseComposeDependsOn(label string) []string {
+	var services []string
+	for _, entry := range splitList(label) {
+		service, _, _ := strings.Cut(entry, ":")
+		if service != "" {
+			services = append(services, service)
+		}
+	}
+	return services
+}
+
+// resolveComposeDependencies adds the dependencies recorded in Compose labels
+// to targets. Compose names services rather than containers, so each service
+// is mapped to the containers of the same project that run it.
+func resolveComposeDependencies(targets []BulkTarget, labels []map[string]string) {
+	type serviceKey struct{ project, service string }
+	containers := make(map[serviceKey][]string)
+	for i, t := range targets {
+		project, service := labels[i][composeProjectLabel], labels[i][composeServiceLabel]
+		if project != "" && service != "" {
+			key := serviceKey{project, service}
+			containers[key] = append(containers[key], t.Name)
+		}
+	}
+	for i := range targets {
+		project := labels[i][composeProjectLabel]
+		if project == "" {
+			continue
+		}
+		for _, service := range parseComposeDependsOn(labels[i][composeDependsOnLabel]) {
+			targets[i].DependsOn = append(targets[i].DependsOn, containers[serviceKey{project, service}]...)
+		}
+	}
+}
@@ -0,0 +1,774 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+)
+
+// ---------------------------------------------------------------------------
+// Compose files
+// ---------------------------------------------------------------------------
+
+// composeHashLabel records a hash of the configuration a container was created
+// from by docker_compose_up, so unchanged services are left alone.
+const composeHashLabel = "unraid-mcp.compose.config-hash"
+
+// composeFileNames are the file names looked for in a project directory, in
+// the order Docker Compose prefers them.
+var composeFileNames = []string{"compose.yaml", "compose.yml", "docker-compose.yaml", "docker-compose.yml"}
+
+// composeServiceKeys are the service keys docker_compose_up understands.
+// Others are reported as warnings and ignored.
+var composeServiceKeys = map[string]bool{
+	"image": true, "container_name": true, "command": true, "entrypoint": true,
+	"environment": true, "labels": true, "ports": true, "volumes": true,
+	"devices": true, "cap_add": true, "cap_drop": true, "privileged": true,
+	"restart": true, "network_mode": true, "networks": true, "depends_on": true,
+	"extra_hosts": true, "mem_limit": true, "cpus": true, "cpuset": true,
+}
+
+// ComposeFile is the subset of the Compose file format docker_compose_up
+// supports.
+type ComposeFile struct {
+	Services map[string]ComposeService `yaml:"services"`
+	Networks map[string]ComposeNetwork `yaml:"networks"`
+	Volumes  map[string]ComposeVolume  `yaml:"volumes"`
+}
+
+// ComposeNetwork is a top-level network definition.
+type ComposeNetwork struct {
+	Name     string `yaml:"name"`
+	External bool   `yaml:"external"`
+}
+
+// ComposeVolume is a top-level volume definition.
+type ComposeVolume struct {
+	Name     string `yaml:"name"`
+	External bool   `yaml:"external"`
+}
+
+// ComposeService is a service definition.
+type ComposeService struct {
+	Image         string                 `yaml:"image"`
+	ContainerName string                 `yaml:"container_name"`
+	Command       composeCommand         `yaml:"command"`
+	Entrypoint    composeCommand         `yaml:"entrypoint"`
+	Environment   composeMapOrList       `yaml:"environment"`
+	Labels        composeMapOrList       `yaml:"labels"`
+	Ports         []string               `yaml:"ports"`
+	Volumes       []string               `yaml:"volumes"`
+	Devices       []string               `yaml:"devices"`
+	CapAdd        []string               `yaml:"cap_add"`
+	CapDrop       []string               `yaml:"cap_drop"`
+	Privileged    bool                   `yaml:"privileged"`
+	Restart       string                 `yaml:"restart"`
+	NetworkMode   string                 `yaml:"network_mode"`
+	Networks      composeServiceNetworks `yaml:"networks"`
+	DependsOn     composeDependsOn       `yaml:"depends_on"`
+	ExtraHosts    []string               `yaml:"extra_hosts"`
+	MemLimit      string                 `yaml:"mem_limit"`
+	CPUs          string                 `yaml:"cpus"`
+	CPUSet        string                 `yaml:"cpuset"`
+}
+
+// composeCommand is a command given either as a string or as a list.
+type composeCommand []string
+
+func (c *composeCommand) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode {
+		args, err := splitArgs(n.Value)
+		if err != nil {
+			return err
+		}
+		*c = args
+		return nil
+	}
+	var list []string
+	if err := n.Decode(&list); err != nil {
+		return err
+	}
+	*c = list
+	return nil
+}
+
+// composeMapOrList is a KEY=value list given either as a list or as a map.
+// Entries are kept as "KEY=value" strings, or "KEY" for a map entry without a
+// value.
+type composeMapOrList []string
+
+func (l *composeMapOrList) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.MappingNode {
+		var out []string
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i].Value, n.Content[i+1]
+			if value.Tag == "!!null" {
+				out = append(out, key)
+			} else {
+				out = append(out, key+"="+value.Value)
+			}
+		}
+		*l = out
+		return nil
+	}
+	var list []string
+	if err := n.Decode(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// toMap converts the entries to a map; entries without a value map to "".
+func (l composeMapOrList) toMap() map[string]string {
+	m := make(map[string]string, len(l))
+	for _, e := range l {
+		k, v, _ := strings.Cut(e, "=")
+		m[k] = v
+	}
+	return m
+}
+
+// composeServiceNetwork is a service's attachment to a network.
+type composeServiceNetwork struct {
+	Name        string
+	Aliases     []string `yaml:"aliases"`
+	IPv4Address string   `yaml:"ipv4_address"`
+}
+
+// composeServiceNetworks is a service's network list, given either as a list
+// of names or as a map of names to attachment options. Order is preserved.
+type composeServiceNetworks []composeServiceNetwork
+
+func (s *composeServiceNetworks) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.MappingNode {
+		var out []composeServiceNetwork
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			var net composeServiceNetwork
+			if n.Content[i+1].Tag != "!!null" {
+				if err := n.Content[i+1].Decode(&net); err != nil {
+					return err
+				}
+			}
+			net.Name = n.Content[i].Value
+			out = append(out, net)
+		}
+		*s = out
+		return nil
+	}
+	var names []string
+	if err := n.Decode(&names); err != nil {
+		return err
+	}
+	for _, name := range names {
+		*s = append(*s, composeServiceNetwork{Name: name})
+	}
+	return nil
+}
+
+// composeDependsOn is a depends_on list, given either as a list of service
+// names or as a map of service names to conditions.
+type composeDependsOn []string
+
+func (d *composeDependsOn) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.MappingNode {
+		var out []string
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			out = append(out, n.Content[i].Value)
+		}
+		*d = out
+		return nil
+	}
+	var list []string
+	if err := n.Decode(&list); err != nil {
+		return err
+	}
+	*d = list
+	return nil
+}
+
+// ParseComposeFile interpolates ${VAR} references from env and parses data.
+// Warnings report unset variables and ignored service keys.
+func ParseComposeFile(data []byte, env map[string]string) (*ComposeFile, []string, error) {
+	text, warnings := interpolateCompose(string(data), env)
+
+	var f ComposeFile
+	if err := yaml.Unmarshal([]byte(text), &f); err != nil {
+		return nil, nil, fmt.Errorf("parse compose file: %w", err)
+	}
+	if len(f.Services) == 0 {
+		return nil, nil, fmt.Errorf("compose file defines no services")
+	}
+
+	var raw struct {
+		Services map[string]map[string]yaml.Node `yaml:"services"`
+	}
+	if err := yaml.Unmarshal([]byte(text), &raw); err == nil {
+		for _, name := range sortedNames(raw.Services) {
+			for _, key := range sortedNames(raw.Services[name]) {
+				if !composeServiceKeys[key] {
+					warnings = append(warnings, fmt.Sprintf("service %s: %s is not supported and was ignored", name, key))
+				}
+			}
+		}
+	}
+	return &f, warnings, nil
+}
+
+// sortedNames returns the keys of m in sorted order.
+func sortedNames[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var composeVarPattern = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)(?:(:?-)([^}]*))?\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+
+// interpolateCompose substitutes $VAR, ${VAR}, ${VAR:-default} and
+// ${VAR-default} from env; "$$" is a literal "$".
+func interpolateCompose(text string, env map[string]string) (string, []string) {
+	var warnings []string
+	warned := map[string]bool{}
+	out := composeVarPattern.ReplaceAllStringFunc(text, func(m string) string {
+		if m == "$$" {
+			return "$"
+		}
+		sub := composeVarPattern.FindStringSubmatch(m)
+		name, op, def := sub[1], sub[2], sub[3]
+		if name == "" {
+			name = sub[4]
+		}
+		value, ok := env[name]
+		switch {
+		case op == ":-" && value == "":
+			return def
+		case op == "-" && !ok:
+			return def
+		case !ok && !warned[name]:
+			warned[name] = true
+			warnings = append(warnings, fmt.Sprintf("variable %s is not set; using an empty string", name))
+		}
+		return value
+	})
+	return out, warnings
+}
+
+// parseEnvFile parses a .env file of KEY=value lines. Blank lines and lines
+// starting with # are skipped; values may be quoted.
+func parseEnvFile(data []byte) map[string]string {
+	env := make(map[string]string)
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(strings.TrimPrefix(key, "export "))
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		env[key] = value
+	}
+	return env
+}
+
+// LoadComposeProject reads and parses the compose file of the named project
+// under dir, which holds one directory per project as the Compose Manager
+// plugin lays them out. A .env file next to the compose file supplies
+// variables.
+func LoadComposeProject(dir, name string) (*ComposeFile, []byte, []string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.Contains(name, "..") {
+		return nil, nil, nil, fmt.Errorf("invalid project name %q", name)
+	}
+	projectDir := filepath.Join(dir, name)
+
+	var data []byte
+	for _, file := range composeFileNames {
+		d, err := os.ReadFile(filepath.Join(projectDir, file))
+		if err == nil {
+			data = d
+			break
+		}
+		if !os.IsNotExist(err) {
+			return nil, nil, nil, fmt.Errorf("read compose file: %w", err)
+		}
+	}
+	if data == nil {
+		return nil, nil, nil, fmt.Errorf("compose project not found: %s", name)
+	}
+
+	env := map[string]string{}
+	if d, err := os.ReadFile(filepath.Join(projectDir, ".env")); err == nil {
+		env = parseEnvFile(d)
+	}
+	f, warnings, err := ParseComposeFile(data, env)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return f, data, warnings, nil
+}
+
+// ---------------------------------------------------------------------------
+// Compose services as containers
+// ---------------------------------------------------------------------------
+
+// composeNetworkName returns the Docker network name of a network declared in
+// the compose file: its explicit name, its own name if external, or
+// "<project>_<name>".
+func (f *ComposeFile) composeNetworkName(project, name string) string {
+	n := f.Networks[name]
+	switch {
+	case n.Name != "":
+		return n.Name
+	case n.External:
+		return name
+	}
+	return project + "_" + name
+}
+
+// ComposeNetworks returns the networks the project needs, excluding external
+// ones, sorted. Services without network_mode or networks use
+// "<project>_default".
+func (f *ComposeFile) ComposeNetworks(project string) []string {
+	seen := map[string]bool{}
+	for _, svc := range f.Services {
+		if svc.NetworkMode != "" {
+			continue
+		}
+		if len(svc.Networks) == 0 {
+			seen[project+"_default"] = true
+			continue
+		}
+		if !f.Networks[svc.Networks[0].Name].External {
+			seen[f.composeNetworkName(project, svc.Networks[0].Name)] = true
+		}
+	}
+	return sortedNames(seen)
+}
+
+// containerName returns the name of the container for a service.
+func (s ComposeService) containerName(project, service string) string {
+	if s.ContainerName != "" {
+		return s.ContainerName
+	}
+	return project + "-" + service + "-1"
+}
+
+// ToCreateConfig converts a service into a ContainerCreateConfig carrying the
+// labels Docker Compose would set, plus a hash of the configuration.
+func (f *ComposeFile) ToCreateConfig(project, service string) (ContainerCreateConfig, []string, error) {
+	svc := f.Services[service]
+	cfg := ContainerCreateConfig{
+		Name:        svc.containerName(project, service),
+		Image:       svc.Image,
+		Env:         svc.Environment,
+		Cmd:         svc.Command,
+		Entrypoint:  svc.Entrypoint,
+		Labels:      svc.Labels.toMap(),
+		NetworkMode: svc.NetworkMode,
+		ExtraHosts:  svc.ExtraHosts,
+		CapAdd:      svc.CapAdd,
+		CapDrop:     svc.CapDrop,
+		Privileged:  svc.Privileged,
+		CPUSet:      svc.CPUSet,
+	}
+	fail := func(format string, args ...any) (ContainerCreateConfig, []string, error) {
+		return cfg, nil, fmt.Errorf("service %s: %s", service, fmt.Sprintf(format, args...))
+	}
+	var warnings []string
+
+	if cfg.Image == "" {
+		return fail("image is required (build is not supported)")
+	}
+	if strings.HasPrefix(cfg.NetworkMode, "service:") {
+		return fail("network_mode %q is not supported", cfg.NetworkMode)
+	}
+
+	if cfg.NetworkMode == "" {
+		if len(svc.Networks) == 0 {
+			cfg.NetworkMode = project + "_default"
+			cfg.Aliases = []string{service}
+		} else {
+			n := svc.Networks[0]
+			cfg.NetworkMode = f.composeNetworkName(project, n.Name)
+			cfg.IPv4Address = n.IPv4Address
+			cfg.Aliases = append([]string{service}, n.Aliases...)
+			for _, extra := range svc.Networks[1:] {
+				warnings = append(warnings, fmt.Sprintf("service %s: only the first network is joined; %s was ignored", service, extra.Name))
+			}
+		}
+	}
+
+	for _, p := range svc.Ports {
+		pm, err := ParsePortSpec(p)
+		if err != nil {
+			return fail("%v", err)
+		}
+		cfg.PortBindings = append(cfg.PortBindings, pm)
+	}
+
+	for _, v := range svc.Volumes {
+		parts := strings.SplitN(v, ":", 2)
+		if len(parts) < 2 {
+			warnings = append(warnings, fmt.Sprintf("service %s: anonymous volume %s was ignored", service, v))
+			continue
+		}
+		src := parts[0]
+		switch {
+		case strings.HasPrefix(src, "/"):
+		case strings.HasPrefix(src, ".") || strings.HasPrefix(src, "~"):
+			return fail("relative bind source %q is not supported; use an absolute host path", src)
+		default:
+			vol := f.Volumes[src]
+			switch {
+			case vol.Name != "":
+				src = vol.Name
+			case !vol.External:
+				src = project + "_" + src
+			}
+		}
+		cfg.Binds = append(cfg.Binds, src+":"+parts[1])
+	}
+
+	for _, d := range svc.Devices {
+		dm, err := ParseDeviceSpec(d)
+		if err != nil {
+			return fail("%v", err)
+		}
+		cfg.Devices = append(cfg.Devices, dm)
+	}
+
+	if svc.Restart != "" {
+		rp, err := ParseRestartPolicy(svc.Restart)
+		if err != nil {
+			return fail("%v", err)
+		}
+		cfg.RestartPolicy = rp
+	}
+	if svc.MemLimit != "" {
+		m, err := ParseMemory(svc.MemLimit)
+		if err != nil {
+			return fail("%v", err)
+		}
+		cfg.MemoryLimit = m
+	}
+	if svc.CPUs != "" {
+		c, err := strconv.ParseFloat(svc.CPUs, 64)
+		if err != nil {
+			return fail("invalid cpus %q", svc.CPUs)
+		}
+		cfg.CPUs = c
+	}
+
+	var deps []string
+	for _, d := range svc.DependsOn {
+		if _, ok := f.Services[d]; !ok {
+			return fail("depends on undefined service %q", d)
+		}
+		deps = append(deps, d+":service_started:false")
+	}
+
+	// The hash covers everything above; the Compose labels are derived from
+	// the project and service names.
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return fail("%v", err)
+	}
+	sum := sha256.Sum256(data)
+
+	cfg.Labels[composeProjectLabel] = project
+	cfg.Labels[composeServiceLabel] = service
+	cfg.Labels[composeNumberLabel] = "1"
+	cfg.Labels[composeOneoffLabel] = "False"
+	if len(deps) > 0 {
+		cfg.Labels[composeDependsOnLabel] = strings.Join(deps, ",")
+	}
+	cfg.Labels[composeHashLabel] = hex.EncodeToString(sum[:])
+	return cfg, warnings, nil
+}
+
+// ---------------------------------------------------------------------------
+// Reconciliation
+// ---------------------------------------------------------------------------
+
+// Compose plan actions.
+const (
+	ComposeCreate    = "create"
+	ComposeRecreate  = "recreate"
+	ComposeStart     = "start"
+	ComposeUnchanged = "unchanged"
+)
+
+// ComposePlanItem is what docker_compose_up will do for one service.
+type ComposePlanItem struct {
+	Service   string
+	Container string
+	Action    string
+	Reason    string
+	DependsOn []string
+}
+
+// ComposePlan is the set of changes that brings a project's containers in
+// line with its compose file. Services are in dependency order.
+type ComposePlan struct {
+	Project  string
+	Networks []string // networks to create
+	Services []ComposePlanItem
+	Warnings []string
+
+	configs  map[string]ContainerCreateConfig // by service
+	existing map[string]*Container            // by service
+}
+
+// PlanComposeUp compares the services of f with the containers of project.
+// Containers of the project that no longer match a service are reported as
+// warnings but left alone.
+func PlanComposeUp(ctx context.Context, mgr DockerManager, filter *safety.Filter, f *ComposeFile, project string) (*ComposePlan, error) {
+	plan := &ComposePlan{
+		Project:  project,
+		configs:  make(map[string]ContainerCreateConfig),
+		existing: make(map[string]*Container),
+	}
+
+	var targets []BulkTarget
+	for _, service := range sortedNames(f.Services) {
+		cfg, warnings, err := f.ToCreateConfig(project, service)
+		if err != nil {
+			return nil, err
+		}
+		plan.Warnings = append(plan.Warnings, warnings...)
+		plan.configs[service] = cfg
+		targets = append(targets, BulkTarget{Name: service, DependsOn: f.Services[service].DependsOn})
+	}
+	ordered, err := OrderByDependencies(targets)
+	if err != nil {
+		return nil, err
+	}
+
+	containers, err := mgr.ListContainers(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]*Container, len(containers))
+	for i := range containers {
+		byName[containers[i].Name] = &containers[i]
+	}
+
+	for _, t := range ordered {
+		cfg := plan.configs[t.Name]
+		item := ComposePlanItem{Service: t.Name, Container: cfg.Name, DependsOn: t.DependsOn}
+		existing := byName[cfg.Name]
+		switch {
+		case existing == nil:
+			item.Action, item.Reason = ComposeCreate, "no container"
+		default:
+			plan.existing[t.Name] = existing
+			detail, err := mgr.InspectContainer(ctx, existing.ID)
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case detail.Config.Labels[composeHashLabel] != cfg.Labels[composeHashLabel]:
+				item.Action, item.Reason = ComposeRecreate, "configuration changed"
+			case existing.State != "running":
+				item.Action, item.Reason = ComposeStart, "container is "+existing.State
+			default:
+				item.Action, item.Reason = ComposeUnchanged, "up to date"
+			}
+		}
+		plan.Services = append(plan.Services, item)
+	}
+
+	networks, err := mgr.ListNetworks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	haveNetwork := make(map[string]bool, len(networks))
+	for _, n := range networks {
+		haveNetwork[n.Name] = true
+	}
+	for _, name := range f.ComposeNetworks(project) {
+		if !haveNetwork[name] {
+			plan.Networks = append(plan.Networks, name)
+		}
+	}
+
+	projects, err := ListComposeProjects(ctx, mgr, filter)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range projects {
+		if p.Name != project {
+			continue
+		}
+		for _, s := range p.Services {
+			if _, ok := f.Services[s.Service]; !ok || plan.configs[s.Service].Name != s.Container {
+				plan.Warnings = append(plan.Warnings, fmt.Sprintf("container %s (service %s) is not in the compose file and was left alone", s.Container, s.Service))
+			}
+		}
+	}
+	return plan, nil
+}
+
+// Changes reports whether applying the plan would do anything.
+func (p *ComposePlan) Changes() bool {
+	if len(p.Networks) > 0 {
+		return true
+	}
+	for _, s := range p.Services {
+		if s.Action != ComposeUnchanged {
+			return true
+		}
+	}
+	return false
+}
+
+// creates returns the configurations of the containers the plan creates or
+// recreates, in order.
+func (p *ComposePlan) creates() []ContainerCreateConfig {
+	var cfgs []ContainerCreateConfig
+	for _, s := range p.Services {
+		if s.Action == ComposeCreate || s.Action == ComposeRecreate {
+			cfgs = append(cfgs, p.configs[s.Service])
+		}
+	}
+	return cfgs
+}
+
+// replacedIDs returns the IDs of the containers the plan recreates.
+func (p *ComposePlan) replacedIDs() []string {
+	var ids []string
+	for _, s := range p.Services {
+		if s.Action == ComposeRecreate {
+			ids = append(ids, p.existing[s.Service].ID)
+		}
+	}
+	return ids
+}
+
+// ComposeUpResult is the outcome of applying a ComposePlan.
+type ComposeUpResult struct {
+	Project         string
+	NetworksCreated []string
+	Succeeded       int
+	Failed          int
+	Skipped         int
+	Services        []ComposeUpItem
+	Warnings        []string
+}
+
+// ComposeUpItem is the outcome for one service.
+type ComposeUpItem struct {
+	Service     string
+	Container   string
+	ContainerID string
+	Action      string
+	Status      string // "ok", "error" or "skipped"
+	Message     string
+	Steps       []string
+}
+
+// ApplyComposePlan creates the plan's networks and then brings up each
+// service in dependency order, pulling images that are not present. New and
+// changed containers go through the same replace-and-roll-back path as
+// docker_template_create. Services whose dependencies failed are skipped.
+func ApplyComposePlan(ctx context.Context, mgr DockerManager, plan *ComposePlan) *ComposeUpResult {
+	result := &ComposeUpResult{Project: plan.Project, Services: []ComposeUpItem{}, Warnings: plan.Warnings}
+	failed := make(map[string]bool)
+	fail := func(item ComposeUpItem, format string, args ...any) {
+		item.Status, item.Message = "error", fmt.Sprintf(format, args...)
+		failed[item.Service] = true
+		result.Failed++
+		result.Services = append(result.Services, item)
+	}
+
+	for _, name := range plan.Networks {
+		if _, err := mgr.CreateNetwork(ctx, NetworkCreateConfig{Name: name, Driver: "bridge"}); err != nil {
+			// Every service may need it; report and let them fail individually.
+			result.Warnings = append(result.Warnings, fmt.Sprintf("create network %s: %v", name, err))
+			continue
+		}
+		result.NetworksCreated = append(result.NetworksCreated, name)
+	}
+
+	for _, s := range plan.Services {
+		item := ComposeUpItem{Service: s.Service, Container: s.Container, Action: s.Action}
+		if dep := firstFailedService(failed, s.DependsOn); dep != "" || ctx.Err() != nil {
+			item.Status, item.Message = "skipped", fmt.Sprintf("dependency %q did not come up", dep)
+			if dep == "" {
+				item.Message = "cancelled"
+			}
+			failed[s.Service] = true
+			result.Skipped++
+			result.Services = append(result.Services, item)
+			continue
+		}
+
+		existing := plan.existing[s.Service]
+		switch s.Action {
+		case ComposeUnchanged:
+			item.ContainerID = existing.ID
+		case ComposeStart:
+			item.ContainerID = existing.ID
+			if err := mgr.StartContainer(ctx, existing.ID); err != nil {
+				fail(item, "start: %v", err)
+				continue
+			}
+		default:
+			cfg := plan.configs[s.Service]
+			if _, err := mgr.InspectImage(ctx, cfg.Image); err != nil {
+				if err := mgr.PullImage(ctx, cfg.Image); err != nil {
+					fail(item, "pull %s: %v", cfg.Image, err)
+					continue
+				}
+				item.Steps = append(item.Steps, "pulled "+cfg.Image)
+			}
+			if s.Action == ComposeCreate {
+				existing = nil
+			}
+			created := createFromTemplate(ctx, mgr, cfg, existing, true)
+			item.Steps = append(item.Steps, created.Steps...)
+			item.ContainerID = created.ContainerID
+			if created.ContainerID == "" || !created.Started {
+				fail(item, "%s failed", s.Action)
+				continue
+			}
+		}
+		item.Status, item.Message = "ok", s.Reason
+		result.Succeeded++
+		result.Services = append(result.Services, item)
+	}
+	return result
+}
+
+func firstFailedService(failed map[string]bool, services []string) string {
+	for _, s := range services {
+		if failed[s] {
+			return s
+		}
+	}
+	return ""
+}
@@ -0,0 +1,501 @@
+package docker
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+	"github.com/jamesprial/unraid-mcp/internal/tools"
+)
+
+// newComposeMock returns a mock holding Compose project "media": web depends
+// on db, and worker depends on both. plex is not part of any project.
+func newComposeMock(t *testing.T) *recordingManager {
+	t.Helper()
+	m := NewMockDockerManager()
+	add := func(id, service, state, dependsOn string) {
+		labels := map[string]string{
+			composeProjectLabel:    "media",
+			composeServiceLabel:    service,
+			composeWorkingDirLabel: "/mnt/user/compose/media",
+		}
+		if dependsOn != "" {
+			labels[composeDependsOnLabel] = dependsOn
+		}
+		m.AddContainer(&ContainerDetail{
+			Container: Container{ID: id, Name: "media-" + service + "-1", Image: service + ":latest", State: state},
+			Config:    ContainerConfig{Labels: labels},
+		})
+	}
+	add("c1", "db", "running", "")
+	add("c2", "web", "running", "db:service_started:false")
+	add("c3", "worker", "exited", "db:service_healthy:true,web:service_started:false")
+	m.AddContainer(&ContainerDetail{Container: Container{ID: "p1", Name: "plex", State: "running"}})
+	return &recordingManager{MockDockerManager: m, fail: map[string]bool{}}
+}
+
+// ---------------------------------------------------------------------------
+// Projects from labels
+// ---------------------------------------------------------------------------
+
+func Test_ListComposeProjects(t *testing.T) {
+	mgr := newComposeMock(t)
+
+	projects, err := ListComposeProjects(t.Context(), mgr, safety.NewFilter(nil, nil))
+	if err != nil {
+		t.Fatalf("ListComposeProjects() error = %v", err)
+	}
+	if len(projects) != 1 {
+		t.Fatalf("got %d projects, want 1: %+v", len(projects), projects)
+	}
+	p := projects[0]
+	if p.Name != "media" || p.Status != "partial" || p.Running != 2 || p.Total != 3 {
+		t.Errorf("project = %+v, want media partial 2/3", p)
+	}
+	if p.WorkingDir != "/mnt/user/compose/media" {
+		t.Errorf("WorkingDir = %q", p.WorkingDir)
+	}
+	worker := p.Services[2]
+	if worker.Service != "worker" || !slices.Equal(worker.DependsOn, []string{"db", "web"}) {
+		t.Errorf("worker = %+v, want depends on db, web", worker)
+	}
+
+	projects, err = ListComposeProjects(t.Context(), mgr, safety.NewFilter(nil, []string{"media-*"}))
+	if err != nil {
+		t.Fatalf("ListComposeProjects() error = %v", err)
+	}
+	if len(projects) != 0 {
+		t.Errorf("denied containers listed: %+v", projects)
+	}
+}
+
+func Test_SelectContainers_ComposeDependencies(t *testing.T) {
+	mgr := newComposeMock(t)
+
+	targets, err := SelectContainers(t.Context(), mgr, safety.NewFilter(nil, nil), ContainerSelector{Labels: []string{composeProjectLabel + "=media"}})
+	if err != nil {
+		t.Fatalf("SelectContainers() error = %v", err)
+	}
+	ordered, err := OrderByDependencies(targets)
+	if err != nil {
+		t.Fatalf("OrderByDependencies() error = %v", err)
+	}
+	if got, want := targetNames(ordered), "media-db-1,media-web-1,media-worker-1"; got != want {
+		t.Errorf("order = %s, want %s", got, want)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Compose files
+// ---------------------------------------------------------------------------
+
+const testComposeFile = `
+services:
+  db:
+    image: postgres:${PG_VERSION:-16}
+    environment:
+      POSTGRES_PASSWORD: ${DB_PASSWORD}
+      EMPTY:
+    volumes:
+      - dbdata:/var/lib/postgresql/data
+    restart: unless-stopped
+  web:
+    image: ghcr.io/acme/web:latest
+    container_name: acme-web
+    command: serve --port 8080
+    ports:
+      - "8080:8080"
+    volumes:
+      - /mnt/user/appdata/web:/config:ro
+    depends_on:
+      db:
+        condition: service_healthy
+    networks:
+      backend:
+        aliases: [api]
+    labels:
+      - "traefik.enable=true"
+    build: .
+    healthcheck:
+      test: ["CMD", "true"]
+networks:
+  backend: {}
+volumes:
+  dbdata: {}
+`
+
+func Test_ParseComposeFile(t *testing.T) {
+	f, warnings, err := ParseComposeFile([]byte(testComposeFile), map[string]string{"DB_PASSWORD": "s3cret"})
+	if err != nil {
+		t.Fatalf("ParseComposeFile() error = %v", err)
+	}
+	if got := strings.Join(warnings, "; "); !strings.Contains(got, "web: build") || !strings.Contains(got, "web: healthcheck") {
+		t.Errorf("warnings = %v, want build and healthcheck reported", warnings)
+	}
+
+	db, web := f.Services["db"], f.Services["web"]
+	if db.Image != "postgres:16" {
+		t.Errorf("db image = %q, want default interpolated", db.Image)
+	}
+	if !slices.Equal(db.Environment, []string{"POSTGRES_PASSWORD=s3cret", "EMPTY"}) {
+		t.Errorf("db environment = %v", db.Environment)
+	}
+	if !slices.Equal(web.Command, []string{"serve", "--port", "8080"}) {
+		t.Errorf("web command = %v", web.Command)
+	}
+	if !slices.Equal(web.DependsOn, []string{"db"}) {
+		t.Errorf("web depends_on = %v", web.DependsOn)
+	}
+	if len(web.Networks) != 1 || web.Networks[0].Name != "backend" || !slices.Equal(web.Networks[0].Aliases, []string{"api"}) {
+		t.Errorf("web networks = %+v", web.Networks)
+	}
+}
+
+func Test_ParseComposeFile_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"invalid yaml", "services: ["},
+		{"no services", "networks: {}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := ParseComposeFile([]byte(tt.data), nil); err == nil {
+				t.Error("ParseComposeFile() error = nil, want error")
+			}
+		})
+	}
+}
+
+func Test_InterpolateCompose(t *testing.T) {
+	env := map[string]string{"SET": "x", "EMPTY": ""}
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"$SET ${SET}", "x x"},
+		{"${EMPTY:-d} ${EMPTY-d}", "d "},
+		{"${UNSET:-d} ${UNSET-d}", "d d"},
+		{"$$SET", "$SET"},
+		{"${UNSET}", ""},
+	}
+	for _, tt := range tests {
+		if got, _ := interpolateCompose(tt.in, env); got != tt.want {
+			t.Errorf("interpolateCompose(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	if _, warnings := interpolateCompose("${UNSET} $UNSET", env); len(warnings) != 1 {
+		t.Errorf("warnings = %v, want one for UNSET", warnings)
+	}
+}
+
+func Test_ComposeFile_ToCreateConfig(t *testing.T) {
+	f, _, err := ParseComposeFile([]byte(testComposeFile), map[string]string{"DB_PASSWORD": "s3cret"})
+	if err != nil {
+		t.Fatalf("ParseComposeFile() error = %v", err)
+	}
+
+	db, _, err := f.ToCreateConfig("acme", "db")
+	if err != nil {
+		t.Fatalf("ToCreateConfig(db) error = %v", err)
+	}
+	if db.Name != "acme-db-1" || db.NetworkMode != "acme_default" || !slices.Equal(db.Aliases, []string{"db"}) {
+		t.Errorf("db Name/NetworkMode/Aliases = %q/%q/%v", db.Name, db.NetworkMode, db.Aliases)
+	}
+	if !slices.Equal(db.Binds, []string{"acme_dbdata:/var/lib/postgresql/data"}) {
+		t.Errorf("db Binds = %v, want project-scoped volume", db.Binds)
+	}
+	if db.RestartPolicy.Name != "unless-stopped" {
+		t.Errorf("db RestartPolicy = %+v", db.RestartPolicy)
+	}
+
+	web, _, err := f.ToCreateConfig("acme", "web")
+	if err != nil {
+		t.Fatalf("ToCreateConfig(web) error = %v", err)
+	}
+	if web.Name != "acme-web" || web.NetworkMode != "acme_backend" || !slices.Equal(web.Aliases, []string{"web", "api"}) {
+		t.Errorf("web Name/NetworkMode/Aliases = %q/%q/%v", web.Name, web.NetworkMode, web.Aliases)
+	}
+	if web.Labels["traefik.enable"] != "true" || web.Labels[composeProjectLabel] != "acme" || web.Labels[composeServiceLabel] != "web" {
+		t.Errorf("web Labels = %v", web.Labels)
+	}
+	if web.Labels[composeDependsOnLabel] != "db:service_started:false" {
+		t.Errorf("web depends_on label = %q", web.Labels[composeDependsOnLabel])
+	}
+	if err := web.Validate(); err != nil {
+		t.Errorf("web Validate() error = %v", err)
+	}
+
+	// The hash changes with the configuration and nothing else.
+	again, _, _ := f.ToCreateConfig("acme", "web")
+	if again.Labels[composeHashLabel] != web.Labels[composeHashLabel] {
+		t.Error("config hash is not stable")
+	}
+	svc := f.Services["web"]
+	svc.Image = "ghcr.io/acme/web:2"
+	f.Services["web"] = svc
+	changed, _, _ := f.ToCreateConfig("acme", "web")
+	if changed.Labels[composeHashLabel] == web.Labels[composeHashLabel] {
+		t.Error("config hash did not change with the image")
+	}
+
+	if got := f.ComposeNetworks("acme"); !slices.Equal(got, []string{"acme_backend", "acme_default"}) {
+		t.Errorf("ComposeNetworks() = %v", got)
+	}
+}
+
+func Test_ComposeFile_ToCreateConfig_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		service string
+		wantErr string
+	}{
+		{"no image", "  svc:\n    build: .\n", "image is required"},
+		{"relative bind", "  svc:\n    image: x\n    volumes: [\"./data:/data\"]\n", "relative bind"},
+		{"undefined dependency", "  svc:\n    image: x\n    depends_on: [nope]\n", "undefined service"},
+		{"service network", "  svc:\n    image: x\n    network_mode: \"service:db\"\n", "not supported"},
+		{"bad port", "  svc:\n    image: x\n    ports: [\"abc\"]\n", "port"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, _, err := ParseComposeFile([]byte("services:\n"+tt.service), nil)
+			if err != nil {
+				t.Fatalf("ParseComposeFile() error = %v", err)
+			}
+			_, _, err = f.ToCreateConfig("p", "svc")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ToCreateConfig() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_LoadComposeProject(t *testing.T) {
+	dir := t.TempDir()
+	projectDir := filepath.Join(dir, "acme")
+	if err := os.MkdirAll(projectDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, "docker-compose.yml"), []byte(testComposeFile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, ".env"), []byte("# secrets\nDB_PASSWORD=\"s3cret\"\nPG_VERSION=15\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, _, _, err := LoadComposeProject(dir, "acme")
+	if err != nil {
+		t.Fatalf("LoadComposeProject() error = %v", err)
+	}
+	if f.Services["db"].Image != "postgres:15" || !slices.Contains(f.Services["db"].Environment, "POSTGRES_PASSWORD=s3cret") {
+		t.Errorf("db = %+v, want .env applied", f.Services["db"])
+	}
+
+	for _, name := range []string{"", "../etc", "a/b", "missing"} {
+		if _, _, _, err := LoadComposeProject(dir, name); err == nil {
+			t.Errorf("LoadComposeProject(%q) error = nil, want error", name)
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Plan and apply
+// ---------------------------------------------------------------------------
+
+func Test_PlanAndApplyComposeUp(t *testing.T) {
+	mgr := newComposeMock(t)
+	filter := safety.NewFilter(nil, nil)
+	f, _, err := ParseComposeFile([]byte(testComposeFile), map[string]string{"DB_PASSWORD": "s3cret"})
+	if err != nil {
+		t.Fatalf("ParseComposeFile() error = %v", err)
+	}
+
+	plan, err := PlanComposeUp(t.Context(), mgr, filter, f, "media")
+	if err != nil {
+		t.Fatalf("PlanComposeUp() error = %v", err)
+	}
+	// db exists without a config hash; web is new under its container_name;
+	// worker is no longer in the file.
+	var got []string
+	for _, s := range plan.Services {
+		got = append(got, s.Service+":"+s.Action)
+	}
+	if want := []string{"db:recreate", "web:create"}; !slices.Equal(got, want) {
+		t.Errorf("plan = %v, want %v", got, want)
+	}
+	if !slices.Equal(plan.Networks, []string{"media_backend", "media_default"}) {
+		t.Errorf("plan networks = %v", plan.Networks)
+	}
+	if w := strings.Join(plan.Warnings, "; "); !strings.Contains(w, "media-worker-1") || !strings.Contains(w, "media-web-1") {
+		t.Errorf("plan warnings = %v, want orphaned worker and web containers", plan.Warnings)
+	}
+
+	result := ApplyComposePlan(t.Context(), mgr, plan)
+	if result.Succeeded != 2 || result.Failed != 0 || len(result.NetworksCreated) != 2 {
+		t.Fatalf("result = %+v, want 2 ok and 2 networks", result)
+	}
+	if _, err := mgr.InspectContainer(t.Context(), "c1"); err == nil {
+		t.Error("replaced db container still exists")
+	}
+
+	// A second plan finds everything up to date.
+	plan, err = PlanComposeUp(t.Context(), mgr, filter, f, "media")
+	if err != nil {
+		t.Fatalf("PlanComposeUp() error = %v", err)
+	}
+	if plan.Changes() {
+		t.Errorf("second plan = %+v, want no changes", plan.Services)
+	}
+}
+
+func Test_ApplyComposePlan_SkipsDependentsOfFailures(t *testing.T) {
+	mgr := newComposeMock(t)
+	f, _, err := ParseComposeFile([]byte(testComposeFile), nil)
+	if err != nil {
+		t.Fatalf("ParseComposeFile() error = %v", err)
+	}
+	plan, err := PlanComposeUp(t.Context(), mgr, safety.NewFilter(nil, nil), f, "media")
+	if err != nil {
+		t.Fatalf("PlanComposeUp() error = %v", err)
+	}
+	plan.Services[0].Action = ComposeStart
+	mgr.fail["start c1"] = true
+
+	result := ApplyComposePlan(t.Context(), mgr, plan)
+	if result.Failed != 1 || result.Skipped != 1 {
+		t.Fatalf("result = %+v, want db failed and web skipped", result)
+	}
+	if web := result.Services[1]; web.Status != "skipped" || !strings.Contains(web.Message, `"db"`) {
+		t.Errorf("web = %+v, want skipped on db", web)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Tools
+// ---------------------------------------------------------------------------
+
+func newComposeToolsUnderTest(t *testing.T) (*recordingManager, []tools.Registration) {
+	t.Helper()
+	mgr := newComposeMock(t)
+	dir := t.TempDir()
+	for name, data := range map[string]string{
+		"media":      testComposeFile,
+		"privileged": "services:\n  svc:\n    image: x\n    privileged: true\n",
+		"denied":     "services:\n  svc:\n    image: x\n    container_name: secret\n",
+	} {
+		if err := os.MkdirAll(filepath.Join(dir, name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name, "compose.yaml"), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	confirm := safety.NewConfirmationTracker(DestructiveTools)
+	policy := &CreatePolicy{AllowedBindPaths: []string{"/mnt/user"}}
+	return mgr, ComposeTools(mgr, dir, safety.NewFilter(nil, []string{"secret"}), policy, confirm, nil)
+}
+
+func Test_ComposeTools_List(t *testing.T) {
+	_, regs := newComposeToolsUnderTest(t)
+
+	text := callTool(t, regs, "docker_compose_list", nil)
+	var projects []ComposeProject
+	if err := json.Unmarshal([]byte(text), &projects); err != nil {
+		t.Fatalf("decode result: %v\n%s", err, text)
+	}
+	if len(projects) != 1 || projects[0].Name != "media" {
+		t.Errorf("projects = %+v, want media", projects)
+	}
+
+	text = callTool(t, regs, "docker_compose_list", map[string]any{"project": "nope"})
+	if !strings.Contains(text, "not found") {
+		t.Errorf("docker_compose_list(nope) = %s, want not found", text)
+	}
+}
+
+func Test_ComposeTools_ActionStopsInReverseDependencyOrder(t *testing.T) {
+	mgr, regs := newComposeToolsUnderTest(t)
+	args := map[string]any{"project": "media", "action": "stop"}
+
+	text := callTool(t, regs, "docker_compose_action", args)
+	if !strings.Contains(text, "media-db-1, media-web-1, media-worker-1") {
+		t.Fatalf("confirmation prompt = %s, want containers in dependency order", text)
+	}
+	args["confirmation_token"] = extractToken(t, text)
+	callTool(t, regs, "docker_compose_action", args)
+
+	if got, want := strings.Join(mgr.calls, ","), "stop c3,stop c2,stop c1"; got != want {
+		t.Errorf("calls = %s, want %s", got, want)
+	}
+}
+
+func Test_ComposeTools_ActionValidation(t *testing.T) {
+	_, regs := newComposeToolsUnderTest(t)
+
+	tests := []struct {
+		name string
+		args map[string]any
+		want string
+	}{
+		{"missing project", map[string]any{"action": "start"}, "project is required"},
+		{"bad action", map[string]any{"project": "media", "action": "pause"}, "invalid action"},
+		{"unknown project", map[string]any{"project": "nope", "action": "start"}, "not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if text := callTool(t, regs, "docker_compose_action", tt.args); !strings.Contains(text, tt.want) {
+				t.Errorf("docker_compose_action = %s, want %q", text, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ComposeTools_Up(t *testing.T) {
+	mgr, regs := newComposeToolsUnderTest(t)
+	args := map[string]any{"project": "media"}
+
+	text := callTool(t, regs, "docker_compose_up", args)
+	if !strings.Contains(text, "recreate media-db-1") || !strings.Contains(text, "create acme-web") {
+		t.Fatalf("confirmation prompt = %s, want the plan", text)
+	}
+	args["confirmation_token"] = extractToken(t, text)
+	text = callTool(t, regs, "docker_compose_up", args)
+
+	var result ComposeUpResult
+	if err := json.Unmarshal([]byte(text), &result); err != nil {
+		t.Fatalf("decode result: %v\n%s", err, text)
+	}
+	if result.Succeeded != 2 {
+		t.Errorf("result = %+v, want 2 services up", result)
+	}
+	web, err := findContainerByName(t.Context(), mgr, "acme-web")
+	if err != nil || web == nil || web.State != "running" {
+		t.Errorf("acme-web = %+v, %v; want running", web, err)
+	}
+}
+
+func Test_ComposeTools_UpRejections(t *testing.T) {
+	_, regs := newComposeToolsUnderTest(t)
+
+	tests := []struct {
+		project string
+		want    string
+	}{
+		{"privileged", "not allowed by policy"},
+		{"denied", "not allowed"},
+		{"../media", "invalid project name"},
+		{"missing", "not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.project, func(t *testing.T) {
+			if text := callTool(t, regs, "docker_compose_up", map[string]any{"project": tt.project}); !strings.Contains(text, tt.want) {
+				t.Errorf("docker_compose_up = %s, want %q", text, tt.want)
+			}
+		})
+	}
+}
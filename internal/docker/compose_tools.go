@@ -0,0 +1,264 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+	"github.com/jamesprial/unraid-mcp/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ComposeTools returns the tool registrations for Docker Compose projects.
+// composeDir holds the Compose Manager plugin's project directories, which
+// docker_compose_up reads compose files from.
+func ComposeTools(
+	mgr DockerManager,
+	composeDir string,
+	filter *safety.Filter,
+	policy *CreatePolicy,
+	confirm *safety.ConfirmationTracker,
+	audit *safety.AuditLogger,
+) []tools.Registration {
+	return []tools.Registration{
+		toolDockerComposeList(mgr, filter, audit),
+		toolDockerComposeAction(mgr, filter, confirm, audit),
+		toolDockerComposeUp(mgr, composeDir, filter, policy, confirm, audit),
+	}
+}
+
+func toolDockerComposeList(mgr DockerManager, filter *safety.Filter, audit *safety.AuditLogger) tools.Registration {
+	const toolName = "docker_compose_list"
+
+	tool := mcp.NewTool(toolName,
+		mcp.WithDescription("List Docker Compose projects, grouping containers by their com.docker.compose.project label. "+
+			"Each project reports whether it is running, partially running or stopped, and each service its container, state, health and dependencies."),
+		mcp.WithString("project",
+			mcp.Description("Only show this project"),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		project := req.GetString("project", "")
+		params := map[string]any{"project": project}
+
+		projects, err := ListComposeProjects(ctx, mgr, filter)
+		if err != nil {
+			tools.LogAudit(audit, toolName, params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+		if project != "" {
+			var found []ComposeProject
+			for _, p := range projects {
+				if p.Name == project {
+					found = append(found, p)
+				}
+			}
+			if len(found) == 0 {
+				return tools.ErrorResult(fmt.Sprintf("compose project not found: %s", project)), nil
+			}
+			projects = found
+		}
+
+		tools.LogAudit(audit, toolName, params, "ok", start)
+		return tools.JSONResult(projects), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
+
+func toolDockerComposeAction(mgr DockerManager, filter *safety.Filter, confirm *safety.ConfirmationTracker, audit *safety.AuditLogger) tools.Registration {
+	const toolName = "docker_compose_action"
+
+	tool := mcp.NewTool(toolName,
+		mcp.WithDescription("Start, stop or restart every container of a Docker Compose project in dependency order, "+
+			"using the depends_on recorded by Compose. Stops run in reverse order. "+
+			"Only containers the server's filter allows are included. Requires confirmation."),
+		mcp.WithString("project",
+			mcp.Required(),
+			mcp.Description("Compose project name"),
+		),
+		mcp.WithString("action",
+			mcp.Required(),
+			mcp.Description("start, stop or restart"),
+		),
+		mcp.WithNumber("timeout",
+			mcp.Description("Seconds to wait for each container to stop before killing it (default: 10)"),
+		),
+		mcp.WithString("confirmation_token",
+			mcp.Description("Confirmation token returned by a prior call to this tool"),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		project := req.GetString("project", "")
+		action := req.GetString("action", "")
+		timeout := req.GetInt("timeout", 10)
+		if timeout == 0 {
+			timeout = 10
+		}
+		token := req.GetString("confirmation_token", "")
+		params := map[string]any{
+			"project": project,
+			"action":  action,
+			"timeout": timeout,
+		}
+
+		if project == "" {
+			return tools.ErrorResult("project is required"), nil
+		}
+		switch action {
+		case BulkStart, BulkStop, BulkRestart:
+		default:
+			return tools.ErrorResult(fmt.Sprintf("invalid action %q (want start, stop or restart)", action)), nil
+		}
+
+		targets, err := SelectContainers(ctx, mgr, filter, ContainerSelector{Labels: []string{composeProjectLabel + "=" + project}})
+		if err != nil {
+			tools.LogAudit(audit, toolName, params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+		if len(targets) == 0 {
+			return tools.ErrorResult(fmt.Sprintf("compose project not found: %s", project)), nil
+		}
+		if targets, err = OrderByDependencies(targets); err != nil {
+			return tools.ErrorResult(err.Error()), nil
+		}
+
+		names := make([]string, len(targets))
+		for i, t := range targets {
+			names[i] = t.Name
+		}
+		params["targets"] = names
+		resource := "compose:" + project
+
+		if !confirm.Confirm(token, toolName, resource, params) {
+			desc := fmt.Sprintf("This will %s compose project %q (%d containers, in order: %s).", action, project, len(targets), strings.Join(names, ", "))
+			return tools.ConfirmPrompt(confirm, toolName, resource, desc, params), nil
+		}
+
+		result := RunBulk(ctx, mgr, action, BulkOrderDependencies, targets, timeout)
+
+		outcome := "ok"
+		if result.Failed > 0 || result.Skipped > 0 {
+			outcome = fmt.Sprintf("partial: %d ok, %d failed, %d skipped", result.Succeeded, result.Failed, result.Skipped)
+		}
+		tools.LogAudit(audit, toolName, params, outcome, start)
+		return tools.JSONResult(result), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
+
+func toolDockerComposeUp(mgr DockerManager, composeDir string, filter *safety.Filter, policy *CreatePolicy, confirm *safety.ConfirmationTracker, audit *safety.AuditLogger) tools.Registration {
+	const toolName = "docker_compose_up"
+
+	tool := mcp.NewTool(toolName,
+		mcp.WithDescription("Bring a Compose Manager project up from its compose file: create missing services, recreate services whose configuration changed "+
+			"(rolling back if the new container fails to start) and start stopped ones, in dependency order. "+
+			"Supports image-based services with ports, volumes, environment, labels, networks, devices, capabilities, restart policy and resource limits; "+
+			"build and other keys are reported and ignored. Every service is subject to the server's create policy. "+
+			"The confirmation prompt shows the plan. Requires confirmation."),
+		mcp.WithString("project",
+			mcp.Required(),
+			mcp.Description("Project (directory) name in the Compose Manager projects directory"),
+		),
+		mcp.WithString("confirmation_token",
+			mcp.Description("Confirmation token returned by a prior call to this tool"),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		project := req.GetString("project", "")
+		token := req.GetString("confirmation_token", "")
+		params := map[string]any{"project": project}
+
+		f, data, warnings, err := LoadComposeProject(composeDir, project)
+		if err != nil {
+			tools.LogAudit(audit, toolName, params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+
+		plan, err := PlanComposeUp(ctx, mgr, filter, f, project)
+		if err != nil {
+			tools.LogAudit(audit, toolName, params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+		plan.Warnings = append(warnings, plan.Warnings...)
+
+		for _, s := range plan.Services {
+			cfg := plan.configs[s.Service]
+			if !filter.IsAllowed(cfg.Name) {
+				tools.LogAudit(audit, toolName, params, "denied", start)
+				return tools.ErrorResult(fmt.Sprintf("creation of container %q is not allowed", cfg.Name)), nil
+			}
+			if err := cfg.Validate(); err != nil {
+				return tools.ErrorResult(fmt.Sprintf("service %s: %v", s.Service, err)), nil
+			}
+			if err := policy.Check(cfg); err != nil {
+				tools.LogAudit(audit, toolName, params, "denied: "+err.Error(), start)
+				return tools.ErrorResult(fmt.Sprintf("service %s: %v", s.Service, err)), nil
+			}
+			if err := checkNetworkContainer(ctx, mgr, filter, cfg.NetworkMode); err != nil {
+				tools.LogAudit(audit, toolName, params, "denied: "+err.Error(), start)
+				return tools.ErrorResult(fmt.Sprintf("service %s: %v", s.Service, err)), nil
+			}
+		}
+		if !plan.Changes() {
+			tools.LogAudit(audit, toolName, params, "ok: unchanged", start)
+			return tools.JSONResult(plan), nil
+		}
+		conflicts, err := preflightCreate(ctx, mgr, filter, plan.creates(), plan.replacedIDs()...)
+		if err != nil {
+			tools.LogAudit(audit, toolName, params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+		plan.Warnings = append(plan.Warnings, conflicts...)
+
+		// Bind the token to the file contents and the plan, so an edit to
+		// either between the prompt and the confirmation needs a new prompt.
+		sum := sha256.Sum256(data)
+		params["file_sha256"] = hex.EncodeToString(sum[:])
+		steps := make([]string, len(plan.Services))
+		for i, s := range plan.Services {
+			steps[i] = s.Service + ":" + s.Action
+		}
+		params["plan"] = steps
+		resource := "compose:" + project
+
+		if !confirm.Confirm(token, toolName, resource, params) {
+			desc := fmt.Sprintf("Compose project %q:", project)
+			if len(plan.Networks) > 0 {
+				desc += fmt.Sprintf(" create networks %s;", strings.Join(plan.Networks, ", "))
+			}
+			for _, s := range plan.Services {
+				desc += fmt.Sprintf(" %s %s (%s, %s);", s.Action, s.Container, s.Service, s.Reason)
+			}
+			desc = strings.TrimSuffix(desc, ";") + "."
+			if len(plan.Warnings) > 0 {
+				desc += " Warnings: " + strings.Join(plan.Warnings, "; ") + "."
+			}
+			return tools.ConfirmPrompt(confirm, toolName, resource, desc, params), nil
+		}
+
+		result := ApplyComposePlan(ctx, mgr, plan)
+
+		outcome := "ok"
+		if result.Failed > 0 || result.Skipped > 0 {
+			outcome = fmt.Sprintf("partial: %d ok, %d failed, %d skipped", result.Succeeded, result.Failed, result.Skipped)
+		}
+		tools.LogAudit(audit, toolName, params, outcome, start)
+		return tools.JSONResult(result), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
@@ -4,6 +4,9 @@ package docker
 import (
 	"context"
 	"fmt"
+	"maps"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/jamesprial/unraid-mcp/internal/safety"
@@ -14,16 +17,20 @@ import (
 
 func toolDockerList(mgr DockerManager, filter *safety.Filter, audit *safety.AuditLogger) tools.Registration {
 	tool := mcp.NewTool("docker_list",
-		mcp.WithDescription("List Docker containers."),
+		mcp.WithDescription("List Docker containers. Health is derived from the container status."),
 		mcp.WithBoolean("all",
 			mcp.Description("Include stopped containers (default: false)"),
 		),
+		mcp.WithBoolean("details",
+			mcp.Description("Also report exit code, OOM kill, restart count, restart policy and healthcheck state, inspecting each container (default: false)"),
+		),
 	)
 
 	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		start := time.Now()
 		all := req.GetBool("all", false)
-		params := map[string]any{"all": all}
+		details := req.GetBool("details", false)
+		params := map[string]any{"all": all, "details": details}
 
 		containers, err := mgr.ListContainers(ctx, all)
 		if err != nil {
@@ -39,6 +46,20 @@ func toolDockerList(mgr DockerManager, filter *safety.Filter, audit *safety.Audi
 			}
 		}
 
+		if details {
+			summaries := make([]ContainerSummary, 0, len(filtered))
+			for _, c := range filtered {
+				summary := ContainerSummary{Container: c}
+				// A container removed since the list keeps its summary only.
+				if detail, err := mgr.InspectContainer(ctx, c.ID); err == nil {
+					summary.RuntimeState = detail.RuntimeState
+				}
+				summaries = append(summaries, summary)
+			}
+			tools.LogAudit(audit, "docker_list", params, "ok", start)
+			return tools.JSONResult(summaries), nil
+		}
+
 		tools.LogAudit(audit, "docker_list", params, "ok", start)
 		return tools.JSONResult(filtered), nil
 	}
@@ -78,9 +99,12 @@ func toolDockerInspect(mgr DockerManager, filter *safety.Filter, audit *safety.A
 	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
 }
 
+// defaultLogMaxBytes caps docker_logs output when max_bytes is not given.
+const defaultLogMaxBytes = 64 * 1024
+
 func toolDockerLogs(mgr DockerManager, filter *safety.Filter, audit *safety.AuditLogger) tools.Registration {
 	tool := mcp.NewTool("docker_logs",
-		mcp.WithDescription("Fetch logs from a Docker container."),
+		mcp.WithDescription("Fetch logs from a Docker container, optionally filtered by time range, stream, and pattern."),
 		mcp.WithString("id",
 			mcp.Required(),
 			mcp.Description("Container ID or name"),
@@ -88,32 +112,134 @@ func toolDockerLogs(mgr DockerManager, filter *safety.Filter, audit *safety.Audi
 		mcp.WithNumber("tail",
 			mcp.Description("Number of lines to return from the end of the logs (0 = all)"),
 		),
+		mcp.WithString("since",
+			mcp.Description("Only return logs after this time: RFC3339 timestamp or relative duration (e.g. 15m, 2h)"),
+		),
+		mcp.WithString("until",
+			mcp.Description("Only return logs before this time: RFC3339 timestamp or relative duration (e.g. 5m)"),
+		),
+		mcp.WithBoolean("timestamps",
+			mcp.Description("Prefix each line with its timestamp (default: false)"),
+		),
+		mcp.WithBoolean("stdout_only",
+			mcp.Description("Return only stdout (default: false)"),
+		),
+		mcp.WithBoolean("stderr_only",
+			mcp.Description("Return only stderr (default: false)"),
+		),
+		mcp.WithString("grep",
+			mcp.Description("Only return lines matching this regular expression"),
+		),
+		mcp.WithNumber("max_bytes",
+			mcp.Description("Maximum bytes of output; older lines are dropped first (default: 65536)"),
+		),
 	)
 
 	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		start := time.Now()
 		id := req.GetString("id", "")
 		tail := req.GetInt("tail", 0)
-		params := map[string]any{"id": id, "tail": tail}
+		since := req.GetString("since", "")
+		until := req.GetString("until", "")
+		timestamps := req.GetBool("timestamps", false)
+		stdoutOnly := req.GetBool("stdout_only", false)
+		stderrOnly := req.GetBool("stderr_only", false)
+		grep := req.GetString("grep", "")
+		maxBytes := req.GetInt("max_bytes", defaultLogMaxBytes)
+		params := map[string]any{
+			"id":          id,
+			"tail":        tail,
+			"since":       since,
+			"until":       until,
+			"timestamps":  timestamps,
+			"stdout_only": stdoutOnly,
+			"stderr_only": stderrOnly,
+			"grep":        grep,
+			"max_bytes":   maxBytes,
+		}
 
 		if !filter.IsAllowed(id) {
 			tools.LogAudit(audit, "docker_logs", params, "denied", start)
 			return tools.ErrorResult(fmt.Sprintf("access to container %q is not allowed", id)), nil
 		}
 
-		logs, err := mgr.GetLogs(ctx, id, tail)
+		opts, err := buildLogOptions(start, tail, since, until, timestamps, stdoutOnly, stderrOnly, grep, maxBytes)
+		if err != nil {
+			tools.LogAudit(audit, "docker_logs", params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+
+		logs, err := mgr.GetContainerLogs(ctx, id, opts)
 		if err != nil {
 			tools.LogAudit(audit, "docker_logs", params, "error: "+err.Error(), start)
 			return tools.ErrorResult(err.Error()), nil
 		}
 
 		tools.LogAudit(audit, "docker_logs", params, "ok", start)
-		return mcp.NewToolResultText(logs), nil
+		return mcp.NewToolResultText(formatLogs(logs, opts.MaxBytes)), nil
 	}
 
 	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
 }
 
+// buildLogOptions validates docker_logs arguments and converts them to
+// LogOptions. Relative since/until values are resolved against now.
+func buildLogOptions(now time.Time, tail int, since, until string, timestamps, stdoutOnly, stderrOnly bool, grep string, maxBytes int) (LogOptions, error) {
+	if stdoutOnly && stderrOnly {
+		return LogOptions{}, fmt.Errorf("stdout_only and stderr_only are mutually exclusive")
+	}
+	if tail < 0 {
+		return LogOptions{}, fmt.Errorf("tail must not be negative")
+	}
+	if maxBytes <= 0 {
+		maxBytes = defaultLogMaxBytes
+	}
+
+	opts := LogOptions{
+		Tail:       tail,
+		Timestamps: timestamps,
+		Stdout:     !stderrOnly,
+		Stderr:     !stdoutOnly,
+		MaxBytes:   maxBytes,
+	}
+
+	var err error
+	if opts.Since, err = ParseLogTime(since, now); err != nil {
+		return LogOptions{}, fmt.Errorf("since: %w", err)
+	}
+	if opts.Until, err = ParseLogTime(until, now); err != nil {
+		return LogOptions{}, fmt.Errorf("until: %w", err)
+	}
+	if !opts.Since.IsZero() && !opts.Until.IsZero() && !opts.Until.After(opts.Since) {
+		return LogOptions{}, fmt.Errorf("until must be after since")
+	}
+
+	if grep != "" {
+		if opts.Grep, err = regexp.Compile(grep); err != nil {
+			return LogOptions{}, fmt.Errorf("invalid grep pattern: %w", err)
+		}
+	}
+	return opts, nil
+}
+
+// formatLogs renders log lines as plain text, one per line, with timestamps
+// when present. A leading note is added when output was truncated.
+func formatLogs(logs *ContainerLogs, maxBytes int) string {
+	var out strings.Builder
+	if logs.Truncated {
+		fmt.Fprintf(&out, "[output truncated to the most recent %d bytes]\n", maxBytes)
+	}
+	for _, line := range logs.Lines {
+		if line.Timestamp != "" {
+			out.WriteString(line.Timestamp)
+			out.WriteByte(' ')
+		}
+		out.WriteString(line.Text)
+		out.WriteByte('\n')
+	}
+	return out.String()
+}
+
 func toolDockerStats(mgr DockerManager, filter *safety.Filter, audit *safety.AuditLogger) tools.Registration {
 	tool := mcp.NewTool("docker_stats",
 		mcp.WithDescription("Get resource usage statistics for a Docker container."),
@@ -209,9 +335,9 @@ func toolDockerStop(mgr DockerManager, filter *safety.Filter, confirm *safety.Co
 			return tools.ErrorResult(fmt.Sprintf("access to container %q is not allowed", id)), nil
 		}
 
-		if !confirm.Confirm(token) {
+		if !confirm.Confirm(token, toolName, id, params) {
 			desc := fmt.Sprintf("This will stop container %q with a %d second timeout.", id, timeout)
-			return tools.ConfirmPrompt(confirm, toolName, id, desc), nil
+			return tools.ConfirmPrompt(confirm, toolName, id, desc, params), nil
 		}
 
 		if err := mgr.StopContainer(ctx, id, timeout); err != nil {
@@ -258,9 +384,9 @@ func toolDockerRestart(mgr DockerManager, filter *safety.Filter, confirm *safety
 			return tools.ErrorResult(fmt.Sprintf("access to container %q is not allowed", id)), nil
 		}
 
-		if !confirm.Confirm(token) {
+		if !confirm.Confirm(token, toolName, id, params) {
 			desc := fmt.Sprintf("This will restart container %q with a %d second timeout.", id, timeout)
-			return tools.ConfirmPrompt(confirm, toolName, id, desc), nil
+			return tools.ConfirmPrompt(confirm, toolName, id, desc, params), nil
 		}
 
 		if err := mgr.RestartContainer(ctx, id, timeout); err != nil {
@@ -304,9 +430,9 @@ func toolDockerRemove(mgr DockerManager, filter *safety.Filter, confirm *safety.
 			return tools.ErrorResult(fmt.Sprintf("access to container %q is not allowed", id)), nil
 		}
 
-		if !confirm.Confirm(token) {
+		if !confirm.Confirm(token, toolName, id, params) {
 			desc := fmt.Sprintf("This will permanently remove container %q (force=%v).", id, force)
-			return tools.ConfirmPrompt(confirm, toolName, id, desc), nil
+			return tools.ConfirmPrompt(confirm, toolName, id, desc, params), nil
 		}
 
 		if err := mgr.RemoveContainer(ctx, id, force); err != nil {
@@ -321,11 +447,148 @@ func toolDockerRemove(mgr DockerManager, filter *safety.Filter, confirm *safety.
 	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
 }
 
-func toolDockerCreate(mgr DockerManager, filter *safety.Filter, confirm *safety.ConfirmationTracker, audit *safety.AuditLogger) tools.Registration {
+// createConfigFromRequest builds a ContainerCreateConfig from docker_create
+// arguments. It also returns the parsed arguments for auditing and
+// confirmation binding.
+func createConfigFromRequest(req mcp.CallToolRequest) (ContainerCreateConfig, map[string]any, error) {
+	cfg := ContainerCreateConfig{
+		Name:        req.GetString("name", ""),
+		Image:       req.GetString("image", ""),
+		Env:         req.GetStringSlice("env", nil),
+		Cmd:         req.GetStringSlice("cmd", nil),
+		Entrypoint:  req.GetStringSlice("entrypoint", nil),
+		Binds:       req.GetStringSlice("volumes", nil),
+		NetworkMode: req.GetString("network", ""),
+		IPv4Address: req.GetString("ip_address", ""),
+		ExtraHosts:  req.GetStringSlice("extra_hosts", nil),
+		CapAdd:      req.GetStringSlice("cap_add", nil),
+		CapDrop:     req.GetStringSlice("cap_drop", nil),
+		Privileged:  req.GetBool("privileged", false),
+		CPUs:        req.GetFloat("cpus", 0),
+		CPUSet:      req.GetString("cpuset", ""),
+	}
+	labelList := req.GetStringSlice("labels", nil)
+	portList := req.GetStringSlice("ports", nil)
+	deviceList := req.GetStringSlice("devices", nil)
+	restart := req.GetString("restart_policy", "")
+	memory := req.GetString("memory", "")
+
+	params := map[string]any{"name": cfg.Name, "image": cfg.Image}
+	for key, value := range map[string]any{
+		"env": envNames(cfg.Env), "cmd": cfg.Cmd, "entrypoint": cfg.Entrypoint, "labels": labelList,
+		"ports": portList, "volumes": cfg.Binds, "restart_policy": restart, "network": cfg.NetworkMode,
+		"ip_address": cfg.IPv4Address, "extra_hosts": cfg.ExtraHosts, "devices": deviceList,
+		"cap_add": cfg.CapAdd, "cap_drop": cfg.CapDrop, "privileged": cfg.Privileged,
+		"memory": memory, "cpus": cfg.CPUs, "cpuset": cfg.CPUSet,
+	} {
+		if !isZeroParam(value) {
+			params[key] = value
+		}
+	}
+
+	var err error
+	if cfg.Labels, err = parseKeyValues(labelList); err != nil {
+		return cfg, params, fmt.Errorf("labels: %w", err)
+	}
+	for _, spec := range portList {
+		pm, err := ParsePortSpec(spec)
+		if err != nil {
+			return cfg, params, err
+		}
+		cfg.PortBindings = append(cfg.PortBindings, pm)
+	}
+	for _, spec := range deviceList {
+		d, err := ParseDeviceSpec(spec)
+		if err != nil {
+			return cfg, params, err
+		}
+		cfg.Devices = append(cfg.Devices, d)
+	}
+	if restart != "" {
+		if cfg.RestartPolicy, err = ParseRestartPolicy(restart); err != nil {
+			return cfg, params, err
+		}
+	}
+	if cfg.MemoryLimit, err = ParseMemory(memory); err != nil {
+		return cfg, params, err
+	}
+	return cfg, params, nil
+}
+
+// envNames returns the variable names of env entries, for the audit log.
+// Values are left out, as they often hold passwords and API keys.
+func envNames(env []string) []string {
+	if len(env) == 0 {
+		return nil
+	}
+	names := make([]string, len(env))
+	for i, e := range env {
+		names[i], _, _ = strings.Cut(e, "=")
+	}
+	return names
+}
+
+// confirmArgsWithEnv returns params with the full env entries in place of
+// their names. The confirmation tracker keeps only a hash of its arguments,
+// so a token is bound to the values without them being logged.
+func confirmArgsWithEnv(params map[string]any, env []string) map[string]any {
+	args := maps.Clone(params)
+	if len(env) > 0 {
+		args["env"] = env
+	}
+	return args
+}
+
+// isZeroParam reports whether an optional tool argument was left unset.
+func isZeroParam(v any) bool {
+	switch x := v.(type) {
+	case string:
+		return x == ""
+	case []string:
+		return len(x) == 0
+	case bool:
+		return !x
+	case float64:
+		return x == 0
+	}
+	return v == nil
+}
+
+// describeCreate summarises the settings of a container about to be created
+// for the confirmation prompt.
+func describeCreate(cfg ContainerCreateConfig) string {
+	desc := fmt.Sprintf("This will create a new container from image %q with name %q.", cfg.Image, cfg.Name)
+	var notes []string
+	if cfg.NetworkMode != "" {
+		n := "network " + cfg.NetworkMode
+		if cfg.IPv4Address != "" {
+			n += " at " + cfg.IPv4Address
+		}
+		notes = append(notes, n)
+	}
+	if cfg.Privileged {
+		notes = append(notes, "PRIVILEGED mode")
+	}
+	if len(cfg.CapAdd) > 0 {
+		notes = append(notes, "capabilities "+strings.Join(cfg.CapAdd, ", "))
+	}
+	for _, d := range cfg.Devices {
+		notes = append(notes, "device "+d.PathOnHost)
+	}
+	if len(cfg.Binds) > 0 {
+		notes = append(notes, "volumes "+strings.Join(cfg.Binds, ", "))
+	}
+	if len(notes) > 0 {
+		desc += " Settings: " + strings.Join(notes, "; ") + "."
+	}
+	return desc
+}
+
+func toolDockerCreate(mgr DockerManager, filter *safety.Filter, policy *CreatePolicy, confirm *safety.ConfirmationTracker, audit *safety.AuditLogger) tools.Registration {
 	const toolName = "docker_create"
 
 	tool := mcp.NewTool(toolName,
-		mcp.WithDescription("Create a new Docker container. Requires confirmation."),
+		mcp.WithDescription("Create a new Docker container. Host-level settings (privileged mode, host networking, capabilities, devices, resource limits) are subject to the server's create policy. Requires confirmation."),
 		mcp.WithString("name",
 			mcp.Description("Container name"),
 		),
@@ -333,6 +596,67 @@ func toolDockerCreate(mgr DockerManager, filter *safety.Filter, confirm *safety.
 			mcp.Required(),
 			mcp.Description("Image name and optional tag (e.g. nginx:latest)"),
 		),
+		mcp.WithArray("env",
+			mcp.Description("Environment variables as KEY=value strings"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithArray("cmd",
+			mcp.Description("Command override"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithArray("entrypoint",
+			mcp.Description("Entrypoint override"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithArray("labels",
+			mcp.Description("Labels as key=value strings"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithArray("ports",
+			mcp.Description("Published ports as [host_ip:]host_port:container_port[/tcp|udp] (e.g. 8080:80, 192.168.1.10:53:53/udp)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithArray("volumes",
+			mcp.Description("Bind mounts or named volumes as source:container_path[:ro] (e.g. /mnt/user/appdata/app:/config)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithString("restart_policy",
+			mcp.Description("Restart policy: no, always, unless-stopped, on-failure or on-failure:N"),
+		),
+		mcp.WithString("network",
+			mcp.Description("Network mode: bridge, host, none, container:<id>, or a network name such as br0"),
+		),
+		mcp.WithString("ip_address",
+			mcp.Description("Static IPv4 address on a user-defined network (e.g. br0)"),
+		),
+		mcp.WithArray("extra_hosts",
+			mcp.Description("Extra /etc/hosts entries as host:ip"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithArray("devices",
+			mcp.Description("Host devices as host_path[:container_path[:rwm]] (e.g. /dev/dri)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithArray("cap_add",
+			mcp.Description("Linux capabilities to add (e.g. NET_ADMIN)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithArray("cap_drop",
+			mcp.Description("Linux capabilities to drop"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithBoolean("privileged",
+			mcp.Description("Run the container in privileged mode (default: false)"),
+		),
+		mcp.WithString("memory",
+			mcp.Description("Memory limit (e.g. 512m, 4g)"),
+		),
+		mcp.WithNumber("cpus",
+			mcp.Description("CPU limit as a number of CPUs (e.g. 1.5)"),
+		),
+		mcp.WithString("cpuset",
+			mcp.Description("CPUs the container may run on (e.g. 0-3,8)"),
+		),
 		mcp.WithString("confirmation_token",
 			mcp.Description("Confirmation token returned by a prior call to this tool"),
 		),
@@ -340,29 +664,45 @@ func toolDockerCreate(mgr DockerManager, filter *safety.Filter, confirm *safety.
 
 	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		start := time.Now()
-		name := req.GetString("name", "")
-		image := req.GetString("image", "")
 		token := req.GetString("confirmation_token", "")
-		params := map[string]any{"name": name, "image": image}
+		cfg, params, err := createConfigFromRequest(req)
+		if err != nil {
+			return tools.ErrorResult(err.Error()), nil
+		}
 
-		resourceName := name
+		resourceName := cfg.Name
 		if resourceName == "" {
-			resourceName = image
+			resourceName = cfg.Image
 		}
 
-		if name != "" && !filter.IsAllowed(name) {
+		if cfg.Name != "" && !filter.IsAllowed(cfg.Name) {
 			tools.LogAudit(audit, toolName, params, "denied", start)
-			return tools.ErrorResult(fmt.Sprintf("creation of container %q is not allowed", name)), nil
+			return tools.ErrorResult(fmt.Sprintf("creation of container %q is not allowed", cfg.Name)), nil
 		}
-
-		if !confirm.Confirm(token) {
-			desc := fmt.Sprintf("This will create a new container from image %q with name %q.", image, name)
-			return tools.ConfirmPrompt(confirm, toolName, resourceName, desc), nil
+		if err := cfg.Validate(); err != nil {
+			return tools.ErrorResult(err.Error()), nil
+		}
+		if err := policy.Check(cfg); err != nil {
+			tools.LogAudit(audit, toolName, params, "denied: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+		if err := checkNetworkContainer(ctx, mgr, filter, cfg.NetworkMode); err != nil {
+			tools.LogAudit(audit, toolName, params, "denied: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+		warnings, err := preflightCreate(ctx, mgr, filter, []ContainerCreateConfig{cfg})
+		if err != nil {
+			tools.LogAudit(audit, toolName, params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
 		}
 
-		cfg := ContainerCreateConfig{
-			Name:  name,
-			Image: image,
+		confirmArgs := confirmArgsWithEnv(params, cfg.Env)
+		if !confirm.Confirm(token, toolName, resourceName, confirmArgs) {
+			desc := describeCreate(cfg)
+			if len(warnings) > 0 {
+				desc += " Warnings: " + strings.Join(warnings, "; ") + "."
+			}
+			return tools.ConfirmPrompt(confirm, toolName, resourceName, desc, confirmArgs), nil
 		}
 
 		containerID, err := mgr.CreateContainer(ctx, cfg)
@@ -372,7 +712,11 @@ func toolDockerCreate(mgr DockerManager, filter *safety.Filter, confirm *safety.
 		}
 
 		tools.LogAudit(audit, toolName, params, "ok: "+containerID, start)
-		return mcp.NewToolResultText(fmt.Sprintf("container created with ID %q", containerID)), nil
+		text := fmt.Sprintf("container created with ID %q", containerID)
+		if len(warnings) > 0 {
+			text += "; warnings: " + strings.Join(warnings, "; ")
+		}
+		return mcp.NewToolResultText(text), nil
 	}
 
 	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
@@ -380,7 +724,8 @@ func toolDockerCreate(mgr DockerManager, filter *safety.Filter, confirm *safety.
 
 func toolDockerPull(mgr DockerManager, audit *safety.AuditLogger) tools.Registration {
 	tool := mcp.NewTool("docker_pull",
-		mcp.WithDescription("Pull a Docker image from a registry."),
+		mcp.WithDescription("Pull a Docker image from a registry, using the configured registry credentials. "+
+			"Reports download progress to clients that request it and returns the pulled digest."),
 		mcp.WithString("image",
 			mcp.Required(),
 			mcp.Description("Image name and optional tag (e.g. nginx:latest)"),
@@ -392,13 +737,184 @@ func toolDockerPull(mgr DockerManager, audit *safety.AuditLogger) tools.Registra
 		image := req.GetString("image", "")
 		params := map[string]any{"image": image}
 
-		if err := mgr.PullImage(ctx, image); err != nil {
+		result, err := mgr.PullImageWithProgress(ctx, image, func(p PullProgress) {
+			tools.NotifyProgress(ctx, req, float64(p.Current), float64(p.Total), fmt.Sprintf(
+				"%d/%d layers, %s of %s", p.LayersDone, p.Layers, tools.FormatBytes(p.Current), tools.FormatBytes(p.Total)))
+		})
+		if err != nil {
 			tools.LogAudit(audit, "docker_pull", params, "error: "+err.Error(), start)
 			return tools.ErrorResult(err.Error()), nil
 		}
 
 		tools.LogAudit(audit, "docker_pull", params, "ok", start)
-		return mcp.NewToolResultText(fmt.Sprintf("image %q pulled successfully", image)), nil
+		return tools.JSONResult(result), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
+
+// checkNetworkContainer refuses a container:<id> network mode whose target
+// the filter hides, by the name or ID given or by its resolved name, since
+// joining a container's network namespace reaches everything it listens on.
+func checkNetworkContainer(ctx context.Context, mgr DockerManager, filter *safety.Filter, networkMode string) error {
+	target, ok := strings.CutPrefix(networkMode, "container:")
+	if !ok {
+		return nil
+	}
+	if filter.IsAllowed(target) {
+		detail, err := mgr.InspectContainer(ctx, target)
+		if err != nil {
+			return err
+		}
+		if filter.IsAllowed(detail.Name) {
+			return nil
+		}
+	}
+	return fmt.Errorf("joining the network of container %q is not allowed", target)
+}
+
+func toolDockerUpdate(mgr DockerManager, filter *safety.Filter, confirm *safety.ConfirmationTracker, audit *safety.AuditLogger) tools.Registration {
+	const toolName = "docker_update"
+
+	tool := mcp.NewTool(toolName,
+		mcp.WithDescription("Update a container: pull its image and, if the image changed, recreate the container with its own settings and the new image's defaults, "+
+			"starting it if it was running. "+
+			"The previous container is restored if the new one fails to start or become healthy. Requires confirmation."),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("Container ID or name"),
+		),
+		mcp.WithBoolean("force",
+			mcp.Description("Recreate the container even if the image is unchanged (default: false)"),
+		),
+		mcp.WithNumber("health_timeout",
+			mcp.Description("Seconds to wait for the new container to become healthy, or to stay running if it has no healthcheck (default: 0, only check that it started)"),
+		),
+		mcp.WithNumber("stop_timeout",
+			mcp.Description("Seconds to wait when stopping the old container (default: 10)"),
+		),
+		mcp.WithString("confirmation_token",
+			mcp.Description("Confirmation token returned by a prior call to this tool"),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		id := req.GetString("id", "")
+		force := req.GetBool("force", false)
+		healthTimeout := req.GetInt("health_timeout", 0)
+		stopTimeout := req.GetInt("stop_timeout", 10)
+		if stopTimeout == 0 {
+			stopTimeout = 10
+		}
+		token := req.GetString("confirmation_token", "")
+		params := map[string]any{"id": id, "force": force, "health_timeout": healthTimeout, "stop_timeout": stopTimeout}
+
+		if !filter.IsAllowed(id) {
+			tools.LogAudit(audit, toolName, params, "denied", start)
+			return tools.ErrorResult(fmt.Sprintf("access to container %q is not allowed", id)), nil
+		}
+		if healthTimeout < 0 {
+			return tools.ErrorResult("health_timeout must not be negative"), nil
+		}
+
+		if !confirm.Confirm(token, toolName, id, params) {
+			desc := fmt.Sprintf("This will pull the image for container %q and, if it changed, stop and recreate the container with its current settings.", id)
+			if force {
+				desc = fmt.Sprintf("This will pull the image for container %q and stop and recreate the container with its current settings.", id)
+			}
+			return tools.ConfirmPrompt(confirm, toolName, id, desc, params), nil
+		}
+
+		result, err := mgr.UpdateContainer(ctx, id, UpdateOptions{
+			Force:         force,
+			StopTimeout:   stopTimeout,
+			HealthTimeout: time.Duration(healthTimeout) * time.Second,
+		})
+		if err != nil {
+			tools.LogAudit(audit, toolName, params, "error: "+err.Error(), start)
+			msg := err.Error()
+			if result != nil && len(result.Steps) > 0 {
+				msg += "\n\nSteps:\n- " + strings.Join(result.Steps, "\n- ")
+			}
+			return tools.ErrorResult(msg), nil
+		}
+
+		outcome := "ok: unchanged"
+		if result.Updated {
+			outcome = fmt.Sprintf("ok: recreated %s as %s", shortID(result.OldID), shortID(result.NewID))
+		}
+		tools.LogAudit(audit, toolName, params, outcome, start)
+		return tools.JSONResult(result), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
+
+func toolDockerExec(mgr DockerManager, filter *safety.Filter, policy *ExecPolicy, audit *safety.AuditLogger) tools.Registration {
+	const toolName = "docker_exec"
+
+	tool := mcp.NewTool(toolName,
+		mcp.WithDescription("Run a diagnostic command inside a running container and return its exit code and output. "+
+			"Only commands allowed for the container by the server's exec policy can be run. No shell is involved: pass each argument separately. "+
+			"Commands run as the container's user in its working directory."),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("Container ID or name"),
+		),
+		mcp.WithArray("command",
+			mcp.Required(),
+			mcp.Description(`Command and arguments (e.g. ["nginx", "-t"])`),
+			mcp.WithStringItems(),
+		),
+		mcp.WithNumber("timeout",
+			mcp.Description("Seconds to wait for the command (default and maximum set by the exec policy)"),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		id := req.GetString("id", "")
+		cmd := req.GetStringSlice("command", nil)
+		timeout := policy.EffectiveTimeout(time.Duration(req.GetFloat("timeout", 0) * float64(time.Second)))
+		params := map[string]any{"id": id, "command": cmd, "timeout": timeout.Seconds()}
+
+		if !filter.IsAllowed(id) {
+			tools.LogAudit(audit, toolName, params, "denied", start)
+			return tools.ErrorResult(fmt.Sprintf("access to container %q is not allowed", id)), nil
+		}
+
+		// Rules are written against container names, so resolve IDs first.
+		detail, err := mgr.InspectContainer(ctx, id)
+		if err != nil {
+			tools.LogAudit(audit, toolName, params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+		if !filter.IsAllowed(detail.Name) {
+			tools.LogAudit(audit, toolName, params, "denied", start)
+			return tools.ErrorResult(fmt.Sprintf("access to container %q is not allowed", detail.Name)), nil
+		}
+		if err := policy.Check(detail.Name, cmd); err != nil {
+			tools.LogAudit(audit, toolName, params, "denied: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+
+		result, err := mgr.ExecContainer(ctx, detail.ID, ExecOptions{
+			Cmd:            cmd,
+			Timeout:        timeout,
+			MaxOutputBytes: policy.EffectiveMaxOutput(),
+		})
+		if err != nil {
+			tools.LogAudit(audit, toolName, params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+
+		outcome := fmt.Sprintf("ok: exit %d", result.ExitCode)
+		if result.TimedOut {
+			outcome = "timeout"
+		}
+		tools.LogAudit(audit, toolName, params, outcome, start)
+		return tools.JSONResult(result), nil
 	}
 
 	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
@@ -0,0 +1,385 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"fmt"
+	"net/netip"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// ContainerCreateConfig validation
+// ---------------------------------------------------------------------------
+
+// minMemoryLimit is the smallest memory limit the Docker daemon accepts.
+const minMemoryLimit = 6 * 1024 * 1024
+
+var (
+	capabilityPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+	cpusetPattern     = regexp.MustCompile(`^[0-9]+(-[0-9]+)?(,[0-9]+(-[0-9]+)?)*$`)
+)
+
+// bindModes lists the options accepted after the second colon of a bind spec.
+var bindModes = map[string]struct{}{
+	"ro": {}, "rw": {}, "z": {}, "Z": {}, "nocopy": {},
+	"shared": {}, "rshared": {}, "slave": {}, "rslave": {}, "private": {}, "rprivate": {},
+}
+
+// userNetworkMode reports whether mode names a user-defined network (such as
+// Unraid's br0 macvlan) on which a static IP may be requested.
+func userNetworkMode(mode string) bool {
+	switch mode {
+	case "", "default", "bridge", "host", "none":
+		return false
+	}
+	return !strings.HasPrefix(mode, "container:")
+}
+
+// Validate checks that every field of c is well formed. It does not apply any
+// policy; see CreatePolicy.Check for that.
+func (c ContainerCreateConfig) Validate() error {
+	if c.Image == "" {
+		return fmt.Errorf("image is required")
+	}
+
+	switch c.RestartPolicy.Name {
+	case "", "no", "always", "unless-stopped":
+		if c.RestartPolicy.MaximumRetryCount != 0 {
+			return fmt.Errorf("restart policy %q does not take a retry count", c.RestartPolicy.Name)
+		}
+	case "on-failure":
+		if c.RestartPolicy.MaximumRetryCount < 0 {
+			return fmt.Errorf("restart retry count must not be negative")
+		}
+	default:
+		return fmt.Errorf("invalid restart policy %q (want no, always, unless-stopped or on-failure)", c.RestartPolicy.Name)
+	}
+
+	for _, p := range c.PortBindings {
+		if p.ContainerPort < 1 || p.ContainerPort > 65535 {
+			return fmt.Errorf("invalid container port %d", p.ContainerPort)
+		}
+		if p.HostPort < 0 || p.HostPort > 65535 {
+			return fmt.Errorf("invalid host port %d", p.HostPort)
+		}
+		switch p.Protocol {
+		case "", "tcp", "udp", "sctp":
+		default:
+			return fmt.Errorf("invalid port protocol %q", p.Protocol)
+		}
+		if p.HostIP != "" {
+			if _, err := netip.ParseAddr(p.HostIP); err != nil {
+				return fmt.Errorf("invalid host IP %q", p.HostIP)
+			}
+		}
+	}
+
+	for _, b := range c.Binds {
+		if err := validateBind(b); err != nil {
+			return err
+		}
+	}
+
+	if c.IPv4Address != "" {
+		if !userNetworkMode(c.NetworkMode) {
+			return fmt.Errorf("a static IP requires a user-defined network such as br0, not %q", c.NetworkMode)
+		}
+		addr, err := netip.ParseAddr(c.IPv4Address)
+		if err != nil || !addr.Is4() {
+			return fmt.Errorf("invalid IPv4 address %q", c.IPv4Address)
+		}
+	}
+	if len(c.Aliases) > 0 && !userNetworkMode(c.NetworkMode) {
+		return fmt.Errorf("network aliases require a user-defined network, not %q", c.NetworkMode)
+	}
+
+	for _, d := range c.Devices {
+		if !strings.HasPrefix(d.PathOnHost, "/dev/") {
+			return fmt.Errorf("device %q must be under /dev/", d.PathOnHost)
+		}
+		if d.PathInContainer != "" && !path.IsAbs(d.PathInContainer) {
+			return fmt.Errorf("device container path %q must be absolute", d.PathInContainer)
+		}
+		if strings.Trim(d.CgroupPermissions, "rwm") != "" {
+			return fmt.Errorf("invalid device permissions %q (want a combination of r, w, m)", d.CgroupPermissions)
+		}
+	}
+
+	for _, capName := range append(append([]string{}, c.CapAdd...), c.CapDrop...) {
+		if !capabilityPattern.MatchString(normalizeCapability(capName)) {
+			return fmt.Errorf("invalid capability %q", capName)
+		}
+	}
+
+	if c.MemoryLimit != 0 && c.MemoryLimit < minMemoryLimit {
+		return fmt.Errorf("memory limit must be at least 6MB")
+	}
+	if c.CPUs < 0 {
+		return fmt.Errorf("cpus must not be negative")
+	}
+	if c.CPUSet != "" && !cpusetPattern.MatchString(c.CPUSet) {
+		return fmt.Errorf("invalid cpuset %q (want e.g. 0-3,8)", c.CPUSet)
+	}
+
+	for _, h := range c.ExtraHosts {
+		host, ip, ok := strings.Cut(h, ":")
+		if !ok || host == "" {
+			return fmt.Errorf("invalid extra host %q (want host:ip)", h)
+		}
+		if ip != "host-gateway" {
+			if _, err := netip.ParseAddr(ip); err != nil {
+				return fmt.Errorf("invalid extra host %q (want host:ip)", h)
+			}
+		}
+	}
+	return nil
+}
+
+// validateBind checks a "source:destination[:options]" bind spec. The source
+// may be an absolute host path or a named volume.
+func validateBind(spec string) error {
+	parts := strings.Split(spec, ":")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" {
+		return fmt.Errorf("invalid volume %q (want host_path:container_path[:ro])", spec)
+	}
+	if strings.Contains(parts[0], "/") && !path.IsAbs(parts[0]) {
+		return fmt.Errorf("volume host path %q must be absolute", parts[0])
+	}
+	if !path.IsAbs(parts[1]) {
+		return fmt.Errorf("volume container path %q must be absolute", parts[1])
+	}
+	if len(parts) == 3 {
+		for _, opt := range strings.Split(parts[2], ",") {
+			if _, ok := bindModes[opt]; !ok {
+				return fmt.Errorf("invalid volume option %q in %q", opt, spec)
+			}
+		}
+	}
+	return nil
+}
+
+// normalizeCapability upper-cases a capability name and strips the optional
+// CAP_ prefix, so "cap_net_admin" and "NET_ADMIN" compare equal.
+func normalizeCapability(name string) string {
+	return strings.TrimPrefix(strings.ToUpper(name), "CAP_")
+}
+
+// ---------------------------------------------------------------------------
+// CreatePolicy
+// ---------------------------------------------------------------------------
+
+// CreatePolicy restricts the host-level settings a new container may request.
+// The zero value allows no privileged mode, no host networking, no added
+// capabilities, no devices, no bind mounts of host paths, and no resource
+// caps.
+type CreatePolicy struct {
+	AllowPrivileged     bool
+	AllowHostNetwork    bool
+	AllowedCapabilities []string // capability names; "*" allows any
+	AllowedDevices      []string // glob patterns matched against the host device path
+	AllowedBindPaths    []string // host directories bind mounts may come from; named volumes are always allowed
+	MaxMemory           int64    // bytes; 0 = no cap
+	MaxCPUs             float64  // 0 = no cap
+}
+
+// Check reports the first setting in c that the policy does not permit. A nil
+// policy behaves like the zero value.
+func (p *CreatePolicy) Check(c ContainerCreateConfig) error {
+	if p == nil {
+		p = &CreatePolicy{}
+	}
+
+	if c.Privileged && !p.AllowPrivileged {
+		return fmt.Errorf("privileged containers are not allowed by policy")
+	}
+	if c.NetworkMode == "host" && !p.AllowHostNetwork {
+		return fmt.Errorf("host networking is not allowed by policy")
+	}
+
+	for _, capName := range c.CapAdd {
+		if !p.capabilityAllowed(normalizeCapability(capName)) {
+			return fmt.Errorf("capability %s is not allowed by policy", normalizeCapability(capName))
+		}
+	}
+
+	for _, d := range c.Devices {
+		if !p.deviceAllowed(d.PathOnHost) {
+			return fmt.Errorf("device %s is not allowed by policy", d.PathOnHost)
+		}
+	}
+
+	for _, src := range c.bindSources() {
+		if !p.bindAllowed(src) {
+			return fmt.Errorf("bind mount of host path %s is not allowed by policy", src)
+		}
+	}
+
+	if p.MaxMemory > 0 && (c.MemoryLimit == 0 || c.MemoryLimit > p.MaxMemory) {
+		return fmt.Errorf("a memory limit of at most %dMB is required by policy", p.MaxMemory/(1024*1024))
+	}
+	if p.MaxCPUs > 0 && (c.CPUs == 0 || c.CPUs > p.MaxCPUs) {
+		return fmt.Errorf("a CPU limit of at most %s is required by policy", strconv.FormatFloat(p.MaxCPUs, 'f', -1, 64))
+	}
+	return nil
+}
+
+func (p *CreatePolicy) capabilityAllowed(name string) bool {
+	for _, allowed := range p.AllowedCapabilities {
+		if allowed == "*" || normalizeCapability(allowed) == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *CreatePolicy) deviceAllowed(hostPath string) bool {
+	for _, pattern := range p.AllowedDevices {
+		if ok, err := path.Match(pattern, hostPath); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// bindAllowed reports whether hostPath is one of the allowed bind paths or
+// lies below one. The path is cleaned first, so ".." cannot climb out.
+func (p *CreatePolicy) bindAllowed(hostPath string) bool {
+	hostPath = path.Clean(hostPath)
+	for _, dir := range p.AllowedBindPaths {
+		dir = path.Clean(dir)
+		if dir == "/" || hostPath == dir || strings.HasPrefix(hostPath, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// bindSources returns the host paths c bind-mounts, from both Binds and
+// Volumes. Sources without a slash name volumes and are left out.
+func (c ContainerCreateConfig) bindSources() []string {
+	var out []string
+	for _, b := range c.Binds {
+		if src, _, _ := strings.Cut(b, ":"); strings.Contains(src, "/") {
+			out = append(out, src)
+		}
+	}
+	for src := range c.Volumes {
+		if strings.Contains(src, "/") {
+			out = append(out, src)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ---------------------------------------------------------------------------
+// CLI-style spec parsers
+// ---------------------------------------------------------------------------
+
+// ParsePortSpec parses a docker-run style port spec:
+// [host_ip:]host_port:container_port[/protocol] or container_port[/protocol].
+func ParsePortSpec(spec string) (PortMapping, error) {
+	var pm PortMapping
+	rest, proto, hasProto := strings.Cut(spec, "/")
+	if hasProto {
+		pm.Protocol = proto
+	}
+
+	// An IPv6 host IP is written in brackets: [::1]:8080:80.
+	if strings.HasPrefix(rest, "[") {
+		end := strings.Index(rest, "]:")
+		if end < 0 {
+			return pm, fmt.Errorf("invalid port %q", spec)
+		}
+		pm.HostIP = rest[1:end]
+		rest = rest[end+2:]
+	}
+
+	parts := strings.Split(rest, ":")
+	var hostPort, containerPort string
+	switch {
+	case len(parts) == 1:
+		containerPort = parts[0]
+	case len(parts) == 2:
+		hostPort, containerPort = parts[0], parts[1]
+	case len(parts) == 3 && pm.HostIP == "":
+		pm.HostIP, hostPort, containerPort = parts[0], parts[1], parts[2]
+	default:
+		return pm, fmt.Errorf("invalid port %q (want [host_ip:]host_port:container_port[/protocol])", spec)
+	}
+
+	cp, err := strconv.Atoi(containerPort)
+	if err != nil {
+		return pm, fmt.Errorf("invalid container port in %q", spec)
+	}
+	pm.ContainerPort = cp
+	if hostPort != "" {
+		hp, err := strconv.Atoi(hostPort)
+		if err != nil {
+			return pm, fmt.Errorf("invalid host port in %q", spec)
+		}
+		pm.HostPort = hp
+	}
+	return pm, nil
+}
+
+// ParseDeviceSpec parses a docker-run style device spec:
+// host_path[:container_path[:permissions]].
+func ParseDeviceSpec(spec string) (DeviceMapping, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) > 3 || parts[0] == "" {
+		return DeviceMapping{}, fmt.Errorf("invalid device %q (want host_path[:container_path[:rwm]])", spec)
+	}
+	d := DeviceMapping{PathOnHost: parts[0]}
+	if len(parts) > 1 {
+		d.PathInContainer = parts[1]
+	}
+	if len(parts) > 2 {
+		d.CgroupPermissions = parts[2]
+	}
+	return d, nil
+}
+
+// ParseRestartPolicy parses "no", "always", "unless-stopped", "on-failure" or
+// "on-failure:N".
+func ParseRestartPolicy(spec string) (RestartPolicy, error) {
+	name, count, hasCount := strings.Cut(spec, ":")
+	rp := RestartPolicy{Name: name}
+	if hasCount {
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			return rp, fmt.Errorf("invalid restart retry count in %q", spec)
+		}
+		rp.MaximumRetryCount = n
+	}
+	return rp, nil
+}
+
+// ParseMemory parses a memory size such as "512m", "2g" or "1073741824".
+// Suffixes b, k, m and g (optionally followed by b) are binary multiples and
+// case-insensitive.
+func ParseMemory(spec string) (int64, error) {
+	s := strings.ToLower(strings.TrimSpace(spec))
+	if s == "" {
+		return 0, nil
+	}
+	s = strings.TrimSuffix(s, "b")
+	mult := int64(1)
+	switch {
+	case strings.HasSuffix(s, "k"):
+		mult, s = 1<<10, strings.TrimSuffix(s, "k")
+	case strings.HasSuffix(s, "m"):
+		mult, s = 1<<20, strings.TrimSuffix(s, "m")
+	case strings.HasSuffix(s, "g"):
+		mult, s = 1<<30, strings.TrimSuffix(s, "g")
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid memory size %q (want e.g. 512m or 2g)", spec)
+	}
+	return int64(n * float64(mult)), nil
+}
@@ -0,0 +1,242 @@
+package docker
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func Test_ContainerCreateConfig_Validate_Cases(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     ContainerCreateConfig
+		wantErr string
+	}{
+		{name: "minimal", cfg: ContainerCreateConfig{Image: "nginx"}},
+		{name: "missing image", cfg: ContainerCreateConfig{}, wantErr: "image is required"},
+		{
+			name: "full unraid style",
+			cfg: ContainerCreateConfig{
+				Image:         "plexinc/pms-docker",
+				RestartPolicy: RestartPolicy{Name: "on-failure", MaximumRetryCount: 3},
+				NetworkMode:   "br0",
+				IPv4Address:   "192.168.1.50",
+				PortBindings:  []PortMapping{{ContainerPort: 32400, Protocol: "tcp", HostIP: "192.168.1.10", HostPort: 32400}},
+				Binds:         []string{"/mnt/user/appdata/plex:/config:rw", "transcode:/transcode"},
+				Devices:       []DeviceMapping{{PathOnHost: "/dev/dri", CgroupPermissions: "rw"}},
+				CapAdd:        []string{"cap_net_admin"},
+				MemoryLimit:   4 << 30,
+				CPUs:          2,
+				CPUSet:        "0-3,8",
+				ExtraHosts:    []string{"nas:192.168.1.2", "host.docker.internal:host-gateway"},
+			},
+		},
+		{name: "bad restart policy", cfg: ContainerCreateConfig{Image: "x", RestartPolicy: RestartPolicy{Name: "sometimes"}}, wantErr: "invalid restart policy"},
+		{name: "retry count without on-failure", cfg: ContainerCreateConfig{Image: "x", RestartPolicy: RestartPolicy{Name: "always", MaximumRetryCount: 2}}, wantErr: "retry count"},
+		{name: "bad protocol", cfg: ContainerCreateConfig{Image: "x", PortBindings: []PortMapping{{ContainerPort: 80, Protocol: "icmp"}}}, wantErr: "protocol"},
+		{name: "bad host ip", cfg: ContainerCreateConfig{Image: "x", PortBindings: []PortMapping{{ContainerPort: 80, HostIP: "nope"}}}, wantErr: "host IP"},
+		{name: "static ip on bridge", cfg: ContainerCreateConfig{Image: "x", NetworkMode: "bridge", IPv4Address: "172.17.0.9"}, wantErr: "user-defined network"},
+		{name: "static ip not v4", cfg: ContainerCreateConfig{Image: "x", NetworkMode: "br0", IPv4Address: "fe80::1"}, wantErr: "invalid IPv4"},
+		{name: "aliases on bridge", cfg: ContainerCreateConfig{Image: "x", Aliases: []string{"db"}}, wantErr: "network aliases"},
+		{name: "relative bind", cfg: ContainerCreateConfig{Image: "x", Binds: []string{"appdata/x:/config"}}, wantErr: "must be absolute"},
+		{name: "bad bind option", cfg: ContainerCreateConfig{Image: "x", Binds: []string{"/a:/b:rx"}}, wantErr: "invalid volume option"},
+		{name: "device outside dev", cfg: ContainerCreateConfig{Image: "x", Devices: []DeviceMapping{{PathOnHost: "/etc/shadow"}}}, wantErr: "under /dev/"},
+		{name: "bad device perms", cfg: ContainerCreateConfig{Image: "x", Devices: []DeviceMapping{{PathOnHost: "/dev/dri", CgroupPermissions: "rwx"}}}, wantErr: "permissions"},
+		{name: "bad capability", cfg: ContainerCreateConfig{Image: "x", CapAdd: []string{"net admin"}}, wantErr: "invalid capability"},
+		{name: "tiny memory", cfg: ContainerCreateConfig{Image: "x", MemoryLimit: 1024}, wantErr: "at least 6MB"},
+		{name: "bad cpuset", cfg: ContainerCreateConfig{Image: "x", CPUSet: "0-"}, wantErr: "invalid cpuset"},
+		{name: "bad extra host", cfg: ContainerCreateConfig{Image: "x", ExtraHosts: []string{"nas"}}, wantErr: "invalid extra host"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_CreatePolicy_Check_Cases(t *testing.T) {
+	permissive := &CreatePolicy{
+		AllowPrivileged:     true,
+		AllowHostNetwork:    true,
+		AllowedCapabilities: []string{"NET_ADMIN"},
+		AllowedDevices:      []string{"/dev/dri", "/dev/dri/*"},
+		MaxMemory:           1 << 30,
+		MaxCPUs:             2,
+	}
+
+	tests := []struct {
+		name    string
+		policy  *CreatePolicy
+		cfg     ContainerCreateConfig
+		wantErr string
+	}{
+		{name: "nil policy allows plain container", policy: nil, cfg: ContainerCreateConfig{Image: "x"}},
+		{name: "nil policy denies privileged", policy: nil, cfg: ContainerCreateConfig{Image: "x", Privileged: true}, wantErr: "privileged"},
+		{name: "zero policy denies host network", policy: &CreatePolicy{}, cfg: ContainerCreateConfig{Image: "x", NetworkMode: "host"}, wantErr: "host networking"},
+		{name: "zero policy denies devices", policy: &CreatePolicy{}, cfg: ContainerCreateConfig{Image: "x", Devices: []DeviceMapping{{PathOnHost: "/dev/dri"}}}, wantErr: "device /dev/dri"},
+		{name: "cap drop is always allowed", policy: &CreatePolicy{}, cfg: ContainerCreateConfig{Image: "x", CapDrop: []string{"ALL"}}},
+		{name: "allowed cap with prefix", policy: permissive, cfg: ContainerCreateConfig{Image: "x", CapAdd: []string{"CAP_NET_ADMIN"}, MemoryLimit: 1 << 29, CPUs: 1}},
+		{name: "disallowed cap", policy: permissive, cfg: ContainerCreateConfig{Image: "x", CapAdd: []string{"SYS_ADMIN"}, MemoryLimit: 1 << 29, CPUs: 1}, wantErr: "SYS_ADMIN"},
+		{name: "wildcard cap", policy: &CreatePolicy{AllowedCapabilities: []string{"*"}}, cfg: ContainerCreateConfig{Image: "x", CapAdd: []string{"SYS_ADMIN"}}},
+		{name: "allowed device glob", policy: permissive, cfg: ContainerCreateConfig{Image: "x", Devices: []DeviceMapping{{PathOnHost: "/dev/dri/renderD128"}}, MemoryLimit: 1 << 29, CPUs: 1}},
+		{name: "memory cap requires limit", policy: permissive, cfg: ContainerCreateConfig{Image: "x", CPUs: 1}, wantErr: "memory limit"},
+		{name: "memory over cap", policy: permissive, cfg: ContainerCreateConfig{Image: "x", MemoryLimit: 2 << 30, CPUs: 1}, wantErr: "memory limit"},
+		{name: "cpus over cap", policy: permissive, cfg: ContainerCreateConfig{Image: "x", MemoryLimit: 1 << 29, CPUs: 4}, wantErr: "CPU limit"},
+		{name: "zero policy denies host paths", policy: &CreatePolicy{}, cfg: ContainerCreateConfig{Image: "x", Binds: []string{"/mnt/user/a:/a"}}, wantErr: "host path /mnt/user/a"},
+		{name: "named volume is always allowed", policy: &CreatePolicy{}, cfg: ContainerCreateConfig{Image: "x", Binds: []string{"data:/data"}}},
+		{name: "bind under allowed path", policy: &CreatePolicy{AllowedBindPaths: []string{"/mnt/user/"}}, cfg: ContainerCreateConfig{Image: "x", Binds: []string{"/mnt/user/appdata/a:/config:rw"}}},
+		{name: "bind of allowed path itself", policy: &CreatePolicy{AllowedBindPaths: []string{"/mnt/user"}}, cfg: ContainerCreateConfig{Image: "x", Binds: []string{"/mnt/user:/mnt"}}},
+		{name: "sibling of allowed path", policy: &CreatePolicy{AllowedBindPaths: []string{"/mnt/user"}}, cfg: ContainerCreateConfig{Image: "x", Binds: []string{"/mnt/username:/a"}}, wantErr: "/mnt/username"},
+		{name: "volumes map host path", policy: &CreatePolicy{AllowedBindPaths: []string{"/mnt/user"}}, cfg: ContainerCreateConfig{Image: "x", Volumes: map[string]string{"/etc": "/host-etc"}}, wantErr: "host path /etc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check(tt.cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_ParsePortSpec_Cases(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    PortMapping
+		wantErr bool
+	}{
+		{spec: "80", want: PortMapping{ContainerPort: 80}},
+		{spec: "8080:80", want: PortMapping{ContainerPort: 80, HostPort: 8080}},
+		{spec: "53:53/udp", want: PortMapping{ContainerPort: 53, HostPort: 53, Protocol: "udp"}},
+		{spec: "192.168.1.10:8080:80/tcp", want: PortMapping{ContainerPort: 80, HostPort: 8080, HostIP: "192.168.1.10", Protocol: "tcp"}},
+		{spec: "[::1]:8080:80", want: PortMapping{ContainerPort: 80, HostPort: 8080, HostIP: "::1"}},
+		{spec: "a:b", wantErr: true},
+		{spec: "1:2:3:4", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParsePortSpec(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParsePortSpec(%q) = %+v, want error", tt.spec, got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ParsePortSpec(%q) = %+v, %v; want %+v", tt.spec, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ParseMemory_Cases(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    int64
+		wantErr bool
+	}{
+		{spec: "", want: 0},
+		{spec: "1048576", want: 1 << 20},
+		{spec: "512m", want: 512 << 20},
+		{spec: "4G", want: 4 << 30},
+		{spec: "1.5g", want: 3 << 29},
+		{spec: "64MB", want: 64 << 20},
+		{spec: "lots", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseMemory(tt.spec)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("ParseMemory(%q) = %d, %v; want %d (err=%v)", tt.spec, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_BuildCreateRequest_HostConfig(t *testing.T) {
+	req := buildCreateRequest(ContainerCreateConfig{
+		Image:         "jellyfin/jellyfin",
+		Entrypoint:    []string{"/init"},
+		RestartPolicy: RestartPolicy{Name: "unless-stopped"},
+		NetworkMode:   "br0",
+		IPv4Address:   "192.168.1.60",
+		Aliases:       []string{"jellyfin"},
+		PortBindings:  []PortMapping{{ContainerPort: 8096, HostIP: "192.168.1.10", HostPort: 8096}, {ContainerPort: 1900, Protocol: "udp"}},
+		Devices:       []DeviceMapping{{PathOnHost: "/dev/dri"}},
+		CapAdd:        []string{"NET_ADMIN"},
+		MemoryLimit:   2 << 30,
+		CPUs:          1.5,
+		CPUSet:        "2-5",
+		ExtraHosts:    []string{"nas:192.168.1.2"},
+	})
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var got struct {
+		Entrypoint []string
+		HostConfig struct {
+			RestartPolicy struct{ Name string }
+			NetworkMode   string
+			PortBindings  map[string][]struct{ HostIp, HostPort string }
+			Devices       []struct{ PathOnHost, PathInContainer, CgroupPermissions string }
+			CapAdd        []string
+			Memory        int64
+			NanoCpus      int64
+			CpusetCpus    string
+			ExtraHosts    []string
+		}
+		NetworkingConfig struct {
+			EndpointsConfig map[string]struct {
+				IPAMConfig struct{ IPv4Address string }
+				Aliases    []string
+			}
+		}
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	hc := got.HostConfig
+	if hc.RestartPolicy.Name != "unless-stopped" || hc.NetworkMode != "br0" {
+		t.Errorf("restart/network = %q/%q", hc.RestartPolicy.Name, hc.NetworkMode)
+	}
+	if b := hc.PortBindings["8096/tcp"]; len(b) != 1 || b[0].HostIp != "192.168.1.10" || b[0].HostPort != "8096" {
+		t.Errorf("8096/tcp binding = %+v", b)
+	}
+	if b := hc.PortBindings["1900/udp"]; len(b) != 1 || b[0].HostPort != "" {
+		t.Errorf("1900/udp binding = %+v", b)
+	}
+	if len(hc.Devices) != 1 || hc.Devices[0].PathInContainer != "/dev/dri" || hc.Devices[0].CgroupPermissions != "rwm" {
+		t.Errorf("Devices = %+v, want defaults filled in", hc.Devices)
+	}
+	if hc.Memory != 2<<30 || hc.NanoCpus != 1_500_000_000 || hc.CpusetCpus != "2-5" {
+		t.Errorf("limits = %d/%d/%q", hc.Memory, hc.NanoCpus, hc.CpusetCpus)
+	}
+	if ep := got.NetworkingConfig.EndpointsConfig["br0"]; ep.IPAMConfig.IPv4Address != "192.168.1.60" || len(ep.Aliases) != 1 {
+		t.Errorf("EndpointsConfig = %+v", got.NetworkingConfig.EndpointsConfig)
+	}
+	if len(got.Entrypoint) != 1 || len(hc.CapAdd) != 1 || len(hc.ExtraHosts) != 1 {
+		t.Errorf("entrypoint/caps/hosts not encoded: %s", data)
+	}
+}
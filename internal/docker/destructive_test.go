@@ -10,7 +10,7 @@ import (
 // ---------------------------------------------------------------------------
 
 func Test_DestructiveTools_Length(t *testing.T) {
-	const wantLen = 5
+	const wantLen = 15
 	if got := len(DestructiveTools); got != wantLen {
 		t.Errorf("len(DestructiveTools) = %d, want %d", got, wantLen)
 	}
@@ -22,7 +22,17 @@ func Test_DestructiveTools_ContainsExpectedNames(t *testing.T) {
 		"docker_restart",
 		"docker_remove",
 		"docker_create",
+		"docker_update",
 		"docker_network_remove",
+		"docker_image_remove",
+		"docker_image_prune",
+		"docker_volume_remove",
+		"docker_volume_prune",
+		"docker_template_create",
+		"docker_file_write",
+		"docker_bulk",
+		"docker_compose_action",
+		"docker_compose_up",
 	}
 
 	// Build a set from the actual variable for O(1) lookup.
@@ -42,11 +52,21 @@ func Test_DestructiveTools_ContainsExpectedNames(t *testing.T) {
 
 func Test_DestructiveTools_NoUnexpectedEntries(t *testing.T) {
 	expected := map[string]struct{}{
-		"docker_stop":           {},
-		"docker_restart":        {},
-		"docker_remove":         {},
-		"docker_create":         {},
-		"docker_network_remove": {},
+		"docker_stop":            {},
+		"docker_restart":         {},
+		"docker_remove":          {},
+		"docker_create":          {},
+		"docker_update":          {},
+		"docker_network_remove":  {},
+		"docker_image_remove":    {},
+		"docker_image_prune":     {},
+		"docker_volume_remove":   {},
+		"docker_volume_prune":    {},
+		"docker_template_create": {},
+		"docker_file_write":      {},
+		"docker_bulk":            {},
+		"docker_compose_action":  {},
+		"docker_compose_up":      {},
 	}
 
 	for _, name := range DestructiveTools {
@@ -59,11 +79,21 @@ func Test_DestructiveTools_NoUnexpectedEntries(t *testing.T) {
 func Test_DestructiveTools_ExactContents(t *testing.T) {
 	// Comprehensive check: sort both slices and compare element-by-element.
 	expected := []string{
+		"docker_bulk",
+		"docker_compose_action",
+		"docker_compose_up",
 		"docker_create",
+		"docker_file_write",
+		"docker_image_prune",
+		"docker_image_remove",
 		"docker_network_remove",
 		"docker_remove",
 		"docker_restart",
 		"docker_stop",
+		"docker_template_create",
+		"docker_update",
+		"docker_volume_prune",
+		"docker_volume_remove",
 	}
 
 	got := make([]string, len(DestructiveTools))
@@ -0,0 +1,198 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Container diagnosis
+// ---------------------------------------------------------------------------
+
+// crashLoopExits is the number of exits within the diagnosis window that is
+// reported as a crash loop.
+const crashLoopExits = 3
+
+// memoryPressurePercent is the memory usage, as a percentage of the limit,
+// above which a diagnosis reports memory pressure.
+const memoryPressurePercent = 90
+
+// diagnoseActions are the event actions considered by Diagnose.
+var diagnoseActions = []string{"start", "die", "oom", "restart", "kill", "health_status"}
+
+// Diagnosis is a structured verdict on a container's health, combining its
+// runtime state, recent events, logs and resource usage.
+type Diagnosis struct {
+	Container
+	RuntimeState
+	Verdict    string   // one-line summary, the most important finding
+	Findings   []string // all problems found, most important first
+	Window     string   // the period events and logs were taken from
+	Events     []Event  // lifecycle and health events in the window
+	RecentLogs []LogLine
+	Stats      *ContainerStats // nil unless the container is running
+	Warnings   []string        // data that could not be collected
+}
+
+// Diagnose builds a Diagnosis from the collected data. events must be the
+// container's events within window; logs and stats may be nil.
+func Diagnose(detail *ContainerDetail, events []Event, logs *ContainerLogs, stats *ContainerStats, window time.Duration) *Diagnosis {
+	d := &Diagnosis{
+		Container:    detail.Container,
+		RuntimeState: detail.RuntimeState,
+		Findings:     []string{},
+		Window:       formatWindow(window),
+		Events:       events,
+		RecentLogs:   []LogLine{},
+		Stats:        stats,
+	}
+	if d.Events == nil {
+		d.Events = []Event{}
+	}
+	if logs != nil {
+		d.RecentLogs = logs.Lines
+	}
+
+	var ooms, exits, unhealthy int
+	for _, e := range events {
+		switch {
+		case e.Action == "oom":
+			ooms++
+		case e.Action == "die":
+			exits++
+		case e.Action == "health_status: unhealthy":
+			unhealthy++
+		}
+	}
+
+	if ooms > 0 {
+		d.Findings = append(d.Findings, fmt.Sprintf("OOM killed %s in the last %s", times(ooms), d.Window))
+	} else if detail.OOMKilled {
+		d.Findings = append(d.Findings, fmt.Sprintf("last exit was an OOM kill (at %s)", detail.FinishedAt.Format(time.RFC3339)))
+	}
+
+	switch {
+	case detail.State == "restarting" || exits >= crashLoopExits:
+		n := exits
+		if n == 0 {
+			n = detail.RestartCount
+		}
+		d.Findings = append(d.Findings, fmt.Sprintf("crash loop: exited %s in the last %s, last exit code %d (%s)",
+			times(n), d.Window, detail.ExitCode, exitCodeMeaning(detail.ExitCode)))
+	case (detail.State == "exited" || detail.State == "dead") && detail.ExitCode != 0:
+		d.Findings = append(d.Findings, fmt.Sprintf("exited with code %d (%s) at %s",
+			detail.ExitCode, exitCodeMeaning(detail.ExitCode), detail.FinishedAt.Format(time.RFC3339)))
+	case detail.RestartCount > 0:
+		d.Findings = append(d.Findings, fmt.Sprintf("restarted %s by the %q restart policy since it was started",
+			times(detail.RestartCount), detail.RestartPolicy.Name))
+	}
+
+	if detail.Error != "" {
+		d.Findings = append(d.Findings, "daemon error: "+detail.Error)
+	}
+
+	if hc := detail.Healthcheck; hc != nil {
+		switch hc.Status {
+		case "unhealthy":
+			finding := fmt.Sprintf("healthcheck failing (%d consecutive failures)", hc.FailingStreak)
+			if out := lastHealthOutput(hc); out != "" {
+				finding += ": " + out
+			}
+			d.Findings = append(d.Findings, finding)
+		case "healthy":
+			if unhealthy > 0 {
+				d.Findings = append(d.Findings, fmt.Sprintf("healthcheck went unhealthy %s in the last %s but is healthy now", times(unhealthy), d.Window))
+			}
+		}
+	}
+
+	if stats != nil && stats.MemoryLimit > 0 {
+		pct := float64(stats.MemoryUsage) / float64(stats.MemoryLimit) * 100
+		if pct >= memoryPressurePercent {
+			d.Findings = append(d.Findings, fmt.Sprintf("memory at %.0f%% of its %d MiB limit", pct, stats.MemoryLimit/(1024*1024)))
+		}
+	}
+
+	if detail.State == "paused" {
+		d.Findings = append(d.Findings, "container is paused")
+	}
+
+	switch {
+	case len(d.Findings) > 0:
+		d.Verdict = d.Findings[0]
+	case detail.State == "running" && detail.Health == "healthy":
+		d.Verdict = "running and healthy"
+	case detail.State == "running":
+		d.Verdict = "running normally"
+	case detail.State == "created":
+		d.Verdict = "created but never started"
+	default:
+		d.Verdict = fmt.Sprintf("%s with exit code %d", detail.State, detail.ExitCode)
+	}
+	return d
+}
+
+// exitCodeMeaning explains common container exit codes.
+func exitCodeMeaning(code int) string {
+	switch code {
+	case 0:
+		return "success"
+	case 1:
+		return "application error"
+	case 125:
+		return "docker failed to run the container"
+	case 126:
+		return "command not executable"
+	case 127:
+		return "command not found"
+	case 137:
+		return "killed by SIGKILL, often the OOM killer or a forced stop"
+	case 139:
+		return "segmentation fault"
+	case 143:
+		return "terminated by SIGTERM"
+	}
+	if code > 128 && code < 160 {
+		return fmt.Sprintf("killed by signal %d", code-128)
+	}
+	return "application error"
+}
+
+// lastHealthOutput returns the first line of the most recent failing probe.
+func lastHealthOutput(hc *HealthState) string {
+	for i := len(hc.Log) - 1; i >= 0; i-- {
+		if hc.Log[i].ExitCode != 0 {
+			line, _, _ := strings.Cut(hc.Log[i].Output, "\n")
+			return strings.TrimSpace(line)
+		}
+	}
+	return ""
+}
+
+// times renders a count as "once", "twice" or "N times".
+func times(n int) string {
+	switch n {
+	case 1:
+		return "once"
+	case 2:
+		return "twice"
+	}
+	return fmt.Sprintf("%d times", n)
+}
+
+// formatWindow renders a duration as "hour", "15 minutes" and so on.
+func formatWindow(d time.Duration) string {
+	switch {
+	case d == time.Hour:
+		return "hour"
+	case d > time.Hour && d%time.Hour == 0:
+		return fmt.Sprintf("%d hours", d/time.Hour)
+	case d == time.Minute:
+		return "minute"
+	case d > time.Minute && d%time.Minute == 0:
+		return fmt.Sprintf("%d minutes", d/time.Minute)
+	}
+	return d.String()
+}
@@ -0,0 +1,246 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+)
+
+// ---------------------------------------------------------------------------
+// Runtime state from the Engine API
+// ---------------------------------------------------------------------------
+
+func Test_InspectContainer_RuntimeState(t *testing.T) {
+	var probes []string
+	for i := 0; i < 7; i++ {
+		probes = append(probes, fmt.Sprintf(`{"Start":"2026-01-01T00:00:0%dZ","End":"2026-01-01T00:00:0%dZ","ExitCode":1,"Output":"probe %d: connection refused\n"}`, i, i, i))
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /containers/app/json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, `{
+			"Id":"app123","Name":"/app","Created":"2026-01-01T00:00:00Z",
+			"State":{"Status":"restarting","OOMKilled":true,"ExitCode":137,"Error":"",
+				"StartedAt":"2026-01-02T00:00:00Z","FinishedAt":"2026-01-02T00:05:00Z",
+				"Health":{"Status":"unhealthy","FailingStreak":7,"Log":[`+strings.Join(probes, ",")+`]}},
+			"RestartCount":4,
+			"HostConfig":{"RestartPolicy":{"Name":"on-failure","MaximumRetryCount":5}},
+			"Config":{"Image":"app:latest"}}`)
+	})
+	mux.HandleFunc("GET /containers/plain/json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, `{"Id":"plain","Name":"/plain","State":{"Status":"exited","FinishedAt":"0001-01-01T00:00:00Z"},"Config":{}}`)
+	})
+	mgr := newFakeDaemon(t, mux)
+
+	detail, err := mgr.InspectContainer(context.Background(), "app")
+	if err != nil {
+		t.Fatalf("InspectContainer() error = %v", err)
+	}
+	if !detail.OOMKilled || detail.ExitCode != 137 || detail.RestartCount != 4 || detail.Health != "unhealthy" {
+		t.Errorf("state = %+v", detail.RuntimeState)
+	}
+	if detail.RestartPolicy != (RestartPolicy{Name: "on-failure", MaximumRetryCount: 5}) {
+		t.Errorf("RestartPolicy = %+v", detail.RestartPolicy)
+	}
+	if !detail.FinishedAt.Equal(time.Date(2026, 1, 2, 0, 5, 0, 0, time.UTC)) {
+		t.Errorf("FinishedAt = %v", detail.FinishedAt)
+	}
+	hc := detail.Healthcheck
+	if hc == nil || hc.FailingStreak != 7 || len(hc.Log) != maxHealthLogEntries {
+		t.Fatalf("Healthcheck = %+v, want the last %d probes", hc, maxHealthLogEntries)
+	}
+	if hc.Log[len(hc.Log)-1].Output != "probe 6: connection refused" {
+		t.Errorf("last probe output = %q", hc.Log[len(hc.Log)-1].Output)
+	}
+
+	detail, err = mgr.InspectContainer(context.Background(), "plain")
+	if err != nil {
+		t.Fatalf("InspectContainer(plain) error = %v", err)
+	}
+	if detail.Healthcheck != nil || detail.Health != "" || !detail.FinishedAt.IsZero() {
+		t.Errorf("plain container state = %+v", detail.RuntimeState)
+	}
+}
+
+func Test_TruncateUTF8(t *testing.T) {
+	tests := []struct {
+		in   string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"abcdef", 3, "abc..."},
+		{"ab€cd", 3, "ab..."}, // "€" is 3 bytes; cutting at 3 would split it
+		{"ab€cd", 5, "ab€..."},
+	}
+	for _, tt := range tests {
+		got := truncateUTF8(tt.in, tt.n)
+		if got != tt.want || !utf8.ValidString(got) {
+			t.Errorf("truncateUTF8(%q, %d) = %q, want %q", tt.in, tt.n, got, tt.want)
+		}
+	}
+}
+
+func Test_HealthFromStatus(t *testing.T) {
+	tests := map[string]string{
+		"Up 2 hours (healthy)":            "healthy",
+		"Up 2 hours (unhealthy)":          "unhealthy",
+		"Up 3 seconds (health: starting)": "starting",
+		"Up 2 hours":                      "",
+		"Exited (0) 5 minutes ago":        "",
+	}
+	for status, want := range tests {
+		if got := healthFromStatus(status); got != want {
+			t.Errorf("healthFromStatus(%q) = %q, want %q", status, got, want)
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Diagnose
+// ---------------------------------------------------------------------------
+
+func Test_Diagnose_Verdicts(t *testing.T) {
+	now := time.Now()
+	die := func(code string) Event {
+		return Event{Time: now, Type: "container", Action: "die", Attributes: map[string]string{"exitCode": code}}
+	}
+	oom := Event{Time: now, Type: "container", Action: "oom"}
+
+	tests := []struct {
+		name        string
+		detail      ContainerDetail
+		events      []Event
+		stats       *ContainerStats
+		wantVerdict string
+	}{
+		{
+			name:        "healthy",
+			detail:      ContainerDetail{Container: Container{State: "running", Health: "healthy"}},
+			wantVerdict: "running and healthy",
+		},
+		{
+			name:        "repeated OOM kills",
+			detail:      ContainerDetail{Container: Container{State: "restarting"}, RuntimeState: RuntimeState{OOMKilled: true, ExitCode: 137}},
+			events:      []Event{oom, die("137"), oom, die("137"), oom, die("137")},
+			wantVerdict: "OOM killed 3 times in the last hour",
+		},
+		{
+			name:        "crash loop",
+			detail:      ContainerDetail{Container: Container{State: "restarting"}, RuntimeState: RuntimeState{ExitCode: 127}},
+			events:      []Event{die("127"), die("127")},
+			wantVerdict: "crash loop: exited twice in the last hour, last exit code 127 (command not found)",
+		},
+		{
+			name: "failing healthcheck",
+			detail: ContainerDetail{Container: Container{State: "running", Health: "unhealthy"}, RuntimeState: RuntimeState{
+				Healthcheck: &HealthState{Status: "unhealthy", FailingStreak: 3, Log: []HealthLogEntry{
+					{ExitCode: 1, Output: "curl: (7) connection refused\nmore"},
+					{ExitCode: 0, Output: "ok"},
+				}},
+			}},
+			wantVerdict: "healthcheck failing (3 consecutive failures): curl: (7) connection refused",
+		},
+		{
+			name:        "exited with error",
+			detail:      ContainerDetail{Container: Container{State: "exited"}, RuntimeState: RuntimeState{ExitCode: 1, FinishedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}},
+			wantVerdict: "exited with code 1 (application error) at 2026-01-01T00:00:00Z",
+		},
+		{
+			name:        "memory pressure",
+			detail:      ContainerDetail{Container: Container{State: "running"}},
+			stats:       &ContainerStats{MemoryUsage: 500 << 20, MemoryLimit: 512 << 20},
+			wantVerdict: "memory at 98% of its 512 MiB limit",
+		},
+		{
+			name:        "clean stop",
+			detail:      ContainerDetail{Container: Container{State: "exited"}},
+			wantVerdict: "exited with exit code 0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Diagnose(&tt.detail, tt.events, nil, tt.stats, time.Hour)
+			if d.Verdict != tt.wantVerdict {
+				t.Errorf("Verdict = %q, want %q (findings %v)", d.Verdict, tt.wantVerdict, d.Findings)
+			}
+		})
+	}
+}
+
+func Test_ExitCodeMeaning(t *testing.T) {
+	tests := map[int]string{
+		137: "killed by SIGKILL, often the OOM killer or a forced stop",
+		130: "killed by signal 2",
+		42:  "application error",
+	}
+	for code, want := range tests {
+		if got := exitCodeMeaning(code); got != want {
+			t.Errorf("exitCodeMeaning(%d) = %q, want %q", code, got, want)
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// docker_diagnose and docker_list details
+// ---------------------------------------------------------------------------
+
+func Test_DockerDiagnoseTool(t *testing.T) {
+	mgr := newPopulatedMock(t)
+	detail, _ := mgr.InspectContainer(context.Background(), "abc123")
+	detail.State = "restarting"
+	detail.ExitCode = 137
+	detail.OOMKilled = true
+
+	history := NewEventHistory(10)
+	history.Add(Event{Time: time.Now().Add(-3 * time.Hour), Type: "container", Action: "oom", ActorID: "abc123", ActorName: "plex"})
+	history.Add(Event{Time: time.Now().Add(-time.Minute), Type: "container", Action: "oom", ActorID: "abc123", ActorName: "plex"})
+	history.Add(Event{Time: time.Now().Add(-time.Minute), Type: "container", Action: "oom", ActorID: "def456", ActorName: "sonarr"})
+
+	regs := DiagnoseTools(mgr, history, safety.NewFilter(nil, []string{"sonarr"}), nil)
+
+	text := callTool(t, regs, "docker_diagnose", map[string]any{"id": "abc123"})
+	var d Diagnosis
+	if err := json.Unmarshal([]byte(text), &d); err != nil {
+		t.Fatalf("decode result: %v\n%s", err, text)
+	}
+	if d.Verdict != "OOM killed once in the last hour" || len(d.Events) != 1 {
+		t.Errorf("diagnosis = %+v", d)
+	}
+
+	text = callTool(t, regs, "docker_diagnose", map[string]any{"id": "abc123", "window": "-5m"})
+	if !strings.Contains(text, "invalid window") {
+		t.Errorf("bad window = %s, want error", text)
+	}
+	text = callTool(t, regs, "docker_diagnose", map[string]any{"id": "def456"})
+	if !strings.Contains(text, "not allowed") {
+		t.Errorf("denied container by ID = %s, want not allowed", text)
+	}
+}
+
+func Test_DockerList_Details(t *testing.T) {
+	mgr, regs := newToolsUnderTest(t)
+	detail, _ := mgr.InspectContainer(context.Background(), "abc123")
+	detail.RestartCount = 2
+	detail.RestartPolicy = RestartPolicy{Name: "unless-stopped"}
+
+	text := callTool(t, regs, "docker_list", map[string]any{"all": true, "details": true})
+	var got []ContainerSummary
+	if err := json.Unmarshal([]byte(text), &got); err != nil {
+		t.Fatalf("decode result: %v\n%s", err, text)
+	}
+	for _, c := range got {
+		if c.ID == "abc123" && (c.RestartCount != 2 || c.RestartPolicy.Name != "unless-stopped") {
+			t.Errorf("plex summary = %+v", c)
+		}
+	}
+	if len(got) != 3 {
+		t.Errorf("got %d containers, want 3", len(got))
+	}
+}
@@ -0,0 +1,120 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+	"github.com/jamesprial/unraid-mcp/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Defaults for docker_diagnose.
+const (
+	defaultDiagnoseWindow   = time.Hour
+	defaultDiagnoseLogLines = 20
+	maxDiagnoseLogBytes     = 16 * 1024
+)
+
+// DiagnoseTools returns the docker_diagnose tool registration. history may be
+// nil, in which case the diagnosis is based on the container state alone.
+func DiagnoseTools(mgr DockerManager, history *EventHistory, filter *safety.Filter, audit *safety.AuditLogger) []tools.Registration {
+	return []tools.Registration{
+		toolDockerDiagnose(mgr, history, filter, audit),
+	}
+}
+
+func toolDockerDiagnose(mgr DockerManager, history *EventHistory, filter *safety.Filter, audit *safety.AuditLogger) tools.Registration {
+	tool := mcp.NewTool("docker_diagnose",
+		mcp.WithDescription("Diagnose a container that is crashing, restarting or unhealthy. Combines its state, restart count, "+
+			"healthcheck results, recent events, recent logs and resource usage into a verdict such as "+
+			"\"OOM killed 3 times in the last hour\" or \"healthcheck failing: connection refused\"."),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("Container ID or name"),
+		),
+		mcp.WithString("window",
+			mcp.Description("How far back to look at events and logs, as a duration (default 1h)"),
+		),
+		mcp.WithNumber("log_lines",
+			mcp.Description(fmt.Sprintf("Number of recent log lines to include (default %d)", defaultDiagnoseLogLines)),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		id := req.GetString("id", "")
+		windowArg := req.GetString("window", "")
+		logLines := req.GetInt("log_lines", defaultDiagnoseLogLines)
+		params := map[string]any{"id": id, "window": windowArg, "log_lines": logLines}
+
+		if !filter.IsAllowed(id) {
+			tools.LogAudit(audit, "docker_diagnose", params, "denied", start)
+			return tools.ErrorResult(fmt.Sprintf("access to container %q is not allowed", id)), nil
+		}
+
+		window := defaultDiagnoseWindow
+		if windowArg != "" {
+			d, err := time.ParseDuration(windowArg)
+			if err != nil || d <= 0 {
+				return tools.ErrorResult(fmt.Sprintf("invalid window %q: want a positive duration like 30m or 6h", windowArg)), nil
+			}
+			window = d
+		}
+		if logLines < 0 {
+			logLines = defaultDiagnoseLogLines
+		}
+
+		detail, err := mgr.InspectContainer(ctx, id)
+		if err != nil {
+			tools.LogAudit(audit, "docker_diagnose", params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+		// The caller may have passed an ID; check the filter against the name too.
+		if !filter.IsAllowed(detail.Name) {
+			tools.LogAudit(audit, "docker_diagnose", params, "denied", start)
+			return tools.ErrorResult(fmt.Sprintf("access to container %q is not allowed", id)), nil
+		}
+
+		since := start.Add(-window)
+		var warnings []string
+
+		var events []Event
+		if history != nil {
+			events = history.Query(EventQuery{Container: detail.ID, Actions: diagnoseActions, Since: since})
+			if connected, lastErr := history.Status(); !connected && lastErr != "" {
+				warnings = append(warnings, "event stream disconnected, events may be incomplete: "+lastErr)
+			}
+		} else {
+			warnings = append(warnings, "event history is not available; counts are based on the container state only")
+		}
+
+		var logs *ContainerLogs
+		if logLines > 0 {
+			logs, err = mgr.GetContainerLogs(ctx, detail.ID, LogOptions{
+				Tail: logLines, Since: since, Stdout: true, Stderr: true, MaxBytes: maxDiagnoseLogBytes,
+			})
+			if err != nil {
+				warnings = append(warnings, "could not read logs: "+err.Error())
+			}
+		}
+
+		var stats *ContainerStats
+		if detail.State == "running" {
+			if stats, err = mgr.GetStats(ctx, detail.ID); err != nil {
+				warnings = append(warnings, "could not read stats: "+err.Error())
+			}
+		}
+
+		diagnosis := Diagnose(detail, events, logs, stats, window)
+		diagnosis.Warnings = warnings
+
+		tools.LogAudit(audit, "docker_diagnose", params, "ok", start)
+		return tools.JSONResult(diagnosis), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
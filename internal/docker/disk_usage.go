@@ -0,0 +1,160 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/jamesprial/unraid-mcp/internal/system"
+	"github.com/jamesprial/unraid-mcp/internal/tools"
+)
+
+// ---------------------------------------------------------------------------
+// Disk usage report
+// ---------------------------------------------------------------------------
+
+// Thresholds for disk usage findings.
+const (
+	dockerImageFullPercent  = 90
+	btrfsAllocatedPercent   = 95
+	largeWritableLayerBytes = 1 << 30
+)
+
+// DiskUsageSummary totals one kind of Docker data, like docker system df.
+type DiskUsageSummary struct {
+	Type        string // "images", "containers", "volumes" or "build_cache"
+	Count       int
+	Active      int
+	Size        int64
+	Reclaimable int64
+}
+
+// DiskUsageReport breaks down Docker's disk usage and relates it to the
+// capacity of docker.img.
+type DiskUsageReport struct {
+	Summary           []DiskUsageSummary
+	TotalSize         int64
+	TotalReclaimable  int64
+	LargestImages     []ImageDiskUsage
+	LargestContainers []ContainerDiskUsage // by writable layer
+	LargestVolumes    []VolumeDiskUsage
+	DockerImage       *system.DockerImageUsage // nil if it could not be read
+	Findings          []string
+	Warnings          []string
+}
+
+// BuildDiskUsageReport summarises du, lists the top largest items of each
+// kind and flags the usual causes of a full docker.img. img may be nil.
+func BuildDiskUsageReport(du *DiskUsage, img *system.DockerImageUsage, top int) *DiskUsageReport {
+	r := &DiskUsageReport{DockerImage: img, Findings: []string{}}
+
+	// Images: as docker system df, the reclaimable space is everything not
+	// held by an image some container uses, counting shared layers once.
+	images := DiskUsageSummary{Type: "images", Count: len(du.Images), Size: du.LayersSize}
+	var imagesInUse int64
+	for _, i := range du.Images {
+		if i.Containers > 0 {
+			images.Active++
+			if i.Size >= 0 && i.SharedSize >= 0 {
+				imagesInUse += i.Size - i.SharedSize
+			}
+		}
+	}
+	images.Reclaimable = max(images.Size-imagesInUse, 0)
+
+	containers := DiskUsageSummary{Type: "containers", Count: len(du.Containers)}
+	for _, c := range du.Containers {
+		if c.SizeRw < 0 {
+			continue
+		}
+		containers.Size += c.SizeRw
+		if c.State == "running" {
+			containers.Active++
+		} else {
+			containers.Reclaimable += c.SizeRw
+		}
+	}
+
+	volumes := DiskUsageSummary{Type: "volumes", Count: len(du.Volumes)}
+	for _, v := range du.Volumes {
+		if v.RefCount > 0 {
+			volumes.Active++
+		}
+		if v.Size < 0 {
+			continue
+		}
+		volumes.Size += v.Size
+		if v.RefCount == 0 {
+			volumes.Reclaimable += v.Size
+		}
+	}
+
+	cache := DiskUsageSummary{Type: "build_cache", Count: len(du.BuildCache)}
+	for _, b := range du.BuildCache {
+		if b.InUse {
+			cache.Active++
+		}
+		if !b.Shared {
+			cache.Size += b.Size
+			if !b.InUse {
+				cache.Reclaimable += b.Size
+			}
+		}
+	}
+
+	r.Summary = []DiskUsageSummary{images, containers, volumes, cache}
+	for _, s := range r.Summary {
+		r.TotalSize += s.Size
+		r.TotalReclaimable += s.Reclaimable
+	}
+
+	r.LargestImages = append([]ImageDiskUsage(nil), du.Images...)
+	sort.SliceStable(r.LargestImages, func(i, j int) bool { return r.LargestImages[i].Size > r.LargestImages[j].Size })
+	r.LargestImages = r.LargestImages[:min(top, len(r.LargestImages))]
+
+	r.LargestContainers = append([]ContainerDiskUsage(nil), du.Containers...)
+	sort.SliceStable(r.LargestContainers, func(i, j int) bool { return r.LargestContainers[i].SizeRw > r.LargestContainers[j].SizeRw })
+	r.LargestContainers = r.LargestContainers[:min(top, len(r.LargestContainers))]
+
+	r.LargestVolumes = append([]VolumeDiskUsage(nil), du.Volumes...)
+	sort.SliceStable(r.LargestVolumes, func(i, j int) bool { return r.LargestVolumes[i].Size > r.LargestVolumes[j].Size })
+	r.LargestVolumes = r.LargestVolumes[:min(top, len(r.LargestVolumes))]
+
+	if img != nil && img.Loop && img.TotalBytes > 0 {
+		if img.UsedBytes > 0 {
+			verdict := "docker.img is"
+			if img.UsedPercent >= dockerImageFullPercent {
+				verdict = "docker.img is nearly full:"
+			}
+			r.Findings = append(r.Findings, fmt.Sprintf("%s %.0f%% used (%s of %s)",
+				verdict, img.UsedPercent, tools.FormatBytes(img.UsedBytes), tools.FormatBytes(img.TotalBytes)))
+		}
+		if img.FsType == "btrfs" && float64(img.AllocatedBytes) >= float64(img.TotalBytes)*btrfsAllocatedPercent/100 {
+			r.Findings = append(r.Findings, fmt.Sprintf("btrfs has allocated %s of %s in chunks; writes can fail with \"no space left on device\" before usage reaches 100%% (a balance frees partly used chunks)",
+				tools.FormatBytes(img.AllocatedBytes), tools.FormatBytes(img.TotalBytes)))
+		}
+	}
+
+	for _, c := range r.LargestContainers {
+		if c.SizeRw >= largeWritableLayerBytes {
+			r.Findings = append(r.Findings, fmt.Sprintf("container %s has written %s into its writable layer; data written to a path that is not mapped to the host fills docker.img",
+				c.Name, tools.FormatBytes(c.SizeRw)))
+		}
+	}
+
+	reclaim := []struct {
+		size int64
+		what string
+	}{
+		{images.Reclaimable, "unused images (docker_image_prune with all)"},
+		{containers.Reclaimable, "stopped containers' writable layers (docker_remove)"},
+		{volumes.Reclaimable, "unused volumes (docker_volume_prune)"},
+		{cache.Reclaimable, "unused build cache"},
+	}
+	for _, rc := range reclaim {
+		if rc.size > 0 {
+			r.Findings = append(r.Findings, fmt.Sprintf("%s reclaimable from %s", tools.FormatBytes(rc.size), rc.what))
+		}
+	}
+	return r
+}
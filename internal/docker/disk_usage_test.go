@@ -0,0 +1,175 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+	"github.com/jamesprial/unraid-mcp/internal/system"
+)
+
+// ---------------------------------------------------------------------------
+// DiskUsage parsing
+// ---------------------------------------------------------------------------
+
+func Test_DockerClientManager_DiskUsage_Parsing(t *testing.T) {
+	mgr := newFakeDaemon(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/system/df") {
+			t.Errorf("path = %s, want /system/df", r.URL.Path)
+		}
+		writeJSON(w, http.StatusOK, `{
+			"LayersSize": 3000,
+			"Images": [{"Id": "sha256:aaa", "RepoTags": ["plex:latest"], "Size": 2000, "SharedSize": 500, "Containers": 1}],
+			"Containers": [{"Id": "abc", "Names": ["/plex"], "Image": "plex:latest", "State": "running", "SizeRw": 10, "SizeRootFs": 2010}],
+			"Volumes": [
+				{"Name": "data", "Driver": "local", "UsageData": {"Size": 100, "RefCount": 2}},
+				{"Name": "unsized", "Driver": "local"}
+			],
+			"BuildCache": [{"ID": "b1", "Type": "regular", "Size": 50, "InUse": false, "Shared": false}]
+		}`)
+	}))
+
+	du, err := mgr.DiskUsage(t.Context())
+	if err != nil {
+		t.Fatalf("DiskUsage() error = %v", err)
+	}
+	if du.LayersSize != 3000 || len(du.Images) != 1 || du.Images[0].SharedSize != 500 {
+		t.Errorf("images = %d %+v", du.LayersSize, du.Images)
+	}
+	if len(du.Containers) != 1 || du.Containers[0].Name != "plex" || du.Containers[0].SizeRw != 10 {
+		t.Errorf("containers = %+v", du.Containers)
+	}
+	if len(du.Volumes) != 2 || du.Volumes[0].RefCount != 2 || du.Volumes[1].Size != -1 || du.Volumes[1].RefCount != -1 {
+		t.Errorf("volumes = %+v", du.Volumes)
+	}
+	if len(du.BuildCache) != 1 || du.BuildCache[0].Size != 50 {
+		t.Errorf("build cache = %+v", du.BuildCache)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Report
+// ---------------------------------------------------------------------------
+
+func Test_BuildDiskUsageReport(t *testing.T) {
+	du := &DiskUsage{
+		LayersSize: 10 << 30,
+		Images: []ImageDiskUsage{
+			{ID: "used", Size: 4 << 30, SharedSize: 1 << 30, Containers: 1},
+			{ID: "unused", Size: 6 << 30, SharedSize: 1 << 30},
+		},
+		Containers: []ContainerDiskUsage{
+			{Name: "plex", State: "running", SizeRw: 2 << 30},
+			{Name: "old", State: "exited", SizeRw: 1 << 20},
+		},
+		Volumes: []VolumeDiskUsage{
+			{Name: "data", Size: 100, RefCount: 1},
+			{Name: "orphan", Size: 200, RefCount: 0},
+			{Name: "unsized", Size: -1, RefCount: -1},
+		},
+		BuildCache: []BuildCacheDiskUsage{
+			{Size: 30, InUse: true},
+			{Size: 40},
+			{Size: 99, Shared: true},
+		},
+	}
+	img := &system.DockerImageUsage{
+		FsType: "btrfs", Loop: true,
+		TotalBytes: 20 << 30, UsedBytes: 19 << 30, AllocatedBytes: 20 << 30, UsedPercent: 95,
+	}
+
+	r := BuildDiskUsageReport(du, img, 1)
+
+	want := map[string]DiskUsageSummary{
+		"images":      {Type: "images", Count: 2, Active: 1, Size: 10 << 30, Reclaimable: 7 << 30},
+		"containers":  {Type: "containers", Count: 2, Active: 1, Size: 2<<30 + 1<<20, Reclaimable: 1 << 20},
+		"volumes":     {Type: "volumes", Count: 3, Active: 1, Size: 300, Reclaimable: 200},
+		"build_cache": {Type: "build_cache", Count: 3, Active: 1, Size: 70, Reclaimable: 40},
+	}
+	for _, s := range r.Summary {
+		if s != want[s.Type] {
+			t.Errorf("summary %s = %+v, want %+v", s.Type, s, want[s.Type])
+		}
+	}
+	if r.TotalReclaimable != 7<<30+1<<20+240 {
+		t.Errorf("TotalReclaimable = %d", r.TotalReclaimable)
+	}
+	if len(r.LargestImages) != 1 || r.LargestImages[0].ID != "unused" {
+		t.Errorf("LargestImages = %+v, want unused only", r.LargestImages)
+	}
+	if len(r.LargestContainers) != 1 || r.LargestContainers[0].Name != "plex" {
+		t.Errorf("LargestContainers = %+v, want plex only", r.LargestContainers)
+	}
+	if len(r.LargestVolumes) != 1 || r.LargestVolumes[0].Name != "orphan" {
+		t.Errorf("LargestVolumes = %+v, want orphan only", r.LargestVolumes)
+	}
+
+	findings := strings.Join(r.Findings, "\n")
+	for _, want := range []string{
+		"docker.img is nearly full: 95% used (19 GiB of 20 GiB)",
+		"btrfs has allocated 20 GiB of 20 GiB",
+		"container plex has written 2 GiB",
+		"7 GiB reclaimable from unused images",
+		"1 MiB reclaimable from stopped containers",
+		"200 B reclaimable from unused volumes",
+		"40 B reclaimable from unused build cache",
+	} {
+		if !strings.Contains(findings, want) {
+			t.Errorf("findings missing %q:\n%s", want, findings)
+		}
+	}
+}
+
+func Test_BuildDiskUsageReport_NoDockerImage(t *testing.T) {
+	r := BuildDiskUsageReport(&DiskUsage{}, nil, 5)
+	if r.TotalSize != 0 || len(r.Findings) != 0 || r.DockerImage != nil {
+		t.Errorf("empty report = %+v", r)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Tool
+// ---------------------------------------------------------------------------
+
+type fakeImageUsage struct {
+	usage *system.DockerImageUsage
+	err   error
+}
+
+func (f fakeImageUsage) GetDockerImageUsage(context.Context) (*system.DockerImageUsage, error) {
+	return f.usage, f.err
+}
+
+func Test_DiskUsageTools(t *testing.T) {
+	mgr := newPopulatedMock(t)
+	mgr.SetDiskUsage(&DiskUsage{
+		Containers: []ContainerDiskUsage{
+			{Name: "plex", State: "running", SizeRw: 10},
+			{Name: "secret", State: "exited", SizeRw: 5},
+		},
+		Volumes: []VolumeDiskUsage{{Name: "secret", Size: 7}},
+	})
+	filter := safety.NewFilter(nil, []string{"secret"})
+
+	regs := DiskUsageTools(mgr, fakeImageUsage{usage: &system.DockerImageUsage{Loop: true, TotalBytes: 100, UsedBytes: 10, UsedPercent: 10}}, filter, nil)
+	text := callTool(t, regs, "docker_disk_usage", nil)
+	var report DiskUsageReport
+	if err := json.Unmarshal([]byte(text), &report); err != nil {
+		t.Fatalf("decode result: %v\n%s", err, text)
+	}
+	if strings.Contains(text, "secret") {
+		t.Errorf("result names a denied container or volume:\n%s", text)
+	}
+	if report.DockerImage == nil || report.DockerImage.TotalBytes != 100 {
+		t.Errorf("DockerImage = %+v", report.DockerImage)
+	}
+
+	regs = DiskUsageTools(mgr, fakeImageUsage{err: errors.New("not mounted")}, filter, nil)
+	if text := callTool(t, regs, "docker_disk_usage", nil); !strings.Contains(text, "could not read docker.img usage: not mounted") {
+		t.Errorf("docker_disk_usage = %s, want docker.img warning", text)
+	}
+}
@@ -0,0 +1,91 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+	"github.com/jamesprial/unraid-mcp/internal/system"
+	"github.com/jamesprial/unraid-mcp/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// defaultDiskUsageTop is how many of the largest items of each kind
+// docker_disk_usage lists.
+const defaultDiskUsageTop = 5
+
+// DockerImageUsageSource reports the capacity of docker.img.
+// system.FileSystemMonitor implements it.
+type DockerImageUsageSource interface {
+	GetDockerImageUsage(ctx context.Context) (*system.DockerImageUsage, error)
+}
+
+// DiskUsageTools returns the docker_disk_usage tool registration. images may
+// be nil, in which case docker.img capacity is not reported.
+func DiskUsageTools(mgr DockerManager, images DockerImageUsageSource, filter *safety.Filter, audit *safety.AuditLogger) []tools.Registration {
+	return []tools.Registration{
+		toolDockerDiskUsage(mgr, images, filter, audit),
+	}
+}
+
+func toolDockerDiskUsage(mgr DockerManager, images DockerImageUsageSource, filter *safety.Filter, audit *safety.AuditLogger) tools.Registration {
+	tool := mcp.NewTool("docker_disk_usage",
+		mcp.WithDescription("Report Docker's disk usage like docker system df: images, container writable layers, volumes and build cache, "+
+			"with the reclaimable space of each and the largest consumers. Also reports how full the loop-mounted docker.img is "+
+			"and flags the usual causes of it filling up, such as containers writing to unmapped paths. "+
+			"Sizing volumes can take a while on large installations."),
+		mcp.WithNumber("top",
+			mcp.Description(fmt.Sprintf("How many of the largest images, containers and volumes to list (default %d)", defaultDiskUsageTop)),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		top := req.GetInt("top", defaultDiskUsageTop)
+		params := map[string]any{"top": top}
+		if top <= 0 {
+			top = defaultDiskUsageTop
+		}
+
+		du, err := mgr.DiskUsage(ctx)
+		if err != nil {
+			tools.LogAudit(audit, "docker_disk_usage", params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+
+		// Denied containers and volumes are left out entirely, as in
+		// docker_list.
+		visible := *du
+		visible.Containers = nil
+		for _, c := range du.Containers {
+			if filter.IsAllowed(c.Name) {
+				visible.Containers = append(visible.Containers, c)
+			}
+		}
+		visible.Volumes = nil
+		for _, v := range du.Volumes {
+			if filter.IsAllowed(v.Name) {
+				visible.Volumes = append(visible.Volumes, v)
+			}
+		}
+
+		var img *system.DockerImageUsage
+		var warnings []string
+		if images != nil {
+			if img, err = images.GetDockerImageUsage(ctx); err != nil {
+				warnings = append(warnings, "could not read docker.img usage: "+err.Error())
+			}
+		}
+
+		report := BuildDiskUsageReport(&visible, img, top)
+		report.Warnings = warnings
+
+		tools.LogAudit(audit, "docker_disk_usage", params, "ok", start)
+		return tools.JSONResult(report), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
@@ -0,0 +1,115 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+	"github.com/jamesprial/unraid-mcp/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// defaultEventLimit is the number of events docker_events returns when no
+// limit is given.
+const defaultEventLimit = 100
+
+// EventsResult is the result of the docker_events tool.
+type EventsResult struct {
+	Connected bool
+	LastError string
+	Events    []Event
+}
+
+// EventTools returns the tool registrations backed by the event history that
+// an EventWatcher fills in the background.
+func EventTools(history *EventHistory, filter *safety.Filter, audit *safety.AuditLogger) []tools.Registration {
+	return []tools.Registration{
+		toolDockerEvents(history, filter, audit),
+	}
+}
+
+func toolDockerEvents(history *EventHistory, filter *safety.Filter, audit *safety.AuditLogger) tools.Registration {
+	tool := mcp.NewTool("docker_events",
+		mcp.WithDescription("Query recent Docker daemon events recorded since the server started: container starts, stops, crashes (die with exitCode), "+
+			"OOM kills, restarts, health status changes, and image, network and volume events. Events are returned oldest first."),
+		mcp.WithString("container",
+			mcp.Description("Only return events for this container name or ID prefix"),
+		),
+		mcp.WithArray("types",
+			mcp.Description("Only return these event types (e.g. container, image, network, volume)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithArray("actions",
+			mcp.Description("Only return these actions (e.g. die, oom, restart, health_status)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithString("since",
+			mcp.Description("Only return events at or after this time: RFC 3339 or a duration ago such as 15m or 2h"),
+		),
+		mcp.WithString("until",
+			mcp.Description("Only return events before this time: RFC 3339 or a duration ago"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description(fmt.Sprintf("Maximum number of most recent matching events to return (default %d)", defaultEventLimit)),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		container := req.GetString("container", "")
+		types := req.GetStringSlice("types", nil)
+		actions := req.GetStringSlice("actions", nil)
+		since := req.GetString("since", "")
+		until := req.GetString("until", "")
+		limit := req.GetInt("limit", defaultEventLimit)
+		params := map[string]any{
+			"container": container,
+			"types":     types,
+			"actions":   actions,
+			"since":     since,
+			"until":     until,
+			"limit":     limit,
+		}
+
+		if container != "" && !filter.IsAllowed(container) {
+			tools.LogAudit(audit, "docker_events", params, "denied", start)
+			return tools.ErrorResult(fmt.Sprintf("access to container %q is not allowed", container)), nil
+		}
+
+		q := EventQuery{Container: container, Types: types, Actions: actions}
+		var err error
+		if q.Since, err = ParseLogTime(since, start); err != nil {
+			return tools.ErrorResult(fmt.Sprintf("since: %v", err)), nil
+		}
+		if q.Until, err = ParseLogTime(until, start); err != nil {
+			return tools.ErrorResult(fmt.Sprintf("until: %v", err)), nil
+		}
+		if limit <= 0 {
+			limit = defaultEventLimit
+		}
+
+		// Hide events of containers the filter does not allow. The limit is
+		// applied afterwards so hidden events do not take up result slots.
+		events := make([]Event, 0)
+		for _, e := range history.Query(q) {
+			if e.Type == "container" && !filter.IsAllowed(e.ActorName) {
+				continue
+			}
+			events = append(events, e)
+		}
+		if len(events) > limit {
+			events = events[len(events)-limit:]
+		}
+
+		result := EventsResult{Events: events}
+		result.Connected, result.LastError = history.Status()
+
+		tools.LogAudit(audit, "docker_events", params, "ok", start)
+		return tools.JSONResult(result), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
@@ -0,0 +1,302 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Event stream
+// ---------------------------------------------------------------------------
+
+// DefaultEventHistorySize is the number of events kept by the history used
+// in cmd/server.
+const DefaultEventHistorySize = 2000
+
+// Event is a single Docker daemon event, such as a container dying or a
+// health status change.
+type Event struct {
+	Time       time.Time
+	Type       string // "container", "image", "network", "volume", ...
+	Action     string // "start", "die", "oom", "health_status: unhealthy", ...
+	ActorID    string
+	ActorName  string // the container, network or volume name, if known
+	Attributes map[string]string
+}
+
+// dockerEvent is the JSON shape of a message on the /events stream.
+type dockerEvent struct {
+	Type   string `json:"Type"`
+	Action string `json:"Action"`
+	Actor  struct {
+		ID         string            `json:"ID"`
+		Attributes map[string]string `json:"Attributes"`
+	} `json:"Actor"`
+	TimeNano int64 `json:"timeNano"`
+}
+
+func (e dockerEvent) toEvent() Event {
+	return Event{
+		Time:       time.Unix(0, e.TimeNano),
+		Type:       e.Type,
+		Action:     e.Action,
+		ActorID:    e.Actor.ID,
+		ActorName:  e.Actor.Attributes["name"],
+		Attributes: e.Actor.Attributes,
+	}
+}
+
+// EventSource streams daemon events. StreamEvents calls connected once the
+// stream is open, before any events.
+type EventSource interface {
+	StreamEvents(ctx context.Context, since time.Time, connected func(), handle func(Event)) error
+}
+
+// StreamEvents consumes the daemon's /events stream, calling handle for each
+// event, until ctx is cancelled or the stream fails. If since is non-zero, the
+// daemon first replays the events recorded since then.
+func (m *DockerClientManager) StreamEvents(ctx context.Context, since time.Time, connected func(), handle func(Event)) error {
+	path := "/events"
+	if !since.IsZero() {
+		path += "?since=" + url.QueryEscape(formatLogTime(since))
+	}
+	resp, err := m.doStreamRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return fmt.Errorf("docker: stream events: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("docker: stream events: %w", checkAPIError(resp.StatusCode, body, "events endpoint not found"))
+	}
+	connected()
+
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var raw dockerEvent
+		if err := dec.Decode(&raw); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if errors.Is(err, io.EOF) {
+				return fmt.Errorf("docker: stream events: stream closed by daemon")
+			}
+			return fmt.Errorf("docker: stream events: %w", err)
+		}
+		handle(raw.toEvent())
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Event history
+// ---------------------------------------------------------------------------
+
+// EventQuery selects events from an EventHistory. Zero fields match everything.
+type EventQuery struct {
+	Container string   // container name or ID prefix
+	Types     []string // event types, e.g. "container"
+	Actions   []string // actions; an entry also matches actions it prefixes, so "health_status" matches every health change
+	Since     time.Time
+	Until     time.Time
+	Limit     int // return at most this many of the newest matches (0 = all)
+}
+
+// EventHistory is a fixed-size, concurrency-safe ring buffer of events. When
+// full, the oldest event is overwritten.
+type EventHistory struct {
+	mu        sync.RWMutex
+	events    []Event
+	next      int
+	full      bool
+	connected bool
+	lastErr   string
+}
+
+// NewEventHistory returns an EventHistory holding up to capacity events.
+func NewEventHistory(capacity int) *EventHistory {
+	if capacity <= 0 {
+		capacity = DefaultEventHistorySize
+	}
+	return &EventHistory{events: make([]Event, capacity)}
+}
+
+// Add records an event.
+func (h *EventHistory) Add(e Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.events[h.next] = e
+	h.next = (h.next + 1) % len(h.events)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// setStatus records whether the event stream is connected and, if not, why.
+func (h *EventHistory) setStatus(connected bool, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.connected = connected
+	if err != nil {
+		h.lastErr = err.Error()
+	}
+}
+
+// Status reports whether the event stream is currently connected and the most
+// recent stream error, if any.
+func (h *EventHistory) Status() (connected bool, lastError string) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.connected, h.lastErr
+}
+
+// Query returns the matching events in chronological order.
+func (h *EventHistory) Query(q EventQuery) []Event {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var ordered []Event
+	if h.full {
+		ordered = append(ordered, h.events[h.next:]...)
+	}
+	ordered = append(ordered, h.events[:h.next]...)
+
+	matches := make([]Event, 0)
+	for _, e := range ordered {
+		if q.matches(e) {
+			matches = append(matches, e)
+		}
+	}
+	if q.Limit > 0 && len(matches) > q.Limit {
+		matches = matches[len(matches)-q.Limit:]
+	}
+	return matches
+}
+
+func (q EventQuery) matches(e Event) bool {
+	if !q.Since.IsZero() && e.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !e.Time.Before(q.Until) {
+		return false
+	}
+	if q.Container != "" {
+		if e.Type != "container" {
+			return false
+		}
+		if e.ActorName != q.Container && !strings.HasPrefix(e.ActorID, q.Container) {
+			return false
+		}
+	}
+	if len(q.Types) > 0 && !containsString(q.Types, e.Type) {
+		return false
+	}
+	if len(q.Actions) > 0 {
+		found := false
+		for _, a := range q.Actions {
+			if e.Action == a || strings.HasPrefix(e.Action, a+":") {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ---------------------------------------------------------------------------
+// Event watcher
+// ---------------------------------------------------------------------------
+
+// EventWatcher keeps an EventHistory filled from an EventSource, reconnecting
+// with exponential backoff when the stream fails.
+type EventWatcher struct {
+	source     EventSource
+	history    *EventHistory
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+// NewEventWatcher returns a watcher that records events from source into
+// history.
+func NewEventWatcher(source EventSource, history *EventHistory) *EventWatcher {
+	return &EventWatcher{
+		source:     source,
+		history:    history,
+		minBackoff: time.Second,
+		maxBackoff: 30 * time.Second,
+	}
+}
+
+// Run consumes events until ctx is cancelled. After a reconnect it asks the
+// daemon to replay events since the last one recorded, skipping duplicates,
+// so short outages do not leave gaps in the history.
+func (w *EventWatcher) Run(ctx context.Context) {
+	var (
+		last     time.Time
+		lastKeys = map[string]struct{}{}
+		backoff  = w.minBackoff
+	)
+	record := func(e Event) {
+		key := e.Type + "|" + e.ActorID + "|" + e.Action
+		if e.Time.Before(last) {
+			return
+		}
+		if e.Time.Equal(last) {
+			if _, dup := lastKeys[key]; dup {
+				return
+			}
+		} else {
+			last = e.Time
+			lastKeys = map[string]struct{}{}
+		}
+		lastKeys[key] = struct{}{}
+		w.history.Add(e)
+	}
+
+	for {
+		started := time.Now()
+		// The watcher only reports itself connected once the daemon has
+		// accepted the request, not while it is still failing to connect.
+		err := w.source.StreamEvents(ctx, last, func() { w.history.setStatus(true, nil) }, record)
+		if ctx.Err() != nil {
+			w.history.setStatus(false, nil)
+			return
+		}
+		w.history.setStatus(false, err)
+
+		if time.Since(started) > w.maxBackoff {
+			backoff = w.minBackoff
+		}
+		log.Printf("docker events: %v — reconnecting in %s", err, backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > w.maxBackoff {
+			backoff = w.maxBackoff
+		}
+	}
+}
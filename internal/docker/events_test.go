@@ -0,0 +1,272 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+)
+
+// testEvent returns a container event at the given Unix second.
+func testEvent(sec int64, name, action string) Event {
+	return Event{Time: time.Unix(sec, 0), Type: "container", Action: action, ActorID: name + "-id", ActorName: name}
+}
+
+// ---------------------------------------------------------------------------
+// StreamEvents against a fake daemon
+// ---------------------------------------------------------------------------
+
+func Test_StreamEvents_DecodesStream(t *testing.T) {
+	var gotSince string
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /events", func(w http.ResponseWriter, r *http.Request) {
+		gotSince = r.URL.Query().Get("since")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"Type":"container","Action":"die","Actor":{"ID":"abc","Attributes":{"name":"plex","exitCode":"137"}},"time":1700000000,"timeNano":1700000000500000000}`+"\n")
+		_, _ = io.WriteString(w, `{"Type":"container","Action":"health_status: unhealthy","Actor":{"ID":"def","Attributes":{"name":"sonarr"}},"timeNano":1700000001000000000}`+"\n")
+	})
+	mgr := newFakeDaemon(t, mux)
+
+	var got []Event
+	err := mgr.StreamEvents(context.Background(), time.Unix(1700000000, 0), func() {}, func(e Event) { got = append(got, e) })
+	if err == nil || !strings.Contains(err.Error(), "stream closed") {
+		t.Errorf("StreamEvents() error = %v, want stream closed", err)
+	}
+	if gotSince != "1700000000.000000000" {
+		t.Errorf("since = %q", gotSince)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d events, want 2", len(got))
+	}
+	if got[0].ActorName != "plex" || got[0].Attributes["exitCode"] != "137" || !got[0].Time.Equal(time.Unix(1700000000, 500000000)) {
+		t.Errorf("event[0] = %+v", got[0])
+	}
+	if got[1].Action != "health_status: unhealthy" || got[1].ActorID != "def" {
+		t.Errorf("event[1] = %+v", got[1])
+	}
+}
+
+func Test_StreamEvents_StopsOnCancel(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /events", func(w http.ResponseWriter, r *http.Request) {
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+	mgr := newFakeDaemon(t, mux)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := mgr.StreamEvents(ctx, time.Time{}, func() {}, func(Event) {})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("StreamEvents() error = %v, want deadline exceeded", err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// EventHistory
+// ---------------------------------------------------------------------------
+
+func Test_EventHistory_RingBufferKeepsNewest(t *testing.T) {
+	h := NewEventHistory(3)
+	for i := int64(1); i <= 5; i++ {
+		h.Add(testEvent(i, "plex", "start"))
+	}
+	got := h.Query(EventQuery{})
+	if len(got) != 3 || got[0].Time.Unix() != 3 || got[2].Time.Unix() != 5 {
+		t.Errorf("Query() = %+v, want events 3..5 oldest first", got)
+	}
+}
+
+func Test_EventHistory_Query(t *testing.T) {
+	h := NewEventHistory(10)
+	h.Add(testEvent(10, "plex", "start"))
+	h.Add(testEvent(20, "plex", "die"))
+	h.Add(testEvent(30, "sonarr", "health_status: unhealthy"))
+	h.Add(testEvent(40, "plex", "oom"))
+	h.Add(Event{Time: time.Unix(50, 0), Type: "network", Action: "connect", ActorID: "net1", ActorName: "bridge"})
+
+	tests := []struct {
+		name  string
+		query EventQuery
+		want  []int64
+	}{
+		{name: "all", query: EventQuery{}, want: []int64{10, 20, 30, 40, 50}},
+		{name: "container name", query: EventQuery{Container: "plex"}, want: []int64{10, 20, 40}},
+		{name: "container ID prefix", query: EventQuery{Container: "sonarr-"}, want: []int64{30}},
+		{name: "type", query: EventQuery{Types: []string{"network"}}, want: []int64{50}},
+		{name: "actions", query: EventQuery{Actions: []string{"die", "oom"}}, want: []int64{20, 40}},
+		{name: "action prefix", query: EventQuery{Actions: []string{"health_status"}}, want: []int64{30}},
+		{name: "window", query: EventQuery{Since: time.Unix(20, 0), Until: time.Unix(40, 0)}, want: []int64{20, 30}},
+		{name: "limit keeps newest", query: EventQuery{Limit: 2}, want: []int64{40, 50}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := h.Query(tt.query)
+			var secs []int64
+			for _, e := range got {
+				secs = append(secs, e.Time.Unix())
+			}
+			if len(secs) != len(tt.want) {
+				t.Fatalf("Query() = %v, want %v", secs, tt.want)
+			}
+			for i := range secs {
+				if secs[i] != tt.want[i] {
+					t.Fatalf("Query() = %v, want %v", secs, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// ---------------------------------------------------------------------------
+// EventWatcher
+// ---------------------------------------------------------------------------
+
+// fakeEventSource replays one scripted batch of events per connection and
+// then fails, recording the since value of each connection. With refuse set,
+// every connection fails before the stream opens.
+type fakeEventSource struct {
+	mu      sync.Mutex
+	batches [][]Event
+	sinces  []time.Time
+	refuse  bool
+	done    chan struct{}
+}
+
+func (s *fakeEventSource) StreamEvents(ctx context.Context, since time.Time, connected func(), handle func(Event)) error {
+	s.mu.Lock()
+	s.sinces = append(s.sinces, since)
+	if s.refuse {
+		if len(s.sinces) == 3 {
+			close(s.done)
+		}
+		s.mu.Unlock()
+		return errors.New("connection refused")
+	}
+	connected()
+	if len(s.batches) == 0 {
+		s.mu.Unlock()
+		close(s.done)
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	batch := s.batches[0]
+	s.batches = s.batches[1:]
+	s.mu.Unlock()
+
+	for _, e := range batch {
+		handle(e)
+	}
+	return errors.New("connection reset")
+}
+
+func Test_EventWatcher_ReconnectsWithoutDuplicates(t *testing.T) {
+	source := &fakeEventSource{
+		batches: [][]Event{
+			{testEvent(1, "plex", "start"), testEvent(2, "plex", "die")},
+			// The daemon replays events at and after the since time.
+			{testEvent(2, "plex", "die"), testEvent(2, "sonarr", "die"), testEvent(3, "plex", "start")},
+		},
+		done: make(chan struct{}),
+	}
+	history := NewEventHistory(10)
+	w := NewEventWatcher(source, history)
+	w.minBackoff, w.maxBackoff = time.Millisecond, 5*time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		w.Run(ctx)
+	}()
+
+	select {
+	case <-source.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watcher did not reconnect")
+	}
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watcher did not stop after cancel")
+	}
+
+	got := history.Query(EventQuery{})
+	if len(got) != 4 {
+		t.Fatalf("history = %+v, want 4 events", got)
+	}
+	source.mu.Lock()
+	defer source.mu.Unlock()
+	if !source.sinces[0].IsZero() || source.sinces[1].Unix() != 2 || source.sinces[2].Unix() != 3 {
+		t.Errorf("since values = %v", source.sinces)
+	}
+	if connected, lastErr := history.Status(); connected || lastErr != "connection reset" {
+		t.Errorf("Status() = %v, %q", connected, lastErr)
+	}
+}
+
+func Test_EventWatcher_NotConnectedUntilStreamOpens(t *testing.T) {
+	source := &fakeEventSource{refuse: true, done: make(chan struct{})}
+	history := NewEventHistory(10)
+	w := NewEventWatcher(source, history)
+	w.minBackoff, w.maxBackoff = time.Millisecond, 5*time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx)
+
+	select {
+	case <-source.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watcher did not retry")
+	}
+	// Poll across a few retries: the watcher must never report itself
+	// connected while every attempt fails.
+	for range 20 {
+		if connected, _ := history.Status(); connected {
+			t.Fatal("Status() reports connected while the stream cannot be opened")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if _, lastErr := history.Status(); lastErr != "connection refused" {
+		t.Errorf("last error = %q, want connection refused", lastErr)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// docker_events tool
+// ---------------------------------------------------------------------------
+
+func Test_DockerEventsTool(t *testing.T) {
+	history := NewEventHistory(10)
+	history.Add(testEvent(time.Now().Add(-2*time.Hour).Unix(), "plex", "die"))
+	history.Add(testEvent(time.Now().Add(-time.Minute).Unix(), "plex", "oom"))
+	history.Add(testEvent(time.Now().Add(-time.Minute).Unix(), "secret", "die"))
+	regs := EventTools(history, safety.NewFilter(nil, []string{"secret"}), nil)
+
+	text := callTool(t, regs, "docker_events", map[string]any{"since": "1h"})
+	var result EventsResult
+	if err := json.Unmarshal([]byte(text), &result); err != nil {
+		t.Fatalf("decode result: %v\n%s", err, text)
+	}
+	if len(result.Events) != 1 || result.Events[0].Action != "oom" {
+		t.Errorf("events = %+v, want only the recent plex oom", result.Events)
+	}
+
+	text = callTool(t, regs, "docker_events", map[string]any{"container": "secret"})
+	if !strings.Contains(text, "not allowed") {
+		t.Errorf("denied container = %s, want not allowed", text)
+	}
+	text = callTool(t, regs, "docker_events", map[string]any{"since": "yesterday"})
+	if !strings.Contains(text, "since") {
+		t.Errorf("bad since = %s, want error", text)
+	}
+}
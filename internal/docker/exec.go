@@ -0,0 +1,286 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+)
+
+// ---------------------------------------------------------------------------
+// Exec
+// ---------------------------------------------------------------------------
+
+// execCreateRequest is the JSON body sent to POST /containers/{id}/exec.
+type execCreateRequest struct {
+	AttachStdout bool     `json:"AttachStdout"`
+	AttachStderr bool     `json:"AttachStderr"`
+	Tty          bool     `json:"Tty"`
+	Cmd          []string `json:"Cmd"`
+	Env          []string `json:"Env,omitempty"`
+}
+
+// ExecContainer runs a command inside a running container and captures its
+// output. The command runs without a TTY so stdout and stderr arrive in the
+// same multiplexed framing as container logs. If opts.Timeout expires, the
+// output collected so far is returned with TimedOut set; the Docker API has
+// no way to stop an exec'd process, so it may keep running.
+func (m *DockerClientManager) ExecContainer(ctx context.Context, id string, opts ExecOptions) (*ExecResult, error) {
+	if len(opts.Cmd) == 0 {
+		return nil, fmt.Errorf("command is required")
+	}
+
+	bodyData, err := json.Marshal(execCreateRequest{
+		AttachStdout: true,
+		AttachStderr: true,
+		Cmd:          opts.Cmd,
+		Env:          opts.Env,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("docker: encode exec request: %w", err)
+	}
+	resp, err := m.doRequest(ctx, http.MethodPost, "/containers/"+id+"/exec", bytes.NewReader(bodyData))
+	if err != nil {
+		return nil, fmt.Errorf("docker: create exec: %w", err)
+	}
+	body, err := readBody(resp)
+	if err != nil {
+		return nil, fmt.Errorf("docker: create exec: %w", err)
+	}
+	if err := checkAPIError(resp.StatusCode, body, fmt.Sprintf("container not found: %s", id)); err != nil {
+		return nil, fmt.Errorf("docker: create exec: %w", err)
+	}
+	var created struct {
+		ID string `json:"Id"`
+	}
+	if err := json.Unmarshal(body, &created); err != nil {
+		return nil, fmt.Errorf("docker: decode exec create response: %w", err)
+	}
+
+	runCtx := ctx
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	// timedOut reports whether a failure was caused by opts.Timeout rather
+	// than by the caller's context.
+	timedOut := func() bool { return runCtx.Err() != nil && ctx.Err() == nil }
+
+	result := &ExecResult{ExitCode: -1}
+	c := &logCollector{opts: LogOptions{MaxBytes: opts.MaxOutputBytes}}
+
+	resp, err = m.doStreamRequest(runCtx, http.MethodPost, "/exec/"+created.ID+"/start",
+		strings.NewReader(`{"Detach":false,"Tty":false}`))
+	if err != nil {
+		if !timedOut() {
+			return nil, fmt.Errorf("docker: start exec: %w", err)
+		}
+		result.TimedOut = true
+	} else {
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			body, _ := readBody(resp)
+			return nil, fmt.Errorf("docker: start exec: %w",
+				checkAPIError(resp.StatusCode, body, fmt.Sprintf("exec instance not found: %s", created.ID)))
+		}
+		err = readMultiplexedLogs(resp.Body, c)
+		_ = resp.Body.Close()
+		if err != nil {
+			if !timedOut() {
+				return nil, err
+			}
+			result.TimedOut = true
+		}
+	}
+
+	var stdout, stderr strings.Builder
+	for _, line := range c.lines {
+		out := &stdout
+		if line.Stream == "stderr" {
+			out = &stderr
+		}
+		out.WriteString(line.Text)
+		out.WriteByte('\n')
+	}
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+	result.Truncated = c.truncated
+
+	if result.TimedOut {
+		return result, nil
+	}
+
+	exitCode, err := m.execExitCode(ctx, created.ID)
+	if err != nil {
+		return nil, err
+	}
+	result.ExitCode = exitCode
+	return result, nil
+}
+
+// execExitCode returns the exit code of a finished exec instance, or -1 if it
+// is still running.
+func (m *DockerClientManager) execExitCode(ctx context.Context, execID string) (int, error) {
+	resp, err := m.doRequest(ctx, http.MethodGet, "/exec/"+execID+"/json", nil)
+	if err != nil {
+		return 0, fmt.Errorf("docker: inspect exec: %w", err)
+	}
+	body, err := readBody(resp)
+	if err != nil {
+		return 0, fmt.Errorf("docker: inspect exec: %w", err)
+	}
+	if err := checkAPIError(resp.StatusCode, body, fmt.Sprintf("exec instance not found: %s", execID)); err != nil {
+		return 0, fmt.Errorf("docker: inspect exec: %w", err)
+	}
+	var raw struct {
+		Running  bool `json:"Running"`
+		ExitCode *int `json:"ExitCode"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return 0, fmt.Errorf("docker: decode exec inspect: %w", err)
+	}
+	if raw.Running || raw.ExitCode == nil {
+		return -1, nil
+	}
+	return *raw.ExitCode, nil
+}
+
+// ---------------------------------------------------------------------------
+// ExecPolicy
+// ---------------------------------------------------------------------------
+
+// Defaults applied when an ExecPolicy leaves Timeout or MaxOutput unset.
+const (
+	DefaultExecTimeout   = 30 * time.Second
+	DefaultExecMaxOutput = 64 * 1024
+)
+
+// ExecRule permits commands matching any of Commands inside containers whose
+// names match any of Containers.
+type ExecRule struct {
+	Containers []string // glob patterns matched against the container name
+	Commands   []string // command patterns, one word per argument; "*" matches within an argument
+}
+
+// ExecPolicy restricts which commands docker_exec may run. A nil policy, or
+// one without rules, permits nothing.
+type ExecPolicy struct {
+	Rules     []ExecRule
+	Timeout   time.Duration // default and maximum run time (0 = DefaultExecTimeout)
+	MaxOutput int           // bytes of output kept (0 = DefaultExecMaxOutput)
+}
+
+// Check reports an error unless some rule permits running cmd in the named
+// container. A pattern is split into words on spaces and matched against the
+// arguments one by one, so it allows exactly as many arguments as it has
+// words. Arguments that step up a directory with ".." are always refused, as
+// they would let a path pattern reach outside its directory.
+func (p *ExecPolicy) Check(container string, cmd []string) error {
+	if p == nil || len(p.Rules) == 0 {
+		return fmt.Errorf("exec is disabled: no exec rules are configured")
+	}
+	if len(cmd) == 0 {
+		return fmt.Errorf("command is required")
+	}
+
+	line := strings.Join(cmd, " ")
+	for _, arg := range cmd {
+		if hasParentRef(arg) {
+			return fmt.Errorf("command %q is not allowed: argument %q refers to a parent directory", line, arg)
+		}
+	}
+	for _, r := range p.Rules {
+		if len(r.Containers) == 0 || !safety.NewFilter(r.Containers, nil).IsAllowed(container) {
+			continue
+		}
+		for _, pattern := range r.Commands {
+			if matchCommand(pattern, cmd) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("command %q is not allowed in container %q by policy", line, container)
+}
+
+// EffectiveTimeout returns the run time for a request asking for requested
+// (0 = default), capped at the policy's timeout.
+func (p *ExecPolicy) EffectiveTimeout(requested time.Duration) time.Duration {
+	limit := DefaultExecTimeout
+	if p != nil && p.Timeout > 0 {
+		limit = p.Timeout
+	}
+	if requested <= 0 || requested > limit {
+		return limit
+	}
+	return requested
+}
+
+// EffectiveMaxOutput returns the output cap in bytes.
+func (p *ExecPolicy) EffectiveMaxOutput() int {
+	if p != nil && p.MaxOutput > 0 {
+		return p.MaxOutput
+	}
+	return DefaultExecMaxOutput
+}
+
+// matchCommand reports whether the arguments of cmd match the words of
+// pattern one for one.
+func matchCommand(pattern string, cmd []string) bool {
+	words := strings.Fields(pattern)
+	if len(words) != len(cmd) {
+		return false
+	}
+	for i, w := range words {
+		if !matchArg(w, cmd[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchArg reports whether arg matches pattern, where "*" matches any run of
+// characters other than a space (slashes included) and "?" matches exactly
+// one such character. All other characters match literally.
+func matchArg(pattern, arg string) bool {
+	p, s := []rune(pattern), []rune(arg)
+	pi, si := 0, 0
+	star, mark := -1, 0
+	for si < len(s) {
+		switch {
+		case pi < len(p) && p[pi] == '?' && s[si] != ' ':
+			pi++
+			si++
+		case pi < len(p) && p[pi] != '*' && p[pi] != '?' && p[pi] == s[si]:
+			pi++
+			si++
+		case pi < len(p) && p[pi] == '*':
+			star, mark = pi, si
+			pi++
+		case star >= 0 && s[mark] != ' ':
+			pi = star + 1
+			mark++
+			si = mark
+		default:
+			return false
+		}
+	}
+	for pi < len(p) && p[pi] == '*' {
+		pi++
+	}
+	return pi == len(p)
+}
+
+// hasParentRef reports whether arg has a ".." path element, including in
+// the value of an option such as "--file=../x". Cleaning the path would not
+// do: "/var/log/nginx/../../../etc/shadow" cleans to a path without one.
+func hasParentRef(arg string) bool {
+	elems := strings.FieldsFunc(arg, func(r rune) bool { return r == '/' || r == '=' })
+	return slices.Contains(elems, "..")
+}
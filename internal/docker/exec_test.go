@@ -0,0 +1,268 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+)
+
+// ---------------------------------------------------------------------------
+// ExecContainer against a fake daemon
+// ---------------------------------------------------------------------------
+
+func Test_ExecContainer_CapturesOutputAndExitCode(t *testing.T) {
+	var gotCmd []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /containers/{id}/exec", func(w http.ResponseWriter, r *http.Request) {
+		var req execCreateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode exec request: %v", err)
+		}
+		if req.Tty || !req.AttachStdout || !req.AttachStderr {
+			t.Errorf("exec request = %+v, want attached stdout/stderr without TTY", req)
+		}
+		gotCmd = req.Cmd
+		writeJSON(w, http.StatusCreated, `{"Id":"exec1"}`)
+	})
+	mux.HandleFunc("POST /exec/exec1/start", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.docker.raw-stream")
+		_, _ = io.WriteString(w, muxFrame(streamStdout, "syntax is ok\n")+
+			muxFrame(streamStderr, "warning: x\n")+
+			muxFrame(streamStdout, "test is successful"))
+	})
+	mux.HandleFunc("GET /exec/exec1/json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, `{"Running":false,"ExitCode":1}`)
+	})
+	mgr := newFakeDaemon(t, mux)
+
+	result, err := mgr.ExecContainer(context.Background(), "nginx", ExecOptions{Cmd: []string{"nginx", "-t"}})
+	if err != nil {
+		t.Fatalf("ExecContainer() error = %v", err)
+	}
+	if strings.Join(gotCmd, " ") != "nginx -t" {
+		t.Errorf("Cmd = %v, want [nginx -t]", gotCmd)
+	}
+	if result.ExitCode != 1 || result.TimedOut || result.Truncated {
+		t.Errorf("result = %+v, want exit 1", result)
+	}
+	if result.Stdout != "syntax is ok\ntest is successful\n" {
+		t.Errorf("Stdout = %q", result.Stdout)
+	}
+	if result.Stderr != "warning: x\n" {
+		t.Errorf("Stderr = %q", result.Stderr)
+	}
+}
+
+func Test_ExecContainer_TimeoutReturnsPartialOutput(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /containers/{id}/exec", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusCreated, `{"Id":"exec1"}`)
+	})
+	mux.HandleFunc("POST /exec/exec1/start", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, muxFrame(streamStdout, "tick\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+	mux.HandleFunc("GET /exec/exec1/json", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("exec inspected after timeout")
+	})
+	mgr := newFakeDaemon(t, mux)
+
+	result, err := mgr.ExecContainer(context.Background(), "app", ExecOptions{
+		Cmd:     []string{"tail", "-f", "/log"},
+		Timeout: 100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("ExecContainer() error = %v", err)
+	}
+	if !result.TimedOut || result.ExitCode != -1 {
+		t.Errorf("result = %+v, want timed out with exit -1", result)
+	}
+	if result.Stdout != "tick\n" {
+		t.Errorf("Stdout = %q, want partial output", result.Stdout)
+	}
+}
+
+func Test_ExecContainer_OutputCap(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /containers/{id}/exec", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusCreated, `{"Id":"exec1"}`)
+	})
+	mux.HandleFunc("POST /exec/exec1/start", func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 100; i++ {
+			_, _ = io.WriteString(w, muxFrame(streamStdout, "0123456789\n"))
+		}
+		_, _ = io.WriteString(w, muxFrame(streamStdout, "last\n"))
+	})
+	mux.HandleFunc("GET /exec/exec1/json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, `{"Running":false,"ExitCode":0}`)
+	})
+	mgr := newFakeDaemon(t, mux)
+
+	result, err := mgr.ExecContainer(context.Background(), "app", ExecOptions{Cmd: []string{"seq"}, MaxOutputBytes: 50})
+	if err != nil {
+		t.Fatalf("ExecContainer() error = %v", err)
+	}
+	if !result.Truncated || len(result.Stdout) > 50 || !strings.HasSuffix(result.Stdout, "last\n") {
+		t.Errorf("result = %+v, want newest 50 bytes ending in last", result)
+	}
+}
+
+func Test_ExecContainer_Errors(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /containers/missing/exec", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusNotFound, `{"message":"No such container: missing"}`)
+	})
+	mux.HandleFunc("POST /containers/stopped/exec", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusConflict, `{"message":"Container stopped is not running"}`)
+	})
+	mgr := newFakeDaemon(t, mux)
+
+	tests := []struct {
+		name    string
+		id      string
+		cmd     []string
+		wantErr string
+	}{
+		{name: "empty command", id: "app", wantErr: "command is required"},
+		{name: "missing container", id: "missing", cmd: []string{"ps"}, wantErr: "container not found: missing"},
+		{name: "stopped container", id: "stopped", cmd: []string{"ps"}, wantErr: "is not running"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := mgr.ExecContainer(context.Background(), tt.id, ExecOptions{Cmd: tt.cmd})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ExecContainer() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// ---------------------------------------------------------------------------
+// ExecPolicy
+// ---------------------------------------------------------------------------
+
+func Test_ExecPolicy_Check(t *testing.T) {
+	policy := &ExecPolicy{Rules: []ExecRule{
+		{Containers: []string{"nginx*"}, Commands: []string{"nginx -t", "cat /var/log/nginx/*"}},
+		{Containers: []string{"*"}, Commands: []string{"ps"}},
+		{Commands: []string{"*"}}, // no containers: matches nothing
+	}}
+
+	tests := []struct {
+		name      string
+		policy    *ExecPolicy
+		container string
+		cmd       []string
+		wantErr   bool
+	}{
+		{name: "exact command", policy: policy, container: "nginx", cmd: []string{"nginx", "-t"}},
+		{name: "wildcard spans slashes", policy: policy, container: "nginx-proxy", cmd: []string{"cat", "/var/log/nginx/sub/error.log"}},
+		{name: "any container rule", policy: policy, container: "plex", cmd: []string{"ps"}},
+		{name: "extra argument", policy: policy, container: "nginx", cmd: []string{"nginx", "-t", "-c", "/tmp/x"}, wantErr: true},
+		{name: "wildcard does not span arguments", policy: policy, container: "nginx", cmd: []string{"cat", "/var/log/nginx/x", "/etc/shadow"}, wantErr: true},
+		{name: "wildcard does not match a space", policy: policy, container: "nginx", cmd: []string{"cat", "/var/log/nginx/x /etc/shadow"}, wantErr: true},
+		{name: "parent directory", policy: policy, container: "nginx", cmd: []string{"cat", "/var/log/nginx/../../../etc/shadow"}, wantErr: true},
+		{name: "parent directory in option", policy: &ExecPolicy{Rules: []ExecRule{{Containers: []string{"*"}, Commands: []string{"nginx -c*"}}}}, container: "nginx", cmd: []string{"nginx", "-c=../../etc/shadow"}, wantErr: true},
+		{name: "wrong container", policy: policy, container: "plex", cmd: []string{"nginx", "-t"}, wantErr: true},
+		{name: "empty command", policy: policy, container: "nginx", wantErr: true},
+		{name: "nil policy", container: "nginx", cmd: []string{"ps"}, wantErr: true},
+		{name: "no rules", policy: &ExecPolicy{}, container: "nginx", cmd: []string{"ps"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check(tt.container, tt.cmd)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Check(%q, %v) error = %v, wantErr %v", tt.container, tt.cmd, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_ExecPolicy_EffectiveLimits(t *testing.T) {
+	var nilPolicy *ExecPolicy
+	if got := nilPolicy.EffectiveTimeout(0); got != DefaultExecTimeout {
+		t.Errorf("nil EffectiveTimeout(0) = %v, want %v", got, DefaultExecTimeout)
+	}
+	if got := nilPolicy.EffectiveMaxOutput(); got != DefaultExecMaxOutput {
+		t.Errorf("nil EffectiveMaxOutput() = %d, want %d", got, DefaultExecMaxOutput)
+	}
+
+	p := &ExecPolicy{Timeout: 10 * time.Second, MaxOutput: 1024}
+	if got := p.EffectiveTimeout(5 * time.Second); got != 5*time.Second {
+		t.Errorf("EffectiveTimeout(5s) = %v, want 5s", got)
+	}
+	if got := p.EffectiveTimeout(time.Minute); got != 10*time.Second {
+		t.Errorf("EffectiveTimeout(1m) = %v, want capped at 10s", got)
+	}
+	if got := p.EffectiveMaxOutput(); got != 1024 {
+		t.Errorf("EffectiveMaxOutput() = %d, want 1024", got)
+	}
+}
+
+func Test_MatchCommand(t *testing.T) {
+	tests := []struct {
+		pattern string
+		cmd     []string
+		want    bool
+	}{
+		{"ps", []string{"ps"}, true},
+		{"ps", []string{"ps", "aux"}, false},
+		{"ps*", []string{"ps", "aux"}, false},
+		{"ps *", []string{"ps", "aux"}, true},
+		{"cat /config/*.log", []string{"cat", "/config/a/b.log"}, true},
+		{"cat /config/*.log", []string{"cat", "/config/a.txt"}, false},
+		{"cat /config/*", []string{"cat", "/config/a", "/etc/shadow"}, false},
+		{"cat /config/*", []string{"cat", "/config/a /etc/shadow"}, false},
+		{"ls -?", []string{"ls", "-l"}, true},
+		{"ls -?", []string{"ls", "-la"}, false},
+		{"*", []string{""}, true},
+		{"", []string{"x"}, false},
+	}
+	for _, tt := range tests {
+		if got := matchCommand(tt.pattern, tt.cmd); got != tt.want {
+			t.Errorf("matchCommand(%q, %q) = %v, want %v", tt.pattern, tt.cmd, got, tt.want)
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// docker_exec tool
+// ---------------------------------------------------------------------------
+
+func Test_DockerTools_Exec(t *testing.T) {
+	mgr := newPopulatedMock(t)
+	confirm := safety.NewConfirmationTracker(DestructiveTools)
+	policy := &ExecPolicy{Rules: []ExecRule{{Containers: []string{"plex"}, Commands: []string{"ls *"}}}}
+	regs := DockerTools(mgr, safety.NewFilter(nil, nil), nil, policy, confirm, nil)
+
+	// The rule names the container, but the caller passes its ID.
+	text := callTool(t, regs, "docker_exec", map[string]any{"id": "abc123", "command": []any{"ls", "/config"}})
+	var result ExecResult
+	if err := json.Unmarshal([]byte(text), &result); err != nil {
+		t.Fatalf("decode result: %v\n%s", err, text)
+	}
+	if result.Stdout != "ls /config\n" {
+		t.Errorf("Stdout = %q", result.Stdout)
+	}
+
+	text = callTool(t, regs, "docker_exec", map[string]any{"id": "abc123", "command": []any{"rm", "-rf", "/config"}})
+	if !strings.Contains(text, "not allowed") {
+		t.Errorf("disallowed command = %s, want not allowed", text)
+	}
+}
+
+func Test_DockerTools_ExecDisabledWithoutPolicy(t *testing.T) {
+	_, regs := newToolsUnderTest(t)
+
+	text := callTool(t, regs, "docker_exec", map[string]any{"id": "abc123", "command": []any{"ps"}})
+	if !strings.Contains(text, "exec is disabled") {
+		t.Errorf("docker_exec = %s, want disabled", text)
+	}
+}
@@ -0,0 +1,166 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+	"github.com/jamesprial/unraid-mcp/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func toolDockerFileRead(mgr DockerManager, filter *safety.Filter, audit *safety.AuditLogger) tools.Registration {
+	tool := mcp.NewTool("docker_file_read",
+		mcp.WithDescription(fmt.Sprintf("Read a text file from inside a container (running or stopped). Files over %d KB and binary files are refused.", MaxFileReadBytes/1024)),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("Container ID or name"),
+		),
+		mcp.WithString("path",
+			mcp.Required(),
+			mcp.Description("Absolute path of the file inside the container (e.g. /config/nginx/nginx.conf)"),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		id := req.GetString("id", "")
+		filePath := req.GetString("path", "")
+		params := map[string]any{"id": id, "path": filePath}
+
+		if !filter.IsAllowed(id) {
+			tools.LogAudit(audit, "docker_file_read", params, "denied", start)
+			return tools.ErrorResult(fmt.Sprintf("access to container %q is not allowed", id)), nil
+		}
+
+		file, err := mgr.ReadContainerFile(ctx, id, filePath, MaxFileReadBytes)
+		if err != nil {
+			tools.LogAudit(audit, "docker_file_read", params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+		if !IsText([]byte(file.Content)) {
+			tools.LogAudit(audit, "docker_file_read", params, "error: binary content", start)
+			return tools.ErrorResult(fmt.Sprintf("%s appears to be a binary file", file.Path)), nil
+		}
+
+		tools.LogAudit(audit, "docker_file_read", params, "ok", start)
+		return tools.JSONResult(file), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
+
+func toolDockerFileList(mgr DockerManager, filter *safety.Filter, audit *safety.AuditLogger) tools.Registration {
+	tool := mcp.NewTool("docker_file_list",
+		mcp.WithDescription("List the entries of a directory inside a container (running or stopped), with size, mode and modification time."),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("Container ID or name"),
+		),
+		mcp.WithString("path",
+			mcp.Required(),
+			mcp.Description("Absolute path of the directory inside the container (e.g. /config)"),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		id := req.GetString("id", "")
+		dir := req.GetString("path", "")
+		params := map[string]any{"id": id, "path": dir}
+
+		if !filter.IsAllowed(id) {
+			tools.LogAudit(audit, "docker_file_list", params, "denied", start)
+			return tools.ErrorResult(fmt.Sprintf("access to container %q is not allowed", id)), nil
+		}
+
+		listing, err := mgr.ListContainerFiles(ctx, id, dir)
+		if err != nil {
+			tools.LogAudit(audit, "docker_file_list", params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+
+		tools.LogAudit(audit, "docker_file_list", params, "ok", start)
+		return tools.JSONResult(listing), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
+
+func toolDockerFileWrite(mgr DockerManager, filter *safety.Filter, confirm *safety.ConfirmationTracker, audit *safety.AuditLogger) tools.Registration {
+	const toolName = "docker_file_write"
+
+	tool := mcp.NewTool(toolName,
+		mcp.WithDescription(fmt.Sprintf("Create or replace a text file inside a container. An existing file keeps its permissions and owner. "+
+			"The parent directory must exist. Content is limited to %d KB. Requires confirmation.", MaxFileWriteBytes/1024)),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("Container ID or name"),
+		),
+		mcp.WithString("path",
+			mcp.Required(),
+			mcp.Description("Absolute path of the file inside the container"),
+		),
+		mcp.WithString("content",
+			mcp.Required(),
+			mcp.Description("New file content (UTF-8 text)"),
+		),
+		mcp.WithString("confirmation_token",
+			mcp.Description("Confirmation token returned by a prior call to this tool"),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		id := req.GetString("id", "")
+		filePath := req.GetString("path", "")
+		content := req.GetString("content", "")
+		token := req.GetString("confirmation_token", "")
+		// The content itself is not audited; its hash binds the confirmation
+		// token to exactly this content.
+		sum := sha256.Sum256([]byte(content))
+		params := map[string]any{
+			"id":             id,
+			"path":           filePath,
+			"bytes":          len(content),
+			"content_sha256": hex.EncodeToString(sum[:]),
+		}
+
+		if !filter.IsAllowed(id) {
+			tools.LogAudit(audit, toolName, params, "denied", start)
+			return tools.ErrorResult(fmt.Sprintf("access to container %q is not allowed", id)), nil
+		}
+
+		cleaned, err := CleanContainerPath(filePath)
+		if err != nil {
+			return tools.ErrorResult(err.Error()), nil
+		}
+		if len(content) > MaxFileWriteBytes {
+			return tools.ErrorResult(fmt.Sprintf("content is %d bytes, larger than the %d byte limit", len(content), MaxFileWriteBytes)), nil
+		}
+		if !IsText([]byte(content)) {
+			return tools.ErrorResult("content must be UTF-8 text without NUL bytes"), nil
+		}
+
+		resource := id + ":" + cleaned
+		if !confirm.Confirm(token, toolName, resource, params) {
+			desc := fmt.Sprintf("This will write %d bytes to %s in container %q, replacing the file if it exists.", len(content), cleaned, id)
+			return tools.ConfirmPrompt(confirm, toolName, resource, desc, params), nil
+		}
+
+		if err := mgr.WriteContainerFile(ctx, id, cleaned, []byte(content)); err != nil {
+			tools.LogAudit(audit, toolName, params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+
+		tools.LogAudit(audit, toolName, params, "ok", start)
+		return mcp.NewToolResultText(fmt.Sprintf("wrote %d bytes to %s in container %q", len(content), cleaned, id)), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
@@ -0,0 +1,289 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Image operations
+// ---------------------------------------------------------------------------
+
+// dockerImage is the JSON shape returned by /images/json.
+type dockerImage struct {
+	ID         string   `json:"Id"`
+	RepoTags   []string `json:"RepoTags"`
+	Created    int64    `json:"Created"`
+	Size       int64    `json:"Size"`
+	Containers int64    `json:"Containers"`
+}
+
+// ListImages returns the images stored on the daemon. If all is false,
+// intermediate layer images are omitted.
+func (m *DockerClientManager) ListImages(ctx context.Context, all bool) ([]Image, error) {
+	path := "/images/json?all=" + strconv.FormatBool(all)
+	resp, err := m.doRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("docker: list images: %w", err)
+	}
+	body, err := readBody(resp)
+	if err != nil {
+		return nil, fmt.Errorf("docker: list images: %w", err)
+	}
+	if err := checkAPIError(resp.StatusCode, body, "image not found"); err != nil {
+		return nil, fmt.Errorf("docker: list images: %w", err)
+	}
+
+	var raw []dockerImage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("docker: decode image list: %w", err)
+	}
+
+	images := make([]Image, 0, len(raw))
+	for _, img := range raw {
+		tags := normalizeRepoTags(img.RepoTags)
+		images = append(images, Image{
+			ID:         img.ID,
+			RepoTags:   tags,
+			Created:    time.Unix(img.Created, 0),
+			Size:       img.Size,
+			Containers: img.Containers,
+			Dangling:   len(tags) == 0,
+		})
+	}
+	return images, nil
+}
+
+// dockerImageInspect is the JSON shape returned by /images/{id}/json.
+type dockerImageInspect struct {
+	ID           string   `json:"Id"`
+	RepoTags     []string `json:"RepoTags"`
+	RepoDigests  []string `json:"RepoDigests"`
+	Created      string   `json:"Created"`
+	Author       string   `json:"Author"`
+	Architecture string   `json:"Architecture"`
+	OS           string   `json:"Os"`
+	Size         int64    `json:"Size"`
+	Config       struct {
+		Env          []string            `json:"Env"`
+		Cmd          []string            `json:"Cmd"`
+		Entrypoint   []string            `json:"Entrypoint"`
+		WorkingDir   string              `json:"WorkingDir"`
+		ExposedPorts map[string]struct{} `json:"ExposedPorts"`
+		Volumes      map[string]struct{} `json:"Volumes"`
+		Labels       map[string]string   `json:"Labels"`
+	} `json:"Config"`
+}
+
+// InspectImage returns detailed information about an image.
+func (m *DockerClientManager) InspectImage(ctx context.Context, id string) (*ImageDetail, error) {
+	if id == "" {
+		return nil, fmt.Errorf("image not found: %s", id)
+	}
+	resp, err := m.doRequest(ctx, http.MethodGet, "/images/"+id+"/json", nil)
+	if err != nil {
+		return nil, fmt.Errorf("docker: inspect image: %w", err)
+	}
+	body, err := readBody(resp)
+	if err != nil {
+		return nil, fmt.Errorf("docker: inspect image: %w", err)
+	}
+	if err := checkAPIError(resp.StatusCode, body, fmt.Sprintf("image not found: %s", id)); err != nil {
+		return nil, fmt.Errorf("docker: inspect image: %w", err)
+	}
+
+	var raw dockerImageInspect
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("docker: decode image inspect: %w", err)
+	}
+
+	created, _ := time.Parse(time.RFC3339Nano, raw.Created)
+	tags := normalizeRepoTags(raw.RepoTags)
+
+	return &ImageDetail{
+		Image: Image{
+			ID:         raw.ID,
+			RepoTags:   tags,
+			Created:    created,
+			Size:       raw.Size,
+			Containers: -1,
+			Dangling:   len(tags) == 0,
+		},
+		RepoDigests:  raw.RepoDigests,
+		Architecture: raw.Architecture,
+		OS:           raw.OS,
+		Author:       raw.Author,
+		Config: ImageConfig{
+			Env:          raw.Config.Env,
+			Cmd:          raw.Config.Cmd,
+			Entrypoint:   raw.Config.Entrypoint,
+			WorkingDir:   raw.Config.WorkingDir,
+			ExposedPorts: sortedKeys(raw.Config.ExposedPorts),
+			Volumes:      sortedKeys(raw.Config.Volumes),
+			Labels:       raw.Config.Labels,
+		},
+	}, nil
+}
+
+// dockerImageHistory is the JSON shape of one entry from /images/{id}/history.
+type dockerImageHistory struct {
+	ID        string   `json:"Id"`
+	Created   int64    `json:"Created"`
+	CreatedBy string   `json:"CreatedBy"`
+	Tags      []string `json:"Tags"`
+	Size      int64    `json:"Size"`
+	Comment   string   `json:"Comment"`
+}
+
+// ImageHistory returns the layer history of an image, newest layer first.
+func (m *DockerClientManager) ImageHistory(ctx context.Context, id string) ([]ImageHistoryEntry, error) {
+	if id == "" {
+		return nil, fmt.Errorf("image not found: %s", id)
+	}
+	resp, err := m.doRequest(ctx, http.MethodGet, "/images/"+id+"/history", nil)
+	if err != nil {
+		return nil, fmt.Errorf("docker: image history: %w", err)
+	}
+	body, err := readBody(resp)
+	if err != nil {
+		return nil, fmt.Errorf("docker: image history: %w", err)
+	}
+	if err := checkAPIError(resp.StatusCode, body, fmt.Sprintf("image not found: %s", id)); err != nil {
+		return nil, fmt.Errorf("docker: image history: %w", err)
+	}
+
+	var raw []dockerImageHistory
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("docker: decode image history: %w", err)
+	}
+
+	history := make([]ImageHistoryEntry, 0, len(raw))
+	for _, h := range raw {
+		history = append(history, ImageHistoryEntry{
+			ID:        h.ID,
+			Created:   time.Unix(h.Created, 0),
+			CreatedBy: h.CreatedBy,
+			Size:      h.Size,
+			Tags:      h.Tags,
+			Comment:   h.Comment,
+		})
+	}
+	return history, nil
+}
+
+// dockerImageDeleteItem is one entry of the response from DELETE /images/{id}
+// and of ImagesDeleted in the /images/prune response.
+type dockerImageDeleteItem struct {
+	Untagged string `json:"Untagged"`
+	Deleted  string `json:"Deleted"`
+}
+
+// RemoveImage removes an image. If force is true, the image is removed even if
+// it is tagged in multiple repositories or used by a stopped container.
+func (m *DockerClientManager) RemoveImage(ctx context.Context, id string, force bool) (*ImageRemoveResult, error) {
+	if id == "" {
+		return nil, fmt.Errorf("image not found: %s", id)
+	}
+	path := fmt.Sprintf("/images/%s?force=%s", id, strconv.FormatBool(force))
+	resp, err := m.doRequest(ctx, http.MethodDelete, path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("docker: remove image: %w", err)
+	}
+	body, err := readBody(resp)
+	if err != nil {
+		return nil, fmt.Errorf("docker: remove image: %w", err)
+	}
+	if err := checkAPIError(resp.StatusCode, body, fmt.Sprintf("image not found: %s", id)); err != nil {
+		return nil, fmt.Errorf("docker: remove image: %w", err)
+	}
+
+	var raw []dockerImageDeleteItem
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("docker: decode image remove response: %w", err)
+	}
+
+	result := &ImageRemoveResult{}
+	for _, item := range raw {
+		if item.Untagged != "" {
+			result.Untagged = append(result.Untagged, item.Untagged)
+		}
+		if item.Deleted != "" {
+			result.Deleted = append(result.Deleted, item.Deleted)
+		}
+	}
+	return result, nil
+}
+
+// dockerImagePrune is the JSON shape returned by /images/prune.
+type dockerImagePrune struct {
+	ImagesDeleted  []dockerImageDeleteItem `json:"ImagesDeleted"`
+	SpaceReclaimed uint64                  `json:"SpaceReclaimed"`
+}
+
+// PruneImages removes unused images. If all is false, only dangling
+// (untagged) images are removed; otherwise every image not used by a
+// container is removed.
+func (m *DockerClientManager) PruneImages(ctx context.Context, all bool) (*ImagePruneReport, error) {
+	filters, err := encodeFilters(map[string][]string{
+		"dangling": {strconv.FormatBool(!all)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("docker: encode prune filters: %w", err)
+	}
+	path := "/images/prune?filters=" + filters
+	resp, err := m.doRequest(ctx, http.MethodPost, path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("docker: prune images: %w", err)
+	}
+	body, err := readBody(resp)
+	if err != nil {
+		return nil, fmt.Errorf("docker: prune images: %w", err)
+	}
+	if err := checkAPIError(resp.StatusCode, body, "image not found"); err != nil {
+		return nil, fmt.Errorf("docker: prune images: %w", err)
+	}
+
+	var raw dockerImagePrune
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("docker: decode prune response: %w", err)
+	}
+
+	report := &ImagePruneReport{SpaceReclaimed: raw.SpaceReclaimed}
+	for _, item := range raw.ImagesDeleted {
+		if item.Deleted != "" {
+			report.ImagesDeleted = append(report.ImagesDeleted, item.Deleted)
+		}
+	}
+	return report, nil
+}
+
+// normalizeRepoTags drops the "<none>:<none>" placeholder Docker reports for
+// untagged images.
+func normalizeRepoTags(tags []string) []string {
+	out := make([]string, 0, len(tags))
+	for _, t := range tags {
+		if t != "<none>:<none>" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// sortedKeys returns the keys of a set-style JSON object in sorted order.
+func sortedKeys(m map[string]struct{}) []string {
+	if len(m) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,124 @@
+package docker
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// ---------------------------------------------------------------------------
+// Image operations against a fake daemon
+// ---------------------------------------------------------------------------
+
+func Test_DockerClientManager_ListImages(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /images/json", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("all"); got != "true" {
+			t.Errorf("query all = %q, want %q", got, "true")
+		}
+		writeJSON(w, http.StatusOK, `[
+			{"Id":"sha256:aaa","RepoTags":["nginx:latest"],"Created":1700000000,"Size":100,"Containers":2},
+			{"Id":"sha256:bbb","RepoTags":["<none>:<none>"],"Created":1700000001,"Size":50,"Containers":0}
+		]`)
+	})
+	mgr := newFakeDaemon(t, mux)
+
+	images, err := mgr.ListImages(t.Context(), true)
+	if err != nil {
+		t.Fatalf("ListImages: %v", err)
+	}
+	if len(images) != 2 {
+		t.Fatalf("got %d images, want 2", len(images))
+	}
+	if images[0].Dangling || len(images[0].RepoTags) != 1 || images[0].Containers != 2 {
+		t.Errorf("images[0] = %+v", images[0])
+	}
+	if !images[1].Dangling || len(images[1].RepoTags) != 0 {
+		t.Errorf("images[1] should be dangling with no tags, got %+v", images[1])
+	}
+}
+
+func Test_DockerClientManager_InspectImage(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /images/nginx:latest/json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, `{
+			"Id":"sha256:aaa","RepoTags":["nginx:latest"],"RepoDigests":["nginx@sha256:ddd"],
+			"Created":"2026-01-02T03:04:05Z","Architecture":"amd64","Os":"linux","Size":100,
+			"Config":{"Cmd":["nginx"],"ExposedPorts":{"80/tcp":{},"443/tcp":{}},"Labels":{"a":"b"}}
+		}`)
+	})
+	mux.HandleFunc("GET /images/missing/json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusNotFound, `{"message":"No such image: missing"}`)
+	})
+	mgr := newFakeDaemon(t, mux)
+
+	detail, err := mgr.InspectImage(t.Context(), "nginx:latest")
+	if err != nil {
+		t.Fatalf("InspectImage: %v", err)
+	}
+	if detail.Architecture != "amd64" || detail.OS != "linux" {
+		t.Errorf("platform = %s/%s, want linux/amd64", detail.OS, detail.Architecture)
+	}
+	if got := strings.Join(detail.Config.ExposedPorts, ","); got != "443/tcp,80/tcp" {
+		t.Errorf("ExposedPorts = %q, want sorted %q", got, "443/tcp,80/tcp")
+	}
+	if detail.Created.Year() != 2026 {
+		t.Errorf("Created = %v, want 2026", detail.Created)
+	}
+
+	_, err = mgr.InspectImage(t.Context(), "missing")
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("err = %v, want it to contain %q", err, "not found")
+	}
+}
+
+func Test_DockerClientManager_RemoveImage(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("DELETE /images/nginx:latest", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("force"); got != "true" {
+			t.Errorf("query force = %q, want %q", got, "true")
+		}
+		writeJSON(w, http.StatusOK, `[{"Untagged":"nginx:latest"},{"Deleted":"sha256:aaa"},{"Deleted":"sha256:layer"}]`)
+	})
+	mgr := newFakeDaemon(t, mux)
+
+	result, err := mgr.RemoveImage(t.Context(), "nginx:latest", true)
+	if err != nil {
+		t.Fatalf("RemoveImage: %v", err)
+	}
+	if len(result.Untagged) != 1 || len(result.Deleted) != 2 {
+		t.Errorf("result = %+v, want 1 untagged and 2 deleted", result)
+	}
+}
+
+func Test_DockerClientManager_PruneImages_Filters(t *testing.T) {
+	tests := []struct {
+		name       string
+		all        bool
+		wantFilter string
+	}{
+		{name: "dangling only", all: false, wantFilter: `{"dangling":["true"]}`},
+		{name: "all unused", all: true, wantFilter: `{"dangling":["false"]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("POST /images/prune", func(w http.ResponseWriter, r *http.Request) {
+				if got := r.URL.Query().Get("filters"); got != tt.wantFilter {
+					t.Errorf("filters = %q, want %q", got, tt.wantFilter)
+				}
+				writeJSON(w, http.StatusOK, `{"ImagesDeleted":[{"Untagged":"x:1"},{"Deleted":"sha256:bbb"}],"SpaceReclaimed":4096}`)
+			})
+			mgr := newFakeDaemon(t, mux)
+
+			report, err := mgr.PruneImages(t.Context(), tt.all)
+			if err != nil {
+				t.Fatalf("PruneImages: %v", err)
+			}
+			if len(report.ImagesDeleted) != 1 || report.SpaceReclaimed != 4096 {
+				t.Errorf("report = %+v", report)
+			}
+		})
+	}
+}
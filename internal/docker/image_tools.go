@@ -0,0 +1,183 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+	"github.com/jamesprial/unraid-mcp/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func toolDockerImageList(mgr DockerManager, audit *safety.AuditLogger) tools.Registration {
+	tool := mcp.NewTool("docker_image_list",
+		mcp.WithDescription("List Docker images with their tags, sizes, and whether they are dangling."),
+		mcp.WithBoolean("all",
+			mcp.Description("Include intermediate layer images (default: false)"),
+		),
+		mcp.WithBoolean("dangling",
+			mcp.Description("Only list dangling (untagged) images; with all, intermediate layers are included too (default: false)"),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		all := req.GetBool("all", false)
+		dangling := req.GetBool("dangling", false)
+		params := map[string]any{"all": all, "dangling": dangling}
+
+		images, err := mgr.ListImages(ctx, all)
+		if err != nil {
+			tools.LogAudit(audit, "docker_image_list", params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+		if dangling {
+			images = slices.DeleteFunc(images, func(img Image) bool { return !img.Dangling })
+		}
+
+		tools.LogAudit(audit, "docker_image_list", params, "ok", start)
+		return tools.JSONResult(images), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
+
+func toolDockerImageInspect(mgr DockerManager, audit *safety.AuditLogger) tools.Registration {
+	tool := mcp.NewTool("docker_image_inspect",
+		mcp.WithDescription("Inspect a Docker image and return its full details."),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("Image ID or reference (e.g. nginx:latest)"),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		id := req.GetString("id", "")
+		params := map[string]any{"id": id}
+
+		detail, err := mgr.InspectImage(ctx, id)
+		if err != nil {
+			tools.LogAudit(audit, "docker_image_inspect", params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+
+		tools.LogAudit(audit, "docker_image_inspect", params, "ok", start)
+		return tools.JSONResult(detail), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
+
+func toolDockerImageHistory(mgr DockerManager, audit *safety.AuditLogger) tools.Registration {
+	tool := mcp.NewTool("docker_image_history",
+		mcp.WithDescription("Show the layer history of a Docker image, newest layer first."),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("Image ID or reference (e.g. nginx:latest)"),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		id := req.GetString("id", "")
+		params := map[string]any{"id": id}
+
+		history, err := mgr.ImageHistory(ctx, id)
+		if err != nil {
+			tools.LogAudit(audit, "docker_image_history", params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+
+		tools.LogAudit(audit, "docker_image_history", params, "ok", start)
+		return tools.JSONResult(history), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
+
+func toolDockerImageRemove(mgr DockerManager, confirm *safety.ConfirmationTracker, audit *safety.AuditLogger) tools.Registration {
+	const toolName = "docker_image_remove"
+
+	tool := mcp.NewTool(toolName,
+		mcp.WithDescription("Remove a Docker image. Requires confirmation."),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("Image ID or reference (e.g. nginx:latest)"),
+		),
+		mcp.WithBoolean("force",
+			mcp.Description("Remove even if the image has multiple tags or is used by a stopped container (default: false)"),
+		),
+		mcp.WithString("confirmation_token",
+			mcp.Description("Confirmation token returned by a prior call to this tool"),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		id := req.GetString("id", "")
+		force := req.GetBool("force", false)
+		token := req.GetString("confirmation_token", "")
+		params := map[string]any{"id": id, "force": force}
+
+		if !confirm.Confirm(token, toolName, id, params) {
+			desc := fmt.Sprintf("This will permanently remove image %q (force=%v).", id, force)
+			return tools.ConfirmPrompt(confirm, toolName, id, desc, params), nil
+		}
+
+		result, err := mgr.RemoveImage(ctx, id, force)
+		if err != nil {
+			tools.LogAudit(audit, toolName, params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+
+		tools.LogAudit(audit, toolName, params, "ok", start)
+		return tools.JSONResult(result), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
+
+func toolDockerImagePrune(mgr DockerManager, confirm *safety.ConfirmationTracker, audit *safety.AuditLogger) tools.Registration {
+	const toolName = "docker_image_prune"
+
+	tool := mcp.NewTool(toolName,
+		mcp.WithDescription("Remove unused Docker images. By default only dangling (untagged) images are removed. Requires confirmation."),
+		mcp.WithBoolean("all",
+			mcp.Description("Remove every image not used by a container, not just dangling ones (default: false)"),
+		),
+		mcp.WithString("confirmation_token",
+			mcp.Description("Confirmation token returned by a prior call to this tool"),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		all := req.GetBool("all", false)
+		token := req.GetString("confirmation_token", "")
+		params := map[string]any{"all": all}
+
+		if !confirm.Confirm(token, toolName, "images", params) {
+			desc := "This will permanently remove all dangling images."
+			if all {
+				desc = "This will permanently remove ALL images not used by at least one container."
+			}
+			return tools.ConfirmPrompt(confirm, toolName, "images", desc, params), nil
+		}
+
+		report, err := mgr.PruneImages(ctx, all)
+		if err != nil {
+			tools.LogAudit(audit, toolName, params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+
+		tools.LogAudit(audit, toolName, params, "ok", start)
+		return tools.JSONResult(report), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
@@ -19,32 +19,49 @@ import (
 
 var _ ContainerManager = (*DockerClientManager)(nil)
 var _ NetworkManager = (*DockerClientManager)(nil)
+var _ ImageManager = (*DockerClientManager)(nil)
+var _ VolumeManager = (*DockerClientManager)(nil)
+var _ FileManager = (*DockerClientManager)(nil)
+var _ SystemManager = (*DockerClientManager)(nil)
 var _ DockerManager = (*DockerClientManager)(nil)
 
 var _ ContainerManager = (*MockDockerManager)(nil)
 var _ NetworkManager = (*MockDockerManager)(nil)
+var _ ImageManager = (*MockDockerManager)(nil)
+var _ VolumeManager = (*MockDockerManager)(nil)
+var _ FileManager = (*MockDockerManager)(nil)
+var _ SystemManager = (*MockDockerManager)(nil)
 var _ DockerManager = (*MockDockerManager)(nil)
 
 // ---------------------------------------------------------------------------
 // Structural equivalence via reflection
 // ---------------------------------------------------------------------------
 
+// dockerSubInterfaces returns the interfaces embedded in DockerManager.
+func dockerSubInterfaces() []reflect.Type {
+	return []reflect.Type{
+		reflect.TypeOf((*ContainerManager)(nil)).Elem(),
+		reflect.TypeOf((*NetworkManager)(nil)).Elem(),
+		reflect.TypeOf((*ImageManager)(nil)).Elem(),
+		reflect.TypeOf((*VolumeManager)(nil)).Elem(),
+		reflect.TypeOf((*FileManager)(nil)).Elem(),
+		reflect.TypeOf((*SystemManager)(nil)).Elem(),
+	}
+}
+
 // Test_DockerManager_MethodCount verifies that the composite DockerManager
-// interface exposes exactly the sum of ContainerManager and NetworkManager
-// methods with no extras and no missing methods.
+// interface exposes exactly the sum of its sub-interface methods with no
+// extras and no missing methods.
 func Test_DockerManager_MethodCount(t *testing.T) {
 	dockerManagerType := reflect.TypeOf((*DockerManager)(nil)).Elem()
-	containerManagerType := reflect.TypeOf((*ContainerManager)(nil)).Elem()
-	networkManagerType := reflect.TypeOf((*NetworkManager)(nil)).Elem()
 
-	got := dockerManagerType.NumMethod()
-	want := containerManagerType.NumMethod() + networkManagerType.NumMethod()
+	want := 0
+	for _, sub := range dockerSubInterfaces() {
+		want += sub.NumMethod()
+	}
 
-	if got != want {
-		t.Errorf(
-			"DockerManager.NumMethod() = %d, want ContainerManager.NumMethod() (%d) + NetworkManager.NumMethod() (%d) = %d",
-			got, containerManagerType.NumMethod(), networkManagerType.NumMethod(), want,
-		)
+	if got := dockerManagerType.NumMethod(); got != want {
+		t.Errorf("DockerManager.NumMethod() = %d, want sum of sub-interface methods = %d", got, want)
 	}
 }
 
@@ -77,12 +94,12 @@ func Test_ContainerManager_NetworkManager_NoOverlap(t *testing.T) {
 }
 
 // Test_ContainerManager_MethodCount verifies that ContainerManager defines
-// exactly 10 methods corresponding to the container operations.
+// exactly 15 methods corresponding to the container operations.
 func Test_ContainerManager_MethodCount(t *testing.T) {
 	containerManagerType := reflect.TypeOf((*ContainerManager)(nil)).Elem()
 
 	got := containerManagerType.NumMethod()
-	want := 10
+	want := 15
 
 	if got != want {
 		t.Errorf("ContainerManager.NumMethod() = %d, want %d", got, want)
@@ -119,7 +136,7 @@ func Test_NetworkManager_MethodCount(t *testing.T) {
 // ---------------------------------------------------------------------------
 
 // Test_ContainerManager_ExpectedMethods verifies that ContainerManager
-// contains exactly the 10 expected method names from the specification.
+// contains exactly the 15 expected method names from the specification.
 func Test_ContainerManager_ExpectedMethods(t *testing.T) {
 	containerManagerType := reflect.TypeOf((*ContainerManager)(nil)).Elem()
 
@@ -130,9 +147,14 @@ func Test_ContainerManager_ExpectedMethods(t *testing.T) {
 		"StopContainer",
 		"RestartContainer",
 		"RemoveContainer",
+		"RenameContainer",
 		"CreateContainer",
 		"PullImage",
+		"PullImageWithProgress",
 		"GetLogs",
+		"GetContainerLogs",
+		"UpdateContainer",
+		"ExecContainer",
 		"GetStats",
 	}
 
@@ -201,31 +223,62 @@ func Test_NetworkManager_ExpectedMethods(t *testing.T) {
 // ---------------------------------------------------------------------------
 
 // Test_DockerManager_ContainsAllSubInterfaceMethods verifies that every method
-// from ContainerManager and NetworkManager is present in DockerManager, which
-// confirms the embedding relationship is correct.
+// from each sub-interface is present in DockerManager, which confirms the
+// embedding relationship is correct.
 func Test_DockerManager_ContainsAllSubInterfaceMethods(t *testing.T) {
 	dockerManagerType := reflect.TypeOf((*DockerManager)(nil)).Elem()
-	containerManagerType := reflect.TypeOf((*ContainerManager)(nil)).Elem()
-	networkManagerType := reflect.TypeOf((*NetworkManager)(nil)).Elem()
 
 	dockerMethods := make(map[string]struct{}, dockerManagerType.NumMethod())
 	for i := 0; i < dockerManagerType.NumMethod(); i++ {
 		dockerMethods[dockerManagerType.Method(i).Name] = struct{}{}
 	}
 
-	// Every ContainerManager method must be in DockerManager.
-	for i := 0; i < containerManagerType.NumMethod(); i++ {
-		name := containerManagerType.Method(i).Name
-		if _, ok := dockerMethods[name]; !ok {
-			t.Errorf("DockerManager is missing ContainerManager method %q", name)
+	for _, sub := range dockerSubInterfaces() {
+		for i := 0; i < sub.NumMethod(); i++ {
+			name := sub.Method(i).Name
+			if _, ok := dockerMethods[name]; !ok {
+				t.Errorf("DockerManager is missing %s method %q", sub.Name(), name)
+			}
 		}
 	}
+}
 
-	// Every NetworkManager method must be in DockerManager.
-	for i := 0; i < networkManagerType.NumMethod(); i++ {
-		name := networkManagerType.Method(i).Name
-		if _, ok := dockerMethods[name]; !ok {
-			t.Errorf("DockerManager is missing NetworkManager method %q", name)
+// Test_DockerManager_SubInterfaces_NoOverlap verifies that no method name is
+// declared by more than one sub-interface.
+func Test_DockerManager_SubInterfaces_NoOverlap(t *testing.T) {
+	owners := make(map[string]string)
+	for _, sub := range dockerSubInterfaces() {
+		for i := 0; i < sub.NumMethod(); i++ {
+			name := sub.Method(i).Name
+			if owner, exists := owners[name]; exists {
+				t.Errorf("method %q is declared by both %s and %s", name, owner, sub.Name())
+				continue
+			}
+			owners[name] = sub.Name()
+		}
+	}
+}
+
+// Test_ImageManager_ExpectedMethods verifies that ImageManager contains
+// exactly the expected image operations.
+func Test_ImageManager_ExpectedMethods(t *testing.T) {
+	imageManagerType := reflect.TypeOf((*ImageManager)(nil)).Elem()
+
+	expected := map[string]struct{}{
+		"ListImages":   {},
+		"InspectImage": {},
+		"ImageHistory": {},
+		"RemoveImage":  {},
+		"PruneImages":  {},
+	}
+
+	if got := imageManagerType.NumMethod(); got != len(expected) {
+		t.Errorf("ImageManager.NumMethod() = %d, want %d", got, len(expected))
+	}
+	for i := 0; i < imageManagerType.NumMethod(); i++ {
+		name := imageManagerType.Method(i).Name
+		if _, ok := expected[name]; !ok {
+			t.Errorf("ImageManager has unexpected method %q", name)
 		}
 	}
 }
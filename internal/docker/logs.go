@@ -0,0 +1,215 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Stream type bytes used in the header of Docker's multiplexed log stream.
+const (
+	streamStdout = 1
+	streamStderr = 2
+)
+
+// ParseLogTime parses a since/until value for log queries. It accepts an
+// RFC3339 timestamp ("2026-01-02T15:04:05Z") or a relative duration such as
+// "15m" or "2h", which is counted back from now. An empty value returns the
+// zero time.
+func ParseLogTime(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: want RFC3339 or a duration like 15m", value)
+	}
+	if d < 0 {
+		return time.Time{}, fmt.Errorf("invalid time %q: duration must be positive", value)
+	}
+	return now.Add(-d), nil
+}
+
+// formatLogTime renders t as the fractional Unix timestamp Docker expects for
+// the since and until query parameters.
+func formatLogTime(t time.Time) string {
+	return fmt.Sprintf("%d.%09d", t.Unix(), t.Nanosecond())
+}
+
+// logCollector accumulates log lines, applying the grep filter and byte cap
+// from LogOptions as lines arrive so memory stays bounded for chatty
+// containers.
+type logCollector struct {
+	opts      LogOptions
+	lines     []LogLine
+	size      int
+	truncated bool
+}
+
+// add records a single raw line from the given stream. When timestamps were
+// requested, Docker prefixes each line with an RFC3339Nano timestamp and a
+// space, which is split off into LogLine.Timestamp.
+func (c *logCollector) add(stream, raw string) {
+	line := LogLine{Stream: stream, Text: strings.TrimSuffix(raw, "\r")}
+	if c.opts.Timestamps {
+		if ts, rest, ok := strings.Cut(line.Text, " "); ok {
+			line.Timestamp = ts
+			line.Text = rest
+		}
+	}
+	if c.opts.Grep != nil && !c.opts.Grep.MatchString(line.Text) {
+		return
+	}
+	// A line longer than the cap on its own is cut rather than dropped, so
+	// the result is never left empty.
+	if over := lineSize(line) - c.opts.MaxBytes; c.opts.MaxBytes > 0 && over > 0 {
+		line.Text = cutUTF8(line.Text, max(len(line.Text)-over, 0))
+		c.truncated = true
+	}
+
+	c.lines = append(c.lines, line)
+	c.size += lineSize(line)
+
+	if c.opts.MaxBytes <= 0 {
+		return
+	}
+	drop := 0
+	for c.size > c.opts.MaxBytes && drop < len(c.lines) {
+		c.size -= lineSize(c.lines[drop])
+		drop++
+	}
+	if drop > 0 {
+		c.lines = append(c.lines[:0], c.lines[drop:]...)
+		c.truncated = true
+	}
+}
+
+// result returns the collected lines as a ContainerLogs value.
+func (c *logCollector) result(tty bool) *ContainerLogs {
+	return &ContainerLogs{
+		Lines:     c.lines,
+		TTY:       tty,
+		Truncated: c.truncated,
+	}
+}
+
+// lineSize returns the number of bytes a line occupies in formatted output.
+func lineSize(l LogLine) int {
+	n := len(l.Text) + 1
+	if l.Timestamp != "" {
+		n += len(l.Timestamp) + 1
+	}
+	return n
+}
+
+// cutUTF8 cuts s to at most n bytes, backing off to a rune boundary so that
+// a multi-byte character is never split.
+func cutUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// lineWriter splits the output of one stream into lines for a collector.
+// It buffers at most one byte more of an unfinished line than the
+// collector's cap, enough for add to see that the line is too long, and
+// discards the rest up to the next newline, so a stream without newlines
+// cannot grow memory without bound.
+type lineWriter struct {
+	c      *logCollector
+	stream string
+	buf    []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		chunk, rest, found := bytes.Cut(p, []byte{'\n'})
+		if limit := w.c.opts.MaxBytes + 1; w.c.opts.MaxBytes > 0 && len(w.buf)+len(chunk) > limit {
+			chunk = chunk[:max(limit-len(w.buf), 0)]
+		}
+		w.buf = append(w.buf, chunk...)
+		if !found {
+			break
+		}
+		w.flush()
+		p = rest
+	}
+	return n, nil
+}
+
+// flush hands any buffered line to the collector.
+func (w *lineWriter) flush() {
+	if len(w.buf) > 0 {
+		w.c.add(w.stream, string(w.buf))
+		w.buf = w.buf[:0]
+	}
+}
+
+// readRawLogs reads an unmultiplexed log stream, as produced for containers
+// started with a TTY, and feeds each line to c.
+func readRawLogs(r io.Reader, c *logCollector) error {
+	w := &lineWriter{c: c}
+	if _, err := io.Copy(w, r); err != nil {
+		return fmt.Errorf("docker: read log stream: %w", err)
+	}
+	// Flush any trailing output that did not end in a newline.
+	w.flush()
+	return nil
+}
+
+// readMultiplexedLogs demultiplexes Docker's framed stdout/stderr stream and
+// feeds each complete line to c. Each frame has an 8-byte header: byte 0 is
+// the stream type and bytes 4-7 are the big-endian payload size. A line may
+// span several frames, so partial lines are buffered per stream.
+func readMultiplexedLogs(r io.Reader, c *logCollector) error {
+	pending := map[byte]*lineWriter{
+		streamStdout: {c: c, stream: streamName(streamStdout)},
+		streamStderr: {c: c, stream: streamName(streamStderr)},
+	}
+	header := make([]byte, 8)
+	for {
+		_, err := io.ReadFull(r, header)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("docker: read log frame header: %w", err)
+		}
+		size := binary.BigEndian.Uint32(header[4:8])
+		var w io.Writer = io.Discard // unknown stream (e.g. stdin)
+		if lw, ok := pending[header[0]]; ok {
+			w = lw
+		}
+		if _, err := io.CopyN(w, r, int64(size)); err != nil {
+			return fmt.Errorf("docker: read log frame: %w", err)
+		}
+	}
+	// Flush any trailing output that did not end in a newline.
+	for _, s := range []byte{streamStdout, streamStderr} {
+		pending[s].flush()
+	}
+	return nil
+}
+
+// streamName maps a multiplexed stream type byte to its name.
+func streamName(s byte) string {
+	if s == streamStderr {
+		return "stderr"
+	}
+	return "stdout"
+}
@@ -0,0 +1,198 @@
+package docker
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_ParseLogTime_Cases(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "empty is zero", value: "", want: time.Time{}},
+		{name: "RFC3339", value: "2026-02-28T10:00:00Z", want: time.Date(2026, 2, 28, 10, 0, 0, 0, time.UTC)},
+		{name: "relative minutes", value: "15m", want: now.Add(-15 * time.Minute)},
+		{name: "relative hours", value: "2h", want: now.Add(-2 * time.Hour)},
+		{name: "negative duration", value: "-5m", wantErr: true},
+		{name: "garbage", value: "yesterday", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLogTime(tt.value, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseLogTime(%q) expected error, got %v", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseLogTime(%q) unexpected error: %v", tt.value, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseLogTime(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ReadMultiplexedLogs_SplitFrames(t *testing.T) {
+	// A single stdout line split across two frames, interleaved with stderr,
+	// plus a trailing partial line with no newline.
+	stream := muxFrame(streamStdout, "hel") +
+		muxFrame(streamStderr, "err1\n") +
+		muxFrame(streamStdout, "lo\nworld") +
+		muxFrame(0, "stdin-ignored")
+
+	c := &logCollector{opts: LogOptions{Stdout: true, Stderr: true}}
+	if err := readMultiplexedLogs(strings.NewReader(stream), c); err != nil {
+		t.Fatalf("readMultiplexedLogs: %v", err)
+	}
+
+	want := []LogLine{
+		{Stream: "stderr", Text: "err1"},
+		{Stream: "stdout", Text: "hello"},
+		{Stream: "stdout", Text: "world"},
+	}
+	if len(c.lines) != len(want) {
+		t.Fatalf("got %d lines %+v, want %d", len(c.lines), c.lines, len(want))
+	}
+	for i := range want {
+		if c.lines[i] != want[i] {
+			t.Errorf("line %d = %+v, want %+v", i, c.lines[i], want[i])
+		}
+	}
+}
+
+func Test_ReadRawLogs_DoesNotCorruptTTYOutput(t *testing.T) {
+	// TTY output has no frame headers; the old 8-byte parser would have
+	// consumed "abcdefgh" as a header.
+	c := &logCollector{opts: LogOptions{Stdout: true, Stderr: true}}
+	if err := readRawLogs(strings.NewReader("abcdefgh ijkl\nsecond"), c); err != nil {
+		t.Fatalf("readRawLogs: %v", err)
+	}
+	if len(c.lines) != 2 || c.lines[0].Text != "abcdefgh ijkl" || c.lines[1].Text != "second" {
+		t.Errorf("lines = %+v", c.lines)
+	}
+}
+
+func Test_LogCollector_GrepAndMaxBytes(t *testing.T) {
+	c := &logCollector{opts: LogOptions{
+		Grep:     regexp.MustCompile(`ERROR`),
+		MaxBytes: 20,
+	}}
+	for _, l := range []string{"ERROR one", "info skip", "ERROR two", "ERROR three"} {
+		c.add("stdout", l)
+	}
+
+	res := c.result(false)
+	if !res.Truncated {
+		t.Error("expected Truncated to be true")
+	}
+	for _, l := range res.Lines {
+		if !strings.Contains(l.Text, "ERROR") {
+			t.Errorf("line %q should have been filtered by grep", l.Text)
+		}
+	}
+	if got := res.Lines[len(res.Lines)-1].Text; got != "ERROR three" {
+		t.Errorf("newest line = %q, want %q", got, "ERROR three")
+	}
+	total := 0
+	for _, l := range res.Lines {
+		total += lineSize(l)
+	}
+	if total > 20 {
+		t.Errorf("retained %d bytes, want <= 20", total)
+	}
+}
+
+func Test_LogCollector_CutsOversizedLine(t *testing.T) {
+	c := &logCollector{opts: LogOptions{MaxBytes: 10}}
+	c.add("stdout", "short")
+	c.add("stdout", "héééééééééé tail")
+
+	res := c.result(false)
+	if !res.Truncated || len(res.Lines) != 1 {
+		t.Fatalf("lines = %+v, truncated %v; want only the cut line", res.Lines, res.Truncated)
+	}
+	if got := res.Lines[0].Text; got != "héééé" || lineSize(res.Lines[0]) > 10 {
+		t.Errorf("cut line = %q, want %q", got, "héééé")
+	}
+}
+
+func Test_ReadLogs_BoundsUnfinishedLine(t *testing.T) {
+	long := strings.Repeat("x", 1<<20)
+	streams := map[string]func(*logCollector) error{
+		"raw": func(c *logCollector) error { return readRawLogs(strings.NewReader(long+"\n"), c) },
+		"multiplexed": func(c *logCollector) error {
+			return readMultiplexedLogs(strings.NewReader(muxFrame(streamStdout, long)+muxFrame(streamStdout, "\n")), c)
+		},
+	}
+	for name, read := range streams {
+		t.Run(name, func(t *testing.T) {
+			c := &logCollector{opts: LogOptions{MaxBytes: 100}}
+			if err := read(c); err != nil {
+				t.Fatal(err)
+			}
+			if !c.truncated || len(c.lines) != 1 || len(c.lines[0].Text) != 99 {
+				t.Errorf("lines = %d, truncated %v; want one line cut to 99 bytes", len(c.lines), c.truncated)
+			}
+		})
+	}
+
+	w := &lineWriter{c: &logCollector{opts: LogOptions{MaxBytes: 100}}}
+	for range 100 {
+		_, _ = w.Write([]byte(long[:4096]))
+	}
+	if len(w.buf) > 101 {
+		t.Errorf("buffered %d bytes of an unfinished line, want at most 101", len(w.buf))
+	}
+}
+
+func Test_BuildLogOptions_Validation(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name       string
+		stdoutOnly bool
+		stderrOnly bool
+		since      string
+		until      string
+		grep       string
+		wantErr    string
+	}{
+		{name: "both stream filters", stdoutOnly: true, stderrOnly: true, wantErr: "mutually exclusive"},
+		{name: "bad grep", grep: "(", wantErr: "invalid grep"},
+		{name: "until before since", since: "5m", until: "10m", wantErr: "until must be after since"},
+		{name: "bad since", since: "nope", wantErr: "since"},
+		{name: "valid", since: "1h", until: "5m", grep: "err"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := buildLogOptions(now, 0, tt.since, tt.until, false, tt.stdoutOnly, tt.stderrOnly, tt.grep, 0)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !opts.Stdout || !opts.Stderr {
+				t.Error("both streams should be selected by default")
+			}
+			if opts.MaxBytes != defaultLogMaxBytes {
+				t.Errorf("MaxBytes = %d, want default %d", opts.MaxBytes, defaultLogMaxBytes)
+			}
+		})
+	}
+}
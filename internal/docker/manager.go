@@ -7,50 +7,38 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
+	"net/url"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
-// dockerAPIVersion is the minimum Docker API version this client targets.
-const dockerAPIVersion = "v1.41"
-
 // DockerClientManager is a real implementation of DockerManager that communicates
-// with the Docker daemon over a Unix socket using the Docker HTTP API.
+// with the Docker daemon over its HTTP API, on a Unix socket or a TCP endpoint.
 type DockerClientManager struct {
-	client     *http.Client
-	socketPath string
-	baseURL    string
+	client       *http.Client
+	streamClient *http.Client         // no overall timeout; bounded by the request context
+	host         string               // as configured, e.g. unix:///var/run/docker.sock
+	baseURL      string               // scheme and host, without the API version
+	registryAuth *RegistryCredentials // nil pulls anonymously
+
+	versionMu      sync.Mutex
+	apiVersion     string    // e.g. "1.43"; empty until negotiated
+	negotiating    bool      // a negotiation is in flight
+	nextNegotiable time.Time // no negotiation before this, after a failed one
 }
 
 // NewDockerClientManager creates a new DockerClientManager that connects to the
-// Docker daemon at the given Unix socket path. It configures the HTTP client with
-// a custom dialer that routes all requests through the Unix socket.
+// Docker daemon at the given Unix socket path. See NewDockerClient for other
+// endpoints.
 func NewDockerClientManager(socketPath string) (*DockerClientManager, error) {
 	if socketPath == "" {
 		return nil, fmt.Errorf("docker: socket path is required")
 	}
-
-	transport := &http.Transport{
-		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
-			return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
-		},
-	}
-
-	client := &http.Client{
-		Transport: transport,
-		Timeout:   30 * time.Second,
-	}
-
-	return &DockerClientManager{
-		client:     client,
-		socketPath: socketPath,
-		// The host in the URL is ignored when using a Unix socket transport;
-		// Docker requires a non-empty hostname so we use "localhost".
-		baseURL: fmt.Sprintf("http://localhost/%s", dockerAPIVersion),
-	}, nil
+	return NewDockerClient(ClientConfig{Host: "unix://" + socketPath})
 }
 
 // ---------------------------------------------------------------------------
@@ -60,8 +48,7 @@ func NewDockerClientManager(socketPath string) (*DockerClientManager, error) {
 // doRequest performs an HTTP request against the Docker daemon and returns the
 // response body. The caller is responsible for closing the body.
 func (m *DockerClientManager) doRequest(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
-	url := m.baseURL + path
-	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	req, err := http.NewRequestWithContext(ctx, method, m.apiURL(ctx, path), body)
 	if err != nil {
 		return nil, fmt.Errorf("docker: build request: %w", err)
 	}
@@ -75,6 +62,23 @@ func (m *DockerClientManager) doRequest(ctx context.Context, method, path string
 	return resp, nil
 }
 
+// doStreamRequest is like doRequest but uses a client without an overall
+// timeout, for long-running streams whose duration is bounded by ctx instead.
+func (m *DockerClientManager) doStreamRequest(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, m.apiURL(ctx, path), body)
+	if err != nil {
+		return nil, fmt.Errorf("docker: build request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := m.streamClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("docker: request failed: %w", err)
+	}
+	return resp, nil
+}
+
 // readBody reads the full response body and closes it.
 func readBody(resp *http.Response) ([]byte, error) {
 	defer func() { _ = resp.Body.Close() }()
@@ -114,6 +118,7 @@ type dockerContainer struct {
 	ID      string   `json:"Id"`
 	Names   []string `json:"Names"`
 	Image   string   `json:"Image"`
+	ImageID string   `json:"ImageID"`
 	State   string   `json:"State"`
 	Status  string   `json:"Status"`
 	Created int64    `json:"Created"`
@@ -153,8 +158,10 @@ func (m *DockerClientManager) ListContainers(ctx context.Context, all bool) ([]C
 			ID:      c.ID,
 			Name:    name,
 			Image:   c.Image,
+			ImageID: c.ImageID,
 			State:   c.State,
 			Status:  c.Status,
+			Health:  healthFromStatus(c.Status),
 			Created: time.Unix(c.Created, 0),
 		})
 	}
@@ -165,15 +172,43 @@ func (m *DockerClientManager) ListContainers(ctx context.Context, all bool) ([]C
 type dockerContainerInspect struct {
 	ID      string `json:"Id"`
 	Name    string `json:"Name"`
+	Image   string `json:"Image"`
 	Created string `json:"Created"`
 	State   struct {
-		Status string `json:"Status"`
+		Status     string `json:"Status"`
+		OOMKilled  bool   `json:"OOMKilled"`
+		ExitCode   int    `json:"ExitCode"`
+		Error      string `json:"Error"`
+		StartedAt  string `json:"StartedAt"`
+		FinishedAt string `json:"FinishedAt"`
+		Health     *struct {
+			Status        string `json:"Status"`
+			FailingStreak int    `json:"FailingStreak"`
+			Log           []struct {
+				Start    string `json:"Start"`
+				End      string `json:"End"`
+				ExitCode int    `json:"ExitCode"`
+				Output   string `json:"Output"`
+			} `json:"Log"`
+		} `json:"Health"`
 	} `json:"State"`
+	RestartCount int `json:"RestartCount"`
+	HostConfig   struct {
+		RestartPolicy struct {
+			Name              string `json:"Name"`
+			MaximumRetryCount int    `json:"MaximumRetryCount"`
+		} `json:"RestartPolicy"`
+		PortBindings map[string][]struct {
+			HostIP   string `json:"HostIp"`
+			HostPort string `json:"HostPort"`
+		} `json:"PortBindings"`
+	} `json:"HostConfig"`
 	Config struct {
 		Image  string            `json:"Image"`
 		Env    []string          `json:"Env"`
 		Cmd    []string          `json:"Cmd"`
 		Labels map[string]string `json:"Labels"`
+		Tty    bool              `json:"Tty"`
 	} `json:"Config"`
 	NetworkSettings struct {
 		IPAddress string `json:"IPAddress"`
@@ -181,8 +216,15 @@ type dockerContainerInspect struct {
 			HostIP   string `json:"HostIp"`
 			HostPort string `json:"HostPort"`
 		} `json:"Ports"`
+		Networks map[string]struct {
+			IPAMConfig *struct {
+				IPv4Address string `json:"IPv4Address"`
+			} `json:"IPAMConfig"`
+		} `json:"Networks"`
 	} `json:"NetworkSettings"`
 	Mounts []struct {
+		Type        string `json:"Type"`
+		Name        string `json:"Name"`
 		Source      string `json:"Source"`
 		Destination string `json:"Destination"`
 		Mode        string `json:"Mode"`
@@ -192,8 +234,8 @@ type dockerContainerInspect struct {
 	// It lives under State.Status in the API response.
 }
 
-// InspectContainer returns detailed information about a container.
-func (m *DockerClientManager) InspectContainer(ctx context.Context, id string) (*ContainerDetail, error) {
+// inspectContainerRaw fetches and decodes /containers/{id}/json.
+func (m *DockerClientManager) inspectContainerRaw(ctx context.Context, id string) (*dockerContainerInspect, error) {
 	if id == "" {
 		return nil, fmt.Errorf("container not found: %s", id)
 	}
@@ -213,6 +255,87 @@ func (m *DockerClientManager) InspectContainer(ctx context.Context, id string) (
 	if err := json.Unmarshal(body, &raw); err != nil {
 		return nil, fmt.Errorf("docker: decode container inspect: %w", err)
 	}
+	return &raw, nil
+}
+
+// Limits applied to the healthcheck log reported by InspectContainer.
+const (
+	maxHealthLogEntries  = 5
+	maxHealthOutputBytes = 1024
+)
+
+// truncateUTF8 cuts s to at most n bytes plus "...", backing off to a rune
+// boundary so that a multi-byte character is never split.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return cutUTF8(s, n) + "..."
+}
+
+// runtimeState extracts the exit, restart and healthcheck state.
+func (raw *dockerContainerInspect) runtimeState() RuntimeState {
+	state := RuntimeState{
+		ExitCode:     raw.State.ExitCode,
+		Error:        raw.State.Error,
+		OOMKilled:    raw.State.OOMKilled,
+		StartedAt:    parseDockerTime(raw.State.StartedAt),
+		FinishedAt:   parseDockerTime(raw.State.FinishedAt),
+		RestartCount: raw.RestartCount,
+		RestartPolicy: RestartPolicy{
+			Name:              raw.HostConfig.RestartPolicy.Name,
+			MaximumRetryCount: raw.HostConfig.RestartPolicy.MaximumRetryCount,
+		},
+	}
+	if h := raw.State.Health; h != nil && h.Status != "" && h.Status != "none" {
+		hs := &HealthState{Status: h.Status, FailingStreak: h.FailingStreak, Log: []HealthLogEntry{}}
+		entries := h.Log
+		if len(entries) > maxHealthLogEntries {
+			entries = entries[len(entries)-maxHealthLogEntries:]
+		}
+		for _, e := range entries {
+			output := strings.TrimSpace(e.Output)
+			output = truncateUTF8(output, maxHealthOutputBytes)
+			hs.Log = append(hs.Log, HealthLogEntry{
+				Start:    parseDockerTime(e.Start),
+				End:      parseDockerTime(e.End),
+				ExitCode: e.ExitCode,
+				Output:   output,
+			})
+		}
+		state.Healthcheck = hs
+	}
+	return state
+}
+
+// parseDockerTime parses a timestamp from the Engine API. Docker reports
+// "0001-01-01T00:00:00Z" for times that never happened, which parses to the
+// zero time.
+func parseDockerTime(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, s)
+	return t
+}
+
+// healthFromStatus extracts the health from a list status such as
+// "Up 2 hours (unhealthy)" or "Up 5 seconds (health: starting)".
+func healthFromStatus(status string) string {
+	switch {
+	case strings.HasSuffix(status, "(healthy)"):
+		return "healthy"
+	case strings.HasSuffix(status, "(unhealthy)"):
+		return "unhealthy"
+	case strings.HasSuffix(status, "(health: starting)"):
+		return "starting"
+	}
+	return ""
+}
+
+// InspectContainer returns detailed information about a container.
+func (m *DockerClientManager) InspectContainer(ctx context.Context, id string) (*ContainerDetail, error) {
+	raw, err := m.inspectContainerRaw(ctx, id)
+	if err != nil {
+		return nil, err
+	}
 
 	created, _ := time.Parse(time.RFC3339Nano, raw.Created)
 
@@ -223,9 +346,41 @@ func (m *DockerClientManager) InspectContainer(ctx context.Context, id string) (
 		}
 	}
 
+	var bindings []PortMapping
+	for portProto, hostPorts := range raw.HostConfig.PortBindings {
+		containerPort, proto, _ := strings.Cut(portProto, "/")
+		cp, err := strconv.Atoi(containerPort)
+		if err != nil {
+			continue
+		}
+		for _, hp := range hostPorts {
+			// An empty or unparsable host port is assigned by the daemon.
+			hostPort, _ := strconv.Atoi(hp.HostPort)
+			bindings = append(bindings, PortMapping{ContainerPort: cp, Protocol: proto, HostIP: hp.HostIP, HostPort: hostPort})
+		}
+	}
+	sort.Slice(bindings, func(i, j int) bool {
+		if bindings[i].HostPort != bindings[j].HostPort {
+			return bindings[i].HostPort < bindings[j].HostPort
+		}
+		return bindings[i].ContainerPort < bindings[j].ContainerPort
+	})
+
+	var staticIPs map[string]string
+	for network, ep := range raw.NetworkSettings.Networks {
+		if ep.IPAMConfig != nil && ep.IPAMConfig.IPv4Address != "" {
+			if staticIPs == nil {
+				staticIPs = make(map[string]string)
+			}
+			staticIPs[network] = ep.IPAMConfig.IPv4Address
+		}
+	}
+
 	mounts := make([]Mount, 0, len(raw.Mounts))
 	for _, mnt := range raw.Mounts {
 		mounts = append(mounts, Mount{
+			Type:        mnt.Type,
+			Name:        mnt.Name,
 			Source:      mnt.Source,
 			Destination: mnt.Destination,
 			ReadOnly:    !mnt.RW,
@@ -233,24 +388,34 @@ func (m *DockerClientManager) InspectContainer(ctx context.Context, id string) (
 	}
 
 	name := strings.TrimPrefix(raw.Name, "/")
+	state := raw.runtimeState()
+	health := ""
+	if state.Healthcheck != nil {
+		health = state.Healthcheck.Status
+	}
 
 	return &ContainerDetail{
 		Container: Container{
 			ID:      raw.ID,
 			Name:    name,
 			Image:   raw.Config.Image,
+			ImageID: raw.Image,
 			State:   raw.State.Status,
 			Status:  raw.State.Status, // Docker API does not expose the human status in inspect
+			Health:  health,
 			Created: created,
 		},
+		RuntimeState: state,
 		Config: ContainerConfig{
 			Env:    raw.Config.Env,
 			Cmd:    raw.Config.Cmd,
 			Labels: raw.Config.Labels,
 		},
 		NetworkSettings: NetworkInfo{
-			IPAddress: raw.NetworkSettings.IPAddress,
-			Ports:     ports,
+			IPAddress:    raw.NetworkSettings.IPAddress,
+			Ports:        ports,
+			PortBindings: bindings,
+			StaticIPs:    staticIPs,
 		},
 		Mounts: mounts,
 	}, nil
@@ -358,53 +523,169 @@ func (m *DockerClientManager) RemoveContainer(ctx context.Context, id string, fo
 
 // containerCreateRequest is the request body for POST /containers/create.
 type containerCreateRequest struct {
-	Image        string              `json:"Image"`
-	Env          []string            `json:"Env,omitempty"`
-	Cmd          []string            `json:"Cmd,omitempty"`
-	Labels       map[string]string   `json:"Labels,omitempty"`
-	ExposedPorts map[string]struct{} `json:"ExposedPorts,omitempty"`
-	HostConfig   containerHostConfig `json:"HostConfig"`
+	Image            string                   `json:"Image"`
+	Env              []string                 `json:"Env,omitempty"`
+	Cmd              []string                 `json:"Cmd,omitempty"`
+	Entrypoint       []string                 `json:"Entrypoint,omitempty"`
+	Labels           map[string]string        `json:"Labels,omitempty"`
+	ExposedPorts     map[string]struct{}      `json:"ExposedPorts,omitempty"`
+	HostConfig       containerHostConfig      `json:"HostConfig"`
+	NetworkingConfig *containerNetworkingConf `json:"NetworkingConfig,omitempty"`
 }
 
 type containerHostConfig struct {
-	PortBindings map[string][]portBinding `json:"PortBindings,omitempty"`
-	Binds        []string                 `json:"Binds,omitempty"`
+	PortBindings  map[string][]portBinding `json:"PortBindings,omitempty"`
+	Binds         []string                 `json:"Binds,omitempty"`
+	RestartPolicy *restartPolicy           `json:"RestartPolicy,omitempty"`
+	NetworkMode   string                   `json:"NetworkMode,omitempty"`
+	ExtraHosts    []string                 `json:"ExtraHosts,omitempty"`
+	Devices       []deviceMapping          `json:"Devices,omitempty"`
+	CapAdd        []string                 `json:"CapAdd,omitempty"`
+	CapDrop       []string                 `json:"CapDrop,omitempty"`
+	Privileged    bool                     `json:"Privileged,omitempty"`
+	Memory        int64                    `json:"Memory,omitempty"`
+	NanoCPUs      int64                    `json:"NanoCpus,omitempty"`
+	CpusetCpus    string                   `json:"CpusetCpus,omitempty"`
 }
 
 type portBinding struct {
+	HostIP   string `json:"HostIp,omitempty"`
 	HostPort string `json:"HostPort"`
 }
 
-// CreateContainer creates a new container from the given configuration and returns
-// the new container's ID.
-func (m *DockerClientManager) CreateContainer(ctx context.Context, config ContainerCreateConfig) (string, error) {
-	if config.Image == "" {
-		return "", fmt.Errorf("image is required")
-	}
+type restartPolicy struct {
+	Name              string `json:"Name"`
+	MaximumRetryCount int    `json:"MaximumRetryCount,omitempty"`
+}
+
+type deviceMapping struct {
+	PathOnHost        string `json:"PathOnHost"`
+	PathInContainer   string `json:"PathInContainer"`
+	CgroupPermissions string `json:"CgroupPermissions"`
+}
+
+type containerNetworkingConf struct {
+	EndpointsConfig map[string]endpointConfig `json:"EndpointsConfig"`
+}
+
+type endpointConfig struct {
+	IPAMConfig *endpointIPAMConfig `json:"IPAMConfig,omitempty"`
+	Aliases    []string            `json:"Aliases,omitempty"`
+}
+
+type endpointIPAMConfig struct {
+	IPv4Address string `json:"IPv4Address,omitempty"`
+	IPv6Address string `json:"IPv6Address,omitempty"`
+}
 
+// buildCreateRequest encodes a validated ContainerCreateConfig as a
+// /containers/create request body.
+func buildCreateRequest(config ContainerCreateConfig) containerCreateRequest {
 	exposedPorts := make(map[string]struct{})
 	portBindings := make(map[string][]portBinding)
 	for containerPort, hostPort := range config.Ports {
 		exposedPorts[containerPort] = struct{}{}
-		portBindings[containerPort] = []portBinding{{HostPort: hostPort}}
+		portBindings[containerPort] = append(portBindings[containerPort], portBinding{HostPort: hostPort})
+	}
+	for _, p := range config.PortBindings {
+		proto := p.Protocol
+		if proto == "" {
+			proto = "tcp"
+		}
+		key := fmt.Sprintf("%d/%s", p.ContainerPort, proto)
+		exposedPorts[key] = struct{}{}
+		hostPort := ""
+		if p.HostPort != 0 {
+			hostPort = strconv.Itoa(p.HostPort)
+		}
+		portBindings[key] = append(portBindings[key], portBinding{HostIP: p.HostIP, HostPort: hostPort})
 	}
 
 	var binds []string
 	for hostPath, containerPath := range config.Volumes {
 		binds = append(binds, hostPath+":"+containerPath)
 	}
+	binds = append(binds, config.Binds...)
+
+	hostCfg := containerHostConfig{
+		PortBindings: portBindings,
+		Binds:        binds,
+		NetworkMode:  config.NetworkMode,
+		ExtraHosts:   config.ExtraHosts,
+		CapAdd:       config.CapAdd,
+		CapDrop:      config.CapDrop,
+		Privileged:   config.Privileged,
+		Memory:       config.MemoryLimit,
+		NanoCPUs:     int64(config.CPUs * 1e9),
+		CpusetCpus:   config.CPUSet,
+	}
+	if config.RestartPolicy.Name != "" {
+		hostCfg.RestartPolicy = &restartPolicy{
+			Name:              config.RestartPolicy.Name,
+			MaximumRetryCount: config.RestartPolicy.MaximumRetryCount,
+		}
+	}
+	for _, d := range config.Devices {
+		dm := deviceMapping{
+			PathOnHost:        d.PathOnHost,
+			PathInContainer:   d.PathInContainer,
+			CgroupPermissions: d.CgroupPermissions,
+		}
+		if dm.PathInContainer == "" {
+			dm.PathInContainer = dm.PathOnHost
+		}
+		if dm.CgroupPermissions == "" {
+			dm.CgroupPermissions = "rwm"
+		}
+		hostCfg.Devices = append(hostCfg.Devices, dm)
+	}
 
 	reqBody := containerCreateRequest{
 		Image:        config.Image,
 		Env:          config.Env,
 		Cmd:          config.Cmd,
+		Entrypoint:   config.Entrypoint,
 		Labels:       config.Labels,
 		ExposedPorts: exposedPorts,
-		HostConfig: containerHostConfig{
-			PortBindings: portBindings,
-			Binds:        binds,
-		},
+		HostConfig:   hostCfg,
+	}
+	if config.IPv4Address != "" || len(config.Aliases) > 0 {
+		ep := endpointConfig{Aliases: config.Aliases}
+		if config.IPv4Address != "" {
+			ep.IPAMConfig = &endpointIPAMConfig{IPv4Address: config.IPv4Address}
+		}
+		reqBody.NetworkingConfig = &containerNetworkingConf{
+			EndpointsConfig: map[string]endpointConfig{config.NetworkMode: ep},
+		}
 	}
+	return reqBody
+}
+
+// RenameContainer changes a container's name.
+func (m *DockerClientManager) RenameContainer(ctx context.Context, id, name string) error {
+	path := fmt.Sprintf("/containers/%s/rename?name=%s", id, url.QueryEscape(name))
+	resp, err := m.doRequest(ctx, http.MethodPost, path, nil)
+	if err != nil {
+		return fmt.Errorf("docker: rename container: %w", err)
+	}
+	body, err := readBody(resp)
+	if err != nil {
+		return err
+	}
+	if err := checkAPIError(resp.StatusCode, body, fmt.Sprintf("container not found: %s", id)); err != nil {
+		return fmt.Errorf("docker: rename container: %w", err)
+	}
+	return nil
+}
+
+// CreateContainer creates a new container from the given configuration and returns
+// the new container's ID.
+func (m *DockerClientManager) CreateContainer(ctx context.Context, config ContainerCreateConfig) (string, error) {
+	if err := config.Validate(); err != nil {
+		return "", err
+	}
+
+	reqBody := buildCreateRequest(config)
 
 	bodyData, err := json.Marshal(reqBody)
 	if err != nil {
@@ -413,7 +694,7 @@ func (m *DockerClientManager) CreateContainer(ctx context.Context, config Contai
 
 	path := "/containers/create"
 	if config.Name != "" {
-		path += "?name=" + config.Name
+		path += "?name=" + url.QueryEscape(config.Name)
 	}
 
 	resp, err := m.doRequest(ctx, http.MethodPost, path, bytes.NewReader(bodyData))
@@ -441,84 +722,86 @@ func (m *DockerClientManager) CreateContainer(ctx context.Context, config Contai
 	return result.ID, nil
 }
 
-// PullImage pulls the specified image from a registry.
-func (m *DockerClientManager) PullImage(ctx context.Context, image string) error {
-	if image == "" {
-		return fmt.Errorf("image name is required")
-	}
-	path := "/images/create?fromImage=" + image
-	resp, err := m.doRequest(ctx, http.MethodPost, path, nil)
+// GetLogs retrieves the container's stdout and stderr. If tail > 0, only the
+// last tail lines are returned.
+func (m *DockerClientManager) GetLogs(ctx context.Context, id string, tail int) (string, error) {
+	logs, err := m.GetContainerLogs(ctx, id, LogOptions{Tail: tail, Stdout: true, Stderr: true})
 	if err != nil {
-		return fmt.Errorf("docker: pull image: %w", err)
+		return "", err
 	}
-	// Drain and discard the streaming JSON progress output.
-	io.Copy(io.Discard, resp.Body) //nolint:errcheck
-	_ = resp.Body.Close()
+	var out strings.Builder
+	for _, line := range logs.Lines {
+		out.WriteString(line.Text)
+		out.WriteByte('\n')
+	}
+	return out.String(), nil
+}
 
-	if resp.StatusCode == http.StatusNotFound {
-		return fmt.Errorf("image not found: %s", image)
+// GetContainerLogs retrieves container output filtered by opts. Containers
+// started with a TTY produce a raw stream with no stdout/stderr separation;
+// all other containers produce Docker's multiplexed stream, which is split
+// into per-stream lines.
+func (m *DockerClientManager) GetContainerLogs(ctx context.Context, id string, opts LogOptions) (*ContainerLogs, error) {
+	if !opts.Stdout && !opts.Stderr {
+		return nil, fmt.Errorf("at least one of stdout or stderr must be selected")
 	}
-	if resp.StatusCode >= 400 {
-		return fmt.Errorf("docker: pull image %q failed with status %d", image, resp.StatusCode)
+
+	raw, err := m.inspectContainerRaw(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("docker: get logs: %w", err)
 	}
-	return nil
-}
+	tty := raw.Config.Tty
 
-// GetLogs retrieves the container's stdout and stderr. If tail > 0, only the
-// last tail lines are returned.
-func (m *DockerClientManager) GetLogs(ctx context.Context, id string, tail int) (string, error) {
-	tailParam := "all"
-	if tail > 0 {
-		tailParam = strconv.Itoa(tail)
+	q := url.Values{}
+	q.Set("stdout", strconv.FormatBool(opts.Stdout))
+	q.Set("stderr", strconv.FormatBool(opts.Stderr))
+	q.Set("timestamps", strconv.FormatBool(opts.Timestamps))
+	q.Set("tail", "all")
+	if opts.Tail > 0 {
+		q.Set("tail", strconv.Itoa(opts.Tail))
 	}
-	path := fmt.Sprintf("/containers/%s/logs?stdout=true&stderr=true&tail=%s", id, tailParam)
-	resp, err := m.doRequest(ctx, http.MethodGet, path, nil)
+	if !opts.Since.IsZero() {
+		q.Set("since", formatLogTime(opts.Since))
+	}
+	if !opts.Until.IsZero() {
+		q.Set("until", formatLogTime(opts.Until))
+	}
+
+	resp, err := m.doRequest(ctx, http.MethodGet, "/containers/"+id+"/logs?"+q.Encode(), nil)
 	if err != nil {
-		return "", fmt.Errorf("docker: get logs: %w", err)
+		return nil, fmt.Errorf("docker: get logs: %w", err)
 	}
 
 	if resp.StatusCode == http.StatusNotFound {
 		_ = resp.Body.Close()
-		return "", fmt.Errorf("container not found: %s", id)
+		return nil, fmt.Errorf("container not found: %s", id)
 	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := readBody(resp)
 		if err := checkAPIError(resp.StatusCode, body, fmt.Sprintf("container not found: %s", id)); err != nil {
-			return "", fmt.Errorf("docker: get logs: %w", err)
+			return nil, fmt.Errorf("docker: get logs: %w", err)
 		}
 	}
 	defer func() { _ = resp.Body.Close() }()
 
-	// Docker multiplexes stdout/stderr using an 8-byte header per frame.
-	// We demultiplex manually: header[0] is stream type, header[4:8] is size.
-	var out strings.Builder
-	header := make([]byte, 8)
-	for {
-		_, err := io.ReadFull(resp.Body, header)
-		if err == io.EOF || err == io.ErrUnexpectedEOF {
-			break
-		}
-		if err != nil {
-			return "", fmt.Errorf("docker: read log frame header: %w", err)
-		}
-		size := int(header[4])<<24 | int(header[5])<<16 | int(header[6])<<8 | int(header[7])
-		if size == 0 {
-			continue
-		}
-		frame := make([]byte, size)
-		if _, err := io.ReadFull(resp.Body, frame); err != nil {
-			return "", fmt.Errorf("docker: read log frame: %w", err)
-		}
-		out.Write(frame)
+	c := &logCollector{opts: opts}
+	if tty {
+		err = readRawLogs(resp.Body, c)
+	} else {
+		err = readMultiplexedLogs(resp.Body, c)
 	}
-	return out.String(), nil
+	if err != nil {
+		return nil, err
+	}
+	return c.result(tty), nil
 }
 
 // dockerStatsResponse is the JSON shape returned by /containers/{id}/stats?stream=false.
 type dockerStatsResponse struct {
 	CPUStats struct {
 		CPUUsage struct {
-			TotalUsage uint64 `json:"total_usage"`
+			TotalUsage  uint64   `json:"total_usage"`
+			PercpuUsage []uint64 `json:"percpu_usage"`
 		} `json:"cpu_usage"`
 		SystemCPUUsage uint64 `json:"system_cpu_usage"`
 		OnlineCPUs     int    `json:"online_cpus"`
@@ -530,18 +813,41 @@ type dockerStatsResponse struct {
 		SystemCPUUsage uint64 `json:"system_cpu_usage"`
 	} `json:"precpu_stats"`
 	MemoryStats struct {
-		Usage uint64 `json:"usage"`
-		Limit uint64 `json:"limit"`
-		Stats struct {
-			Cache uint64 `json:"cache"`
-		} `json:"stats"`
+		Usage uint64            `json:"usage"`
+		Limit uint64            `json:"limit"`
+		Stats map[string]uint64 `json:"stats"`
 	} `json:"memory_stats"`
+	BlkioStats struct {
+		IOServiceBytesRecursive []struct {
+			Op    string `json:"op"`
+			Value uint64 `json:"value"`
+		} `json:"io_service_bytes_recursive"`
+	} `json:"blkio_stats"`
+	PidsStats struct {
+		Current uint64 `json:"current"`
+	} `json:"pids_stats"`
 	Networks map[string]struct {
 		RxBytes uint64 `json:"rx_bytes"`
 		TxBytes uint64 `json:"tx_bytes"`
 	} `json:"networks"`
 }
 
+// memoryUsage returns the memory usage excluding reclaimable page cache, as
+// docker stats reports it: total_inactive_file on cgroup v1, inactive_file on
+// cgroup v2, and cache on daemons that report neither.
+func (r *dockerStatsResponse) memoryUsage() uint64 {
+	usage := r.MemoryStats.Usage
+	for _, key := range []string{"total_inactive_file", "inactive_file", "cache"} {
+		if v, ok := r.MemoryStats.Stats[key]; ok {
+			if v <= usage {
+				return usage - v
+			}
+			return usage
+		}
+	}
+	return usage
+}
+
 // GetStats retrieves a single-shot resource usage snapshot for the container.
 func (m *DockerClientManager) GetStats(ctx context.Context, id string) (*ContainerStats, error) {
 	path := fmt.Sprintf("/containers/%s/stats?stream=false", id)
@@ -562,22 +868,21 @@ func (m *DockerClientManager) GetStats(ctx context.Context, id string) (*Contain
 		return nil, fmt.Errorf("docker: decode stats: %w", err)
 	}
 
-	// Calculate CPU percent using the delta method.
-	cpuDelta := float64(raw.CPUStats.CPUUsage.TotalUsage) - float64(raw.PreCPUStats.CPUUsage.TotalUsage)
-	systemDelta := float64(raw.CPUStats.SystemCPUUsage) - float64(raw.PreCPUStats.SystemCPUUsage)
-	cpuPercent := 0.0
 	numCPUs := raw.CPUStats.OnlineCPUs
 	if numCPUs == 0 {
-		numCPUs = 1
+		numCPUs = len(raw.CPUStats.CPUUsage.PercpuUsage)
 	}
-	if systemDelta > 0 && cpuDelta > 0 {
-		cpuPercent = (cpuDelta / systemDelta) * float64(numCPUs) * 100.0
+	if numCPUs == 0 {
+		numCPUs = 1
 	}
 
-	// Subtract page cache from memory usage (cgroup v1).
-	memUsage := raw.MemoryStats.Usage
-	if raw.MemoryStats.Stats.Cache <= memUsage {
-		memUsage -= raw.MemoryStats.Stats.Cache
+	// precpu_stats is empty on a container's first read, leaving the CPU
+	// percentage at 0; StatsSampler measures over its own interval instead.
+	cpuPercent := 0.0
+	if raw.PreCPUStats.SystemCPUUsage > 0 {
+		cpuPercent = cpuPercentBetween(
+			raw.PreCPUStats.CPUUsage.TotalUsage, raw.CPUStats.CPUUsage.TotalUsage,
+			raw.PreCPUStats.SystemCPUUsage, raw.CPUStats.SystemCPUUsage, numCPUs)
 	}
 
 	var rxBytes, txBytes uint64
@@ -586,15 +891,41 @@ func (m *DockerClientManager) GetStats(ctx context.Context, id string) (*Contain
 		txBytes += net.TxBytes
 	}
 
+	// cgroup v1 reports "Read"/"Write", cgroup v2 "read"/"write".
+	var blkRead, blkWrite uint64
+	for _, e := range raw.BlkioStats.IOServiceBytesRecursive {
+		switch strings.ToLower(e.Op) {
+		case "read":
+			blkRead += e.Value
+		case "write":
+			blkWrite += e.Value
+		}
+	}
+
 	return &ContainerStats{
-		CPUPercent:     cpuPercent,
-		MemoryUsage:    memUsage,
-		MemoryLimit:    raw.MemoryStats.Limit,
-		NetworkRxBytes: rxBytes,
-		NetworkTxBytes: txBytes,
+		CPUPercent:      cpuPercent,
+		MemoryUsage:     raw.memoryUsage(),
+		MemoryLimit:     raw.MemoryStats.Limit,
+		NetworkRxBytes:  rxBytes,
+		NetworkTxBytes:  txBytes,
+		BlockReadBytes:  blkRead,
+		BlockWriteBytes: blkWrite,
+		PIDs:            raw.PidsStats.Current,
+		CPUTotalUsage:   raw.CPUStats.CPUUsage.TotalUsage,
+		SystemCPUUsage:  raw.CPUStats.SystemCPUUsage,
+		OnlineCPUs:      numCPUs,
 	}, nil
 }
 
+// cpuPercentBetween returns the CPU usage between two readings of the
+// container and host CPU counters, where 100% is one full CPU.
+func cpuPercentBetween(prevCPU, curCPU, prevSystem, curSystem uint64, numCPUs int) float64 {
+	if curCPU <= prevCPU || curSystem <= prevSystem {
+		return 0
+	}
+	return float64(curCPU-prevCPU) / float64(curSystem-prevSystem) * float64(numCPUs) * 100.0
+}
+
 // ---------------------------------------------------------------------------
 // Network operations
 // ---------------------------------------------------------------------------
@@ -639,15 +970,24 @@ type dockerNetworkInspect struct {
 	Name       string `json:"Name"`
 	Driver     string `json:"Driver"`
 	Scope      string `json:"Scope"`
+	EnableIPv6 bool   `json:"EnableIPv6"`
+	Internal   bool   `json:"Internal"`
 	Containers map[string]struct {
-		Name string `json:"Name"`
+		Name        string `json:"Name"`
+		IPv4Address string `json:"IPv4Address"`
+		IPv6Address string `json:"IPv6Address"`
+		MacAddress  string `json:"MacAddress"`
 	} `json:"Containers"`
 	IPAM struct {
 		Config []struct {
-			Subnet  string `json:"Subnet"`
-			Gateway string `json:"Gateway"`
+			Subnet             string            `json:"Subnet"`
+			Gateway            string            `json:"Gateway"`
+			IPRange            string            `json:"IPRange"`
+			AuxiliaryAddresses map[string]string `json:"AuxiliaryAddresses"`
 		} `json:"Config"`
 	} `json:"IPAM"`
+	Options map[string]string `json:"Options"`
+	Labels  map[string]string `json:"Labels"`
 }
 
 // InspectNetwork returns detailed information about a network.
@@ -673,9 +1013,20 @@ func (m *DockerClientManager) InspectNetwork(ctx context.Context, id string) (*N
 	}
 
 	containerIDs := make([]string, 0, len(raw.Containers))
-	for cid := range raw.Containers {
+	endpoints := make([]NetworkEndpoint, 0, len(raw.Containers))
+	for cid, ep := range raw.Containers {
 		containerIDs = append(containerIDs, cid)
+		ipv4, _, _ := strings.Cut(ep.IPv4Address, "/")
+		ipv6, _, _ := strings.Cut(ep.IPv6Address, "/")
+		endpoints = append(endpoints, NetworkEndpoint{
+			ContainerID: cid,
+			Name:        ep.Name,
+			IPv4Address: ipv4,
+			IPv6Address: ipv6,
+			MacAddress:  ep.MacAddress,
+		})
 	}
+	sort.Slice(endpoints, func(i, j int) bool { return endpoints[i].Name < endpoints[j].Name })
 
 	subnet := ""
 	gateway := ""
@@ -683,6 +1034,15 @@ func (m *DockerClientManager) InspectNetwork(ctx context.Context, id string) (*N
 		subnet = raw.IPAM.Config[0].Subnet
 		gateway = raw.IPAM.Config[0].Gateway
 	}
+	ipam := make([]IPAMConfig, 0, len(raw.IPAM.Config))
+	for _, c := range raw.IPAM.Config {
+		ipam = append(ipam, IPAMConfig{
+			Subnet:       c.Subnet,
+			Gateway:      c.Gateway,
+			IPRange:      c.IPRange,
+			AuxAddresses: c.AuxiliaryAddresses,
+		})
+	}
 
 	return &NetworkDetail{
 		Network: Network{
@@ -692,8 +1052,14 @@ func (m *DockerClientManager) InspectNetwork(ctx context.Context, id string) (*N
 			Scope:  raw.Scope,
 		},
 		Containers: containerIDs,
+		Endpoints:  endpoints,
 		Subnet:     subnet,
 		Gateway:    gateway,
+		IPAM:       ipam,
+		EnableIPv6: raw.EnableIPv6,
+		Internal:   raw.Internal,
+		Options:    raw.Options,
+		Labels:     raw.Labels,
 	}, nil
 }
 
@@ -702,7 +1068,10 @@ type networkCreateRequest struct {
 	Name           string            `json:"Name"`
 	Driver         string            `json:"Driver,omitempty"`
 	CheckDuplicate bool              `json:"CheckDuplicate"`
+	EnableIPv6     bool              `json:"EnableIPv6,omitempty"`
+	Internal       bool              `json:"Internal,omitempty"`
 	IPAM           *networkIPAM      `json:"IPAM,omitempty"`
+	Options        map[string]string `json:"Options,omitempty"`
 	Labels         map[string]string `json:"Labels,omitempty"`
 }
 
@@ -712,26 +1081,58 @@ type networkIPAM struct {
 }
 
 type networkIPAMCfg struct {
-	Subnet string `json:"Subnet,omitempty"`
+	Subnet             string            `json:"Subnet,omitempty"`
+	Gateway            string            `json:"Gateway,omitempty"`
+	IPRange            string            `json:"IPRange,omitempty"`
+	AuxiliaryAddresses map[string]string `json:"AuxiliaryAddresses,omitempty"`
 }
 
-// CreateNetwork creates a new Docker network and returns its ID.
-func (m *DockerClientManager) CreateNetwork(ctx context.Context, config NetworkCreateConfig) (string, error) {
-	if config.Name == "" {
-		return "", fmt.Errorf("network name is required")
-	}
-
+// buildNetworkCreateRequest encodes a validated NetworkCreateConfig as a
+// /networks/create request body.
+func buildNetworkCreateRequest(config NetworkCreateConfig) networkCreateRequest {
 	reqBody := networkCreateRequest{
 		Name:           config.Name,
 		Driver:         config.Driver,
 		CheckDuplicate: true,
+		EnableIPv6:     config.IPv6Subnet != "",
+		Internal:       config.Internal,
+		Labels:         config.Labels,
+	}
+	if len(config.Options) > 0 || config.Parent != "" {
+		reqBody.Options = make(map[string]string, len(config.Options)+1)
+		for k, v := range config.Options {
+			reqBody.Options[k] = v
+		}
+		if config.Parent != "" {
+			reqBody.Options["parent"] = config.Parent
+		}
 	}
+
+	var pools []networkIPAMCfg
 	if config.Subnet != "" {
-		reqBody.IPAM = &networkIPAM{
-			Driver: "default",
-			Config: []networkIPAMCfg{{Subnet: config.Subnet}},
-		}
+		pools = append(pools, networkIPAMCfg{
+			Subnet:             config.Subnet,
+			Gateway:            config.Gateway,
+			IPRange:            config.IPRange,
+			AuxiliaryAddresses: config.AuxAddresses,
+		})
+	}
+	if config.IPv6Subnet != "" {
+		pools = append(pools, networkIPAMCfg{Subnet: config.IPv6Subnet, Gateway: config.IPv6Gateway})
 	}
+	if len(pools) > 0 {
+		reqBody.IPAM = &networkIPAM{Driver: "default", Config: pools}
+	}
+	return reqBody
+}
+
+// CreateNetwork creates a new Docker network and returns its ID.
+func (m *DockerClientManager) CreateNetwork(ctx context.Context, config NetworkCreateConfig) (string, error) {
+	if err := config.Validate(); err != nil {
+		return "", err
+	}
+
+	reqBody := buildNetworkCreateRequest(config)
 
 	bodyData, err := json.Marshal(reqBody)
 	if err != nil {
@@ -787,12 +1188,23 @@ func (m *DockerClientManager) RemoveNetwork(ctx context.Context, id string) erro
 
 // networkConnectRequest is the request body for POST /networks/{id}/connect.
 type networkConnectRequest struct {
-	Container string `json:"Container"`
+	Container      string          `json:"Container"`
+	EndpointConfig *endpointConfig `json:"EndpointConfig,omitempty"`
 }
 
-// ConnectNetwork connects a container to a network.
-func (m *DockerClientManager) ConnectNetwork(ctx context.Context, networkID, containerID string) error {
+// ConnectNetwork connects a container to a network, with the static
+// addresses and aliases in opts.
+func (m *DockerClientManager) ConnectNetwork(ctx context.Context, networkID, containerID string, opts NetworkConnectOptions) error {
+	if err := opts.Validate(networkID); err != nil {
+		return err
+	}
 	reqBody := networkConnectRequest{Container: containerID}
+	if opts.IPv4Address != "" || opts.IPv6Address != "" || len(opts.Aliases) > 0 {
+		reqBody.EndpointConfig = &endpointConfig{Aliases: opts.Aliases}
+		if opts.IPv4Address != "" || opts.IPv6Address != "" {
+			reqBody.EndpointConfig.IPAMConfig = &endpointIPAMConfig{IPv4Address: opts.IPv4Address, IPv6Address: opts.IPv6Address}
+		}
+	}
 	bodyData, err := json.Marshal(reqBody)
 	if err != nil {
 		return fmt.Errorf("docker: encode connect request: %w", err)
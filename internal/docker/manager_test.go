@@ -21,6 +21,10 @@ type MockDockerManager struct {
 	networks   map[string]*NetworkDetail
 	logs       map[string]string
 	stats      map[string]*ContainerStats
+	images     map[string]*ImageDetail
+	volumes    map[string]*VolumeDetail
+	files      map[string]map[string]string // container ID -> path -> content
+	diskUsage  *DiskUsage
 	idCounter  int
 
 	// networkLinks tracks which containers are connected to which networks.
@@ -35,6 +39,9 @@ func NewMockDockerManager() *MockDockerManager {
 		networks:     make(map[string]*NetworkDetail),
 		logs:         make(map[string]string),
 		stats:        make(map[string]*ContainerStats),
+		images:       make(map[string]*ImageDetail),
+		volumes:      make(map[string]*VolumeDetail),
+		files:        make(map[string]map[string]string),
 		networkLinks: make(map[string]map[string]struct{}),
 	}
 }
@@ -56,6 +63,20 @@ func (m *MockDockerManager) AddNetwork(detail *NetworkDetail) {
 	}
 }
 
+// AddImage is a test helper that inserts an image into the mock store.
+func (m *MockDockerManager) AddImage(detail *ImageDetail) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.images[detail.ID] = detail
+}
+
+// AddVolume is a test helper that inserts a volume into the mock store.
+func (m *MockDockerManager) AddVolume(detail *VolumeDetail) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.volumes[detail.Name] = detail
+}
+
 // SetLogs is a test helper that sets the logs for a container.
 func (m *MockDockerManager) SetLogs(containerID, logs string) {
 	m.mu.Lock()
@@ -70,6 +91,13 @@ func (m *MockDockerManager) SetStats(containerID string, stats *ContainerStats)
 	m.stats[containerID] = stats
 }
 
+// SetDiskUsage is a test helper that sets the result of DiskUsage.
+func (m *MockDockerManager) SetDiskUsage(du *DiskUsage) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.diskUsage = du
+}
+
 func (m *MockDockerManager) nextID() string {
 	m.idCounter++
 	return fmt.Sprintf("mock-%d", m.idCounter)
@@ -183,6 +211,26 @@ func (m *MockDockerManager) RemoveContainer(ctx context.Context, id string, forc
 	return nil
 }
 
+func (m *MockDockerManager) RenameContainer(ctx context.Context, id, name string) error {
+	if err := checkCtx(ctx); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.containers[id]
+	if !ok {
+		return fmt.Errorf("container not found: %s", id)
+	}
+	for _, other := range m.containers {
+		if other.Name == name && other.ID != id {
+			return fmt.Errorf("container name already in use: %s", name)
+		}
+	}
+	c.Name = name
+	return nil
+}
+
 func (m *MockDockerManager) CreateContainer(ctx context.Context, config ContainerCreateConfig) (string, error) {
 	if err := checkCtx(ctx); err != nil {
 		return "", err
@@ -215,7 +263,11 @@ func (m *MockDockerManager) CreateContainer(ctx context.Context, config Containe
 			Cmd:    config.Cmd,
 			Labels: config.Labels,
 		},
-		Mounts: mounts,
+		NetworkSettings: NetworkInfo{PortBindings: config.PortBindings},
+		Mounts:          mounts,
+	}
+	if config.IPv4Address != "" {
+		detail.NetworkSettings.StaticIPs = map[string]string{config.NetworkMode: config.IPv4Address}
 	}
 	m.containers[id] = detail
 	return id, nil
@@ -231,6 +283,16 @@ func (m *MockDockerManager) PullImage(ctx context.Context, image string) error {
 	return nil
 }
 
+func (m *MockDockerManager) PullImageWithProgress(ctx context.Context, image string, onProgress func(PullProgress)) (*PullResult, error) {
+	if err := m.PullImage(ctx, image); err != nil {
+		return nil, err
+	}
+	if onProgress != nil {
+		onProgress(PullProgress{Layers: 1, LayersDone: 1, Status: "Pull complete"})
+	}
+	return &PullResult{Image: image, Status: "Image is up to date for " + image, Layers: 1}, nil
+}
+
 func (m *MockDockerManager) GetLogs(ctx context.Context, id string, tail int) (string, error) {
 	if err := checkCtx(ctx); err != nil {
 		return "", err
@@ -253,6 +315,35 @@ func (m *MockDockerManager) GetLogs(ctx context.Context, id string, tail int) (s
 	return strings.Join(lines, "\n"), nil
 }
 
+func (m *MockDockerManager) GetContainerLogs(ctx context.Context, id string, opts LogOptions) (*ContainerLogs, error) {
+	if err := checkCtx(ctx); err != nil {
+		return nil, err
+	}
+	if !opts.Stdout && !opts.Stderr {
+		return nil, fmt.Errorf("at least one of stdout or stderr must be selected")
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, ok := m.containers[id]; !ok {
+		return nil, fmt.Errorf("container not found: %s", id)
+	}
+	// The mock treats all stored logs as stdout.
+	c := &logCollector{opts: opts}
+	logs, ok := m.logs[id]
+	if !ok || !opts.Stdout {
+		return c.result(false), nil
+	}
+	lines := strings.Split(logs, "\n")
+	if opts.Tail > 0 && opts.Tail < len(lines) {
+		lines = lines[len(lines)-opts.Tail:]
+	}
+	for _, line := range lines {
+		c.add("stdout", line)
+	}
+	return c.result(false), nil
+}
+
 func (m *MockDockerManager) GetStats(ctx context.Context, id string) (*ContainerStats, error) {
 	if err := checkCtx(ctx); err != nil {
 		return nil, err
@@ -270,6 +361,142 @@ func (m *MockDockerManager) GetStats(ctx context.Context, id string) (*Container
 	return stats, nil
 }
 
+// UpdateContainer simulates a successful recreate: the container is re-keyed
+// under a new ID with its configuration unchanged.
+func (m *MockDockerManager) UpdateContainer(ctx context.Context, id string, opts UpdateOptions) (*UpdateResult, error) {
+	if err := checkCtx(ctx); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.containers[id]
+	if !ok {
+		return nil, fmt.Errorf("container not found: %s", id)
+	}
+	result := &UpdateResult{Name: c.Name, Image: c.Image, OldID: c.ID}
+	if !opts.Force {
+		result.Steps = []string{"image unchanged; container not recreated"}
+		return result, nil
+	}
+
+	newID := m.nextID()
+	cp := *c
+	cp.ID = newID
+	delete(m.containers, id)
+	m.containers[newID] = &cp
+	result.NewID = newID
+	result.Updated = true
+	result.Steps = []string{"pulled " + c.Image, "created new container " + newID, "removed old container"}
+	return result, nil
+}
+
+// ExecContainer simulates running a command by echoing its arguments to
+// stdout. It fails for containers that are not running, as the daemon does.
+func (m *MockDockerManager) ExecContainer(ctx context.Context, id string, opts ExecOptions) (*ExecResult, error) {
+	if err := checkCtx(ctx); err != nil {
+		return nil, err
+	}
+	if len(opts.Cmd) == 0 {
+		return nil, fmt.Errorf("command is required")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.containers[id]
+	if !ok {
+		return nil, fmt.Errorf("container not found: %s", id)
+	}
+	if c.State != "running" {
+		return nil, fmt.Errorf("docker: container %s is not running", id)
+	}
+	return &ExecResult{ExitCode: 0, Stdout: strings.Join(opts.Cmd, " ") + "\n"}, nil
+}
+
+// AddFile is a test helper that stores a file inside a container.
+func (m *MockDockerManager) AddFile(containerID, path, content string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.files[containerID] == nil {
+		m.files[containerID] = make(map[string]string)
+	}
+	m.files[containerID][path] = content
+}
+
+func (m *MockDockerManager) ReadContainerFile(ctx context.Context, id, path string, maxBytes int64) (*ContainerFile, error) {
+	if err := checkCtx(ctx); err != nil {
+		return nil, err
+	}
+	path, err := CleanContainerPath(path)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, ok := m.containers[id]; !ok {
+		return nil, fmt.Errorf("container not found: %s", id)
+	}
+	content, ok := m.files[id][path]
+	if !ok {
+		return nil, fmt.Errorf("not found: %s in container %s", path, id)
+	}
+	if int64(len(content)) > maxBytes {
+		return nil, fmt.Errorf("%s is %d bytes, larger than the %d byte limit", path, len(content), maxBytes)
+	}
+	return &ContainerFile{
+		ContainerFileInfo: ContainerFileInfo{Name: path[strings.LastIndex(path, "/")+1:], Size: int64(len(content)), Mode: "-rw-r--r--"},
+		Path:              path,
+		Content:           content,
+	}, nil
+}
+
+func (m *MockDockerManager) ListContainerFiles(ctx context.Context, id, dir string) (*ContainerDirListing, error) {
+	if err := checkCtx(ctx); err != nil {
+		return nil, err
+	}
+	dir, err := CleanContainerPath(dir)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, ok := m.containers[id]; !ok {
+		return nil, fmt.Errorf("container not found: %s", id)
+	}
+	prefix := strings.TrimSuffix(dir, "/") + "/"
+	listing := &ContainerDirListing{Path: dir, Entries: []ContainerFileInfo{}}
+	for p, content := range m.files[id] {
+		rel, ok := strings.CutPrefix(p, prefix)
+		if ok && !strings.Contains(rel, "/") {
+			listing.Entries = append(listing.Entries, ContainerFileInfo{Name: rel, Size: int64(len(content)), Mode: "-rw-r--r--"})
+		}
+	}
+	return listing, nil
+}
+
+func (m *MockDockerManager) WriteContainerFile(ctx context.Context, id, path string, content []byte) error {
+	if err := checkCtx(ctx); err != nil {
+		return err
+	}
+	path, err := CleanContainerPath(path)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.containers[id]; !ok {
+		return fmt.Errorf("container not found: %s", id)
+	}
+	if m.files[id] == nil {
+		m.files[id] = make(map[string]string)
+	}
+	m.files[id][path] = string(content)
+	return nil
+}
+
 func (m *MockDockerManager) ListNetworks(ctx context.Context) ([]Network, error) {
 	if err := checkCtx(ctx); err != nil {
 		return nil, err
@@ -302,8 +529,8 @@ func (m *MockDockerManager) CreateNetwork(ctx context.Context, config NetworkCre
 	if err := checkCtx(ctx); err != nil {
 		return "", err
 	}
-	if config.Name == "" {
-		return "", fmt.Errorf("network name is required")
+	if err := config.Validate(); err != nil {
+		return "", err
 	}
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -317,7 +544,11 @@ func (m *MockDockerManager) CreateNetwork(ctx context.Context, config NetworkCre
 			Scope:  "local",
 		},
 		Subnet:     config.Subnet,
+		Gateway:    config.Gateway,
 		Containers: nil,
+		EnableIPv6: config.IPv6Subnet != "",
+		Internal:   config.Internal,
+		Labels:     config.Labels,
 	}
 	m.networks[id] = detail
 	m.networkLinks[id] = make(map[string]struct{})
@@ -339,10 +570,13 @@ func (m *MockDockerManager) RemoveNetwork(ctx context.Context, id string) error
 	return nil
 }
 
-func (m *MockDockerManager) ConnectNetwork(ctx context.Context, networkID, containerID string) error {
+func (m *MockDockerManager) ConnectNetwork(ctx context.Context, networkID, containerID string, opts NetworkConnectOptions) error {
 	if err := checkCtx(ctx); err != nil {
 		return err
 	}
+	if err := opts.Validate(networkID); err != nil {
+		return err
+	}
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -373,6 +607,215 @@ func (m *MockDockerManager) DisconnectNetwork(ctx context.Context, networkID, co
 	return nil
 }
 
+func (m *MockDockerManager) ListImages(ctx context.Context, all bool) ([]Image, error) {
+	if err := checkCtx(ctx); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]Image, 0, len(m.images))
+	for _, img := range m.images {
+		result = append(result, img.Image)
+	}
+	return result, nil
+}
+
+func (m *MockDockerManager) InspectImage(ctx context.Context, id string) (*ImageDetail, error) {
+	if err := checkCtx(ctx); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	img, ok := m.images[id]
+	if !ok {
+		return nil, fmt.Errorf("image not found: %s", id)
+	}
+	cp := *img
+	return &cp, nil
+}
+
+func (m *MockDockerManager) ImageHistory(ctx context.Context, id string) ([]ImageHistoryEntry, error) {
+	if err := checkCtx(ctx); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	img, ok := m.images[id]
+	if !ok {
+		return nil, fmt.Errorf("image not found: %s", id)
+	}
+	return []ImageHistoryEntry{{ID: img.ID, Created: img.Created, Size: img.Size, Tags: img.RepoTags}}, nil
+}
+
+func (m *MockDockerManager) RemoveImage(ctx context.Context, id string, force bool) (*ImageRemoveResult, error) {
+	if err := checkCtx(ctx); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	img, ok := m.images[id]
+	if !ok {
+		return nil, fmt.Errorf("image not found: %s", id)
+	}
+	if img.Containers > 0 && !force {
+		return nil, fmt.Errorf("image %s is in use by %d container(s)", id, img.Containers)
+	}
+	delete(m.images, id)
+	return &ImageRemoveResult{Untagged: img.RepoTags, Deleted: []string{img.ID}}, nil
+}
+
+func (m *MockDockerManager) PruneImages(ctx context.Context, all bool) (*ImagePruneReport, error) {
+	if err := checkCtx(ctx); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	report := &ImagePruneReport{}
+	for id, img := range m.images {
+		if img.Containers > 0 || (!all && !img.Dangling) {
+			continue
+		}
+		delete(m.images, id)
+		report.ImagesDeleted = append(report.ImagesDeleted, id)
+		report.SpaceReclaimed += uint64(img.Size)
+	}
+	return report, nil
+}
+
+// volumeUsersLocked returns the names of containers mounting the named
+// volume. The caller must hold m.mu.
+func (m *MockDockerManager) volumeUsersLocked(name string) []string {
+	var users []string
+	for _, c := range m.containers {
+		for _, mnt := range c.Mounts {
+			if mnt.Type == "volume" && mnt.Name == name {
+				users = append(users, c.Name)
+				break
+			}
+		}
+	}
+	return users
+}
+
+func (m *MockDockerManager) ListVolumes(ctx context.Context, danglingOnly bool) ([]Volume, error) {
+	if err := checkCtx(ctx); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]Volume, 0, len(m.volumes))
+	for _, v := range m.volumes {
+		if danglingOnly && len(m.volumeUsersLocked(v.Name)) > 0 {
+			continue
+		}
+		result = append(result, v.Volume)
+	}
+	return result, nil
+}
+
+func (m *MockDockerManager) InspectVolume(ctx context.Context, name string) (*VolumeDetail, error) {
+	if err := checkCtx(ctx); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	v, ok := m.volumes[name]
+	if !ok {
+		return nil, fmt.Errorf("volume not found: %s", name)
+	}
+	cp := *v
+	cp.Containers = m.volumeUsersLocked(name)
+	return &cp, nil
+}
+
+func (m *MockDockerManager) CreateVolume(ctx context.Context, config VolumeCreateConfig) (*Volume, error) {
+	if err := checkCtx(ctx); err != nil {
+		return nil, err
+	}
+	if config.Name == "" {
+		return nil, fmt.Errorf("volume name is required")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.volumes[config.Name]; exists {
+		return nil, fmt.Errorf("volume %q already exists", config.Name)
+	}
+	driver := config.Driver
+	if driver == "" {
+		driver = "local"
+	}
+	vol := Volume{
+		Name:       config.Name,
+		Driver:     driver,
+		Mountpoint: "/var/lib/docker/volumes/" + config.Name + "/_data",
+		Scope:      "local",
+		Labels:     config.Labels,
+	}
+	m.volumes[config.Name] = &VolumeDetail{Volume: vol, Options: config.Options}
+	return &vol, nil
+}
+
+func (m *MockDockerManager) RemoveVolume(ctx context.Context, name string, force bool) error {
+	if err := checkCtx(ctx); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.volumes[name]; !ok {
+		if force {
+			return nil
+		}
+		return fmt.Errorf("volume not found: %s", name)
+	}
+	if users := m.volumeUsersLocked(name); len(users) > 0 {
+		return fmt.Errorf("volume %s is in use by %v", name, users)
+	}
+	delete(m.volumes, name)
+	return nil
+}
+
+func (m *MockDockerManager) PruneVolumes(ctx context.Context) (*VolumePruneReport, error) {
+	if err := checkCtx(ctx); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	report := &VolumePruneReport{}
+	for name := range m.volumes {
+		if len(m.volumeUsersLocked(name)) > 0 {
+			continue
+		}
+		delete(m.volumes, name)
+		report.VolumesDeleted = append(report.VolumesDeleted, name)
+	}
+	return report, nil
+}
+
+// DiskUsage returns the value set by SetDiskUsage, or an empty report.
+func (m *MockDockerManager) DiskUsage(ctx context.Context) (*DiskUsage, error) {
+	if err := checkCtx(ctx); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.diskUsage == nil {
+		return &DiskUsage{}, nil
+	}
+	du := *m.diskUsage
+	return &du, nil
+}
+
 // ---------------------------------------------------------------------------
 // Interface compliance
 // ---------------------------------------------------------------------------
@@ -1591,7 +2034,7 @@ func Test_ConnectNetwork_Cases(t *testing.T) {
 			m := newPopulatedMock(t)
 			ctx := context.Background()
 
-			err := m.ConnectNetwork(ctx, tt.networkID, tt.containerID)
+			err := m.ConnectNetwork(ctx, tt.networkID, tt.containerID, NetworkConnectOptions{})
 			if tt.wantErr {
 				if err == nil {
 					t.Fatal("expected error, got nil")
@@ -1710,10 +2153,21 @@ func Test_ContextCancellation_AllMethods(t *testing.T) {
 			name: "PullImage",
 			fn:   func() error { return m.PullImage(ctx, "test") },
 		},
+		{
+			name: "PullImageWithProgress",
+			fn:   func() error { _, err := m.PullImageWithProgress(ctx, "test", nil); return err },
+		},
 		{
 			name: "GetLogs",
 			fn:   func() error { _, err := m.GetLogs(ctx, "abc123", 10); return err },
 		},
+		{
+			name: "GetContainerLogs",
+			fn: func() error {
+				_, err := m.GetContainerLogs(ctx, "abc123", LogOptions{Stdout: true, Stderr: true})
+				return err
+			},
+		},
 		{
 			name: "GetStats",
 			fn:   func() error { _, err := m.GetStats(ctx, "abc123"); return err },
@@ -1739,7 +2193,7 @@ func Test_ContextCancellation_AllMethods(t *testing.T) {
 		},
 		{
 			name: "ConnectNetwork",
-			fn:   func() error { return m.ConnectNetwork(ctx, "net1", "abc123") },
+			fn:   func() error { return m.ConnectNetwork(ctx, "net1", "abc123", NetworkConnectOptions{}) },
 		},
 		{
 			name: "DisconnectNetwork",
@@ -2067,7 +2521,7 @@ func Test_NetworkFullLifecycle(t *testing.T) {
 	}
 
 	// 4. Connect a container.
-	if err := m.ConnectNetwork(ctx, netID, containerID); err != nil {
+	if err := m.ConnectNetwork(ctx, netID, containerID, NetworkConnectOptions{}); err != nil {
 		t.Fatalf("ConnectNetwork: %v", err)
 	}
 
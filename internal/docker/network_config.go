@@ -0,0 +1,127 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// Network configuration validation
+// ---------------------------------------------------------------------------
+
+// Validate checks that every field of c is well formed and consistent: the
+// gateway, IP range and auxiliary addresses must lie in their subnet, and a
+// parent interface needs the macvlan or ipvlan driver.
+func (c NetworkCreateConfig) Validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("network name is required")
+	}
+	if c.Parent != "" {
+		if c.Driver != "macvlan" && c.Driver != "ipvlan" {
+			return fmt.Errorf("a parent interface requires the macvlan or ipvlan driver, not %q", c.Driver)
+		}
+		if p, ok := c.Options["parent"]; ok && p != c.Parent {
+			return fmt.Errorf("parent %q conflicts with driver option parent=%s", c.Parent, p)
+		}
+	}
+
+	if c.Subnet == "" {
+		if c.Gateway != "" || c.IPRange != "" || len(c.AuxAddresses) > 0 {
+			return fmt.Errorf("gateway, ip_range and aux_addresses require a subnet")
+		}
+	} else {
+		subnet, err := parseSubnet(c.Subnet, true)
+		if err != nil {
+			return err
+		}
+		if err := checkInSubnet(subnet, "gateway", c.Gateway); err != nil {
+			return err
+		}
+		if c.IPRange != "" {
+			r, err := netip.ParsePrefix(c.IPRange)
+			if err != nil || r.Bits() < subnet.Bits() || !subnet.Contains(r.Addr()) {
+				return fmt.Errorf("IP range %q must be a CIDR within subnet %s", c.IPRange, subnet)
+			}
+		}
+		for host, addr := range c.AuxAddresses {
+			if addr == "" {
+				return fmt.Errorf("aux address %s needs an address, as host=ip", host)
+			}
+			if err := checkInSubnet(subnet, "aux address "+host, addr); err != nil {
+				return err
+			}
+		}
+	}
+
+	if c.IPv6Subnet == "" {
+		if c.IPv6Gateway != "" {
+			return fmt.Errorf("an IPv6 gateway requires an IPv6 subnet")
+		}
+	} else {
+		subnet, err := parseSubnet(c.IPv6Subnet, false)
+		if err != nil {
+			return err
+		}
+		if err := checkInSubnet(subnet, "IPv6 gateway", c.IPv6Gateway); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate checks o for a connection to network. Static addresses and
+// aliases are only supported on user-defined networks.
+func (o NetworkConnectOptions) Validate(network string) error {
+	if (o.IPv4Address != "" || o.IPv6Address != "" || len(o.Aliases) > 0) && !userNetworkMode(network) {
+		return fmt.Errorf("static addresses and aliases require a user-defined network such as br0, not %q", network)
+	}
+	if o.IPv4Address != "" {
+		if addr, err := netip.ParseAddr(o.IPv4Address); err != nil || !addr.Is4() {
+			return fmt.Errorf("invalid IPv4 address %q", o.IPv4Address)
+		}
+	}
+	if o.IPv6Address != "" {
+		if addr, err := netip.ParseAddr(o.IPv6Address); err != nil || !addr.Is6() || addr.Is4In6() {
+			return fmt.Errorf("invalid IPv6 address %q", o.IPv6Address)
+		}
+	}
+	return nil
+}
+
+// parseSubnet parses an IPv4 (or, if ipv4 is false, IPv6) CIDR.
+func parseSubnet(s string, ipv4 bool) (netip.Prefix, error) {
+	family := "IPv6"
+	if ipv4 {
+		family = "IPv4"
+	}
+	p, err := netip.ParsePrefix(s)
+	if err != nil || p.Addr().Is4() != ipv4 {
+		return netip.Prefix{}, fmt.Errorf("invalid %s subnet %q", family, s)
+	}
+	return p.Masked(), nil
+}
+
+// checkInSubnet checks that addr, if set, is an address in subnet.
+func checkInSubnet(subnet netip.Prefix, what, addr string) error {
+	if addr == "" {
+		return nil
+	}
+	a, err := netip.ParseAddr(addr)
+	if err != nil || !subnet.Contains(a) {
+		return fmt.Errorf("%s %q must be an address in subnet %s", what, addr, subnet)
+	}
+	return nil
+}
+
+// parseKeyValuesStrict is parseKeyValues for settings that mean nothing
+// without a value, such as auxiliary addresses and network driver options.
+func parseKeyValuesStrict(entries []string) (map[string]string, error) {
+	for _, e := range entries {
+		if _, value, _ := strings.Cut(e, "="); value == "" {
+			return nil, fmt.Errorf("entry %q has no value; use key=value", e)
+		}
+	}
+	return parseKeyValues(entries)
+}
@@ -0,0 +1,211 @@
+package docker
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+)
+
+func Test_NetworkCreateConfig_Validate_Cases(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     NetworkCreateConfig
+		wantErr string
+	}{
+		{name: "name only", cfg: NetworkCreateConfig{Name: "n"}},
+		{
+			name: "unraid style macvlan",
+			cfg: NetworkCreateConfig{
+				Name: "br0.10", Driver: "macvlan", Parent: "br0.10",
+				Subnet: "192.168.10.0/24", Gateway: "192.168.10.1", IPRange: "192.168.10.128/25",
+				AuxAddresses: map[string]string{"router": "192.168.10.2"},
+				IPv6Subnet:   "fd00:10::/64", IPv6Gateway: "fd00:10::1",
+				Options: map[string]string{"macvlan_mode": "bridge"},
+			},
+		},
+		{name: "missing name", cfg: NetworkCreateConfig{}, wantErr: "name is required"},
+		{name: "parent on bridge", cfg: NetworkCreateConfig{Name: "n", Driver: "bridge", Parent: "eth0"}, wantErr: "macvlan or ipvlan"},
+		{name: "parent conflicts with option", cfg: NetworkCreateConfig{Name: "n", Driver: "ipvlan", Parent: "eth0", Options: map[string]string{"parent": "eth1"}}, wantErr: "conflicts"},
+		{name: "gateway without subnet", cfg: NetworkCreateConfig{Name: "n", Gateway: "10.0.0.1"}, wantErr: "require a subnet"},
+		{name: "v6 subnet as v4", cfg: NetworkCreateConfig{Name: "n", Subnet: "fd00::/64"}, wantErr: "invalid IPv4 subnet"},
+		{name: "gateway outside subnet", cfg: NetworkCreateConfig{Name: "n", Subnet: "10.0.0.0/24", Gateway: "10.0.1.1"}, wantErr: "gateway \"10.0.1.1\""},
+		{name: "range wider than subnet", cfg: NetworkCreateConfig{Name: "n", Subnet: "10.0.0.0/24", IPRange: "10.0.0.0/16"}, wantErr: "IP range"},
+		{name: "aux outside subnet", cfg: NetworkCreateConfig{Name: "n", Subnet: "10.0.0.0/24", AuxAddresses: map[string]string{"nas": "10.9.0.2"}}, wantErr: "aux address nas"},
+		{name: "aux without address", cfg: NetworkCreateConfig{Name: "n", Subnet: "10.0.0.0/24", AuxAddresses: map[string]string{"nas": ""}}, wantErr: "needs an address"},
+		{name: "v6 gateway without subnet", cfg: NetworkCreateConfig{Name: "n", IPv6Gateway: "fd00::1"}, wantErr: "IPv6 subnet"},
+		{name: "v4 as v6 subnet", cfg: NetworkCreateConfig{Name: "n", IPv6Subnet: "10.0.0.0/24"}, wantErr: "invalid IPv6 subnet"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_NetworkConnectOptions_Validate_Cases(t *testing.T) {
+	tests := []struct {
+		name    string
+		network string
+		opts    NetworkConnectOptions
+		wantErr string
+	}{
+		{name: "none on bridge", network: "bridge"},
+		{name: "static on br0", network: "br0", opts: NetworkConnectOptions{IPv4Address: "192.168.1.9", IPv6Address: "fd00::9", Aliases: []string{"db"}}},
+		{name: "static on bridge", network: "bridge", opts: NetworkConnectOptions{IPv4Address: "172.17.0.9"}, wantErr: "user-defined network"},
+		{name: "bad v4", network: "br0", opts: NetworkConnectOptions{IPv4Address: "fd00::9"}, wantErr: "invalid IPv4"},
+		{name: "bad v6", network: "br0", opts: NetworkConnectOptions{IPv6Address: "192.168.1.9"}, wantErr: "invalid IPv6"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate(tt.network)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_DockerClientManager_Network_RequestsAndInspect(t *testing.T) {
+	var createBody, connectBody map[string]any
+	mgr := newFakeDaemon(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/networks/create":
+			data, _ := io.ReadAll(r.Body)
+			_ = json.Unmarshal(data, &createBody)
+			writeJSON(w, http.StatusCreated, `{"Id": "new-id"}`)
+		case "/networks/br0/connect":
+			data, _ := io.ReadAll(r.Body)
+			_ = json.Unmarshal(data, &connectBody)
+			w.WriteHeader(http.StatusOK)
+		case "/networks/br0":
+			writeJSON(w, http.StatusOK, `{
+				"Id": "br0-id", "Name": "br0", "Driver": "macvlan", "EnableIPv6": true,
+				"Options": {"parent": "br0"},
+				"Containers": {"c1": {"Name": "plex", "IPv4Address": "192.168.1.20/24", "IPv6Address": "fd00::20/64", "MacAddress": "02:42:c0:a8:01:14"}},
+				"IPAM": {"Config": [
+					{"Subnet": "192.168.1.0/24", "Gateway": "192.168.1.1", "IPRange": "192.168.1.128/25", "AuxiliaryAddresses": {"router": "192.168.1.2"}},
+					{"Subnet": "fd00::/64"}
+				]}
+			}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	ctx := t.Context()
+
+	_, err := mgr.CreateNetwork(ctx, NetworkCreateConfig{
+		Name: "br0", Driver: "macvlan", Parent: "br0",
+		Subnet: "192.168.1.0/24", Gateway: "192.168.1.1", IPRange: "192.168.1.128/25",
+		AuxAddresses: map[string]string{"router": "192.168.1.2"},
+		IPv6Subnet:   "fd00::/64",
+	})
+	if err != nil {
+		t.Fatalf("CreateNetwork() error = %v", err)
+	}
+	if createBody["EnableIPv6"] != true || createBody["Options"].(map[string]any)["parent"] != "br0" {
+		t.Errorf("create body = %v", createBody)
+	}
+	pools := createBody["IPAM"].(map[string]any)["Config"].([]any)
+	if len(pools) != 2 || pools[0].(map[string]any)["IPRange"] != "192.168.1.128/25" || pools[1].(map[string]any)["Subnet"] != "fd00::/64" {
+		t.Errorf("IPAM pools = %v", pools)
+	}
+
+	opts := NetworkConnectOptions{IPv4Address: "192.168.1.50", Aliases: []string{"web"}}
+	if err := mgr.ConnectNetwork(ctx, "br0", "web", opts); err != nil {
+		t.Fatalf("ConnectNetwork() error = %v", err)
+	}
+	ep, _ := connectBody["EndpointConfig"].(map[string]any)
+	if ep == nil || ep["IPAMConfig"].(map[string]any)["IPv4Address"] != "192.168.1.50" || ep["Aliases"].([]any)[0] != "web" {
+		t.Errorf("connect body = %v", connectBody)
+	}
+	if err := mgr.ConnectNetwork(ctx, "bridge", "web", opts); err == nil {
+		t.Error("ConnectNetwork(bridge) with a static IP error = nil")
+	}
+
+	n, err := mgr.InspectNetwork(ctx, "br0")
+	if err != nil {
+		t.Fatalf("InspectNetwork() error = %v", err)
+	}
+	if len(n.IPAM) != 2 || n.IPAM[0].AuxAddresses["router"] != "192.168.1.2" || !n.EnableIPv6 || n.Options["parent"] != "br0" {
+		t.Errorf("network = %+v", n)
+	}
+	want := NetworkEndpoint{ContainerID: "c1", Name: "plex", IPv4Address: "192.168.1.20", IPv6Address: "fd00::20", MacAddress: "02:42:c0:a8:01:14"}
+	if len(n.Endpoints) != 1 || n.Endpoints[0] != want {
+		t.Errorf("Endpoints = %+v, want %+v", n.Endpoints, want)
+	}
+}
+
+func Test_DockerTools_NetworkConnect_StaticIP(t *testing.T) {
+	mgr := newPortsMock(t)
+	regs := DockerTools(mgr, safety.NewFilter(nil, nil), nil, nil, safety.NewConfirmationTracker(DestructiveTools), nil)
+
+	tests := []struct {
+		name string
+		args map[string]any
+		want string
+	}{
+		{"taken", map[string]any{"network_id": "br0", "container_id": "ghi789", "ip_address": "192.168.1.20"}, "already assigned to plex"},
+		{"invalid", map[string]any{"network_id": "br0", "container_id": "ghi789", "ip_address": "nope"}, "invalid IPv4"},
+		{"free", map[string]any{"network_id": "br0-id", "container_id": "ghi789", "ip_address": "192.168.1.40", "aliases": []any{"radarr.lan"}}, "connected to network"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if text := callTool(t, regs, "docker_network_connect", tt.args); !strings.Contains(text, tt.want) {
+				t.Errorf("docker_network_connect = %s, want %q", text, tt.want)
+			}
+		})
+	}
+
+	text := callTool(t, regs, "docker_network_create", map[string]any{"name": "vlan", "driver": "bridge", "parent": "eth0"})
+	if !strings.Contains(text, "macvlan or ipvlan") {
+		t.Errorf("docker_network_create = %s, want validation error before confirmation", text)
+	}
+
+	text = callTool(t, regs, "docker_network_create", map[string]any{
+		"name": "vlan", "driver": "macvlan", "subnet": "192.168.10.0/24", "aux_addresses": []any{"router"},
+	})
+	if !strings.Contains(text, "has no value") {
+		t.Errorf("docker_network_create(aux without address) = %s", text)
+	}
+
+	text = callTool(t, regs, "docker_network_create", map[string]any{
+		"name": "vlan", "driver": "macvlan", "subnet": "192.168.10.0/24", "gateway": "192.168.10.1",
+		"ip_range": "192.168.10.128/25", "options": []any{"macvlan_mode=bridge"}, "internal": true,
+	})
+	for _, want := range []string{`gateway="192.168.10.1"`, `ip_range="192.168.10.128/25"`, "options=macvlan_mode=bridge", "internal"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("docker_network_create prompt lacks %s:\n%s", want, text)
+		}
+	}
+}
+
+func Test_ParseKeyValuesStrict(t *testing.T) {
+	for _, entry := range []string{"router", "router="} {
+		if _, err := parseKeyValuesStrict([]string{"nas=10.0.0.2", entry}); err == nil {
+			t.Errorf("parseKeyValuesStrict(%q) succeeded, want an error for the missing value", entry)
+		}
+	}
+	if got, err := parseKeyValuesStrict([]string{"router=10.0.0.2"}); err != nil || got["router"] != "10.0.0.2" {
+		t.Errorf("parseKeyValuesStrict() = %v, %v", got, err)
+	}
+}
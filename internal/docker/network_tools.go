@@ -4,6 +4,7 @@ package docker
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jamesprial/unraid-mcp/internal/safety"
@@ -58,6 +59,15 @@ func toolDockerNetworkInspect(mgr DockerManager, filter *safety.Filter, audit *s
 			tools.LogAudit(audit, "docker_network_inspect", params, "error: "+err.Error(), start)
 			return tools.ErrorResult(err.Error()), nil
 		}
+		redacted := *detail
+		redacted.Endpoints = make([]NetworkEndpoint, len(detail.Endpoints))
+		for i, ep := range detail.Endpoints {
+			if !filter.IsAllowed(ep.Name) {
+				ep.Name, ep.ContainerID = hiddenContainer, ""
+			}
+			redacted.Endpoints[i] = ep
+		}
+		detail = &redacted
 
 		tools.LogAudit(audit, "docker_network_inspect", params, "ok", start)
 		return tools.JSONResult(detail), nil
@@ -70,16 +80,42 @@ func toolDockerNetworkCreate(mgr DockerManager, filter *safety.Filter, confirm *
 	const toolName = "docker_network_create"
 
 	tool := mcp.NewTool(toolName,
-		mcp.WithDescription("Create a new Docker network. Requires confirmation."),
+		mcp.WithDescription("Create a new Docker network, including macvlan and ipvlan networks on a host interface like Unraid's br0. Requires confirmation."),
 		mcp.WithString("name",
 			mcp.Required(),
 			mcp.Description("Network name"),
 		),
 		mcp.WithString("driver",
-			mcp.Description("Network driver (e.g. bridge, overlay)"),
+			mcp.Description("Network driver (e.g. bridge, macvlan, ipvlan)"),
 		),
 		mcp.WithString("subnet",
-			mcp.Description("Subnet in CIDR notation (e.g. 192.168.100.0/24)"),
+			mcp.Description("IPv4 subnet in CIDR notation (e.g. 192.168.100.0/24)"),
+		),
+		mcp.WithString("gateway",
+			mcp.Description("IPv4 gateway within the subnet (e.g. 192.168.100.1)"),
+		),
+		mcp.WithString("ip_range",
+			mcp.Description("Part of the subnet to allocate container addresses from, in CIDR notation (e.g. 192.168.100.128/25)"),
+		),
+		mcp.WithArray("aux_addresses",
+			mcp.Description("Addresses the daemon must not allocate, as host=ip strings (e.g. router=192.168.100.2)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithString("ipv6_subnet",
+			mcp.Description("IPv6 subnet in CIDR notation; enables IPv6"),
+		),
+		mcp.WithString("ipv6_gateway",
+			mcp.Description("IPv6 gateway within the IPv6 subnet"),
+		),
+		mcp.WithString("parent",
+			mcp.Description("Host interface for macvlan or ipvlan, optionally with a VLAN (e.g. eth0, br0.10)"),
+		),
+		mcp.WithArray("options",
+			mcp.Description("Other driver options as key=value strings (e.g. macvlan_mode=bridge, ipvlan_mode=l2)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithBoolean("internal",
+			mcp.Description("Restrict the network to container-to-container traffic (default: false)"),
 		),
 		mcp.WithString("confirmation_token",
 			mcp.Description("Confirmation token returned by a prior call to this tool"),
@@ -92,22 +128,67 @@ func toolDockerNetworkCreate(mgr DockerManager, filter *safety.Filter, confirm *
 		driver := req.GetString("driver", "")
 		subnet := req.GetString("subnet", "")
 		token := req.GetString("confirmation_token", "")
+		cfg := NetworkCreateConfig{
+			Name:        name,
+			Driver:      driver,
+			Subnet:      subnet,
+			Gateway:     req.GetString("gateway", ""),
+			IPRange:     req.GetString("ip_range", ""),
+			IPv6Subnet:  req.GetString("ipv6_subnet", ""),
+			IPv6Gateway: req.GetString("ipv6_gateway", ""),
+			Parent:      req.GetString("parent", ""),
+			Internal:    req.GetBool("internal", false),
+		}
+		auxList := req.GetStringSlice("aux_addresses", nil)
+		optionList := req.GetStringSlice("options", nil)
 		params := map[string]any{"name": name, "driver": driver, "subnet": subnet}
+		for key, value := range map[string]any{
+			"gateway": cfg.Gateway, "ip_range": cfg.IPRange, "aux_addresses": auxList,
+			"ipv6_subnet": cfg.IPv6Subnet, "ipv6_gateway": cfg.IPv6Gateway, "parent": cfg.Parent,
+			"options": optionList, "internal": cfg.Internal,
+		} {
+			if !isZeroParam(value) {
+				params[key] = value
+			}
+		}
 
 		if !filter.IsAllowed(name) {
 			tools.LogAudit(audit, toolName, params, "denied", start)
 			return tools.ErrorResult(fmt.Sprintf("creation of network %q is not allowed", name)), nil
 		}
 
-		if !confirm.Confirm(token) {
-			desc := fmt.Sprintf("This will create a new Docker network %q (driver=%q, subnet=%q).", name, driver, subnet)
-			return tools.ConfirmPrompt(confirm, toolName, name, desc), nil
+		var err error
+		if cfg.AuxAddresses, err = parseKeyValuesStrict(auxList); err != nil {
+			return tools.ErrorResult(fmt.Sprintf("aux_addresses: %v", err)), nil
+		}
+		if cfg.Options, err = parseKeyValuesStrict(optionList); err != nil {
+			return tools.ErrorResult(fmt.Sprintf("options: %v", err)), nil
+		}
+		if err := cfg.Validate(); err != nil {
+			return tools.ErrorResult(err.Error()), nil
 		}
 
-		cfg := NetworkCreateConfig{
-			Name:   name,
-			Driver: driver,
-			Subnet: subnet,
+		if !confirm.Confirm(token, toolName, name, params) {
+			desc := fmt.Sprintf("This will create a new Docker network %q (driver=%q, subnet=%q", name, driver, subnet)
+			for _, f := range []struct{ key, value string }{
+				{"gateway", cfg.Gateway}, {"ip_range", cfg.IPRange},
+				{"ipv6_subnet", cfg.IPv6Subnet}, {"ipv6_gateway", cfg.IPv6Gateway}, {"parent", cfg.Parent},
+			} {
+				if f.value != "" {
+					desc += fmt.Sprintf(", %s=%q", f.key, f.value)
+				}
+			}
+			if len(auxList) > 0 {
+				desc += fmt.Sprintf(", aux_addresses=%s", strings.Join(auxList, ","))
+			}
+			if len(optionList) > 0 {
+				desc += fmt.Sprintf(", options=%s", strings.Join(optionList, ","))
+			}
+			if cfg.Internal {
+				desc += ", internal"
+			}
+			desc += ")."
+			return tools.ConfirmPrompt(confirm, toolName, name, desc, params), nil
 		}
 
 		networkID, err := mgr.CreateNetwork(ctx, cfg)
@@ -148,9 +229,9 @@ func toolDockerNetworkRemove(mgr DockerManager, filter *safety.Filter, confirm *
 			return tools.ErrorResult(fmt.Sprintf("access to network %q is not allowed", id)), nil
 		}
 
-		if !confirm.Confirm(token) {
+		if !confirm.Confirm(token, toolName, id, params) {
 			desc := fmt.Sprintf("This will permanently remove network %q.", id)
-			return tools.ConfirmPrompt(confirm, toolName, id, desc), nil
+			return tools.ConfirmPrompt(confirm, toolName, id, desc, params), nil
 		}
 
 		if err := mgr.RemoveNetwork(ctx, id); err != nil {
@@ -167,7 +248,8 @@ func toolDockerNetworkRemove(mgr DockerManager, filter *safety.Filter, confirm *
 
 func toolDockerNetworkConnect(mgr DockerManager, filter *safety.Filter, audit *safety.AuditLogger) tools.Registration {
 	tool := mcp.NewTool("docker_network_connect",
-		mcp.WithDescription("Connect a container to a Docker network."),
+		mcp.WithDescription("Connect a container to a Docker network, optionally with a static address and aliases. "+
+			"Refuses addresses already held by a running container."),
 		mcp.WithString("network_id",
 			mcp.Required(),
 			mcp.Description("Network ID or name"),
@@ -176,13 +258,35 @@ func toolDockerNetworkConnect(mgr DockerManager, filter *safety.Filter, audit *s
 			mcp.Required(),
 			mcp.Description("Container ID or name"),
 		),
+		mcp.WithString("ip_address",
+			mcp.Description("Static IPv4 address on a user-defined network (e.g. br0)"),
+		),
+		mcp.WithString("ipv6_address",
+			mcp.Description("Static IPv6 address on a user-defined network"),
+		),
+		mcp.WithArray("aliases",
+			mcp.Description("Extra DNS names for the container on the network"),
+			mcp.WithStringItems(),
+		),
 	)
 
 	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		start := time.Now()
 		networkID := req.GetString("network_id", "")
 		containerID := req.GetString("container_id", "")
+		opts := NetworkConnectOptions{
+			IPv4Address: req.GetString("ip_address", ""),
+			IPv6Address: req.GetString("ipv6_address", ""),
+			Aliases:     req.GetStringSlice("aliases", nil),
+		}
 		params := map[string]any{"network_id": networkID, "container_id": containerID}
+		for key, value := range map[string]any{
+			"ip_address": opts.IPv4Address, "ipv6_address": opts.IPv6Address, "aliases": opts.Aliases,
+		} {
+			if !isZeroParam(value) {
+				params[key] = value
+			}
+		}
 
 		if !filter.IsAllowed(networkID) {
 			tools.LogAudit(audit, "docker_network_connect", params, "denied", start)
@@ -193,13 +297,26 @@ func toolDockerNetworkConnect(mgr DockerManager, filter *safety.Filter, audit *s
 			return tools.ErrorResult(fmt.Sprintf("access to container %q is not allowed", containerID)), nil
 		}
 
-		if err := mgr.ConnectNetwork(ctx, networkID, containerID); err != nil {
+		if err := opts.Validate(networkID); err != nil {
+			return tools.ErrorResult(err.Error()), nil
+		}
+		warnings, err := preflightConnect(ctx, mgr, filter, networkID, containerID, opts.IPv4Address)
+		if err != nil {
+			tools.LogAudit(audit, "docker_network_connect", params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+
+		if err := mgr.ConnectNetwork(ctx, networkID, containerID, opts); err != nil {
 			tools.LogAudit(audit, "docker_network_connect", params, "error: "+err.Error(), start)
 			return tools.ErrorResult(err.Error()), nil
 		}
 
 		tools.LogAudit(audit, "docker_network_connect", params, "ok", start)
-		return mcp.NewToolResultText(fmt.Sprintf("container %q connected to network %q", containerID, networkID)), nil
+		text := fmt.Sprintf("container %q connected to network %q", containerID, networkID)
+		if len(warnings) > 0 {
+			text += "; warnings: " + strings.Join(warnings, "; ")
+		}
+		return mcp.NewToolResultText(text), nil
 	}
 
 	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
@@ -0,0 +1,38 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"context"
+	"time"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+	"github.com/jamesprial/unraid-mcp/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func toolDockerPortMap(mgr DockerManager, filter *safety.Filter, audit *safety.AuditLogger) tools.Registration {
+	tool := mcp.NewTool("docker_port_map",
+		mcp.WithDescription("Show every host port published by a container, running or stopped, and every IPv4 address "+
+			"containers hold on each network, including static addresses reserved by stopped containers. "+
+			"Use it to pick a free host port or IP before creating a container. "+
+			"docker_create and docker_network_connect check against the same map and refuse conflicts with running containers."),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		params := map[string]any{}
+
+		p, err := CollectPortMap(ctx, mgr)
+		if err != nil {
+			tools.LogAudit(audit, "docker_port_map", params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+		p.redact(filter)
+
+		tools.LogAudit(audit, "docker_port_map", params, "ok", start)
+		return tools.JSONResult(p), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
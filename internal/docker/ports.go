@@ -0,0 +1,473 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+)
+
+// ---------------------------------------------------------------------------
+// Port and address conflicts
+// ---------------------------------------------------------------------------
+
+// hiddenContainer stands in for the name of a container the filter denies.
+const hiddenContainer = "(filtered)"
+
+// PortUse is a host port published by a container. Stopped containers keep
+// their configured ports and claim them again when started.
+type PortUse struct {
+	HostIP        string // empty = all interfaces
+	HostPort      int
+	Protocol      string
+	ContainerPort int
+	Container     string
+	ContainerID   string
+	State         string // container state; "planned" for one not yet created
+}
+
+// AddressUse is an IPv4 address held by a container on a network. Static
+// addresses are reserved even while the container is stopped.
+type AddressUse struct {
+	Network     string
+	IPv4Address string
+	Container   string
+	ContainerID string
+	State       string
+	Static      bool
+}
+
+// PortMap lists the host ports and network addresses used by all containers.
+type PortMap struct {
+	Ports     []PortUse
+	Addresses []AddressUse
+	Warnings  []string
+
+	networks []networkRange
+}
+
+// networkRange is the addressing of a network, for checking static IPs.
+type networkRange struct {
+	id, name string
+	subnet   netip.Prefix
+	gateway  netip.Addr
+}
+
+// Conflict is a host port or address that a new container or network
+// connection would collide with.
+type Conflict struct {
+	Kind      string // "port" or "ip"
+	Resource  string // e.g. "0.0.0.0:8080/tcp" or "192.168.1.50 on br0"
+	Container string // holder of the resource; empty for reserved addresses
+	State     string
+	Reason    string
+}
+
+// Blocking reports whether the daemon will refuse the operation, because
+// the resource is held by a running container or cannot be assigned at all.
+// Conflicts with stopped containers surface only when both are running.
+func (c Conflict) Blocking() bool {
+	switch c.State {
+	case "created", "exited", "dead", "stopped":
+		return false
+	}
+	return true
+}
+
+// CollectPortMap inspects every container and network to build the map of
+// host ports and addresses in use. Containers or networks that disappear
+// while it runs are skipped.
+func CollectPortMap(ctx context.Context, mgr DockerManager) (*PortMap, error) {
+	containers, err := mgr.ListContainers(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	networks, err := mgr.ListNetworks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &PortMap{}
+	running := make(map[string]bool)
+	for _, c := range containers {
+		detail, err := mgr.InspectContainer(ctx, c.ID)
+		if err != nil {
+			p.Warnings = append(p.Warnings, fmt.Sprintf("skipped container %s: %v", c.Name, err))
+			continue
+		}
+		p.addContainer(detail)
+		running[c.ID] = c.State == "running"
+	}
+
+	for _, n := range networks {
+		detail, err := mgr.InspectNetwork(ctx, n.ID)
+		if err != nil {
+			p.Warnings = append(p.Warnings, fmt.Sprintf("skipped network %s: %v", n.Name, err))
+			continue
+		}
+		r := networkRange{id: detail.ID, name: detail.Name}
+		pools := append([]IPAMConfig{{Subnet: detail.Subnet, Gateway: detail.Gateway}}, detail.IPAM...)
+		for _, pool := range pools {
+			if subnet, err := netip.ParsePrefix(pool.Subnet); err == nil && subnet.Addr().Is4() {
+				r.subnet = subnet
+				r.gateway, _ = netip.ParseAddr(pool.Gateway)
+				break
+			}
+		}
+		p.networks = append(p.networks, r)
+
+		for _, ep := range detail.Endpoints {
+			if ep.IPv4Address == "" || p.hasAddress(detail.Name, ep.IPv4Address, ep.ContainerID) {
+				continue
+			}
+			state := "running"
+			if r, ok := running[ep.ContainerID]; ok && !r {
+				state = "stopped"
+			}
+			p.Addresses = append(p.Addresses, AddressUse{
+				Network:     detail.Name,
+				IPv4Address: ep.IPv4Address,
+				Container:   ep.Name,
+				ContainerID: ep.ContainerID,
+				State:       state,
+			})
+		}
+	}
+
+	p.sort()
+	return p, nil
+}
+
+// addContainer records the ports and static addresses of c.
+func (p *PortMap) addContainer(c *ContainerDetail) {
+	seen := make(map[PortMapping]bool)
+	add := func(pm PortMapping) {
+		if pm.HostPort == 0 || seen[pm] {
+			return
+		}
+		seen[pm] = true
+		p.Ports = append(p.Ports, PortUse{
+			HostIP:        pm.HostIP,
+			HostPort:      pm.HostPort,
+			Protocol:      pm.Protocol,
+			ContainerPort: pm.ContainerPort,
+			Container:     c.Name,
+			ContainerID:   c.ID,
+			State:         c.State,
+		})
+	}
+	for _, pm := range c.NetworkSettings.PortBindings {
+		add(normalizePortMapping(pm))
+	}
+	// A running container's ports include those the daemon assigned.
+	if c.State == "running" {
+		for portProto, binding := range c.NetworkSettings.Ports {
+			i := strings.LastIndex(binding, ":")
+			containerPort, proto, _ := strings.Cut(portProto, "/")
+			cp, err := strconv.Atoi(containerPort)
+			if i < 0 || err != nil {
+				continue
+			}
+			hp, err := strconv.Atoi(binding[i+1:])
+			if err != nil {
+				continue
+			}
+			pm := normalizePortMapping(PortMapping{ContainerPort: cp, Protocol: proto, HostIP: binding[:i], HostPort: hp})
+			if !portCovered(seen, pm) {
+				add(pm)
+			}
+		}
+	}
+
+	for network, ip := range c.NetworkSettings.StaticIPs {
+		p.Addresses = append(p.Addresses, AddressUse{
+			Network:     network,
+			IPv4Address: ip,
+			Container:   c.Name,
+			ContainerID: c.ID,
+			State:       c.State,
+			Static:      true,
+		})
+	}
+}
+
+// Add records the ports and static address of cfg as a planned container,
+// so containers created together are checked against each other.
+func (p *PortMap) Add(cfg ContainerCreateConfig) {
+	for _, pm := range createPortBindings(cfg) {
+		p.Ports = append(p.Ports, PortUse{
+			HostIP:        pm.HostIP,
+			HostPort:      pm.HostPort,
+			Protocol:      pm.Protocol,
+			ContainerPort: pm.ContainerPort,
+			Container:     cfg.Name,
+			State:         "planned",
+		})
+	}
+	if cfg.IPv4Address != "" {
+		p.Addresses = append(p.Addresses, AddressUse{
+			Network:     p.networkName(cfg.NetworkMode),
+			IPv4Address: cfg.IPv4Address,
+			Container:   cfg.Name,
+			State:       "planned",
+			Static:      true,
+		})
+	}
+}
+
+// CheckCreate returns the conflicts of a container created from cfg.
+// Containers named or identified in replacing are ignored, as they will be
+// removed first.
+func (p *PortMap) CheckCreate(cfg ContainerCreateConfig, replacing ...string) []Conflict {
+	ignore := make(map[string]bool, len(replacing))
+	for _, r := range replacing {
+		ignore[r] = true
+	}
+	skip := func(name, id string) bool {
+		return ignore[name] || (id != "" && ignore[id]) || (cfg.Name != "" && name == cfg.Name)
+	}
+
+	var conflicts []Conflict
+	for _, pm := range createPortBindings(cfg) {
+		for _, u := range p.Ports {
+			if skip(u.Container, u.ContainerID) || u.HostPort != pm.HostPort || u.Protocol != pm.Protocol || !hostIPsOverlap(u.HostIP, pm.HostIP) {
+				continue
+			}
+			conflicts = append(conflicts, Conflict{
+				Kind:      "port",
+				Resource:  formatHostPort(pm),
+				Container: u.Container,
+				State:     u.State,
+				Reason:    fmt.Sprintf("host port %s is published by %s", formatHostPort(pm), u.Container),
+			})
+		}
+	}
+	if cfg.IPv4Address != "" {
+		conflicts = append(conflicts, p.checkAddress(cfg.NetworkMode, cfg.IPv4Address, skip)...)
+	}
+	return conflicts
+}
+
+// CheckConnect returns the conflicts of connecting container to network,
+// with a static ipv4 address if it is not empty.
+func (p *PortMap) CheckConnect(network, container, ipv4 string) []Conflict {
+	name := p.networkName(network)
+	var conflicts []Conflict
+	for _, a := range p.Addresses {
+		if a.Network == name && (a.Container == container || a.ContainerID == container) {
+			conflicts = append(conflicts, Conflict{
+				Kind:     "ip",
+				Resource: a.IPv4Address + " on " + name,
+				Reason:   fmt.Sprintf("container %s is already connected to %s", a.Container, name),
+			})
+			break
+		}
+	}
+	if ipv4 != "" {
+		skip := func(n, id string) bool { return n == container || id == container }
+		conflicts = append(conflicts, p.checkAddress(network, ipv4, skip)...)
+	}
+	return conflicts
+}
+
+// checkAddress checks a static ip on network against the addresses in use
+// and the network's subnet and gateway.
+func (p *PortMap) checkAddress(network, ip string, skip func(name, id string) bool) []Conflict {
+	name := p.networkName(network)
+	resource := ip + " on " + name
+	var conflicts []Conflict
+	if addr, err := netip.ParseAddr(ip); err == nil {
+		for _, r := range p.networks {
+			if r.name != name {
+				continue
+			}
+			if r.subnet.IsValid() && !r.subnet.Contains(addr) {
+				conflicts = append(conflicts, Conflict{Kind: "ip", Resource: resource,
+					Reason: fmt.Sprintf("%s is outside %s's subnet %s", ip, name, r.subnet)})
+			}
+			if r.gateway == addr {
+				conflicts = append(conflicts, Conflict{Kind: "ip", Resource: resource,
+					Reason: fmt.Sprintf("%s is %s's gateway", ip, name)})
+			}
+		}
+	}
+	for _, a := range p.Addresses {
+		if a.Network != name || a.IPv4Address != ip || skip(a.Container, a.ContainerID) {
+			continue
+		}
+		conflicts = append(conflicts, Conflict{
+			Kind:      "ip",
+			Resource:  resource,
+			Container: a.Container,
+			State:     a.State,
+			Reason:    fmt.Sprintf("%s is already assigned to %s", resource, a.Container),
+		})
+	}
+	return conflicts
+}
+
+// networkName resolves a network ID to its name; names pass through.
+func (p *PortMap) networkName(network string) string {
+	for _, r := range p.networks {
+		if r.id == network {
+			return r.name
+		}
+	}
+	return network
+}
+
+func (p *PortMap) hasAddress(network, ip, containerID string) bool {
+	for _, a := range p.Addresses {
+		if a.Network == network && a.IPv4Address == ip && a.ContainerID == containerID {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *PortMap) sort() {
+	sort.SliceStable(p.Ports, func(i, j int) bool {
+		a, b := p.Ports[i], p.Ports[j]
+		if a.HostPort != b.HostPort {
+			return a.HostPort < b.HostPort
+		}
+		if a.Protocol != b.Protocol {
+			return a.Protocol < b.Protocol
+		}
+		return a.Container < b.Container
+	})
+	sort.SliceStable(p.Addresses, func(i, j int) bool {
+		a, b := p.Addresses[i], p.Addresses[j]
+		if a.Network != b.Network {
+			return a.Network < b.Network
+		}
+		return a.IPv4Address < b.IPv4Address
+	})
+}
+
+// redact replaces the names and IDs of containers the filter denies with a
+// placeholder. The ports and addresses themselves stay, as they are taken
+// either way.
+func (p *PortMap) redact(filter *safety.Filter) {
+	for i := range p.Ports {
+		if !filter.IsAllowed(p.Ports[i].Container) {
+			p.Ports[i].Container, p.Ports[i].ContainerID = hiddenContainer, ""
+		}
+	}
+	for i := range p.Addresses {
+		if !filter.IsAllowed(p.Addresses[i].Container) {
+			p.Addresses[i].Container, p.Addresses[i].ContainerID = hiddenContainer, ""
+		}
+	}
+}
+
+// createPortBindings returns the host ports cfg publishes, normalized.
+// Daemon-assigned ports cannot conflict and are left out, as are all ports
+// of a host-networked container, which the daemon ignores.
+func createPortBindings(cfg ContainerCreateConfig) []PortMapping {
+	if cfg.NetworkMode == "host" {
+		return nil
+	}
+	var out []PortMapping
+	for containerPort, hostPort := range cfg.Ports {
+		port, proto, _ := strings.Cut(containerPort, "/")
+		cp, _ := strconv.Atoi(port)
+		hp, err := strconv.Atoi(hostPort)
+		if err != nil || hp == 0 {
+			continue
+		}
+		out = append(out, normalizePortMapping(PortMapping{ContainerPort: cp, Protocol: proto, HostPort: hp}))
+	}
+	for _, pm := range cfg.PortBindings {
+		if pm.HostPort != 0 {
+			out = append(out, normalizePortMapping(pm))
+		}
+	}
+	return out
+}
+
+// normalizePortMapping defaults the protocol to tcp and treats every form of
+// "all interfaces" as empty.
+func normalizePortMapping(pm PortMapping) PortMapping {
+	if pm.Protocol == "" {
+		pm.Protocol = "tcp"
+	}
+	if pm.HostIP == "0.0.0.0" || pm.HostIP == "::" {
+		pm.HostIP = ""
+	}
+	return pm
+}
+
+// portCovered reports whether pm is already in seen, ignoring the host IP:
+// the daemon reports one binding per address family.
+func portCovered(seen map[PortMapping]bool, pm PortMapping) bool {
+	for s := range seen {
+		if s.HostPort == pm.HostPort && s.Protocol == pm.Protocol && s.ContainerPort == pm.ContainerPort {
+			return true
+		}
+	}
+	return false
+}
+
+// hostIPsOverlap reports whether bindings on host IPs a and b would claim
+// the same socket. An empty IP means all interfaces.
+func hostIPsOverlap(a, b string) bool {
+	return a == "" || b == "" || a == b
+}
+
+func formatHostPort(pm PortMapping) string {
+	ip := pm.HostIP
+	if ip == "" {
+		ip = "0.0.0.0"
+	}
+	return net.JoinHostPort(ip, strconv.Itoa(pm.HostPort)) + "/" + pm.Protocol
+}
+
+// preflightCreate checks cfgs, in order, against the ports and addresses in
+// use, ignoring the containers in replacing. Blocking conflicts are returned
+// as an error; conflicts with stopped containers, and a failure to collect
+// the port map, as warnings.
+func preflightCreate(ctx context.Context, mgr DockerManager, filter *safety.Filter, cfgs []ContainerCreateConfig, replacing ...string) ([]string, error) {
+	p, err := CollectPortMap(ctx, mgr)
+	if err != nil {
+		return []string{"could not check for port and IP conflicts: " + err.Error()}, nil
+	}
+	p.redact(filter)
+	var conflicts []Conflict
+	for _, cfg := range cfgs {
+		conflicts = append(conflicts, p.CheckCreate(cfg, replacing...)...)
+		p.Add(cfg)
+	}
+	return conflictResult(conflicts)
+}
+
+// preflightConnect is preflightCreate for a network connection.
+func preflightConnect(ctx context.Context, mgr DockerManager, filter *safety.Filter, network, container, ipv4 string) ([]string, error) {
+	p, err := CollectPortMap(ctx, mgr)
+	if err != nil {
+		return []string{"could not check for IP conflicts: " + err.Error()}, nil
+	}
+	p.redact(filter)
+	return conflictResult(p.CheckConnect(network, container, ipv4))
+}
+
+func conflictResult(conflicts []Conflict) ([]string, error) {
+	var blocking, warnings []string
+	for _, c := range conflicts {
+		if c.Blocking() {
+			blocking = append(blocking, c.Reason)
+		} else {
+			warnings = append(warnings, fmt.Sprintf("%s (%s); they cannot run at the same time", c.Reason, c.State))
+		}
+	}
+	if len(blocking) > 0 {
+		return warnings, fmt.Errorf("conflict: %s", strings.Join(blocking, "; "))
+	}
+	return warnings, nil
+}
@@ -0,0 +1,240 @@
+package docker
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+)
+
+// ---------------------------------------------------------------------------
+// Inspect parsing
+// ---------------------------------------------------------------------------
+
+func Test_DockerClientManager_PortsAndEndpoints_Parsing(t *testing.T) {
+	mgr := newFakeDaemon(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/containers/web/json":
+			writeJSON(w, http.StatusOK, `{
+				"Id": "web-id", "Name": "/web", "State": {"Status": "exited"},
+				"HostConfig": {"PortBindings": {
+					"80/tcp": [{"HostIp": "", "HostPort": "8080"}],
+					"53/udp": [{"HostIp": "192.168.1.10", "HostPort": "53"}],
+					"9000/tcp": [{"HostIp": "", "HostPort": ""}]
+				}},
+				"NetworkSettings": {"Networks": {
+					"br0": {"IPAMConfig": {"IPv4Address": "192.168.1.50"}},
+					"bridge": {"IPAMConfig": null}
+				}}
+			}`)
+		case "/networks/br0":
+			writeJSON(w, http.StatusOK, `{
+				"Id": "br0-id", "Name": "br0", "Driver": "macvlan",
+				"Containers": {"web-id": {"Name": "web", "IPv4Address": "192.168.1.50/24"}},
+				"IPAM": {"Config": [{"Subnet": "192.168.1.0/24", "Gateway": "192.168.1.1"}]}
+			}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	c, err := mgr.InspectContainer(t.Context(), "web")
+	if err != nil {
+		t.Fatalf("InspectContainer() error = %v", err)
+	}
+	want := []PortMapping{
+		{ContainerPort: 9000, Protocol: "tcp"},
+		{ContainerPort: 53, Protocol: "udp", HostIP: "192.168.1.10", HostPort: 53},
+		{ContainerPort: 80, Protocol: "tcp", HostPort: 8080},
+	}
+	if len(c.NetworkSettings.PortBindings) != len(want) {
+		t.Fatalf("PortBindings = %+v, want %+v", c.NetworkSettings.PortBindings, want)
+	}
+	for i := range want {
+		if c.NetworkSettings.PortBindings[i] != want[i] {
+			t.Errorf("PortBindings[%d] = %+v, want %+v", i, c.NetworkSettings.PortBindings[i], want[i])
+		}
+	}
+	if got := c.NetworkSettings.StaticIPs; len(got) != 1 || got["br0"] != "192.168.1.50" {
+		t.Errorf("StaticIPs = %v, want br0 only", got)
+	}
+
+	n, err := mgr.InspectNetwork(t.Context(), "br0")
+	if err != nil {
+		t.Fatalf("InspectNetwork() error = %v", err)
+	}
+	if len(n.Endpoints) != 1 || n.Endpoints[0] != (NetworkEndpoint{ContainerID: "web-id", Name: "web", IPv4Address: "192.168.1.50"}) {
+		t.Errorf("Endpoints = %+v", n.Endpoints)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Conflict checks
+// ---------------------------------------------------------------------------
+
+// newPortsMock returns a mock with plex publishing 32400/tcp and running on
+// br0 at .20, and a stopped sonarr publishing 8989/tcp with a static .30.
+func newPortsMock(t *testing.T) *MockDockerManager {
+	t.Helper()
+	m := newPopulatedMock(t)
+	m.containers["def456"].NetworkSettings = NetworkInfo{
+		PortBindings: []PortMapping{{ContainerPort: 8989, Protocol: "tcp", HostPort: 8989}},
+		StaticIPs:    map[string]string{"br0": "192.168.1.30"},
+	}
+	m.AddNetwork(&NetworkDetail{
+		Network:    Network{ID: "br0-id", Name: "br0", Driver: "macvlan"},
+		Containers: []string{"abc123"},
+		Endpoints:  []NetworkEndpoint{{ContainerID: "abc123", Name: "plex", IPv4Address: "192.168.1.20"}},
+		Subnet:     "192.168.1.0/24",
+		Gateway:    "192.168.1.1",
+	})
+	return m
+}
+
+func Test_PortMap_CheckCreate(t *testing.T) {
+	p, err := CollectPortMap(t.Context(), newPortsMock(t))
+	if err != nil {
+		t.Fatalf("CollectPortMap() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		cfg       ContainerCreateConfig
+		replacing []string
+		want      []string // reasons
+		blocking  bool
+	}{
+		{
+			name:     "running holder",
+			cfg:      ContainerCreateConfig{Name: "new", PortBindings: []PortMapping{{ContainerPort: 80, HostPort: 32400}}},
+			want:     []string{"host port 0.0.0.0:32400/tcp is published by plex"},
+			blocking: true,
+		},
+		{
+			name: "stopped holder",
+			cfg:  ContainerCreateConfig{Name: "new", Ports: map[string]string{"80/tcp": "8989"}},
+			want: []string{"host port 0.0.0.0:8989/tcp is published by sonarr"},
+		},
+		{
+			name: "other protocol",
+			cfg:  ContainerCreateConfig{Name: "new", PortBindings: []PortMapping{{ContainerPort: 80, Protocol: "udp", HostPort: 32400}}},
+		},
+		{
+			name: "host networking",
+			cfg:  ContainerCreateConfig{Name: "new", NetworkMode: "host", PortBindings: []PortMapping{{ContainerPort: 80, HostPort: 32400}}},
+		},
+		{
+			name:      "replacing the holder",
+			cfg:       ContainerCreateConfig{Name: "new", PortBindings: []PortMapping{{ContainerPort: 80, HostPort: 32400}}},
+			replacing: []string{"abc123"},
+		},
+		{
+			name:     "IP in use by ID",
+			cfg:      ContainerCreateConfig{Name: "new", NetworkMode: "br0-id", IPv4Address: "192.168.1.20"},
+			want:     []string{"192.168.1.20 on br0 is already assigned to plex"},
+			blocking: true,
+		},
+		{
+			name: "IP reserved by stopped container",
+			cfg:  ContainerCreateConfig{Name: "new", NetworkMode: "br0", IPv4Address: "192.168.1.30"},
+			want: []string{"192.168.1.30 on br0 is already assigned to sonarr"},
+		},
+		{
+			name:     "gateway",
+			cfg:      ContainerCreateConfig{Name: "new", NetworkMode: "br0", IPv4Address: "192.168.1.1"},
+			want:     []string{"192.168.1.1 is br0's gateway"},
+			blocking: true,
+		},
+		{
+			name:     "outside subnet",
+			cfg:      ContainerCreateConfig{Name: "new", NetworkMode: "br0", IPv4Address: "10.0.0.5"},
+			want:     []string{"10.0.0.5 is outside br0's subnet 192.168.1.0/24"},
+			blocking: true,
+		},
+		{
+			name: "free IP",
+			cfg:  ContainerCreateConfig{Name: "new", NetworkMode: "br0", IPv4Address: "192.168.1.40"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conflicts := p.CheckCreate(tt.cfg, tt.replacing...)
+			var reasons []string
+			for _, c := range conflicts {
+				reasons = append(reasons, c.Reason)
+				if c.Blocking() != tt.blocking {
+					t.Errorf("%q Blocking() = %v, want %v", c.Reason, c.Blocking(), tt.blocking)
+				}
+			}
+			if strings.Join(reasons, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("conflicts = %q, want %q", reasons, tt.want)
+			}
+		})
+	}
+}
+
+func Test_PortMap_PlannedContainers(t *testing.T) {
+	p := &PortMap{}
+	first := ContainerCreateConfig{Name: "db", PortBindings: []PortMapping{{ContainerPort: 5432, HostPort: 5432}}}
+	p.Add(first)
+	second := ContainerCreateConfig{Name: "db2", PortBindings: []PortMapping{{ContainerPort: 5432, HostIP: "127.0.0.1", HostPort: 5432}}}
+	if got := p.CheckCreate(second); len(got) != 1 || !got[0].Blocking() {
+		t.Errorf("CheckCreate() = %+v, want one blocking conflict with db", got)
+	}
+}
+
+func Test_PortMap_CheckConnect(t *testing.T) {
+	p, err := CollectPortMap(t.Context(), newPortsMock(t))
+	if err != nil {
+		t.Fatalf("CollectPortMap() error = %v", err)
+	}
+	if got := p.CheckConnect("br0", "plex", ""); len(got) != 1 || !strings.Contains(got[0].Reason, "already connected") {
+		t.Errorf("CheckConnect(plex) = %+v, want already connected", got)
+	}
+	if got := p.CheckConnect("br0", "radarr", ""); len(got) != 0 {
+		t.Errorf("CheckConnect(radarr) = %+v, want none", got)
+	}
+	if got := p.CheckConnect("br0-id", "radarr", "192.168.1.20"); len(got) != 1 || got[0].Container != "plex" {
+		t.Errorf("CheckConnect(radarr, .20) = %+v, want conflict with plex", got)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Tools
+// ---------------------------------------------------------------------------
+
+func Test_DockerTools_PortConflicts(t *testing.T) {
+	mgr := newPortsMock(t)
+	confirm := safety.NewConfirmationTracker(DestructiveTools)
+	regs := DockerTools(mgr, safety.NewFilter(nil, []string{"sonarr"}), nil, nil, confirm, nil)
+
+	text := callTool(t, regs, "docker_create", map[string]any{"name": "web", "image": "nginx", "ports": []any{"32400:80"}})
+	if !strings.Contains(text, "conflict: host port 0.0.0.0:32400/tcp is published by plex") {
+		t.Errorf("docker_create = %s, want port conflict", text)
+	}
+
+	// The stopped holder is denied by the filter, so it is not named.
+	text = callTool(t, regs, "docker_create", map[string]any{"name": "web", "image": "nginx", "ports": []any{"8989:80"}})
+	if !strings.Contains(text, "published by (filtered) (exited)") || strings.Contains(text, "sonarr") {
+		t.Errorf("docker_create = %s, want a redacted warning", text)
+	}
+
+	text = callTool(t, regs, "docker_network_connect", map[string]any{"network_id": "br0", "container_id": "plex"})
+	if !strings.Contains(text, "already connected") {
+		t.Errorf("docker_network_connect = %s, want already connected", text)
+	}
+
+	text = callTool(t, regs, "docker_port_map", nil)
+	var p PortMap
+	if err := json.Unmarshal([]byte(text), &p); err != nil {
+		t.Fatalf("decode result: %v\n%s", err, text)
+	}
+	if len(p.Ports) != 2 || p.Ports[0].HostPort != 8989 || p.Ports[0].Container != hiddenContainer || p.Ports[1].Container != "plex" {
+		t.Errorf("Ports = %+v", p.Ports)
+	}
+	if len(p.Addresses) != 2 || p.Addresses[0].IPv4Address != "192.168.1.20" || !p.Addresses[1].Static {
+		t.Errorf("Addresses = %+v", p.Addresses)
+	}
+}
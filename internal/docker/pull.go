@@ -0,0 +1,203 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Image pulls
+// ---------------------------------------------------------------------------
+
+// pullProgressInterval is the minimum time between progress reports while
+// layers download.
+const pullProgressInterval = time.Second
+
+// PullProgress is a snapshot of an image pull in progress.
+type PullProgress struct {
+	Layers     int   // layers seen so far
+	LayersDone int   // layers downloaded or already present
+	Current    int64 // bytes downloaded
+	Total      int64 // bytes to download, for layers whose size is known
+	Status     string
+}
+
+// PullResult is the outcome of an image pull.
+type PullResult struct {
+	Image    string
+	Digest   string // manifest digest, e.g. sha256:...
+	Status   string // e.g. "Downloaded newer image for nginx:latest"
+	Updated  bool   // a newer image was downloaded
+	Layers   int
+	Duration string
+}
+
+// SetRegistryCredentials sets the credentials sent with image pulls. Pulls
+// from registries without a credential are anonymous.
+func (m *DockerClientManager) SetRegistryCredentials(creds *RegistryCredentials) {
+	m.registryAuth = creds
+}
+
+// pullMessage is one line of the JSON stream returned by /images/create.
+type pullMessage struct {
+	ID             string `json:"id"`
+	Status         string `json:"status"`
+	ProgressDetail struct {
+		Current int64 `json:"current"`
+		Total   int64 `json:"total"`
+	} `json:"progressDetail"`
+	Error       string `json:"error"`
+	ErrorDetail struct {
+		Message string `json:"message"`
+	} `json:"errorDetail"`
+}
+
+// PullImage pulls an image from its registry, without progress reports.
+func (m *DockerClientManager) PullImage(ctx context.Context, image string) error {
+	_, err := m.PullImageWithProgress(ctx, image, nil)
+	return err
+}
+
+// PullImageWithProgress pulls an image, authenticating with the registry's
+// credential if one is set, and calls onProgress (if not nil) as layers
+// download. The daemon reports most failures, such as a denied pull or a
+// missing tag, in the stream after a 200 response; these are returned as
+// errors.
+func (m *DockerClientManager) PullImageWithProgress(ctx context.Context, image string, onProgress func(PullProgress)) (*PullResult, error) {
+	if image == "" {
+		return nil, fmt.Errorf("image name is required")
+	}
+	ref, err := parseImageReference(image)
+	if err != nil {
+		return nil, err
+	}
+
+	q := url.Values{"fromImage": {strings.TrimSuffix(image, ":"+ref.Tag)}}
+	if ref.Digest == "" {
+		q.Set("tag", ref.Tag)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.apiURL(ctx, "/images/create?"+q.Encode()), nil)
+	if err != nil {
+		return nil, fmt.Errorf("docker: build request: %w", err)
+	}
+	if auth, ok := m.registryAuth.Lookup(ref.Registry); ok {
+		header, err := registryAuthHeader(ref.Registry, auth)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("X-Registry-Auth", header)
+	}
+
+	start := time.Now()
+	// Pulls of large images take far longer than the request timeout; the
+	// stream is bounded by ctx instead.
+	resp, err := m.streamClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("docker: pull image: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		if err := checkAPIError(resp.StatusCode, body, fmt.Sprintf("image not found: %s", image)); err != nil {
+			return nil, fmt.Errorf("docker: pull image: %w", err)
+		}
+	}
+
+	result, err := readPullStream(resp.Body, onProgress)
+	if err != nil {
+		return nil, fmt.Errorf("docker: pull image %s: %w", image, err)
+	}
+	result.Image = image
+	result.Duration = time.Since(start).Round(time.Millisecond).String()
+	return result, nil
+}
+
+// pullLayer tracks the download of one layer.
+type pullLayer struct {
+	current, total int64
+	done           bool
+}
+
+// readPullStream consumes the progress stream of a pull, reporting progress
+// and returning the digest and final status, or the error the stream ends
+// with.
+func readPullStream(r io.Reader, onProgress func(PullProgress)) (*PullResult, error) {
+	result := &PullResult{}
+	layers := make(map[string]*pullLayer)
+	var order []string
+	var lastReport time.Time
+
+	report := func(status string, force bool) {
+		if onProgress == nil || (!force && time.Since(lastReport) < pullProgressInterval) {
+			return
+		}
+		lastReport = time.Now()
+		p := PullProgress{Layers: len(order), Status: status}
+		for _, id := range order {
+			l := layers[id]
+			if l.done {
+				p.LayersDone++
+			}
+			p.Current += l.current
+			p.Total += l.total
+		}
+		onProgress(p)
+	}
+
+	dec := json.NewDecoder(r)
+	for {
+		var msg pullMessage
+		if err := dec.Decode(&msg); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("read progress: %w", err)
+		}
+		if msg.Error != "" || msg.ErrorDetail.Message != "" {
+			if msg.ErrorDetail.Message != "" {
+				return nil, errors.New(msg.ErrorDetail.Message)
+			}
+			return nil, errors.New(msg.Error)
+		}
+
+		switch {
+		case strings.HasPrefix(msg.Status, "Digest: "):
+			result.Digest = strings.TrimPrefix(msg.Status, "Digest: ")
+			continue
+		case strings.HasPrefix(msg.Status, "Status: "):
+			result.Status = strings.TrimPrefix(msg.Status, "Status: ")
+			result.Updated = strings.HasPrefix(result.Status, "Downloaded newer image")
+			continue
+		case msg.ID == "" || strings.HasPrefix(msg.Status, "Pulling from "):
+			continue
+		}
+
+		l, ok := layers[msg.ID]
+		if !ok {
+			l = &pullLayer{}
+			layers[msg.ID] = l
+			order = append(order, msg.ID)
+		}
+		switch msg.Status {
+		case "Downloading":
+			l.current, l.total = msg.ProgressDetail.Current, msg.ProgressDetail.Total
+			report(msg.Status, false)
+		case "Download complete", "Verifying Checksum":
+			l.current = l.total
+		case "Pull complete", "Already exists":
+			l.current, l.done = l.total, true
+			report(msg.Status, true)
+		}
+	}
+	result.Layers = len(order)
+	return result, nil
+}
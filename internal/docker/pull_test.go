@@ -0,0 +1,140 @@
+package docker
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const pullStream = `{"status":"Pulling from owner/app","id":"v2"}
+{"status":"Pulling fs layer","progressDetail":{},"id":"l1"}
+{"status":"Already exists","progressDetail":{},"id":"l2"}
+{"status":"Downloading","progressDetail":{"current":512,"total":1024},"id":"l1"}
+{"status":"Download complete","progressDetail":{},"id":"l1"}
+{"status":"Pull complete","progressDetail":{},"id":"l1"}
+{"status":"Digest: sha256:feed"}
+{"status":"Status: Downloaded newer image for ghcr.io/owner/app:v2"}
+`
+
+func Test_PullImageWithProgress_AuthAndDigest(t *testing.T) {
+	var gotAuth, gotFrom, gotTag string
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /images/create", func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("X-Registry-Auth")
+		gotFrom, gotTag = r.URL.Query().Get("fromImage"), r.URL.Query().Get("tag")
+		writeJSON(w, http.StatusOK, pullStream)
+	})
+	mgr := newFakeDaemon(t, mux)
+	creds := NewRegistryCredentials()
+	creds.Add("https://ghcr.io", RegistryAuth{Username: "gh", Password: "secret"})
+	mgr.SetRegistryCredentials(creds)
+
+	var reports []PullProgress
+	result, err := mgr.PullImageWithProgress(context.Background(), "ghcr.io/owner/app:v2", func(p PullProgress) {
+		reports = append(reports, p)
+	})
+	if err != nil {
+		t.Fatalf("PullImageWithProgress: %v", err)
+	}
+
+	if gotFrom != "ghcr.io/owner/app" || gotTag != "v2" {
+		t.Errorf("fromImage=%q tag=%q", gotFrom, gotTag)
+	}
+	data, err := base64.URLEncoding.DecodeString(gotAuth)
+	if err != nil {
+		t.Fatalf("X-Registry-Auth %q: %v", gotAuth, err)
+	}
+	var auth map[string]string
+	if err := json.Unmarshal(data, &auth); err != nil || auth["password"] != "secret" || auth["serveraddress"] != "ghcr.io" {
+		t.Errorf("X-Registry-Auth = %s", data)
+	}
+
+	if result.Digest != "sha256:feed" || !result.Updated || result.Layers != 2 {
+		t.Errorf("result = %+v", result)
+	}
+	if len(reports) == 0 {
+		t.Fatal("no progress reported")
+	}
+	last := reports[len(reports)-1]
+	if last.LayersDone != 2 || last.Layers != 2 || last.Current != 1024 || last.Total != 1024 {
+		t.Errorf("last progress = %+v", last)
+	}
+}
+
+func Test_PullImageWithProgress_Anonymous(t *testing.T) {
+	var gotAuth string
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /images/create", func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("X-Registry-Auth")
+		writeJSON(w, http.StatusOK, `{"status":"Status: Image is up to date for nginx:latest"}`)
+	})
+	mgr := newFakeDaemon(t, mux)
+	mgr.SetRegistryCredentials(NewRegistryCredentials())
+
+	result, err := mgr.PullImageWithProgress(context.Background(), "nginx", nil)
+	if err != nil {
+		t.Fatalf("PullImageWithProgress: %v", err)
+	}
+	if gotAuth != "" {
+		t.Errorf("X-Registry-Auth = %q, want none without a credential", gotAuth)
+	}
+	if result.Updated || result.Status != "Image is up to date for nginx:latest" {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func Test_PullImage_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{
+			name:    "error at the end of the stream",
+			status:  http.StatusOK,
+			body:    `{"status":"Pulling from owner/app","id":"v2"}` + "\n" + `{"errorDetail":{"message":"unauthorized: authentication required"},"error":"unauthorized"}`,
+			wantErr: "unauthorized: authentication required",
+		},
+		{
+			name:    "error without detail",
+			status:  http.StatusOK,
+			body:    `{"error":"manifest unknown"}`,
+			wantErr: "manifest unknown",
+		},
+		{
+			name:    "not found",
+			status:  http.StatusNotFound,
+			body:    `{"message":"pull access denied"}`,
+			wantErr: "image not found",
+		},
+		{
+			name:    "daemon error",
+			status:  http.StatusInternalServerError,
+			body:    `{"message":"Get https://ghcr.io/v2/: timeout"}`,
+			wantErr: "timeout",
+		},
+		{
+			name:    "truncated stream",
+			status:  http.StatusOK,
+			body:    `{"status":"Downloading"`,
+			wantErr: "read progress",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("POST /images/create", func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, tt.status, tt.body)
+			})
+			mgr := newFakeDaemon(t, mux)
+			err := mgr.PullImage(context.Background(), "ghcr.io/owner/app:v2")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ---------------------------------------------------------------------------
+// Image references
+// ---------------------------------------------------------------------------
+
+// dockerHubRegistry is the registry of images named without a domain.
+const dockerHubRegistry = "docker.io"
+
+// imageReference is a parsed image reference such as
+// ghcr.io/linuxserver/plex:latest.
+type imageReference struct {
+	Registry   string // e.g. docker.io, ghcr.io, registry.lan:5000
+	Repository string // e.g. library/nginx
+	Tag        string // "latest" when neither a tag nor a digest is given
+	Digest     string // e.g. sha256:...; empty if pulled by tag
+}
+
+// parseImageReference splits ref into its registry, repository, tag and
+// digest, applying Docker's defaults.
+func parseImageReference(ref string) (imageReference, error) {
+	var r imageReference
+	if ref == "" || strings.ContainsAny(ref, " \t\n") {
+		return r, fmt.Errorf("invalid image reference %q", ref)
+	}
+	name := ref
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, r.Digest = name[:i], name[i+1:]
+		if !strings.Contains(r.Digest, ":") {
+			return r, fmt.Errorf("invalid image reference %q: bad digest", ref)
+		}
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, r.Tag = name[:i], name[i+1:]
+		if r.Tag == "" {
+			return r, fmt.Errorf("invalid image reference %q: empty tag", ref)
+		}
+	}
+	if r.Tag == "" && r.Digest == "" {
+		r.Tag = "latest"
+	}
+
+	r.Registry = dockerHubRegistry
+	if first, rest, ok := strings.Cut(name, "/"); ok && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		r.Registry, name = normalizeRegistry(first), rest
+	}
+	if r.Registry == dockerHubRegistry && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	if name == "" || strings.HasPrefix(name, "/") || strings.HasSuffix(name, "/") {
+		return r, fmt.Errorf("invalid image reference %q", ref)
+	}
+	r.Repository = name
+	return r, nil
+}
+
+// String returns the reference in full, e.g. docker.io/library/nginx:latest.
+func (r imageReference) String() string {
+	s := r.Registry + "/" + r.Repository
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// normalizeRegistry reduces a registry given as a host, URL or Docker Hub
+// alias to the host the daemon uses, e.g. "https://index.docker.io/v1/"
+// becomes "docker.io".
+func normalizeRegistry(registry string) string {
+	host := strings.ToLower(registry)
+	host = strings.TrimPrefix(host, "https://")
+	host = strings.TrimPrefix(host, "http://")
+	host, _, _ = strings.Cut(host, "/")
+	switch host {
+	case "index.docker.io", "registry-1.docker.io", "registry.hub.docker.com":
+		return dockerHubRegistry
+	}
+	return host
+}
+
+// ---------------------------------------------------------------------------
+// Registry credentials
+// ---------------------------------------------------------------------------
+
+// RegistryAuth is a credential for one image registry.
+type RegistryAuth struct {
+	Username      string
+	Password      string // password or access token
+	IdentityToken string // OAuth refresh token, used instead of a password
+}
+
+// RegistryCredentials holds credentials by registry host. It is safe for
+// concurrent use.
+type RegistryCredentials struct {
+	mu    sync.RWMutex
+	auths map[string]RegistryAuth
+}
+
+// NewRegistryCredentials returns an empty credential store.
+func NewRegistryCredentials() *RegistryCredentials {
+	return &RegistryCredentials{auths: make(map[string]RegistryAuth)}
+}
+
+// Add sets the credential for registry, replacing any earlier one.
+func (c *RegistryCredentials) Add(registry string, auth RegistryAuth) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.auths[normalizeRegistry(registry)] = auth
+}
+
+// dockerConfigFile is the part of a docker config.json that holds
+// credentials.
+type dockerConfigFile struct {
+	Auths map[string]struct {
+		Auth          string `json:"auth"` // base64 of username:password
+		Username      string `json:"username"`
+		Password      string `json:"password"`
+		IdentityToken string `json:"identitytoken"`
+	} `json:"auths"`
+	CredsStore  string            `json:"credsStore"`
+	CredHelpers map[string]string `json:"credHelpers"`
+}
+
+// LoadDockerConfig adds the credentials stored in a docker config.json, as
+// written by docker login. Credentials already added take precedence.
+// Credential helpers cannot be run from inside the server's container; the
+// returned warnings name the registries that use them.
+func (c *RegistryCredentials) LoadDockerConfig(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("docker: read registry config: %w", err)
+	}
+	var f dockerConfigFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("docker: parse registry config %s: %w", path, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for registry, entry := range f.Auths {
+		auth := RegistryAuth{Username: entry.Username, Password: entry.Password, IdentityToken: entry.IdentityToken}
+		if entry.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("docker: registry config %s: invalid auth for %s", path, registry)
+			}
+			auth.Username, auth.Password, _ = strings.Cut(string(decoded), ":")
+		}
+		if auth == (RegistryAuth{}) {
+			continue // stored by a credential helper
+		}
+		host := normalizeRegistry(registry)
+		if _, ok := c.auths[host]; !ok {
+			c.auths[host] = auth
+		}
+	}
+
+	var warnings []string
+	if f.CredsStore != "" {
+		warnings = append(warnings, fmt.Sprintf("registry config %s uses the credential store %q, which is not supported; add those registries to the server config instead", path, f.CredsStore))
+	}
+	for _, registry := range sortedNames(f.CredHelpers) {
+		warnings = append(warnings, fmt.Sprintf("registry config %s uses a credential helper for %s, which is not supported", path, registry))
+	}
+	return warnings, nil
+}
+
+// Lookup returns the credential for registry, if there is one.
+func (c *RegistryCredentials) Lookup(registry string) (RegistryAuth, bool) {
+	if c == nil {
+		return RegistryAuth{}, false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	auth, ok := c.auths[normalizeRegistry(registry)]
+	return auth, ok
+}
+
+// registryAuthHeader encodes auth for the X-Registry-Auth header.
+func registryAuthHeader(registry string, auth RegistryAuth) (string, error) {
+	if registry == dockerHubRegistry {
+		registry = "https://index.docker.io/v1/" // the address docker login records
+	}
+	data, err := json.Marshal(map[string]string{
+		"username":      auth.Username,
+		"password":      auth.Password,
+		"identitytoken": auth.IdentityToken,
+		"serveraddress": registry,
+	})
+	if err != nil {
+		return "", fmt.Errorf("docker: encode registry auth: %w", err)
+	}
+	return base64.URLEncoding.EncodeToString(data), nil
+}
@@ -0,0 +1,445 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Registry v2 API client
+// ---------------------------------------------------------------------------
+
+// Manifest media types accepted from registries. Lists and indexes name one
+// manifest per platform.
+const (
+	mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeOCIImageIndex      = "application/vnd.oci.image.index.v1+json"
+	mediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+)
+
+// manifestAccept is the Accept header of manifest requests; without it,
+// registries fall back to legacy schema 1 manifests.
+var manifestAccept = strings.Join([]string{
+	mediaTypeDockerManifestList, mediaTypeOCIImageIndex, mediaTypeDockerManifest, mediaTypeOCIManifest,
+}, ", ")
+
+// maxManifestBytes bounds the size of a manifest read from a registry.
+const maxManifestBytes = 4 << 20
+
+// Manifest is the manifest an image reference resolves to in its registry.
+type Manifest struct {
+	Digest    string
+	MediaType string
+	Platforms []ManifestPlatform // for manifest lists and image indexes only
+}
+
+// ManifestPlatform is one platform's entry in a manifest list.
+type ManifestPlatform struct {
+	Digest       string
+	OS           string
+	Architecture string
+	Variant      string
+}
+
+// RegistryClient reads manifests from image registries.
+type RegistryClient interface {
+	// ManifestDigest returns the digest of the manifest image resolves to.
+	// Unlike Manifest, it does not count as a pull against Docker Hub's
+	// rate limit.
+	ManifestDigest(ctx context.Context, image string) (string, error)
+	// Manifest fetches the manifest image resolves to.
+	Manifest(ctx context.Context, image string) (*Manifest, error)
+}
+
+// InsecureRegistrySource reports the registries the Docker daemon is
+// configured to reach over plain HTTP, as registry hosts or CIDRs.
+type InsecureRegistrySource interface {
+	InsecureRegistries(ctx context.Context) ([]string, error)
+}
+
+// insecureRegistriesTTL is how long the daemon's insecure registries are
+// cached before they are read again.
+const insecureRegistriesTTL = 10 * time.Minute
+
+// HTTPRegistryClient is a RegistryClient for the registry v2 HTTP API. It
+// authenticates with basic auth or bearer tokens as the registry demands,
+// using the credential for the registry if there is one.
+type HTTPRegistryClient struct {
+	client *http.Client
+	creds  *RegistryCredentials
+
+	mu              sync.Mutex
+	tokens          map[string]registryToken // by registry and scope
+	insecureSource  InsecureRegistrySource
+	insecure        []string
+	insecureChecked time.Time
+}
+
+// registryToken is a bearer token issued by a registry's token service.
+type registryToken struct {
+	token   string
+	expires time.Time
+}
+
+// NewHTTPRegistryClient returns a registry client that makes its requests
+// with client, or with a client with a 30 second timeout if client is nil.
+// creds may be nil, in which case registries are accessed anonymously.
+func NewHTTPRegistryClient(client *http.Client, creds *RegistryCredentials) *HTTPRegistryClient {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &HTTPRegistryClient{client: client, creds: creds, tokens: make(map[string]registryToken)}
+}
+
+// SetInsecureRegistries makes the client reach the registries src reports
+// over plain HTTP when HTTPS fails, as the daemon does. Without it, every
+// registry is reached over HTTPS.
+func (c *HTTPRegistryClient) SetInsecureRegistries(src InsecureRegistrySource) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.insecureSource, c.insecure, c.insecureChecked = src, nil, time.Time{}
+}
+
+// ManifestDigest returns the digest of the manifest image resolves to, from
+// a HEAD request.
+func (c *HTTPRegistryClient) ManifestDigest(ctx context.Context, image string) (string, error) {
+	ref, err := parseImageReference(image)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.manifestRequest(ctx, http.MethodHead, ref)
+	if err != nil {
+		return "", err
+	}
+	_ = resp.Body.Close()
+	if digest := resp.Header.Get("Docker-Content-Digest"); digest != "" {
+		return digest, nil
+	}
+	// Registries need not send the digest; hashing the manifest yields it.
+	m, err := c.Manifest(ctx, image)
+	if err != nil {
+		return "", err
+	}
+	return m.Digest, nil
+}
+
+// Manifest fetches the manifest image resolves to.
+func (c *HTTPRegistryClient) Manifest(ctx context.Context, image string) (*Manifest, error) {
+	ref, err := parseImageReference(image)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.manifestRequest(ctx, http.MethodGet, ref)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestBytes))
+	if err != nil {
+		return nil, fmt.Errorf("registry %s: read manifest: %w", ref.Registry, err)
+	}
+
+	var raw struct {
+		MediaType string `json:"mediaType"`
+		Manifests []struct {
+			Digest   string `json:"digest"`
+			Platform struct {
+				OS           string `json:"os"`
+				Architecture string `json:"architecture"`
+				Variant      string `json:"variant"`
+			} `json:"platform"`
+		} `json:"manifests"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("registry %s: decode manifest: %w", ref.Registry, err)
+	}
+
+	m := &Manifest{Digest: resp.Header.Get("Docker-Content-Digest"), MediaType: raw.MediaType}
+	if ct, _, _ := strings.Cut(resp.Header.Get("Content-Type"), ";"); ct != "" && ct != "application/json" {
+		m.MediaType = strings.TrimSpace(ct)
+	}
+	if m.Digest == "" {
+		sum := sha256.Sum256(body)
+		m.Digest = "sha256:" + hex.EncodeToString(sum[:])
+	}
+	if m.MediaType == mediaTypeDockerManifestList || m.MediaType == mediaTypeOCIImageIndex {
+		for _, e := range raw.Manifests {
+			m.Platforms = append(m.Platforms, ManifestPlatform{
+				Digest:       e.Digest,
+				OS:           e.Platform.OS,
+				Architecture: e.Platform.Architecture,
+				Variant:      e.Platform.Variant,
+			})
+		}
+	}
+	return m, nil
+}
+
+// registryHost returns the host serving the v2 API of registry.
+func registryHost(registry string) string {
+	if registry == dockerHubRegistry {
+		return "registry-1.docker.io"
+	}
+	return registry
+}
+
+// manifestRequest requests the manifest of ref, authenticating if the
+// registry challenges the anonymous request. The caller closes the body of
+// the returned response, whose status is 200.
+func (c *HTTPRegistryClient) manifestRequest(ctx context.Context, method string, ref imageReference) (*http.Response, error) {
+	tagOrDigest := ref.Digest
+	if tagOrDigest == "" {
+		tagOrDigest = ref.Tag
+	}
+	path := "/v2/" + ref.Repository + "/manifests/" + tagOrDigest
+	scope := "repository:" + ref.Repository + ":pull"
+	auth, hasAuth := c.creds.Lookup(ref.Registry)
+
+	bases := []string{"https://" + registryHost(ref.Registry)}
+	if c.insecureRegistry(ctx, ref.Registry) {
+		// Like the daemon, try HTTPS first and fall back to plain HTTP.
+		bases = append(bases, "http://"+registryHost(ref.Registry))
+	}
+	do := func(authorization string) (*http.Response, error) {
+		var lastErr error
+		for _, base := range bases {
+			req, err := http.NewRequestWithContext(ctx, method, base+path, nil)
+			if err != nil {
+				return nil, fmt.Errorf("registry %s: build request: %w", ref.Registry, err)
+			}
+			req.Header.Set("Accept", manifestAccept)
+			if authorization != "" {
+				req.Header.Set("Authorization", authorization)
+			}
+			resp, err := c.client.Do(req)
+			if err == nil {
+				return resp, nil
+			}
+			lastErr = err
+		}
+		return nil, fmt.Errorf("registry %s: %w", ref.Registry, lastErr)
+	}
+
+	authorization := c.cachedToken(ref.Registry, scope)
+	resp, err := do(authorization)
+	if err != nil {
+		return nil, err
+	}
+	// A cached token that the registry no longer accepts is replaced too.
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		_ = resp.Body.Close()
+		scheme, params := parseAuthChallenge(challenge)
+		switch scheme {
+		case "bearer":
+			token, err := c.fetchToken(ctx, ref.Registry, params, scope, auth, hasAuth)
+			if err != nil {
+				return nil, err
+			}
+			authorization = "Bearer " + token
+		case "basic":
+			if !hasAuth {
+				return nil, fmt.Errorf("registry %s requires credentials; add them to docker_registry in the config", ref.Registry)
+			}
+			authorization = "Basic " + base64.StdEncoding.EncodeToString([]byte(auth.Username+":"+auth.Password))
+		default:
+			return nil, fmt.Errorf("registry %s: unsupported authentication challenge %q", ref.Registry, challenge)
+		}
+		if resp, err = do(authorization); err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		return resp, nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	_ = resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, fmt.Errorf("registry %s: access to %s denied; check the registry credentials", ref.Registry, ref.Repository)
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("registry %s: manifest unknown: %s", ref.Registry, ref)
+	case http.StatusTooManyRequests:
+		return nil, fmt.Errorf("registry %s: rate limit exceeded; try again later", ref.Registry)
+	}
+	return nil, fmt.Errorf("registry %s: unexpected status %d: %s", ref.Registry, resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// insecureRegistry reports whether the daemon reaches registry over plain
+// HTTP: it is listed by name, or one of its addresses lies in a listed CIDR.
+// The list is read from the InsecureRegistrySource, if any, and cached.
+func (c *HTTPRegistryClient) insecureRegistry(ctx context.Context, registry string) bool {
+	c.mu.Lock()
+	src, list, fresh := c.insecureSource, c.insecure, time.Since(c.insecureChecked) < insecureRegistriesTTL
+	c.mu.Unlock()
+	if src == nil {
+		return false
+	}
+	if !fresh {
+		if l, err := src.InsecureRegistries(ctx); err == nil {
+			list = l
+			c.mu.Lock()
+			c.insecure, c.insecureChecked = l, time.Now()
+			c.mu.Unlock()
+		}
+	}
+
+	var prefixes []netip.Prefix
+	for _, entry := range list {
+		if entry == registry {
+			return true
+		}
+		if p, err := netip.ParsePrefix(entry); err == nil {
+			prefixes = append(prefixes, p)
+		}
+	}
+	if len(prefixes) == 0 {
+		return false
+	}
+	host := registry
+	if h, _, err := net.SplitHostPort(registry); err == nil {
+		host = h
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return false
+	}
+	for _, addr := range addrs {
+		for _, p := range prefixes {
+			if p.Contains(addr.Unmap()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// cachedToken returns the Authorization header for an unexpired token
+// already issued for scope, or "".
+func (c *HTTPRegistryClient) cachedToken(registry, scope string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, ok := c.tokens[registry+" "+scope]
+	if !ok || time.Now().After(t.expires) {
+		return ""
+	}
+	return "Bearer " + t.token
+}
+
+// fetchToken obtains a bearer token for scope from the token service named
+// in a challenge, as described in the Docker token authentication spec. An
+// identity token, the OAuth2 refresh token docker login stores for some
+// registries, is exchanged with a refresh_token grant; a password is sent as
+// basic auth.
+func (c *HTTPRegistryClient) fetchToken(ctx context.Context, registry string, challenge map[string]string, scope string, auth RegistryAuth, hasAuth bool) (string, error) {
+	realm := challenge["realm"]
+	if realm == "" {
+		return "", fmt.Errorf("registry %s: bearer challenge without a realm", registry)
+	}
+	q := url.Values{"scope": {scope}}
+	if service := challenge["service"]; service != "" {
+		q.Set("service", service)
+	}
+	var req *http.Request
+	var err error
+	if hasAuth && auth.IdentityToken != "" {
+		q.Set("grant_type", "refresh_token")
+		q.Set("refresh_token", auth.IdentityToken)
+		q.Set("client_id", "unraid-mcp")
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, realm, strings.NewReader(q.Encode()))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	} else {
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, realm+"?"+q.Encode(), nil)
+		if err == nil && hasAuth && auth.Password != "" {
+			req.SetBasicAuth(auth.Username, auth.Password)
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("registry %s: build token request: %w", registry, err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("registry %s: token request: %w", registry, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return "", fmt.Errorf("registry %s: read token: %w", registry, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry %s: token request failed with status %d; check the registry credentials", registry, resp.StatusCode)
+	}
+
+	var t struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &t); err != nil {
+		return "", fmt.Errorf("registry %s: decode token: %w", registry, err)
+	}
+	token := t.Token
+	if token == "" {
+		token = t.AccessToken
+	}
+	if token == "" {
+		return "", fmt.Errorf("registry %s: token service returned no token", registry)
+	}
+	if t.ExpiresIn <= 0 {
+		t.ExpiresIn = 60 // the spec's default
+	}
+
+	c.mu.Lock()
+	// Expire early so a token is not used just as it runs out.
+	c.tokens[registry+" "+scope] = registryToken{token: token, expires: time.Now().Add(time.Duration(t.ExpiresIn)*time.Second - 10*time.Second)}
+	c.mu.Unlock()
+	return token, nil
+}
+
+// parseAuthChallenge parses a WWW-Authenticate header such as
+// `Bearer realm="https://auth.docker.io/token",service="registry.docker.io"`
+// into its lower-cased scheme and parameters.
+func parseAuthChallenge(header string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	params := make(map[string]string)
+	for rest = strings.TrimSpace(rest); rest != ""; {
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		if strings.HasPrefix(value, `"`) {
+			// Quoted values may contain commas, e.g. a scope with several
+			// actions.
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				params[key] = value[1:]
+				break
+			}
+			params[key], rest = value[1:end+1], value[end+2:]
+		} else {
+			params[key], rest, _ = strings.Cut(value, ",")
+			params[key] = strings.TrimSpace(params[key])
+		}
+		rest = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(rest), ","))
+	}
+	return strings.ToLower(scheme), params
+}
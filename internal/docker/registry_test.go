@@ -0,0 +1,124 @@
+package docker
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_parseImageReference_Cases(t *testing.T) {
+	tests := []struct {
+		ref     string
+		want    imageReference
+		wantErr bool
+	}{
+		{ref: "nginx", want: imageReference{Registry: "docker.io", Repository: "library/nginx", Tag: "latest"}},
+		{ref: "linuxserver/plex:1.40", want: imageReference{Registry: "docker.io", Repository: "linuxserver/plex", Tag: "1.40"}},
+		{ref: "ghcr.io/owner/app:v2", want: imageReference{Registry: "ghcr.io", Repository: "owner/app", Tag: "v2"}},
+		{ref: "registry.lan:5000/tools/app", want: imageReference{Registry: "registry.lan:5000", Repository: "tools/app", Tag: "latest"}},
+		{ref: "localhost/app", want: imageReference{Registry: "localhost", Repository: "app", Tag: "latest"}},
+		{ref: "index.docker.io/library/redis:7", want: imageReference{Registry: "docker.io", Repository: "library/redis", Tag: "7"}},
+		{ref: "nginx@sha256:abc", want: imageReference{Registry: "docker.io", Repository: "library/nginx", Digest: "sha256:abc"}},
+		{ref: "nginx:1.25@sha256:abc", want: imageReference{Registry: "docker.io", Repository: "library/nginx", Tag: "1.25", Digest: "sha256:abc"}},
+		{ref: "", wantErr: true},
+		{ref: "nginx:", wantErr: true},
+		{ref: "nginx@abc", wantErr: true},
+		{ref: "bad image", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := parseImageReference(tt.ref)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_RegistryCredentials_LoadDockerConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	config := `{
+		"auths": {
+			"https://index.docker.io/v1/": {"auth": "` + base64.StdEncoding.EncodeToString([]byte("hubuser:hubpass")) + `"},
+			"ghcr.io": {"username": "gh", "password": "token-from-file"},
+			"registry.lan:5000": {}
+		},
+		"credsStore": "desktop",
+		"credHelpers": {"gcr.io": "gcloud"}
+	}`
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	creds := NewRegistryCredentials()
+	creds.Add("ghcr.io", RegistryAuth{Username: "gh", Password: "token-from-config"})
+	warnings, err := creds.LoadDockerConfig(path)
+	if err != nil {
+		t.Fatalf("LoadDockerConfig: %v", err)
+	}
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "desktop") || !strings.Contains(warnings[1], "gcr.io") {
+		t.Errorf("warnings = %q, want the credential store and the gcr.io helper", warnings)
+	}
+
+	if auth, ok := creds.Lookup("docker.io"); !ok || auth.Username != "hubuser" || auth.Password != "hubpass" {
+		t.Errorf("docker.io = %+v, %v; want hubuser from the auth field", auth, ok)
+	}
+	if auth, _ := creds.Lookup("ghcr.io"); auth.Password != "token-from-config" {
+		t.Errorf("ghcr.io password = %q, want the credential added first to win", auth.Password)
+	}
+	if _, ok := creds.Lookup("registry.lan:5000"); ok {
+		t.Error("an empty entry should not become a credential")
+	}
+}
+
+func Test_RegistryCredentials_LoadDockerConfig_Errors(t *testing.T) {
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(bad, []byte(`{"auths": {"ghcr.io": {"auth": "%%%"}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	creds := NewRegistryCredentials()
+	if _, err := creds.LoadDockerConfig(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+	if _, err := creds.LoadDockerConfig(bad); err == nil || !strings.Contains(err.Error(), "ghcr.io") {
+		t.Errorf("error = %v, want invalid auth for ghcr.io", err)
+	}
+}
+
+func Test_RegistryCredentials_NilLookup(t *testing.T) {
+	var creds *RegistryCredentials
+	if _, ok := creds.Lookup("ghcr.io"); ok {
+		t.Error("a nil store should have no credentials")
+	}
+}
+
+func Test_registryAuthHeader(t *testing.T) {
+	header, err := registryAuthHeader("docker.io", RegistryAuth{Username: "u", Password: "p"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := base64.URLEncoding.DecodeString(header)
+	if err != nil {
+		t.Fatalf("header is not base64url: %v", err)
+	}
+	var got map[string]string
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["username"] != "u" || got["password"] != "p" || got["serveraddress"] != "https://index.docker.io/v1/" {
+		t.Errorf("header = %v", got)
+	}
+}
@@ -0,0 +1,278 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jamesprial/unraid-mcp/internal/stats"
+)
+
+// ---------------------------------------------------------------------------
+// Stats history
+// ---------------------------------------------------------------------------
+
+// statsWorkers bounds the concurrent stats requests per sampling round; each
+// takes up to a couple of seconds while the daemon samples CPU usage.
+const statsWorkers = 8
+
+// Stats ranking metrics for StatsHistory.Top.
+const (
+	StatsMetricCPU     = "cpu"
+	StatsMetricMemory  = "memory"
+	StatsMetricNetwork = "network"
+	StatsMetricBlockIO = "block_io"
+	StatsMetricPIDs    = "pids"
+)
+
+// StatsSample is one reading of a container's resource usage. CPUPercent is
+// measured since the previous sample; byte counts are cumulative.
+type StatsSample struct {
+	Time            time.Time
+	CPUPercent      float64
+	MemoryUsage     uint64
+	MemoryLimit     uint64
+	MemoryPercent   float64
+	NetworkRxBytes  uint64
+	NetworkTxBytes  uint64
+	BlockReadBytes  uint64
+	BlockWriteBytes uint64
+	PIDs            uint64
+}
+
+// SampleTime returns when the sample was taken.
+func (s StatsSample) SampleTime() time.Time { return s.Time }
+
+// StatsSummary aggregates a container's samples over a window. Byte counts
+// are the amounts transferred within the window.
+type StatsSummary struct {
+	ID              string
+	Name            string
+	Samples         int
+	CPUAvgPercent   float64
+	CPUMaxPercent   float64
+	MemoryAvg       uint64
+	MemoryMax       uint64
+	MemoryLimit     uint64
+	NetworkRxBytes  uint64
+	NetworkTxBytes  uint64
+	BlockReadBytes  uint64
+	BlockWriteBytes uint64
+	PIDsMax         uint64
+}
+
+// ContainerStatsHistory is a container's samples within a window.
+type ContainerStatsHistory struct {
+	Summary StatsSummary
+	Samples []StatsSample
+}
+
+// StatsHistory keeps a bounded time series of resource usage per container
+// ID. It is safe for concurrent use.
+type StatsHistory struct {
+	*stats.History[StatsSample]
+}
+
+// NewStatsHistory returns a history holding retention worth of samples taken
+// every interval.
+func NewStatsHistory(interval, retention time.Duration) *StatsHistory {
+	return &StatsHistory{stats.NewHistory[StatsSample](interval, retention)}
+}
+
+// Container returns the samples since the given time for the container with
+// the given name, ID or ID prefix. A prefix of several containers' IDs is an
+// error, as it is for Docker.
+func (h *StatsHistory) Container(idOrName string, since time.Time) (*ContainerStatsHistory, error) {
+	var match *stats.Series[StatsSample]
+	prefixMatches := 0
+	for _, s := range h.Window(since) {
+		if s.Name == idOrName || s.ID == idOrName {
+			match, prefixMatches = &s, 0
+			break
+		}
+		if strings.HasPrefix(s.ID, idOrName) && idOrName != "" {
+			match = &s
+			prefixMatches++
+		}
+	}
+	if prefixMatches > 1 {
+		return nil, fmt.Errorf("ambiguous ID prefix %q: it matches %d containers", idOrName, prefixMatches)
+	}
+	if match == nil {
+		return nil, fmt.Errorf("no stats recorded for container %q; only running containers are sampled", idOrName)
+	}
+	return &ContainerStatsHistory{Summary: summarize(match.ID, match.Name, match.Samples), Samples: match.Samples}, nil
+}
+
+// Top returns the containers allowed by include with the highest usage of
+// metric since the given time, highest first.
+func (h *StatsHistory) Top(metric string, since time.Time, limit int, include func(name string) bool) ([]StatsSummary, error) {
+	key, ok := statsRankKeys[metric]
+	if !ok {
+		return nil, fmt.Errorf("invalid metric %q (want cpu, memory, network, block_io or pids)", metric)
+	}
+	var summaries []StatsSummary
+	for _, s := range h.Window(since) {
+		if len(s.Samples) > 0 && (include == nil || include(s.Name)) {
+			summaries = append(summaries, summarize(s.ID, s.Name, s.Samples))
+		}
+	}
+	return stats.Rank(summaries, key, func(s StatsSummary) string { return s.Name }, limit), nil
+}
+
+// statsRankKeys maps each metric to the summary value containers are ranked by.
+var statsRankKeys = map[string]func(StatsSummary) float64{
+	StatsMetricCPU:     func(s StatsSummary) float64 { return s.CPUAvgPercent },
+	StatsMetricMemory:  func(s StatsSummary) float64 { return float64(s.MemoryAvg) },
+	StatsMetricNetwork: func(s StatsSummary) float64 { return float64(s.NetworkRxBytes + s.NetworkTxBytes) },
+	StatsMetricBlockIO: func(s StatsSummary) float64 { return float64(s.BlockReadBytes + s.BlockWriteBytes) },
+	StatsMetricPIDs:    func(s StatsSummary) float64 { return float64(s.PIDsMax) },
+}
+
+// summarize aggregates samples, which must be in chronological order.
+func summarize(id, name string, samples []StatsSample) StatsSummary {
+	sum := StatsSummary{ID: id, Name: name, Samples: len(samples)}
+	if len(samples) == 0 {
+		return sum
+	}
+	var cpu float64
+	var mem uint64
+	for i, s := range samples {
+		cpu += s.CPUPercent
+		mem += s.MemoryUsage
+		sum.CPUMaxPercent = max(sum.CPUMaxPercent, s.CPUPercent)
+		sum.MemoryMax = max(sum.MemoryMax, s.MemoryUsage)
+		sum.PIDsMax = max(sum.PIDsMax, s.PIDs)
+		if i > 0 {
+			prev := samples[i-1]
+			sum.NetworkRxBytes += counterDelta(prev.NetworkRxBytes, s.NetworkRxBytes)
+			sum.NetworkTxBytes += counterDelta(prev.NetworkTxBytes, s.NetworkTxBytes)
+			sum.BlockReadBytes += counterDelta(prev.BlockReadBytes, s.BlockReadBytes)
+			sum.BlockWriteBytes += counterDelta(prev.BlockWriteBytes, s.BlockWriteBytes)
+		}
+	}
+	sum.CPUAvgPercent = cpu / float64(len(samples))
+	sum.MemoryAvg = mem / uint64(len(samples))
+	sum.MemoryLimit = samples[len(samples)-1].MemoryLimit
+	return sum
+}
+
+// counterDelta returns the increase of a cumulative counter. A decrease means
+// the container restarted and the counter began again from zero.
+func counterDelta(prev, cur uint64) uint64 {
+	if cur < prev {
+		return cur
+	}
+	return cur - prev
+}
+
+// ---------------------------------------------------------------------------
+// Stats sampler
+// ---------------------------------------------------------------------------
+
+// StatsSampler polls the stats of every running container into a
+// StatsHistory.
+type StatsSampler struct {
+	mgr      ContainerManager
+	history  *StatsHistory
+	interval time.Duration
+	prev     map[string]*ContainerStats // last reading per container ID
+}
+
+// NewStatsSampler returns a sampler that records into history every
+// interval.
+func NewStatsSampler(mgr ContainerManager, history *StatsHistory, interval time.Duration) *StatsSampler {
+	if interval <= 0 {
+		interval = stats.DefaultInterval
+	}
+	return &StatsSampler{
+		mgr:      mgr,
+		history:  history,
+		interval: interval,
+		prev:     make(map[string]*ContainerStats),
+	}
+}
+
+// Run samples immediately and then every interval until ctx is cancelled.
+func (s *StatsSampler) Run(ctx context.Context) {
+	stats.Run(ctx, s.interval, "docker stats", s.sample)
+}
+
+// sample takes one reading of every running container. CPU usage is measured
+// between this reading and the previous one, which is accurate where the
+// daemon's own precpu_stats are missing or cover only its short sampling
+// period.
+func (s *StatsSampler) sample(ctx context.Context) error {
+	containers, err := s.mgr.ListContainers(ctx, false)
+	if err != nil {
+		s.history.SetStatus(time.Now(), err)
+		return err
+	}
+
+	type reading struct {
+		c     Container
+		stats *ContainerStats
+		err   error
+	}
+	readings := make([]reading, len(containers))
+	sem := make(chan struct{}, statsWorkers)
+	var wg sync.WaitGroup
+	for i, c := range containers {
+		readings[i].c = c
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(r *reading) {
+			defer func() { <-sem; wg.Done() }()
+			r.stats, r.err = s.mgr.GetStats(ctx, r.c.ID)
+		}(&readings[i])
+	}
+	wg.Wait()
+
+	now := time.Now()
+	var failed []string
+	seen := make(map[string]bool, len(readings))
+	for _, r := range readings {
+		if r.err != nil {
+			failed = append(failed, r.c.Name)
+			continue
+		}
+		seen[r.c.ID] = true
+		cur := r.stats
+		cpu := cur.CPUPercent
+		if prev := s.prev[r.c.ID]; prev != nil && prev.SystemCPUUsage > 0 && cur.SystemCPUUsage > prev.SystemCPUUsage {
+			cpu = cpuPercentBetween(prev.CPUTotalUsage, cur.CPUTotalUsage, prev.SystemCPUUsage, cur.SystemCPUUsage, cur.OnlineCPUs)
+		}
+		s.prev[r.c.ID] = cur
+
+		sample := StatsSample{
+			Time:            now,
+			CPUPercent:      cpu,
+			MemoryUsage:     cur.MemoryUsage,
+			MemoryLimit:     cur.MemoryLimit,
+			NetworkRxBytes:  cur.NetworkRxBytes,
+			NetworkTxBytes:  cur.NetworkTxBytes,
+			BlockReadBytes:  cur.BlockReadBytes,
+			BlockWriteBytes: cur.BlockWriteBytes,
+			PIDs:            cur.PIDs,
+		}
+		if cur.MemoryLimit > 0 {
+			sample.MemoryPercent = float64(cur.MemoryUsage) / float64(cur.MemoryLimit) * 100
+		}
+		s.history.Add(r.c.ID, r.c.Name, sample)
+	}
+	for id := range s.prev {
+		if !seen[id] {
+			delete(s.prev, id)
+		}
+	}
+	s.history.Prune(now)
+
+	if len(failed) > 0 {
+		err = fmt.Errorf("could not read stats for %s", strings.Join(failed, ", "))
+	}
+	s.history.SetStatus(now, err)
+	return err
+}
@@ -0,0 +1,264 @@
+package docker
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+)
+
+// ---------------------------------------------------------------------------
+// GetStats parsing
+// ---------------------------------------------------------------------------
+
+func Test_DockerClientManager_GetStats_Parsing(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantCPU float64
+		wantMem uint64
+		wantBlk [2]uint64
+	}{
+		{
+			name: "cgroup v2",
+			body: `{
+				"cpu_stats": {"cpu_usage": {"total_usage": 3000}, "system_cpu_usage": 20000, "online_cpus": 4},
+				"precpu_stats": {"cpu_usage": {"total_usage": 1000}, "system_cpu_usage": 10000},
+				"memory_stats": {"usage": 1000, "limit": 4000, "stats": {"inactive_file": 300, "file": 500}},
+				"blkio_stats": {"io_service_bytes_recursive": [{"op": "read", "value": 10}, {"op": "write", "value": 20}]},
+				"pids_stats": {"current": 7},
+				"networks": {"eth0": {"rx_bytes": 5, "tx_bytes": 6}}
+			}`,
+			wantCPU: 80,
+			wantMem: 700,
+			wantBlk: [2]uint64{10, 20},
+		},
+		{
+			name: "cgroup v1 first read",
+			body: `{
+				"cpu_stats": {"cpu_usage": {"total_usage": 3000, "percpu_usage": [1500, 1500]}, "system_cpu_usage": 20000},
+				"precpu_stats": {"cpu_usage": {"total_usage": 0}, "system_cpu_usage": 0},
+				"memory_stats": {"usage": 1000, "limit": 4000, "stats": {"total_inactive_file": 100, "cache": 600}},
+				"blkio_stats": {"io_service_bytes_recursive": [{"op": "Read", "value": 1}, {"op": "Write", "value": 2}, {"op": "Total", "value": 3}]}
+			}`,
+			wantCPU: 0,
+			wantMem: 900,
+			wantBlk: [2]uint64{1, 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mgr := newFakeDaemon(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, http.StatusOK, tt.body)
+			}))
+			stats, err := mgr.GetStats(t.Context(), "abc")
+			if err != nil {
+				t.Fatalf("GetStats() error = %v", err)
+			}
+			if math.Abs(stats.CPUPercent-tt.wantCPU) > 0.001 {
+				t.Errorf("CPUPercent = %v, want %v", stats.CPUPercent, tt.wantCPU)
+			}
+			if stats.MemoryUsage != tt.wantMem {
+				t.Errorf("MemoryUsage = %d, want %d", stats.MemoryUsage, tt.wantMem)
+			}
+			if stats.BlockReadBytes != tt.wantBlk[0] || stats.BlockWriteBytes != tt.wantBlk[1] {
+				t.Errorf("block I/O = %d/%d, want %v", stats.BlockReadBytes, stats.BlockWriteBytes, tt.wantBlk)
+			}
+			if stats.CPUTotalUsage != 3000 || stats.SystemCPUUsage != 20000 {
+				t.Errorf("CPU counters = %d/%d, want 3000/20000", stats.CPUTotalUsage, stats.SystemCPUUsage)
+			}
+		})
+	}
+}
+
+// ---------------------------------------------------------------------------
+// History
+// ---------------------------------------------------------------------------
+
+func Test_StatsHistory_BoundsSamples(t *testing.T) {
+	h := NewStatsHistory(time.Minute, 5*time.Minute)
+	base := time.Now().Add(-time.Hour)
+	for i := range 20 {
+		h.Add("abc", "plex", StatsSample{Time: base.Add(time.Duration(i) * time.Minute)})
+	}
+	got, err := h.Container("plex", time.Time{})
+	if err != nil {
+		t.Fatalf("Container(plex) error = %v", err)
+	}
+	if len(got.Samples) != 6 {
+		t.Errorf("kept %d samples, want 6", len(got.Samples))
+	}
+
+	h.Prune(base.Add(30 * time.Minute))
+	if _, err := h.Container("plex", time.Time{}); err == nil {
+		t.Error("stale container was not pruned")
+	}
+}
+
+func Test_StatsHistory_ContainerPrefix(t *testing.T) {
+	h := NewStatsHistory(time.Minute, time.Hour)
+	now := time.Now()
+	h.Add("abc123", "plex", StatsSample{Time: now})
+	h.Add("abd456", "sonarr", StatsSample{Time: now})
+
+	if got, err := h.Container("abc", time.Time{}); err != nil || got.Summary.Name != "plex" {
+		t.Errorf("Container(abc) = %+v, %v, want plex", got, err)
+	}
+	if _, err := h.Container("ab", time.Time{}); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("Container(ab) error = %v, want ambiguous", err)
+	}
+	if _, err := h.Container("zzz", time.Time{}); err == nil {
+		t.Error("Container(zzz) succeeded")
+	}
+}
+
+func Test_StatsHistory_Summary(t *testing.T) {
+	h := NewStatsHistory(time.Minute, time.Hour)
+	now := time.Now()
+	samples := []StatsSample{
+		{Time: now.Add(-40 * time.Minute), CPUPercent: 90, NetworkRxBytes: 0},
+		{Time: now.Add(-3 * time.Minute), CPUPercent: 10, MemoryUsage: 100, NetworkRxBytes: 1000, PIDs: 3},
+		{Time: now.Add(-2 * time.Minute), CPUPercent: 30, MemoryUsage: 300, NetworkRxBytes: 1500, PIDs: 5},
+		// Restarted: the counter begins again from zero.
+		{Time: now.Add(-1 * time.Minute), CPUPercent: 20, MemoryUsage: 200, NetworkRxBytes: 200, PIDs: 4},
+	}
+	for _, s := range samples {
+		h.Add("abc", "plex", s)
+	}
+
+	got, _ := h.Container("abc", now.Add(-30*time.Minute))
+	sum := got.Summary
+	if sum.Samples != 3 || sum.CPUAvgPercent != 20 || sum.CPUMaxPercent != 30 {
+		t.Errorf("CPU summary = %+v, want 3 samples, avg 20, max 30", sum)
+	}
+	if sum.MemoryAvg != 200 || sum.MemoryMax != 300 || sum.PIDsMax != 5 {
+		t.Errorf("memory/PIDs summary = %+v", sum)
+	}
+	if sum.NetworkRxBytes != 700 {
+		t.Errorf("NetworkRxBytes = %d, want 700 (500 + 200 after the reset)", sum.NetworkRxBytes)
+	}
+}
+
+func Test_StatsHistory_Top(t *testing.T) {
+	h := NewStatsHistory(time.Minute, time.Hour)
+	now := time.Now()
+	add := func(name string, cpu float64, mem, rx uint64) {
+		h.Add(name+"-id", name, StatsSample{Time: now.Add(-2 * time.Minute)})
+		h.Add(name+"-id", name, StatsSample{Time: now.Add(-time.Minute), CPUPercent: cpu * 2, MemoryUsage: mem * 2, NetworkRxBytes: rx})
+	}
+	add("plex", 50, 10, 100)
+	add("sonarr", 10, 40, 900)
+	add("radarr", 20, 20, 50)
+	add("secret", 99, 99, 999)
+	filter := safety.NewFilter(nil, []string{"secret"})
+
+	tests := []struct {
+		metric string
+		want   string
+	}{
+		{StatsMetricCPU, "plex,radarr"},
+		{StatsMetricMemory, "sonarr,radarr"},
+		{StatsMetricNetwork, "sonarr,plex"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.metric, func(t *testing.T) {
+			top, err := h.Top(tt.metric, now.Add(-time.Hour), 2, filter.IsAllowed)
+			if err != nil {
+				t.Fatalf("Top() error = %v", err)
+			}
+			var names []string
+			for _, s := range top {
+				names = append(names, s.Name)
+			}
+			if got := strings.Join(names, ","); got != tt.want {
+				t.Errorf("Top(%s) = %s, want %s", tt.metric, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := h.Top("disk", now, 2, nil); err == nil {
+		t.Error("Top(disk) error = nil, want invalid metric")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Sampler
+// ---------------------------------------------------------------------------
+
+func Test_StatsSampler_MeasuresCPUBetweenSamples(t *testing.T) {
+	mgr := newPopulatedMock(t)
+	h := NewStatsHistory(time.Minute, time.Hour)
+	s := NewStatsSampler(mgr, h, time.Minute)
+
+	// The daemon's own figure, with no precpu_stats, is 0.
+	mgr.SetStats("abc123", &ContainerStats{CPUTotalUsage: 1000, SystemCPUUsage: 10000, OnlineCPUs: 2, MemoryUsage: 50, MemoryLimit: 200})
+	if err := s.sample(t.Context()); err != nil {
+		t.Fatalf("sample() error = %v", err)
+	}
+	mgr.SetStats("abc123", &ContainerStats{CPUTotalUsage: 2000, SystemCPUUsage: 20000, OnlineCPUs: 2, MemoryUsage: 50, MemoryLimit: 200})
+	if err := s.sample(t.Context()); err != nil {
+		t.Fatalf("sample() error = %v", err)
+	}
+
+	got, err := h.Container("plex", time.Time{})
+	if err != nil || len(got.Samples) != 2 {
+		t.Fatalf("plex history = %+v, want 2 samples", got)
+	}
+	if cpu := got.Samples[1].CPUPercent; math.Abs(cpu-20) > 0.001 {
+		t.Errorf("CPUPercent = %v, want 20", cpu)
+	}
+	if got.Samples[1].MemoryPercent != 25 {
+		t.Errorf("MemoryPercent = %v, want 25", got.Samples[1].MemoryPercent)
+	}
+	if _, err := h.Container("sonarr", time.Time{}); err == nil {
+		t.Error("stopped container was sampled")
+	}
+	if lastRun, lastErr := h.Status(); lastRun.IsZero() || lastErr != "" {
+		t.Errorf("Status() = %v, %q", lastRun, lastErr)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Tools
+// ---------------------------------------------------------------------------
+
+func Test_StatsTools(t *testing.T) {
+	h := NewStatsHistory(time.Minute, time.Hour)
+	now := time.Now()
+	h.Add("abc123", "plex", StatsSample{Time: now.Add(-time.Minute), CPUPercent: 5})
+	h.Add("ghi789", "radarr", StatsSample{Time: now.Add(-time.Minute), CPUPercent: 50})
+	regs := StatsTools(h, safety.NewFilter(nil, []string{"radarr"}), nil)
+
+	text := callTool(t, regs, "docker_top", map[string]any{"window": "10m"})
+	var top TopResult
+	if err := json.Unmarshal([]byte(text), &top); err != nil {
+		t.Fatalf("decode result: %v\n%s", err, text)
+	}
+	if len(top.Containers) != 1 || top.Containers[0].Name != "plex" || top.Window != "10m0s" {
+		t.Errorf("docker_top = %+v, want plex only over 10m", top)
+	}
+
+	tests := []struct {
+		name string
+		tool string
+		args map[string]any
+		want string
+	}{
+		{"bad metric", "docker_top", map[string]any{"metric": "disk"}, "invalid metric"},
+		{"bad window", "docker_top", map[string]any{"window": "soon"}, "invalid window"},
+		{"history", "docker_stats_history", map[string]any{"id": "plex"}, `"Name": "plex"`},
+		{"history by ID of denied", "docker_stats_history", map[string]any{"id": "ghi789"}, "not allowed"},
+		{"history unknown", "docker_stats_history", map[string]any{"id": "sonarr"}, "no stats recorded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if text := callTool(t, regs, tt.tool, tt.args); !strings.Contains(text, tt.want) {
+				t.Errorf("%s = %s, want %q", tt.tool, text, tt.want)
+			}
+		})
+	}
+}
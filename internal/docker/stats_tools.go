@@ -0,0 +1,141 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+	"github.com/jamesprial/unraid-mcp/internal/stats"
+	"github.com/jamesprial/unraid-mcp/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// TopResult is the result of docker_top.
+type TopResult struct {
+	Metric     string
+	Window     string
+	LastSample time.Time
+	LastError  string
+	Containers []StatsSummary
+}
+
+// StatsHistoryResult is the result of docker_stats_history.
+type StatsHistoryResult struct {
+	Window     string
+	LastSample time.Time
+	LastError  string
+	ContainerStatsHistory
+}
+
+// StatsTools returns the tool registrations for the resource usage recorded by
+// the background stats sampler.
+func StatsTools(history *StatsHistory, filter *safety.Filter, audit *safety.AuditLogger) []tools.Registration {
+	return []tools.Registration{
+		toolDockerTop(history, filter, audit),
+		toolDockerStatsHistory(history, filter, audit),
+	}
+}
+
+func toolDockerTop(history *StatsHistory, filter *safety.Filter, audit *safety.AuditLogger) tools.Registration {
+	tool := mcp.NewTool("docker_top",
+		mcp.WithDescription("Rank containers by resource usage over a recent window, from stats sampled in the background: "+
+			"\"which containers used the most CPU, memory or network in the last 30 minutes\". "+
+			"CPU and memory are ranked by average, network and block I/O by bytes transferred in the window, PIDs by maximum."),
+		mcp.WithString("metric",
+			mcp.Description("cpu (default), memory, network, block_io or pids"),
+		),
+		mcp.WithString("window",
+			mcp.Description("How far back to look, as a duration (default 30m; limited by the configured retention)"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description(fmt.Sprintf("Number of containers to return (default %d)", stats.DefaultTopLimit)),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		metric := req.GetString("metric", StatsMetricCPU)
+		windowArg := req.GetString("window", "")
+		limit := req.GetInt("limit", stats.DefaultTopLimit)
+		params := map[string]any{"metric": metric, "window": windowArg, "limit": limit}
+
+		window, err := stats.ParseWindow(windowArg, history.Retention())
+		if err != nil {
+			return tools.ErrorResult(err.Error()), nil
+		}
+		if limit <= 0 {
+			limit = stats.DefaultTopLimit
+		}
+
+		containers, err := history.Top(metric, start.Add(-window), limit, filter.IsAllowed)
+		if err != nil {
+			return tools.ErrorResult(err.Error()), nil
+		}
+		lastRun, lastErr := history.Status()
+
+		tools.LogAudit(audit, "docker_top", params, "ok", start)
+		return tools.JSONResult(TopResult{
+			Metric:     metric,
+			Window:     window.String(),
+			LastSample: lastRun,
+			LastError:  lastErr,
+			Containers: containers,
+		}), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
+
+func toolDockerStatsHistory(history *StatsHistory, filter *safety.Filter, audit *safety.AuditLogger) tools.Registration {
+	tool := mcp.NewTool("docker_stats_history",
+		mcp.WithDescription("Get a container's resource usage over a recent window, from stats sampled in the background: "+
+			"CPU, memory, network, block I/O and PIDs per sample, plus averages, maxima and bytes transferred in the window."),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("Container ID or name"),
+		),
+		mcp.WithString("window",
+			mcp.Description("How far back to look, as a duration (default 30m; limited by the configured retention)"),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		id := req.GetString("id", "")
+		windowArg := req.GetString("window", "")
+		params := map[string]any{"id": id, "window": windowArg}
+
+		if !filter.IsAllowed(id) {
+			tools.LogAudit(audit, "docker_stats_history", params, "denied", start)
+			return tools.ErrorResult(fmt.Sprintf("access to container %q is not allowed", id)), nil
+		}
+		window, err := stats.ParseWindow(windowArg, history.Retention())
+		if err != nil {
+			return tools.ErrorResult(err.Error()), nil
+		}
+
+		h, err := history.Container(id, start.Add(-window))
+		if err != nil {
+			return tools.ErrorResult(err.Error()), nil
+		}
+		// The caller may have passed an ID; check the filter against the name too.
+		if !filter.IsAllowed(h.Summary.Name) {
+			tools.LogAudit(audit, "docker_stats_history", params, "denied", start)
+			return tools.ErrorResult(fmt.Sprintf("access to container %q is not allowed", id)), nil
+		}
+		lastRun, lastErr := history.Status()
+
+		tools.LogAudit(audit, "docker_stats_history", params, "ok", start)
+		return tools.JSONResult(StatsHistoryResult{
+			Window:                window.String(),
+			LastSample:            lastRun,
+			LastError:             lastErr,
+			ContainerStatsHistory: *h,
+		}), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
@@ -0,0 +1,160 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// System operations
+// ---------------------------------------------------------------------------
+
+// dockerDiskUsage is the JSON shape returned by /system/df.
+type dockerDiskUsage struct {
+	LayersSize int64 `json:"LayersSize"`
+	Images     []struct {
+		ID         string   `json:"Id"`
+		RepoTags   []string `json:"RepoTags"`
+		Size       int64    `json:"Size"`
+		SharedSize int64    `json:"SharedSize"`
+		Containers int      `json:"Containers"`
+	} `json:"Images"`
+	Containers []struct {
+		ID         string   `json:"Id"`
+		Names      []string `json:"Names"`
+		Image      string   `json:"Image"`
+		State      string   `json:"State"`
+		SizeRw     int64    `json:"SizeRw"`
+		SizeRootFs int64    `json:"SizeRootFs"`
+	} `json:"Containers"`
+	Volumes []struct {
+		Name      string `json:"Name"`
+		Driver    string `json:"Driver"`
+		UsageData *struct {
+			Size     int64 `json:"Size"`
+			RefCount int   `json:"RefCount"`
+		} `json:"UsageData"`
+	} `json:"Volumes"`
+	BuildCache []struct {
+		ID     string `json:"ID"`
+		Type   string `json:"Type"`
+		Size   int64  `json:"Size"`
+		InUse  bool   `json:"InUse"`
+		Shared bool   `json:"Shared"`
+	} `json:"BuildCache"`
+}
+
+// diskUsageTimeout bounds /system/df, which outlasts the client's usual
+// timeout on large installations.
+const diskUsageTimeout = 5 * time.Minute
+
+// DiskUsage returns the space used by images, containers' writable layers,
+// volumes and the build cache. The daemon walks every volume to size it, so
+// this can take a while on large installations; it is given diskUsageTimeout
+// rather than the client's usual 30 seconds.
+func (m *DockerClientManager) DiskUsage(ctx context.Context) (*DiskUsage, error) {
+	ctx, cancel := context.WithTimeout(ctx, diskUsageTimeout)
+	defer cancel()
+	resp, err := m.doStreamRequest(ctx, http.MethodGet, "/system/df", nil)
+	if err != nil {
+		return nil, fmt.Errorf("docker: disk usage: %w", err)
+	}
+	body, err := readBody(resp)
+	if err != nil {
+		return nil, fmt.Errorf("docker: disk usage: %w", err)
+	}
+	if err := checkAPIError(resp.StatusCode, body, "not found"); err != nil {
+		return nil, fmt.Errorf("docker: disk usage: %w", err)
+	}
+
+	var raw dockerDiskUsage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("docker: decode disk usage: %w", err)
+	}
+
+	du := &DiskUsage{LayersSize: raw.LayersSize}
+	for _, img := range raw.Images {
+		du.Images = append(du.Images, ImageDiskUsage{
+			ID:         img.ID,
+			Tags:       normalizeRepoTags(img.RepoTags),
+			Size:       img.Size,
+			SharedSize: img.SharedSize,
+			Containers: img.Containers,
+		})
+	}
+	for _, c := range raw.Containers {
+		name := ""
+		if len(c.Names) > 0 {
+			name = strings.TrimPrefix(c.Names[0], "/")
+		}
+		du.Containers = append(du.Containers, ContainerDiskUsage{
+			ID:         c.ID,
+			Name:       name,
+			Image:      c.Image,
+			State:      c.State,
+			SizeRw:     c.SizeRw,
+			SizeRootFs: c.SizeRootFs,
+		})
+	}
+	for _, v := range raw.Volumes {
+		vu := VolumeDiskUsage{Name: v.Name, Driver: v.Driver, Size: -1, RefCount: -1}
+		if v.UsageData != nil {
+			vu.Size, vu.RefCount = v.UsageData.Size, v.UsageData.RefCount
+		}
+		du.Volumes = append(du.Volumes, vu)
+	}
+	for _, b := range raw.BuildCache {
+		du.BuildCache = append(du.BuildCache, BuildCacheDiskUsage{
+			ID:     b.ID,
+			Type:   b.Type,
+			Size:   b.Size,
+			InUse:  b.InUse,
+			Shared: b.Shared,
+		})
+	}
+	return du, nil
+}
+
+// InsecureRegistries returns the registries the daemon reaches over plain
+// HTTP: those configured with --insecure-registry by name, and the CIDRs
+// (127.0.0.0/8 by default) whose registries are all treated that way.
+func (m *DockerClientManager) InsecureRegistries(ctx context.Context) ([]string, error) {
+	resp, err := m.doRequest(ctx, http.MethodGet, "/info", nil)
+	if err != nil {
+		return nil, fmt.Errorf("docker: info: %w", err)
+	}
+	body, err := readBody(resp)
+	if err != nil {
+		return nil, fmt.Errorf("docker: info: %w", err)
+	}
+	if err := checkAPIError(resp.StatusCode, body, "not found"); err != nil {
+		return nil, fmt.Errorf("docker: info: %w", err)
+	}
+
+	var info struct {
+		RegistryConfig struct {
+			InsecureRegistryCIDRs []string `json:"InsecureRegistryCIDRs"`
+			IndexConfigs          map[string]struct {
+				Name   string `json:"Name"`
+				Secure bool   `json:"Secure"`
+			} `json:"IndexConfigs"`
+		} `json:"RegistryConfig"`
+	}
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, fmt.Errorf("docker: decode info: %w", err)
+	}
+	registries := slices.Clone(info.RegistryConfig.InsecureRegistryCIDRs)
+	for name, index := range info.RegistryConfig.IndexConfigs {
+		if !index.Secure {
+			registries = append(registries, name)
+		}
+	}
+	slices.Sort(registries)
+	return registries, nil
+}
@@ -0,0 +1,421 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// Unraid dockerMan templates
+// ---------------------------------------------------------------------------
+
+// Labels the Unraid web UI uses to recognise and decorate containers it manages.
+const (
+	labelManaged = "net.unraid.docker.managed"
+	labelWebUI   = "net.unraid.docker.webui"
+	labelIcon    = "net.unraid.docker.icon"
+)
+
+// Template is an Unraid dockerMan container template (<Container version="2">).
+type Template struct {
+	XMLName     xml.Name         `xml:"Container" json:"-"`
+	Version     string           `xml:"version,attr"`
+	Name        string           `xml:"Name"`
+	Repository  string           `xml:"Repository"`
+	Registry    string           `xml:"Registry"`
+	Network     string           `xml:"Network"`
+	MyIP        string           `xml:"MyIP"`
+	Shell       string           `xml:"Shell"`
+	Privileged  string           `xml:"Privileged"`
+	Support     string           `xml:"Support"`
+	Project     string           `xml:"Project"`
+	Overview    string           `xml:"Overview"`
+	Category    string           `xml:"Category"`
+	WebUI       string           `xml:"WebUI"`
+	TemplateURL string           `xml:"TemplateURL"`
+	Icon        string           `xml:"Icon"`
+	ExtraParams string           `xml:"ExtraParams"`
+	PostArgs    string           `xml:"PostArgs"`
+	CPUset      string           `xml:"CPUset"`
+	Description string           `xml:"Description"`
+	Configs     []TemplateConfig `xml:"Config"`
+}
+
+// TemplateConfig is one <Config> entry of a template.
+type TemplateConfig struct {
+	Name        string `xml:"Name,attr"`
+	Target      string `xml:"Target,attr"`
+	Default     string `xml:"Default,attr"`
+	Mode        string `xml:"Mode,attr"`
+	Description string `xml:"Description,attr"`
+	Type        string `xml:"Type,attr"`
+	Display     string `xml:"Display,attr"`
+	Required    string `xml:"Required,attr"`
+	Mask        string `xml:"Mask,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// TemplateSummary is a short description of a template file.
+type TemplateSummary struct {
+	Name       string
+	File       string
+	Repository string
+	Network    string
+	Error      string // set if the file could not be parsed
+}
+
+// TemplateCreateResult describes the outcome of creating a container from a
+// template. Steps records each action taken, including any rollback, in order.
+type TemplateCreateResult struct {
+	Template    string
+	Name        string
+	ContainerID string
+	ReplacedID  string // ID of the container that was replaced, if any
+	Started     bool
+	RolledBack  bool
+	Warnings    []string
+	Steps       []string
+}
+
+// ParseTemplate decodes a dockerMan template.
+func ParseTemplate(data []byte) (*Template, error) {
+	var t Template
+	if err := xml.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("parse template: %w", err)
+	}
+	if t.Version != "2" {
+		return nil, fmt.Errorf("unsupported template version %q (only version 2 is supported)", t.Version)
+	}
+	if t.Repository == "" {
+		return nil, fmt.Errorf("template %q has no repository", t.Name)
+	}
+	for i := range t.Configs {
+		t.Configs[i].Value = strings.TrimSpace(t.Configs[i].Value)
+	}
+	return &t, nil
+}
+
+// ToCreateConfig maps the template onto a ContainerCreateConfig the same way
+// the Unraid web UI would build its docker run command. Flags in ExtraParams
+// that ContainerCreateConfig cannot express are returned as warnings rather
+// than silently dropped.
+func (t *Template) ToCreateConfig() (ContainerCreateConfig, []string, error) {
+	cfg := ContainerCreateConfig{
+		Name:        t.Name,
+		Image:       t.Repository,
+		NetworkMode: t.Network,
+		Privileged:  strings.EqualFold(t.Privileged, "true"),
+		CPUSet:      strings.TrimSpace(t.CPUset),
+		Labels:      map[string]string{labelManaged: "dockerman"},
+	}
+	if userNetworkMode(cfg.NetworkMode) {
+		cfg.IPv4Address = strings.TrimSpace(t.MyIP)
+	}
+	if t.WebUI != "" {
+		cfg.Labels[labelWebUI] = t.WebUI
+	}
+	if t.Icon != "" {
+		cfg.Labels[labelIcon] = t.Icon
+	}
+
+	// Published ports only make sense when the container shares the host's
+	// bridge; on host, container: and macvlan networks they are ignored.
+	publishPorts := cfg.NetworkMode != "host" && cfg.NetworkMode != "none" &&
+		!strings.HasPrefix(cfg.NetworkMode, "container:") && cfg.IPv4Address == ""
+
+	for _, c := range t.Configs {
+		switch c.Type {
+		case "Port":
+			if c.Value == "" || !publishPorts {
+				continue
+			}
+			containerPort, err := strconv.Atoi(c.Target)
+			if err != nil {
+				return cfg, nil, fmt.Errorf("config %q: invalid container port %q", c.Name, c.Target)
+			}
+			hostPort, err := strconv.Atoi(c.Value)
+			if err != nil {
+				return cfg, nil, fmt.Errorf("config %q: invalid host port %q", c.Name, c.Value)
+			}
+			cfg.PortBindings = append(cfg.PortBindings, PortMapping{
+				ContainerPort: containerPort,
+				Protocol:      c.Mode,
+				HostPort:      hostPort,
+			})
+		case "Path":
+			if c.Value == "" {
+				continue
+			}
+			bind := c.Value + ":" + c.Target
+			if c.Mode != "" {
+				bind += ":" + c.Mode
+			}
+			cfg.Binds = append(cfg.Binds, bind)
+		case "Variable":
+			cfg.Env = append(cfg.Env, c.Target+"="+c.Value)
+		case "Label":
+			cfg.Labels[c.Target] = c.Value
+		case "Device":
+			if c.Value == "" {
+				continue
+			}
+			d, err := ParseDeviceSpec(c.Value)
+			if err != nil {
+				return cfg, nil, fmt.Errorf("config %q: %w", c.Name, err)
+			}
+			cfg.Devices = append(cfg.Devices, d)
+		}
+	}
+
+	warnings, err := applyExtraParams(&cfg, t.ExtraParams)
+	if err != nil {
+		return cfg, nil, err
+	}
+	if t.PostArgs != "" {
+		args, err := splitArgs(t.PostArgs)
+		if err != nil {
+			return cfg, nil, fmt.Errorf("post args: %w", err)
+		}
+		cfg.Cmd = args
+	}
+	return cfg, warnings, nil
+}
+
+// applyExtraParams applies the docker run flags in extra to cfg. Unsupported
+// flags are reported as warnings.
+func applyExtraParams(cfg *ContainerCreateConfig, extra string) ([]string, error) {
+	args, err := splitArgs(extra)
+	if err != nil {
+		return nil, fmt.Errorf("extra params: %w", err)
+	}
+
+	var warnings []string
+	for i := 0; i < len(args); i++ {
+		flag, value, hasValue := strings.Cut(args[i], "=")
+		takeValue := func() (string, error) {
+			if hasValue {
+				return value, nil
+			}
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("extra params: %s requires a value", flag)
+			}
+			i++
+			return args[i], nil
+		}
+
+		var err error
+		switch flag {
+		case "--privileged":
+			cfg.Privileged = !hasValue || value == "true"
+		case "--restart":
+			var v string
+			if v, err = takeValue(); err == nil {
+				cfg.RestartPolicy, err = ParseRestartPolicy(v)
+			}
+		case "--memory", "-m":
+			var v string
+			if v, err = takeValue(); err == nil {
+				cfg.MemoryLimit, err = ParseMemory(v)
+			}
+		case "--cpus":
+			var v string
+			if v, err = takeValue(); err == nil {
+				if cfg.CPUs, err = strconv.ParseFloat(v, 64); err != nil {
+					err = fmt.Errorf("extra params: invalid --cpus %q", v)
+				}
+			}
+		case "--cpuset-cpus":
+			cfg.CPUSet, err = takeValue()
+		case "--add-host":
+			var v string
+			if v, err = takeValue(); err == nil {
+				cfg.ExtraHosts = append(cfg.ExtraHosts, v)
+			}
+		case "--cap-add":
+			var v string
+			if v, err = takeValue(); err == nil {
+				cfg.CapAdd = append(cfg.CapAdd, v)
+			}
+		case "--cap-drop":
+			var v string
+			if v, err = takeValue(); err == nil {
+				cfg.CapDrop = append(cfg.CapDrop, v)
+			}
+		case "--device":
+			var v string
+			if v, err = takeValue(); err == nil {
+				var d DeviceMapping
+				if d, err = ParseDeviceSpec(v); err == nil {
+					cfg.Devices = append(cfg.Devices, d)
+				}
+			}
+		case "-e", "--env":
+			var v string
+			if v, err = takeValue(); err == nil {
+				cfg.Env = append(cfg.Env, v)
+			}
+		case "-l", "--label":
+			var v string
+			if v, err = takeValue(); err == nil {
+				k, lv, _ := strings.Cut(v, "=")
+				cfg.Labels[k] = lv
+			}
+		case "--entrypoint":
+			var v string
+			if v, err = takeValue(); err == nil {
+				cfg.Entrypoint = []string{v}
+			}
+		default:
+			if strings.HasPrefix(flag, "-") {
+				// Skip the flag's separate value, if it has one, so it is not
+				// mistaken for the next flag.
+				if !hasValue && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+					i++
+					warnings = append(warnings, fmt.Sprintf("unsupported extra parameter %s %s ignored", flag, args[i]))
+				} else {
+					warnings = append(warnings, fmt.Sprintf("unsupported extra parameter %s ignored", args[i]))
+				}
+			} else {
+				warnings = append(warnings, fmt.Sprintf("unexpected extra parameter %q ignored", args[i]))
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return warnings, nil
+}
+
+// splitArgs splits a command line into words, honouring single and double
+// quotes and backslash escapes outside single quotes.
+func splitArgs(s string) ([]string, error) {
+	var (
+		args    []string
+		cur     strings.Builder
+		inWord  bool
+		quote   rune
+		escaped bool
+	)
+	for _, r := range s {
+		switch {
+		case escaped:
+			cur.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				args = append(args, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+		default:
+			cur.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape in %q", s)
+	}
+	if inWord {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
+
+// ---------------------------------------------------------------------------
+// Template store
+// ---------------------------------------------------------------------------
+
+// TemplateStore provides access to dockerMan user templates.
+type TemplateStore interface {
+	ListTemplates() ([]TemplateSummary, error)
+	LoadTemplate(name string) (*Template, error)
+}
+
+// FileTemplateStore reads templates from a dockerMan templates-user directory.
+type FileTemplateStore struct {
+	dir string
+}
+
+// NewFileTemplateStore returns a FileTemplateStore reading from dir, normally
+// /boot/config/plugins/dockerMan/templates-user mounted into the container.
+func NewFileTemplateStore(dir string) *FileTemplateStore {
+	return &FileTemplateStore{dir: dir}
+}
+
+// ListTemplates returns a summary of every .xml file in the directory, sorted
+// by file name. Files that fail to parse are included with Error set.
+func (s *FileTemplateStore) ListTemplates() ([]TemplateSummary, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("read template directory: %w", err)
+	}
+
+	var summaries []TemplateSummary
+	for _, e := range entries {
+		if e.IsDir() || !strings.EqualFold(filepath.Ext(e.Name()), ".xml") {
+			continue
+		}
+		sum := TemplateSummary{File: e.Name()}
+		t, err := s.readFile(e.Name())
+		if err != nil {
+			sum.Error = err.Error()
+		} else {
+			sum.Name = t.Name
+			sum.Repository = t.Repository
+			sum.Network = t.Network
+		}
+		summaries = append(summaries, sum)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].File < summaries[j].File })
+	return summaries, nil
+}
+
+// LoadTemplate returns the template for the named container. It first tries
+// dockerMan's "my-<name>.xml" file and then falls back to scanning every
+// template for a matching <Name>.
+func (s *FileTemplateStore) LoadTemplate(name string) (*Template, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.Contains(name, "..") {
+		return nil, fmt.Errorf("invalid template name %q", name)
+	}
+
+	if t, err := s.readFile("my-" + name + ".xml"); err == nil && t.Name == name {
+		return t, nil
+	}
+
+	summaries, err := s.ListTemplates()
+	if err != nil {
+		return nil, err
+	}
+	for _, sum := range summaries {
+		if sum.Error == "" && sum.Name == name {
+			return s.readFile(sum.File)
+		}
+	}
+	return nil, fmt.Errorf("template not found: %s", name)
+}
+
+func (s *FileTemplateStore) readFile(file string) (*Template, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, file))
+	if err != nil {
+		return nil, err
+	}
+	return ParseTemplate(data)
+}
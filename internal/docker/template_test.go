@@ -0,0 +1,419 @@
+package docker
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+	"github.com/jamesprial/unraid-mcp/internal/tools"
+)
+
+// ---------------------------------------------------------------------------
+// Template parsing and mapping
+// ---------------------------------------------------------------------------
+
+func Test_ParseTemplate_RepoTemplate(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "templates", "unraid-mcp.xml"))
+	if err != nil {
+		t.Fatalf("read template: %v", err)
+	}
+	tmpl, err := ParseTemplate(data)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	if tmpl.Name != "unraid-mcp" || tmpl.Repository != "jamesprial/unraid-mcp:latest" {
+		t.Errorf("Name/Repository = %q/%q", tmpl.Name, tmpl.Repository)
+	}
+
+	cfg, warnings, err := tmpl.ToCreateConfig()
+	if err != nil {
+		t.Fatalf("ToCreateConfig() error = %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if cfg.Image != "jamesprial/unraid-mcp:latest" || cfg.NetworkMode != "bridge" || cfg.Privileged {
+		t.Errorf("Image/NetworkMode/Privileged = %q/%q/%v", cfg.Image, cfg.NetworkMode, cfg.Privileged)
+	}
+	wantPorts := []PortMapping{{ContainerPort: 8080, Protocol: "tcp", HostPort: 8080}}
+	if !reflect.DeepEqual(cfg.PortBindings, wantPorts) {
+		t.Errorf("PortBindings = %+v, want %+v", cfg.PortBindings, wantPorts)
+	}
+	if !slices.Contains(cfg.Binds, "/var/local/emhttp:/host/emhttp:ro") {
+		t.Errorf("Binds = %v, missing emhttp mount", cfg.Binds)
+	}
+	if !slices.Contains(cfg.Env, "UNRAID_MCP_AUTH_TOKEN=") {
+		t.Errorf("Env = %v, missing UNRAID_MCP_AUTH_TOKEN", cfg.Env)
+	}
+	if !reflect.DeepEqual(cfg.ExtraHosts, []string{"host.docker.internal:host-gateway"}) {
+		t.Errorf("ExtraHosts = %v", cfg.ExtraHosts)
+	}
+	if cfg.Labels[labelManaged] != "dockerman" || cfg.Labels[labelIcon] == "" {
+		t.Errorf("Labels = %v, want dockerMan labels", cfg.Labels)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "--group-add 0") {
+		t.Errorf("warnings = %v, want one --group-add warning", warnings)
+	}
+}
+
+func Test_ParseTemplate_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "not xml", data: "nope"},
+		{name: "version 1", data: `<Container><Name>a</Name><Repository>b</Repository></Container>`},
+		{name: "no repository", data: `<Container version="2"><Name>a</Name></Container>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseTemplate([]byte(tt.data)); err == nil {
+				t.Error("ParseTemplate() error = nil, want error")
+			}
+		})
+	}
+}
+
+func Test_Template_ToCreateConfig(t *testing.T) {
+	base := func() *Template {
+		return &Template{
+			Name:       "app",
+			Repository: "example/app:1",
+			Network:    "bridge",
+			Configs: []TemplateConfig{
+				{Name: "Web", Target: "80", Mode: "tcp", Type: "Port", Value: "8080"},
+				{Name: "Data", Target: "/data", Mode: "rw", Type: "Path", Value: "/mnt/user/appdata/app"},
+				{Name: "Empty path", Target: "/empty", Type: "Path"},
+				{Name: "TZ", Target: "TZ", Type: "Variable", Value: "UTC"},
+				{Name: "Label", Target: "com.example.tier", Type: "Label", Value: "web"},
+				{Name: "GPU", Type: "Device", Value: "/dev/dri"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(*Template)
+		check   func(t *testing.T, cfg ContainerCreateConfig, warnings []string)
+		wantErr bool
+	}{
+		{
+			name:   "bridge maps every config type",
+			modify: func(*Template) {},
+			check: func(t *testing.T, cfg ContainerCreateConfig, warnings []string) {
+				t.Helper()
+				if len(cfg.PortBindings) != 1 || cfg.PortBindings[0].HostPort != 8080 {
+					t.Errorf("PortBindings = %+v", cfg.PortBindings)
+				}
+				if !reflect.DeepEqual(cfg.Binds, []string{"/mnt/user/appdata/app:/data:rw"}) {
+					t.Errorf("Binds = %v", cfg.Binds)
+				}
+				if !reflect.DeepEqual(cfg.Env, []string{"TZ=UTC"}) {
+					t.Errorf("Env = %v", cfg.Env)
+				}
+				if cfg.Labels["com.example.tier"] != "web" {
+					t.Errorf("Labels = %v", cfg.Labels)
+				}
+				if len(cfg.Devices) != 1 || cfg.Devices[0].PathOnHost != "/dev/dri" {
+					t.Errorf("Devices = %+v", cfg.Devices)
+				}
+				if len(warnings) != 0 {
+					t.Errorf("warnings = %v, want none", warnings)
+				}
+			},
+		},
+		{
+			name:   "host network drops published ports",
+			modify: func(tm *Template) { tm.Network = "host" },
+			check: func(t *testing.T, cfg ContainerCreateConfig, _ []string) {
+				t.Helper()
+				if len(cfg.PortBindings) != 0 {
+					t.Errorf("PortBindings = %+v, want none", cfg.PortBindings)
+				}
+			},
+		},
+		{
+			name: "custom network with static IP",
+			modify: func(tm *Template) {
+				tm.Network = "br0"
+				tm.MyIP = "192.168.1.50"
+			},
+			check: func(t *testing.T, cfg ContainerCreateConfig, _ []string) {
+				t.Helper()
+				if cfg.IPv4Address != "192.168.1.50" || len(cfg.PortBindings) != 0 {
+					t.Errorf("IPv4Address/PortBindings = %q/%+v", cfg.IPv4Address, cfg.PortBindings)
+				}
+			},
+		},
+		{
+			name: "extra params and post args",
+			modify: func(tm *Template) {
+				tm.ExtraParams = `--restart=unless-stopped -m 512m --cpus 1.5 --cap-add NET_ADMIN -e 'GREETING=hello world' --log-opt max-size=10m --init`
+				tm.PostArgs = `serve --port "9000"`
+			},
+			check: func(t *testing.T, cfg ContainerCreateConfig, warnings []string) {
+				t.Helper()
+				if cfg.RestartPolicy.Name != "unless-stopped" {
+					t.Errorf("RestartPolicy = %+v", cfg.RestartPolicy)
+				}
+				if cfg.MemoryLimit != 512*1024*1024 || cfg.CPUs != 1.5 {
+					t.Errorf("MemoryLimit/CPUs = %d/%v", cfg.MemoryLimit, cfg.CPUs)
+				}
+				if !reflect.DeepEqual(cfg.CapAdd, []string{"NET_ADMIN"}) {
+					t.Errorf("CapAdd = %v", cfg.CapAdd)
+				}
+				if !slices.Contains(cfg.Env, "GREETING=hello world") {
+					t.Errorf("Env = %v", cfg.Env)
+				}
+				if !reflect.DeepEqual(cfg.Cmd, []string{"serve", "--port", "9000"}) {
+					t.Errorf("Cmd = %v", cfg.Cmd)
+				}
+				if len(warnings) != 2 {
+					t.Errorf("warnings = %v, want --log-opt and --init", warnings)
+				}
+			},
+		},
+		{
+			name:    "invalid port",
+			modify:  func(tm *Template) { tm.Configs[0].Value = "http" },
+			wantErr: true,
+		},
+		{
+			name:    "unterminated quote",
+			modify:  func(tm *Template) { tm.ExtraParams = `-e "A=b` },
+			wantErr: true,
+		},
+		{
+			name:    "flag missing value",
+			modify:  func(tm *Template) { tm.ExtraParams = "--memory" },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := base()
+			tt.modify(tmpl)
+			cfg, warnings, err := tmpl.ToCreateConfig()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ToCreateConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.check != nil {
+				tt.check(t, cfg, warnings)
+			}
+		})
+	}
+}
+
+// ---------------------------------------------------------------------------
+// FileTemplateStore
+// ---------------------------------------------------------------------------
+
+func writeTemplate(t *testing.T, dir, file, name string) {
+	t.Helper()
+	data := `<?xml version="1.0"?>
+<Container version="2">
+  <Name>` + name + `</Name>
+  <Repository>example/` + name + `:latest</Repository>
+  <Network>bridge</Network>
+  <Config Name="Password" Target="PASSWORD" Type="Variable" Mask="true">hunter2</Config>
+  <Config Name="Web" Target="80" Mode="tcp" Type="Port">8080</Config>
+</Container>`
+	if err := os.WriteFile(filepath.Join(dir, file), []byte(data), 0o600); err != nil {
+		t.Fatalf("write template: %v", err)
+	}
+}
+
+func newTemplateDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeTemplate(t, dir, "my-lidarr.xml", "lidarr")
+	writeTemplate(t, dir, "custom-name.xml", "bazarr")
+	if err := os.WriteFile(filepath.Join(dir, "broken.xml"), []byte("<Container"), 0o600); err != nil {
+		t.Fatalf("write template: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	return dir
+}
+
+func Test_FileTemplateStore_ListTemplates(t *testing.T) {
+	store := NewFileTemplateStore(newTemplateDir(t))
+
+	summaries, err := store.ListTemplates()
+	if err != nil {
+		t.Fatalf("ListTemplates() error = %v", err)
+	}
+	if len(summaries) != 3 {
+		t.Fatalf("len(summaries) = %d, want 3: %+v", len(summaries), summaries)
+	}
+	if summaries[0].File != "broken.xml" || summaries[0].Error == "" {
+		t.Errorf("summaries[0] = %+v, want broken.xml with an error", summaries[0])
+	}
+	if summaries[2].Name != "lidarr" || summaries[2].Repository != "example/lidarr:latest" {
+		t.Errorf("summaries[2] = %+v, want lidarr", summaries[2])
+	}
+}
+
+func Test_FileTemplateStore_LoadTemplate(t *testing.T) {
+	store := NewFileTemplateStore(newTemplateDir(t))
+
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "lidarr", want: "lidarr"},
+		{name: "bazarr", want: "bazarr"},
+		{name: "prowlarr", wantErr: true},
+		{name: "../etc/passwd", wantErr: true},
+		{name: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := store.LoadTemplate(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadTemplate(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if err == nil && tmpl.Name != tt.want {
+				t.Errorf("LoadTemplate(%q).Name = %q, want %q", tt.name, tmpl.Name, tt.want)
+			}
+		})
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Template tools
+// ---------------------------------------------------------------------------
+
+func newTemplateToolsUnderTest(t *testing.T) (*MockDockerManager, []tools.Registration) {
+	t.Helper()
+	mgr := newPopulatedMock(t)
+	confirm := safety.NewConfirmationTracker(DestructiveTools)
+	store := NewFileTemplateStore(newTemplateDir(t))
+	return mgr, TemplateTools(mgr, store, safety.NewFilter(nil, []string{"bazarr"}), nil, confirm, nil)
+}
+
+func Test_TemplateTools_ListFiltersDenied(t *testing.T) {
+	_, regs := newTemplateToolsUnderTest(t)
+
+	text := callTool(t, regs, "docker_template_list", nil)
+	if strings.Contains(text, "bazarr") || !strings.Contains(text, "lidarr") {
+		t.Errorf("docker_template_list = %s, want lidarr without bazarr", text)
+	}
+}
+
+func Test_TemplateTools_ShowMasksSecrets(t *testing.T) {
+	_, regs := newTemplateToolsUnderTest(t)
+
+	text := callTool(t, regs, "docker_template_show", map[string]any{"name": "lidarr"})
+	if strings.Contains(text, "hunter2") {
+		t.Errorf("docker_template_show leaked a masked value: %s", text)
+	}
+	if !strings.Contains(text, "PASSWORD="+maskedValue) {
+		t.Errorf("docker_template_show = %s, want masked PASSWORD", text)
+	}
+}
+
+func Test_TemplateTools_Create(t *testing.T) {
+	mgr, regs := newTemplateToolsUnderTest(t)
+	args := map[string]any{"template": "lidarr", "env": []any{"PASSWORD=s3cret"}}
+
+	text := callTool(t, regs, "docker_template_create", args)
+	args["confirmation_token"] = extractToken(t, text)
+	text = callTool(t, regs, "docker_template_create", args)
+
+	var result TemplateCreateResult
+	if err := json.Unmarshal([]byte(text), &result); err != nil {
+		t.Fatalf("decode result: %v\n%s", err, text)
+	}
+	if !result.Started || result.ReplacedID != "" {
+		t.Errorf("result = %+v, want started new container", result)
+	}
+	detail, err := mgr.InspectContainer(t.Context(), result.ContainerID)
+	if err != nil {
+		t.Fatalf("InspectContainer() error = %v", err)
+	}
+	if detail.Name != "lidarr" || detail.State != "running" {
+		t.Errorf("Name/State = %q/%q, want lidarr/running", detail.Name, detail.State)
+	}
+	if !slices.Contains(detail.Config.Env, "PASSWORD=s3cret") {
+		t.Errorf("Env = %v, want override applied", detail.Config.Env)
+	}
+}
+
+func Test_TemplateTools_CreateTokenCoversTemplateFile(t *testing.T) {
+	dir := newTemplateDir(t)
+	confirm := safety.NewConfirmationTracker(DestructiveTools)
+	regs := TemplateTools(newPopulatedMock(t), NewFileTemplateStore(dir), safety.NewFilter(nil, nil), nil, confirm, nil)
+	args := map[string]any{"template": "lidarr"}
+	args["confirmation_token"] = extractToken(t, callTool(t, regs, "docker_template_create", args))
+
+	// The template now pulls a different image than the prompt showed.
+	data, err := os.ReadFile(filepath.Join(dir, "my-lidarr.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := strings.Replace(string(data), "example/lidarr:latest", "evil/miner:latest", 1)
+	if err := os.WriteFile(filepath.Join(dir, "my-lidarr.xml"), []byte(edited), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	text := callTool(t, regs, "docker_template_create", args)
+	if !strings.Contains(text, "confirmation_token") || !strings.Contains(text, "evil/miner") {
+		t.Errorf("confirm after a template edit = %s, want a fresh prompt", text)
+	}
+}
+
+func Test_TemplateTools_CreateRecreatesExisting(t *testing.T) {
+	mgr, regs := newTemplateToolsUnderTest(t)
+
+	// The existing "plex" container is replaced by one built from the lidarr
+	// template under the plex name.
+	args := map[string]any{"template": "lidarr", "name": "plex"}
+	text := callTool(t, regs, "docker_template_create", args)
+	if !strings.Contains(text, "already exists") {
+		t.Fatalf("create over existing container = %s, want already exists error", text)
+	}
+
+	args["recreate"] = true
+	text = callTool(t, regs, "docker_template_create", args)
+	if !strings.Contains(text, "will be stopped and replaced") {
+		t.Fatalf("confirmation prompt = %s, want replacement note", text)
+	}
+	args["confirmation_token"] = extractToken(t, text)
+	text = callTool(t, regs, "docker_template_create", args)
+
+	var result TemplateCreateResult
+	if err := json.Unmarshal([]byte(text), &result); err != nil {
+		t.Fatalf("decode result: %v\n%s", err, text)
+	}
+	if result.ReplacedID != "abc123" || !result.Started {
+		t.Errorf("result = %+v, want abc123 replaced", result)
+	}
+	if _, err := mgr.InspectContainer(t.Context(), "abc123"); err == nil {
+		t.Error("replaced container still exists")
+	}
+	detail, err := mgr.InspectContainer(t.Context(), result.ContainerID)
+	if err != nil {
+		t.Fatalf("InspectContainer() error = %v", err)
+	}
+	if detail.Name != "plex" || detail.Image != "example/lidarr:latest" {
+		t.Errorf("Name/Image = %q/%q", detail.Name, detail.Image)
+	}
+}
+
+func Test_TemplateTools_CreateDeniedTemplate(t *testing.T) {
+	_, regs := newTemplateToolsUnderTest(t)
+
+	text := callTool(t, regs, "docker_template_create", map[string]any{"template": "bazarr"})
+	if !strings.Contains(text, "not allowed") {
+		t.Errorf("docker_template_create = %s, want not allowed", text)
+	}
+}
@@ -0,0 +1,375 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+	"github.com/jamesprial/unraid-mcp/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// maskedValue replaces the value of template configs marked Mask="true".
+const maskedValue = "********"
+
+// TemplateTools returns the tool registrations for creating containers from
+// Unraid dockerMan templates. Creation is subject to the same filter, create
+// policy and confirmation flow as docker_create.
+func TemplateTools(
+	mgr DockerManager,
+	store TemplateStore,
+	filter *safety.Filter,
+	policy *CreatePolicy,
+	confirm *safety.ConfirmationTracker,
+	audit *safety.AuditLogger,
+) []tools.Registration {
+	return []tools.Registration{
+		toolDockerTemplateList(store, filter, audit),
+		toolDockerTemplateShow(store, filter, audit),
+		toolDockerTemplateCreate(mgr, store, filter, policy, confirm, audit),
+	}
+}
+
+// maskTemplate returns a copy of t with masked config values hidden.
+func maskTemplate(t *Template) *Template {
+	cp := *t
+	cp.Configs = make([]TemplateConfig, len(t.Configs))
+	for i, c := range t.Configs {
+		if strings.EqualFold(c.Mask, "true") && c.Value != "" {
+			c.Value = maskedValue
+		}
+		cp.Configs[i] = c
+	}
+	return &cp
+}
+
+// applyTemplateOverrides applies tool arguments to a loaded template. Variable
+// overrides replace the value of a matching Variable config or add a new one.
+func applyTemplateOverrides(t *Template, name, network, ip string, env []string) error {
+	if name != "" {
+		t.Name = name
+	}
+	if network != "" {
+		if network != t.Network {
+			t.MyIP = ""
+		}
+		t.Network = network
+	}
+	if ip != "" {
+		t.MyIP = ip
+	}
+	for _, e := range env {
+		key, value, ok := strings.Cut(e, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("invalid env entry %q (want KEY=value)", e)
+		}
+		found := false
+		for i := range t.Configs {
+			if t.Configs[i].Type == "Variable" && t.Configs[i].Target == key {
+				t.Configs[i].Value = value
+				found = true
+			}
+		}
+		if !found {
+			t.Configs = append(t.Configs, TemplateConfig{Name: key, Target: key, Type: "Variable", Value: value})
+		}
+	}
+	return nil
+}
+
+func toolDockerTemplateList(store TemplateStore, filter *safety.Filter, audit *safety.AuditLogger) tools.Registration {
+	tool := mcp.NewTool("docker_template_list",
+		mcp.WithDescription("List the Unraid dockerMan user templates (the container definitions shown in the Unraid Docker tab)."),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		params := map[string]any{}
+
+		summaries, err := store.ListTemplates()
+		if err != nil {
+			tools.LogAudit(audit, "docker_template_list", params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+
+		filtered := make([]TemplateSummary, 0, len(summaries))
+		for _, s := range summaries {
+			if s.Name == "" || filter.IsAllowed(s.Name) {
+				filtered = append(filtered, s)
+			}
+		}
+
+		tools.LogAudit(audit, "docker_template_list", params, "ok", start)
+		return tools.JSONResult(filtered), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
+
+func toolDockerTemplateShow(store TemplateStore, filter *safety.Filter, audit *safety.AuditLogger) tools.Registration {
+	tool := mcp.NewTool("docker_template_show",
+		mcp.WithDescription("Show an Unraid dockerMan template and the container configuration it maps to. Masked values such as passwords are hidden."),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("Template (container) name"),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		name := req.GetString("name", "")
+		params := map[string]any{"name": name}
+
+		if !filter.IsAllowed(name) {
+			tools.LogAudit(audit, "docker_template_show", params, "denied", start)
+			return tools.ErrorResult(fmt.Sprintf("access to template %q is not allowed", name)), nil
+		}
+
+		t, err := store.LoadTemplate(name)
+		if err != nil {
+			tools.LogAudit(audit, "docker_template_show", params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+
+		masked := maskTemplate(t)
+		cfg, warnings, err := masked.ToCreateConfig()
+		if err != nil {
+			tools.LogAudit(audit, "docker_template_show", params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+
+		tools.LogAudit(audit, "docker_template_show", params, "ok", start)
+		return tools.JSONResult(map[string]any{
+			"Template": masked,
+			"Config":   cfg,
+			"Warnings": warnings,
+		}), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
+
+func toolDockerTemplateCreate(mgr DockerManager, store TemplateStore, filter *safety.Filter, policy *CreatePolicy, confirm *safety.ConfirmationTracker, audit *safety.AuditLogger) tools.Registration {
+	const toolName = "docker_template_create"
+
+	tool := mcp.NewTool(toolName,
+		mcp.WithDescription("Create a container from an Unraid dockerMan template, exactly as the Unraid web UI would. "+
+			"With recreate, an existing container of the same name is replaced and restored if the new one fails to start. "+
+			"Subject to the server's create policy. Requires confirmation."),
+		mcp.WithString("template",
+			mcp.Required(),
+			mcp.Description("Template (container) name"),
+		),
+		mcp.WithString("name",
+			mcp.Description("Container name override (default: the template's name)"),
+		),
+		mcp.WithArray("env",
+			mcp.Description("Variable overrides as KEY=value strings"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithString("network",
+			mcp.Description("Network override (e.g. bridge, br0)"),
+		),
+		mcp.WithString("ip_address",
+			mcp.Description("Static IPv4 address override on a user-defined network"),
+		),
+		mcp.WithBoolean("recreate",
+			mcp.Description("Replace an existing container with the same name (default: false)"),
+		),
+		mcp.WithBoolean("start",
+			mcp.Description("Start the container after creating it (default: true)"),
+		),
+		mcp.WithString("confirmation_token",
+			mcp.Description("Confirmation token returned by a prior call to this tool"),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		templateName := req.GetString("template", "")
+		name := req.GetString("name", "")
+		env := req.GetStringSlice("env", nil)
+		network := req.GetString("network", "")
+		ip := req.GetString("ip_address", "")
+		recreate := req.GetBool("recreate", false)
+		startAfter := req.GetBool("start", true)
+		token := req.GetString("confirmation_token", "")
+		params := map[string]any{
+			"template":   templateName,
+			"name":       name,
+			"env":        envNames(env),
+			"network":    network,
+			"ip_address": ip,
+			"recreate":   recreate,
+			"start":      startAfter,
+		}
+
+		if !filter.IsAllowed(templateName) {
+			tools.LogAudit(audit, toolName, params, "denied", start)
+			return tools.ErrorResult(fmt.Sprintf("access to template %q is not allowed", templateName)), nil
+		}
+
+		t, err := store.LoadTemplate(templateName)
+		if err != nil {
+			tools.LogAudit(audit, toolName, params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+		if err := applyTemplateOverrides(t, name, network, ip, env); err != nil {
+			return tools.ErrorResult(err.Error()), nil
+		}
+		cfg, warnings, err := t.ToCreateConfig()
+		if err != nil {
+			return tools.ErrorResult(err.Error()), nil
+		}
+
+		if !filter.IsAllowed(cfg.Name) {
+			tools.LogAudit(audit, toolName, params, "denied", start)
+			return tools.ErrorResult(fmt.Sprintf("creation of container %q is not allowed", cfg.Name)), nil
+		}
+		if err := cfg.Validate(); err != nil {
+			return tools.ErrorResult(err.Error()), nil
+		}
+		if err := policy.Check(cfg); err != nil {
+			tools.LogAudit(audit, toolName, params, "denied: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+		if err := checkNetworkContainer(ctx, mgr, filter, cfg.NetworkMode); err != nil {
+			tools.LogAudit(audit, toolName, params, "denied: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+
+		existing, err := findContainerByName(ctx, mgr, cfg.Name)
+		if err != nil {
+			tools.LogAudit(audit, toolName, params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+		if existing != nil && !recreate {
+			return tools.ErrorResult(fmt.Sprintf("container %q already exists; set recreate to replace it", cfg.Name)), nil
+		}
+		conflicts, err := preflightCreate(ctx, mgr, filter, []ContainerCreateConfig{cfg})
+		if err != nil {
+			tools.LogAudit(audit, toolName, params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+		warnings = append(warnings, conflicts...)
+
+		// Bind the token to the container the template renders, so an edit to
+		// the template file between the prompt and the confirmation needs a
+		// new prompt.
+		rendered, err := json.Marshal(cfg)
+		if err != nil {
+			return tools.ErrorResult(fmt.Sprintf("encode container config: %v", err)), nil
+		}
+		sum := sha256.Sum256(rendered)
+		confirmArgs := confirmArgsWithEnv(params, env)
+		confirmArgs["config_sha256"] = hex.EncodeToString(sum[:])
+		if !confirm.Confirm(token, toolName, cfg.Name, confirmArgs) {
+			desc := fmt.Sprintf("Template %q: ", t.Name) + describeCreate(cfg)
+			if existing != nil {
+				desc += fmt.Sprintf(" The existing container %q (%s) will be stopped and replaced.", existing.Name, shortID(existing.ID))
+			}
+			if len(warnings) > 0 {
+				desc += " Warnings: " + strings.Join(warnings, "; ") + "."
+			}
+			return tools.ConfirmPrompt(confirm, toolName, cfg.Name, desc, confirmArgs), nil
+		}
+
+		result := createFromTemplate(ctx, mgr, cfg, existing, startAfter)
+		result.Template = templateName
+		result.Warnings = warnings
+		if result.ContainerID == "" || (startAfter && !result.Started) {
+			msg := "container creation failed"
+			if result.RolledBack {
+				msg += "; previous container restored"
+			}
+			tools.LogAudit(audit, toolName, params, "error: "+strings.Join(result.Steps, "; "), start)
+			return tools.ErrorResult(fmt.Sprintf("%s: %s", msg, strings.Join(result.Steps, "; "))), nil
+		}
+
+		tools.LogAudit(audit, toolName, params, "ok: "+result.ContainerID, start)
+		return tools.JSONResult(result), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
+
+// findContainerByName returns the container with the given name, or nil if
+// there is none.
+func findContainerByName(ctx context.Context, mgr DockerManager, name string) (*Container, error) {
+	containers, err := mgr.ListContainers(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	for i := range containers {
+		if containers[i].Name == name {
+			return &containers[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// createFromTemplate creates (and optionally starts) a container from cfg. If
+// existing is non-nil it is set aside first, then removed once the new
+// container is up, or restored if anything fails.
+func createFromTemplate(ctx context.Context, mgr DockerManager, cfg ContainerCreateConfig, existing *Container, startAfter bool) *TemplateCreateResult {
+	result := &TemplateCreateResult{Name: cfg.Name}
+	step := func(format string, args ...any) {
+		result.Steps = append(result.Steps, fmt.Sprintf(format, args...))
+	}
+
+	var aside *asideContainer
+	if existing != nil {
+		result.ReplacedID = existing.ID
+		var err error
+		aside, err = setAside(ctx, mgr, existing.ID, cfg.Name, existing.State == "running", 10, step)
+		if err != nil {
+			step("set existing container aside: %v", err)
+			return result
+		}
+	}
+	// rollback restores the replaced container, if any, after a failure.
+	rollback := func(newID string) {
+		if aside == nil {
+			return
+		}
+		result.RolledBack = true
+		if err := aside.restore(ctx, newID); err != nil {
+			step("rollback failed: %v", err)
+		}
+	}
+
+	id, err := mgr.CreateContainer(ctx, cfg)
+	if err != nil {
+		step("create container: %v", err)
+		rollback("")
+		return result
+	}
+	step("created container %s", shortID(id))
+
+	if startAfter {
+		if err := mgr.StartContainer(ctx, id); err != nil {
+			step("start container: %v", err)
+			if aside != nil {
+				rollback(id)
+			} else {
+				result.ContainerID = id
+			}
+			return result
+		}
+		result.Started = true
+		step("started container %s", shortID(id))
+	}
+	result.ContainerID = id
+
+	if aside != nil {
+		aside.remove(ctx)
+	}
+	return result
+}
@@ -12,15 +12,29 @@ var DestructiveTools = []string{
 	"docker_restart",
 	"docker_remove",
 	"docker_create",
+	"docker_update",
 	"docker_network_remove",
+	"docker_image_remove",
+	"docker_image_prune",
+	"docker_volume_remove",
+	"docker_volume_prune",
+	"docker_template_create",
+	"docker_file_write",
+	"docker_bulk",
+	"docker_compose_action",
+	"docker_compose_up",
 }
 
 // DockerTools returns a slice of tool registrations for all Docker MCP tools.
 // Each tool is wired to the provided DockerManager, safety Filter,
-// ConfirmationTracker, and AuditLogger.
+// CreatePolicy, ExecPolicy, ConfirmationTracker, and AuditLogger. A nil create
+// policy denies privileged mode, host networking, added capabilities and
+// devices; a nil exec policy disables docker_exec.
 func DockerTools(
 	mgr DockerManager,
 	filter *safety.Filter,
+	policy *CreatePolicy,
+	execPolicy *ExecPolicy,
 	confirm *safety.ConfirmationTracker,
 	audit *safety.AuditLogger,
 ) []tools.Registration {
@@ -33,13 +47,29 @@ func DockerTools(
 		toolDockerStop(mgr, filter, confirm, audit),
 		toolDockerRestart(mgr, filter, confirm, audit),
 		toolDockerRemove(mgr, filter, confirm, audit),
-		toolDockerCreate(mgr, filter, confirm, audit),
+		toolDockerCreate(mgr, filter, policy, confirm, audit),
 		toolDockerPull(mgr, audit),
+		toolDockerUpdate(mgr, filter, confirm, audit),
+		toolDockerExec(mgr, filter, execPolicy, audit),
+		toolDockerFileList(mgr, filter, audit),
+		toolDockerFileRead(mgr, filter, audit),
+		toolDockerFileWrite(mgr, filter, confirm, audit),
 		toolDockerNetworkList(mgr, audit),
 		toolDockerNetworkInspect(mgr, filter, audit),
 		toolDockerNetworkCreate(mgr, filter, confirm, audit),
 		toolDockerNetworkRemove(mgr, filter, confirm, audit),
 		toolDockerNetworkConnect(mgr, filter, audit),
 		toolDockerNetworkDisconnect(mgr, filter, audit),
+		toolDockerPortMap(mgr, filter, audit),
+		toolDockerImageList(mgr, audit),
+		toolDockerImageInspect(mgr, audit),
+		toolDockerImageHistory(mgr, audit),
+		toolDockerImageRemove(mgr, confirm, audit),
+		toolDockerImagePrune(mgr, confirm, audit),
+		toolDockerVolumeList(mgr, filter, audit),
+		toolDockerVolumeInspect(mgr, filter, audit),
+		toolDockerVolumeCreate(mgr, filter, audit),
+		toolDockerVolumeRemove(mgr, filter, confirm, audit),
+		toolDockerVolumePrune(mgr, filter, confirm, audit),
 	}
 }
@@ -0,0 +1,338 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"maps"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+	"github.com/jamesprial/unraid-mcp/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// ---------------------------------------------------------------------------
+// Test helpers
+// ---------------------------------------------------------------------------
+
+// newCallToolRequest builds an mcp.CallToolRequest for the given tool name and
+// arguments map.
+func newCallToolRequest(name string, args map[string]any) mcp.CallToolRequest {
+	req := mcp.CallToolRequest{}
+	req.Params.Name = name
+	req.Params.Arguments = args
+	return req
+}
+
+// extractResultText extracts the text string from a CallToolResult, assuming
+// the first content entry is TextContent.
+func extractResultText(t *testing.T, result *mcp.CallToolResult) string {
+	t.Helper()
+	if result == nil {
+		t.Fatal("result is nil")
+	}
+	if len(result.Content) == 0 {
+		t.Fatal("result has no content entries")
+	}
+	tc, ok := mcp.AsTextContent(result.Content[0])
+	if !ok {
+		t.Fatalf("first content entry is not TextContent, got %T", result.Content[0])
+	}
+	return tc.Text
+}
+
+// findRegistration returns the registration with the given tool name.
+func findRegistration(t *testing.T, regs []tools.Registration, name string) tools.Registration {
+	t.Helper()
+	for _, r := range regs {
+		if r.Tool.Name == name {
+			return r
+		}
+	}
+	t.Fatalf("registration for %q not found", name)
+	return tools.Registration{} // unreachable
+}
+
+// tokenPattern matches the confirmation token in a ConfirmPrompt result.
+var tokenPattern = regexp.MustCompile(`confirmation_token="([a-f0-9]+)"`)
+
+// extractToken pulls the confirmation token from a ConfirmPrompt result text.
+func extractToken(t *testing.T, text string) string {
+	t.Helper()
+	m := tokenPattern.FindStringSubmatch(text)
+	if len(m) < 2 {
+		t.Fatalf("no confirmation_token found in text:\n%s", text)
+	}
+	return m[1]
+}
+
+// callTool invokes the named tool handler and returns its result text.
+func callTool(t *testing.T, regs []tools.Registration, name string, args map[string]any) string {
+	t.Helper()
+	reg := findRegistration(t, regs, name)
+	result, err := reg.Handler(context.Background(), newCallToolRequest(name, args))
+	if err != nil {
+		t.Fatalf("%s handler returned error: %v", name, err)
+	}
+	return extractResultText(t, result)
+}
+
+// newToolsUnderTest returns the Docker tool registrations backed by a
+// populated mock and a fresh confirmation tracker.
+func newToolsUnderTest(t *testing.T) (*MockDockerManager, []tools.Registration) {
+	t.Helper()
+	mgr := newPopulatedMock(t)
+	confirm := safety.NewConfirmationTracker(DestructiveTools)
+	regs := DockerTools(mgr, safety.NewFilter(nil, nil), nil, nil, confirm, nil)
+	return mgr, regs
+}
+
+// ---------------------------------------------------------------------------
+// Confirmation binding
+// ---------------------------------------------------------------------------
+
+func Test_DockerTools_ConfirmationFlow(t *testing.T) {
+	mgr, regs := newToolsUnderTest(t)
+
+	text := callTool(t, regs, "docker_stop", map[string]any{"id": "abc123"})
+	if !strings.Contains(text, "Confirmation required") {
+		t.Fatalf("first call should request confirmation, got %q", text)
+	}
+	token := extractToken(t, text)
+
+	text = callTool(t, regs, "docker_stop", map[string]any{"id": "abc123", "confirmation_token": token})
+	if !strings.Contains(text, "stopped successfully") {
+		t.Fatalf("confirmed call should succeed, got %q", text)
+	}
+
+	detail, _ := mgr.InspectContainer(context.Background(), "abc123")
+	if detail.State != "exited" {
+		t.Errorf("container state = %q, want %q", detail.State, "exited")
+	}
+}
+
+func Test_DockerTools_ConfirmationReplayRejected(t *testing.T) {
+	tests := []struct {
+		name      string
+		replyTool string
+		replyArgs map[string]any
+	}{
+		{
+			name:      "token from docker_stop replayed against docker_remove",
+			replyTool: "docker_remove",
+			replyArgs: map[string]any{"id": "abc123"},
+		},
+		{
+			name:      "token for one container replayed against another",
+			replyTool: "docker_stop",
+			replyArgs: map[string]any{"id": "ghi789"},
+		},
+		{
+			name:      "token replayed with different arguments",
+			replyTool: "docker_stop",
+			replyArgs: map[string]any{"id": "abc123", "timeout": float64(1)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mgr, regs := newToolsUnderTest(t)
+
+			token := extractToken(t, callTool(t, regs, "docker_stop", map[string]any{"id": "abc123"}))
+
+			args := map[string]any{"confirmation_token": token}
+			for k, v := range tt.replyArgs {
+				args[k] = v
+			}
+			text := callTool(t, regs, tt.replyTool, args)
+			if !strings.Contains(text, "Confirmation required") {
+				t.Errorf("replayed token should not be accepted, got %q", text)
+			}
+
+			// Nothing should have changed on the mock.
+			for _, id := range []string{"abc123", "ghi789"} {
+				detail, err := mgr.InspectContainer(context.Background(), id)
+				if err != nil {
+					t.Fatalf("container %q should still exist: %v", id, err)
+				}
+				if detail.State != "running" {
+					t.Errorf("container %q state = %q, want running", id, detail.State)
+				}
+			}
+		})
+	}
+}
+
+func Test_DockerTools_ImageList_Dangling(t *testing.T) {
+	mgr, regs := newToolsUnderTest(t)
+	mgr.AddImage(&ImageDetail{Image: Image{ID: "sha256:dangling", Size: 10, Dangling: true}})
+	mgr.AddImage(&ImageDetail{Image: Image{ID: "sha256:tagged", RepoTags: []string{"nginx:latest"}, Size: 20}})
+
+	// dangling applies together with all rather than being ignored.
+	for _, args := range []map[string]any{{"dangling": true}, {"dangling": true, "all": true}} {
+		text := callTool(t, regs, "docker_image_list", args)
+		if !strings.Contains(text, "sha256:dangling") || strings.Contains(text, "sha256:tagged") {
+			t.Errorf("docker_image_list(%v) = %s, want only the dangling image", args, text)
+		}
+	}
+}
+
+func Test_DockerTools_ImagePrune_RequiresConfirmation(t *testing.T) {
+	mgr, regs := newToolsUnderTest(t)
+	mgr.AddImage(&ImageDetail{Image: Image{ID: "sha256:dangling", Size: 10, Dangling: true}})
+	mgr.AddImage(&ImageDetail{Image: Image{ID: "sha256:tagged", RepoTags: []string{"nginx:latest"}, Size: 20}})
+
+	text := callTool(t, regs, "docker_image_prune", map[string]any{})
+	token := extractToken(t, text)
+
+	// A token issued for dangling-only pruning must not authorize pruning all.
+	text = callTool(t, regs, "docker_image_prune", map[string]any{"all": true, "confirmation_token": token})
+	if !strings.Contains(text, "confirmation_token") {
+		t.Fatalf("expected a fresh confirmation prompt, got:\n%s", text)
+	}
+
+	token = extractToken(t, callTool(t, regs, "docker_image_prune", map[string]any{}))
+	text = callTool(t, regs, "docker_image_prune", map[string]any{"confirmation_token": token})
+	if !strings.Contains(text, "sha256:dangling") || strings.Contains(text, "sha256:tagged") {
+		t.Errorf("prune result = %s, want only the dangling image removed", text)
+	}
+}
+
+func Test_DockerTools_VolumePrune_KeepsFilteredVolumes(t *testing.T) {
+	mgr := newPopulatedMock(t)
+	mgr.AddVolume(&VolumeDetail{Volume: Volume{Name: "orphan"}})
+	mgr.AddVolume(&VolumeDetail{Volume: Volume{Name: "protected-db"}})
+
+	confirm := safety.NewConfirmationTracker(DestructiveTools)
+	regs := DockerTools(mgr, safety.NewFilter(nil, []string{"protected-*"}), nil, nil, confirm, nil)
+
+	text := callTool(t, regs, "docker_volume_prune", map[string]any{})
+	if strings.Contains(text, "protected-db") {
+		t.Errorf("confirmation prompt should not list filtered volumes:\n%s", text)
+	}
+	token := extractToken(t, text)
+
+	callTool(t, regs, "docker_volume_prune", map[string]any{"confirmation_token": token})
+
+	if _, err := mgr.InspectVolume(context.Background(), "orphan"); err == nil {
+		t.Error("orphan volume should have been removed")
+	}
+	if _, err := mgr.InspectVolume(context.Background(), "protected-db"); err != nil {
+		t.Errorf("protected volume should have been kept: %v", err)
+	}
+}
+
+func Test_DockerTools_VolumePrune_BindsPromptedVolumes(t *testing.T) {
+	mgr, regs := newToolsUnderTest(t)
+	mgr.AddVolume(&VolumeDetail{Volume: Volume{Name: "orphan"}})
+
+	token := extractToken(t, callTool(t, regs, "docker_volume_prune", map[string]any{}))
+
+	// A volume that became unused after the prompt was shown is not covered
+	// by the token.
+	mgr.AddVolume(&VolumeDetail{Volume: Volume{Name: "late"}})
+	text := callTool(t, regs, "docker_volume_prune", map[string]any{"confirmation_token": token})
+	if !strings.Contains(text, "confirmation_token") || !strings.Contains(text, "late") {
+		t.Fatalf("expected a fresh prompt listing the new volume, got:\n%s", text)
+	}
+	if _, err := mgr.InspectVolume(context.Background(), "late"); err != nil {
+		t.Errorf("volume not shown in the prompt was removed: %v", err)
+	}
+
+	token = extractToken(t, text)
+	text = callTool(t, regs, "docker_volume_prune", map[string]any{"confirmation_token": token})
+	if !strings.Contains(text, "orphan") || !strings.Contains(text, "late") {
+		t.Errorf("prune result = %s, want both volumes removed", text)
+	}
+}
+
+func Test_DockerTools_Create_AuditOmitsEnvValues(t *testing.T) {
+	mgr := newPopulatedMock(t)
+	var buf bytes.Buffer
+	confirm := safety.NewConfirmationTracker(DestructiveTools)
+	regs := DockerTools(mgr, safety.NewFilter(nil, nil), nil, nil, confirm, safety.NewAuditLogger(&buf))
+
+	args := map[string]any{"name": "db", "image": "postgres", "env": []any{"POSTGRES_PASSWORD=hunter2"}}
+	args["confirmation_token"] = extractToken(t, callTool(t, regs, "docker_create", args))
+
+	// A token for other env values does not confirm the call.
+	changed := maps.Clone(args)
+	changed["env"] = []any{"POSTGRES_PASSWORD=other"}
+	if text := callTool(t, regs, "docker_create", changed); !strings.Contains(text, "confirmation_token") {
+		t.Fatalf("token confirmed different env values:\n%s", text)
+	}
+	args["confirmation_token"] = extractToken(t, callTool(t, regs, "docker_create", args))
+	if text := callTool(t, regs, "docker_create", args); !strings.Contains(text, "container created") {
+		t.Fatalf("docker_create = %s", text)
+	}
+
+	if log := buf.String(); strings.Contains(log, "hunter2") || !strings.Contains(log, "POSTGRES_PASSWORD") {
+		t.Errorf("audit log should name the variable without its value:\n%s", log)
+	}
+}
+
+func Test_DockerTools_CreatePolicyDeniesBeforeConfirmation(t *testing.T) {
+	_, regs := newToolsUnderTest(t)
+
+	text := callTool(t, regs, "docker_create", map[string]any{
+		"name":       "rogue",
+		"image":      "alpine",
+		"privileged": true,
+	})
+	if !strings.Contains(text, "privileged containers are not allowed") {
+		t.Errorf("expected policy denial, got:\n%s", text)
+	}
+	if strings.Contains(text, "confirmation_token") {
+		t.Error("a denied request must not issue a confirmation token")
+	}
+
+	text = callTool(t, regs, "docker_create", map[string]any{
+		"name":           "web",
+		"image":          "nginx",
+		"ports":          []any{"8080:80"},
+		"restart_policy": "unless-stopped",
+	})
+	token := extractToken(t, text)
+	text = callTool(t, regs, "docker_create", map[string]any{
+		"name":               "web",
+		"image":              "nginx",
+		"ports":              []any{"8080:80"},
+		"restart_policy":     "unless-stopped",
+		"confirmation_token": token,
+	})
+	if !strings.Contains(text, "container created") {
+		t.Errorf("expected creation after confirmation, got:\n%s", text)
+	}
+}
+
+func Test_DockerTools_CreateChecksBindsAndNetworkContainer(t *testing.T) {
+	mgr := newPopulatedMock(t)
+	policy := &CreatePolicy{AllowedBindPaths: []string{"/mnt/user"}}
+	confirm := safety.NewConfirmationTracker(DestructiveTools)
+	regs := DockerTools(mgr, safety.NewFilter(nil, []string{"sonarr"}), policy, nil, confirm, nil)
+
+	tests := []struct {
+		name string
+		args map[string]any
+		want string
+	}{
+		{"docker socket", map[string]any{"volumes": []any{"/var/run/docker.sock:/var/run/docker.sock"}}, "/var/run/docker.sock is not allowed"},
+		{"host root", map[string]any{"volumes": []any{"/:/host"}}, "host path / is not allowed"},
+		{"climbing out", map[string]any{"volumes": []any{"/mnt/user/../../etc:/etc"}}, "not allowed by policy"},
+		{"hidden container by name", map[string]any{"network": "container:sonarr"}, `network of container "sonarr" is not allowed`},
+		{"hidden container by id", map[string]any{"network": "container:def456"}, `network of container "def456" is not allowed`},
+		{"allowed share", map[string]any{"volumes": []any{"/mnt/user/appdata/web:/config", "webdata:/data"}}, "confirmation_token"},
+		{"visible container", map[string]any{"network": "container:abc123"}, "confirmation_token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := map[string]any{"name": "web", "image": "nginx"}
+			maps.Copy(args, tt.args)
+			if text := callTool(t, regs, "docker_create", args); !strings.Contains(text, tt.want) {
+				t.Errorf("docker_create = %s, want %q", text, tt.want)
+			}
+		})
+	}
+}
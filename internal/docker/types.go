@@ -3,6 +3,7 @@ package docker
 
 import (
 	"context"
+	"regexp"
 	"time"
 )
 
@@ -10,20 +11,58 @@ import (
 type Container struct {
 	ID      string
 	Name    string
-	Image   string
+	Image   string // as configured, e.g. nginx:latest
+	ImageID string // ID of the image the container runs, e.g. sha256:...
 	State   string // "running", "exited", "paused", etc.
 	Status  string // human-readable status like "Up 2 hours"
+	Health  string // "healthy", "unhealthy", "starting", or empty without a healthcheck
 	Created time.Time
 }
 
 // ContainerDetail holds the full details of a Docker container.
 type ContainerDetail struct {
 	Container
+	RuntimeState
 	Config          ContainerConfig
 	NetworkSettings NetworkInfo
 	Mounts          []Mount
 }
 
+// RuntimeState describes how a container has been running: its last exit,
+// restarts and healthcheck results.
+type RuntimeState struct {
+	ExitCode      int
+	Error         string // error from the daemon's last attempt to start the container
+	OOMKilled     bool   // the last exit was caused by the kernel OOM killer
+	StartedAt     time.Time
+	FinishedAt    time.Time
+	RestartCount  int // restarts by the restart policy since the container was started
+	RestartPolicy RestartPolicy
+	Healthcheck   *HealthState // nil when the container has no healthcheck
+}
+
+// HealthState is the state of a container's healthcheck.
+type HealthState struct {
+	Status        string // "healthy", "unhealthy" or "starting"
+	FailingStreak int
+	Log           []HealthLogEntry // most recent probes, oldest first
+}
+
+// HealthLogEntry is the result of a single healthcheck probe.
+type HealthLogEntry struct {
+	Start    time.Time
+	End      time.Time
+	ExitCode int
+	Output   string
+}
+
+// ContainerSummary is a list entry with the runtime state of the container
+// included.
+type ContainerSummary struct {
+	Container
+	RuntimeState
+}
+
 // ContainerConfig holds configuration details for a container.
 type ContainerConfig struct {
 	Env    []string
@@ -33,35 +72,132 @@ type ContainerConfig struct {
 
 // NetworkInfo describes a container's network settings.
 type NetworkInfo struct {
-	IPAddress string
-	Ports     map[string]string // "80/tcp" -> "0.0.0.0:8080"
+	IPAddress    string
+	Ports        map[string]string // "80/tcp" -> "0.0.0.0:8080"
+	PortBindings []PortMapping     // configured host ports, kept while the container is stopped
+	StaticIPs    map[string]string // network name -> configured static IPv4 address
 }
 
 // Mount describes a bind mount or volume attached to a container.
 type Mount struct {
+	Type        string // "bind", "volume", "tmpfs", ...
+	Name        string // volume name; empty for bind mounts
 	Source      string
 	Destination string
 	ReadOnly    bool
 }
 
+// LogOptions controls which log lines GetContainerLogs returns.
+type LogOptions struct {
+	Tail       int            // number of lines from the end of the logs (0 = all)
+	Since      time.Time      // only lines at or after this time (zero = no bound)
+	Until      time.Time      // only lines before this time (zero = no bound)
+	Timestamps bool           // include the Docker timestamp for each line
+	Stdout     bool           // include stdout lines
+	Stderr     bool           // include stderr lines
+	Grep       *regexp.Regexp // only lines matching this pattern (nil = all)
+	MaxBytes   int            // keep at most this many bytes, newest first (0 = unlimited)
+}
+
+// LogLine is a single line of container output.
+type LogLine struct {
+	Stream    string // "stdout" or "stderr"; empty for TTY containers
+	Timestamp string // RFC3339Nano timestamp, set only when requested
+	Text      string
+}
+
+// ContainerLogs holds the result of a log query.
+type ContainerLogs struct {
+	Lines     []LogLine
+	TTY       bool // the container runs with a TTY, so streams are not separated
+	Truncated bool // older lines were dropped to honour LogOptions.MaxBytes
+}
+
+// ExecOptions controls a command run inside a container by ExecContainer.
+type ExecOptions struct {
+	Cmd            []string
+	Env            []string      // extra KEY=value environment variables
+	Timeout        time.Duration // stop waiting for output after this long (0 = no limit)
+	MaxOutputBytes int           // keep at most this many bytes of output, newest first (0 = unlimited)
+}
+
+// ExecResult holds the outcome of ExecContainer.
+type ExecResult struct {
+	ExitCode  int // -1 if the command did not finish
+	Stdout    string
+	Stderr    string
+	Truncated bool // older output was dropped to honour ExecOptions.MaxOutputBytes
+	TimedOut  bool // the timeout expired; the command may still be running
+}
+
 // ContainerCreateConfig holds parameters for creating a new container.
 type ContainerCreateConfig struct {
-	Name    string
-	Image   string
-	Env     []string
-	Cmd     []string
-	Labels  map[string]string
-	Ports   map[string]string // container_port -> host_port
-	Volumes map[string]string // host_path -> container_path
+	Name         string
+	Image        string
+	Env          []string
+	Cmd          []string
+	Entrypoint   []string
+	Labels       map[string]string
+	Ports        map[string]string // container_port -> host_port
+	PortBindings []PortMapping
+	Volumes      map[string]string // host_path -> container_path
+	Binds        []string          // "host_path:container_path[:ro]" or "volume:container_path"
+
+	RestartPolicy RestartPolicy
+	NetworkMode   string   // bridge, host, none, container:<id>, or a network name such as br0
+	IPv4Address   string   // static IP on NetworkMode; user-defined networks only
+	Aliases       []string // extra DNS names on NetworkMode; user-defined networks only
+	ExtraHosts    []string
+
+	Devices    []DeviceMapping
+	CapAdd     []string
+	CapDrop    []string
+	Privileged bool
+
+	MemoryLimit int64   // bytes; 0 = unlimited
+	CPUs        float64 // fractional CPUs; 0 = unlimited
+	CPUSet      string  // e.g. "0-3,8"
+}
+
+// PortMapping publishes a container port on the host.
+type PortMapping struct {
+	ContainerPort int
+	Protocol      string // tcp (default), udp or sctp
+	HostIP        string // empty = all interfaces
+	HostPort      int    // 0 = daemon-assigned
+}
+
+// DeviceMapping exposes a host device inside a container.
+type DeviceMapping struct {
+	PathOnHost        string
+	PathInContainer   string // defaults to PathOnHost
+	CgroupPermissions string // combination of r, w, m; defaults to rwm
+}
+
+// RestartPolicy controls when Docker restarts a container.
+type RestartPolicy struct {
+	Name              string // no, always, unless-stopped, on-failure
+	MaximumRetryCount int    // on-failure only
 }
 
 // ContainerStats holds runtime resource usage statistics for a container.
+// Network and block I/O byte counts are cumulative since the container
+// started.
 type ContainerStats struct {
-	CPUPercent     float64
-	MemoryUsage    uint64
-	MemoryLimit    uint64
-	NetworkRxBytes uint64
-	NetworkTxBytes uint64
+	CPUPercent      float64
+	MemoryUsage     uint64 // excluding reclaimable page cache
+	MemoryLimit     uint64
+	NetworkRxBytes  uint64
+	NetworkTxBytes  uint64
+	BlockReadBytes  uint64
+	BlockWriteBytes uint64
+	PIDs            uint64
+
+	// Cumulative CPU counters, so usage can be measured over a longer
+	// interval than the daemon's own sampling period.
+	CPUTotalUsage  uint64 // container CPU time, ns
+	SystemCPUUsage uint64 // host CPU time, ns
+	OnlineCPUs     int
 }
 
 // Network represents a Docker network summary.
@@ -76,15 +212,109 @@ type Network struct {
 type NetworkDetail struct {
 	Network
 	Containers []string // container IDs
-	Subnet     string
-	Gateway    string
+	Endpoints  []NetworkEndpoint
+	Subnet     string // of the first IPAM config
+	Gateway    string // of the first IPAM config
+	IPAM       []IPAMConfig
+	EnableIPv6 bool
+	Internal   bool
+	Options    map[string]string // driver options, e.g. parent for macvlan
+	Labels     map[string]string
+}
+
+// IPAMConfig is one address pool of a network.
+type IPAMConfig struct {
+	Subnet       string
+	Gateway      string
+	IPRange      string            // part of Subnet that addresses are allocated from
+	AuxAddresses map[string]string // host name -> address the daemon must not allocate
+}
+
+// NetworkEndpoint is a running container's attachment to a network.
+// Addresses are without the prefix length.
+type NetworkEndpoint struct {
+	ContainerID string
+	Name        string
+	IPv4Address string
+	IPv6Address string
+	MacAddress  string
 }
 
 // NetworkCreateConfig holds parameters for creating a new Docker network.
 type NetworkCreateConfig struct {
-	Name   string
-	Driver string
-	Subnet string
+	Name         string
+	Driver       string
+	Subnet       string
+	Gateway      string            // default: chosen by the daemon
+	IPRange      string            // part of Subnet to allocate container addresses from
+	AuxAddresses map[string]string // host name -> address the daemon must not allocate
+	IPv6Subnet   string            // enables IPv6
+	IPv6Gateway  string
+	Parent       string            // host interface for macvlan and ipvlan, e.g. eth0 or br0.10
+	Options      map[string]string // other driver options, e.g. macvlan_mode
+	Internal     bool              // no external connectivity
+	Labels       map[string]string
+}
+
+// NetworkConnectOptions configures a container's endpoint on a network.
+type NetworkConnectOptions struct {
+	IPv4Address string   // static address; user-defined networks only
+	IPv6Address string   // static address; user-defined networks only
+	Aliases     []string // extra DNS names; user-defined networks only
+}
+
+// Image represents a Docker image summary.
+type Image struct {
+	ID         string
+	RepoTags   []string
+	Created    time.Time
+	Size       int64
+	Containers int64 // containers using the image; -1 if the daemon did not compute it
+	Dangling   bool  // untagged and not referenced by any tagged image
+}
+
+// ImageDetail holds the full details of a Docker image.
+type ImageDetail struct {
+	Image
+	RepoDigests  []string
+	Architecture string
+	OS           string
+	Author       string
+	Config       ImageConfig
+}
+
+// ImageConfig holds the default container configuration baked into an image.
+type ImageConfig struct {
+	Env          []string
+	Cmd          []string
+	Entrypoint   []string
+	WorkingDir   string
+	ExposedPorts []string
+	Volumes      []string
+	Labels       map[string]string
+}
+
+// ImageHistoryEntry describes one layer in an image's history.
+type ImageHistoryEntry struct {
+	ID        string
+	Created   time.Time
+	CreatedBy string
+	Size      int64
+	Tags      []string
+	Comment   string
+}
+
+// ImageRemoveResult lists the references untagged and the layers deleted by
+// an image removal.
+type ImageRemoveResult struct {
+	Untagged []string
+	Deleted  []string
+}
+
+// ImagePruneReport summarises the result of pruning unused images.
+type ImagePruneReport struct {
+	ImagesDeleted  []string
+	SpaceReclaimed uint64
 }
 
 // ContainerManager defines operations for managing Docker containers.
@@ -95,10 +325,15 @@ type ContainerManager interface {
 	StopContainer(ctx context.Context, id string, timeout int) error
 	RestartContainer(ctx context.Context, id string, timeout int) error
 	RemoveContainer(ctx context.Context, id string, force bool) error
+	RenameContainer(ctx context.Context, id, name string) error
 	CreateContainer(ctx context.Context, config ContainerCreateConfig) (string, error)
 	PullImage(ctx context.Context, image string) error
+	PullImageWithProgress(ctx context.Context, image string, onProgress func(PullProgress)) (*PullResult, error)
 	GetLogs(ctx context.Context, id string, tail int) (string, error)
+	GetContainerLogs(ctx context.Context, id string, opts LogOptions) (*ContainerLogs, error)
 	GetStats(ctx context.Context, id string) (*ContainerStats, error)
+	UpdateContainer(ctx context.Context, id string, opts UpdateOptions) (*UpdateResult, error)
+	ExecContainer(ctx context.Context, id string, opts ExecOptions) (*ExecResult, error)
 }
 
 // NetworkManager defines operations for managing Docker networks.
@@ -107,13 +342,171 @@ type NetworkManager interface {
 	InspectNetwork(ctx context.Context, id string) (*NetworkDetail, error)
 	CreateNetwork(ctx context.Context, config NetworkCreateConfig) (string, error)
 	RemoveNetwork(ctx context.Context, id string) error
-	ConnectNetwork(ctx context.Context, networkID, containerID string) error
+	ConnectNetwork(ctx context.Context, networkID, containerID string, opts NetworkConnectOptions) error
 	DisconnectNetwork(ctx context.Context, networkID, containerID string) error
 }
 
-// DockerManager combines ContainerManager and NetworkManager.
+// UpdateOptions controls how UpdateContainer recreates a container.
+type UpdateOptions struct {
+	Force         bool          // recreate even if the pulled image is unchanged
+	StopTimeout   int           // seconds to wait when stopping the old container
+	HealthTimeout time.Duration // how long to wait for the new container to become healthy (0 = only check that it started)
+}
+
+// UpdateResult describes the outcome of UpdateContainer. Steps records each
+// action taken, including any rollback, in order.
+type UpdateResult struct {
+	Name       string
+	Image      string
+	OldID      string
+	NewID      string
+	OldImageID string
+	NewImageID string
+	Updated    bool // false if the image was unchanged and Force was not set
+	RolledBack bool // true if the update failed and the previous container was restored
+	Steps      []string
+}
+
+// Volume represents a Docker volume summary.
+type Volume struct {
+	Name       string
+	Driver     string
+	Mountpoint string
+	Scope      string
+	Labels     map[string]string
+	CreatedAt  time.Time
+}
+
+// VolumeDetail holds the full details of a Docker volume, including the
+// containers that mount it.
+type VolumeDetail struct {
+	Volume
+	Options    map[string]string
+	Containers []string // names of containers (running or stopped) using the volume
+}
+
+// VolumeCreateConfig holds parameters for creating a new Docker volume.
+type VolumeCreateConfig struct {
+	Name    string
+	Driver  string
+	Labels  map[string]string
+	Options map[string]string
+}
+
+// VolumePruneReport summarises the result of pruning unused volumes.
+type VolumePruneReport struct {
+	VolumesDeleted []string
+	SpaceReclaimed uint64
+}
+
+// DiskUsage is the daemon's disk usage, as reported by /system/df. Sizes are
+// in bytes; -1 means the daemon did not compute the size.
+type DiskUsage struct {
+	LayersSize int64 // all image layers, counting shared layers once
+	Images     []ImageDiskUsage
+	Containers []ContainerDiskUsage
+	Volumes    []VolumeDiskUsage
+	BuildCache []BuildCacheDiskUsage
+}
+
+// ImageDiskUsage is the disk usage of one image.
+type ImageDiskUsage struct {
+	ID         string
+	Tags       []string
+	Size       int64
+	SharedSize int64 // bytes in layers shared with other images
+	Containers int   // containers using the image
+}
+
+// ContainerDiskUsage is the disk usage of one container.
+type ContainerDiskUsage struct {
+	ID         string
+	Name       string
+	Image      string
+	State      string
+	SizeRw     int64 // writable layer
+	SizeRootFs int64 // writable layer plus image
+}
+
+// VolumeDiskUsage is the disk usage of one volume.
+type VolumeDiskUsage struct {
+	Name     string
+	Driver   string
+	Size     int64
+	RefCount int // containers using the volume
+}
+
+// BuildCacheDiskUsage is the disk usage of one build cache record.
+type BuildCacheDiskUsage struct {
+	ID     string
+	Type   string
+	Size   int64
+	InUse  bool
+	Shared bool
+}
+
+// ContainerFileInfo describes a file or directory inside a container.
+type ContainerFileInfo struct {
+	Name       string
+	Size       int64
+	Mode       string // permission string, e.g. "-rw-r--r--"
+	ModTime    time.Time
+	IsDir      bool
+	LinkTarget string // set for symbolic links
+}
+
+// ContainerFile is a regular file read from a container.
+type ContainerFile struct {
+	ContainerFileInfo
+	Path    string
+	Content string
+}
+
+// ContainerDirListing lists the direct children of a directory in a container.
+type ContainerDirListing struct {
+	Path      string
+	Entries   []ContainerFileInfo
+	Truncated bool // the listing stopped early; more entries exist
+}
+
+// ImageManager defines operations for managing Docker images.
+type ImageManager interface {
+	ListImages(ctx context.Context, all bool) ([]Image, error)
+	InspectImage(ctx context.Context, id string) (*ImageDetail, error)
+	ImageHistory(ctx context.Context, id string) ([]ImageHistoryEntry, error)
+	RemoveImage(ctx context.Context, id string, force bool) (*ImageRemoveResult, error)
+	PruneImages(ctx context.Context, all bool) (*ImagePruneReport, error)
+}
+
+// VolumeManager defines operations for managing Docker volumes.
+type VolumeManager interface {
+	ListVolumes(ctx context.Context, danglingOnly bool) ([]Volume, error)
+	InspectVolume(ctx context.Context, name string) (*VolumeDetail, error)
+	CreateVolume(ctx context.Context, config VolumeCreateConfig) (*Volume, error)
+	RemoveVolume(ctx context.Context, name string, force bool) error
+	PruneVolumes(ctx context.Context) (*VolumePruneReport, error)
+}
+
+// FileManager defines operations on files inside containers.
+type FileManager interface {
+	ReadContainerFile(ctx context.Context, id, path string, maxBytes int64) (*ContainerFile, error)
+	ListContainerFiles(ctx context.Context, id, dir string) (*ContainerDirListing, error)
+	WriteContainerFile(ctx context.Context, id, path string, content []byte) error
+}
+
+// SystemManager defines daemon-wide queries.
+type SystemManager interface {
+	DiskUsage(ctx context.Context) (*DiskUsage, error)
+}
+
+// DockerManager combines the container, network, image, volume, file and
+// system managers.
 // Existing code that depends on DockerManager continues to compile without changes.
 type DockerManager interface {
 	ContainerManager
 	NetworkManager
+	ImageManager
+	VolumeManager
+	FileManager
+	SystemManager
 }
@@ -0,0 +1,482 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Container update (pull + recreate + rollback)
+// ---------------------------------------------------------------------------
+
+// updatePollInterval is how often UpdateContainer polls the new container
+// while waiting for it to become healthy.
+var updatePollInterval = time.Second
+
+// dockerUpdateInspect is the subset of /containers/{id}/json needed to
+// recreate a container. Config and HostConfig are kept as raw JSON so every
+// setting survives the round trip, including ones this package does not model.
+type dockerUpdateInspect struct {
+	ID    string `json:"Id"`
+	Name  string `json:"Name"`
+	Image string `json:"Image"`
+	State struct {
+		Running  bool   `json:"Running"`
+		Status   string `json:"Status"`
+		ExitCode int    `json:"ExitCode"`
+		Health   *struct {
+			Status string `json:"Status"`
+		} `json:"Health"`
+	} `json:"State"`
+	Config          map[string]json.RawMessage `json:"Config"`
+	HostConfig      json.RawMessage            `json:"HostConfig"`
+	NetworkSettings struct {
+		Networks map[string]json.RawMessage `json:"Networks"`
+	} `json:"NetworkSettings"`
+}
+
+// updateEndpoint is the user-configurable part of a network endpoint. Runtime
+// fields such as EndpointID, IPAddress and Gateway are dropped so the daemon
+// assigns fresh ones, while static IPs survive through IPAMConfig.
+type updateEndpoint struct {
+	IPAMConfig json.RawMessage   `json:"IPAMConfig,omitempty"`
+	Links      []string          `json:"Links,omitempty"`
+	Aliases    []string          `json:"Aliases,omitempty"`
+	DriverOpts map[string]string `json:"DriverOpts,omitempty"`
+}
+
+// inspectForUpdate fetches the full inspect document of a container.
+func (m *DockerClientManager) inspectForUpdate(ctx context.Context, id string) (*dockerUpdateInspect, error) {
+	if id == "" {
+		return nil, fmt.Errorf("container not found: %s", id)
+	}
+	resp, err := m.doRequest(ctx, http.MethodGet, "/containers/"+id+"/json", nil)
+	if err != nil {
+		return nil, fmt.Errorf("docker: inspect container: %w", err)
+	}
+	body, err := readBody(resp)
+	if err != nil {
+		return nil, fmt.Errorf("docker: inspect container: %w", err)
+	}
+	if err := checkAPIError(resp.StatusCode, body, fmt.Sprintf("container not found: %s", id)); err != nil {
+		return nil, fmt.Errorf("docker: inspect container: %w", err)
+	}
+	var raw dockerUpdateInspect
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("docker: decode container inspect: %w", err)
+	}
+	return &raw, nil
+}
+
+// UpdateContainer pulls the image a container was created from and, if the
+// image changed (or opts.Force is set), recreates the container with its
+// original Config, HostConfig and network endpoints. Settings the old image
+// supplied are left for the new image to supply. The new container is
+// started only if the old one was running. The old container is stopped and
+// renamed aside rather than removed, so that if the new container cannot be
+// created, started, or does not become healthy, it is restored under its
+// original name. The old container is removed only after the new one is up.
+//
+// On failure the returned result is still populated so callers can report
+// which steps ran.
+func (m *DockerClientManager) UpdateContainer(ctx context.Context, id string, opts UpdateOptions) (*UpdateResult, error) {
+	old, err := m.inspectForUpdate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var imageRef string
+	if err := json.Unmarshal(old.Config["Image"], &imageRef); err != nil || imageRef == "" {
+		return nil, fmt.Errorf("docker: container %s has no image reference", id)
+	}
+
+	name := strings.TrimPrefix(old.Name, "/")
+	result := &UpdateResult{
+		Name:       name,
+		Image:      imageRef,
+		OldID:      old.ID,
+		OldImageID: old.Image,
+	}
+	step := func(format string, args ...any) {
+		result.Steps = append(result.Steps, fmt.Sprintf(format, args...))
+	}
+
+	if err := m.PullImage(ctx, imageRef); err != nil {
+		return result, err
+	}
+	step("pulled %s", imageRef)
+
+	img, err := m.InspectImage(ctx, imageRef)
+	if err != nil {
+		return result, err
+	}
+	result.NewImageID = img.ID
+
+	if img.ID == old.Image && !opts.Force {
+		step("image unchanged; container not recreated")
+		return result, nil
+	}
+
+	// The old image is still present: the old container holds on to it.
+	oldImg, err := m.InspectImage(ctx, old.Image)
+	if err != nil {
+		return result, err
+	}
+	createBody, extraNetworks, err := buildRecreateRequest(old, oldImg.Config)
+	if err != nil {
+		return result, err
+	}
+
+	aside, err := setAside(ctx, m, old.ID, name, old.State.Running, opts.StopTimeout, step)
+	if err != nil {
+		return result, err
+	}
+
+	newID, err := m.createRaw(ctx, name, createBody)
+	if err == nil {
+		result.NewID = newID
+		step("created new container %s", shortID(newID))
+		err = m.connectEndpoints(ctx, newID, extraNetworks)
+	}
+	if err == nil && old.State.Running {
+		err = m.StartContainer(ctx, newID)
+		if err == nil {
+			step("started new container")
+			err = m.waitHealthy(ctx, newID, opts.HealthTimeout)
+		}
+	}
+	if err != nil {
+		step("update failed: %v", err)
+		if rbErr := aside.restore(ctx, newID); rbErr != nil {
+			return result, fmt.Errorf("docker: update failed: %w; rollback failed: %v", err, rbErr)
+		}
+		result.RolledBack = true
+		return result, fmt.Errorf("docker: update failed and was rolled back: %w", err)
+	}
+	result.Updated = true
+
+	aside.remove(ctx)
+	return result, nil
+}
+
+// asideContainer is a container stopped and renamed out of the way of its
+// replacement. It is removed once the replacement is up, or restored if the
+// replacement fails.
+type asideContainer struct {
+	mgr        DockerManager
+	id         string
+	name       string // the original name, now held by the replacement
+	backupName string
+	wasRunning bool
+	step       func(string, ...any)
+}
+
+// setAside stops the container id if it is running and renames it from name
+// to a backup name, freeing the name for a replacement. If the rename fails,
+// the container is restarted. Each action is reported through step.
+func setAside(ctx context.Context, mgr DockerManager, id, name string, running bool, stopTimeout int, step func(string, ...any)) (*asideContainer, error) {
+	if running {
+		if err := mgr.StopContainer(ctx, id, stopTimeout); err != nil {
+			return nil, err
+		}
+		step("stopped old container %s", shortID(id))
+	}
+	backupName := fmt.Sprintf("%s_old_%d", name, time.Now().Unix())
+	if err := mgr.RenameContainer(ctx, id, backupName); err != nil {
+		if running {
+			if startErr := mgr.StartContainer(context.WithoutCancel(ctx), id); startErr == nil {
+				step("restarted old container")
+			}
+		}
+		return nil, err
+	}
+	step("renamed old container to %s", backupName)
+	return &asideContainer{mgr: mgr, id: id, name: name, backupName: backupName, wasRunning: running, step: step}, nil
+}
+
+// restore removes the partially created replacement newID (if any), gives
+// the old container its name back, and restarts it if it was running. It
+// runs even if ctx has been cancelled.
+func (a *asideContainer) restore(ctx context.Context, newID string) error {
+	ctx = context.WithoutCancel(ctx)
+	var errs []error
+	if newID != "" {
+		if err := a.mgr.RemoveContainer(ctx, newID, true); err != nil {
+			errs = append(errs, err)
+		} else {
+			a.step("rollback: removed new container")
+		}
+	}
+	if err := a.mgr.RenameContainer(ctx, a.id, a.name); err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+	a.step("rollback: restored name %s", a.name)
+	if a.wasRunning {
+		if err := a.mgr.StartContainer(ctx, a.id); err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+		a.step("rollback: restarted old container")
+	}
+	return errors.Join(errs...)
+}
+
+// remove deletes the old container once its replacement is up. A failure
+// only leaves the backup behind, so it is reported as a warning.
+func (a *asideContainer) remove(ctx context.Context) {
+	if err := a.mgr.RemoveContainer(ctx, a.id, true); err != nil {
+		a.step("warning: could not remove old container %s: %v", a.backupName, err)
+	} else {
+		a.step("removed old container")
+	}
+}
+
+// buildRecreateRequest turns an inspect document into a /containers/create
+// body. Docker only accepts one endpoint at create time on API v1.41, so any
+// additional networks are returned separately to be connected afterwards.
+//
+// Docker copies an image's Env, Cmd, Entrypoint, WorkingDir and Labels into
+// the container's Config, so those still equal to the old image's defaults
+// (imgCfg) are dropped, letting the new image supply its own.
+func buildRecreateRequest(old *dockerUpdateInspect, imgCfg ImageConfig) (map[string]any, map[string]updateEndpoint, error) {
+	body := make(map[string]any, len(old.Config)+2)
+	for k, v := range old.Config {
+		body[k] = v
+	}
+	if err := dropImageDefaults(body, old.Config, imgCfg); err != nil {
+		return nil, nil, err
+	}
+	// A hostname equal to the short container ID was generated by Docker, not
+	// chosen by the user; let the new container get its own.
+	var hostname string
+	if err := json.Unmarshal(old.Config["Hostname"], &hostname); err == nil && hostname == shortID(old.ID) {
+		delete(body, "Hostname")
+	}
+	if len(old.HostConfig) > 0 {
+		body["HostConfig"] = old.HostConfig
+	}
+
+	var hostCfg struct {
+		NetworkMode string `json:"NetworkMode"`
+	}
+	if len(old.HostConfig) > 0 {
+		if err := json.Unmarshal(old.HostConfig, &hostCfg); err != nil {
+			return nil, nil, fmt.Errorf("docker: decode host config: %w", err)
+		}
+	}
+
+	endpoints := make(map[string]updateEndpoint, len(old.NetworkSettings.Networks))
+	for netName, raw := range old.NetworkSettings.Networks {
+		var ep updateEndpoint
+		if err := json.Unmarshal(raw, &ep); err != nil {
+			return nil, nil, fmt.Errorf("docker: decode endpoint %s: %w", netName, err)
+		}
+		if string(ep.IPAMConfig) == "null" {
+			ep.IPAMConfig = nil
+		}
+		ep.Aliases = dropAlias(ep.Aliases, shortID(old.ID))
+		endpoints[netName] = ep
+	}
+
+	mode := hostCfg.NetworkMode
+	if mode == "default" || mode == "" {
+		mode = "bridge"
+	}
+	if mode == "host" || mode == "none" || strings.HasPrefix(mode, "container:") {
+		// These modes have no configurable endpoints.
+		return body, nil, nil
+	}
+
+	primary := mode
+	if _, ok := endpoints[primary]; !ok {
+		names := make([]string, 0, len(endpoints))
+		for n := range endpoints {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return body, nil, nil
+		}
+		primary = names[0]
+	}
+	body["NetworkingConfig"] = map[string]any{
+		"EndpointsConfig": map[string]updateEndpoint{primary: endpoints[primary]},
+	}
+	delete(endpoints, primary)
+	return body, endpoints, nil
+}
+
+// dropImageDefaults removes from body the settings of cfg that came from an
+// image with the config img: Cmd, Entrypoint and WorkingDir when unchanged,
+// and each Env variable and label the image set to the same value.
+func dropImageDefaults(body map[string]any, cfg map[string]json.RawMessage, img ImageConfig) error {
+	var c struct {
+		Env        []string
+		Cmd        []string
+		Entrypoint []string
+		WorkingDir string
+		Labels     map[string]string
+	}
+	raw, err := json.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("docker: encode container config: %w", err)
+	}
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return fmt.Errorf("docker: decode container config: %w", err)
+	}
+
+	if slices.Equal(c.Cmd, img.Cmd) {
+		delete(body, "Cmd")
+	}
+	if slices.Equal(c.Entrypoint, img.Entrypoint) {
+		delete(body, "Entrypoint")
+	}
+	if c.WorkingDir == img.WorkingDir {
+		delete(body, "WorkingDir")
+	}
+	env := slices.DeleteFunc(c.Env, func(v string) bool { return slices.Contains(img.Env, v) })
+	if len(env) == 0 {
+		delete(body, "Env")
+	} else {
+		body["Env"] = env
+	}
+	maps.DeleteFunc(c.Labels, func(k, v string) bool {
+		imgValue, ok := img.Labels[k]
+		return ok && imgValue == v
+	})
+	if len(c.Labels) == 0 {
+		delete(body, "Labels")
+	} else {
+		body["Labels"] = c.Labels
+	}
+	return nil
+}
+
+// createRaw creates a container from a pre-built request body.
+func (m *DockerClientManager) createRaw(ctx context.Context, name string, reqBody map[string]any) (string, error) {
+	bodyData, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("docker: encode create request: %w", err)
+	}
+	path := "/containers/create?name=" + url.QueryEscape(name)
+	resp, err := m.doRequest(ctx, http.MethodPost, path, bytes.NewReader(bodyData))
+	if err != nil {
+		return "", fmt.Errorf("docker: create container: %w", err)
+	}
+	body, err := readBody(resp)
+	if err != nil {
+		return "", err
+	}
+	if err := checkAPIError(resp.StatusCode, body, fmt.Sprintf("image not found: %s", name)); err != nil {
+		return "", fmt.Errorf("docker: create container: %w", err)
+	}
+	var result struct {
+		ID string `json:"Id"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("docker: decode create response: %w", err)
+	}
+	if result.ID == "" {
+		return "", fmt.Errorf("docker: create container returned empty ID")
+	}
+	return result.ID, nil
+}
+
+// connectEndpoints attaches a container to additional networks, preserving
+// each endpoint's static addressing and aliases.
+func (m *DockerClientManager) connectEndpoints(ctx context.Context, containerID string, endpoints map[string]updateEndpoint) error {
+	names := make([]string, 0, len(endpoints))
+	for n := range endpoints {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	for _, netName := range names {
+		bodyData, err := json.Marshal(map[string]any{
+			"Container":      containerID,
+			"EndpointConfig": endpoints[netName],
+		})
+		if err != nil {
+			return fmt.Errorf("docker: encode network connect request: %w", err)
+		}
+		resp, err := m.doRequest(ctx, http.MethodPost, "/networks/"+netName+"/connect", bytes.NewReader(bodyData))
+		if err != nil {
+			return fmt.Errorf("docker: connect network %s: %w", netName, err)
+		}
+		body, err := readBody(resp)
+		if err != nil {
+			return err
+		}
+		if err := checkAPIError(resp.StatusCode, body, fmt.Sprintf("network not found: %s", netName)); err != nil {
+			return fmt.Errorf("docker: connect network %s: %w", netName, err)
+		}
+	}
+	return nil
+}
+
+// waitHealthy checks that a freshly started container is running. If timeout
+// is positive, it keeps polling until the container's healthcheck reports
+// healthy or, for containers without a healthcheck, until it has stayed up for
+// the whole timeout. It fails as soon as the container exits or reports
+// unhealthy.
+func (m *DockerClientManager) waitHealthy(ctx context.Context, id string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		st, err := m.inspectForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !st.State.Running {
+			return fmt.Errorf("new container is %s (exit code %d)", st.State.Status, st.State.ExitCode)
+		}
+		if st.State.Health != nil {
+			switch st.State.Health.Status {
+			case "healthy":
+				return nil
+			case "unhealthy":
+				return fmt.Errorf("new container reported unhealthy")
+			}
+		}
+		if !time.Now().Before(deadline) {
+			if st.State.Health != nil && timeout > 0 {
+				return fmt.Errorf("new container did not become healthy within %s (status %s)", timeout, st.State.Health.Status)
+			}
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(updatePollInterval):
+		}
+	}
+}
+
+// shortID returns the 12-character form of a container ID.
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+// dropAlias returns aliases without alias.
+func dropAlias(aliases []string, alias string) []string {
+	out := aliases[:0:0]
+	for _, a := range aliases {
+		if a != alias {
+			out = append(out, a)
+		}
+	}
+	return out
+}
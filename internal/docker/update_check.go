@@ -0,0 +1,257 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Image update checks
+// ---------------------------------------------------------------------------
+
+// DefaultUpdateCheckTTL is how long a registry's answer for an image is
+// reused before it is asked again.
+const DefaultUpdateCheckTTL = 6 * time.Hour
+
+// updateCheckWorkers bounds the concurrent registry requests of a check.
+const updateCheckWorkers = 4
+
+// Update statuses reported by UpdateChecker.
+const (
+	UpdateStatusUpToDate  = "up_to_date"
+	UpdateStatusAvailable = "update_available"
+	UpdateStatusPinned    = "pinned" // the image is referenced by digest and cannot change
+	UpdateStatusLocal     = "local"  // the image has no registry digest, e.g. it was built locally
+	UpdateStatusError     = "error"
+)
+
+// imageIDPattern matches a bare image ID, which /containers/json reports in
+// place of the image name once the container's tag points elsewhere.
+var imageIDPattern = regexp.MustCompile(`^(sha256:)?[0-9a-f]{12,64}$`)
+
+// ContainerUpdateStatus reports whether a newer image is available for a
+// container.
+type ContainerUpdateStatus struct {
+	ID           string
+	Name         string
+	Image        string
+	Status       string
+	LocalDigest  string
+	RemoteDigest string
+	CheckedAt    time.Time // when the registry was asked; zero if it was not
+	Error        string
+}
+
+// UpdateChecker compares the images containers run with the manifests in
+// their registries, caching each registry answer for a TTL. It is safe for
+// concurrent use.
+type UpdateChecker struct {
+	registry RegistryClient
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]*remoteImage // by full image reference
+}
+
+// remoteImage is what a registry reported for an image reference.
+type remoteImage struct {
+	digest    string
+	platforms []ManifestPlatform // nil until the manifest list is fetched
+	fetched   bool               // the manifest itself has been fetched
+	checkedAt time.Time
+}
+
+// NewUpdateChecker returns an UpdateChecker that asks registry, reusing its
+// answers for ttl (DefaultUpdateCheckTTL if ttl <= 0).
+func NewUpdateChecker(registry RegistryClient, ttl time.Duration) *UpdateChecker {
+	if ttl <= 0 {
+		ttl = DefaultUpdateCheckTTL
+	}
+	return &UpdateChecker{registry: registry, ttl: ttl, now: time.Now, cache: make(map[string]*remoteImage)}
+}
+
+// updateTarget is a container to check with its local image details.
+type updateTarget struct {
+	status   *ContainerUpdateStatus
+	ref      imageReference
+	digests  []string // the local image's digests in ref's repository
+	platform ManifestPlatform
+}
+
+// Check reports for each container whether its registry has a newer image
+// than the one it runs. Nothing is pulled: the digest the local image was
+// pulled with is compared with the registry's current manifest digest.
+// Registry answers younger than the TTL are reused unless refresh is set.
+func (u *UpdateChecker) Check(ctx context.Context, mgr DockerManager, containers []Container, refresh bool) []ContainerUpdateStatus {
+	results := make([]ContainerUpdateStatus, len(containers))
+	byRef := make(map[string][]*updateTarget)
+	for i, c := range containers {
+		results[i] = ContainerUpdateStatus{ID: c.ID, Name: c.Name, Image: c.Image}
+		t, err := u.localTarget(ctx, mgr, c, &results[i])
+		if err != nil {
+			results[i].Status, results[i].Error = UpdateStatusError, err.Error()
+			continue
+		}
+		if t != nil {
+			key := t.ref.String()
+			byRef[key] = append(byRef[key], t)
+		}
+	}
+
+	refs := sortedNames(byRef)
+	work := make(chan string)
+	var wg sync.WaitGroup
+	for range min(updateCheckWorkers, len(refs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ref := range work {
+				u.checkRemote(ctx, ref, byRef[ref], refresh)
+			}
+		}()
+	}
+	for _, ref := range refs {
+		work <- ref
+	}
+	close(work)
+	wg.Wait()
+	return results
+}
+
+// localTarget fills in what can be known about c's image locally. It
+// returns nil when the status is settled without asking the registry.
+func (u *UpdateChecker) localTarget(ctx context.Context, mgr DockerManager, c Container, status *ContainerUpdateStatus) (*updateTarget, error) {
+	if c.ImageID == "" || imageIDPattern.MatchString(c.Image) {
+		detail, err := mgr.InspectContainer(ctx, c.ID)
+		if err != nil {
+			return nil, err
+		}
+		c.Image, c.ImageID = detail.Image, detail.ImageID
+		status.Image = c.Image
+	}
+	ref, err := parseImageReference(c.Image)
+	if err != nil {
+		return nil, err
+	}
+	if ref.Digest != "" {
+		status.Status, status.LocalDigest = UpdateStatusPinned, ref.Digest
+		return nil, nil
+	}
+
+	imageID := c.ImageID
+	if imageID == "" {
+		imageID = c.Image
+	}
+	img, err := mgr.InspectImage(ctx, imageID)
+	if err != nil {
+		return nil, err
+	}
+	t := &updateTarget{status: status, ref: ref, platform: ManifestPlatform{OS: img.OS, Architecture: img.Architecture}}
+	for _, rd := range img.RepoDigests {
+		local, err := parseImageReference(rd)
+		if err == nil && local.Registry == ref.Registry && local.Repository == ref.Repository && local.Digest != "" {
+			t.digests = append(t.digests, local.Digest)
+		}
+	}
+	if len(t.digests) == 0 {
+		status.Status = UpdateStatusLocal
+		return nil, nil
+	}
+	status.LocalDigest = t.digests[0]
+	return t, nil
+}
+
+// checkRemote asks the registry about ref, or reuses a fresh answer, and
+// settles the status of every target running an image of ref.
+func (u *UpdateChecker) checkRemote(ctx context.Context, ref string, targets []*updateTarget, refresh bool) {
+	fail := func(err error) {
+		for _, t := range targets {
+			t.status.Status, t.status.Error = UpdateStatusError, err.Error()
+		}
+	}
+
+	u.mu.Lock()
+	cached, ok := u.cache[ref]
+	u.mu.Unlock()
+
+	var remote *remoteImage
+	if ok && !refresh && u.now().Sub(cached.checkedAt) < u.ttl {
+		cp := *cached // copied: the manifest list may be added below
+		remote = &cp
+	} else {
+		digest, err := u.registry.ManifestDigest(ctx, ref)
+		if err != nil {
+			fail(err)
+			return
+		}
+		remote = &remoteImage{digest: digest, checkedAt: u.now()}
+	}
+
+	// The local image usually records the digest of the manifest list it
+	// was pulled from, which is what the registry reports for the tag. An
+	// image pulled by platform instead records its platform's manifest, so
+	// on a mismatch the list is fetched to compare that too.
+	stale := false
+	for _, t := range targets {
+		if !slices.Contains(t.digests, remote.digest) {
+			stale = true
+		}
+	}
+	if stale && !remote.fetched {
+		m, err := u.registry.Manifest(ctx, ref)
+		if err != nil {
+			fail(err)
+			return
+		}
+		if m.Digest != remote.digest {
+			// The tag moved between the two requests.
+			remote = &remoteImage{digest: m.Digest, checkedAt: u.now()}
+		}
+		remote.platforms, remote.fetched = m.Platforms, true
+	}
+
+	u.mu.Lock()
+	u.cache[ref] = remote
+	u.mu.Unlock()
+
+	for _, t := range targets {
+		t.status.RemoteDigest, t.status.CheckedAt = remote.digest, remote.checkedAt
+		t.status.Status = UpdateStatusAvailable
+		if slices.Contains(t.digests, remote.digest) {
+			t.status.Status = UpdateStatusUpToDate
+			continue
+		}
+		for _, p := range remote.platforms {
+			if p.OS == t.platform.OS && p.Architecture == t.platform.Architecture && slices.Contains(t.digests, p.Digest) {
+				t.status.Status = UpdateStatusUpToDate
+				t.status.LocalDigest = p.Digest
+				break
+			}
+		}
+	}
+}
+
+// SortUpdateStatuses orders statuses with available updates first, then
+// errors, then by name.
+func SortUpdateStatuses(statuses []ContainerUpdateStatus) {
+	rank := map[string]int{UpdateStatusAvailable: 0, UpdateStatusError: 1}
+	order := func(s string) int {
+		if r, ok := rank[s]; ok {
+			return r
+		}
+		return 2
+	}
+	sort.SliceStable(statuses, func(i, j int) bool {
+		if oi, oj := order(statuses[i].Status), order(statuses[j].Status); oi != oj {
+			return oi < oj
+		}
+		return strings.ToLower(statuses[i].Name) < strings.ToLower(statuses[j].Name)
+	})
+}
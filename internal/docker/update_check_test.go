@@ -0,0 +1,398 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+)
+
+// ---------------------------------------------------------------------------
+// Registry stand-in
+// ---------------------------------------------------------------------------
+
+// fakeRegistry is a registry v2 API stand-in that issues bearer tokens like
+// Docker Hub and serves a manifest list per tag.
+type fakeRegistry struct {
+	srv *httptest.Server
+
+	mu        sync.Mutex
+	lists     map[string]string // "repo:tag" -> manifest list digest
+	platforms map[string]string // "repo:tag" -> linux/amd64 manifest digest
+	heads     int
+	gets      int
+	tokens    int
+	basicAuth string // Authorization header of the last token request
+}
+
+func newFakeRegistry(t *testing.T) *fakeRegistry {
+	t.Helper()
+	r := &fakeRegistry{lists: make(map[string]string), platforms: make(map[string]string)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /token", func(w http.ResponseWriter, req *http.Request) {
+		r.mu.Lock()
+		r.tokens++
+		r.basicAuth = req.Header.Get("Authorization")
+		r.mu.Unlock()
+		if req.URL.Query().Get("service") != "fake-registry" || !strings.HasPrefix(req.URL.Query().Get("scope"), "repository:") {
+			http.Error(w, "bad token request", http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, http.StatusOK, `{"token":"tok-`+req.URL.Query().Get("scope")+`","expires_in":300}`)
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, req *http.Request) {
+		r.mu.Lock()
+		r.tokens++
+		r.mu.Unlock()
+		if req.PostFormValue("grant_type") != "refresh_token" || req.PostFormValue("refresh_token") != "refresh-me" {
+			http.Error(w, "bad refresh token", http.StatusUnauthorized)
+			return
+		}
+		writeJSON(w, http.StatusOK, `{"access_token":"tok-`+req.PostFormValue("scope")+`","expires_in":300}`)
+	})
+	mux.HandleFunc("/v2/", func(w http.ResponseWriter, req *http.Request) {
+		repo, tag, ok := strings.Cut(strings.TrimPrefix(req.URL.Path, "/v2/"), "/manifests/")
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		if req.Header.Get("Authorization") != "Bearer tok-repository:"+repo+":pull" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="fake-registry",scope="repository:%s:pull"`, r.srv.URL, repo))
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		r.mu.Lock()
+		list, ok := r.lists[repo+":"+tag]
+		platform := r.platforms[repo+":"+tag]
+		if req.Method == http.MethodHead {
+			r.heads++
+		} else {
+			r.gets++
+		}
+		r.mu.Unlock()
+		if !ok {
+			http.Error(w, `{"errors":[{"code":"MANIFEST_UNKNOWN"}]}`, http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", mediaTypeDockerManifestList)
+		w.Header().Set("Docker-Content-Digest", list)
+		if req.Method == http.MethodHead {
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"mediaType": mediaTypeDockerManifestList,
+			"manifests": []map[string]any{
+				{"digest": "sha256:arm64", "platform": map[string]string{"os": "linux", "architecture": "arm64"}},
+				{"digest": platform, "platform": map[string]string{"os": "linux", "architecture": "amd64"}},
+			},
+		})
+	})
+	r.srv = httptest.NewTLSServer(mux)
+	t.Cleanup(r.srv.Close)
+	return r
+}
+
+// host returns the registry's host, as used in image references.
+func (r *fakeRegistry) host() string {
+	return strings.TrimPrefix(r.srv.URL, "https://")
+}
+
+func (r *fakeRegistry) setTag(repoTag, list, platform string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lists[repoTag], r.platforms[repoTag] = list, platform
+}
+
+func (r *fakeRegistry) counts() (heads, gets, tokens int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.heads, r.gets, r.tokens
+}
+
+// ---------------------------------------------------------------------------
+// HTTPRegistryClient tests
+// ---------------------------------------------------------------------------
+
+func Test_HTTPRegistryClient_TokenAuthAndManifestList(t *testing.T) {
+	reg := newFakeRegistry(t)
+	reg.setTag("owner/app:v2", "sha256:list", "sha256:amd64")
+	creds := NewRegistryCredentials()
+	creds.Add(reg.host(), RegistryAuth{Username: "user", Password: "pass"})
+	client := NewHTTPRegistryClient(reg.srv.Client(), creds)
+	ctx := context.Background()
+	image := reg.host() + "/owner/app:v2"
+
+	digest, err := client.ManifestDigest(ctx, image)
+	if err != nil {
+		t.Fatalf("ManifestDigest: %v", err)
+	}
+	if digest != "sha256:list" {
+		t.Errorf("digest = %q, want sha256:list", digest)
+	}
+	if reg.basicAuth == "" {
+		t.Error("the token request should carry the registry credential")
+	}
+
+	m, err := client.Manifest(ctx, image)
+	if err != nil {
+		t.Fatalf("Manifest: %v", err)
+	}
+	if m.Digest != "sha256:list" || m.MediaType != mediaTypeDockerManifestList || len(m.Platforms) != 2 {
+		t.Fatalf("manifest = %+v", m)
+	}
+	if p := m.Platforms[1]; p.Architecture != "amd64" || p.Digest != "sha256:amd64" {
+		t.Errorf("amd64 platform = %+v", p)
+	}
+
+	// The token is reused for the second request.
+	if _, _, tokens := reg.counts(); tokens != 1 {
+		t.Errorf("token requests = %d, want 1", tokens)
+	}
+}
+
+func Test_HTTPRegistryClient_Errors(t *testing.T) {
+	reg := newFakeRegistry(t)
+	client := NewHTTPRegistryClient(reg.srv.Client(), nil)
+
+	_, err := client.ManifestDigest(context.Background(), reg.host()+"/owner/missing:latest")
+	if err == nil || !strings.Contains(err.Error(), "manifest unknown") {
+		t.Errorf("error = %v, want manifest unknown", err)
+	}
+
+	basic := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="private"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	t.Cleanup(basic.Close)
+	client = NewHTTPRegistryClient(basic.Client(), nil)
+	_, err = client.ManifestDigest(context.Background(), strings.TrimPrefix(basic.URL, "https://")+"/app:latest")
+	if err == nil || !strings.Contains(err.Error(), "requires credentials") {
+		t.Errorf("error = %v, want a request for credentials", err)
+	}
+}
+
+func Test_HTTPRegistryClient_IdentityToken(t *testing.T) {
+	reg := newFakeRegistry(t)
+	reg.setTag("owner/app:v2", "sha256:list", "sha256:amd64")
+	creds := NewRegistryCredentials()
+	creds.Add(reg.host(), RegistryAuth{Username: "<token>", IdentityToken: "refresh-me"})
+	client := NewHTTPRegistryClient(reg.srv.Client(), creds)
+
+	digest, err := client.ManifestDigest(context.Background(), reg.host()+"/owner/app:v2")
+	if err != nil || digest != "sha256:list" {
+		t.Fatalf("ManifestDigest() = %q, %v", digest, err)
+	}
+	if reg.basicAuth != "" {
+		t.Errorf("the identity token went out as basic auth %q", reg.basicAuth)
+	}
+}
+
+// insecureList is an InsecureRegistrySource with a fixed list.
+type insecureList []string
+
+func (l insecureList) InsecureRegistries(context.Context) ([]string, error) { return l, nil }
+
+func Test_HTTPRegistryClient_InsecureRegistry(t *testing.T) {
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Docker-Content-Digest", "sha256:plain")
+		w.Header().Set("Content-Type", mediaTypeDockerManifest)
+	}))
+	t.Cleanup(plain.Close)
+	host := strings.TrimPrefix(plain.URL, "http://")
+	image := host + "/app:latest"
+
+	client := NewHTTPRegistryClient(plain.Client(), nil)
+	if _, err := client.ManifestDigest(context.Background(), image); err == nil {
+		t.Error("ManifestDigest() over plain HTTP succeeded without the registry being insecure")
+	}
+
+	for _, list := range []insecureList{{host}, {"127.0.0.0/8"}} {
+		client.SetInsecureRegistries(list)
+		digest, err := client.ManifestDigest(context.Background(), image)
+		if err != nil || digest != "sha256:plain" {
+			t.Errorf("ManifestDigest(insecure %v) = %q, %v", list, digest, err)
+		}
+	}
+}
+
+func Test_DockerClient_InsecureRegistries(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /info", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, `{"RegistryConfig":{"InsecureRegistryCIDRs":["127.0.0.0/8"],"IndexConfigs":{`+
+			`"docker.io":{"Name":"docker.io","Secure":true},"nas.lan:5000":{"Name":"nas.lan:5000","Secure":false}}}}`)
+	})
+	got, err := newFakeDaemon(t, mux).InsecureRegistries(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"127.0.0.0/8", "nas.lan:5000"}; !slices.Equal(got, want) {
+		t.Errorf("InsecureRegistries() = %v, want %v", got, want)
+	}
+}
+
+func Test_parseAuthChallenge(t *testing.T) {
+	scheme, params := parseAuthChallenge(`Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:a/b:pull,push"`)
+	if scheme != "bearer" {
+		t.Errorf("scheme = %q", scheme)
+	}
+	want := map[string]string{"realm": "https://auth.docker.io/token", "service": "registry.docker.io", "scope": "repository:a/b:pull,push"}
+	for k, v := range want {
+		if params[k] != v {
+			t.Errorf("%s = %q, want %q", k, params[k], v)
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// UpdateChecker tests
+// ---------------------------------------------------------------------------
+
+// newUpdateCheckMock returns a mock with containers covering every update
+// status, running images from reg.
+func newUpdateCheckMock(t *testing.T, reg *fakeRegistry) *MockDockerManager {
+	t.Helper()
+	m := NewMockDockerManager()
+	host := reg.host()
+	add := func(id, name, image, imageID string, repoDigests ...string) {
+		m.AddContainer(&ContainerDetail{Container: Container{ID: id, Name: name, Image: image, ImageID: imageID, State: "running"}})
+		m.AddImage(&ImageDetail{Image: Image{ID: imageID}, RepoDigests: repoDigests, OS: "linux", Architecture: "amd64"})
+	}
+	add("c1", "current", host+"/owner/app:v2", "sha256:img1", host+"/owner/app@sha256:list")
+	add("c2", "outdated", host+"/owner/db:15", "sha256:img2", host+"/owner/db@sha256:old")
+	add("c3", "by-platform", host+"/owner/web:1", "sha256:img3", host+"/owner/web@sha256:web-amd64")
+	add("c4", "pinned", host+"/owner/app@sha256:list", "sha256:img4", host+"/owner/app@sha256:list")
+	add("c5", "built", "my-local-build:latest", "sha256:img5")
+	add("c6", "gone", host+"/owner/missing:1", "sha256:img6", host+"/owner/missing@sha256:x")
+	reg.setTag("owner/app:v2", "sha256:list", "sha256:app-amd64")
+	reg.setTag("owner/db:15", "sha256:db-list", "sha256:db-amd64")
+	reg.setTag("owner/web:1", "sha256:web-list", "sha256:web-amd64")
+	return m
+}
+
+func Test_UpdateChecker_Statuses(t *testing.T) {
+	reg := newFakeRegistry(t)
+	mgr := newUpdateCheckMock(t, reg)
+	checker := NewUpdateChecker(NewHTTPRegistryClient(reg.srv.Client(), nil), time.Hour)
+	containers, _ := mgr.ListContainers(context.Background(), true)
+
+	statuses := checker.Check(context.Background(), mgr, containers, false)
+	got := make(map[string]ContainerUpdateStatus)
+	for _, s := range statuses {
+		got[s.Name] = s
+	}
+	want := map[string]string{
+		"current":     UpdateStatusUpToDate,
+		"outdated":    UpdateStatusAvailable,
+		"by-platform": UpdateStatusUpToDate,
+		"pinned":      UpdateStatusPinned,
+		"built":       UpdateStatusLocal,
+		"gone":        UpdateStatusError,
+	}
+	for name, status := range want {
+		if got[name].Status != status {
+			t.Errorf("%s: status = %q (%s), want %q", name, got[name].Status, got[name].Error, status)
+		}
+	}
+	if s := got["outdated"]; s.LocalDigest != "sha256:old" || s.RemoteDigest != "sha256:db-list" || s.CheckedAt.IsZero() {
+		t.Errorf("outdated = %+v", s)
+	}
+
+	// Only the mismatching images needed their manifest list.
+	if heads, gets, _ := reg.counts(); heads != 4 || gets != 2 {
+		t.Errorf("HEAD/GET requests = %d/%d, want 4/2", heads, gets)
+	}
+}
+
+func Test_UpdateChecker_CacheTTL(t *testing.T) {
+	reg := newFakeRegistry(t)
+	mgr := newUpdateCheckMock(t, reg)
+	checker := NewUpdateChecker(NewHTTPRegistryClient(reg.srv.Client(), nil), time.Hour)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	checker.now = func() time.Time { return now }
+	ctx := context.Background()
+	container, _ := mgr.InspectContainer(ctx, "c2")
+	check := func(refresh bool) ContainerUpdateStatus {
+		return checker.Check(ctx, mgr, []Container{container.Container}, refresh)[0]
+	}
+
+	first := check(false)
+	heads, gets, _ := reg.counts()
+
+	// The image is updated in the registry, but the cached answer is fresh.
+	reg.setTag("owner/db:15", "sha256:old", "sha256:db-amd64")
+	now = now.Add(30 * time.Minute)
+	if s := check(false); s.Status != UpdateStatusAvailable || !s.CheckedAt.Equal(first.CheckedAt) {
+		t.Errorf("cached status = %+v", s)
+	}
+	if h, g, _ := reg.counts(); h != heads || g != gets {
+		t.Errorf("a fresh cached answer should not reach the registry")
+	}
+
+	if s := check(true); s.Status != UpdateStatusUpToDate {
+		t.Errorf("refreshed status = %q, want up to date", s.Status)
+	}
+
+	reg.setTag("owner/db:15", "sha256:newer", "sha256:db-amd64-2")
+	now = now.Add(2 * time.Hour)
+	if s := check(false); s.Status != UpdateStatusAvailable || s.RemoteDigest != "sha256:newer" {
+		t.Errorf("expired status = %+v", s)
+	}
+}
+
+func Test_UpdateChecker_ResolvesImageIDReference(t *testing.T) {
+	reg := newFakeRegistry(t)
+	mgr := newUpdateCheckMock(t, reg)
+	checker := NewUpdateChecker(NewHTTPRegistryClient(reg.srv.Client(), nil), time.Hour)
+
+	// The list reports an image ID once the container's tag has moved.
+	c, _ := mgr.InspectContainer(context.Background(), "c1")
+	listed := c.Container
+	listed.Image = "sha256:0123456789abcdef"
+	s := checker.Check(context.Background(), mgr, []Container{listed}, false)[0]
+	if s.Image != reg.host()+"/owner/app:v2" || s.Status != UpdateStatusUpToDate {
+		t.Errorf("status = %+v", s)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// docker_check_updates tests
+// ---------------------------------------------------------------------------
+
+func Test_DockerCheckUpdates_Tool(t *testing.T) {
+	reg := newFakeRegistry(t)
+	mgr := newUpdateCheckMock(t, reg)
+	checker := NewUpdateChecker(NewHTTPRegistryClient(reg.srv.Client(), nil), time.Hour)
+	filter := safety.NewFilter(nil, []string{"gone"})
+	regs := UpdateCheckTools(mgr, checker, filter, nil)
+
+	var report UpdateCheckReport
+	if err := json.Unmarshal([]byte(callTool(t, regs, "docker_check_updates", nil)), &report); err != nil {
+		t.Fatalf("decode report: %v", err)
+	}
+	if report.Checked != 5 || report.UpdatesAvailable != 1 || report.Errors != 0 {
+		t.Errorf("report = %+v", report)
+	}
+	if report.Containers[0].Name != "outdated" {
+		t.Errorf("first container = %s, want the one with an update", report.Containers[0].Name)
+	}
+	for _, c := range report.Containers {
+		if c.Name == "gone" {
+			t.Error("a denied container was checked")
+		}
+	}
+
+	if err := json.Unmarshal([]byte(callTool(t, regs, "docker_check_updates", map[string]any{"names": []any{"by-*"}})), &report); err != nil {
+		t.Fatalf("decode report: %v", err)
+	}
+	if report.Checked != 1 || report.Containers[0].Name != "by-platform" {
+		t.Errorf("names filter: report = %+v", report)
+	}
+}
@@ -0,0 +1,91 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"context"
+	"time"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+	"github.com/jamesprial/unraid-mcp/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// UpdateCheckReport is the result of docker_check_updates.
+type UpdateCheckReport struct {
+	Checked          int
+	UpdatesAvailable int
+	Errors           int
+	CacheTTL         string
+	Containers       []ContainerUpdateStatus // updates first, then errors, then by name
+}
+
+// UpdateCheckTools returns the docker_check_updates tool registration.
+func UpdateCheckTools(mgr DockerManager, checker *UpdateChecker, filter *safety.Filter, audit *safety.AuditLogger) []tools.Registration {
+	return []tools.Registration{
+		toolDockerCheckUpdates(mgr, checker, filter, audit),
+	}
+}
+
+func toolDockerCheckUpdates(mgr DockerManager, checker *UpdateChecker, filter *safety.Filter, audit *safety.AuditLogger) tools.Registration {
+	tool := mcp.NewTool("docker_check_updates",
+		mcp.WithDescription("Check which containers run outdated images without pulling anything: each image's local digest is compared "+
+			"with the current manifest digest in its registry, including multi-arch images. Registry answers are cached; "+
+			"pass refresh to ask again. Images pinned by digest or built locally are reported as such. "+
+			"Use docker_update to update a container."),
+		mcp.WithArray("names",
+			mcp.Description("Container name glob patterns to check, e.g. [\"*arr\", \"plex\"] (default: all containers)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithBoolean("refresh",
+			mcp.Description("Ask the registries again even if a cached answer is still fresh (default false)"),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		names := req.GetStringSlice("names", nil)
+		refresh := req.GetBool("refresh", false)
+		params := map[string]any{}
+		if len(names) > 0 {
+			params["names"] = names
+		}
+		if refresh {
+			params["refresh"] = true
+		}
+
+		containers, err := mgr.ListContainers(ctx, true)
+		if err != nil {
+			tools.LogAudit(audit, "docker_check_updates", params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+		// Denied containers are left out entirely, as in docker_list.
+		var selected []Container
+		for _, c := range containers {
+			if filter.IsAllowed(c.Name) && (len(names) == 0 || matchAnyGlob(names, c.Name)) {
+				selected = append(selected, c)
+			}
+		}
+
+		statuses := checker.Check(ctx, mgr, selected, refresh)
+		SortUpdateStatuses(statuses)
+		report := UpdateCheckReport{
+			Checked:    len(statuses),
+			CacheTTL:   checker.ttl.String(),
+			Containers: statuses,
+		}
+		for _, s := range statuses {
+			switch s.Status {
+			case UpdateStatusAvailable:
+				report.UpdatesAvailable++
+			case UpdateStatusError:
+				report.Errors++
+			}
+		}
+
+		tools.LogAudit(audit, "docker_check_updates", params, "ok", start)
+		return tools.JSONResult(report), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
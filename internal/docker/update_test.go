@@ -0,0 +1,236 @@
+package docker
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// ---------------------------------------------------------------------------
+// UpdateContainer against a stateful fake daemon
+// ---------------------------------------------------------------------------
+
+const (
+	updateOldID = "0123456789abcdef0000"
+	updateNewID = "fedcba98765432100000"
+)
+
+// updateDaemon records the calls UpdateContainer makes.
+type updateDaemon struct {
+	mu         sync.Mutex
+	imageID    string // ID returned for the pulled image
+	failStart  bool   // make starting the new container fail
+	stopped    bool   // the old container is not running
+	createName string
+	createBody map[string]json.RawMessage
+	connected  []string
+	renames    []string
+	started    []string
+	removed    []string
+}
+
+func (d *updateDaemon) handler(t *testing.T) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /containers/{id}/json", func(w http.ResponseWriter, r *http.Request) {
+		switch r.PathValue("id") {
+		case "web", updateOldID:
+			state := `{"Running":true,"Status":"running"}`
+			if d.stopped {
+				state = `{"Running":false,"Status":"exited"}`
+			}
+			writeJSON(w, http.StatusOK, `{
+				"Id":"`+updateOldID+`","Name":"/web","Image":"sha256:old",
+				"State":`+state+`,
+				"Config":{"Image":"nginx:latest","Hostname":"0123456789ab",
+					"Env":["PATH=/usr/bin","NGINX_VERSION=1.25","A=1"],"Cmd":["nginx","-g","daemon off;"],
+					"Entrypoint":["/docker-entrypoint.sh"],"WorkingDir":"/srv",
+					"Labels":{"maintainer":"NGINX","app":"web"}},
+				"HostConfig":{"NetworkMode":"br0","RestartPolicy":{"Name":"unless-stopped"},"CapAdd":["NET_ADMIN"]},
+				"NetworkSettings":{"Networks":{
+					"br0":{"IPAMConfig":{"IPv4Address":"192.168.1.50"},"Aliases":["0123456789ab","web"],"IPAddress":"192.168.1.50","EndpointID":"e1"},
+					"proxynet":{"IPAMConfig":null,"EndpointID":"e2"}
+				}}
+			}`)
+		case updateNewID:
+			writeJSON(w, http.StatusOK, `{"Id":"`+updateNewID+`","Name":"/web","State":{"Running":true,"Status":"running"}}`)
+		default:
+			writeJSON(w, http.StatusNotFound, `{"message":"no such container"}`)
+		}
+	})
+	mux.HandleFunc("POST /images/create", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("GET /images/nginx:latest/json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, `{"Id":"`+d.imageID+`"}`)
+	})
+	mux.HandleFunc("GET /images/sha256:old/json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, `{"Id":"sha256:old","Config":{
+			"Env":["PATH=/usr/bin","NGINX_VERSION=1.25"],"Cmd":["nginx","-g","daemon off;"],
+			"Entrypoint":["/docker-entrypoint.sh"],"WorkingDir":"/","Labels":{"maintainer":"NGINX"}}}`)
+	})
+	mux.HandleFunc("POST /containers/{id}/stop", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("POST /containers/{id}/rename", func(w http.ResponseWriter, r *http.Request) {
+		d.mu.Lock()
+		d.renames = append(d.renames, r.URL.Query().Get("name"))
+		d.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("POST /containers/create", func(w http.ResponseWriter, r *http.Request) {
+		d.mu.Lock()
+		d.createName = r.URL.Query().Get("name")
+		if err := json.NewDecoder(r.Body).Decode(&d.createBody); err != nil {
+			t.Errorf("decode create body: %v", err)
+		}
+		d.mu.Unlock()
+		writeJSON(w, http.StatusCreated, `{"Id":"`+updateNewID+`"}`)
+	})
+	mux.HandleFunc("POST /networks/{name}/connect", func(w http.ResponseWriter, r *http.Request) {
+		d.mu.Lock()
+		d.connected = append(d.connected, r.PathValue("name"))
+		d.mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("POST /containers/{id}/start", func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		if id == updateNewID && d.failStart {
+			writeJSON(w, http.StatusInternalServerError, `{"message":"port is already allocated"}`)
+			return
+		}
+		d.mu.Lock()
+		d.started = append(d.started, id)
+		d.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("DELETE /containers/{id}", func(w http.ResponseWriter, r *http.Request) {
+		d.mu.Lock()
+		d.removed = append(d.removed, r.PathValue("id"))
+		d.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return mux
+}
+
+func Test_DockerClientManager_UpdateContainer_RecreatesWithOriginalConfig(t *testing.T) {
+	d := &updateDaemon{imageID: "sha256:new"}
+	mgr := newFakeDaemon(t, d.handler(t))
+
+	result, err := mgr.UpdateContainer(t.Context(), "web", UpdateOptions{StopTimeout: 10})
+	if err != nil {
+		t.Fatalf("UpdateContainer: %v\nsteps: %v", err, result.Steps)
+	}
+	if !result.Updated || result.RolledBack {
+		t.Errorf("result = %+v, want Updated and not RolledBack", result)
+	}
+	if result.NewImageID != "sha256:new" || result.OldImageID != "sha256:old" {
+		t.Errorf("image IDs = %s -> %s", result.OldImageID, result.NewImageID)
+	}
+
+	if d.createName != "web" {
+		t.Errorf("created with name %q, want %q", d.createName, "web")
+	}
+	if _, ok := d.createBody["Hostname"]; ok {
+		t.Error("generated hostname should not be carried over")
+	}
+	for _, key := range []string{"Cmd", "Entrypoint"} {
+		if _, ok := d.createBody[key]; ok {
+			t.Errorf("%s from the old image should not be carried over", key)
+		}
+	}
+	if string(d.createBody["Env"]) != `["A=1"]` || string(d.createBody["Labels"]) != `{"app":"web"}` ||
+		string(d.createBody["WorkingDir"]) != `"/srv"` {
+		t.Errorf("env, labels, working dir = %s, %s, %s; want only the container's own settings",
+			d.createBody["Env"], d.createBody["Labels"], d.createBody["WorkingDir"])
+	}
+	var hostCfg struct {
+		RestartPolicy struct{ Name string }
+		CapAdd        []string
+	}
+	if err := json.Unmarshal(d.createBody["HostConfig"], &hostCfg); err != nil {
+		t.Fatalf("decode HostConfig: %v", err)
+	}
+	if hostCfg.RestartPolicy.Name != "unless-stopped" || len(hostCfg.CapAdd) != 1 {
+		t.Errorf("HostConfig not preserved: %+v", hostCfg)
+	}
+
+	var netCfg struct {
+		EndpointsConfig map[string]struct {
+			IPAMConfig struct{ IPv4Address string }
+			Aliases    []string
+			EndpointID string
+		}
+	}
+	if err := json.Unmarshal(d.createBody["NetworkingConfig"], &netCfg); err != nil {
+		t.Fatalf("decode NetworkingConfig: %v", err)
+	}
+	br0, ok := netCfg.EndpointsConfig["br0"]
+	if !ok || len(netCfg.EndpointsConfig) != 1 {
+		t.Fatalf("EndpointsConfig = %+v, want only br0", netCfg.EndpointsConfig)
+	}
+	if br0.IPAMConfig.IPv4Address != "192.168.1.50" {
+		t.Errorf("static IP not preserved: %+v", br0)
+	}
+	if strings.Join(br0.Aliases, ",") != "web" || br0.EndpointID != "" {
+		t.Errorf("runtime endpoint fields leaked: %+v", br0)
+	}
+	if strings.Join(d.connected, ",") != "proxynet" {
+		t.Errorf("connected = %v, want [proxynet]", d.connected)
+	}
+	if strings.Join(d.removed, ",") != updateOldID {
+		t.Errorf("removed = %v, want only the old container", d.removed)
+	}
+}
+
+func Test_DockerClientManager_UpdateContainer_RollsBackOnStartFailure(t *testing.T) {
+	d := &updateDaemon{imageID: "sha256:new", failStart: true}
+	mgr := newFakeDaemon(t, d.handler(t))
+
+	result, err := mgr.UpdateContainer(t.Context(), "web", UpdateOptions{})
+	if err == nil || !strings.Contains(err.Error(), "rolled back") {
+		t.Fatalf("err = %v, want rollback error", err)
+	}
+	if result == nil || !result.RolledBack || result.Updated {
+		t.Fatalf("result = %+v, want RolledBack", result)
+	}
+	if strings.Join(d.removed, ",") != updateNewID {
+		t.Errorf("removed = %v, want only the new container", d.removed)
+	}
+	if len(d.renames) != 2 || d.renames[1] != "web" {
+		t.Errorf("renames = %v, want old renamed aside then back to web", d.renames)
+	}
+	if strings.Join(d.started, ",") != updateOldID {
+		t.Errorf("started = %v, want the old container restarted", d.started)
+	}
+}
+
+func Test_DockerClientManager_UpdateContainer_UnchangedImage(t *testing.T) {
+	d := &updateDaemon{imageID: "sha256:old"}
+	mgr := newFakeDaemon(t, d.handler(t))
+
+	result, err := mgr.UpdateContainer(t.Context(), "web", UpdateOptions{})
+	if err != nil {
+		t.Fatalf("UpdateContainer: %v", err)
+	}
+	if result.Updated {
+		t.Error("Updated should be false when the image is unchanged")
+	}
+	if d.createBody != nil || len(d.renames) != 0 {
+		t.Error("container should not have been recreated")
+	}
+}
+
+func Test_DockerClientManager_UpdateContainer_LeavesStoppedContainerStopped(t *testing.T) {
+	d := &updateDaemon{imageID: "sha256:new", stopped: true}
+	mgr := newFakeDaemon(t, d.handler(t))
+
+	result, err := mgr.UpdateContainer(t.Context(), "web", UpdateOptions{})
+	if err != nil {
+		t.Fatalf("UpdateContainer: %v\nsteps: %v", err, result.Steps)
+	}
+	if !result.Updated || len(d.started) != 0 {
+		t.Errorf("updated = %v, started = %v; want the new container created but not started", result.Updated, d.started)
+	}
+}
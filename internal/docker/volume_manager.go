@@ -0,0 +1,251 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Volume operations
+// ---------------------------------------------------------------------------
+
+// dockerVolume is the JSON shape of a volume returned by /volumes and
+// /volumes/{name}.
+type dockerVolume struct {
+	Name       string            `json:"Name"`
+	Driver     string            `json:"Driver"`
+	Mountpoint string            `json:"Mountpoint"`
+	Scope      string            `json:"Scope"`
+	CreatedAt  string            `json:"CreatedAt"`
+	Labels     map[string]string `json:"Labels"`
+	Options    map[string]string `json:"Options"`
+}
+
+func (v dockerVolume) toVolume() Volume {
+	created, _ := time.Parse(time.RFC3339Nano, v.CreatedAt)
+	return Volume{
+		Name:       v.Name,
+		Driver:     v.Driver,
+		Mountpoint: v.Mountpoint,
+		Scope:      v.Scope,
+		Labels:     v.Labels,
+		CreatedAt:  created,
+	}
+}
+
+// encodeFilters encodes a Docker API filters query value.
+func encodeFilters(filters map[string][]string) (string, error) {
+	data, err := json.Marshal(filters)
+	if err != nil {
+		return "", err
+	}
+	return url.QueryEscape(string(data)), nil
+}
+
+// ListVolumes returns the volumes known to the daemon. If danglingOnly is
+// true, only volumes not referenced by any container are returned.
+func (m *DockerClientManager) ListVolumes(ctx context.Context, danglingOnly bool) ([]Volume, error) {
+	path := "/volumes"
+	if danglingOnly {
+		filters, err := encodeFilters(map[string][]string{"dangling": {"true"}})
+		if err != nil {
+			return nil, fmt.Errorf("docker: encode volume filters: %w", err)
+		}
+		path += "?filters=" + filters
+	}
+
+	resp, err := m.doRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("docker: list volumes: %w", err)
+	}
+	body, err := readBody(resp)
+	if err != nil {
+		return nil, fmt.Errorf("docker: list volumes: %w", err)
+	}
+	if err := checkAPIError(resp.StatusCode, body, "volume not found"); err != nil {
+		return nil, fmt.Errorf("docker: list volumes: %w", err)
+	}
+
+	var raw struct {
+		Volumes []dockerVolume `json:"Volumes"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("docker: decode volume list: %w", err)
+	}
+
+	volumes := make([]Volume, 0, len(raw.Volumes))
+	for _, v := range raw.Volumes {
+		volumes = append(volumes, v.toVolume())
+	}
+	return volumes, nil
+}
+
+// InspectVolume returns detailed information about a volume, including the
+// names of all containers (running or stopped) that mount it.
+func (m *DockerClientManager) InspectVolume(ctx context.Context, name string) (*VolumeDetail, error) {
+	if name == "" {
+		return nil, fmt.Errorf("volume not found: %s", name)
+	}
+	resp, err := m.doRequest(ctx, http.MethodGet, "/volumes/"+name, nil)
+	if err != nil {
+		return nil, fmt.Errorf("docker: inspect volume: %w", err)
+	}
+	body, err := readBody(resp)
+	if err != nil {
+		return nil, fmt.Errorf("docker: inspect volume: %w", err)
+	}
+	if err := checkAPIError(resp.StatusCode, body, fmt.Sprintf("volume not found: %s", name)); err != nil {
+		return nil, fmt.Errorf("docker: inspect volume: %w", err)
+	}
+
+	var raw dockerVolume
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("docker: decode volume inspect: %w", err)
+	}
+
+	users, err := m.volumeUsers(ctx, raw.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &VolumeDetail{
+		Volume:     raw.toVolume(),
+		Options:    raw.Options,
+		Containers: users,
+	}, nil
+}
+
+// volumeUsers returns the names of all containers that mount the named volume.
+func (m *DockerClientManager) volumeUsers(ctx context.Context, name string) ([]string, error) {
+	filters, err := encodeFilters(map[string][]string{"volume": {name}})
+	if err != nil {
+		return nil, fmt.Errorf("docker: encode container filters: %w", err)
+	}
+	resp, err := m.doRequest(ctx, http.MethodGet, "/containers/json?all=true&filters="+filters, nil)
+	if err != nil {
+		return nil, fmt.Errorf("docker: list volume users: %w", err)
+	}
+	body, err := readBody(resp)
+	if err != nil {
+		return nil, fmt.Errorf("docker: list volume users: %w", err)
+	}
+	if err := checkAPIError(resp.StatusCode, body, "container not found"); err != nil {
+		return nil, fmt.Errorf("docker: list volume users: %w", err)
+	}
+
+	var raw []dockerContainer
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("docker: decode container list: %w", err)
+	}
+
+	users := make([]string, 0, len(raw))
+	for _, c := range raw {
+		if len(c.Names) > 0 {
+			users = append(users, strings.TrimPrefix(c.Names[0], "/"))
+		} else {
+			users = append(users, c.ID)
+		}
+	}
+	return users, nil
+}
+
+// volumeCreateRequest is the JSON body sent to POST /volumes/create.
+type volumeCreateRequest struct {
+	Name       string            `json:"Name"`
+	Driver     string            `json:"Driver,omitempty"`
+	DriverOpts map[string]string `json:"DriverOpts,omitempty"`
+	Labels     map[string]string `json:"Labels,omitempty"`
+}
+
+// CreateVolume creates a new Docker volume and returns it.
+func (m *DockerClientManager) CreateVolume(ctx context.Context, config VolumeCreateConfig) (*Volume, error) {
+	if config.Name == "" {
+		return nil, fmt.Errorf("volume name is required")
+	}
+
+	bodyData, err := json.Marshal(volumeCreateRequest{
+		Name:       config.Name,
+		Driver:     config.Driver,
+		DriverOpts: config.Options,
+		Labels:     config.Labels,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("docker: encode volume create request: %w", err)
+	}
+
+	resp, err := m.doRequest(ctx, http.MethodPost, "/volumes/create", bytes.NewReader(bodyData))
+	if err != nil {
+		return nil, fmt.Errorf("docker: create volume: %w", err)
+	}
+	body, err := readBody(resp)
+	if err != nil {
+		return nil, fmt.Errorf("docker: create volume: %w", err)
+	}
+	if err := checkAPIError(resp.StatusCode, body, "volume driver not found"); err != nil {
+		return nil, fmt.Errorf("docker: create volume: %w", err)
+	}
+
+	var raw dockerVolume
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("docker: decode volume create response: %w", err)
+	}
+	vol := raw.toVolume()
+	return &vol, nil
+}
+
+// RemoveVolume removes a volume. The daemon refuses to remove a volume that is
+// in use by a container; force only suppresses errors for volumes already
+// removed by their driver.
+func (m *DockerClientManager) RemoveVolume(ctx context.Context, name string, force bool) error {
+	if name == "" {
+		return fmt.Errorf("volume not found: %s", name)
+	}
+	path := fmt.Sprintf("/volumes/%s?force=%s", name, strconv.FormatBool(force))
+	resp, err := m.doRequest(ctx, http.MethodDelete, path, nil)
+	if err != nil {
+		return fmt.Errorf("docker: remove volume: %w", err)
+	}
+	body, err := readBody(resp)
+	if err != nil {
+		return fmt.Errorf("docker: remove volume: %w", err)
+	}
+	if err := checkAPIError(resp.StatusCode, body, fmt.Sprintf("volume not found: %s", name)); err != nil {
+		return fmt.Errorf("docker: remove volume: %w", err)
+	}
+	return nil
+}
+
+// PruneVolumes removes all local volumes not used by at least one container.
+func (m *DockerClientManager) PruneVolumes(ctx context.Context) (*VolumePruneReport, error) {
+	resp, err := m.doRequest(ctx, http.MethodPost, "/volumes/prune", nil)
+	if err != nil {
+		return nil, fmt.Errorf("docker: prune volumes: %w", err)
+	}
+	body, err := readBody(resp)
+	if err != nil {
+		return nil, fmt.Errorf("docker: prune volumes: %w", err)
+	}
+	if err := checkAPIError(resp.StatusCode, body, "volume not found"); err != nil {
+		return nil, fmt.Errorf("docker: prune volumes: %w", err)
+	}
+
+	var raw struct {
+		VolumesDeleted []string `json:"VolumesDeleted"`
+		SpaceReclaimed uint64   `json:"SpaceReclaimed"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("docker: decode volume prune response: %w", err)
+	}
+	return &VolumePruneReport{
+		VolumesDeleted: raw.VolumesDeleted,
+		SpaceReclaimed: raw.SpaceReclaimed,
+	}, nil
+}
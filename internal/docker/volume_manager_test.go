@@ -0,0 +1,162 @@
+package docker
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// ---------------------------------------------------------------------------
+// Volume operations against a fake daemon
+// ---------------------------------------------------------------------------
+
+func Test_DockerClientManager_ListVolumes_DanglingFilter(t *testing.T) {
+	tests := []struct {
+		name         string
+		danglingOnly bool
+		wantFilter   string
+	}{
+		{name: "all volumes", danglingOnly: false, wantFilter: ""},
+		{name: "dangling only", danglingOnly: true, wantFilter: `{"dangling":["true"]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /volumes", func(w http.ResponseWriter, r *http.Request) {
+				if got := r.URL.Query().Get("filters"); got != tt.wantFilter {
+					t.Errorf("filters = %q, want %q", got, tt.wantFilter)
+				}
+				writeJSON(w, http.StatusOK, `{"Volumes":[
+					{"Name":"appdata","Driver":"local","Mountpoint":"/var/lib/docker/volumes/appdata/_data",
+					 "Scope":"local","CreatedAt":"2026-01-02T03:04:05Z","Labels":{"owner":"plex"}}
+				],"Warnings":null}`)
+			})
+			mgr := newFakeDaemon(t, mux)
+
+			volumes, err := mgr.ListVolumes(t.Context(), tt.danglingOnly)
+			if err != nil {
+				t.Fatalf("ListVolumes: %v", err)
+			}
+			if len(volumes) != 1 || volumes[0].Name != "appdata" || volumes[0].Labels["owner"] != "plex" {
+				t.Errorf("volumes = %+v", volumes)
+			}
+			if volumes[0].CreatedAt.IsZero() {
+				t.Error("CreatedAt was not parsed")
+			}
+		})
+	}
+}
+
+func Test_DockerClientManager_InspectVolume_IncludesUsers(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /volumes/appdata", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, `{"Name":"appdata","Driver":"local","Mountpoint":"/mnt/x","Options":{"type":"none"}}`)
+	})
+	mux.HandleFunc("GET /containers/json", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("filters"); got != `{"volume":["appdata"]}` {
+			t.Errorf("filters = %q", got)
+		}
+		if got := r.URL.Query().Get("all"); got != "true" {
+			t.Errorf("all = %q, want true", got)
+		}
+		writeJSON(w, http.StatusOK, `[{"Id":"c1","Names":["/plex"]},{"Id":"c2","Names":["/tautulli"]}]`)
+	})
+	mux.HandleFunc("GET /volumes/missing", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusNotFound, `{"message":"get missing: no such volume"}`)
+	})
+	mgr := newFakeDaemon(t, mux)
+
+	detail, err := mgr.InspectVolume(t.Context(), "appdata")
+	if err != nil {
+		t.Fatalf("InspectVolume: %v", err)
+	}
+	if got := strings.Join(detail.Containers, ","); got != "plex,tautulli" {
+		t.Errorf("Containers = %q, want %q", got, "plex,tautulli")
+	}
+	if detail.Options["type"] != "none" {
+		t.Errorf("Options = %v", detail.Options)
+	}
+
+	_, err = mgr.InspectVolume(t.Context(), "missing")
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("err = %v, want it to contain %q", err, "not found")
+	}
+}
+
+func Test_DockerClientManager_CreateVolume(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /volumes/create", func(w http.ResponseWriter, r *http.Request) {
+		var body volumeCreateRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		if body.Name != "media" || body.Labels["a"] != "b" || body.DriverOpts["type"] != "nfs" {
+			t.Errorf("body = %+v", body)
+		}
+		writeJSON(w, http.StatusCreated, `{"Name":"media","Driver":"local","Mountpoint":"/var/lib/docker/volumes/media/_data"}`)
+	})
+	mgr := newFakeDaemon(t, mux)
+
+	vol, err := mgr.CreateVolume(t.Context(), VolumeCreateConfig{
+		Name:    "media",
+		Labels:  map[string]string{"a": "b"},
+		Options: map[string]string{"type": "nfs"},
+	})
+	if err != nil {
+		t.Fatalf("CreateVolume: %v", err)
+	}
+	if vol.Mountpoint == "" {
+		t.Errorf("vol = %+v, want a mountpoint", vol)
+	}
+}
+
+func Test_DockerClientManager_RemoveVolume_InUse(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("DELETE /volumes/appdata", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusConflict, `{"message":"remove appdata: volume is in use - [c1]"}`)
+	})
+	mgr := newFakeDaemon(t, mux)
+
+	err := mgr.RemoveVolume(t.Context(), "appdata", false)
+	if err == nil || !strings.Contains(err.Error(), "in use") {
+		t.Errorf("err = %v, want it to contain %q", err, "in use")
+	}
+}
+
+func Test_DockerClientManager_PruneVolumes(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /volumes/prune", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, `{"VolumesDeleted":["old1","old2"],"SpaceReclaimed":2048}`)
+	})
+	mgr := newFakeDaemon(t, mux)
+
+	report, err := mgr.PruneVolumes(t.Context())
+	if err != nil {
+		t.Fatalf("PruneVolumes: %v", err)
+	}
+	if len(report.VolumesDeleted) != 2 || report.SpaceReclaimed != 2048 {
+		t.Errorf("report = %+v", report)
+	}
+}
+
+func Test_ParseKeyValues_Cases(t *testing.T) {
+	got, err := parseKeyValues([]string{"a=b", "c=d=e", "flag"})
+	if err != nil {
+		t.Fatalf("parseKeyValues: %v", err)
+	}
+	if got["a"] != "b" || got["c"] != "d=e" {
+		t.Errorf("got %v", got)
+	}
+	if v, ok := got["flag"]; !ok || v != "" {
+		t.Errorf("flag = %q, %v; want empty value present", v, ok)
+	}
+
+	if _, err := parseKeyValues([]string{"=value"}); err == nil {
+		t.Error("expected error for empty key")
+	}
+	if got, _ := parseKeyValues(nil); got != nil {
+		t.Errorf("nil input = %v, want nil", got)
+	}
+}
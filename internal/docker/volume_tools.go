@@ -0,0 +1,276 @@
+// Package docker provides Docker container and network management for the unraid-mcp server.
+package docker
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+	"github.com/jamesprial/unraid-mcp/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// parseKeyValues converts a list of "key=value" strings into a map. An entry
+// without "=" maps the key to an empty value.
+func parseKeyValues(entries []string) (map[string]string, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]string, len(entries))
+	for _, e := range entries {
+		key, value, _ := strings.Cut(e, "=")
+		if key == "" {
+			return nil, fmt.Errorf("invalid key=value entry %q", e)
+		}
+		out[key] = value
+	}
+	return out, nil
+}
+
+func toolDockerVolumeList(mgr DockerManager, filter *safety.Filter, audit *safety.AuditLogger) tools.Registration {
+	tool := mcp.NewTool("docker_volume_list",
+		mcp.WithDescription("List Docker volumes. Use dangling_only to find orphaned volumes that no container references."),
+		mcp.WithBoolean("dangling_only",
+			mcp.Description("Only list volumes not used by any container (default: false)"),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		danglingOnly := req.GetBool("dangling_only", false)
+		params := map[string]any{"dangling_only": danglingOnly}
+
+		volumes, err := mgr.ListVolumes(ctx, danglingOnly)
+		if err != nil {
+			tools.LogAudit(audit, "docker_volume_list", params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+
+		filtered := make([]Volume, 0, len(volumes))
+		for _, v := range volumes {
+			if filter.IsAllowed(v.Name) {
+				filtered = append(filtered, v)
+			}
+		}
+
+		tools.LogAudit(audit, "docker_volume_list", params, "ok", start)
+		return tools.JSONResult(filtered), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
+
+func toolDockerVolumeInspect(mgr DockerManager, filter *safety.Filter, audit *safety.AuditLogger) tools.Registration {
+	tool := mcp.NewTool("docker_volume_inspect",
+		mcp.WithDescription("Inspect a Docker volume and return its driver, mountpoint, labels, and the containers that use it."),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("Volume name"),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		name := req.GetString("name", "")
+		params := map[string]any{"name": name}
+
+		if !filter.IsAllowed(name) {
+			tools.LogAudit(audit, "docker_volume_inspect", params, "denied", start)
+			return tools.ErrorResult(fmt.Sprintf("access to volume %q is not allowed", name)), nil
+		}
+
+		detail, err := mgr.InspectVolume(ctx, name)
+		if err != nil {
+			tools.LogAudit(audit, "docker_volume_inspect", params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+
+		tools.LogAudit(audit, "docker_volume_inspect", params, "ok", start)
+		return tools.JSONResult(detail), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
+
+func toolDockerVolumeCreate(mgr DockerManager, filter *safety.Filter, audit *safety.AuditLogger) tools.Registration {
+	tool := mcp.NewTool("docker_volume_create",
+		mcp.WithDescription("Create a new Docker volume."),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("Volume name"),
+		),
+		mcp.WithString("driver",
+			mcp.Description("Volume driver (default: local)"),
+		),
+		mcp.WithArray("labels",
+			mcp.Description("Labels as key=value strings"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithArray("options",
+			mcp.Description("Driver options as key=value strings (e.g. type=nfs)"),
+			mcp.WithStringItems(),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		name := req.GetString("name", "")
+		driver := req.GetString("driver", "")
+		labelList := req.GetStringSlice("labels", nil)
+		optionList := req.GetStringSlice("options", nil)
+		params := map[string]any{"name": name, "driver": driver, "labels": labelList, "options": optionList}
+
+		if !filter.IsAllowed(name) {
+			tools.LogAudit(audit, "docker_volume_create", params, "denied", start)
+			return tools.ErrorResult(fmt.Sprintf("creation of volume %q is not allowed", name)), nil
+		}
+
+		labels, err := parseKeyValues(labelList)
+		if err != nil {
+			return tools.ErrorResult("labels: " + err.Error()), nil
+		}
+		options, err := parseKeyValues(optionList)
+		if err != nil {
+			return tools.ErrorResult("options: " + err.Error()), nil
+		}
+
+		vol, err := mgr.CreateVolume(ctx, VolumeCreateConfig{
+			Name:    name,
+			Driver:  driver,
+			Labels:  labels,
+			Options: options,
+		})
+		if err != nil {
+			tools.LogAudit(audit, "docker_volume_create", params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+
+		tools.LogAudit(audit, "docker_volume_create", params, "ok", start)
+		return tools.JSONResult(vol), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
+
+func toolDockerVolumeRemove(mgr DockerManager, filter *safety.Filter, confirm *safety.ConfirmationTracker, audit *safety.AuditLogger) tools.Registration {
+	const toolName = "docker_volume_remove"
+
+	tool := mcp.NewTool(toolName,
+		mcp.WithDescription("Remove a Docker volume and all data stored in it. Fails if a container uses the volume. Requires confirmation."),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("Volume name"),
+		),
+		mcp.WithBoolean("force",
+			mcp.Description("Do not fail if the volume driver has already removed the volume (default: false)"),
+		),
+		mcp.WithString("confirmation_token",
+			mcp.Description("Confirmation token returned by a prior call to this tool"),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		name := req.GetString("name", "")
+		force := req.GetBool("force", false)
+		token := req.GetString("confirmation_token", "")
+		params := map[string]any{"name": name, "force": force}
+
+		if !filter.IsAllowed(name) {
+			tools.LogAudit(audit, toolName, params, "denied", start)
+			return tools.ErrorResult(fmt.Sprintf("access to volume %q is not allowed", name)), nil
+		}
+
+		if !confirm.Confirm(token, toolName, name, params) {
+			desc := fmt.Sprintf("This will permanently remove volume %q and all data stored in it.", name)
+			return tools.ConfirmPrompt(confirm, toolName, name, desc, params), nil
+		}
+
+		if err := mgr.RemoveVolume(ctx, name, force); err != nil {
+			tools.LogAudit(audit, toolName, params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+
+		tools.LogAudit(audit, toolName, params, "ok", start)
+		return mcp.NewToolResultText(fmt.Sprintf("volume %q removed successfully", name)), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
+
+func toolDockerVolumePrune(mgr DockerManager, filter *safety.Filter, confirm *safety.ConfirmationTracker, audit *safety.AuditLogger) tools.Registration {
+	const toolName = "docker_volume_prune"
+
+	tool := mcp.NewTool(toolName,
+		mcp.WithDescription("Remove all Docker volumes not used by any container. Volumes excluded by the container filter are kept. Requires confirmation."),
+		mcp.WithString("confirmation_token",
+			mcp.Description("Confirmation token returned by a prior call to this tool"),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		token := req.GetString("confirmation_token", "")
+		params := map[string]any{}
+
+		dangling, err := mgr.ListVolumes(ctx, true)
+		if err != nil {
+			tools.LogAudit(audit, toolName, params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+
+		var allowed []string
+		skipped := 0
+		for _, v := range dangling {
+			if filter.IsAllowed(v.Name) {
+				allowed = append(allowed, v.Name)
+			} else {
+				skipped++
+			}
+		}
+		// The token is bound to the volumes listed in the prompt, so a
+		// volume that became unused after it was shown is never removed.
+		slices.Sort(allowed)
+		params["volumes"] = allowed
+
+		if !confirm.Confirm(token, toolName, "volumes", params) {
+			if len(allowed) == 0 {
+				return mcp.NewToolResultText("no unused volumes to remove"), nil
+			}
+			desc := fmt.Sprintf("This will permanently remove %d unused volume(s) and their data: %s.",
+				len(allowed), strings.Join(allowed, ", "))
+			if skipped > 0 {
+				desc += fmt.Sprintf(" %d unused volume(s) excluded by the filter will be kept.", skipped)
+			}
+			return tools.ConfirmPrompt(confirm, toolName, "volumes", desc, params), nil
+		}
+
+		// The confirmed volumes are removed one at a time rather than with a
+		// daemon-wide prune, which would also take volumes protected by the
+		// filter or not shown in the prompt.
+		report := &VolumePruneReport{}
+		var failures []string
+		for _, name := range allowed {
+			if err := mgr.RemoveVolume(ctx, name, false); err != nil {
+				failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+				continue
+			}
+			report.VolumesDeleted = append(report.VolumesDeleted, name)
+		}
+		if len(failures) > 0 {
+			result := "partial: " + strings.Join(failures, "; ")
+			tools.LogAudit(audit, toolName, params, result, start)
+			return tools.ErrorResult(fmt.Sprintf("removed %d volume(s); failed: %s",
+				len(report.VolumesDeleted), strings.Join(failures, "; "))), nil
+		}
+
+		tools.LogAudit(audit, toolName, params, "ok", start)
+		return tools.JSONResult(report), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
@@ -174,13 +174,13 @@ func toolNotificationsManage(mgr NotificationManager, confirm *safety.Confirmati
 
 		// Check confirmation for destructive actions.
 		if _, isDestructive := destructiveActions[action]; isDestructive {
-			if !confirm.Confirm(token) {
-				resource := action
-				if id != "" {
-					resource = fmt.Sprintf("%s (id=%s)", action, id)
-				}
+			resource := action
+			if id != "" {
+				resource = fmt.Sprintf("%s (id=%s)", action, id)
+			}
+			if !confirm.Confirm(token, toolName, resource, params) {
 				desc := fmt.Sprintf("This will permanently %s. This cannot be undone.", action)
-				return tools.ConfirmPrompt(confirm, toolName, resource, desc), nil
+				return tools.ConfirmPrompt(confirm, toolName, resource, desc, params), nil
 			}
 		}
 
@@ -2,7 +2,9 @@ package safety
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"sync"
 	"time"
 )
@@ -13,6 +15,7 @@ const tokenTTL = 5 * time.Minute
 type pendingConfirmation struct {
 	tool         string
 	resourceName string
+	argsHash     string
 	description  string
 	createdAt    time.Time
 }
@@ -57,9 +60,11 @@ func (ct *ConfirmationTracker) sweepExpired() {
 }
 
 // RequestConfirmation creates a new confirmation token for the given tool,
-// resource, and description and returns the opaque token string. Tokens are
-// valid for 5 minutes and are single-use.
-func (ct *ConfirmationTracker) RequestConfirmation(tool, resourceName, description string) string {
+// resource, and description and returns the opaque token string. The token is
+// bound to tool, resourceName, and a hash of args, so it can only be redeemed
+// by a call with the same values. Tokens are valid for 5 minutes and are
+// single-use.
+func (ct *ConfirmationTracker) RequestConfirmation(tool, resourceName, description string, args map[string]any) string {
 	token := generateToken()
 
 	ct.mu.Lock()
@@ -67,6 +72,7 @@ func (ct *ConfirmationTracker) RequestConfirmation(tool, resourceName, descripti
 	ct.tokens[token] = &pendingConfirmation{
 		tool:         tool,
 		resourceName: resourceName,
+		argsHash:     hashArgs(args),
 		description:  description,
 		createdAt:    time.Now(),
 	}
@@ -75,9 +81,11 @@ func (ct *ConfirmationTracker) RequestConfirmation(tool, resourceName, descripti
 	return token
 }
 
-// Confirm consumes the given token and returns true if it was valid and
-// unexpired. Subsequent calls with the same token return false.
-func (ct *ConfirmationTracker) Confirm(token string) bool {
+// Confirm consumes the given token and returns true if it was valid,
+// unexpired, and was issued for the same tool, resource, and arguments.
+// The token is consumed even when the binding does not match, so subsequent
+// calls with the same token always return false.
+func (ct *ConfirmationTracker) Confirm(token, tool, resourceName string, args map[string]any) bool {
 	if token == "" {
 		return false
 	}
@@ -98,7 +106,27 @@ func (ct *ConfirmationTracker) Confirm(token string) bool {
 		return false
 	}
 
-	return true
+	// Check the token was issued for this exact operation.
+	if pending.tool != tool || pending.resourceName != resourceName {
+		return false
+	}
+	return pending.argsHash == hashArgs(args)
+}
+
+// hashArgs returns a stable hex-encoded SHA-256 digest of args. Map keys are
+// serialised in sorted order by encoding/json, so equal maps always produce
+// the same digest. A nil or empty map hashes to the empty string.
+func hashArgs(args map[string]any) string {
+	if len(args) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(args)
+	if err != nil {
+		// Unserialisable arguments can never be matched safely.
+		return "unhashable:" + generateToken()
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
 }
 
 // generateToken returns a cryptographically random hex-encoded token string.
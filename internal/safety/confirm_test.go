@@ -85,13 +85,13 @@ func Test_ConfirmationTracker_NeedsConfirmation_NilDestructiveList(t *testing.T)
 func Test_ConfirmationTracker_RequestAndConfirm(t *testing.T) {
 	ct := NewConfirmationTracker([]string{"docker_remove"})
 
-	token := ct.RequestConfirmation("docker_remove", "my-container", "Remove container my-container")
+	token := ct.RequestConfirmation("docker_remove", "my-container", "Remove container my-container", nil)
 
 	if token == "" {
 		t.Fatal("RequestConfirmation() returned empty token")
 	}
 
-	if !ct.Confirm(token) {
+	if !ct.Confirm(token, "docker_remove", "my-container", nil) {
 		t.Error("Confirm() should return true for a valid, unused token")
 	}
 }
@@ -99,7 +99,7 @@ func Test_ConfirmationTracker_RequestAndConfirm(t *testing.T) {
 func Test_ConfirmationTracker_InvalidToken(t *testing.T) {
 	ct := NewConfirmationTracker([]string{"docker_remove"})
 
-	if ct.Confirm("bogus-token-that-was-never-issued") {
+	if ct.Confirm("bogus-token-that-was-never-issued", "docker_remove", "my-container", nil) {
 		t.Error("Confirm() should return false for an invalid token")
 	}
 }
@@ -107,7 +107,7 @@ func Test_ConfirmationTracker_InvalidToken(t *testing.T) {
 func Test_ConfirmationTracker_EmptyToken(t *testing.T) {
 	ct := NewConfirmationTracker([]string{"docker_remove"})
 
-	if ct.Confirm("") {
+	if ct.Confirm("", "docker_remove", "my-container", nil) {
 		t.Error("Confirm() should return false for an empty token")
 	}
 }
@@ -115,10 +115,10 @@ func Test_ConfirmationTracker_EmptyToken(t *testing.T) {
 func Test_ConfirmationTracker_TokenSingleUse(t *testing.T) {
 	ct := NewConfirmationTracker([]string{"docker_remove"})
 
-	token := ct.RequestConfirmation("docker_remove", "my-container", "Remove container")
+	token := ct.RequestConfirmation("docker_remove", "my-container", "Remove container", nil)
 
-	first := ct.Confirm(token)
-	second := ct.Confirm(token)
+	first := ct.Confirm(token, "docker_remove", "my-container", nil)
+	second := ct.Confirm(token, "docker_remove", "my-container", nil)
 
 	if !first {
 		t.Error("first Confirm() should return true")
@@ -131,26 +131,26 @@ func Test_ConfirmationTracker_TokenSingleUse(t *testing.T) {
 func Test_ConfirmationTracker_MultipleTokensIndependent(t *testing.T) {
 	ct := NewConfirmationTracker([]string{"docker_remove", "vm_delete"})
 
-	token1 := ct.RequestConfirmation("docker_remove", "container-a", "Remove container-a")
-	token2 := ct.RequestConfirmation("vm_delete", "vm-b", "Delete vm-b")
+	token1 := ct.RequestConfirmation("docker_remove", "container-a", "Remove container-a", nil)
+	token2 := ct.RequestConfirmation("vm_delete", "vm-b", "Delete vm-b", nil)
 
 	if token1 == token2 {
 		t.Error("different requests should produce different tokens")
 	}
 
 	// Confirm token2 first, token1 should still work.
-	if !ct.Confirm(token2) {
+	if !ct.Confirm(token2, "vm_delete", "vm-b", nil) {
 		t.Error("Confirm(token2) should return true")
 	}
-	if !ct.Confirm(token1) {
+	if !ct.Confirm(token1, "docker_remove", "container-a", nil) {
 		t.Error("Confirm(token1) should return true even after token2 was confirmed")
 	}
 
 	// Both should now be consumed.
-	if ct.Confirm(token1) {
+	if ct.Confirm(token1, "docker_remove", "container-a", nil) {
 		t.Error("Confirm(token1) second use should return false")
 	}
-	if ct.Confirm(token2) {
+	if ct.Confirm(token2, "vm_delete", "vm-b", nil) {
 		t.Error("Confirm(token2) second use should return false")
 	}
 }
@@ -186,7 +186,7 @@ func Test_ConfirmationTracker_RequestConfirmation_ReturnsNonEmptyToken(t *testin
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			token := ct.RequestConfirmation(tt.tool, tt.resourceName, tt.description)
+			token := ct.RequestConfirmation(tt.tool, tt.resourceName, tt.description, nil)
 			if token == "" {
 				t.Error("RequestConfirmation() should return a non-empty token")
 			}
@@ -207,10 +207,10 @@ func Test_ConfirmationTracker_TokenExpiry(t *testing.T) {
 	// clock-injection tests will validate.
 	ct := NewConfirmationTracker([]string{"docker_remove"})
 
-	token := ct.RequestConfirmation("docker_remove", "container", "Remove container")
+	token := ct.RequestConfirmation("docker_remove", "container", "Remove container", nil)
 
 	// Token should be valid immediately (well within 5 minute window).
-	if !ct.Confirm(token) {
+	if !ct.Confirm(token, "docker_remove", "container", nil) {
 		t.Error("token should be valid immediately after creation")
 	}
 }
@@ -228,12 +228,12 @@ func Test_ConfirmationTracker_TokenExpiry_Simulation(t *testing.T) {
 	// We test the boundary: a token used within a very short time should work.
 	// This is already covered above but included here for completeness.
 	ct := NewConfirmationTracker([]string{"docker_remove"})
-	token := ct.RequestConfirmation("docker_remove", "container", "desc")
+	token := ct.RequestConfirmation("docker_remove", "container", "desc", nil)
 
 	// Small sleep to ensure non-zero elapsed time but well within 5 minutes.
 	time.Sleep(10 * time.Millisecond)
 
-	if !ct.Confirm(token) {
+	if !ct.Confirm(token, "docker_remove", "container", nil) {
 		t.Error("token should be valid within the 5 minute window")
 	}
 }
@@ -257,3 +257,110 @@ func Test_NewConfirmationTracker_ReturnsNonNil(t *testing.T) {
 		})
 	}
 }
+
+func Test_ConfirmationTracker_Confirm_BindingCases(t *testing.T) {
+	issuedArgs := map[string]any{"id": "plex", "force": false}
+
+	tests := []struct {
+		name     string
+		tool     string
+		resource string
+		args     map[string]any
+		want     bool
+	}{
+		{
+			name:     "same tool, resource and args",
+			tool:     "docker_remove",
+			resource: "plex",
+			args:     map[string]any{"force": false, "id": "plex"},
+			want:     true,
+		},
+		{
+			name:     "cross-tool replay is rejected",
+			tool:     "docker_stop",
+			resource: "plex",
+			args:     issuedArgs,
+			want:     false,
+		},
+		{
+			name:     "different resource is rejected",
+			tool:     "docker_remove",
+			resource: "nextcloud",
+			args:     issuedArgs,
+			want:     false,
+		},
+		{
+			name:     "changed argument is rejected",
+			tool:     "docker_remove",
+			resource: "plex",
+			args:     map[string]any{"id": "plex", "force": true},
+			want:     false,
+		},
+		{
+			name:     "missing arguments are rejected",
+			tool:     "docker_remove",
+			resource: "plex",
+			args:     nil,
+			want:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := NewConfirmationTracker([]string{"docker_remove", "docker_stop"})
+			token := ct.RequestConfirmation("docker_remove", "plex", "Remove plex", issuedArgs)
+
+			if got := ct.Confirm(token, tt.tool, tt.resource, tt.args); got != tt.want {
+				t.Errorf("Confirm() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ConfirmationTracker_Confirm_MismatchConsumesToken(t *testing.T) {
+	ct := NewConfirmationTracker([]string{"docker_remove", "docker_stop"})
+	token := ct.RequestConfirmation("docker_stop", "plex", "Stop plex", nil)
+
+	if ct.Confirm(token, "docker_remove", "nextcloud", nil) {
+		t.Fatal("Confirm() should reject a token replayed against another tool")
+	}
+	if ct.Confirm(token, "docker_stop", "plex", nil) {
+		t.Error("a token presented with the wrong binding should be consumed")
+	}
+}
+
+func Test_ConfirmationTracker_Confirm_AcrossTrackers(t *testing.T) {
+	dockerCT := NewConfirmationTracker([]string{"docker_stop"})
+	vmCT := NewConfirmationTracker([]string{"vm_stop"})
+
+	token := dockerCT.RequestConfirmation("docker_stop", "plex", "Stop plex", nil)
+
+	if vmCT.Confirm(token, "docker_stop", "plex", nil) {
+		t.Error("a token issued by one tracker must not be accepted by another")
+	}
+	if !dockerCT.Confirm(token, "docker_stop", "plex", nil) {
+		t.Error("the issuing tracker should still accept the token")
+	}
+}
+
+func Test_hashArgs_Cases(t *testing.T) {
+	tests := []struct {
+		name string
+		a    map[string]any
+		b    map[string]any
+		same bool
+	}{
+		{name: "nil and empty are equal", a: nil, b: map[string]any{}, same: true},
+		{name: "key order does not matter", a: map[string]any{"a": 1, "b": "x"}, b: map[string]any{"b": "x", "a": 1}, same: true},
+		{name: "different values differ", a: map[string]any{"force": true}, b: map[string]any{"force": false}, same: false},
+		{name: "extra key differs", a: map[string]any{"id": "x"}, b: map[string]any{"id": "x", "force": true}, same: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hashArgs(tt.a) == hashArgs(tt.b); got != tt.same {
+				t.Errorf("hashArgs(%v) == hashArgs(%v) is %v, want %v", tt.a, tt.b, got, tt.same)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+// Package stats keeps the bounded resource usage history that the Docker and
+// VM background samplers record into and their tools read from.
+package stats
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Defaults for the background samplers and the tools that read their history.
+const (
+	DefaultInterval  = 30 * time.Second
+	DefaultRetention = time.Hour
+	DefaultWindow    = 30 * time.Minute
+	DefaultTopLimit  = 10
+)
+
+// Sample is one reading of an object's resource usage.
+type Sample interface {
+	SampleTime() time.Time
+}
+
+// Series is the samples of one object, in chronological order.
+type Series[S Sample] struct {
+	ID      string
+	Name    string
+	Samples []S
+}
+
+// History keeps a bounded time series of samples per object, by ID. It is
+// safe for concurrent use.
+type History[S Sample] struct {
+	mu        sync.RWMutex
+	retention time.Duration
+	capacity  int // samples kept per object
+	series    map[string]*Series[S]
+	lastRun   time.Time
+	lastErr   string
+}
+
+// NewHistory returns a history holding retention worth of samples taken
+// every interval.
+func NewHistory[S Sample](interval, retention time.Duration) *History[S] {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	if retention <= 0 {
+		retention = DefaultRetention
+	}
+	return &History[S]{
+		retention: retention,
+		capacity:  int(retention/interval) + 1,
+		series:    make(map[string]*Series[S]),
+	}
+}
+
+// Add records a sample for the object, dropping samples older than the
+// retention period.
+func (h *History[S]) Add(id, name string, s S) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	series := h.series[id]
+	if series == nil {
+		series = &Series[S]{ID: id}
+		h.series[id] = series
+	}
+	series.Name = name
+	series.Samples = append(series.Samples, s)
+	cutoff := s.SampleTime().Add(-h.retention)
+	drop := max(len(series.Samples)-h.capacity, 0)
+	for drop < len(series.Samples) && series.Samples[drop].SampleTime().Before(cutoff) {
+		drop++
+	}
+	if drop > 0 {
+		series.Samples = append([]S(nil), series.Samples[drop:]...)
+	}
+}
+
+// Prune forgets objects with no samples since the retention period.
+func (h *History[S]) Prune(now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	cutoff := now.Add(-h.retention)
+	for id, s := range h.series {
+		if len(s.Samples) == 0 || s.Samples[len(s.Samples)-1].SampleTime().Before(cutoff) {
+			delete(h.series, id)
+		}
+	}
+}
+
+// SetStatus records when a sampling round ran and its error, if any.
+func (h *History[S]) SetStatus(at time.Time, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastRun = at
+	h.lastErr = ""
+	if err != nil {
+		h.lastErr = err.Error()
+	}
+}
+
+// Status reports when the last sampling round ran and its error, if any.
+func (h *History[S]) Status() (lastRun time.Time, lastError string) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.lastRun, h.lastErr
+}
+
+// Retention reports how far back the history reaches.
+func (h *History[S]) Retention() time.Duration {
+	return h.retention
+}
+
+// Get returns the samples since the given time of the object with the given
+// ID.
+func (h *History[S]) Get(id string, since time.Time) (Series[S], bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	s, ok := h.series[id]
+	if !ok {
+		return Series[S]{}, false
+	}
+	return s.since(since), true
+}
+
+// Window returns the samples since the given time of every object, including
+// objects with none in the window, in no particular order.
+func (h *History[S]) Window(since time.Time) []Series[S] {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	out := make([]Series[S], 0, len(h.series))
+	for _, s := range h.series {
+		out = append(out, s.since(since))
+	}
+	return out
+}
+
+func (s *Series[S]) since(t time.Time) Series[S] {
+	i := sort.Search(len(s.Samples), func(i int) bool { return !s.Samples[i].SampleTime().Before(t) })
+	return Series[S]{ID: s.ID, Name: s.Name, Samples: append([]S(nil), s.Samples[i:]...)}
+}
+
+// Rank sorts items by key, highest first and then by name, and keeps the
+// first limit of them; a limit of 0 or less keeps all.
+func Rank[T any](items []T, key func(T) float64, name func(T) string, limit int) []T {
+	sort.Slice(items, func(i, j int) bool {
+		ki, kj := key(items[i]), key(items[j])
+		if ki != kj {
+			return ki > kj
+		}
+		return name(items[i]) < name(items[j])
+	})
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+	return items
+}
+
+// ParseWindow parses a window argument, defaulting to DefaultWindow and
+// capping it at retention.
+func ParseWindow(arg string, retention time.Duration) (time.Duration, error) {
+	window := DefaultWindow
+	if arg != "" {
+		d, err := time.ParseDuration(arg)
+		if err != nil || d <= 0 {
+			return 0, fmt.Errorf("invalid window %q: want a positive duration like 30m or 1h", arg)
+		}
+		window = d
+	}
+	return min(window, retention), nil
+}
+
+// Run calls sample immediately and then every interval until ctx is
+// cancelled, logging its errors with the given prefix.
+func Run(ctx context.Context, interval time.Duration, prefix string, sample func(context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := sample(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("%s: %v", prefix, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package stats
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testSample is a Sample with nothing but a time and a value.
+type testSample struct {
+	at    time.Time
+	value float64
+}
+
+func (s testSample) SampleTime() time.Time { return s.at }
+
+func Test_History_BoundsAndPrunes(t *testing.T) {
+	h := NewHistory[testSample](time.Minute, 5*time.Minute)
+	base := time.Now().Add(-time.Hour)
+	for i := range 20 {
+		h.Add("abc", "plex", testSample{at: base.Add(time.Duration(i) * time.Minute)})
+	}
+	s, ok := h.Get("abc", time.Time{})
+	if !ok || len(s.Samples) != 6 || s.Name != "plex" {
+		t.Fatalf("Get(abc) = %+v, %v; want 6 samples of plex", s, ok)
+	}
+	if s, _ := h.Get("abc", base.Add(18*time.Minute)); len(s.Samples) != 2 {
+		t.Errorf("Get(abc, last 2 minutes) has %d samples, want 2", len(s.Samples))
+	}
+
+	h.Add("def", "sonarr", testSample{at: base.Add(50 * time.Minute)})
+	h.Prune(base.Add(30 * time.Minute))
+	if _, ok := h.Get("abc", time.Time{}); ok {
+		t.Error("stale series was not pruned")
+	}
+	if w := h.Window(time.Time{}); len(w) != 1 || w[0].ID != "def" {
+		t.Errorf("Window() = %+v, want def only", w)
+	}
+}
+
+func Test_History_Status(t *testing.T) {
+	h := NewHistory[testSample](0, 0)
+	if h.Retention() != DefaultRetention {
+		t.Errorf("Retention() = %v, want %v", h.Retention(), DefaultRetention)
+	}
+	now := time.Now()
+	h.SetStatus(now, errors.New("daemon unreachable"))
+	if at, msg := h.Status(); !at.Equal(now) || msg != "daemon unreachable" {
+		t.Errorf("Status() = %v, %q", at, msg)
+	}
+	h.SetStatus(now, nil)
+	if _, msg := h.Status(); msg != "" {
+		t.Errorf("Status() error = %q after a clean round", msg)
+	}
+}
+
+func Test_Rank(t *testing.T) {
+	items := []testSample{{value: 1}, {value: 3}, {value: 2}, {value: 3}}
+	names := map[float64]string{1: "a", 2: "b", 3: "c"}
+	got := Rank(items, func(s testSample) float64 { return s.value }, func(s testSample) string { return names[s.value] }, 3)
+	if len(got) != 3 || got[0].value != 3 || got[1].value != 3 || got[2].value != 2 {
+		t.Errorf("Rank() = %+v", got)
+	}
+	if got := Rank(items, func(s testSample) float64 { return s.value }, func(testSample) string { return "" }, 0); len(got) != 4 {
+		t.Errorf("Rank(no limit) kept %d items, want 4", len(got))
+	}
+}
+
+func Test_ParseWindow(t *testing.T) {
+	tests := []struct {
+		arg     string
+		want    time.Duration
+		wantErr string
+	}{
+		{"", DefaultWindow, ""},
+		{"10m", 10 * time.Minute, ""},
+		{"3h", time.Hour, ""}, // capped at the retention
+		{"-5m", 0, "invalid window"},
+		{"soon", 0, "invalid window"},
+	}
+	for _, tt := range tests {
+		got, err := ParseWindow(tt.arg, time.Hour)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseWindow(%q) error = %v, want %q", tt.arg, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseWindow(%q) = %v, %v; want %v", tt.arg, got, err, tt.want)
+		}
+	}
+}
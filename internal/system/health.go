@@ -8,6 +8,8 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+
+	"golang.org/x/sys/unix"
 )
 
 // FileSystemMonitor implements SystemMonitor by reading data from the local
@@ -16,6 +18,10 @@ type FileSystemMonitor struct {
 	procPath   string
 	sysPath    string
 	emhttpPath string
+
+	// statfs returns the size and used space of the filesystem holding path.
+	// Tests replace it; it defaults to statfsUsage.
+	statfs func(path string) (total, used uint64, err error)
 }
 
 // NewFileSystemMonitor returns a new FileSystemMonitor configured to read from
@@ -29,6 +35,7 @@ func NewFileSystemMonitor(procPath, sysPath, emhttpPath string) *FileSystemMonit
 		procPath:   procPath,
 		sysPath:    sysPath,
 		emhttpPath: emhttpPath,
+		statfs:     statfsUsage,
 	}
 }
 
@@ -247,6 +254,142 @@ func (m *FileSystemMonitor) GetDiskInfo(ctx context.Context) ([]DiskInfo, error)
 	return disks, nil
 }
 
+// dockerMountPoint is where Unraid mounts docker.img.
+const dockerMountPoint = "/var/lib/docker"
+
+// GetDockerImageUsage finds the /var/lib/docker mount in the host's mount
+// table ({procPath}/1/mountinfo, falling back to {procPath}/mounts) and takes
+// the used space from statfs(2) on {procPath}/1/root/var/lib/docker, the
+// mount as seen from the host's root. For a loop device it reads the image
+// size and backing file from {sysPath}/block/<loop>, and for btrfs the chunk
+// allocation from {sysPath}/fs/btrfs/<uuid>/allocation. The allocation only
+// stands in for the used space when statfs is not possible, since btrfs
+// allocates chunks well ahead of the data written to them. With neither, the
+// used space is unknown and the statfs error is returned.
+func (m *FileSystemMonitor) GetDockerImageUsage(ctx context.Context) (*DockerImageUsage, error) {
+	device, fsType, err := m.findMount(dockerMountPoint)
+	if err != nil {
+		return nil, err
+	}
+	u := &DockerImageUsage{MountPoint: dockerMountPoint, Device: device, FsType: fsType}
+
+	fsTotal, fsUsed, statErr := m.statfs(filepath.Join(m.procPath, "1", "root", dockerMountPoint))
+	if statErr == nil {
+		u.TotalBytes, u.UsedBytes = fsTotal, fsUsed
+	}
+
+	if name := filepath.Base(device); strings.HasPrefix(device, "/dev/loop") {
+		u.Loop = true
+
+		blockDir := filepath.Join(m.sysPath, "block", name)
+		sectors, err := readSysUint(filepath.Join(blockDir, "size"))
+		if err != nil {
+			return nil, fmt.Errorf("read %s size: %w", name, err)
+		}
+		// The image size rather than the filesystem size, which leaves out
+		// the space the filesystem keeps for itself.
+		u.TotalBytes = sectors * 512
+		if data, err := os.ReadFile(filepath.Join(blockDir, "loop", "backing_file")); err == nil {
+			u.BackingFile = strings.TrimSpace(string(data))
+		}
+
+		if fsType == "btrfs" {
+			allocUsed, allocTotal := m.btrfsAllocation(name)
+			u.AllocatedBytes = allocTotal
+			if statErr != nil && allocTotal > 0 {
+				u.UsedBytes = allocUsed
+				statErr = nil
+			}
+		}
+	}
+	if statErr != nil {
+		return nil, statErr
+	}
+	if u.TotalBytes > 0 {
+		u.UsedPercent = float64(u.UsedBytes) / float64(u.TotalBytes) * 100
+	}
+	return u, nil
+}
+
+// btrfsAllocation sums the used and allocated chunk space of the btrfs
+// filesystem on device name from {sysPath}/fs/btrfs/<uuid>/allocation.
+func (m *FileSystemMonitor) btrfsAllocation(name string) (used, total uint64) {
+	devices, _ := filepath.Glob(filepath.Join(m.sysPath, "fs", "btrfs", "*", "devices", name))
+	if len(devices) == 0 {
+		return 0, 0
+	}
+	allocation := filepath.Join(filepath.Dir(filepath.Dir(devices[0])), "allocation")
+	for _, kind := range []string{"data", "metadata", "system"} {
+		dir := filepath.Join(allocation, kind)
+		// disk_used accounts for duplicated metadata; older kernels only
+		// have bytes_used.
+		u, err := readSysUint(filepath.Join(dir, "disk_used"))
+		if err != nil {
+			u, _ = readSysUint(filepath.Join(dir, "bytes_used"))
+		}
+		t, err := readSysUint(filepath.Join(dir, "disk_total"))
+		if err != nil {
+			t, _ = readSysUint(filepath.Join(dir, "total_bytes"))
+		}
+		used += u
+		total += t
+	}
+	return used, total
+}
+
+// statfsUsage returns the size and used space of the filesystem holding path.
+func statfsUsage(path string) (total, used uint64, err error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return 0, 0, fmt.Errorf("statfs %s: %w", path, err)
+	}
+	bsize := uint64(st.Bsize)
+	return st.Blocks * bsize, (st.Blocks - st.Bfree) * bsize, nil
+}
+
+// findMount returns the device and filesystem type mounted at mountPoint,
+// preferring the host's mount namespace (PID 1) over the server's own.
+func (m *FileSystemMonitor) findMount(mountPoint string) (device, fsType string, err error) {
+	if data, err := os.ReadFile(filepath.Join(m.procPath, "1", "mountinfo")); err == nil {
+		// ID parent major:minor root mountpoint options [optional...] - fstype source superoptions
+		for _, line := range strings.Split(string(data), "\n") {
+			pre, post, ok := strings.Cut(line, " - ")
+			fields, tail := strings.Fields(pre), strings.Fields(post)
+			if ok && len(fields) >= 5 && len(tail) >= 2 && fields[4] == mountPoint {
+				device, fsType = tail[1], tail[0]
+			}
+		}
+		if device != "" {
+			return device, fsType, nil
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(m.procPath, "mounts"))
+	if err != nil {
+		return "", "", fmt.Errorf("read mounts: %w", err)
+	}
+	// device mountpoint fstype options dump pass
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 3 && fields[1] == mountPoint {
+			device, fsType = fields[0], fields[2]
+		}
+	}
+	if device == "" {
+		return "", "", fmt.Errorf("%s is not mounted", mountPoint)
+	}
+	return device, fsType, nil
+}
+
+// readSysUint reads a single unsigned integer from a sysfs file.
+func readSysUint(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+}
+
 // ---------------------------------------------------------------------------
 // Internal ini parsers
 // ---------------------------------------------------------------------------
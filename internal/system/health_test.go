@@ -2,6 +2,7 @@ package system
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -835,6 +836,126 @@ func Test_GetDiskInfo_ReturnsSlice(t *testing.T) {
 	}
 }
 
+func Test_GetDockerImageUsage_Cases(t *testing.T) {
+	const gib = 1 << 30
+	btrfsSys := map[string]string{
+		"block/loop2/size":                             "41943040\n", // 20 GiB in 512-byte sectors
+		"block/loop2/loop/backing_file":                "/mnt/user/system/docker/docker.img\n",
+		"fs/btrfs/abcd/devices/loop2/.keep":            "",
+		"fs/btrfs/abcd/allocation/data/disk_used":      "15032385536\n", // 14 GiB
+		"fs/btrfs/abcd/allocation/data/disk_total":     "16106127360\n", // 15 GiB
+		"fs/btrfs/abcd/allocation/metadata/disk_used":  "1073741824\n",  // 1 GiB
+		"fs/btrfs/abcd/allocation/metadata/disk_total": "2147483648\n",  // 2 GiB
+	}
+
+	tests := []struct {
+		name    string
+		proc    map[string]string
+		sys     map[string]string
+		statfs  []uint64 // total and used bytes; statfs fails when nil
+		wantErr string
+		want    DockerImageUsage
+	}{
+		{
+			name: "btrfs docker.img from host mountinfo",
+			proc: map[string]string{
+				"1/mountinfo": "22 1 0:21 / / rw - rootfs rootfs rw\n" +
+					"80 22 0:45 / /var/lib/docker rw,noatime shared:40 - btrfs /dev/loop2 rw,space_cache\n",
+				"mounts": "overlay / overlay rw 0 0\n",
+			},
+			sys: btrfsSys,
+			want: DockerImageUsage{
+				MountPoint: "/var/lib/docker", Device: "/dev/loop2", FsType: "btrfs", Loop: true,
+				BackingFile: "/mnt/user/system/docker/docker.img",
+				TotalBytes:  20 * gib, UsedBytes: 15 * gib, AllocatedBytes: 17 * gib, UsedPercent: 75,
+			},
+		},
+		{
+			name:   "btrfs docker.img used space from statfs",
+			proc:   map[string]string{"mounts": "/dev/loop2 /var/lib/docker btrfs rw 0 0\n"},
+			sys:    btrfsSys,
+			statfs: []uint64{19 * gib, 5 * gib},
+			want: DockerImageUsage{
+				MountPoint: "/var/lib/docker", Device: "/dev/loop2", FsType: "btrfs", Loop: true,
+				BackingFile: "/mnt/user/system/docker/docker.img",
+				TotalBytes:  20 * gib, UsedBytes: 5 * gib, AllocatedBytes: 17 * gib, UsedPercent: 25,
+			},
+		},
+		{
+			name:   "xfs image from mounts",
+			proc:   map[string]string{"mounts": "/dev/loop3 /var/lib/docker xfs rw 0 0\n"},
+			sys:    map[string]string{"block/loop3/size": "2097152\n"},
+			statfs: []uint64{gib - gib/64, gib / 4},
+			want: DockerImageUsage{
+				MountPoint: "/var/lib/docker", Device: "/dev/loop3", FsType: "xfs", Loop: true,
+				TotalBytes: gib, UsedBytes: gib / 4, UsedPercent: 25,
+			},
+		},
+		{
+			name:    "xfs image without statfs",
+			proc:    map[string]string{"mounts": "/dev/loop3 /var/lib/docker xfs rw 0 0\n"},
+			sys:     map[string]string{"block/loop3/size": "2097152\n"},
+			wantErr: "no such file or directory",
+		},
+		{
+			name:    "btrfs image without statfs or allocation",
+			proc:    map[string]string{"mounts": "/dev/loop2 /var/lib/docker btrfs rw 0 0\n"},
+			sys:     map[string]string{"block/loop2/size": "41943040\n"},
+			wantErr: "no such file or directory",
+		},
+		{
+			name:    "directory without statfs",
+			proc:    map[string]string{"mounts": "/dev/md1p1 /var/lib/docker xfs rw 0 0\n"},
+			sys:     map[string]string{},
+			wantErr: "no such file or directory",
+		},
+		{
+			name:   "directory with statfs",
+			proc:   map[string]string{"mounts": "/dev/md1p1 /var/lib/docker xfs rw 0 0\n"},
+			sys:    map[string]string{},
+			statfs: []uint64{8 * gib, 2 * gib},
+			want: DockerImageUsage{
+				MountPoint: "/var/lib/docker", Device: "/dev/md1p1", FsType: "xfs",
+				TotalBytes: 8 * gib, UsedBytes: 2 * gib, UsedPercent: 25,
+			},
+		},
+		{
+			name:    "not mounted",
+			proc:    map[string]string{"mounts": "overlay / overlay rw 0 0\n"},
+			sys:     map[string]string{},
+			wantErr: "not mounted",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			procPath := writeTempDir(t, tt.proc)
+			m := NewFileSystemMonitor(procPath, writeTempDir(t, tt.sys), testdataEmhttpPath(t))
+			m.statfs = func(path string) (uint64, uint64, error) {
+				if want := filepath.Join(procPath, "1", "root", "var", "lib", "docker"); path != want {
+					t.Errorf("statfs(%q), want %q", path, want)
+				}
+				if tt.statfs == nil {
+					return 0, 0, errors.New("no such file or directory")
+				}
+				return tt.statfs[0], tt.statfs[1], nil
+			}
+			got, err := m.GetDockerImageUsage(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *got != tt.want {
+				t.Errorf("GetDockerImageUsage() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Benchmark Tests
 // ---------------------------------------------------------------------------
@@ -77,6 +77,43 @@ type DiskInfo struct {
 	FsUsed uint64
 }
 
+// DockerImageUsage describes the filesystem holding Docker's data directory,
+// which on Unraid is normally the loop-mounted docker.img.
+type DockerImageUsage struct {
+	// MountPoint is the Docker data directory (/var/lib/docker).
+	MountPoint string
+
+	// Device is the mounted block device (e.g. "/dev/loop2").
+	Device string
+
+	// FsType is the filesystem type of the mount (e.g. "btrfs", "xfs").
+	FsType string
+
+	// Loop reports whether the mount is a loop device, i.e. an image file
+	// rather than a directory on a share.
+	Loop bool
+
+	// BackingFile is the image file behind the loop device
+	// (e.g. "/mnt/user/system/docker/docker.img").
+	BackingFile string
+
+	// TotalBytes is the size of the loop device, or of the filesystem when
+	// Docker's data lives in a directory.
+	TotalBytes uint64
+
+	// UsedBytes is the space used on the filesystem as statfs(2) reports it.
+	// When the mount cannot be reached it falls back to the btrfs chunk
+	// allocation.
+	UsedBytes uint64
+
+	// AllocatedBytes is the space btrfs has reserved in chunks, which runs
+	// ahead of UsedBytes; 0 for other filesystems.
+	AllocatedBytes uint64
+
+	// UsedPercent is UsedBytes as a percentage of TotalBytes.
+	UsedPercent float64
+}
+
 // SystemMonitor defines the read-only operations for querying system health.
 type SystemMonitor interface {
 	// GetOverview returns a current snapshot of CPU, memory, and temperature data.
@@ -87,4 +124,7 @@ type SystemMonitor interface {
 
 	// GetDiskInfo returns per-disk details for every disk known to emhttp.
 	GetDiskInfo(ctx context.Context) ([]DiskInfo, error)
+
+	// GetDockerImageUsage returns the capacity and usage of docker.img.
+	GetDockerImageUsage(ctx context.Context) (*DockerImageUsage, error)
 }
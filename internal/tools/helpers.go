@@ -2,12 +2,15 @@
 package tools
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jamesprial/unraid-mcp/internal/safety"
 	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
 )
 
 // JSONResult marshals v to indented JSON and returns an mcp.CallToolResult.
@@ -39,10 +42,51 @@ func LogAudit(audit *safety.AuditLogger, toolName string, params map[string]any,
 }
 
 // ConfirmPrompt issues a confirmation request and returns the prompt result.
-func ConfirmPrompt(confirm *safety.ConfirmationTracker, toolName, resource, description string) *mcp.CallToolResult {
-	token := confirm.RequestConfirmation(toolName, resource, description)
+// The issued token is bound to toolName, resource, and args; the follow-up call
+// must pass the same values to Confirm.
+func ConfirmPrompt(confirm *safety.ConfirmationTracker, toolName, resource, description string, args map[string]any) *mcp.CallToolResult {
+	token := confirm.RequestConfirmation(toolName, resource, description, args)
 	return mcp.NewToolResultText(fmt.Sprintf(
 		"Confirmation required for %s on %q.\n\n%s\n\nTo proceed, call %s again with confirmation_token=%q.",
 		toolName, resource, description, toolName, token,
 	))
 }
+
+// NotifyProgress sends a progress notification for req to the client that
+// made it. It does nothing unless the client asked for progress by sending
+// a progress token; a total of 0 means the total is unknown.
+func NotifyProgress(ctx context.Context, req mcp.CallToolRequest, progress, total float64, message string) {
+	if req.Params.Meta == nil || req.Params.Meta.ProgressToken == nil {
+		return
+	}
+	srv := server.ServerFromContext(ctx)
+	if srv == nil {
+		return
+	}
+	params := map[string]any{
+		"progressToken": req.Params.Meta.ProgressToken,
+		"progress":      progress,
+	}
+	if total > 0 {
+		params["total"] = total
+	}
+	if message != "" {
+		params["message"] = message
+	}
+	_ = srv.SendNotificationToClient(ctx, "notifications/progress", params)
+}
+
+// FormatBytes formats n with a binary unit, e.g. "1.5 GiB".
+func FormatBytes[T int64 | uint64](n T) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := T(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	value := strings.TrimSuffix(fmt.Sprintf("%.1f", float64(n)/float64(div)), ".0")
+	return fmt.Sprintf("%s %ciB", value, "KMGTPE"[exp])
+}
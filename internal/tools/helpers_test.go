@@ -2,6 +2,7 @@ package tools_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"regexp"
@@ -372,7 +373,7 @@ func Test_LogAudit_TimestampMatchesStart(t *testing.T) {
 func Test_ConfirmPrompt_StandardPrompt(t *testing.T) {
 	confirm := safety.NewConfirmationTracker([]string{"docker_stop"})
 
-	result := tools.ConfirmPrompt(confirm, "docker_stop", "my-container", "stop it")
+	result := tools.ConfirmPrompt(confirm, "docker_stop", "my-container", "stop it", nil)
 	text := resultText(t, result)
 
 	// Verify all required substrings are present.
@@ -392,7 +393,7 @@ func Test_ConfirmPrompt_StandardPrompt(t *testing.T) {
 func Test_ConfirmPrompt_FormatStructure(t *testing.T) {
 	confirm := safety.NewConfirmationTracker([]string{"docker_stop"})
 
-	result := tools.ConfirmPrompt(confirm, "docker_stop", "my-container", "Stop the container gracefully")
+	result := tools.ConfirmPrompt(confirm, "docker_stop", "my-container", "Stop the container gracefully", nil)
 	text := resultText(t, result)
 
 	// The expected format is:
@@ -420,8 +421,8 @@ func Test_ConfirmPrompt_FormatStructure(t *testing.T) {
 func Test_ConfirmPrompt_TokenUnique(t *testing.T) {
 	confirm := safety.NewConfirmationTracker([]string{"docker_stop"})
 
-	result1 := tools.ConfirmPrompt(confirm, "docker_stop", "container-a", "stop a")
-	result2 := tools.ConfirmPrompt(confirm, "docker_stop", "container-a", "stop a")
+	result1 := tools.ConfirmPrompt(confirm, "docker_stop", "container-a", "stop a", nil)
+	result2 := tools.ConfirmPrompt(confirm, "docker_stop", "container-a", "stop a", nil)
 
 	text1 := resultText(t, result1)
 	text2 := resultText(t, result2)
@@ -437,7 +438,7 @@ func Test_ConfirmPrompt_TokenUnique(t *testing.T) {
 func Test_ConfirmPrompt_TokenConsumable(t *testing.T) {
 	confirm := safety.NewConfirmationTracker([]string{"docker_stop"})
 
-	result := tools.ConfirmPrompt(confirm, "docker_stop", "my-container", "stop it")
+	result := tools.ConfirmPrompt(confirm, "docker_stop", "my-container", "stop it", nil)
 	text := resultText(t, result)
 	token := extractToken(t, text)
 
@@ -446,12 +447,12 @@ func Test_ConfirmPrompt_TokenConsumable(t *testing.T) {
 	}
 
 	// First confirmation should succeed.
-	if !confirm.Confirm(token) {
+	if !confirm.Confirm(token, "docker_stop", "my-container", nil) {
 		t.Error("Confirm(token) should return true on first use")
 	}
 
 	// Second confirmation should fail (single-use).
-	if confirm.Confirm(token) {
+	if confirm.Confirm(token, "docker_stop", "my-container", nil) {
 		t.Error("Confirm(token) should return false on second use (single-use token)")
 	}
 }
@@ -493,7 +494,7 @@ func Test_ConfirmPrompt_DifferentToolsAndResources(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			confirm := safety.NewConfirmationTracker([]string{tt.tool})
 
-			result := tools.ConfirmPrompt(confirm, tt.tool, tt.resource, tt.description)
+			result := tools.ConfirmPrompt(confirm, tt.tool, tt.resource, tt.description, nil)
 			text := resultText(t, result)
 
 			// Tool name must appear in the text.
@@ -512,7 +513,7 @@ func Test_ConfirmPrompt_DifferentToolsAndResources(t *testing.T) {
 
 func Test_ConfirmPrompt_ReturnsNonNil(t *testing.T) {
 	confirm := safety.NewConfirmationTracker([]string{"test_tool"})
-	result := tools.ConfirmPrompt(confirm, "test_tool", "res", "desc")
+	result := tools.ConfirmPrompt(confirm, "test_tool", "res", "desc", nil)
 	if result == nil {
 		t.Fatal("ConfirmPrompt returned nil")
 	}
@@ -558,7 +559,7 @@ func Benchmark_ConfirmPrompt(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		tools.ConfirmPrompt(confirm, "test_tool", "resource", "description")
+		tools.ConfirmPrompt(confirm, "test_tool", "resource", "description", nil)
 	}
 }
 
@@ -693,3 +694,34 @@ func Test_ErrorResult_PrefixFormat(t *testing.T) {
 		})
 	}
 }
+
+func Test_NotifyProgress_NoProgressToken_NoPanic(t *testing.T) {
+	// Without a progress token, or outside a server, there is no one to notify.
+	tools.NotifyProgress(context.Background(), mcp.CallToolRequest{}, 1, 2, "halfway")
+
+	var req mcp.CallToolRequest
+	req.Params.Meta = &mcp.Meta{ProgressToken: "tok"}
+	tools.NotifyProgress(context.Background(), req, 1, 0, "")
+}
+
+func Test_FormatBytes(t *testing.T) {
+	tests := []struct {
+		in   int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1 KiB"},
+		{1536, "1.5 KiB"},
+		{5 << 30, "5 GiB"},
+		{3 << 40, "3 TiB"},
+	}
+	for _, tt := range tests {
+		if got := tools.FormatBytes(tt.in); got != tt.want {
+			t.Errorf("FormatBytes(%d) = %q, want %q", tt.in, got, tt.want)
+		}
+		if got := tools.FormatBytes(uint64(tt.in)); got != tt.want {
+			t.Errorf("FormatBytes(uint64(%d)) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
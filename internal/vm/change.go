@@ -0,0 +1,396 @@
+// Package vm provides virtual machine management for Unraid systems via libvirt.
+package vm
+
+import (
+	"crypto/rand"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ----------------------------------------------------------------------------
+// Configuration changes
+// ----------------------------------------------------------------------------
+
+// diffContext is the number of unchanged lines xmlDiff shows around a change.
+const diffContext = 2
+
+var (
+	vcpuPattern     = regexp.MustCompile(`<vcpu\b([^>]*)>\s*\d+\s*</vcpu>`)
+	vcpuCurrentAttr = regexp.MustCompile(`\s+current=['"]\d+['"]`)
+	topologyPattern = regexp.MustCompile(`<topology\b[^>]*/>`)
+	topologyAttr    = regexp.MustCompile(`\b(sockets|dies|clusters|cores|threads)=['"](\d+)['"]`)
+	vcpupinPattern  = regexp.MustCompile(`\s*<vcpupin\s+vcpu=['"](\d+)['"][^>]*/>`)
+	emptyCputune    = regexp.MustCompile(`\s*<cputune>\s*</cputune>`)
+)
+
+// ParseVMChangeMode parses the mode argument of a configuration change tool;
+// empty means VMChangeBoth.
+func ParseVMChangeMode(s string) (VMChangeMode, error) {
+	switch m := VMChangeMode(s); m {
+	case "":
+		return VMChangeBoth, nil
+	case VMChangeBoth, VMChangeLive, VMChangePersistent:
+		return m, nil
+	}
+	return "", fmt.Errorf("invalid mode %q (want both, live or persistent)", s)
+}
+
+// xmlDiff returns a unified-style line diff of two XML documents, with
+// diffContext unchanged lines around each change. It is empty when the
+// documents are equal.
+func xmlDiff(before, after string) string {
+	a := strings.Split(strings.TrimRight(before, "\n"), "\n")
+	b := strings.Split(strings.TrimRight(after, "\n"), "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	type diffLine struct {
+		op           byte // ' ', '-' or '+'
+		text         string
+		aLine, bLine int // 1-based line numbers before each line
+	}
+	var lines []diffLine
+	changed := false
+	for i, j := 0, 0; i < len(a) || j < len(b); {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, diffLine{' ', a[i], i + 1, j + 1})
+			i, j = i+1, j+1
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, diffLine{'-', a[i], i + 1, j + 1})
+			i, changed = i+1, true
+		default:
+			lines = append(lines, diffLine{'+', b[j], i + 1, j + 1})
+			j, changed = j+1, true
+		}
+	}
+	if !changed {
+		return ""
+	}
+
+	show := make([]bool, len(lines))
+	for k, l := range lines {
+		if l.op == ' ' {
+			continue
+		}
+		for c := max(0, k-diffContext); c <= min(len(lines)-1, k+diffContext); c++ {
+			show[c] = true
+		}
+	}
+
+	var out strings.Builder
+	for k := 0; k < len(lines); {
+		if !show[k] {
+			k++
+			continue
+		}
+		end := k
+		var aCount, bCount int
+		for ; end < len(lines) && show[end]; end++ {
+			if lines[end].op != '+' {
+				aCount++
+			}
+			if lines[end].op != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", lines[k].aLine, aCount, lines[k].bLine, bCount)
+		for _, l := range lines[k:end] {
+			out.WriteByte(l.op)
+			out.WriteString(l.text)
+			out.WriteByte('\n')
+		}
+		k = end
+	}
+	return out.String()
+}
+
+// domainDevice is a disk or interface element of a domain document. XML
+// holds the element verbatim, so that it can be passed back to libvirt to
+// detach or update the device.
+type domainDevice struct {
+	XML  string
+	Disk defDiskXML      // set for disk elements
+	NIC  defInterfaceXML // set for interface elements
+}
+
+// rawElementXML captures an element with its attributes and content as is.
+type rawElementXML struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Inner   string     `xml:",innerxml"`
+}
+
+type rawDevicesXML struct {
+	Disks      []rawElementXML `xml:"devices>disk"`
+	Interfaces []rawElementXML `xml:"devices>interface"`
+}
+
+// parseDomainDevices returns the disk and interface elements of a domain
+// document.
+func parseDomainDevices(doc string) (disks, nics []domainDevice, err error) {
+	var raw rawDevicesXML
+	if err := xml.Unmarshal([]byte(doc), &raw); err != nil {
+		return nil, nil, fmt.Errorf("parse domain xml: %w", err)
+	}
+	for _, r := range raw.Disks {
+		dev, err := decodeDevice(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := xml.Unmarshal([]byte(dev.XML), &dev.Disk); err != nil {
+			return nil, nil, fmt.Errorf("parse disk xml: %w", err)
+		}
+		disks = append(disks, dev)
+	}
+	for _, r := range raw.Interfaces {
+		dev, err := decodeDevice(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := xml.Unmarshal([]byte(dev.XML), &dev.NIC); err != nil {
+			return nil, nil, fmt.Errorf("parse interface xml: %w", err)
+		}
+		nics = append(nics, dev)
+	}
+	return disks, nics, nil
+}
+
+// decodeDevice re-encodes a captured element as a standalone document.
+func decodeDevice(r rawElementXML) (domainDevice, error) {
+	out, err := xml.Marshal(r)
+	if err != nil {
+		return domainDevice{}, fmt.Errorf("encode %s xml: %w", r.XMLName.Local, err)
+	}
+	return domainDevice{XML: string(out)}, nil
+}
+
+// findDisk returns the disk (not cdrom) of a domain document whose target
+// device or image path is key.
+func findDisk(doc, key string) (domainDevice, error) {
+	disks, _, err := parseDomainDevices(doc)
+	if err != nil {
+		return domainDevice{}, err
+	}
+	for _, d := range disks {
+		if d.Disk.Device != "" && d.Disk.Device != "disk" {
+			continue
+		}
+		if d.Disk.Target.Dev == key || (d.Disk.Source != nil && (d.Disk.Source.File == key || d.Disk.Source.Dev == key)) {
+			return d, nil
+		}
+	}
+	return domainDevice{}, fmt.Errorf("no disk %q (give its target, e.g. hdd, or its image path)", key)
+}
+
+// findCDROM returns the cdrom drive of a domain document with target device
+// drive, or the first one when drive is empty.
+func findCDROM(doc, drive string) (domainDevice, error) {
+	disks, _, err := parseDomainDevices(doc)
+	if err != nil {
+		return domainDevice{}, err
+	}
+	for _, d := range disks {
+		if d.Disk.Device == "cdrom" && (drive == "" || d.Disk.Target.Dev == drive) {
+			return d, nil
+		}
+	}
+	if drive == "" {
+		return domainDevice{}, fmt.Errorf("no cdrom drive")
+	}
+	return domainDevice{}, fmt.Errorf("no cdrom drive %q", drive)
+}
+
+// findNIC returns the interface of a domain document with the given MAC
+// address.
+func findNIC(doc, mac string) (domainDevice, error) {
+	_, nics, err := parseDomainDevices(doc)
+	if err != nil {
+		return domainDevice{}, err
+	}
+	for _, n := range nics {
+		if strings.EqualFold(n.NIC.MAC.Address, mac) {
+			return n, nil
+		}
+	}
+	return domainDevice{}, fmt.Errorf("no interface with mac address %q", mac)
+}
+
+// nextDiskTarget returns the first hdX target, from hdc on, that none of the
+// domain documents uses. Passing both the live and the persistent document
+// keeps a new disk clear of either.
+func nextDiskTarget(docs ...string) (string, error) {
+	used := make(map[string]bool)
+	for _, doc := range docs {
+		disks, _, err := parseDomainDevices(doc)
+		if err != nil {
+			return "", err
+		}
+		for _, d := range disks {
+			used[d.Disk.Target.Dev] = true
+		}
+	}
+	for c := 'c'; c <= 'z'; c++ {
+		if dev := "hd" + string(c); !used[dev] {
+			return dev, nil
+		}
+	}
+	return "", fmt.Errorf("no free disk target left")
+}
+
+// nextVDiskPath returns the first vdiskN.img in the VM's folder of the
+// domains share that is not one of its disks.
+func nextVDiskPath(name string, disks []VMDisk) string {
+	used := make(map[string]bool, len(disks))
+	for _, d := range disks {
+		used[d.Source] = true
+	}
+	for n := 1; ; n++ {
+		p := path.Join(unraidDomainsDir, name, fmt.Sprintf("vdisk%d.img", n))
+		if !used[p] {
+			return p
+		}
+	}
+}
+
+// diskDeviceXML returns the element that attaches a normalized disk at the
+// given target.
+func diskDeviceXML(d VMDiskSpec, target string) (string, error) {
+	out, err := xml.Marshal(defDiskXML{
+		Type:   "file",
+		Device: "disk",
+		Driver: defDiskDriverXML{Name: "qemu", Type: d.Format, Cache: "writeback"},
+		Source: &defDiskSourceXML{File: d.Path},
+		Target: defDiskTargetXML{Dev: target, Bus: d.Bus},
+	})
+	if err != nil {
+		return "", fmt.Errorf("encode disk xml: %w", err)
+	}
+	return string(out), nil
+}
+
+// cdromMediaXML returns the element that updates a cdrom drive to hold iso,
+// or to be empty when iso is "". The drive's target, bus and boot order are
+// kept, as libvirt refuses to change them in an update.
+func cdromMediaXML(drive defDiskXML, iso string) (string, error) {
+	cd := defDiskXML{
+		Type:     "file",
+		Device:   "cdrom",
+		Driver:   defDiskDriverXML{Name: "qemu", Type: "raw"},
+		Target:   drive.Target,
+		ReadOnly: &struct{}{},
+		Boot:     drive.Boot,
+	}
+	if iso != "" {
+		cd.Source = &defDiskSourceXML{File: iso}
+	}
+	out, err := xml.Marshal(cd)
+	if err != nil {
+		return "", fmt.Errorf("encode cdrom xml: %w", err)
+	}
+	return string(out), nil
+}
+
+// nicDeviceXML returns the element that attaches a normalized interface.
+func nicDeviceXML(n VMNICSpec) (string, error) {
+	out, err := xml.Marshal(defInterfaceXML{
+		Type:   "bridge",
+		MAC:    defMACXML{Address: n.MAC},
+		Source: defBridgeXML{Bridge: n.Bridge},
+		Model:  defModelTypeXML{Type: n.Model},
+	})
+	if err != nil {
+		return "", fmt.Errorf("encode interface xml: %w", err)
+	}
+	return string(out), nil
+}
+
+// normalizeNIC fills in the defaults of an interface to attach. Unlike in a
+// new VM the MAC address is random, as one derived from the VM could repeat
+// that of an interface detached before.
+func normalizeNIC(n VMNICSpec) (VMNICSpec, error) {
+	if n.MAC == "" {
+		n.MAC = randomMAC()
+	}
+	s := VMCreateSpec{NICs: []VMNICSpec{n}}
+	if err := s.normalizeNICs(); err != nil {
+		return n, err
+	}
+	return s.NICs[0], nil
+}
+
+// randomMAC returns a random MAC address in QEMU's 52:54:00 range.
+func randomMAC() string {
+	var b [3]byte
+	_, _ = rand.Read(b[:])
+	return fmt.Sprintf("52:54:00:%02x:%02x:%02x", b[0], b[1], b[2])
+}
+
+// setVCPUsXML returns the persistent domain document doc with vcpus vCPUs
+// as a single socket of threads threads per core; threads 0 keeps the
+// current number where it divides vcpus. Pins of vCPUs that no longer exist
+// are dropped. The document is edited as text, so that everything else in
+// it is kept exactly as libvirt wrote it.
+func setVCPUsXML(doc string, vcpus, threads int) (string, error) {
+	if vcpus < 1 {
+		return "", fmt.Errorf("invalid vcpu count %d", vcpus)
+	}
+	if !vcpuPattern.MatchString(doc) {
+		return "", fmt.Errorf("domain xml has no <vcpu> element")
+	}
+	doc = vcpuPattern.ReplaceAllStringFunc(doc, func(el string) string {
+		attrs := vcpuCurrentAttr.ReplaceAllString(vcpuPattern.FindStringSubmatch(el)[1], "")
+		return fmt.Sprintf("<vcpu%s>%d</vcpu>", attrs, vcpus)
+	})
+
+	if topo := topologyPattern.FindString(doc); topo != "" {
+		if threads == 0 {
+			threads = 1
+			for _, m := range topologyAttr.FindAllStringSubmatch(topo, -1) {
+				if n, _ := strconv.Atoi(m[2]); m[1] == "threads" && n > 0 && vcpus%n == 0 {
+					threads = n
+				}
+			}
+		}
+		if threads < 1 || vcpus%threads != 0 {
+			return "", fmt.Errorf("%d vCPUs cannot be split into cores of %d threads", vcpus, threads)
+		}
+		newTopo := topologyAttr.ReplaceAllStringFunc(topo, func(attr string) string {
+			m := topologyAttr.FindStringSubmatch(attr)
+			switch m[1] {
+			case "cores":
+				return fmt.Sprintf("cores='%d'", vcpus/threads)
+			case "threads":
+				return fmt.Sprintf("threads='%d'", threads)
+			}
+			return m[1] + "='1'"
+		})
+		doc = strings.Replace(doc, topo, newTopo, 1)
+	}
+
+	doc = vcpupinPattern.ReplaceAllStringFunc(doc, func(pin string) string {
+		if n, _ := strconv.Atoi(vcpupinPattern.FindStringSubmatch(pin)[1]); n >= vcpus {
+			return ""
+		}
+		return pin
+	})
+	return emptyCputune.ReplaceAllString(doc, ""), nil
+}
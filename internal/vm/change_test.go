@@ -0,0 +1,372 @@
+package vm
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+)
+
+// testDomainXML is a persistent domain document in the shape Unraid writes.
+const testDomainXML = `<domain type='kvm'>
+  <name>win10</name>
+  <memory unit='KiB'>4194304</memory>
+  <vcpu placement='static' current='2'>4</vcpu>
+  <cputune>
+    <vcpupin vcpu='0' cpuset='2'/>
+    <vcpupin vcpu='1' cpuset='3'/>
+    <vcpupin vcpu='2' cpuset='4'/>
+    <vcpupin vcpu='3' cpuset='5'/>
+  </cputune>
+  <cpu mode='host-passthrough' check='none' migratable='on'>
+    <topology sockets='1' dies='1' clusters='1' cores='2' threads='2'/>
+  </cpu>
+  <devices>
+    <disk type='file' device='disk'>
+      <driver name='qemu' type='raw' cache='writeback'/>
+      <source file='/mnt/user/domains/win10/vdisk1.img'/>
+      <target dev='hdc' bus='virtio'/>
+      <boot order='1'/>
+    </disk>
+    <disk type='file' device='cdrom'>
+      <driver name='qemu' type='raw'/>
+      <source file='/mnt/user/isos/win10.iso'/>
+      <target dev='hda' bus='sata'/>
+      <readonly/>
+      <boot order='2'/>
+    </disk>
+    <disk type='file' device='cdrom'>
+      <driver name='qemu' type='raw'/>
+      <target dev='hdb' bus='sata'/>
+      <readonly/>
+    </disk>
+    <interface type='bridge'>
+      <mac address='52:54:00:AB:cd:01'/>
+      <source bridge='br0'/>
+      <model type='virtio-net'/>
+    </interface>
+  </devices>
+</domain>`
+
+// ---------------------------------------------------------------------------
+// Diff
+// ---------------------------------------------------------------------------
+
+func Test_XMLDiff(t *testing.T) {
+	before := "a\nb\nc\nd\ne\nf\ng\nh\n"
+	if got := xmlDiff(before, before); got != "" {
+		t.Errorf("xmlDiff(equal) = %q, want empty", got)
+	}
+
+	after := "a\nb\nc\nD\ne\nf\ng\nh\ni\n"
+	want := "@@ -2,7 +2,8 @@\n b\n c\n-d\n+D\n e\n f\n g\n h\n+i\n"
+	if got := xmlDiff(before, after); got != want {
+		t.Errorf("xmlDiff() =\n%s\nwant\n%s", got, want)
+	}
+
+	// Changes further apart than twice the context get their own hunks.
+	after = "A\nb\nc\nd\ne\nf\ng\nH\n"
+	if got := xmlDiff(before, after); strings.Count(got, "@@ -") != 2 {
+		t.Errorf("xmlDiff(distant changes) =\n%s\nwant two hunks", got)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Devices
+// ---------------------------------------------------------------------------
+
+func Test_FindDevices(t *testing.T) {
+	for _, key := range []string{"hdc", "/mnt/user/domains/win10/vdisk1.img"} {
+		d, err := findDisk(testDomainXML, key)
+		if err != nil || d.Disk.Target.Dev != "hdc" || !strings.Contains(d.XML, "<boot order='1'/>") {
+			t.Errorf("findDisk(%q) = %+v, %v", key, d, err)
+		}
+	}
+	if _, err := findDisk(testDomainXML, "hda"); err == nil {
+		t.Error("findDisk(hda) found a cdrom drive")
+	}
+
+	cd, err := findCDROM(testDomainXML, "")
+	if err != nil || cd.Disk.Target.Dev != "hda" {
+		t.Errorf("findCDROM(first) = %+v, %v", cd.Disk, err)
+	}
+	cd, err = findCDROM(testDomainXML, "hdb")
+	if err != nil || cd.Disk.Source != nil {
+		t.Errorf("findCDROM(hdb) = %+v, %v; want the empty drive", cd.Disk, err)
+	}
+	if _, err := findCDROM(testDomainXML, "hdd"); err == nil {
+		t.Error("findCDROM(hdd) succeeded")
+	}
+
+	nic, err := findNIC(testDomainXML, "52:54:00:ab:CD:01")
+	if err != nil || nic.NIC.Source.Bridge != "br0" {
+		t.Errorf("findNIC() = %+v, %v", nic.NIC, err)
+	}
+	if _, err := findNIC(testDomainXML, "52:54:00:00:00:00"); err == nil {
+		t.Error("findNIC(unknown) succeeded")
+	}
+}
+
+func Test_NextDiskTarget(t *testing.T) {
+	got, err := nextDiskTarget(testDomainXML)
+	if err != nil || got != "hdd" {
+		t.Errorf("nextDiskTarget() = %q, %v, want hdd", got, err)
+	}
+	other := `<domain><devices><disk device='disk'><target dev='hdd'/></disk></devices></domain>`
+	if got, _ := nextDiskTarget(testDomainXML, other); got != "hde" {
+		t.Errorf("nextDiskTarget(both documents) = %q, want hde", got)
+	}
+
+	disks := []VMDisk{{Source: "/mnt/user/domains/win10/vdisk1.img"}}
+	if got := nextVDiskPath("win10", disks); got != "/mnt/user/domains/win10/vdisk2.img" {
+		t.Errorf("nextVDiskPath() = %q", got)
+	}
+}
+
+func Test_CDROMMediaXML(t *testing.T) {
+	cd, err := findCDROM(testDomainXML, "hda")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eject, err := cdromMediaXML(cd.Disk, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`device="cdrom"`, `<target dev="hda" bus="sata">`, `<readonly>`, `<boot order="2">`} {
+		if !strings.Contains(eject, want) {
+			t.Errorf("eject xml lacks %s:\n%s", want, eject)
+		}
+	}
+	if strings.Contains(eject, "<source") {
+		t.Errorf("eject xml has a source:\n%s", eject)
+	}
+
+	insert, _ := cdromMediaXML(cd.Disk, "/mnt/user/isos/virtio-win.iso")
+	if !strings.Contains(insert, `<source file="/mnt/user/isos/virtio-win.iso">`) {
+		t.Errorf("insert xml:\n%s", insert)
+	}
+}
+
+func Test_SetVCPUsXML(t *testing.T) {
+	got, err := setVCPUsXML(testDomainXML, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<vcpu placement='static'>2</vcpu>",
+		"<topology sockets='1' dies='1' clusters='1' cores='1' threads='2'/>",
+		"<vcpupin vcpu='1' cpuset='3'/>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("setVCPUsXML(2) lacks %s:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "vcpu='2'") || strings.Contains(got, "current=") {
+		t.Errorf("setVCPUsXML(2) kept a removed pin or the current count:\n%s", got)
+	}
+
+	// An odd count no longer fits two threads per core.
+	got, _ = setVCPUsXML(testDomainXML, 3, 0)
+	if !strings.Contains(got, "cores='3' threads='1'") {
+		t.Errorf("setVCPUsXML(3):\n%s", got)
+	}
+	if _, err := setVCPUsXML(testDomainXML, 3, 2); err == nil {
+		t.Error("setVCPUsXML(3, 2 threads) succeeded")
+	}
+
+	noPins := strings.Replace(testDomainXML, "<vcpupin vcpu='0' cpuset='2'/>", "", 1)
+	got, _ = setVCPUsXML(noPins, 1, 1)
+	if strings.Contains(got, "cputune") {
+		t.Errorf("setVCPUsXML(1) kept an empty <cputune>:\n%s", got)
+	}
+}
+
+func Test_ParseVMChangeMode(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    VMChangeMode
+		wantErr bool
+	}{
+		{"", VMChangeBoth, false},
+		{"both", VMChangeBoth, false},
+		{"live", VMChangeLive, false},
+		{"persistent", VMChangePersistent, false},
+		{"config", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseVMChangeMode(tt.in)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseVMChangeMode(%q) = %q, %v", tt.in, got, err)
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Mock
+// ---------------------------------------------------------------------------
+
+func Test_MockVMManager_Changes(t *testing.T) {
+	mgr := newSeededMock(t)
+	ctx := t.Context()
+
+	res, err := mgr.SetVMMemory(ctx, "win10", 8<<20, VMChangeBoth)
+	if err != nil || !res.Live || !res.Persistent || !strings.Contains(res.Diff, "+<memory>8388608</memory>") {
+		t.Errorf("SetVMMemory(running) = %+v, %v", res, err)
+	}
+	res, err = mgr.SetVMVCPUs(ctx, "ubuntu", 4, 0, VMChangeBoth)
+	if err != nil || res.Live || !res.Persistent {
+		t.Errorf("SetVMVCPUs(shut off) = %+v, %v; want a persistent change only", res, err)
+	}
+	if _, err := mgr.SetVMVCPUs(ctx, "ubuntu", 4, 0, VMChangeLive); err == nil {
+		t.Error("live change of a shut off vm succeeded")
+	}
+
+	if _, err := mgr.AttachDisk(ctx, "win10", VMDiskSpec{Path: "/mnt/user/domains/win10/vdisk2.img"}, VMChangeBoth); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mgr.DetachDisk(ctx, "win10", "vda", VMChangeBoth); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mgr.AttachNIC(ctx, "win10", VMNICSpec{Bridge: "br1", MAC: "52:54:00:00:00:01"}, VMChangeBoth); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mgr.DetachNIC(ctx, "win10", "52:54:00:12:34:56", VMChangeLive); err != nil {
+		t.Fatal(err)
+	}
+	d, _ := mgr.InspectVM(ctx, "win10")
+	if len(d.Disks) != 1 || d.Disks[0].Target != "hdc" || len(d.NICs) != 1 || d.NICs[0].Network != "br1" {
+		t.Errorf("after attach and detach: disks %+v, nics %+v", d.Disks, d.NICs)
+	}
+
+	errTests := []struct {
+		name string
+		call func() error
+	}{
+		{"unknown vm", func() error { _, err := mgr.SetVMMemory(ctx, "nope", 8<<20, VMChangeBoth); return err }},
+		{"too little memory", func() error { _, err := mgr.SetVMMemory(ctx, "win10", 1024, VMChangeBoth); return err }},
+		{"unknown disk", func() error { _, err := mgr.DetachDisk(ctx, "win10", "hdz", VMChangeBoth); return err }},
+		{"unknown nic", func() error { _, err := mgr.DetachNIC(ctx, "win10", "52:54:00:ff:ff:ff", VMChangeBoth); return err }},
+		{"no cdrom", func() error { _, err := mgr.ChangeMedia(ctx, "win10", "", "/x.iso", VMChangeBoth); return err }},
+	}
+	for _, tt := range errTests {
+		if tt.call() == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Tools
+// ---------------------------------------------------------------------------
+
+func Test_ChangeTools(t *testing.T) {
+	seed := seedVMs()
+	seed[1].CDROMs = []VMDisk{{Target: "hda", Type: "file"}}
+	mgr := NewMockVMManager(seed)
+	regs := VMTools(mgr, safety.NewFilter(nil, []string{"blocked"}), safety.NewConfirmationTracker(DestructiveTools), nil)
+
+	// A persistent change asks for confirmation first.
+	args := map[string]any{"name": "win10", "disk": "20g:sata"}
+	text := callStatsTool(t, regs, "vm_disk_attach", args)
+	m := vmTokenPattern.FindStringSubmatch(text)
+	if m == nil {
+		t.Fatalf("no confirmation prompt:\n%s", text)
+	}
+	if !strings.Contains(text, "/mnt/user/domains/win10/vdisk1.img") {
+		t.Errorf("prompt does not name the new image:\n%s", text)
+	}
+	if len(mgr.disks) != 0 {
+		t.Fatal("disk created before confirmation")
+	}
+	args["confirmation_token"] = m[1]
+	var res VMChangeResult
+	if err := json.Unmarshal([]byte(callStatsTool(t, regs, "vm_disk_attach", args)), &res); err != nil {
+		t.Fatalf("decode result: %v", err)
+	}
+	if !res.Live || !res.Persistent || !strings.Contains(res.Diff, `+<disk target="hdc" source="/mnt/user/domains/win10/vdisk1.img"/>`) {
+		t.Errorf("vm_disk_attach = %+v", res)
+	}
+	if mgr.disks["/mnt/user/domains/win10/vdisk1.img"] != 20<<30 {
+		t.Errorf("disks = %v", mgr.disks)
+	}
+
+	// A failed attach removes the image it created.
+	text = callStatsTool(t, regs, "vm_disk_attach", map[string]any{"name": "ubuntu", "disk": "10g", "mode": "live"})
+	if !strings.Contains(text, "not running") {
+		t.Errorf("live attach to a stopped vm = %s", text)
+	}
+	if len(mgr.disks) != 1 {
+		t.Errorf("image of the failed attach was left behind: %v", mgr.disks)
+	}
+
+	// A live-only change runs straight away.
+	text = callStatsTool(t, regs, "vm_set_memory", map[string]any{"name": "win10", "memory": "6g", "mode": "live"})
+	if err := json.Unmarshal([]byte(text), &res); err != nil {
+		t.Fatalf("decode result: %v\n%s", err, text)
+	}
+	if !res.Live || res.Persistent {
+		t.Errorf("vm_set_memory(live) = %+v", res)
+	}
+
+	// Removing a device asks for confirmation even in live mode.
+	args = map[string]any{"name": "win10", "mac": "52:54:00:ff:ff:ff", "mode": "live"}
+	m = vmTokenPattern.FindStringSubmatch(callStatsTool(t, regs, "vm_nic_detach", args))
+	if m == nil {
+		t.Fatal("live vm_nic_detach did not ask for confirmation")
+	}
+	args["confirmation_token"] = m[1]
+	if text := callStatsTool(t, regs, "vm_nic_detach", args); !strings.Contains(text, "no interface") {
+		t.Errorf("confirmed vm_nic_detach = %s", text)
+	}
+
+	args = map[string]any{"name": "ubuntu", "iso": "/mnt/user/isos/debian.iso", "mode": "persistent"}
+	m = vmTokenPattern.FindStringSubmatch(callStatsTool(t, regs, "vm_cdrom_insert", args))
+	if m == nil {
+		t.Fatal("vm_cdrom_insert did not ask for confirmation")
+	}
+	args["confirmation_token"] = m[1]
+	callStatsTool(t, regs, "vm_cdrom_insert", args)
+	if d, _ := mgr.InspectVM(t.Context(), "ubuntu"); d.CDROMs[0].Source != "/mnt/user/isos/debian.iso" {
+		t.Errorf("cdroms = %+v", d.CDROMs)
+	}
+
+	errTests := []struct {
+		tool string
+		args map[string]any
+		want string
+	}{
+		{"vm_set_vcpus", map[string]any{"name": "blocked", "vcpus": float64(2)}, "not allowed"},
+		{"vm_set_vcpus", map[string]any{"name": "win10", "vcpus": float64(3), "threads": float64(2)}, "invalid cpu topology"},
+		{"vm_set_memory", map[string]any{"name": "win10", "memory": "lots"}, "memory"},
+		{"vm_set_memory", map[string]any{"name": "win10", "memory": "4g", "mode": "now"}, "invalid mode"},
+		{"vm_set_memory", map[string]any{"name": "ubuntu", "memory": "4g", "mode": "live"}, "not running"},
+		{"vm_disk_attach", map[string]any{"name": "win10", "disk": ":sata"}, "give a size"},
+		{"vm_nic_attach", map[string]any{"name": "win10", "nic": "br0:ne2k"}, "model"},
+		{"vm_disk_detach", map[string]any{"name": "win10", "disk": "hdc", "mode": "live"}, "Confirmation required"},
+		{"vm_nic_detach", map[string]any{"name": "win10", "mac": "52:54:00:ff:ff:ff", "mode": "live"}, "Confirmation required"},
+		{"vm_cdrom_eject", map[string]any{"name": "win10", "mode": "live"}, "Confirmation required"},
+	}
+	for _, tt := range errTests {
+		t.Run(tt.tool, func(t *testing.T) {
+			if text := callStatsTool(t, regs, tt.tool, tt.args); !strings.Contains(text, tt.want) {
+				t.Errorf("%s = %s, want %q", tt.tool, text, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ChangeTools_InvalidModeNotAudited(t *testing.T) {
+	var buf bytes.Buffer
+	regs := VMTools(newSeededMock(t), safety.NewFilter(nil, []string{"blocked"}), safety.NewConfirmationTracker(DestructiveTools), safety.NewAuditLogger(&buf))
+
+	text := callStatsTool(t, regs, "vm_set_memory", map[string]any{"name": "blocked", "memory": "4g", "mode": "now"})
+	if !strings.Contains(text, "invalid mode") {
+		t.Errorf("vm_set_memory = %s, want an invalid mode error", text)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("invalid mode was audited: %s", buf.String())
+	}
+}
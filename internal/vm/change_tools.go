@@ -0,0 +1,407 @@
+// Package vm provides virtual machine management for Unraid systems via libvirt.
+package vm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+	"github.com/jamesprial/unraid-mcp/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// changeTool returns a VM configuration change tool with the given
+// description and arguments, plus the name, mode and confirmation_token
+// arguments they all share.
+func changeTool(toolName, description string, opts ...mcp.ToolOption) mcp.Tool {
+	opts = append([]mcp.ToolOption{
+		mcp.WithDescription(description + " Applied live to a running VM where the guest supports it and saved to its definition; " +
+			"use mode to choose. Changes to the definition, and any detach or eject, require confirmation. The result includes a diff of the XML."),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("VM name"),
+		),
+	}, opts...)
+	opts = append(opts,
+		mcp.WithString("mode",
+			mcp.Description("both (default): change the running VM if possible and its definition; "+
+				"live: only the running VM, until it is next shut down; persistent: only the definition, from the next start"),
+		),
+		mcp.WithString("confirmation_token",
+			mcp.Description("Confirmation token returned by a prior call to this tool"),
+		),
+	)
+	return mcp.NewTool(toolName, opts...)
+}
+
+// changeMode checks that a change tool may act on the named VM and parses
+// its mode argument, which it adds to params. It returns a result instead
+// when the call cannot go ahead.
+func changeMode(req mcp.CallToolRequest, filter *safety.Filter, audit *safety.AuditLogger, toolName, name string, params map[string]any, start time.Time) (VMChangeMode, *mcp.CallToolResult) {
+	mode, err := ParseVMChangeMode(req.GetString("mode", ""))
+	if err != nil {
+		return "", tools.ErrorResult(err.Error())
+	}
+	params["mode"] = string(mode)
+	if !filter.IsAllowed(name) {
+		tools.LogAudit(audit, toolName, params, "denied", start)
+		return "", tools.ErrorResult(fmt.Sprintf("access to VM %q is not allowed", name))
+	}
+	return mode, nil
+}
+
+// removalTools are the change tools that take a device away from a VM. The
+// guest loses the device even when only the running VM is changed, so they
+// need confirmation in every mode.
+var removalTools = map[string]bool{
+	"vm_disk_detach": true,
+	"vm_nic_detach":  true,
+	"vm_cdrom_eject": true,
+}
+
+// runChange applies a configuration change to the named VM. Changes that
+// touch the definition, and removals, need confirmation first; any other
+// live-only change is lost when the VM shuts down, so it runs straight away.
+func runChange(confirm *safety.ConfirmationTracker, audit *safety.AuditLogger, toolName, name string, mode VMChangeMode, token, desc string, params map[string]any, start time.Time, apply func() (*VMChangeResult, error)) *mcp.CallToolResult {
+	if (mode != VMChangeLive || removalTools[toolName]) && !confirm.Confirm(token, toolName, name, params) {
+		desc = fmt.Sprintf("This will %s of VM %q", desc, name)
+		switch mode {
+		case VMChangeLive:
+			desc += " while it runs, leaving its saved definition unchanged."
+		case VMChangePersistent:
+			desc += " in its saved definition, taking effect at its next start."
+		default:
+			desc += ", live if it is running and in its saved definition."
+		}
+		return tools.ConfirmPrompt(confirm, toolName, name, desc, params)
+	}
+
+	result, err := apply()
+	if err != nil {
+		tools.LogAudit(audit, toolName, params, "error: "+err.Error(), start)
+		return tools.ErrorResult(err.Error())
+	}
+
+	tools.LogAudit(audit, toolName, params, "ok", start)
+	return tools.JSONResult(result)
+}
+
+func vmSetMemory(mgr VMManager, filter *safety.Filter, confirm *safety.ConfirmationTracker, audit *safety.AuditLogger) tools.Registration {
+	const toolName = "vm_set_memory"
+
+	tool := changeTool(toolName, "Change the memory of a virtual machine.",
+		mcp.WithString("memory",
+			mcp.Required(),
+			mcp.Description("New memory size (e.g. 8g, 4096m)"),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		name := req.GetString("name", "")
+		memory := req.GetString("memory", "")
+		token := req.GetString("confirmation_token", "")
+		params := map[string]any{"name": name, "memory": memory}
+
+		mode, res := changeMode(req, filter, audit, toolName, name, params, start)
+		if res != nil {
+			return res, nil
+		}
+		n, err := parseSize(memory)
+		if err != nil {
+			return tools.ErrorResult("memory: " + err.Error()), nil
+		}
+
+		desc := fmt.Sprintf("set the memory to %s", tools.FormatBytes(n/1024*1024))
+		return runChange(confirm, audit, toolName, name, mode, token, desc, params, start, func() (*VMChangeResult, error) {
+			return mgr.SetVMMemory(ctx, name, n/1024, mode)
+		}), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
+
+func vmSetVCPUs(mgr VMManager, filter *safety.Filter, confirm *safety.ConfirmationTracker, audit *safety.AuditLogger) tools.Registration {
+	const toolName = "vm_set_vcpus"
+
+	tool := changeTool(toolName, "Change the number of vCPUs of a virtual machine. "+
+		"Its CPU topology is adjusted to match; pinned vCPUs beyond the new count are unpinned.",
+		mcp.WithNumber("vcpus",
+			mcp.Required(),
+			mcp.Description("New number of vCPUs"),
+		),
+		mcp.WithNumber("threads",
+			mcp.Description("CPU topology: threads per core (default: keep the current number if it divides vcpus, otherwise 1)"),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		name := req.GetString("name", "")
+		vcpus := req.GetInt("vcpus", 0)
+		threads := req.GetInt("threads", 0)
+		token := req.GetString("confirmation_token", "")
+		params := map[string]any{"name": name, "vcpus": vcpus, "threads": threads}
+
+		mode, res := changeMode(req, filter, audit, toolName, name, params, start)
+		if res != nil {
+			return res, nil
+		}
+		if vcpus < 1 || threads < 0 || (threads > 0 && vcpus%threads != 0) {
+			return tools.ErrorResult(fmt.Sprintf("invalid cpu topology: %d vCPUs with %d threads per core", vcpus, threads)), nil
+		}
+
+		desc := fmt.Sprintf("set the number of vCPUs to %d", vcpus)
+		return runChange(confirm, audit, toolName, name, mode, token, desc, params, start, func() (*VMChangeResult, error) {
+			return mgr.SetVMVCPUs(ctx, name, vcpus, threads, mode)
+		}), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
+
+func vmDiskAttach(mgr VMManager, filter *safety.Filter, confirm *safety.ConfirmationTracker, audit *safety.AuditLogger) tools.Registration {
+	const toolName = "vm_disk_attach"
+
+	tool := changeTool(toolName, "Attach a disk to a virtual machine, creating a new image if a size is given. "+
+		"It takes the next free hdX target.",
+		mcp.WithString("disk",
+			mcp.Required(),
+			mcp.Description("Disk as SIZE|PATH[=SIZE][:BUS[:FORMAT]], as in vm_create. A size creates a new image "+
+				"(the next free vdiskN.img in the VM's folder unless a path is given); a path alone attaches an existing one. "+
+				"E.g. 30g, 64g:sata:qcow2, /mnt/disks/nvme/vm/data.img"),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		name := req.GetString("name", "")
+		arg := req.GetString("disk", "")
+		token := req.GetString("confirmation_token", "")
+		params := map[string]any{"name": name, "disk": arg}
+
+		mode, res := changeMode(req, filter, audit, toolName, name, params, start)
+		if res != nil {
+			return res, nil
+		}
+		disk, err := parseDiskArg(arg)
+		if err != nil {
+			return tools.ErrorResult(err.Error()), nil
+		}
+		if disk.Path == "" {
+			if disk.Size == 0 {
+				return tools.ErrorResult(fmt.Sprintf("disk %q: give a size, a path or both", arg)), nil
+			}
+			detail, err := mgr.InspectVM(ctx, name)
+			if err != nil {
+				return tools.ErrorResult(err.Error()), nil
+			}
+			disk.Path = nextVDiskPath(name, detail.Disks)
+		}
+		if disk, err = normalizeDisk(disk, false); err != nil {
+			return tools.ErrorResult(err.Error()), nil
+		}
+		// The token is bound to the image path as well as the argument, so
+		// a confirmed change cannot pick a different image.
+		params["path"] = disk.Path
+
+		desc := fmt.Sprintf("attach disk %s", disk.Path)
+		if disk.Size > 0 {
+			desc = fmt.Sprintf("create a %s %s image at %s and attach it", tools.FormatBytes(disk.Size), disk.Format, disk.Path)
+		}
+		desc += fmt.Sprintf(" on the %s bus", disk.Bus)
+		return runChange(confirm, audit, toolName, name, mode, token, desc, params, start, func() (*VMChangeResult, error) {
+			if disk.Size == 0 {
+				return mgr.AttachDisk(ctx, name, disk, mode)
+			}
+			if err := mgr.CreateDisk(ctx, disk.Path, disk.Format, disk.Size); err != nil {
+				return nil, err
+			}
+			res, err := mgr.AttachDisk(ctx, name, disk, mode)
+			if err != nil {
+				return nil, removeDisks(ctx, mgr, []string{disk.Path}, err)
+			}
+			return res, nil
+		}), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
+
+func vmDiskDetach(mgr VMManager, filter *safety.Filter, confirm *safety.ConfirmationTracker, audit *safety.AuditLogger) tools.Registration {
+	const toolName = "vm_disk_detach"
+
+	tool := changeTool(toolName, "Detach a disk from a virtual machine. The disk image is NOT deleted.",
+		mcp.WithString("disk",
+			mcp.Required(),
+			mcp.Description("Target device (e.g. hdc) or image path of the disk, as shown by vm_inspect"),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		name := req.GetString("name", "")
+		disk := req.GetString("disk", "")
+		token := req.GetString("confirmation_token", "")
+		params := map[string]any{"name": name, "disk": disk}
+
+		mode, res := changeMode(req, filter, audit, toolName, name, params, start)
+		if res != nil {
+			return res, nil
+		}
+
+		desc := fmt.Sprintf("detach disk %s", disk)
+		return runChange(confirm, audit, toolName, name, mode, token, desc, params, start, func() (*VMChangeResult, error) {
+			return mgr.DetachDisk(ctx, name, disk, mode)
+		}), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
+
+func vmNICAttach(mgr VMManager, filter *safety.Filter, confirm *safety.ConfirmationTracker, audit *safety.AuditLogger) tools.Registration {
+	const toolName = "vm_nic_attach"
+
+	tool := changeTool(toolName, "Attach a network interface to a virtual machine.",
+		mcp.WithString("nic",
+			mcp.Required(),
+			mcp.Description("Interface as BRIDGE[:MODEL[:MAC]], as in vm_create (e.g. br0, br1:e1000). "+
+				"Model virtio-net (default), virtio, e1000, rtl8139 or vmxnet3; a MAC address is generated if not given"),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		name := req.GetString("name", "")
+		arg := req.GetString("nic", "")
+		token := req.GetString("confirmation_token", "")
+		params := map[string]any{"name": name, "nic": arg}
+
+		mode, res := changeMode(req, filter, audit, toolName, name, params, start)
+		if res != nil {
+			return res, nil
+		}
+		// A MAC address is generated on each call when not given, so the
+		// token is bound to the argument rather than to the address.
+		nic, err := normalizeNIC(parseNICArg(arg))
+		if err != nil {
+			return tools.ErrorResult(err.Error()), nil
+		}
+
+		desc := fmt.Sprintf("attach a %s interface on %s", nic.Model, nic.Bridge)
+		return runChange(confirm, audit, toolName, name, mode, token, desc, params, start, func() (*VMChangeResult, error) {
+			return mgr.AttachNIC(ctx, name, nic, mode)
+		}), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
+
+func vmNICDetach(mgr VMManager, filter *safety.Filter, confirm *safety.ConfirmationTracker, audit *safety.AuditLogger) tools.Registration {
+	const toolName = "vm_nic_detach"
+
+	tool := changeTool(toolName, "Detach a network interface from a virtual machine.",
+		mcp.WithString("mac",
+			mcp.Required(),
+			mcp.Description("MAC address of the interface, as shown by vm_inspect"),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		name := req.GetString("name", "")
+		mac := req.GetString("mac", "")
+		token := req.GetString("confirmation_token", "")
+		params := map[string]any{"name": name, "mac": mac}
+
+		mode, res := changeMode(req, filter, audit, toolName, name, params, start)
+		if res != nil {
+			return res, nil
+		}
+
+		desc := fmt.Sprintf("detach the interface with MAC address %s", mac)
+		return runChange(confirm, audit, toolName, name, mode, token, desc, params, start, func() (*VMChangeResult, error) {
+			return mgr.DetachNIC(ctx, name, mac, mode)
+		}), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
+
+func vmCDROMInsert(mgr VMManager, filter *safety.Filter, confirm *safety.ConfirmationTracker, audit *safety.AuditLogger) tools.Registration {
+	const toolName = "vm_cdrom_insert"
+
+	tool := changeTool(toolName, "Insert an ISO image into a cdrom drive of a virtual machine, replacing any inserted one.",
+		mcp.WithString("iso",
+			mcp.Required(),
+			mcp.Description("ISO image path (e.g. /mnt/user/isos/virtio-win.iso)"),
+		),
+		mcp.WithString("drive",
+			mcp.Description("Target device of the cdrom drive (e.g. hdb; default: the first cdrom drive)"),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		name := req.GetString("name", "")
+		iso := req.GetString("iso", "")
+		drive := req.GetString("drive", "")
+		token := req.GetString("confirmation_token", "")
+		params := map[string]any{"name": name, "iso": iso, "drive": drive}
+
+		mode, res := changeMode(req, filter, audit, toolName, name, params, start)
+		if res != nil {
+			return res, nil
+		}
+		if iso == "" {
+			return tools.ErrorResult("iso is required; use vm_cdrom_eject to empty a drive"), nil
+		}
+
+		desc := fmt.Sprintf("insert %s into %s", iso, driveName(drive))
+		return runChange(confirm, audit, toolName, name, mode, token, desc, params, start, func() (*VMChangeResult, error) {
+			return mgr.ChangeMedia(ctx, name, drive, iso, mode)
+		}), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
+
+func vmCDROMEject(mgr VMManager, filter *safety.Filter, confirm *safety.ConfirmationTracker, audit *safety.AuditLogger) tools.Registration {
+	const toolName = "vm_cdrom_eject"
+
+	tool := changeTool(toolName, "Eject the ISO image from a cdrom drive of a virtual machine, leaving the drive empty.",
+		mcp.WithString("drive",
+			mcp.Description("Target device of the cdrom drive (e.g. hdb; default: the first cdrom drive)"),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		name := req.GetString("name", "")
+		drive := req.GetString("drive", "")
+		token := req.GetString("confirmation_token", "")
+		params := map[string]any{"name": name, "drive": drive}
+
+		mode, res := changeMode(req, filter, audit, toolName, name, params, start)
+		if res != nil {
+			return res, nil
+		}
+
+		desc := fmt.Sprintf("eject the ISO image from %s", driveName(drive))
+		return runChange(confirm, audit, toolName, name, mode, token, desc, params, start, func() (*VMChangeResult, error) {
+			return mgr.ChangeMedia(ctx, name, drive, "", mode)
+		}), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
+
+// driveName names a cdrom drive argument in confirmation prompts.
+func driveName(drive string) string {
+	if drive == "" {
+		return "the first cdrom drive"
+	}
+	return "cdrom drive " + drive
+}
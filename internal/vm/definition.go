@@ -0,0 +1,895 @@
+// Package vm provides virtual machine management for Unraid systems via libvirt.
+package vm
+
+import (
+	"crypto/sha256"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ----------------------------------------------------------------------------
+// VM definitions
+// ----------------------------------------------------------------------------
+
+// Paths and defaults used by Unraid's VM manager.
+const (
+	unraidDomainsDir = "/mnt/user/domains"
+	unraidEmulator   = "/usr/local/sbin/qemu"
+	unraidOVMFCode   = "/usr/share/qemu/ovmf-x64/OVMF_CODE-pure-efi.fd"
+	unraidOVMFVars   = "/usr/share/qemu/ovmf-x64/OVMF_VARS-pure-efi.fd"
+	unraidNVRAMDir   = "/etc/libvirt/qemu/nvram"
+
+	defaultVMMemory = 1024 * 1024 // KB
+	minVMMemory     = 128 * 1024  // KB
+	defaultVMBridge = "br0"
+)
+
+// Unraid names vdisks hdc, hdd, ... and keeps hda and hdb for the install and
+// driver ISOs.
+const (
+	maxVMDisks  = 24
+	maxVMCDROMs = 2
+)
+
+var (
+	usbIDPattern   = regexp.MustCompile(`^([0-9a-fA-F]{4}):([0-9a-fA-F]{4})$`)
+	pciAddrPattern = regexp.MustCompile(`^(?:([0-9a-fA-F]{4}):)?([0-9a-fA-F]{2}):([0-9a-fA-F]{2})\.([0-7])$`)
+	macPattern     = regexp.MustCompile(`^[0-9a-fA-F]{2}(:[0-9a-fA-F]{2}){5}$`)
+	uuidPattern    = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// Normalize returns s with every default filled in, and checks that it
+// describes a VM the Unraid VM manager could have created. The UUID and MAC
+// addresses are derived from the spec when not given, so normalizing the same
+// spec twice, as a dry run and the confirmed create do, yields the same
+// definition.
+func (s VMCreateSpec) Normalize() (VMCreateSpec, error) {
+	if strings.TrimSpace(s.Name) == "" {
+		return s, fmt.Errorf("vm name must not be empty")
+	}
+	if strings.Contains(s.Name, "/") {
+		return s, fmt.Errorf("vm name %q must not contain '/'", s.Name)
+	}
+	if s.UUID == "" {
+		s.UUID = deriveUUID(s)
+	} else if !uuidPattern.MatchString(s.UUID) {
+		return s, fmt.Errorf("invalid uuid %q", s.UUID)
+	}
+	s.UUID = strings.ToLower(s.UUID)
+
+	switch s.OS {
+	case "":
+		s.OS = "linux"
+	case "linux", "windows":
+	default:
+		return s, fmt.Errorf("invalid os %q (want linux or windows)", s.OS)
+	}
+
+	switch {
+	case s.Memory == 0:
+		s.Memory = defaultVMMemory
+	case s.Memory < minVMMemory:
+		return s, fmt.Errorf("memory %d KB is below the minimum of %d KB", s.Memory, minVMMemory)
+	}
+
+	if s.Threads == 0 {
+		s.Threads = 1
+	}
+	if s.Cores == 0 {
+		s.Cores = max(s.VCPUs, 1) / s.Threads
+	}
+	if s.VCPUs == 0 {
+		s.VCPUs = s.Cores * s.Threads
+	}
+	if s.Cores < 1 || s.Threads < 1 || s.Cores*s.Threads != s.VCPUs {
+		return s, fmt.Errorf("invalid cpu topology: %d vCPUs as %d cores of %d threads", s.VCPUs, s.Cores, s.Threads)
+	}
+
+	switch {
+	case s.Machine == "":
+		s.Machine = "q35"
+	case s.Machine == "i440fx":
+		s.Machine = "pc"
+	case s.Machine == "q35", s.Machine == "pc",
+		strings.HasPrefix(s.Machine, "pc-q35-"), strings.HasPrefix(s.Machine, "pc-i440fx-"):
+	default:
+		return s, fmt.Errorf("invalid machine type %q (want q35, i440fx or a versioned pc-q35-*/pc-i440fx-* type)", s.Machine)
+	}
+
+	switch s.BIOS {
+	case "":
+		s.BIOS = "ovmf"
+	case "ovmf", "seabios":
+	default:
+		return s, fmt.Errorf("invalid bios %q (want ovmf or seabios)", s.BIOS)
+	}
+
+	switch s.Graphics {
+	case "":
+		s.Graphics = "vnc"
+	case "vnc", "none":
+	default:
+		return s, fmt.Errorf("invalid graphics %q (want vnc or none)", s.Graphics)
+	}
+
+	if err := s.normalizeDisks(); err != nil {
+		return s, err
+	}
+	if err := s.normalizeNICs(); err != nil {
+		return s, err
+	}
+	return s, s.normalizeHostdevs()
+}
+
+// normalizeDisks fills in the disk and cdrom defaults of a normalized spec.
+// The slices are copied, so the caller's spec is not modified.
+func (s *VMCreateSpec) normalizeDisks() error {
+	if len(s.Disks) > maxVMDisks {
+		return fmt.Errorf("too many disks: %d (at most %d)", len(s.Disks), maxVMDisks)
+	}
+	disks := make([]VMDiskSpec, len(s.Disks))
+	seen := make(map[string]bool, len(s.Disks))
+	for i, d := range s.Disks {
+		switch {
+		case d.Path == "" && d.Size == 0:
+			return fmt.Errorf("disk %d needs a path or a size", i+1)
+		case d.Path == "":
+			d.Path = path.Join(unraidDomainsDir, s.Name, fmt.Sprintf("vdisk%d.img", i+1))
+		case !path.IsAbs(d.Path):
+			return fmt.Errorf("disk path %q must be absolute", d.Path)
+		}
+		if seen[d.Path] {
+			return fmt.Errorf("disk %q is listed twice", d.Path)
+		}
+		seen[d.Path] = true
+
+		d, err := normalizeDisk(d, s.isQ35())
+		if err != nil {
+			return err
+		}
+		disks[i] = d
+	}
+	s.Disks = disks
+
+	if len(s.CDROMs) > maxVMCDROMs {
+		return fmt.Errorf("too many cdroms: %d (at most %d)", len(s.CDROMs), maxVMCDROMs)
+	}
+	for _, iso := range s.CDROMs {
+		if !path.IsAbs(iso) {
+			return fmt.Errorf("iso path %q must be absolute", iso)
+		}
+	}
+	return nil
+}
+
+// normalizeDisk fills in the bus and format defaults of a disk with a path.
+func normalizeDisk(d VMDiskSpec, q35 bool) (VMDiskSpec, error) {
+	switch d.Bus {
+	case "":
+		d.Bus = "virtio"
+	case "virtio", "sata", "scsi", "usb":
+	case "ide":
+		if q35 {
+			return d, fmt.Errorf("disk %q: the q35 machine type has no ide bus", d.Path)
+		}
+	default:
+		return d, fmt.Errorf("disk %q: invalid bus %q (want virtio, sata, scsi, usb or ide)", d.Path, d.Bus)
+	}
+	switch d.Format {
+	case "":
+		d.Format = "raw"
+	case "raw", "qcow2":
+	default:
+		return d, fmt.Errorf("disk %q: invalid format %q (want raw or qcow2)", d.Path, d.Format)
+	}
+	return d, nil
+}
+
+// normalizeNICs fills in the interface defaults of a normalized spec.
+func (s *VMCreateSpec) normalizeNICs() error {
+	nics := make([]VMNICSpec, len(s.NICs))
+	for i, n := range s.NICs {
+		if n.Bridge == "" {
+			n.Bridge = defaultVMBridge
+		}
+		switch n.Model {
+		case "":
+			n.Model = "virtio-net"
+		case "virtio-net", "virtio", "e1000", "rtl8139", "vmxnet3":
+		default:
+			return fmt.Errorf("nic %d: invalid model %q (want virtio-net, virtio, e1000, rtl8139 or vmxnet3)", i+1, n.Model)
+		}
+		switch {
+		case n.MAC == "":
+			n.MAC = deriveMAC(s.UUID, i)
+		case !macPattern.MatchString(n.MAC):
+			return fmt.Errorf("nic %d: invalid mac address %q", i+1, n.MAC)
+		}
+		n.MAC = strings.ToLower(n.MAC)
+		nics[i] = n
+	}
+	s.NICs = nics
+	return nil
+}
+
+// normalizeHostdevs checks the passthrough devices of a spec and rewrites
+// them in their canonical form: lower-case vendor:product IDs and full
+// domain:bus:slot.function PCI addresses.
+func (s *VMCreateSpec) normalizeHostdevs() error {
+	usb := make([]string, len(s.USB))
+	for i, id := range s.USB {
+		if !usbIDPattern.MatchString(id) {
+			return fmt.Errorf("invalid usb device %q (want vendor:product, e.g. 046d:c52b)", id)
+		}
+		usb[i] = strings.ToLower(id)
+	}
+	s.USB = usb
+
+	pci := make([]string, len(s.PCI))
+	for i, addr := range s.PCI {
+		m := pciAddrPattern.FindStringSubmatch(addr)
+		if m == nil {
+			return fmt.Errorf("invalid pci device %q (want [domain:]bus:slot.function, e.g. 01:00.0)", addr)
+		}
+		if m[1] == "" {
+			m[1] = "0000"
+		}
+		pci[i] = strings.ToLower(fmt.Sprintf("%s:%s:%s.%s", m[1], m[2], m[3], m[4]))
+	}
+	s.PCI = pci
+	return nil
+}
+
+// isQ35 reports whether the spec's machine type is a q35 one.
+func (s VMCreateSpec) isQ35() bool {
+	return s.Machine == "" || s.Machine == "q35" || strings.HasPrefix(s.Machine, "pc-q35-")
+}
+
+// NewDisks returns the disks of a normalized spec that CreateDisk must create
+// before the VM is defined.
+func (s VMCreateSpec) NewDisks() []VMDiskSpec {
+	var out []VMDiskSpec
+	for _, d := range s.Disks {
+		if d.Size > 0 {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// deriveUUID returns a UUID computed from the spec, so that the same spec
+// always yields the same definition.
+func deriveUUID(s VMCreateSpec) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%#v", s)))
+	sum[6] = sum[6]&0x0f | 0x80 // version 8: custom
+	sum[8] = sum[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// deriveMAC returns the MAC address of a VM's nth interface, in the
+// 52:54:00 range QEMU and the Unraid VM manager assign from.
+func deriveMAC(uuid string, n int) string {
+	sum := sha256.Sum256([]byte(uuid + "/" + strconv.Itoa(n)))
+	return fmt.Sprintf("52:54:00:%02x:%02x:%02x", sum[0], sum[1], sum[2])
+}
+
+// ----------------------------------------------------------------------------
+// Domain XML
+// ----------------------------------------------------------------------------
+
+// defDomainXML is the libvirt domain document written for a VMCreateSpec. It
+// follows the layout of the XML the Unraid VM manager writes, so that VMs
+// created here can be edited in its form.
+type defDomainXML struct {
+	XMLName       xml.Name         `xml:"domain"`
+	Type          string           `xml:"type,attr"`
+	Name          string           `xml:"name"`
+	UUID          string           `xml:"uuid"`
+	Metadata      defMetadataXML   `xml:"metadata"`
+	Memory        defMemoryXML     `xml:"memory"`
+	CurrentMemory defMemoryXML     `xml:"currentMemory"`
+	MemoryBacking defMemBackingXML `xml:"memoryBacking"`
+	VCPU          defVCPUXML       `xml:"vcpu"`
+	OS            defOSXML         `xml:"os"`
+	Features      defFeaturesXML   `xml:"features"`
+	CPU           defCPUXML        `xml:"cpu"`
+	Clock         defClockXML      `xml:"clock"`
+	OnPoweroff    string           `xml:"on_poweroff"`
+	OnReboot      string           `xml:"on_reboot"`
+	OnCrash       string           `xml:"on_crash"`
+	Devices       defDevicesXML    `xml:"devices"`
+}
+
+type defMetadataXML struct {
+	Template defTemplateXML `xml:"unraid vmtemplate"`
+}
+
+type defTemplateXML struct {
+	Name string `xml:"name,attr"`
+	Icon string `xml:"icon,attr"`
+	OS   string `xml:"os,attr"`
+}
+
+type defMemoryXML struct {
+	Unit  string `xml:"unit,attr"`
+	Value uint64 `xml:",chardata"`
+}
+
+type defMemBackingXML struct {
+	NoSharePages struct{} `xml:"nosharepages"`
+}
+
+type defVCPUXML struct {
+	Placement string `xml:"placement,attr"`
+	Value     int    `xml:",chardata"`
+}
+
+type defOSXML struct {
+	Type   defOSTypeXML  `xml:"type"`
+	Loader *defLoaderXML `xml:"loader,omitempty"`
+	NVRAM  *defNVRAMXML  `xml:"nvram,omitempty"`
+}
+
+type defOSTypeXML struct {
+	Arch    string `xml:"arch,attr"`
+	Machine string `xml:"machine,attr"`
+	Value   string `xml:",chardata"`
+}
+
+type defLoaderXML struct {
+	ReadOnly string `xml:"readonly,attr"`
+	Type     string `xml:"type,attr"`
+	Path     string `xml:",chardata"`
+}
+
+type defNVRAMXML struct {
+	Template string `xml:"template,attr"`
+	Path     string `xml:",chardata"`
+}
+
+type defFeaturesXML struct {
+	ACPI   struct{}      `xml:"acpi"`
+	APIC   struct{}      `xml:"apic"`
+	HyperV *defHyperVXML `xml:"hyperv,omitempty"`
+}
+
+type defHyperVXML struct {
+	Mode      string         `xml:"mode,attr"`
+	Relaxed   defStateXML    `xml:"relaxed"`
+	VAPIC     defStateXML    `xml:"vapic"`
+	Spinlocks defSpinlockXML `xml:"spinlocks"`
+	VendorID  defVendorIDXML `xml:"vendor_id"`
+}
+
+type defStateXML struct {
+	State string `xml:"state,attr"`
+}
+
+type defSpinlockXML struct {
+	State   string `xml:"state,attr"`
+	Retries int    `xml:"retries,attr"`
+}
+
+type defVendorIDXML struct {
+	State string `xml:"state,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type defCPUXML struct {
+	Mode       string         `xml:"mode,attr"`
+	Check      string         `xml:"check,attr"`
+	Migratable string         `xml:"migratable,attr"`
+	Topology   defTopologyXML `xml:"topology"`
+	Cache      defCacheXML    `xml:"cache"`
+}
+
+type defTopologyXML struct {
+	Sockets int `xml:"sockets,attr"`
+	Dies    int `xml:"dies,attr"`
+	Cores   int `xml:"cores,attr"`
+	Threads int `xml:"threads,attr"`
+}
+
+type defCacheXML struct {
+	Mode string `xml:"mode,attr"`
+}
+
+type defClockXML struct {
+	Offset string        `xml:"offset,attr"`
+	Timers []defTimerXML `xml:"timer"`
+}
+
+type defTimerXML struct {
+	Name       string `xml:"name,attr"`
+	TickPolicy string `xml:"tickpolicy,attr,omitempty"`
+	Present    string `xml:"present,attr,omitempty"`
+}
+
+type defDevicesXML struct {
+	Emulator    string             `xml:"emulator"`
+	Disks       []defDiskXML       `xml:"disk"`
+	Controllers []defControllerXML `xml:"controller"`
+	Interfaces  []defInterfaceXML  `xml:"interface"`
+	Serial      defSerialXML       `xml:"serial"`
+	Console     defSerialXML       `xml:"console"`
+	Channel     defChannelXML      `xml:"channel"`
+	Inputs      []defInputXML      `xml:"input"`
+	Graphics    *defGraphicsXML    `xml:"graphics,omitempty"`
+	Video       *defVideoXML       `xml:"video,omitempty"`
+	Hostdevs    []defHostdevXML    `xml:"hostdev"`
+	Memballoon  defModelXML        `xml:"memballoon"`
+}
+
+type defDiskXML struct {
+	Type     string            `xml:"type,attr"`
+	Device   string            `xml:"device,attr"`
+	Driver   defDiskDriverXML  `xml:"driver"`
+	Source   *defDiskSourceXML `xml:"source,omitempty"` // nil for an empty cdrom drive
+	Target   defDiskTargetXML  `xml:"target"`
+	ReadOnly *struct{}         `xml:"readonly,omitempty"`
+	Boot     *defBootXML       `xml:"boot,omitempty"`
+}
+
+type defDiskDriverXML struct {
+	Name  string `xml:"name,attr"`
+	Type  string `xml:"type,attr"`
+	Cache string `xml:"cache,attr,omitempty"`
+}
+
+type defDiskSourceXML struct {
+	File string `xml:"file,attr,omitempty"`
+	Dev  string `xml:"dev,attr,omitempty"`
+}
+
+type defDiskTargetXML struct {
+	Dev string `xml:"dev,attr"`
+	Bus string `xml:"bus,attr"`
+}
+
+type defBootXML struct {
+	Order int `xml:"order,attr"`
+}
+
+type defControllerXML struct {
+	Type  string `xml:"type,attr"`
+	Index int    `xml:"index,attr"`
+	Model string `xml:"model,attr,omitempty"`
+	Ports int    `xml:"ports,attr,omitempty"`
+}
+
+type defInterfaceXML struct {
+	Type   string          `xml:"type,attr"`
+	MAC    defMACXML       `xml:"mac"`
+	Source defBridgeXML    `xml:"source"`
+	Model  defModelTypeXML `xml:"model"`
+}
+
+type defMACXML struct {
+	Address string `xml:"address,attr"`
+}
+
+type defBridgeXML struct {
+	Bridge string `xml:"bridge,attr"`
+}
+
+type defModelTypeXML struct {
+	Type string `xml:"type,attr"`
+}
+
+type defSerialXML struct {
+	Type   string             `xml:"type,attr"`
+	Target defSerialTargetXML `xml:"target"`
+}
+
+type defSerialTargetXML struct {
+	Type string `xml:"type,attr,omitempty"`
+	Port int    `xml:"port,attr"`
+}
+
+type defChannelXML struct {
+	Type   string              `xml:"type,attr"`
+	Target defChannelTargetXML `xml:"target"`
+}
+
+type defChannelTargetXML struct {
+	Type string `xml:"type,attr"`
+	Name string `xml:"name,attr"`
+}
+
+type defInputXML struct {
+	Type string `xml:"type,attr"`
+	Bus  string `xml:"bus,attr"`
+}
+
+type defGraphicsXML struct {
+	Type       string       `xml:"type,attr"`
+	Port       int          `xml:"port,attr"`
+	Autoport   string       `xml:"autoport,attr"`
+	Websocket  int          `xml:"websocket,attr"`
+	ListenAddr string       `xml:"listen,attr"`
+	Keymap     string       `xml:"keymap,attr"`
+	Listen     defListenXML `xml:"listen"`
+}
+
+type defListenXML struct {
+	Type    string `xml:"type,attr"`
+	Address string `xml:"address,attr"`
+}
+
+type defVideoXML struct {
+	Model defVideoModelXML `xml:"model"`
+}
+
+type defVideoModelXML struct {
+	Type    string `xml:"type,attr"`
+	RAM     int    `xml:"ram,attr"`
+	VRAM    int    `xml:"vram,attr"`
+	VGAMem  int    `xml:"vgamem,attr"`
+	Heads   int    `xml:"heads,attr"`
+	Primary string `xml:"primary,attr"`
+}
+
+type defHostdevXML struct {
+	Mode    string              `xml:"mode,attr"`
+	Type    string              `xml:"type,attr"`
+	Managed string              `xml:"managed,attr"`
+	Driver  *defDriverNameXML   `xml:"driver,omitempty"`
+	Source  defHostdevSourceXML `xml:"source"`
+}
+
+type defDriverNameXML struct {
+	Name string `xml:"name,attr"`
+}
+
+type defHostdevSourceXML struct {
+	StartupPolicy string         `xml:"startupPolicy,attr,omitempty"`
+	Vendor        *defIDXML      `xml:"vendor,omitempty"`
+	Product       *defIDXML      `xml:"product,omitempty"`
+	Address       *defPCIAddrXML `xml:"address,omitempty"`
+}
+
+type defIDXML struct {
+	ID string `xml:"id,attr"`
+}
+
+type defPCIAddrXML struct {
+	Domain   string `xml:"domain,attr"`
+	Bus      string `xml:"bus,attr"`
+	Slot     string `xml:"slot,attr"`
+	Function string `xml:"function,attr"`
+}
+
+type defModelXML struct {
+	Model string `xml:"model,attr"`
+}
+
+// DomainXML renders the spec as a libvirt domain document laid out like the
+// ones the Unraid VM manager writes: its template metadata, OVMF firmware
+// and per-VM NVRAM paths, emulator, and hdX disk naming.
+func (s VMCreateSpec) DomainXML() (string, error) {
+	s, err := s.Normalize()
+	if err != nil {
+		return "", err
+	}
+
+	doc := defDomainXML{
+		Type:          "kvm",
+		Name:          s.Name,
+		UUID:          s.UUID,
+		Memory:        defMemoryXML{Unit: "KiB", Value: s.Memory},
+		CurrentMemory: defMemoryXML{Unit: "KiB", Value: s.Memory},
+		VCPU:          defVCPUXML{Placement: "static", Value: s.VCPUs},
+		OS: defOSXML{
+			Type: defOSTypeXML{Arch: "x86_64", Machine: s.Machine, Value: "hvm"},
+		},
+		CPU: defCPUXML{
+			Mode:       "host-passthrough",
+			Check:      "none",
+			Migratable: "on",
+			Topology:   defTopologyXML{Sockets: 1, Dies: 1, Cores: s.Cores, Threads: s.Threads},
+			Cache:      defCacheXML{Mode: "passthrough"},
+		},
+		OnPoweroff: "destroy",
+		OnReboot:   "restart",
+		OnCrash:    "restart",
+		Devices: defDevicesXML{
+			Emulator: unraidEmulator,
+			Serial:   defSerialXML{Type: "pty", Target: defSerialTargetXML{Port: 0}},
+			Console:  defSerialXML{Type: "pty", Target: defSerialTargetXML{Type: "serial", Port: 0}},
+			Channel: defChannelXML{
+				Type:   "unix",
+				Target: defChannelTargetXML{Type: "virtio", Name: "org.qemu.guest_agent.0"},
+			},
+			Inputs: []defInputXML{
+				{Type: "tablet", Bus: "usb"},
+				{Type: "mouse", Bus: "ps2"},
+				{Type: "keyboard", Bus: "ps2"},
+			},
+			Memballoon: defModelXML{Model: "virtio"},
+		},
+	}
+
+	if s.OS == "windows" {
+		doc.Metadata.Template = defTemplateXML{Name: "Windows 10", Icon: "windows.png", OS: "windows10"}
+		doc.Features.HyperV = &defHyperVXML{
+			Mode:      "custom",
+			Relaxed:   defStateXML{State: "on"},
+			VAPIC:     defStateXML{State: "on"},
+			Spinlocks: defSpinlockXML{State: "on", Retries: 8191},
+			VendorID:  defVendorIDXML{State: "on", Value: "none"},
+		}
+		doc.Clock = defClockXML{Offset: "localtime", Timers: []defTimerXML{
+			{Name: "hypervclock", Present: "yes"},
+			{Name: "hpet", Present: "no"},
+		}}
+	} else {
+		doc.Metadata.Template = defTemplateXML{Name: "Linux", Icon: "linux.png", OS: "linux"}
+		doc.Clock = defClockXML{Offset: "utc", Timers: []defTimerXML{
+			{Name: "rtc", TickPolicy: "catchup"},
+			{Name: "pit", TickPolicy: "delay"},
+			{Name: "hpet", Present: "no"},
+		}}
+	}
+
+	if s.BIOS == "ovmf" {
+		doc.OS.Loader = &defLoaderXML{ReadOnly: "yes", Type: "pflash", Path: unraidOVMFCode}
+		// libvirt copies the template on first start, as the VM manager does
+		// when it creates the VM.
+		doc.OS.NVRAM = &defNVRAMXML{
+			Template: unraidOVMFVars,
+			Path:     path.Join(unraidNVRAMDir, s.UUID+"_VARS-pure-efi.fd"),
+		}
+	}
+
+	boot := 1
+	scsi := false
+	for i, d := range s.Disks {
+		doc.Devices.Disks = append(doc.Devices.Disks, defDiskXML{
+			Type:   "file",
+			Device: "disk",
+			Driver: defDiskDriverXML{Name: "qemu", Type: d.Format, Cache: "writeback"},
+			Source: &defDiskSourceXML{File: d.Path},
+			Target: defDiskTargetXML{Dev: "hd" + string(rune('c'+i)), Bus: d.Bus},
+			Boot:   &defBootXML{Order: boot},
+		})
+		boot++
+		scsi = scsi || d.Bus == "scsi"
+	}
+	cdromBus := "sata"
+	if !s.isQ35() {
+		cdromBus = "ide"
+	}
+	for i, iso := range s.CDROMs {
+		doc.Devices.Disks = append(doc.Devices.Disks, defDiskXML{
+			Type:     "file",
+			Device:   "cdrom",
+			Driver:   defDiskDriverXML{Name: "qemu", Type: "raw"},
+			Source:   &defDiskSourceXML{File: iso},
+			Target:   defDiskTargetXML{Dev: "hd" + string(rune('a'+i)), Bus: cdromBus},
+			ReadOnly: &struct{}{},
+			Boot:     &defBootXML{Order: boot},
+		})
+		boot++
+	}
+
+	doc.Devices.Controllers = append(doc.Devices.Controllers,
+		defControllerXML{Type: "usb", Index: 0, Model: "qemu-xhci", Ports: 15})
+	if scsi {
+		doc.Devices.Controllers = append(doc.Devices.Controllers,
+			defControllerXML{Type: "scsi", Index: 0, Model: "virtio-scsi"})
+	}
+
+	for _, n := range s.NICs {
+		doc.Devices.Interfaces = append(doc.Devices.Interfaces, defInterfaceXML{
+			Type:   "bridge",
+			MAC:    defMACXML{Address: n.MAC},
+			Source: defBridgeXML{Bridge: n.Bridge},
+			Model:  defModelTypeXML{Type: n.Model},
+		})
+	}
+
+	if s.Graphics == "vnc" {
+		doc.Devices.Graphics = &defGraphicsXML{
+			Type:       "vnc",
+			Port:       -1,
+			Autoport:   "yes",
+			Websocket:  -1,
+			ListenAddr: "0.0.0.0",
+			Keymap:     "en-us",
+			Listen:     defListenXML{Type: "address", Address: "0.0.0.0"},
+		}
+		doc.Devices.Video = &defVideoXML{Model: defVideoModelXML{
+			Type: "qxl", RAM: 65536, VRAM: 65536, VGAMem: 16384, Heads: 1, Primary: "yes",
+		}}
+	}
+
+	for _, id := range s.USB {
+		vendor, product, _ := strings.Cut(id, ":")
+		doc.Devices.Hostdevs = append(doc.Devices.Hostdevs, defHostdevXML{
+			Mode:    "subsystem",
+			Type:    "usb",
+			Managed: "no",
+			Source: defHostdevSourceXML{
+				StartupPolicy: "optional",
+				Vendor:        &defIDXML{ID: "0x" + vendor},
+				Product:       &defIDXML{ID: "0x" + product},
+			},
+		})
+	}
+	for _, addr := range s.PCI {
+		m := pciAddrPattern.FindStringSubmatch(addr)
+		doc.Devices.Hostdevs = append(doc.Devices.Hostdevs, defHostdevXML{
+			Mode:    "subsystem",
+			Type:    "pci",
+			Managed: "yes",
+			Driver:  &defDriverNameXML{Name: "vfio"},
+			Source: defHostdevSourceXML{Address: &defPCIAddrXML{
+				Domain: "0x" + m[1], Bus: "0x" + m[2], Slot: "0x" + m[3], Function: "0x" + m[4],
+			}},
+		})
+	}
+
+	if len(s.PCI) > 0 {
+		// A passed-through device needs all guest memory pinned for DMA, so
+		// a balloon could not return any of it; Unraid disables it then.
+		doc.Devices.Memballoon = defModelXML{Model: "none"}
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("encode domain xml: %w", err)
+	}
+	return string(out), nil
+}
+
+// ----------------------------------------------------------------------------
+// Disk images
+// ----------------------------------------------------------------------------
+
+// defPoolXML is a transient directory storage pool, used to have libvirt
+// create a disk image in a directory that is not part of a defined pool.
+type defPoolXML struct {
+	XMLName xml.Name         `xml:"pool"`
+	Type    string           `xml:"type,attr"`
+	Name    string           `xml:"name"`
+	Target  defPoolTargetXML `xml:"target"`
+}
+
+type defPoolTargetXML struct {
+	Path string `xml:"path"`
+}
+
+type defVolumeXML struct {
+	XMLName    xml.Name        `xml:"volume"`
+	Name       string          `xml:"name"`
+	Capacity   defCapacityXML  `xml:"capacity"`
+	Allocation defCapacityXML  `xml:"allocation"`
+	Target     defVolTargetXML `xml:"target"`
+}
+
+type defCapacityXML struct {
+	Unit  string `xml:"unit,attr"`
+	Value uint64 `xml:",chardata"`
+}
+
+type defVolTargetXML struct {
+	Format defModelTypeXML `xml:"format"`
+}
+
+// diskPoolXML returns the document of a transient directory pool named name
+// over dir.
+func diskPoolXML(name, dir string) (string, error) {
+	out, err := xml.Marshal(defPoolXML{Type: "dir", Name: name, Target: defPoolTargetXML{Path: dir}})
+	if err != nil {
+		return "", fmt.Errorf("encode pool xml: %w", err)
+	}
+	return string(out), nil
+}
+
+// diskVolumeXML returns the document of a sparse disk image named file of
+// size bytes.
+func diskVolumeXML(file, format string, size uint64) (string, error) {
+	out, err := xml.Marshal(defVolumeXML{
+		Name:       file,
+		Capacity:   defCapacityXML{Unit: "bytes", Value: size},
+		Allocation: defCapacityXML{Unit: "bytes", Value: 0},
+		Target:     defVolTargetXML{Format: defModelTypeXML{Type: format}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("encode volume xml: %w", err)
+	}
+	return string(out), nil
+}
+
+// ----------------------------------------------------------------------------
+// Tool arguments
+// ----------------------------------------------------------------------------
+
+// parseSize parses a size such as "30g", "512M" or "1TiB" into bytes.
+// Suffixes k, m, g and t are binary multiples and case-insensitive; a bare
+// number is bytes.
+func parseSize(spec string) (uint64, error) {
+	s := strings.ToLower(strings.TrimSpace(spec))
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "b"), "i")
+	mult := uint64(1)
+	if n := len(s); n > 0 {
+		switch s[n-1] {
+		case 'k':
+			mult = 1 << 10
+		case 'm':
+			mult = 1 << 20
+		case 'g':
+			mult = 1 << 30
+		case 't':
+			mult = 1 << 40
+		}
+		if mult > 1 {
+			s = s[:n-1]
+		}
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q (want e.g. 30g or 512m)", spec)
+	}
+	return uint64(n * float64(mult)), nil
+}
+
+// parseDiskArg parses a vm_create disk argument of the form
+// SIZE|PATH[=SIZE][:BUS[:FORMAT]], e.g. "30g", "30g:sata:qcow2" or
+// "/mnt/disks/nvme/vm/vdisk1.img=64g".
+func parseDiskArg(arg string) (VMDiskSpec, error) {
+	parts := strings.SplitN(arg, ":", 3)
+	var d VMDiskSpec
+	size := parts[0]
+	if strings.HasPrefix(parts[0], "/") {
+		d.Path, size, _ = strings.Cut(parts[0], "=")
+	}
+	if size != "" {
+		n, err := parseSize(size)
+		if err != nil {
+			return d, fmt.Errorf("disk %q: %w", arg, err)
+		}
+		d.Size = n
+	}
+	if len(parts) > 1 {
+		d.Bus = parts[1]
+	}
+	if len(parts) > 2 {
+		d.Format = parts[2]
+	}
+	return d, nil
+}
+
+// domainName returns the name of the domain defined by the XML document doc.
+func domainName(doc string) (string, error) {
+	var d struct {
+		XMLName xml.Name `xml:"domain"`
+		Name    string   `xml:"name"`
+	}
+	if err := xml.Unmarshal([]byte(doc), &d); err != nil {
+		return "", fmt.Errorf("parse xml_config: %w", err)
+	}
+	name := strings.TrimSpace(d.Name)
+	if name == "" {
+		return "", fmt.Errorf("xml_config has no <name>")
+	}
+	return name, nil
+}
+
+// parseNICArg parses a vm_create nic argument of the form
+// BRIDGE[:MODEL[:MAC]], e.g. "br0" or "br1:e1000:52:54:00:12:34:56".
+func parseNICArg(arg string) VMNICSpec {
+	parts := strings.SplitN(arg, ":", 3)
+	n := VMNICSpec{Bridge: parts[0]}
+	if len(parts) > 1 {
+		n.Model = parts[1]
+	}
+	if len(parts) > 2 {
+		n.MAC = parts[2]
+	}
+	return n
+}
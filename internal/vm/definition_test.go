@@ -0,0 +1,357 @@
+package vm
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+)
+
+// ---------------------------------------------------------------------------
+// VMCreateSpec
+// ---------------------------------------------------------------------------
+
+func Test_VMCreateSpec_Normalize_Defaults(t *testing.T) {
+	in := VMCreateSpec{Name: "debian", Disks: []VMDiskSpec{{Size: 30 << 30}}, NICs: []VMNICSpec{{}}}
+	got, err := in.Normalize()
+	if err != nil {
+		t.Fatalf("Normalize() error = %v", err)
+	}
+
+	if got.OS != "linux" || got.Machine != "q35" || got.BIOS != "ovmf" || got.Graphics != "vnc" {
+		t.Errorf("os/machine/bios/graphics = %q/%q/%q/%q", got.OS, got.Machine, got.BIOS, got.Graphics)
+	}
+	if got.Memory != defaultVMMemory || got.VCPUs != 1 || got.Cores != 1 || got.Threads != 1 {
+		t.Errorf("memory/vcpus/cores/threads = %d/%d/%d/%d", got.Memory, got.VCPUs, got.Cores, got.Threads)
+	}
+	wantDisk := VMDiskSpec{Path: "/mnt/user/domains/debian/vdisk1.img", Size: 30 << 30, Bus: "virtio", Format: "raw"}
+	if got.Disks[0] != wantDisk {
+		t.Errorf("disk = %+v, want %+v", got.Disks[0], wantDisk)
+	}
+	if n := got.NICs[0]; n.Bridge != "br0" || n.Model != "virtio-net" || !strings.HasPrefix(n.MAC, "52:54:00:") {
+		t.Errorf("nic = %+v", n)
+	}
+	if !uuidPattern.MatchString(got.UUID) {
+		t.Errorf("uuid = %q", got.UUID)
+	}
+	if in.Disks[0].Path != "" || in.NICs[0].MAC != "" {
+		t.Error("Normalize() modified the caller's slices")
+	}
+
+	// A dry run and the confirmed create must render the same definition.
+	again, _ := in.Normalize()
+	if again.UUID != got.UUID || again.NICs[0].MAC != got.NICs[0].MAC {
+		t.Error("Normalize() is not deterministic")
+	}
+	other, _ := VMCreateSpec{Name: "ubuntu", NICs: []VMNICSpec{{}}}.Normalize()
+	if other.UUID == got.UUID || other.NICs[0].MAC == got.NICs[0].MAC {
+		t.Error("different specs derived the same uuid or mac")
+	}
+}
+
+func Test_VMCreateSpec_Normalize_Cases(t *testing.T) {
+	tests := []struct {
+		name        string
+		spec        VMCreateSpec
+		errContains string
+	}{
+		{"vcpus split into cores", VMCreateSpec{Name: "a", VCPUs: 4, Threads: 2}, ""},
+		{"topology gives vcpus", VMCreateSpec{Name: "a", Cores: 3, Threads: 2}, ""},
+		{"i440fx with ide", VMCreateSpec{Name: "a", Machine: "i440fx", Disks: []VMDiskSpec{{Size: 1 << 30, Bus: "ide"}}}, ""},
+		{"versioned machine", VMCreateSpec{Name: "a", Machine: "pc-q35-9.2"}, ""},
+		{"passthrough", VMCreateSpec{Name: "a", USB: []string{"046D:C52B"}, PCI: []string{"0000:0a:00.1"}}, ""},
+		{"empty name", VMCreateSpec{}, "name"},
+		{"slash in name", VMCreateSpec{Name: "a/b"}, "'/'"},
+		{"bad os", VMCreateSpec{Name: "a", OS: "macos"}, "invalid os"},
+		{"too little memory", VMCreateSpec{Name: "a", Memory: 1024}, "minimum"},
+		{"uneven topology", VMCreateSpec{Name: "a", VCPUs: 3, Threads: 2}, "topology"},
+		{"bad machine", VMCreateSpec{Name: "a", Machine: "virt"}, "machine type"},
+		{"bad bios", VMCreateSpec{Name: "a", BIOS: "coreboot"}, "bios"},
+		{"bad graphics", VMCreateSpec{Name: "a", Graphics: "spice"}, "graphics"},
+		{"disk without path or size", VMCreateSpec{Name: "a", Disks: []VMDiskSpec{{}}}, "path or a size"},
+		{"relative disk path", VMCreateSpec{Name: "a", Disks: []VMDiskSpec{{Path: "vdisk1.img"}}}, "absolute"},
+		{"duplicate disk", VMCreateSpec{Name: "a", Disks: []VMDiskSpec{{Path: "/x.img"}, {Path: "/x.img"}}}, "twice"},
+		{"ide on q35", VMCreateSpec{Name: "a", Disks: []VMDiskSpec{{Size: 1 << 30, Bus: "ide"}}}, "ide"},
+		{"bad format", VMCreateSpec{Name: "a", Disks: []VMDiskSpec{{Size: 1 << 30, Format: "vmdk"}}}, "format"},
+		{"too many cdroms", VMCreateSpec{Name: "a", CDROMs: []string{"/a.iso", "/b.iso", "/c.iso"}}, "cdroms"},
+		{"bad nic model", VMCreateSpec{Name: "a", NICs: []VMNICSpec{{Model: "ne2k"}}}, "model"},
+		{"bad mac", VMCreateSpec{Name: "a", NICs: []VMNICSpec{{MAC: "52:54:00"}}}, "mac"},
+		{"bad usb id", VMCreateSpec{Name: "a", USB: []string{"logitech"}}, "usb"},
+		{"bad pci address", VMCreateSpec{Name: "a", PCI: []string{"1:0.0"}}, "pci"},
+		{"bad uuid", VMCreateSpec{Name: "a", UUID: "not-a-uuid"}, "uuid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.spec.Normalize()
+			if tt.errContains == "" {
+				if err != nil {
+					t.Errorf("Normalize() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("Normalize() error = %v, want one containing %q", err, tt.errContains)
+			}
+		})
+	}
+}
+
+func Test_VMCreateSpec_DomainXML(t *testing.T) {
+	spec := VMCreateSpec{
+		Name:    "win11",
+		OS:      "windows",
+		Memory:  8 << 20,
+		VCPUs:   4,
+		Threads: 2,
+		Disks:   []VMDiskSpec{{Size: 64 << 30}, {Path: "/mnt/disks/nvme/data.qcow2", Bus: "scsi", Format: "qcow2"}},
+		CDROMs:  []string{"/mnt/user/isos/win11.iso", "/mnt/user/isos/virtio-win.iso"},
+		NICs:    []VMNICSpec{{Bridge: "br1", Model: "e1000", MAC: "52:54:00:AA:BB:CC"}},
+		USB:     []string{"046d:c52b"},
+		PCI:     []string{"01:00.0"},
+	}
+	out, err := spec.DomainXML()
+	if err != nil {
+		t.Fatalf("DomainXML() error = %v", err)
+	}
+	var doc defDomainXML
+	if err := xml.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("generated XML does not parse: %v\n%s", err, out)
+	}
+
+	if doc.Name != "win11" || doc.Memory.Value != 8<<20 || doc.VCPU.Value != 4 {
+		t.Errorf("name/memory/vcpu = %q/%d/%d", doc.Name, doc.Memory.Value, doc.VCPU.Value)
+	}
+	if tp := doc.CPU.Topology; tp.Sockets != 1 || tp.Cores != 2 || tp.Threads != 2 {
+		t.Errorf("topology = %+v", tp)
+	}
+	if !strings.Contains(out, `<vmtemplate xmlns="unraid" name="Windows 10" icon="windows.png" os="windows10">`) {
+		t.Errorf("missing Unraid template metadata:\n%s", out)
+	}
+	if doc.OS.Loader == nil || doc.OS.Loader.Path != unraidOVMFCode {
+		t.Errorf("loader = %+v", doc.OS.Loader)
+	}
+	if doc.OS.NVRAM == nil || doc.OS.NVRAM.Path != unraidNVRAMDir+"/"+doc.UUID+"_VARS-pure-efi.fd" {
+		t.Errorf("nvram = %+v", doc.OS.NVRAM)
+	}
+	if doc.Features.HyperV == nil || doc.Clock.Offset != "localtime" {
+		t.Errorf("windows guest without hyperv or localtime clock: %+v %+v", doc.Features.HyperV, doc.Clock)
+	}
+
+	var devs []string
+	for _, d := range doc.Devices.Disks {
+		devs = append(devs, d.Device+":"+d.Target.Dev+":"+d.Target.Bus+":"+d.Source.File)
+	}
+	wantDevs := []string{
+		"disk:hdc:virtio:/mnt/user/domains/win11/vdisk1.img",
+		"disk:hdd:scsi:/mnt/disks/nvme/data.qcow2",
+		"cdrom:hda:sata:/mnt/user/isos/win11.iso",
+		"cdrom:hdb:sata:/mnt/user/isos/virtio-win.iso",
+	}
+	if strings.Join(devs, ",") != strings.Join(wantDevs, ",") {
+		t.Errorf("disks = %v, want %v", devs, wantDevs)
+	}
+	if len(doc.Devices.Controllers) != 2 || doc.Devices.Controllers[1].Model != "virtio-scsi" {
+		t.Errorf("controllers = %+v, want usb and virtio-scsi", doc.Devices.Controllers)
+	}
+	if n := doc.Devices.Interfaces; len(n) != 1 || n[0].MAC.Address != "52:54:00:aa:bb:cc" || n[0].Source.Bridge != "br1" || n[0].Model.Type != "e1000" {
+		t.Errorf("interfaces = %+v", n)
+	}
+	if doc.Devices.Graphics == nil || doc.Devices.Graphics.Type != "vnc" {
+		t.Errorf("graphics = %+v", doc.Devices.Graphics)
+	}
+	if h := doc.Devices.Hostdevs; len(h) != 2 ||
+		h[0].Source.Vendor.ID != "0x046d" || h[0].Source.Product.ID != "0xc52b" ||
+		h[1].Source.Address.Domain != "0x0000" || h[1].Source.Address.Bus != "0x01" || h[1].Source.Address.Function != "0x0" {
+		t.Errorf("hostdevs = %+v", h)
+	}
+	if doc.Devices.Memballoon.Model != "none" {
+		t.Errorf("memballoon = %q with a PCI device passed through, want none", doc.Devices.Memballoon.Model)
+	}
+}
+
+func Test_VMCreateSpec_DomainXML_SeaBIOSWithoutGraphics(t *testing.T) {
+	out, err := VMCreateSpec{Name: "router", BIOS: "seabios", Graphics: "none", Machine: "i440fx"}.DomainXML()
+	if err != nil {
+		t.Fatalf("DomainXML() error = %v", err)
+	}
+	for _, unwanted := range []string{"<loader", "<nvram", "<graphics", "<video", "<hyperv"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("DomainXML() contains %s:\n%s", unwanted, out)
+		}
+	}
+	if !strings.Contains(out, `machine="pc"`) {
+		t.Errorf("i440fx not rendered as machine pc:\n%s", out)
+	}
+	if !strings.Contains(out, `<memballoon model="virtio">`) {
+		t.Errorf("memballoon is not virtio without passthrough:\n%s", out)
+	}
+}
+
+func Test_DiskVolumeXML(t *testing.T) {
+	got, err := diskVolumeXML("vdisk1.img", "qcow2", 1<<30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<volume><name>vdisk1.img</name><capacity unit="bytes">1073741824</capacity>` +
+		`<allocation unit="bytes">0</allocation><target><format type="qcow2"></format></target></volume>`
+	if got != want {
+		t.Errorf("diskVolumeXML() = %s, want %s", got, want)
+	}
+	pool, _ := diskPoolXML("tmp", "/mnt/user/domains/a & b")
+	if pool != `<pool type="dir"><name>tmp</name><target><path>/mnt/user/domains/a &amp; b</path></target></pool>` {
+		t.Errorf("diskPoolXML() = %s", pool)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Tool arguments
+// ---------------------------------------------------------------------------
+
+func Test_ParseDiskArg(t *testing.T) {
+	tests := []struct {
+		arg     string
+		want    VMDiskSpec
+		wantErr bool
+	}{
+		{arg: "30g", want: VMDiskSpec{Size: 30 << 30}},
+		{arg: "1.5TiB:sata:qcow2", want: VMDiskSpec{Size: 3 << 39, Bus: "sata", Format: "qcow2"}},
+		{arg: "/mnt/user/domains/a/vdisk1.img", want: VMDiskSpec{Path: "/mnt/user/domains/a/vdisk1.img"}},
+		{arg: "/mnt/disks/nvme/a.img=64g:scsi", want: VMDiskSpec{Path: "/mnt/disks/nvme/a.img", Size: 64 << 30, Bus: "scsi"}},
+		{arg: "big", wantErr: true},
+		{arg: "-1g", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseDiskArg(tt.arg)
+		if (err != nil) != tt.wantErr || (!tt.wantErr && got != tt.want) {
+			t.Errorf("parseDiskArg(%q) = %+v, %v; want %+v", tt.arg, got, err, tt.want)
+		}
+	}
+
+	if n := parseNICArg("br1:e1000:52:54:00:12:34:56"); n != (VMNICSpec{Bridge: "br1", Model: "e1000", MAC: "52:54:00:12:34:56"}) {
+		t.Errorf("parseNICArg() = %+v", n)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// vm_create
+// ---------------------------------------------------------------------------
+
+var vmTokenPattern = regexp.MustCompile(`confirmation_token="([a-f0-9]+)"`)
+
+func Test_VMCreate_Spec(t *testing.T) {
+	mgr := newSeededMock(t)
+	regs := VMTools(mgr, safety.NewFilter(nil, []string{"blocked"}), safety.NewConfirmationTracker(DestructiveTools), nil)
+	args := map[string]any{
+		"name":   "debian",
+		"memory": "4g",
+		"vcpus":  float64(2),
+		"disks":  []any{"30g"},
+		"cdroms": []any{"/mnt/user/isos/debian.iso"},
+		"nics":   []any{"br0"},
+	}
+
+	preview := map[string]any{"dry_run": true}
+	for k, v := range args {
+		preview[k] = v
+	}
+	var p CreatePreview
+	if err := json.Unmarshal([]byte(callStatsTool(t, regs, "vm_create", preview)), &p); err != nil {
+		t.Fatalf("decode dry run: %v", err)
+	}
+	if !strings.Contains(p.XMLConfig, "<name>debian</name>") || len(p.NewDisks) != 1 || p.NewDisks[0].Size != 30<<30 {
+		t.Errorf("dry run = %+v", p)
+	}
+	if len(mgr.disks) != 0 {
+		t.Fatal("dry run created disks")
+	}
+
+	text := callStatsTool(t, regs, "vm_create", args)
+	m := vmTokenPattern.FindStringSubmatch(text)
+	if m == nil {
+		t.Fatalf("no confirmation prompt:\n%s", text)
+	}
+	if !strings.Contains(text, "/mnt/user/domains/debian/vdisk1.img (30 GiB, raw)") {
+		t.Errorf("prompt does not list the new disk:\n%s", text)
+	}
+	args["confirmation_token"] = m[1]
+	if text := callStatsTool(t, regs, "vm_create", args); !strings.Contains(text, "created successfully") {
+		t.Fatalf("confirmed create = %s", text)
+	}
+	if mgr.disks["/mnt/user/domains/debian/vdisk1.img"] != 30<<30 {
+		t.Errorf("disks = %v", mgr.disks)
+	}
+	vms, _ := mgr.ListVMs(t.Context())
+	found := false
+	for _, v := range vms {
+		d, _ := mgr.InspectVM(t.Context(), v.Name)
+		found = found || d.XMLConfig == p.XMLConfig
+	}
+	if !found {
+		t.Error("defined XML differs from the dry run")
+	}
+
+	errTests := []struct {
+		name string
+		args map[string]any
+		want string
+	}{
+		{"denied", map[string]any{"name": "blocked"}, "not allowed"},
+		{"existing", map[string]any{"name": "win10"}, "already exists"},
+		{"both xml and spec", map[string]any{"name": "x", "xml_config": "<domain/>"}, "either"},
+		{"xml without name", map[string]any{"xml_config": "<domain/>"}, "no <name>"},
+		{"xml not a domain", map[string]any{"xml_config": "<pool><name>x</name></pool>"}, "parse xml_config"},
+		{"xml denied", map[string]any{"xml_config": "<domain><name>blocked</name></domain>"}, "not allowed"},
+		{"neither", map[string]any{}, "name is required"},
+		{"bad memory", map[string]any{"name": "x", "memory": "lots"}, "memory"},
+		{"bad spec", map[string]any{"name": "x", "bios": "uefi"}, "bios"},
+	}
+	for _, tt := range errTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if text := callStatsTool(t, regs, "vm_create", tt.args); !strings.Contains(text, tt.want) {
+				t.Errorf("vm_create = %s, want %q", text, tt.want)
+			}
+		})
+	}
+}
+
+func Test_VMCreate_XMLConfirmsByName(t *testing.T) {
+	mgr := newSeededMock(t)
+	regs := VMTools(mgr, safety.NewFilter(nil, nil), safety.NewConfirmationTracker(DestructiveTools), nil)
+	args := map[string]any{"xml_config": "<domain type=\"kvm\"><name> router </name></domain>"}
+
+	text := callStatsTool(t, regs, "vm_create", args)
+	m := vmTokenPattern.FindStringSubmatch(text)
+	if m == nil || !strings.Contains(text, `on "router"`) {
+		t.Fatalf("prompt is not bound to the domain name:\n%s", text)
+	}
+	args["confirmation_token"] = m[1]
+	if text := callStatsTool(t, regs, "vm_create", args); !strings.Contains(text, `"router" created successfully`) {
+		t.Errorf("confirmed create = %s", text)
+	}
+}
+
+func Test_VMCreate_RemovesDisksOnFailure(t *testing.T) {
+	mgr := newSeededMock(t)
+	// The second image is in the way, so creating it fails.
+	mgr.disks = map[string]uint64{"/mnt/user/domains/debian/vdisk2.img": 1 << 30}
+	regs := VMTools(mgr, safety.NewFilter(nil, nil), safety.NewConfirmationTracker(DestructiveTools), nil)
+	args := map[string]any{"name": "debian", "disks": []any{"30g", "10g"}}
+
+	m := vmTokenPattern.FindStringSubmatch(callStatsTool(t, regs, "vm_create", args))
+	if m == nil {
+		t.Fatal("no confirmation prompt")
+	}
+	args["confirmation_token"] = m[1]
+	if text := callStatsTool(t, regs, "vm_create", args); !strings.Contains(text, "already exists") {
+		t.Fatalf("vm_create = %s, want the disk error", text)
+	}
+	if _, ok := mgr.disks["/mnt/user/domains/debian/vdisk1.img"]; ok {
+		t.Error("first image was left behind")
+	}
+	if _, err := mgr.InspectVM(t.Context(), "debian"); err == nil {
+		t.Error("vm was defined despite the failure")
+	}
+}
@@ -10,7 +10,7 @@ import (
 // ---------------------------------------------------------------------------
 
 func Test_DestructiveTools_Length(t *testing.T) {
-	const wantLen = 5
+	const wantLen = 15
 	if got := len(DestructiveTools); got != wantLen {
 		t.Errorf("len(DestructiveTools) = %d, want %d", got, wantLen)
 	}
@@ -23,6 +23,16 @@ func Test_DestructiveTools_ContainsExpectedNames(t *testing.T) {
 		"vm_restart",
 		"vm_create",
 		"vm_delete",
+		"vm_snapshot_revert",
+		"vm_snapshot_delete",
+		"vm_set_memory",
+		"vm_set_vcpus",
+		"vm_disk_attach",
+		"vm_disk_detach",
+		"vm_nic_attach",
+		"vm_nic_detach",
+		"vm_cdrom_insert",
+		"vm_cdrom_eject",
 	}
 
 	// Build a set from the actual variable for O(1) lookup.
@@ -42,11 +52,21 @@ func Test_DestructiveTools_ContainsExpectedNames(t *testing.T) {
 
 func Test_DestructiveTools_NoUnexpectedEntries(t *testing.T) {
 	expected := map[string]struct{}{
-		"vm_stop":       {},
-		"vm_force_stop": {},
-		"vm_restart":    {},
-		"vm_create":     {},
-		"vm_delete":     {},
+		"vm_stop":            {},
+		"vm_force_stop":      {},
+		"vm_restart":         {},
+		"vm_create":          {},
+		"vm_delete":          {},
+		"vm_snapshot_revert": {},
+		"vm_snapshot_delete": {},
+		"vm_set_memory":      {},
+		"vm_set_vcpus":       {},
+		"vm_disk_attach":     {},
+		"vm_disk_detach":     {},
+		"vm_nic_attach":      {},
+		"vm_nic_detach":      {},
+		"vm_cdrom_insert":    {},
+		"vm_cdrom_eject":     {},
 	}
 
 	for _, name := range DestructiveTools {
@@ -59,10 +79,20 @@ func Test_DestructiveTools_NoUnexpectedEntries(t *testing.T) {
 func Test_DestructiveTools_ExactContents(t *testing.T) {
 	// Comprehensive check: sort both slices and compare element-by-element.
 	expected := []string{
+		"vm_cdrom_eject",
+		"vm_cdrom_insert",
 		"vm_create",
 		"vm_delete",
+		"vm_disk_attach",
+		"vm_disk_detach",
 		"vm_force_stop",
+		"vm_nic_attach",
+		"vm_nic_detach",
 		"vm_restart",
+		"vm_set_memory",
+		"vm_set_vcpus",
+		"vm_snapshot_delete",
+		"vm_snapshot_revert",
 		"vm_stop",
 	}
 
@@ -20,6 +20,7 @@ import (
 	"encoding/xml"
 	"fmt"
 	"net"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -311,6 +312,60 @@ func (m *LibvirtVMManager) RestartVM(ctx context.Context, name string) error {
 	return nil
 }
 
+// SetAutostart sets whether the named domain is started when the host boots.
+func (m *LibvirtVMManager) SetAutostart(ctx context.Context, name string, enabled bool) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("set autostart: %w", err)
+	}
+
+	dom, err := m.l.DomainLookupByName(name)
+	if err != nil {
+		return fmt.Errorf("vm %q not found: %w", name, err)
+	}
+
+	var autostart int32
+	if enabled {
+		autostart = 1
+	}
+	if err := m.l.DomainSetAutostart(dom, autostart); err != nil {
+		return fmt.Errorf("set autostart for vm %q: %w", name, err)
+	}
+	return nil
+}
+
+// vmStatsTypes selects the domain stats groups GetVMStats reads.
+const vmStatsTypes = libvirt.DomainStatsState | libvirt.DomainStatsCPUTotal | libvirt.DomainStatsBalloon |
+	libvirt.DomainStatsVCPU | libvirt.DomainStatsInterface | libvirt.DomainStatsBlock
+
+// GetVMStats reads the named VM's resource counters with a single domain
+// stats call. The counters of a VM that is not running are zero.
+func (m *LibvirtVMManager) GetVMStats(ctx context.Context, name string) (*VMStats, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("get vm stats: %w", err)
+	}
+
+	dom, err := m.l.DomainLookupByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("vm %q not found: %w", name, err)
+	}
+
+	records, err := m.l.ConnectGetAllDomainStats([]libvirt.Domain{dom}, uint32(vmStatsTypes), 0)
+	if err != nil {
+		return nil, fmt.Errorf("get vm stats %q: %w", name, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("get vm stats %q: no stats returned", name)
+	}
+
+	params := make(map[string]any, len(records[0].Params))
+	for _, p := range records[0].Params {
+		params[p.Field] = p.Value.I
+	}
+	stats := vmStatsFromParams(name, params)
+	stats.State = libvirtStateToVMState(libvirt.DomainState(paramUint(params, "state.state")))
+	return stats, nil
+}
+
 // CreateVM defines a new domain from the supplied XML configuration.  The XML
 // must not be empty or consist solely of whitespace.
 func (m *LibvirtVMManager) CreateVM(ctx context.Context, xmlConfig string) error {
@@ -327,6 +382,67 @@ func (m *LibvirtVMManager) CreateVM(ctx context.Context, xmlConfig string) error
 	return nil
 }
 
+// CreateDisk creates a sparse disk image of size bytes at path, creating its
+// directory if needed. libvirt writes the image, through a transient
+// directory pool over its folder, so the server needs no access to the
+// host's shares; the image must not already exist.
+func (m *LibvirtVMManager) CreateDisk(ctx context.Context, path, format string, size uint64) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("create disk: %w", err)
+	}
+
+	volDoc, err := diskVolumeXML(filepath.Base(path), format, size)
+	if err != nil {
+		return fmt.Errorf("create disk %q: %w", path, err)
+	}
+	err = m.withDiskPool(path, libvirt.StoragePoolCreateWithBuild, func(pool libvirt.StoragePool) error {
+		_, err := m.l.StorageVolCreateXML(pool, volDoc, 0)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("create disk %q: %w", path, err)
+	}
+	return nil
+}
+
+// DeleteDisk removes the disk image at path through the same kind of
+// transient pool CreateDisk uses. It is meant for rolling back images a
+// failed create or attach left behind.
+func (m *LibvirtVMManager) DeleteDisk(ctx context.Context, path string) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("delete disk: %w", err)
+	}
+
+	err := m.withDiskPool(path, 0, func(pool libvirt.StoragePool) error {
+		vol, err := m.l.StorageVolLookupByName(pool, filepath.Base(path))
+		if err != nil {
+			return err
+		}
+		return m.l.StorageVolDelete(vol, 0)
+	})
+	if err != nil {
+		return fmt.Errorf("delete disk %q: %w", path, err)
+	}
+	return nil
+}
+
+// withDiskPool runs fn against a transient directory pool over the folder
+// holding path. Destroying a transient pool removes only the pool, not its
+// files.
+func (m *LibvirtVMManager) withDiskPool(path string, flags libvirt.StoragePoolCreateFlags, fn func(libvirt.StoragePool) error) error {
+	poolName := fmt.Sprintf("unraid-mcp-%d", time.Now().UnixNano())
+	poolDoc, err := diskPoolXML(poolName, filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	pool, err := m.l.StoragePoolCreateXML(poolDoc, flags)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = m.l.StoragePoolDestroy(pool) }()
+	return fn(pool)
+}
+
 // DeleteVM undefines (removes the persistent definition of) the named domain.
 // It returns an error containing "not found" if the domain does not exist.
 func (m *LibvirtVMManager) DeleteVM(ctx context.Context, name string) error {
@@ -345,8 +461,294 @@ func (m *LibvirtVMManager) DeleteVM(ctx context.Context, name string) error {
 	return nil
 }
 
+// Flags of libvirt's device, memory and vCPU update calls
+// (virDomainModificationImpact and the per-call MAXIMUM and FORCE bits), and
+// of virDomainGetXMLDesc. They are untyped so that they fit whichever flag
+// type each go-libvirt call takes.
+const (
+	affectLive    = 1
+	affectConfig  = 2
+	affectMaximum = 4 // VIR_DOMAIN_MEM_MAXIMUM, VIR_DOMAIN_VCPU_MAXIMUM
+	deviceForce   = 4 // VIR_DOMAIN_DEVICE_MODIFY_FORCE
+	xmlInactive   = 2 // VIR_DOMAIN_XML_INACTIVE
+)
+
+// domainXMLDesc returns the persistent definition of a domain when inactive
+// is true, and its live document otherwise.
+func (m *LibvirtVMManager) domainXMLDesc(dom libvirt.Domain, inactive bool) (string, error) {
+	var doc string
+	var err error
+	if inactive {
+		doc, err = m.l.DomainGetXMLDesc(dom, xmlInactive)
+	} else {
+		doc, err = m.l.DomainGetXMLDesc(dom, 0)
+	}
+	if err != nil {
+		return "", fmt.Errorf("get xml desc: %w", err)
+	}
+	return doc, nil
+}
+
+// applyChange applies a configuration change to the named domain: live
+// changes the running domain and config its persistent definition, each as
+// mode asks. In VMChangeBoth mode a change the running guest rejects, such
+// as more memory than it booted with, is still saved in the definition, and
+// the result notes why it is not live. The diff is of the definition, or of
+// the live document for a live-only change.
+func (m *LibvirtVMManager) applyChange(ctx context.Context, op, name string, mode VMChangeMode, live, config func(libvirt.Domain) error) (*VMChangeResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	dom, err := m.l.DomainLookupByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("vm %q not found: %w", name, err)
+	}
+	state, err := m.domainState(dom)
+	if err != nil {
+		return nil, fmt.Errorf("%s %q: %w", op, name, err)
+	}
+	running := state == VMStateRunning || state == VMStatePaused
+	if mode == VMChangeLive && !running {
+		return nil, fmt.Errorf("%s %q: vm is not running, so there is nothing to change live", op, name)
+	}
+
+	persistent := mode != VMChangeLive
+	before, err := m.domainXMLDesc(dom, persistent)
+	if err != nil {
+		return nil, fmt.Errorf("%s %q: %w", op, name, err)
+	}
+
+	result := &VMChangeResult{}
+	switch {
+	case mode == VMChangePersistent:
+	case !running:
+		result.Note = "the vm is not running; the change takes effect when it starts"
+	default:
+		if err := live(dom); err != nil {
+			if mode == VMChangeLive {
+				return nil, fmt.Errorf("%s %q: %w", op, name, err)
+			}
+			result.Note = fmt.Sprintf("not applied to the running vm (%v); the change takes effect when it next starts", err)
+		} else {
+			result.Live = true
+		}
+	}
+	if persistent {
+		if err := config(dom); err != nil {
+			if result.Live {
+				return nil, fmt.Errorf("%s %q: applied to the running vm but not saved in its definition: %w", op, name, err)
+			}
+			return nil, fmt.Errorf("%s %q: %w", op, name, err)
+		}
+		result.Persistent = true
+	}
+
+	after, err := m.domainXMLDesc(dom, persistent)
+	if err != nil {
+		return nil, fmt.Errorf("%s %q: %w", op, name, err)
+	}
+	result.Diff = xmlDiff(before, after)
+	return result, nil
+}
+
+// SetVMMemory sets the memory of the named domain, in KB. Live, only the
+// balloon target can change, up to the memory the guest booted with; the
+// definition's maximum and current memory are both set.
+func (m *LibvirtVMManager) SetVMMemory(ctx context.Context, name string, memory uint64, mode VMChangeMode) (*VMChangeResult, error) {
+	if memory < minVMMemory {
+		return nil, fmt.Errorf("set memory: %d KB is below the minimum of %d KB", memory, minVMMemory)
+	}
+	return m.applyChange(ctx, "set memory", name, mode,
+		func(dom libvirt.Domain) error {
+			return m.l.DomainSetMemoryFlags(dom, memory, affectLive)
+		},
+		func(dom libvirt.Domain) error {
+			// Setting the maximum first also lowers the current memory when
+			// shrinking.
+			if err := m.l.DomainSetMemoryFlags(dom, memory, affectConfig|affectMaximum); err != nil {
+				return err
+			}
+			return m.l.DomainSetMemoryFlags(dom, memory, affectConfig)
+		})
+}
+
+// SetVMVCPUs sets the vCPU count of the named domain. Live, vCPUs can only
+// be hot-plugged up to the domain's maximum. The definition is rewritten
+// with a matching CPU topology of threads threads per core (0 keeps the
+// current number where it fits), since libvirt refuses a vCPU count that
+// does not match the topology.
+func (m *LibvirtVMManager) SetVMVCPUs(ctx context.Context, name string, vcpus, threads int, mode VMChangeMode) (*VMChangeResult, error) {
+	if vcpus < 1 {
+		return nil, fmt.Errorf("set vcpus: invalid vcpu count %d", vcpus)
+	}
+	return m.applyChange(ctx, "set vcpus", name, mode,
+		func(dom libvirt.Domain) error {
+			return m.l.DomainSetVcpusFlags(dom, uint32(vcpus), affectLive)
+		},
+		func(dom libvirt.Domain) error {
+			doc, err := m.domainXMLDesc(dom, true)
+			if err != nil {
+				return err
+			}
+			doc, err = setVCPUsXML(doc, vcpus, threads)
+			if err != nil {
+				return err
+			}
+			_, err = m.l.DomainDefineXML(doc)
+			return err
+		})
+}
+
+// AttachDisk attaches a normalized disk image to the named domain, at the
+// first hdX target free in both its live and persistent documents.
+func (m *LibvirtVMManager) AttachDisk(ctx context.Context, name string, disk VMDiskSpec, mode VMChangeMode) (*VMChangeResult, error) {
+	var doc string
+	prepare := func(dom libvirt.Domain) error {
+		if doc != "" {
+			return nil
+		}
+		liveDoc, err := m.domainXMLDesc(dom, false)
+		if err != nil {
+			return err
+		}
+		configDoc, err := m.domainXMLDesc(dom, true)
+		if err != nil {
+			return err
+		}
+		target, err := nextDiskTarget(liveDoc, configDoc)
+		if err != nil {
+			return err
+		}
+		doc, err = diskDeviceXML(disk, target)
+		return err
+	}
+	return m.applyChange(ctx, "attach disk", name, mode,
+		func(dom libvirt.Domain) error {
+			if err := prepare(dom); err != nil {
+				return err
+			}
+			return m.l.DomainAttachDeviceFlags(dom, doc, affectLive)
+		},
+		func(dom libvirt.Domain) error {
+			if err := prepare(dom); err != nil {
+				return err
+			}
+			return m.l.DomainAttachDeviceFlags(dom, doc, affectConfig)
+		})
+}
+
+// DetachDisk detaches the disk of the named domain with the given target or
+// image path. The image itself is kept.
+func (m *LibvirtVMManager) DetachDisk(ctx context.Context, name, disk string, mode VMChangeMode) (*VMChangeResult, error) {
+	return m.applyChange(ctx, "detach disk", name, mode,
+		func(dom libvirt.Domain) error {
+			doc, err := m.domainXMLDesc(dom, false)
+			if err != nil {
+				return err
+			}
+			dev, err := findDisk(doc, disk)
+			if err != nil {
+				return err
+			}
+			return m.l.DomainDetachDeviceFlags(dom, dev.XML, affectLive)
+		},
+		func(dom libvirt.Domain) error {
+			doc, err := m.domainXMLDesc(dom, true)
+			if err != nil {
+				return err
+			}
+			dev, err := findDisk(doc, disk)
+			if err != nil {
+				return err
+			}
+			return m.l.DomainDetachDeviceFlags(dom, dev.XML, affectConfig)
+		})
+}
+
+// AttachNIC attaches a normalized bridge interface to the named domain.
+func (m *LibvirtVMManager) AttachNIC(ctx context.Context, name string, nic VMNICSpec, mode VMChangeMode) (*VMChangeResult, error) {
+	doc, err := nicDeviceXML(nic)
+	if err != nil {
+		return nil, fmt.Errorf("attach nic: %w", err)
+	}
+	return m.applyChange(ctx, "attach nic", name, mode,
+		func(dom libvirt.Domain) error {
+			return m.l.DomainAttachDeviceFlags(dom, doc, affectLive)
+		},
+		func(dom libvirt.Domain) error {
+			return m.l.DomainAttachDeviceFlags(dom, doc, affectConfig)
+		})
+}
+
+// DetachNIC detaches the interface of the named domain with the given MAC
+// address.
+func (m *LibvirtVMManager) DetachNIC(ctx context.Context, name, mac string, mode VMChangeMode) (*VMChangeResult, error) {
+	return m.applyChange(ctx, "detach nic", name, mode,
+		func(dom libvirt.Domain) error {
+			doc, err := m.domainXMLDesc(dom, false)
+			if err != nil {
+				return err
+			}
+			dev, err := findNIC(doc, mac)
+			if err != nil {
+				return err
+			}
+			return m.l.DomainDetachDeviceFlags(dom, dev.XML, affectLive)
+		},
+		func(dom libvirt.Domain) error {
+			doc, err := m.domainXMLDesc(dom, true)
+			if err != nil {
+				return err
+			}
+			dev, err := findNIC(doc, mac)
+			if err != nil {
+				return err
+			}
+			return m.l.DomainDetachDeviceFlags(dom, dev.XML, affectConfig)
+		})
+}
+
+// ChangeMedia inserts iso into a cdrom drive of the named domain, or ejects
+// the drive's media when iso is empty. drive is the drive's target; empty
+// means the first cdrom drive. A live eject is forced, as the guest may have
+// locked the tray.
+func (m *LibvirtVMManager) ChangeMedia(ctx context.Context, name, drive, iso string, mode VMChangeMode) (*VMChangeResult, error) {
+	return m.applyChange(ctx, "change media", name, mode,
+		func(dom libvirt.Domain) error {
+			doc, err := m.domainXMLDesc(dom, false)
+			if err != nil {
+				return err
+			}
+			dev, err := findCDROM(doc, drive)
+			if err != nil {
+				return err
+			}
+			update, err := cdromMediaXML(dev.Disk, iso)
+			if err != nil {
+				return err
+			}
+			return m.l.DomainUpdateDeviceFlags(dom, update, affectLive|deviceForce)
+		},
+		func(dom libvirt.Domain) error {
+			doc, err := m.domainXMLDesc(dom, true)
+			if err != nil {
+				return err
+			}
+			dev, err := findCDROM(doc, drive)
+			if err != nil {
+				return err
+			}
+			update, err := cdromMediaXML(dev.Disk, iso)
+			if err != nil {
+				return err
+			}
+			return m.l.DomainUpdateDeviceFlags(dom, update, affectConfig)
+		})
+}
+
 // ListSnapshots returns the metadata for every snapshot associated with
-// the named VM.
+// the named VM, read from each snapshot's XML description.
 func (m *LibvirtVMManager) ListSnapshots(ctx context.Context, vmName string) ([]Snapshot, error) {
 	if err := ctx.Err(); err != nil {
 		return nil, fmt.Errorf("list snapshots: %w", err)
@@ -357,47 +759,159 @@ func (m *LibvirtVMManager) ListSnapshots(ctx context.Context, vmName string) ([]
 		return nil, fmt.Errorf("vm %q not found: %w", vmName, err)
 	}
 
-	names, err := m.l.DomainSnapshotListNames(dom, 0, 0)
+	snaps, _, err := m.l.DomainListAllSnapshots(dom, 1, 0)
 	if err != nil {
 		return nil, fmt.Errorf("list snapshots for %q: %w", vmName, err)
 	}
+	current := m.currentSnapshot(dom)
 
-	out := make([]Snapshot, 0, len(names))
-	for _, n := range names {
+	out := make([]Snapshot, 0, len(snaps))
+	for _, snap := range snaps {
 		if ctx.Err() != nil {
 			return nil, fmt.Errorf("list snapshots: %w", ctx.Err())
 		}
-		out = append(out, Snapshot{
-			Name:      n,
-			CreatedAt: time.Now(),
-		})
+		detail, err := m.snapshotDetail(snap, current)
+		if err != nil {
+			// Snapshots deleted while listing are skipped, as in ListVMs.
+			continue
+		}
+		out = append(out, detail.Snapshot)
 	}
 	return out, nil
 }
 
+// InspectSnapshot returns the full details of the named snapshot of a VM. It
+// returns an error containing "not found" if either does not exist.
+func (m *LibvirtVMManager) InspectSnapshot(ctx context.Context, vmName, snapName string) (*SnapshotDetail, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("inspect snapshot: %w", err)
+	}
+
+	dom, snap, err := m.lookupSnapshot(vmName, snapName)
+	if err != nil {
+		return nil, err
+	}
+
+	detail, err := m.snapshotDetail(snap, m.currentSnapshot(dom))
+	if err != nil {
+		return nil, fmt.Errorf("inspect snapshot %q of vm %q: %w", snapName, vmName, err)
+	}
+	return detail, nil
+}
+
 // CreateSnapshot creates a new snapshot of the named VM with the given snapshot
-// name.
-func (m *LibvirtVMManager) CreateSnapshot(ctx context.Context, vmName, snapName string) error {
+// name. opts selects a disk-only snapshot, optionally with the guest
+// filesystems quiesced through the QEMU guest agent.
+func (m *LibvirtVMManager) CreateSnapshot(ctx context.Context, vmName, snapName string, opts SnapshotOptions) error {
 	if err := ctx.Err(); err != nil {
 		return fmt.Errorf("create snapshot: %w", err)
 	}
 
+	snapXML, err := snapshotCreateXML(snapName, opts)
+	if err != nil {
+		return fmt.Errorf("create snapshot: %w", err)
+	}
+
 	dom, err := m.l.DomainLookupByName(vmName)
 	if err != nil {
 		return fmt.Errorf("vm %q not found: %w", vmName, err)
 	}
 
-	snapXML := fmt.Sprintf("<domainsnapshot><name>%s</name></domainsnapshot>", snapName)
-	if _, err := m.l.DomainSnapshotCreateXML(dom, snapXML, 0); err != nil {
+	// DomainSnapshotCreateXML takes its flags as a plain uint32.
+	var flags uint32
+	if opts.DiskOnly {
+		flags |= uint32(libvirt.DomainSnapshotCreateDiskOnly)
+	}
+	if opts.Quiesce {
+		flags |= uint32(libvirt.DomainSnapshotCreateQuiesce)
+	}
+	if _, err := m.l.DomainSnapshotCreateXML(dom, snapXML, flags); err != nil {
 		return fmt.Errorf("create snapshot %q for vm %q: %w", snapName, vmName, err)
 	}
 	return nil
 }
 
+// RevertSnapshot reverts the named VM to a snapshot, discarding its current
+// state. The VM ends up in the state the snapshot recorded.
+func (m *LibvirtVMManager) RevertSnapshot(ctx context.Context, vmName, snapName string) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("revert snapshot: %w", err)
+	}
+
+	_, snap, err := m.lookupSnapshot(vmName, snapName)
+	if err != nil {
+		return err
+	}
+
+	if err := m.l.DomainRevertToSnapshot(snap, 0); err != nil {
+		return fmt.Errorf("revert vm %q to snapshot %q: %w", vmName, snapName, err)
+	}
+	return nil
+}
+
+// DeleteSnapshot deletes a snapshot of the named VM. Its children are
+// re-parented onto its parent rather than deleted.
+func (m *LibvirtVMManager) DeleteSnapshot(ctx context.Context, vmName, snapName string) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("delete snapshot: %w", err)
+	}
+
+	_, snap, err := m.lookupSnapshot(vmName, snapName)
+	if err != nil {
+		return err
+	}
+
+	if err := m.l.DomainSnapshotDelete(snap, 0); err != nil {
+		return fmt.Errorf("delete snapshot %q of vm %q: %w", snapName, vmName, err)
+	}
+	return nil
+}
+
 // ----------------------------------------------------------------------------
 // Internal helpers
 // ----------------------------------------------------------------------------
 
+// lookupSnapshot resolves a VM and one of its snapshots by name.
+func (m *LibvirtVMManager) lookupSnapshot(vmName, snapName string) (libvirt.Domain, libvirt.DomainSnapshot, error) {
+	dom, err := m.l.DomainLookupByName(vmName)
+	if err != nil {
+		return libvirt.Domain{}, libvirt.DomainSnapshot{}, fmt.Errorf("vm %q not found: %w", vmName, err)
+	}
+	snap, err := m.l.DomainSnapshotLookupByName(dom, snapName, 0)
+	if err != nil {
+		return libvirt.Domain{}, libvirt.DomainSnapshot{}, fmt.Errorf("snapshot %q of vm %q not found: %w", snapName, vmName, err)
+	}
+	return dom, snap, nil
+}
+
+// currentSnapshot returns the name of the domain's current snapshot, or ""
+// if it has none.
+func (m *LibvirtVMManager) currentSnapshot(dom libvirt.Domain) string {
+	has, err := m.l.DomainHasCurrentSnapshot(dom, 0)
+	if err != nil || has == 0 {
+		return ""
+	}
+	snap, err := m.l.DomainSnapshotCurrent(dom, 0)
+	if err != nil {
+		return ""
+	}
+	return snap.Name
+}
+
+// snapshotDetail builds a SnapshotDetail from a snapshot's XML description.
+func (m *LibvirtVMManager) snapshotDetail(snap libvirt.DomainSnapshot, current string) (*SnapshotDetail, error) {
+	xmlDesc, err := m.l.DomainSnapshotGetXMLDesc(snap, 0)
+	if err != nil {
+		return nil, fmt.Errorf("get snapshot xml desc: %w", err)
+	}
+	detail, err := parseSnapshotXML(xmlDesc)
+	if err != nil {
+		return nil, err
+	}
+	detail.Current = current != "" && detail.Name == current
+	return detail, nil
+}
+
 // domainState retrieves the current VMState for a domain.
 func (m *LibvirtVMManager) domainState(dom libvirt.Domain) (VMState, error) {
 	state, _, err := m.l.DomainGetState(dom, 0)
@@ -407,6 +921,16 @@ func (m *LibvirtVMManager) domainState(dom libvirt.Domain) (VMState, error) {
 	return libvirtStateToVMState(libvirt.DomainState(state)), nil
 }
 
+// domainAutostart reports whether libvirt starts the domain when the host
+// boots.
+func (m *LibvirtVMManager) domainAutostart(dom libvirt.Domain) (bool, error) {
+	autostart, err := m.l.DomainGetAutostart(dom)
+	if err != nil {
+		return false, fmt.Errorf("get autostart: %w", err)
+	}
+	return autostart != 0, nil
+}
+
 // domainToVM builds a VM summary from a libvirt Domain.
 func (m *LibvirtVMManager) domainToVM(dom libvirt.Domain) (VM, error) {
 	state, err := m.domainState(dom)
@@ -426,12 +950,18 @@ func (m *LibvirtVMManager) domainToVM(dom libvirt.Domain) (VM, error) {
 
 	memory := normalizeMemoryKB(d.Memory)
 
+	autostart, err := m.domainAutostart(dom)
+	if err != nil {
+		return VM{}, err
+	}
+
 	return VM{
-		Name:   dom.Name,
-		UUID:   formatUUID(dom.UUID),
-		State:  state,
-		Memory: memory,
-		VCPUs:  d.VCPUs,
+		Name:      dom.Name,
+		UUID:      formatUUID(dom.UUID),
+		State:     state,
+		Memory:    memory,
+		VCPUs:     d.VCPUs,
+		Autostart: autostart,
 	}, nil
 }
 
@@ -454,31 +984,40 @@ func (m *LibvirtVMManager) domainToVMDetail(dom libvirt.Domain) (*VMDetail, erro
 
 	memory := normalizeMemoryKB(d.Memory)
 
+	autostart, err := m.domainAutostart(dom)
+	if err != nil {
+		return nil, err
+	}
+
 	detail := &VMDetail{
 		VM: VM{
-			Name:   dom.Name,
-			UUID:   formatUUID(dom.UUID),
-			State:  state,
-			Memory: memory,
-			VCPUs:  d.VCPUs,
+			Name:      dom.Name,
+			UUID:      formatUUID(dom.UUID),
+			State:     state,
+			Memory:    memory,
+			VCPUs:     d.VCPUs,
+			Autostart: autostart,
 		},
 		XMLConfig: xmlDesc,
 	}
 
-	// Populate disks (only "disk" devices, not cdroms).
+	// Populate disks and cdrom drives; floppies and LUNs are left out.
 	for _, disk := range d.Devices.Disks {
-		if disk.Device != "disk" && disk.Device != "" {
-			continue
-		}
 		src := disk.Source.File
 		if src == "" {
 			src = disk.Source.Dev
 		}
-		detail.Disks = append(detail.Disks, VMDisk{
+		vd := VMDisk{
 			Source: src,
 			Target: disk.Target.Dev,
 			Type:   disk.Type,
-		})
+		}
+		switch disk.Device {
+		case "disk", "":
+			detail.Disks = append(detail.Disks, vd)
+		case "cdrom":
+			detail.CDROMs = append(detail.CDROMs, vd)
+		}
 	}
 
 	// Populate NICs.
@@ -79,22 +79,92 @@ func (m *LibvirtVMManager) RestartVM(_ context.Context, name string) error {
 	return ErrLibvirtNotCompiled
 }
 
+// SetAutostart always returns an error in stub mode.
+func (m *LibvirtVMManager) SetAutostart(_ context.Context, name string, enabled bool) error {
+	return ErrLibvirtNotCompiled
+}
+
+// GetVMStats always returns an error in stub mode.
+func (m *LibvirtVMManager) GetVMStats(_ context.Context, name string) (*VMStats, error) {
+	return nil, ErrLibvirtNotCompiled
+}
+
 // CreateVM always returns an error in stub mode.
 func (m *LibvirtVMManager) CreateVM(_ context.Context, xmlConfig string) error {
 	return ErrLibvirtNotCompiled
 }
 
+// CreateDisk always returns an error in stub mode.
+func (m *LibvirtVMManager) CreateDisk(_ context.Context, path, format string, size uint64) error {
+	return ErrLibvirtNotCompiled
+}
+
+// DeleteDisk always returns an error in stub mode.
+func (m *LibvirtVMManager) DeleteDisk(_ context.Context, path string) error {
+	return ErrLibvirtNotCompiled
+}
+
 // DeleteVM always returns an error in stub mode.
 func (m *LibvirtVMManager) DeleteVM(_ context.Context, name string) error {
 	return ErrLibvirtNotCompiled
 }
 
+// SetVMMemory always returns an error in stub mode.
+func (m *LibvirtVMManager) SetVMMemory(_ context.Context, name string, memory uint64, mode VMChangeMode) (*VMChangeResult, error) {
+	return nil, ErrLibvirtNotCompiled
+}
+
+// SetVMVCPUs always returns an error in stub mode.
+func (m *LibvirtVMManager) SetVMVCPUs(_ context.Context, name string, vcpus, threads int, mode VMChangeMode) (*VMChangeResult, error) {
+	return nil, ErrLibvirtNotCompiled
+}
+
+// AttachDisk always returns an error in stub mode.
+func (m *LibvirtVMManager) AttachDisk(_ context.Context, name string, disk VMDiskSpec, mode VMChangeMode) (*VMChangeResult, error) {
+	return nil, ErrLibvirtNotCompiled
+}
+
+// DetachDisk always returns an error in stub mode.
+func (m *LibvirtVMManager) DetachDisk(_ context.Context, name, disk string, mode VMChangeMode) (*VMChangeResult, error) {
+	return nil, ErrLibvirtNotCompiled
+}
+
+// AttachNIC always returns an error in stub mode.
+func (m *LibvirtVMManager) AttachNIC(_ context.Context, name string, nic VMNICSpec, mode VMChangeMode) (*VMChangeResult, error) {
+	return nil, ErrLibvirtNotCompiled
+}
+
+// DetachNIC always returns an error in stub mode.
+func (m *LibvirtVMManager) DetachNIC(_ context.Context, name, mac string, mode VMChangeMode) (*VMChangeResult, error) {
+	return nil, ErrLibvirtNotCompiled
+}
+
+// ChangeMedia always returns an error in stub mode.
+func (m *LibvirtVMManager) ChangeMedia(_ context.Context, name, drive, iso string, mode VMChangeMode) (*VMChangeResult, error) {
+	return nil, ErrLibvirtNotCompiled
+}
+
 // ListSnapshots always returns an error in stub mode.
 func (m *LibvirtVMManager) ListSnapshots(_ context.Context, vmName string) ([]Snapshot, error) {
 	return nil, ErrLibvirtNotCompiled
 }
 
+// InspectSnapshot always returns an error in stub mode.
+func (m *LibvirtVMManager) InspectSnapshot(_ context.Context, vmName, snapName string) (*SnapshotDetail, error) {
+	return nil, ErrLibvirtNotCompiled
+}
+
 // CreateSnapshot always returns an error in stub mode.
-func (m *LibvirtVMManager) CreateSnapshot(_ context.Context, vmName, snapName string) error {
+func (m *LibvirtVMManager) CreateSnapshot(_ context.Context, vmName, snapName string, opts SnapshotOptions) error {
+	return ErrLibvirtNotCompiled
+}
+
+// RevertSnapshot always returns an error in stub mode.
+func (m *LibvirtVMManager) RevertSnapshot(_ context.Context, vmName, snapName string) error {
+	return ErrLibvirtNotCompiled
+}
+
+// DeleteSnapshot always returns an error in stub mode.
+func (m *LibvirtVMManager) DeleteSnapshot(_ context.Context, vmName, snapName string) error {
 	return ErrLibvirtNotCompiled
 }
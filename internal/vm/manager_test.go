@@ -3,6 +3,7 @@ package vm
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
 	"testing"
@@ -17,6 +18,7 @@ import (
 type mockVM struct {
 	detail    VMDetail
 	snapshots []Snapshot
+	stats     VMStats
 }
 
 // MockVMManager implements VMManager using in-memory state.
@@ -25,8 +27,9 @@ type mockVM struct {
 //   - State-transition guards (e.g. cannot start a running VM)
 //   - Context cancellation checks
 type MockVMManager struct {
-	mu  sync.Mutex
-	vms map[string]*mockVM
+	mu    sync.Mutex
+	vms   map[string]*mockVM
+	disks map[string]uint64 // size of each image created by CreateDisk
 }
 
 // NewMockVMManager creates a MockVMManager pre-loaded with the supplied VMs.
@@ -168,6 +171,46 @@ func (m *MockVMManager) RestartVM(ctx context.Context, name string) error {
 	return nil
 }
 
+func (m *MockVMManager) SetAutostart(ctx context.Context, name string, enabled bool) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("set autostart: %w", err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	v, ok := m.vms[name]
+	if !ok {
+		return fmt.Errorf("vm %q not found", name)
+	}
+	v.detail.Autostart = enabled
+	return nil
+}
+
+func (m *MockVMManager) GetVMStats(ctx context.Context, name string) (*VMStats, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("get vm stats: %w", err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	v, ok := m.vms[name]
+	if !ok {
+		return nil, fmt.Errorf("vm %q not found", name)
+	}
+	stats := v.stats
+	stats.Name, stats.State, stats.VCPUs = name, v.detail.State, v.detail.VCPUs
+	return &stats, nil
+}
+
+// SetVMStats sets the counters GetVMStats reports for a VM.
+func (m *MockVMManager) SetVMStats(name string, stats VMStats) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if v, ok := m.vms[name]; ok {
+		v.stats = stats
+	}
+}
+
 func (m *MockVMManager) CreateVM(ctx context.Context, xmlConfig string) error {
 	if err := ctx.Err(); err != nil {
 		return fmt.Errorf("create vm: %w", err)
@@ -191,6 +234,165 @@ func (m *MockVMManager) CreateVM(ctx context.Context, xmlConfig string) error {
 	return nil
 }
 
+func (m *MockVMManager) CreateDisk(ctx context.Context, path, format string, size uint64) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("create disk: %w", err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.disks[path]; ok {
+		return fmt.Errorf("create disk %q: already exists", path)
+	}
+	if m.disks == nil {
+		m.disks = make(map[string]uint64)
+	}
+	m.disks[path] = size
+	return nil
+}
+
+func (m *MockVMManager) DeleteDisk(ctx context.Context, path string) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("delete disk: %w", err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.disks[path]; !ok {
+		return fmt.Errorf("delete disk %q: not found", path)
+	}
+	delete(m.disks, path)
+	return nil
+}
+
+// change applies edit to the named VM as a configuration change in the given
+// mode. The mock keeps a single configuration, which live and persistent
+// changes both edit; the diff is of a simple rendering of it.
+func (m *MockVMManager) change(ctx context.Context, op, name string, mode VMChangeMode, edit func(*VMDetail) error) (*VMChangeResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	v, ok := m.vms[name]
+	if !ok {
+		return nil, fmt.Errorf("vm %q not found", name)
+	}
+	running := v.detail.State == VMStateRunning || v.detail.State == VMStatePaused
+	if mode == VMChangeLive && !running {
+		return nil, fmt.Errorf("%s %q: vm is not running", op, name)
+	}
+
+	d := v.detail
+	d.Disks = slices.Clone(d.Disks)
+	d.CDROMs = slices.Clone(d.CDROMs)
+	d.NICs = slices.Clone(d.NICs)
+	if err := edit(&d); err != nil {
+		return nil, fmt.Errorf("%s %q: %w", op, name, err)
+	}
+	before := mockDescribe(v.detail)
+	v.detail = d
+	return &VMChangeResult{
+		Live:       running && mode != VMChangePersistent,
+		Persistent: mode != VMChangeLive,
+		Diff:       xmlDiff(before, mockDescribe(d)),
+	}, nil
+}
+
+// mockDescribe renders the parts of a VM that configuration changes edit,
+// one line per setting or device.
+func mockDescribe(d VMDetail) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<memory>%d</memory>\n<vcpu>%d</vcpu>\n", d.Memory, d.VCPUs)
+	for _, disk := range d.Disks {
+		fmt.Fprintf(&b, "<disk target=%q source=%q/>\n", disk.Target, disk.Source)
+	}
+	for _, cd := range d.CDROMs {
+		fmt.Fprintf(&b, "<cdrom target=%q source=%q/>\n", cd.Target, cd.Source)
+	}
+	for _, nic := range d.NICs {
+		fmt.Fprintf(&b, "<interface mac=%q bridge=%q model=%q/>\n", nic.MAC, nic.Network, nic.Model)
+	}
+	return b.String()
+}
+
+func (m *MockVMManager) SetVMMemory(ctx context.Context, name string, memory uint64, mode VMChangeMode) (*VMChangeResult, error) {
+	return m.change(ctx, "set memory", name, mode, func(d *VMDetail) error {
+		if memory < minVMMemory {
+			return fmt.Errorf("%d KB is below the minimum of %d KB", memory, minVMMemory)
+		}
+		d.Memory = memory
+		return nil
+	})
+}
+
+func (m *MockVMManager) SetVMVCPUs(ctx context.Context, name string, vcpus, threads int, mode VMChangeMode) (*VMChangeResult, error) {
+	return m.change(ctx, "set vcpus", name, mode, func(d *VMDetail) error {
+		if vcpus < 1 || (threads > 0 && vcpus%threads != 0) {
+			return fmt.Errorf("invalid cpu topology: %d vCPUs with %d threads", vcpus, threads)
+		}
+		d.VCPUs = vcpus
+		return nil
+	})
+}
+
+func (m *MockVMManager) AttachDisk(ctx context.Context, name string, disk VMDiskSpec, mode VMChangeMode) (*VMChangeResult, error) {
+	return m.change(ctx, "attach disk", name, mode, func(d *VMDetail) error {
+		used := make(map[string]bool)
+		for _, x := range append(slices.Clone(d.Disks), d.CDROMs...) {
+			used[x.Target] = true
+		}
+		for c := 'c'; c <= 'z'; c++ {
+			if target := "hd" + string(c); !used[target] {
+				d.Disks = append(d.Disks, VMDisk{Source: disk.Path, Target: target, Type: "file"})
+				return nil
+			}
+		}
+		return fmt.Errorf("no free disk target left")
+	})
+}
+
+func (m *MockVMManager) DetachDisk(ctx context.Context, name, disk string, mode VMChangeMode) (*VMChangeResult, error) {
+	return m.change(ctx, "detach disk", name, mode, func(d *VMDetail) error {
+		i := slices.IndexFunc(d.Disks, func(x VMDisk) bool { return x.Target == disk || x.Source == disk })
+		if i < 0 {
+			return fmt.Errorf("no disk %q", disk)
+		}
+		d.Disks = slices.Delete(d.Disks, i, i+1)
+		return nil
+	})
+}
+
+func (m *MockVMManager) AttachNIC(ctx context.Context, name string, nic VMNICSpec, mode VMChangeMode) (*VMChangeResult, error) {
+	return m.change(ctx, "attach nic", name, mode, func(d *VMDetail) error {
+		d.NICs = append(d.NICs, VMNIC{MAC: nic.MAC, Network: nic.Bridge, Model: nic.Model})
+		return nil
+	})
+}
+
+func (m *MockVMManager) DetachNIC(ctx context.Context, name, mac string, mode VMChangeMode) (*VMChangeResult, error) {
+	return m.change(ctx, "detach nic", name, mode, func(d *VMDetail) error {
+		i := slices.IndexFunc(d.NICs, func(x VMNIC) bool { return strings.EqualFold(x.MAC, mac) })
+		if i < 0 {
+			return fmt.Errorf("no interface with mac address %q", mac)
+		}
+		d.NICs = slices.Delete(d.NICs, i, i+1)
+		return nil
+	})
+}
+
+func (m *MockVMManager) ChangeMedia(ctx context.Context, name, drive, iso string, mode VMChangeMode) (*VMChangeResult, error) {
+	return m.change(ctx, "change media", name, mode, func(d *VMDetail) error {
+		i := slices.IndexFunc(d.CDROMs, func(x VMDisk) bool { return drive == "" || x.Target == drive })
+		if i < 0 {
+			return fmt.Errorf("no cdrom drive %q", drive)
+		}
+		d.CDROMs[i].Source = iso
+		return nil
+	})
+}
+
 func (m *MockVMManager) DeleteVM(ctx context.Context, name string) error {
 	if err := ctx.Err(); err != nil {
 		return fmt.Errorf("delete vm: %w", err)
@@ -221,10 +423,31 @@ func (m *MockVMManager) ListSnapshots(ctx context.Context, vmName string) ([]Sna
 	return out, nil
 }
 
-func (m *MockVMManager) CreateSnapshot(ctx context.Context, vmName, snapName string) error {
+func (m *MockVMManager) InspectSnapshot(ctx context.Context, vmName, snapName string) (*SnapshotDetail, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("inspect snapshot: %w", err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	v, ok := m.vms[vmName]
+	if !ok {
+		return nil, fmt.Errorf("vm %q not found", vmName)
+	}
+	i := v.snapshotIndex(snapName)
+	if i < 0 {
+		return nil, fmt.Errorf("snapshot %q of vm %q not found", snapName, vmName)
+	}
+	return &SnapshotDetail{Snapshot: v.snapshots[i]}, nil
+}
+
+func (m *MockVMManager) CreateSnapshot(ctx context.Context, vmName, snapName string, opts SnapshotOptions) error {
 	if err := ctx.Err(); err != nil {
 		return fmt.Errorf("create snapshot: %w", err)
 	}
+	if _, err := snapshotCreateXML(snapName, opts); err != nil {
+		return fmt.Errorf("create snapshot: %w", err)
+	}
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -232,14 +455,95 @@ func (m *MockVMManager) CreateSnapshot(ctx context.Context, vmName, snapName str
 	if !ok {
 		return fmt.Errorf("vm %q not found", vmName)
 	}
-	v.snapshots = append(v.snapshots, Snapshot{
-		Name:      snapName,
-		CreatedAt: time.Now(),
-		State:     string(v.detail.State),
-	})
+	if v.snapshotIndex(snapName) >= 0 {
+		return fmt.Errorf("snapshot %q of vm %q already exists", snapName, vmName)
+	}
+	snap := Snapshot{
+		Name:        snapName,
+		Description: opts.Description,
+		CreatedAt:   time.Now(),
+		State:       string(v.detail.State),
+		DiskOnly:    opts.DiskOnly,
+		Memory:      !opts.DiskOnly && v.detail.State == VMStateRunning,
+		Current:     true,
+	}
+	if opts.DiskOnly {
+		snap.State = snapshotStateDisk
+	}
+	for i := range v.snapshots {
+		if v.snapshots[i].Current {
+			snap.Parent = v.snapshots[i].Name
+			v.snapshots[i].Current = false
+		}
+	}
+	v.snapshots = append(v.snapshots, snap)
+	return nil
+}
+
+func (m *MockVMManager) RevertSnapshot(ctx context.Context, vmName, snapName string) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("revert snapshot: %w", err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	v, ok := m.vms[vmName]
+	if !ok {
+		return fmt.Errorf("vm %q not found", vmName)
+	}
+	i := v.snapshotIndex(snapName)
+	if i < 0 {
+		return fmt.Errorf("snapshot %q of vm %q not found", snapName, vmName)
+	}
+	for j := range v.snapshots {
+		v.snapshots[j].Current = j == i
+	}
+	v.detail.State = VMStateShutoff
+	if v.snapshots[i].Memory {
+		v.detail.State = VMStateRunning
+	}
 	return nil
 }
 
+func (m *MockVMManager) DeleteSnapshot(ctx context.Context, vmName, snapName string) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("delete snapshot: %w", err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	v, ok := m.vms[vmName]
+	if !ok {
+		return fmt.Errorf("vm %q not found", vmName)
+	}
+	i := v.snapshotIndex(snapName)
+	if i < 0 {
+		return fmt.Errorf("snapshot %q of vm %q not found", snapName, vmName)
+	}
+	// Like libvirt, children move up to the deleted snapshot's parent.
+	deleted := v.snapshots[i]
+	v.snapshots = append(v.snapshots[:i], v.snapshots[i+1:]...)
+	for j := range v.snapshots {
+		if v.snapshots[j].Parent == deleted.Name {
+			v.snapshots[j].Parent = deleted.Parent
+		}
+		if deleted.Current && v.snapshots[j].Name == deleted.Parent {
+			v.snapshots[j].Current = true
+		}
+	}
+	return nil
+}
+
+// snapshotIndex returns the index of the named snapshot, or -1.
+func (v *mockVM) snapshotIndex(name string) int {
+	for i, s := range v.snapshots {
+		if s.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
 // ---------------------------------------------------------------------------
 // Helpers
 // ---------------------------------------------------------------------------
@@ -949,6 +1253,51 @@ func Test_RestartVM_CancelledContext(t *testing.T) {
 	}
 }
 
+// ---------------------------------------------------------------------------
+// SetAutostart
+// ---------------------------------------------------------------------------
+
+func Test_SetAutostart_Cases(t *testing.T) {
+	tests := []struct {
+		name        string
+		vmName      string
+		enabled     bool
+		wantErr     bool
+		errContains string
+	}{
+		{name: "disable autostart", vmName: "win10", enabled: false},
+		{name: "enable autostart", vmName: "ubuntu", enabled: true},
+		{name: "nonexistent VM returns not found", vmName: "nonexistent", enabled: true, wantErr: true, errContains: "not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mgr := newSeededMock(t)
+			err := mgr.SetAutostart(context.Background(), tt.vmName, tt.enabled)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				if !strings.Contains(strings.ToLower(err.Error()), tt.errContains) {
+					t.Errorf("error = %q, want it to contain %q", err.Error(), tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			detail, err := mgr.InspectVM(context.Background(), tt.vmName)
+			if err != nil {
+				t.Fatalf("InspectVM after SetAutostart: %v", err)
+			}
+			if detail.Autostart != tt.enabled {
+				t.Errorf("Autostart = %v, want %v", detail.Autostart, tt.enabled)
+			}
+		})
+	}
+}
+
 // ---------------------------------------------------------------------------
 // CreateVM
 // ---------------------------------------------------------------------------
@@ -1127,10 +1476,10 @@ func Test_ListSnapshots_Cases(t *testing.T) {
 			vmName: "win10",
 			setup: func(t *testing.T, mgr *MockVMManager) {
 				t.Helper()
-				if err := mgr.CreateSnapshot(context.Background(), "win10", "snap1"); err != nil {
+				if err := mgr.CreateSnapshot(context.Background(), "win10", "snap1", SnapshotOptions{}); err != nil {
 					t.Fatalf("setup: CreateSnapshot snap1: %v", err)
 				}
-				if err := mgr.CreateSnapshot(context.Background(), "win10", "snap2"); err != nil {
+				if err := mgr.CreateSnapshot(context.Background(), "win10", "snap2", SnapshotOptions{}); err != nil {
 					t.Fatalf("setup: CreateSnapshot snap2: %v", err)
 				}
 			},
@@ -1218,7 +1567,7 @@ func Test_CreateSnapshot_Cases(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mgr := newSeededMock(t)
-			err := mgr.CreateSnapshot(context.Background(), tt.vmName, tt.snapName)
+			err := mgr.CreateSnapshot(context.Background(), tt.vmName, tt.snapName, SnapshotOptions{})
 
 			if tt.wantErr {
 				if err == nil {
@@ -1260,12 +1609,176 @@ func Test_CreateSnapshot_CancelledContext(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
 
-	err := mgr.CreateSnapshot(ctx, "win10", "snap1")
+	err := mgr.CreateSnapshot(ctx, "win10", "snap1", SnapshotOptions{})
 	if err == nil {
 		t.Fatal("expected error for cancelled context, got nil")
 	}
 }
 
+// ---------------------------------------------------------------------------
+// InspectSnapshot / RevertSnapshot / DeleteSnapshot
+// ---------------------------------------------------------------------------
+
+func Test_Snapshot_LifecycleMetadata(t *testing.T) {
+	mgr := newSeededMock(t)
+	ctx := context.Background()
+
+	if err := mgr.CreateSnapshot(ctx, "win10", "base", SnapshotOptions{Description: "clean install"}); err != nil {
+		t.Fatalf("CreateSnapshot base: %v", err)
+	}
+	if err := mgr.CreateSnapshot(ctx, "win10", "disks", SnapshotOptions{DiskOnly: true, Quiesce: true}); err != nil {
+		t.Fatalf("CreateSnapshot disks: %v", err)
+	}
+
+	base, err := mgr.InspectSnapshot(ctx, "win10", "base")
+	if err != nil {
+		t.Fatalf("InspectSnapshot base: %v", err)
+	}
+	if base.Description != "clean install" || !base.Memory || base.DiskOnly || base.Current {
+		t.Errorf("base = %+v, want described full snapshot that is no longer current", base.Snapshot)
+	}
+
+	disks, err := mgr.InspectSnapshot(ctx, "win10", "disks")
+	if err != nil {
+		t.Fatalf("InspectSnapshot disks: %v", err)
+	}
+	if !disks.DiskOnly || disks.Memory || disks.Parent != "base" || !disks.Current {
+		t.Errorf("disks = %+v, want current disk-only child of base", disks.Snapshot)
+	}
+}
+
+func Test_SnapshotOperations_Cases(t *testing.T) {
+	tests := []struct {
+		name        string
+		call        func(mgr *MockVMManager) error
+		errContains string
+	}{
+		{
+			name: "inspect on nonexistent VM",
+			call: func(mgr *MockVMManager) error {
+				_, err := mgr.InspectSnapshot(context.Background(), "nonexistent", "s")
+				return err
+			},
+			errContains: "not found",
+		},
+		{
+			name: "inspect nonexistent snapshot",
+			call: func(mgr *MockVMManager) error {
+				_, err := mgr.InspectSnapshot(context.Background(), "win10", "missing")
+				return err
+			},
+			errContains: "not found",
+		},
+		{
+			name:        "revert to nonexistent snapshot",
+			call:        func(mgr *MockVMManager) error { return mgr.RevertSnapshot(context.Background(), "win10", "missing") },
+			errContains: "not found",
+		},
+		{
+			name:        "delete nonexistent snapshot",
+			call:        func(mgr *MockVMManager) error { return mgr.DeleteSnapshot(context.Background(), "win10", "missing") },
+			errContains: "not found",
+		},
+		{
+			name: "create duplicate snapshot",
+			call: func(mgr *MockVMManager) error {
+				if err := mgr.CreateSnapshot(context.Background(), "win10", "dup", SnapshotOptions{}); err != nil {
+					return err
+				}
+				return mgr.CreateSnapshot(context.Background(), "win10", "dup", SnapshotOptions{})
+			},
+			errContains: "already exists",
+		},
+		{
+			name: "create quiesced snapshot that is not disk-only",
+			call: func(mgr *MockVMManager) error {
+				return mgr.CreateSnapshot(context.Background(), "win10", "q", SnapshotOptions{Quiesce: true})
+			},
+			errContains: "disk-only",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call(newSeededMock(t))
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if !strings.Contains(strings.ToLower(err.Error()), tt.errContains) {
+				t.Errorf("error = %q, want it to contain %q", err.Error(), tt.errContains)
+			}
+		})
+	}
+}
+
+func Test_RevertSnapshot_RestoresState(t *testing.T) {
+	mgr := newSeededMock(t)
+	ctx := context.Background()
+
+	// win10 is running, so "live" holds its memory.
+	if err := mgr.CreateSnapshot(ctx, "win10", "live", SnapshotOptions{}); err != nil {
+		t.Fatalf("CreateSnapshot: %v", err)
+	}
+	if err := mgr.CreateSnapshot(ctx, "win10", "later", SnapshotOptions{}); err != nil {
+		t.Fatalf("CreateSnapshot: %v", err)
+	}
+	if err := mgr.ForceStopVM(ctx, "win10"); err != nil {
+		t.Fatalf("ForceStopVM: %v", err)
+	}
+
+	if err := mgr.RevertSnapshot(ctx, "win10", "live"); err != nil {
+		t.Fatalf("RevertSnapshot: %v", err)
+	}
+	detail, err := mgr.InspectVM(ctx, "win10")
+	if err != nil {
+		t.Fatalf("InspectVM: %v", err)
+	}
+	if detail.State != VMStateRunning {
+		t.Errorf("state after revert = %q, want %q", detail.State, VMStateRunning)
+	}
+	snaps, err := mgr.ListSnapshots(ctx, "win10")
+	if err != nil {
+		t.Fatalf("ListSnapshots: %v", err)
+	}
+	for _, s := range snaps {
+		if s.Current != (s.Name == "live") {
+			t.Errorf("snapshot %q Current = %v after reverting to \"live\"", s.Name, s.Current)
+		}
+	}
+}
+
+func Test_DeleteSnapshot_ReparentsChildren(t *testing.T) {
+	mgr := newSeededMock(t)
+	ctx := context.Background()
+
+	for _, name := range []string{"a", "b", "c"} {
+		if err := mgr.CreateSnapshot(ctx, "win10", name, SnapshotOptions{}); err != nil {
+			t.Fatalf("CreateSnapshot %s: %v", name, err)
+		}
+	}
+	if err := mgr.DeleteSnapshot(ctx, "win10", "b"); err != nil {
+		t.Fatalf("DeleteSnapshot: %v", err)
+	}
+
+	c, err := mgr.InspectSnapshot(ctx, "win10", "c")
+	if err != nil {
+		t.Fatalf("InspectSnapshot: %v", err)
+	}
+	if c.Parent != "a" {
+		t.Errorf("c.Parent = %q, want %q", c.Parent, "a")
+	}
+	if _, err := mgr.InspectSnapshot(ctx, "win10", "b"); err == nil {
+		t.Error("deleted snapshot b is still inspectable")
+	}
+	snaps, err := mgr.ListSnapshots(ctx, "win10")
+	if err != nil {
+		t.Fatalf("ListSnapshots: %v", err)
+	}
+	if len(snaps) != 2 {
+		t.Errorf("snapshot count = %d, want 2", len(snaps))
+	}
+}
+
 // ---------------------------------------------------------------------------
 // VMState constants
 // ---------------------------------------------------------------------------
@@ -1486,10 +1999,10 @@ func Test_Snapshot_CreateThenList(t *testing.T) {
 	}
 
 	// Create two snapshots
-	if err := mgr.CreateSnapshot(ctx, "win10", "before-update"); err != nil {
+	if err := mgr.CreateSnapshot(ctx, "win10", "before-update", SnapshotOptions{}); err != nil {
 		t.Fatalf("CreateSnapshot 1: %v", err)
 	}
-	if err := mgr.CreateSnapshot(ctx, "win10", "after-update"); err != nil {
+	if err := mgr.CreateSnapshot(ctx, "win10", "after-update", SnapshotOptions{}); err != nil {
 		t.Fatalf("CreateSnapshot 2: %v", err)
 	}
 
@@ -1567,7 +2080,7 @@ func Test_ConcurrentAccess_NoDataRace(t *testing.T) {
 		i := i
 		go func() {
 			defer wg.Done()
-			_ = mgr.CreateSnapshot(ctx, "win10", fmt.Sprintf("snap-%d", i))
+			_ = mgr.CreateSnapshot(ctx, "win10", fmt.Sprintf("snap-%d", i), SnapshotOptions{})
 		}()
 	}
 
@@ -1604,10 +2117,24 @@ func Test_ContextDeadlineExceeded(t *testing.T) {
 		{"PauseVM", func() error { return mgr.PauseVM(ctx, "win10") }},
 		{"ResumeVM", func() error { return mgr.ResumeVM(ctx, "paused-vm") }},
 		{"RestartVM", func() error { return mgr.RestartVM(ctx, "win10") }},
+		{"SetAutostart", func() error { return mgr.SetAutostart(ctx, "win10", false) }},
+		{"GetVMStats", func() error { _, err := mgr.GetVMStats(ctx, "win10"); return err }},
 		{"CreateVM", func() error { return mgr.CreateVM(ctx, "<domain/>") }},
+		{"CreateDisk", func() error { return mgr.CreateDisk(ctx, "/mnt/user/domains/x/vdisk1.img", "raw", 1<<30) }},
+		{"DeleteDisk", func() error { return mgr.DeleteDisk(ctx, "/mnt/user/domains/x/vdisk1.img") }},
+		{"SetVMMemory", func() error { _, err := mgr.SetVMMemory(ctx, "win10", 4<<20, VMChangeBoth); return err }},
+		{"SetVMVCPUs", func() error { _, err := mgr.SetVMVCPUs(ctx, "win10", 4, 0, VMChangeBoth); return err }},
+		{"AttachDisk", func() error { _, err := mgr.AttachDisk(ctx, "win10", VMDiskSpec{}, VMChangeBoth); return err }},
+		{"DetachDisk", func() error { _, err := mgr.DetachDisk(ctx, "win10", "hdc", VMChangeBoth); return err }},
+		{"AttachNIC", func() error { _, err := mgr.AttachNIC(ctx, "win10", VMNICSpec{}, VMChangeBoth); return err }},
+		{"DetachNIC", func() error { _, err := mgr.DetachNIC(ctx, "win10", "", VMChangeBoth); return err }},
+		{"ChangeMedia", func() error { _, err := mgr.ChangeMedia(ctx, "win10", "", "", VMChangeBoth); return err }},
 		{"DeleteVM", func() error { return mgr.DeleteVM(ctx, "win10") }},
 		{"ListSnapshots", func() error { _, err := mgr.ListSnapshots(ctx, "win10"); return err }},
-		{"CreateSnapshot", func() error { return mgr.CreateSnapshot(ctx, "win10", "s") }},
+		{"InspectSnapshot", func() error { _, err := mgr.InspectSnapshot(ctx, "win10", "s"); return err }},
+		{"CreateSnapshot", func() error { return mgr.CreateSnapshot(ctx, "win10", "s", SnapshotOptions{}) }},
+		{"RevertSnapshot", func() error { return mgr.RevertSnapshot(ctx, "win10", "s") }},
+		{"DeleteSnapshot", func() error { return mgr.DeleteSnapshot(ctx, "win10", "s") }},
 	}
 
 	for _, m := range methods {
@@ -0,0 +1,128 @@
+// Package vm provides virtual machine management for Unraid systems via libvirt.
+package vm
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ----------------------------------------------------------------------------
+// Snapshot XML
+// ----------------------------------------------------------------------------
+
+// snapshotStateDisk is the state libvirt records for a disk-only snapshot.
+const snapshotStateDisk = "disk-snapshot"
+
+// snapshotXML is the subset of a libvirt domainsnapshot document that we read
+// and write. Empty fields are omitted so that libvirt fills in its defaults.
+type snapshotXML struct {
+	XMLName      xml.Name           `xml:"domainsnapshot"`
+	Name         string             `xml:"name"`
+	Description  string             `xml:"description,omitempty"`
+	State        string             `xml:"state,omitempty"`
+	CreationTime string             `xml:"creationTime,omitempty"` // seconds since the epoch
+	Parent       *snapshotParentXML `xml:"parent,omitempty"`
+	Memory       *snapshotMemoryXML `xml:"memory,omitempty"`
+	Disks        *snapshotDisksXML  `xml:"disks,omitempty"`
+}
+
+type snapshotParentXML struct {
+	Name string `xml:"name"`
+}
+
+type snapshotMemoryXML struct {
+	Snapshot string `xml:"snapshot,attr"` // "no", "internal" or "external"
+}
+
+type snapshotDisksXML struct {
+	Disks []snapshotDiskXML `xml:"disk"`
+}
+
+type snapshotDiskXML struct {
+	Name     string              `xml:"name,attr"`
+	Snapshot string              `xml:"snapshot,attr,omitempty"` // "no", "internal" or "external"
+	Source   *snapshotDiskSrcXML `xml:"source,omitempty"`
+}
+
+type snapshotDiskSrcXML struct {
+	File string `xml:"file,attr,omitempty"`
+	Dev  string `xml:"dev,attr,omitempty"`
+}
+
+// snapshotCreateXML returns the domainsnapshot document that creates a
+// snapshot named name. The name and description are escaped, so any text is
+// safe to pass.
+func snapshotCreateXML(name string, opts SnapshotOptions) (string, error) {
+	if strings.TrimSpace(name) == "" {
+		return "", fmt.Errorf("snapshot name must not be empty")
+	}
+	if strings.Contains(name, "/") {
+		return "", fmt.Errorf("snapshot name %q must not contain '/'", name)
+	}
+	if opts.Quiesce && !opts.DiskOnly {
+		return "", fmt.Errorf("quiesce requires a disk-only snapshot")
+	}
+
+	doc := snapshotXML{Name: name, Description: opts.Description}
+	if opts.DiskOnly {
+		// Without a memory image the guest's RAM is not saved; libvirt picks
+		// external overlay files for every disk.
+		doc.Memory = &snapshotMemoryXML{Snapshot: "no"}
+	}
+	out, err := xml.Marshal(doc)
+	if err != nil {
+		return "", fmt.Errorf("encode snapshot xml: %w", err)
+	}
+	return string(out), nil
+}
+
+// parseSnapshotXML reads the metadata of a snapshot from the document
+// returned by libvirt's virDomainSnapshotGetXMLDesc.
+func parseSnapshotXML(doc string) (*SnapshotDetail, error) {
+	var s snapshotXML
+	if err := xml.Unmarshal([]byte(doc), &s); err != nil {
+		return nil, fmt.Errorf("parse snapshot xml: %w", err)
+	}
+
+	detail := &SnapshotDetail{
+		Snapshot: Snapshot{
+			Name:        s.Name,
+			Description: strings.TrimSpace(s.Description),
+			State:       s.State,
+			DiskOnly:    s.State == snapshotStateDisk,
+		},
+		XMLConfig: doc,
+	}
+	if secs, err := strconv.ParseInt(strings.TrimSpace(s.CreationTime), 10, 64); err == nil {
+		detail.CreatedAt = time.Unix(secs, 0).UTC()
+	}
+	if s.Parent != nil {
+		detail.Parent = s.Parent.Name
+	}
+	// Older libvirt omits <memory>; a snapshot of a running guest that is not
+	// disk-only then always holds its memory.
+	switch {
+	case s.Memory != nil:
+		detail.Memory = s.Memory.Snapshot != "" && s.Memory.Snapshot != "no"
+	case !detail.DiskOnly:
+		detail.Memory = s.State == string(VMStateRunning) || s.State == string(VMStatePaused)
+	}
+
+	if s.Disks == nil {
+		return detail, nil
+	}
+	for _, d := range s.Disks.Disks {
+		disk := SnapshotDisk{Name: d.Name, Snapshot: d.Snapshot}
+		if d.Source != nil {
+			disk.Source = d.Source.File
+			if disk.Source == "" {
+				disk.Source = d.Source.Dev
+			}
+		}
+		detail.Disks = append(detail.Disks, disk)
+	}
+	return detail, nil
+}
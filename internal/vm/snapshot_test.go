@@ -0,0 +1,214 @@
+package vm
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// snapshotCreateXML
+// ---------------------------------------------------------------------------
+
+func Test_SnapshotCreateXML_Cases(t *testing.T) {
+	tests := []struct {
+		name        string
+		snapName    string
+		opts        SnapshotOptions
+		want        string
+		wantErr     bool
+		errContains string
+	}{
+		{
+			name:     "plain name",
+			snapName: "before-update",
+			want:     "<domainsnapshot><name>before-update</name></domainsnapshot>",
+		},
+		{
+			name:     "markup in name and description is escaped",
+			snapName: "a<evil>&amp;",
+			opts:     SnapshotOptions{Description: "x & </description>"},
+			want:     "<domainsnapshot><name>a&lt;evil&gt;&amp;amp;</name><description>x &amp; &lt;/description&gt;</description></domainsnapshot>",
+		},
+		{
+			name:     "disk-only omits memory",
+			snapName: "disks",
+			opts:     SnapshotOptions{DiskOnly: true, Quiesce: true},
+			want:     `<domainsnapshot><name>disks</name><memory snapshot="no"></memory></domainsnapshot>`,
+		},
+		{
+			name:        "empty name",
+			snapName:    "  ",
+			wantErr:     true,
+			errContains: "must not be empty",
+		},
+		{
+			name:        "slash in name",
+			snapName:    "a/b",
+			wantErr:     true,
+			errContains: "must not contain",
+		},
+		{
+			name:        "quiesce without disk-only",
+			snapName:    "q",
+			opts:        SnapshotOptions{Quiesce: true},
+			wantErr:     true,
+			errContains: "disk-only",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := snapshotCreateXML(tt.snapName, tt.opts)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %q", got)
+				}
+				if !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("error = %q, want it to contain %q", err.Error(), tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("snapshotCreateXML() = %q, want %q", got, tt.want)
+			}
+			// The name must survive a round trip unchanged.
+			var back snapshotXML
+			if err := xml.Unmarshal([]byte(got), &back); err != nil {
+				t.Fatalf("output is not valid XML: %v", err)
+			}
+			if back.Name != tt.snapName {
+				t.Errorf("round-tripped name = %q, want %q", back.Name, tt.snapName)
+			}
+		})
+	}
+}
+
+// ---------------------------------------------------------------------------
+// parseSnapshotXML
+// ---------------------------------------------------------------------------
+
+func Test_ParseSnapshotXML_FullSnapshot(t *testing.T) {
+	doc := `<domainsnapshot>
+  <name>after-update</name>
+  <description>
+    Patched to 22H2
+  </description>
+  <state>running</state>
+  <parent>
+    <name>before-update</name>
+  </parent>
+  <creationTime>1700000000</creationTime>
+  <memory snapshot='internal'/>
+  <disks>
+    <disk name='hdc' snapshot='no'/>
+    <disk name='vda' snapshot='internal'/>
+  </disks>
+  <domain type='kvm'><name>win10</name></domain>
+</domainsnapshot>`
+
+	got, err := parseSnapshotXML(doc)
+	if err != nil {
+		t.Fatalf("parseSnapshotXML: %v", err)
+	}
+	if got.Name != "after-update" {
+		t.Errorf("Name = %q, want %q", got.Name, "after-update")
+	}
+	if got.Description != "Patched to 22H2" {
+		t.Errorf("Description = %q, want %q", got.Description, "Patched to 22H2")
+	}
+	if got.State != "running" {
+		t.Errorf("State = %q, want %q", got.State, "running")
+	}
+	if got.Parent != "before-update" {
+		t.Errorf("Parent = %q, want %q", got.Parent, "before-update")
+	}
+	if want := time.Unix(1700000000, 0).UTC(); !got.CreatedAt.Equal(want) {
+		t.Errorf("CreatedAt = %v, want %v", got.CreatedAt, want)
+	}
+	if got.DiskOnly {
+		t.Error("DiskOnly = true, want false")
+	}
+	if !got.Memory {
+		t.Error("Memory = false, want true")
+	}
+	if len(got.Disks) != 2 || got.Disks[1] != (SnapshotDisk{Name: "vda", Snapshot: "internal"}) {
+		t.Errorf("Disks = %+v, want hdc and internal vda", got.Disks)
+	}
+	if got.XMLConfig != doc {
+		t.Error("XMLConfig does not hold the original document")
+	}
+}
+
+func Test_ParseSnapshotXML_DiskOnly(t *testing.T) {
+	doc := `<domainsnapshot>
+  <name>disks</name>
+  <state>disk-snapshot</state>
+  <creationTime>1700000100</creationTime>
+  <memory snapshot='no'/>
+  <disks>
+    <disk name='vda' snapshot='external' type='file'>
+      <driver type='qcow2'/>
+      <source file='/mnt/user/domains/win10/vdisk1.disks'/>
+    </disk>
+    <disk name='vdb' snapshot='external' type='block'>
+      <source dev='/dev/mapper/overlay'/>
+    </disk>
+  </disks>
+</domainsnapshot>`
+
+	got, err := parseSnapshotXML(doc)
+	if err != nil {
+		t.Fatalf("parseSnapshotXML: %v", err)
+	}
+	if !got.DiskOnly {
+		t.Error("DiskOnly = false, want true")
+	}
+	if got.Memory {
+		t.Error("Memory = true, want false")
+	}
+	if got.Parent != "" {
+		t.Errorf("Parent = %q, want empty", got.Parent)
+	}
+	wantSources := []string{"/mnt/user/domains/win10/vdisk1.disks", "/dev/mapper/overlay"}
+	if len(got.Disks) != len(wantSources) {
+		t.Fatalf("len(Disks) = %d, want %d", len(got.Disks), len(wantSources))
+	}
+	for i, want := range wantSources {
+		if got.Disks[i].Source != want {
+			t.Errorf("Disks[%d].Source = %q, want %q", i, got.Disks[i].Source, want)
+		}
+	}
+}
+
+func Test_ParseSnapshotXML_MissingOptionalFields(t *testing.T) {
+	// Older libvirt omits <memory>; a shutoff snapshot then holds no memory.
+	got, err := parseSnapshotXML(`<domainsnapshot><name>old</name><state>shutoff</state></domainsnapshot>`)
+	if err != nil {
+		t.Fatalf("parseSnapshotXML: %v", err)
+	}
+	if !got.CreatedAt.IsZero() {
+		t.Errorf("CreatedAt = %v, want zero", got.CreatedAt)
+	}
+	if got.Memory {
+		t.Error("Memory = true, want false for a shutoff snapshot")
+	}
+
+	got, err = parseSnapshotXML(`<domainsnapshot><name>old</name><state>running</state></domainsnapshot>`)
+	if err != nil {
+		t.Fatalf("parseSnapshotXML: %v", err)
+	}
+	if !got.Memory {
+		t.Error("Memory = false, want true for a running snapshot without <memory>")
+	}
+}
+
+func Test_ParseSnapshotXML_Invalid(t *testing.T) {
+	if _, err := parseSnapshotXML("<domainsnapshot><name>"); err == nil {
+		t.Fatal("expected error for truncated XML, got nil")
+	}
+}
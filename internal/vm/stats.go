@@ -0,0 +1,367 @@
+// Package vm provides virtual machine management for Unraid systems via libvirt.
+package vm
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jamesprial/unraid-mcp/internal/stats"
+)
+
+// ----------------------------------------------------------------------------
+// Domain stats parsing
+// ----------------------------------------------------------------------------
+
+// vmStatsFromParams builds a VMStats from the typed parameters of a libvirt
+// domain stats record (virConnectGetAllDomainStats), keyed by field name such
+// as "cpu.time" or "block.0.rd.bytes". Memory fields are already in KiB.
+func vmStatsFromParams(name string, params map[string]any) *VMStats {
+	s := &VMStats{
+		Name:            name,
+		VCPUs:           int(paramUint(params, "vcpu.current")),
+		CPUTime:         paramUint(params, "cpu.time"),
+		MemoryMax:       paramUint(params, "balloon.maximum"),
+		MemoryActual:    paramUint(params, "balloon.current"),
+		MemoryUnused:    paramUint(params, "balloon.unused"),
+		MemoryAvailable: paramUint(params, "balloon.available"),
+		MemoryRSS:       paramUint(params, "balloon.rss"),
+	}
+	for i := range int(paramUint(params, "block.count")) {
+		p := "block." + strconv.Itoa(i) + "."
+		s.Disks = append(s.Disks, VMDiskStats{
+			Name:          paramString(params, p+"name"),
+			ReadBytes:     paramUint(params, p+"rd.bytes"),
+			WriteBytes:    paramUint(params, p+"wr.bytes"),
+			ReadRequests:  paramUint(params, p+"rd.reqs"),
+			WriteRequests: paramUint(params, p+"wr.reqs"),
+		})
+	}
+	for i := range int(paramUint(params, "net.count")) {
+		p := "net." + strconv.Itoa(i) + "."
+		s.NICs = append(s.NICs, VMNICStats{
+			Name:      paramString(params, p+"name"),
+			RxBytes:   paramUint(params, p+"rx.bytes"),
+			TxBytes:   paramUint(params, p+"tx.bytes"),
+			RxPackets: paramUint(params, p+"rx.pkts"),
+			TxPackets: paramUint(params, p+"tx.pkts"),
+		})
+	}
+	return s
+}
+
+// paramUint returns a numeric stats parameter, or 0 if it is missing.
+func paramUint(params map[string]any, key string) uint64 {
+	switch v := params[key].(type) {
+	case int32:
+		return uint64(max(v, 0))
+	case uint32:
+		return uint64(v)
+	case int64:
+		return uint64(max(v, 0))
+	case uint64:
+		return v
+	case float64:
+		return uint64(max(v, 0))
+	default:
+		return 0
+	}
+}
+
+// paramString returns a string stats parameter, or "" if it is missing.
+func paramString(params map[string]any, key string) string {
+	s, _ := params[key].(string)
+	return s
+}
+
+// memoryUsed estimates the KB in use by the guest: what the balloon driver
+// reports, else the host-side RSS, else the assigned memory.
+func (s *VMStats) memoryUsed() uint64 {
+	switch {
+	case s.MemoryAvailable > 0 && s.MemoryUnused <= s.MemoryAvailable:
+		return s.MemoryAvailable - s.MemoryUnused
+	case s.MemoryRSS > 0:
+		return s.MemoryRSS
+	default:
+		return s.MemoryActual
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Stats history
+// ----------------------------------------------------------------------------
+
+// Stats ranking metrics for StatsHistory.Top.
+const (
+	StatsMetricCPU     = "cpu"
+	StatsMetricMemory  = "memory"
+	StatsMetricDisk    = "disk"
+	StatsMetricIOPS    = "iops"
+	StatsMetricNetwork = "network"
+)
+
+// StatsSample is one reading of a VM's resource usage. CPU and rates are
+// measured since the previous sample; memory is in KB.
+type StatsSample struct {
+	Time                 time.Time
+	CPUPercent           float64 // of the VM's vCPUs
+	MemoryUsed           uint64
+	MemoryActual         uint64
+	DiskReadBytesPerSec  float64
+	DiskWriteBytesPerSec float64
+	DiskReadIOPS         float64
+	DiskWriteIOPS        float64
+	NetworkRxBytesPerSec float64
+	NetworkTxBytesPerSec float64
+}
+
+// SampleTime returns when the sample was taken.
+func (s StatsSample) SampleTime() time.Time { return s.Time }
+
+// StatsSummary aggregates a VM's samples over a window. Rates are averages
+// over the window.
+type StatsSummary struct {
+	Name                 string
+	Samples              int
+	CPUAvgPercent        float64
+	CPUMaxPercent        float64
+	MemoryAvg            uint64
+	MemoryMax            uint64
+	MemoryActual         uint64
+	DiskReadBytesPerSec  float64
+	DiskWriteBytesPerSec float64
+	DiskReadIOPS         float64
+	DiskWriteIOPS        float64
+	NetworkRxBytesPerSec float64
+	NetworkTxBytesPerSec float64
+}
+
+// VMStatsHistory is a VM's samples within a window.
+type VMStatsHistory struct {
+	Summary StatsSummary
+	Samples []StatsSample
+}
+
+// StatsHistory keeps a bounded time series of resource usage per VM, by
+// name. It is safe for concurrent use.
+type StatsHistory struct {
+	*stats.History[StatsSample]
+}
+
+// NewStatsHistory returns a history holding retention worth of samples taken
+// every interval.
+func NewStatsHistory(interval, retention time.Duration) *StatsHistory {
+	return &StatsHistory{stats.NewHistory[StatsSample](interval, retention)}
+}
+
+// Add records a sample for the named VM, dropping samples older than the
+// retention period.
+func (h *StatsHistory) Add(name string, s StatsSample) {
+	h.History.Add(name, name, s)
+}
+
+// VM returns the samples since the given time for the named VM.
+func (h *StatsHistory) VM(name string, since time.Time) (*VMStatsHistory, bool) {
+	s, ok := h.Get(name, since)
+	if !ok {
+		return nil, false
+	}
+	return &VMStatsHistory{Summary: summarize(name, s.Samples), Samples: s.Samples}, true
+}
+
+// Top returns the VMs allowed by include with the highest usage of metric
+// since the given time, highest first.
+func (h *StatsHistory) Top(metric string, since time.Time, limit int, include func(name string) bool) ([]StatsSummary, error) {
+	key, ok := statsRankKeys[metric]
+	if !ok {
+		return nil, fmt.Errorf("invalid metric %q (want cpu, memory, disk, iops or network)", metric)
+	}
+	var summaries []StatsSummary
+	for _, s := range h.Window(since) {
+		if len(s.Samples) > 0 && (include == nil || include(s.Name)) {
+			summaries = append(summaries, summarize(s.Name, s.Samples))
+		}
+	}
+	return stats.Rank(summaries, key, func(s StatsSummary) string { return s.Name }, limit), nil
+}
+
+// statsRankKeys maps each metric to the summary value VMs are ranked by.
+var statsRankKeys = map[string]func(StatsSummary) float64{
+	StatsMetricCPU:     func(s StatsSummary) float64 { return s.CPUAvgPercent },
+	StatsMetricMemory:  func(s StatsSummary) float64 { return float64(s.MemoryAvg) },
+	StatsMetricDisk:    func(s StatsSummary) float64 { return s.DiskReadBytesPerSec + s.DiskWriteBytesPerSec },
+	StatsMetricIOPS:    func(s StatsSummary) float64 { return s.DiskReadIOPS + s.DiskWriteIOPS },
+	StatsMetricNetwork: func(s StatsSummary) float64 { return s.NetworkRxBytesPerSec + s.NetworkTxBytesPerSec },
+}
+
+// summarize aggregates samples, which must be in chronological order.
+func summarize(name string, samples []StatsSample) StatsSummary {
+	sum := StatsSummary{Name: name, Samples: len(samples)}
+	if len(samples) == 0 {
+		return sum
+	}
+	var cpu float64
+	var mem uint64
+	for _, s := range samples {
+		cpu += s.CPUPercent
+		mem += s.MemoryUsed
+		sum.CPUMaxPercent = max(sum.CPUMaxPercent, s.CPUPercent)
+		sum.MemoryMax = max(sum.MemoryMax, s.MemoryUsed)
+		sum.DiskReadBytesPerSec += s.DiskReadBytesPerSec
+		sum.DiskWriteBytesPerSec += s.DiskWriteBytesPerSec
+		sum.DiskReadIOPS += s.DiskReadIOPS
+		sum.DiskWriteIOPS += s.DiskWriteIOPS
+		sum.NetworkRxBytesPerSec += s.NetworkRxBytesPerSec
+		sum.NetworkTxBytesPerSec += s.NetworkTxBytesPerSec
+	}
+	n := float64(len(samples))
+	sum.CPUAvgPercent = cpu / n
+	sum.MemoryAvg = mem / uint64(len(samples))
+	sum.MemoryActual = samples[len(samples)-1].MemoryActual
+	sum.DiskReadBytesPerSec /= n
+	sum.DiskWriteBytesPerSec /= n
+	sum.DiskReadIOPS /= n
+	sum.DiskWriteIOPS /= n
+	sum.NetworkRxBytesPerSec /= n
+	sum.NetworkTxBytesPerSec /= n
+	return sum
+}
+
+// ----------------------------------------------------------------------------
+// Stats sampler
+// ----------------------------------------------------------------------------
+
+// StatsSampler polls the counters of every running VM and records them into a
+// StatsHistory as rates.
+type StatsSampler struct {
+	mgr      VMManager
+	history  *StatsHistory
+	interval time.Duration
+	now      func() time.Time
+	prev     map[string]statsReading // last reading per VM name
+}
+
+// statsReading is a VM's counters and when they were read.
+type statsReading struct {
+	at    time.Time
+	stats *VMStats
+}
+
+// NewStatsSampler returns a sampler that records into history every
+// interval.
+func NewStatsSampler(mgr VMManager, history *StatsHistory, interval time.Duration) *StatsSampler {
+	if interval <= 0 {
+		interval = stats.DefaultInterval
+	}
+	return &StatsSampler{
+		mgr:      mgr,
+		history:  history,
+		interval: interval,
+		now:      time.Now,
+		prev:     make(map[string]statsReading),
+	}
+}
+
+// Run samples immediately and then every interval until ctx is cancelled.
+func (s *StatsSampler) Run(ctx context.Context) {
+	stats.Run(ctx, s.interval, "vm stats", s.sample)
+}
+
+// sample reads the counters of every running VM. A VM's first reading only
+// establishes a baseline: its rates are recorded from the second on.
+func (s *StatsSampler) sample(ctx context.Context) error {
+	vms, err := s.mgr.ListVMs(ctx)
+	if err != nil {
+		s.history.SetStatus(s.now(), err)
+		return err
+	}
+
+	var failed []string
+	seen := make(map[string]bool, len(vms))
+	for _, v := range vms {
+		if v.State != VMStateRunning {
+			continue
+		}
+		cur, err := s.mgr.GetVMStats(ctx, v.Name)
+		if err != nil {
+			failed = append(failed, v.Name)
+			continue
+		}
+		now := s.now()
+		seen[v.Name] = true
+		prev, ok := s.prev[v.Name]
+		s.prev[v.Name] = statsReading{at: now, stats: cur}
+		if ok && now.After(prev.at) {
+			s.history.Add(v.Name, statsBetween(prev.stats, cur, now.Sub(prev.at), now))
+		}
+	}
+	for name := range s.prev {
+		if !seen[name] {
+			delete(s.prev, name)
+		}
+	}
+	now := s.now()
+	s.history.Prune(now)
+
+	if len(failed) > 0 {
+		err = fmt.Errorf("could not read stats for %s", strings.Join(failed, ", "))
+	}
+	s.history.SetStatus(now, err)
+	return err
+}
+
+// statsBetween turns two readings of a VM's counters, elapsed apart, into a
+// sample taken at the given time.
+func statsBetween(prev, cur *VMStats, elapsed time.Duration, at time.Time) StatsSample {
+	secs := elapsed.Seconds()
+	vcpus := max(cur.VCPUs, 1)
+	prevDisk, curDisk := diskTotals(prev), diskTotals(cur)
+	prevNet, curNet := nicTotals(prev), nicTotals(cur)
+	return StatsSample{
+		Time:                 at,
+		CPUPercent:           counterRate(prev.CPUTime, cur.CPUTime, secs) / 1e9 / float64(vcpus) * 100,
+		MemoryUsed:           cur.memoryUsed(),
+		MemoryActual:         cur.MemoryActual,
+		DiskReadBytesPerSec:  counterRate(prevDisk.ReadBytes, curDisk.ReadBytes, secs),
+		DiskWriteBytesPerSec: counterRate(prevDisk.WriteBytes, curDisk.WriteBytes, secs),
+		DiskReadIOPS:         counterRate(prevDisk.ReadRequests, curDisk.ReadRequests, secs),
+		DiskWriteIOPS:        counterRate(prevDisk.WriteRequests, curDisk.WriteRequests, secs),
+		NetworkRxBytesPerSec: counterRate(prevNet.RxBytes, curNet.RxBytes, secs),
+		NetworkTxBytesPerSec: counterRate(prevNet.TxBytes, curNet.TxBytes, secs),
+	}
+}
+
+// counterRate returns the per-second increase of a cumulative counter. A
+// decrease means the VM restarted and the counter began again from zero.
+func counterRate(prev, cur uint64, secs float64) float64 {
+	if cur < prev {
+		prev = 0
+	}
+	return float64(cur-prev) / secs
+}
+
+// diskTotals sums the counters of all of a VM's disks.
+func diskTotals(s *VMStats) VMDiskStats {
+	var t VMDiskStats
+	for _, d := range s.Disks {
+		t.ReadBytes += d.ReadBytes
+		t.WriteBytes += d.WriteBytes
+		t.ReadRequests += d.ReadRequests
+		t.WriteRequests += d.WriteRequests
+	}
+	return t
+}
+
+// nicTotals sums the counters of all of a VM's interfaces.
+func nicTotals(s *VMStats) VMNICStats {
+	var t VMNICStats
+	for _, n := range s.NICs {
+		t.RxBytes += n.RxBytes
+		t.TxBytes += n.TxBytes
+		t.RxPackets += n.RxPackets
+		t.TxPackets += n.TxPackets
+	}
+	return t
+}
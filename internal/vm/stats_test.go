@@ -0,0 +1,299 @@
+package vm
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+	"github.com/jamesprial/unraid-mcp/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// ---------------------------------------------------------------------------
+// Domain stats parsing
+// ---------------------------------------------------------------------------
+
+func Test_VMStatsFromParams(t *testing.T) {
+	params := map[string]any{
+		"state.state":       int32(1),
+		"cpu.time":          uint64(5_000_000_000),
+		"vcpu.current":      uint32(4),
+		"balloon.current":   uint64(4194304),
+		"balloon.maximum":   uint64(8388608),
+		"balloon.unused":    uint64(1048576),
+		"balloon.available": uint64(4096000),
+		"balloon.rss":       uint64(4300000),
+		"block.count":       uint32(2),
+		"block.0.name":      "vda",
+		"block.0.rd.bytes":  uint64(1000),
+		"block.0.wr.bytes":  uint64(2000),
+		"block.0.rd.reqs":   uint64(10),
+		"block.0.wr.reqs":   uint64(20),
+		"block.1.name":      "hdc",
+		"net.count":         uint32(1),
+		"net.0.name":        "vnet0",
+		"net.0.rx.bytes":    uint64(300),
+		"net.0.tx.bytes":    uint64(400),
+		"net.0.rx.pkts":     uint64(3),
+		"net.0.tx.pkts":     uint64(4),
+		"unknown.field":     true,
+	}
+
+	got := vmStatsFromParams("win10", params)
+	if got.Name != "win10" || got.VCPUs != 4 || got.CPUTime != 5_000_000_000 {
+		t.Errorf("Name/VCPUs/CPUTime = %q/%d/%d", got.Name, got.VCPUs, got.CPUTime)
+	}
+	if got.MemoryActual != 4194304 || got.MemoryMax != 8388608 || got.MemoryUnused != 1048576 {
+		t.Errorf("memory = %+v", got)
+	}
+	if len(got.Disks) != 2 {
+		t.Fatalf("len(Disks) = %d, want 2", len(got.Disks))
+	}
+	wantDisk := VMDiskStats{Name: "vda", ReadBytes: 1000, WriteBytes: 2000, ReadRequests: 10, WriteRequests: 20}
+	if got.Disks[0] != wantDisk {
+		t.Errorf("Disks[0] = %+v, want %+v", got.Disks[0], wantDisk)
+	}
+	if got.Disks[1] != (VMDiskStats{Name: "hdc"}) {
+		t.Errorf("Disks[1] = %+v, want hdc with no counters", got.Disks[1])
+	}
+	wantNIC := VMNICStats{Name: "vnet0", RxBytes: 300, TxBytes: 400, RxPackets: 3, TxPackets: 4}
+	if len(got.NICs) != 1 || got.NICs[0] != wantNIC {
+		t.Errorf("NICs = %+v, want [%+v]", got.NICs, wantNIC)
+	}
+	if used := got.memoryUsed(); used != 4096000-1048576 {
+		t.Errorf("memoryUsed() = %d, want %d", used, 4096000-1048576)
+	}
+}
+
+func Test_VMStats_MemoryUsedFallbacks(t *testing.T) {
+	tests := []struct {
+		name  string
+		stats VMStats
+		want  uint64
+	}{
+		{"balloon driver", VMStats{MemoryActual: 100, MemoryAvailable: 90, MemoryUnused: 30, MemoryRSS: 120}, 60},
+		{"no balloon driver uses rss", VMStats{MemoryActual: 100, MemoryRSS: 120}, 120},
+		{"nothing but assigned memory", VMStats{MemoryActual: 100}, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.stats.memoryUsed(); got != tt.want {
+				t.Errorf("memoryUsed() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// ---------------------------------------------------------------------------
+// History
+// ---------------------------------------------------------------------------
+
+func Test_StatsHistory_BoundsSamples(t *testing.T) {
+	h := NewStatsHistory(time.Minute, 10*time.Minute)
+	start := time.Now().Add(-time.Hour)
+	for i := range 30 {
+		h.Add("win10", StatsSample{Time: start.Add(time.Duration(i) * time.Minute)})
+	}
+	got, ok := h.VM("win10", time.Time{})
+	if !ok {
+		t.Fatal("VM(win10) not found")
+	}
+	if len(got.Samples) != 11 {
+		t.Errorf("len(Samples) = %d, want 11", len(got.Samples))
+	}
+}
+
+func Test_StatsHistory_Top(t *testing.T) {
+	h := NewStatsHistory(time.Minute, time.Hour)
+	now := time.Now()
+	h.Add("win10", StatsSample{Time: now.Add(-time.Minute), CPUPercent: 80, MemoryUsed: 100, DiskReadIOPS: 5})
+	h.Add("ubuntu", StatsSample{Time: now.Add(-time.Minute), CPUPercent: 10, MemoryUsed: 300, NetworkRxBytesPerSec: 1000})
+	h.Add("hidden", StatsSample{Time: now.Add(-time.Minute), CPUPercent: 99, MemoryUsed: 999, DiskReadIOPS: 99})
+	filter := safety.NewFilter(nil, []string{"hidden"})
+
+	tests := []struct {
+		metric string
+		want   string
+	}{
+		{StatsMetricCPU, "win10"},
+		{StatsMetricMemory, "ubuntu"},
+		{StatsMetricIOPS, "win10"},
+		{StatsMetricNetwork, "ubuntu"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.metric, func(t *testing.T) {
+			top, err := h.Top(tt.metric, now.Add(-time.Hour), 1, filter.IsAllowed)
+			if err != nil {
+				t.Fatalf("Top(%s) error = %v", tt.metric, err)
+			}
+			if len(top) != 1 || top[0].Name != tt.want {
+				t.Errorf("Top(%s) = %+v, want %s first", tt.metric, top, tt.want)
+			}
+		})
+	}
+	if _, err := h.Top("pids", now, 1, nil); err == nil {
+		t.Error("Top(pids) error = nil, want invalid metric")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Sampler
+// ---------------------------------------------------------------------------
+
+func Test_StatsSampler_ComputesRates(t *testing.T) {
+	mgr := newSeededMock(t)
+	h := NewStatsHistory(time.Minute, time.Hour)
+	s := NewStatsSampler(mgr, h, time.Minute)
+	clock := time.Now()
+	s.now = func() time.Time { return clock }
+
+	mgr.SetVMStats("win10", VMStats{
+		CPUTime:      10e9,
+		MemoryActual: 4194304,
+		Disks:        []VMDiskStats{{Name: "vda", ReadBytes: 1000, ReadRequests: 10}, {Name: "vdb", WriteBytes: 500}},
+		NICs:         []VMNICStats{{Name: "vnet0", RxBytes: 100, TxBytes: 50}},
+	})
+	if err := s.sample(t.Context()); err != nil {
+		t.Fatalf("sample() error = %v", err)
+	}
+	if _, ok := h.VM("win10", time.Time{}); ok {
+		t.Error("first reading recorded a sample; it should only set the baseline")
+	}
+
+	// 10s later: 20 CPU-seconds over 4 vCPUs is 50%.
+	clock = clock.Add(10 * time.Second)
+	mgr.SetVMStats("win10", VMStats{
+		CPUTime:      30e9,
+		MemoryActual: 4194304,
+		MemoryRSS:    2000000,
+		Disks:        []VMDiskStats{{Name: "vda", ReadBytes: 11000, ReadRequests: 110}, {Name: "vdb", WriteBytes: 5500}},
+		NICs:         []VMNICStats{{Name: "vnet0", RxBytes: 1100, TxBytes: 50}},
+	})
+	if err := s.sample(t.Context()); err != nil {
+		t.Fatalf("sample() error = %v", err)
+	}
+
+	got, ok := h.VM("win10", time.Time{})
+	if !ok || len(got.Samples) != 1 {
+		t.Fatalf("win10 history = %+v, want 1 sample", got)
+	}
+	sample := got.Samples[0]
+	checks := []struct {
+		name      string
+		got, want float64
+	}{
+		{"CPUPercent", sample.CPUPercent, 50},
+		{"DiskReadBytesPerSec", sample.DiskReadBytesPerSec, 1000},
+		{"DiskWriteBytesPerSec", sample.DiskWriteBytesPerSec, 500},
+		{"DiskReadIOPS", sample.DiskReadIOPS, 10},
+		{"NetworkRxBytesPerSec", sample.NetworkRxBytesPerSec, 100},
+		{"NetworkTxBytesPerSec", sample.NetworkTxBytesPerSec, 0},
+	}
+	for _, c := range checks {
+		if math.Abs(c.got-c.want) > 0.001 {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+	if sample.MemoryUsed != 2000000 {
+		t.Errorf("MemoryUsed = %d, want 2000000", sample.MemoryUsed)
+	}
+	if _, ok := h.VM("ubuntu", time.Time{}); ok {
+		t.Error("shutoff VM was sampled")
+	}
+	if lastRun, lastErr := h.Status(); !lastRun.Equal(clock) || lastErr != "" {
+		t.Errorf("Status() = %v, %q", lastRun, lastErr)
+	}
+}
+
+func Test_StatsBetween_CounterReset(t *testing.T) {
+	// The VM restarted between readings: counters start again from zero.
+	prev := &VMStats{VCPUs: 1, CPUTime: 100e9, Disks: []VMDiskStats{{ReadBytes: 1 << 30}}}
+	cur := &VMStats{VCPUs: 1, CPUTime: 5e9, Disks: []VMDiskStats{{ReadBytes: 1000}}}
+	got := statsBetween(prev, cur, 10*time.Second, time.Now())
+	if math.Abs(got.CPUPercent-50) > 0.001 {
+		t.Errorf("CPUPercent = %v, want 50", got.CPUPercent)
+	}
+	if got.DiskReadBytesPerSec != 100 {
+		t.Errorf("DiskReadBytesPerSec = %v, want 100", got.DiskReadBytesPerSec)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Tools
+// ---------------------------------------------------------------------------
+
+// callStatsTool invokes the named tool handler and returns its result text.
+func callStatsTool(t *testing.T, regs []tools.Registration, name string, args map[string]any) string {
+	t.Helper()
+	for _, r := range regs {
+		if r.Tool.Name != name {
+			continue
+		}
+		req := mcp.CallToolRequest{}
+		req.Params.Name = name
+		req.Params.Arguments = args
+		result, err := r.Handler(context.Background(), req)
+		if err != nil {
+			t.Fatalf("%s handler returned error: %v", name, err)
+		}
+		tc, ok := mcp.AsTextContent(result.Content[0])
+		if !ok {
+			t.Fatalf("first content entry is not TextContent, got %T", result.Content[0])
+		}
+		return tc.Text
+	}
+	t.Fatalf("registration for %q not found", name)
+	return ""
+}
+
+func Test_StatsTools(t *testing.T) {
+	mgr := newSeededMock(t)
+	mgr.SetVMStats("win10", VMStats{CPUTime: 42})
+	h := NewStatsHistory(time.Minute, time.Hour)
+	now := time.Now()
+	h.Add("win10", StatsSample{Time: now.Add(-time.Minute), CPUPercent: 5})
+	h.Add("paused-vm", StatsSample{Time: now.Add(-time.Minute), CPUPercent: 50})
+	regs := StatsTools(mgr, h, safety.NewFilter(nil, []string{"paused-vm"}), nil)
+
+	text := callStatsTool(t, regs, "vm_top", map[string]any{"window": "10m"})
+	var top TopResult
+	if err := json.Unmarshal([]byte(text), &top); err != nil {
+		t.Fatalf("decode result: %v\n%s", err, text)
+	}
+	if len(top.VMs) != 1 || top.VMs[0].Name != "win10" || top.Window != "10m0s" {
+		t.Errorf("vm_top = %+v, want win10 only over 10m", top)
+	}
+
+	text = callStatsTool(t, regs, "vm_stats", map[string]any{"name": "win10"})
+	var stats StatsResult
+	if err := json.Unmarshal([]byte(text), &stats); err != nil {
+		t.Fatalf("decode result: %v\n%s", err, text)
+	}
+	if stats.Current == nil || stats.Current.CPUTime != 42 || len(stats.Samples) != 1 || stats.Summary.Name != "win10" {
+		t.Errorf("vm_stats = %+v, want live counters and one sample", stats)
+	}
+
+	tests := []struct {
+		name string
+		tool string
+		args map[string]any
+		want string
+	}{
+		{"bad metric", "vm_top", map[string]any{"metric": "pids"}, "invalid metric"},
+		{"bad window", "vm_stats", map[string]any{"name": "win10", "window": "soon"}, "invalid window"},
+		{"denied", "vm_stats", map[string]any{"name": "paused-vm"}, "not allowed"},
+		{"unknown", "vm_stats", map[string]any{"name": "nonexistent"}, "not found"},
+		{"not sampled yet", "vm_stats", map[string]any{"name": "ubuntu"}, `"Samples": null`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if text := callStatsTool(t, regs, tt.tool, tt.args); !strings.Contains(text, tt.want) {
+				t.Errorf("%s = %s, want %q", tt.tool, text, tt.want)
+			}
+		})
+	}
+}
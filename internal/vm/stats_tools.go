@@ -0,0 +1,141 @@
+// Package vm provides virtual machine management for Unraid systems via libvirt.
+package vm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jamesprial/unraid-mcp/internal/safety"
+	"github.com/jamesprial/unraid-mcp/internal/stats"
+	"github.com/jamesprial/unraid-mcp/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// TopResult is the result of vm_top.
+type TopResult struct {
+	Metric     string
+	Window     string
+	LastSample time.Time
+	LastError  string
+	VMs        []StatsSummary
+}
+
+// StatsResult is the result of vm_stats: the VM's counters right now plus the
+// rates recorded by the background sampler over the window.
+type StatsResult struct {
+	Window     string
+	LastSample time.Time
+	LastError  string
+	Current    *VMStats
+	VMStatsHistory
+}
+
+// StatsTools returns the tool registrations for live VM statistics and the
+// history recorded by the background stats sampler.
+func StatsTools(mgr VMManager, history *StatsHistory, filter *safety.Filter, audit *safety.AuditLogger) []tools.Registration {
+	return []tools.Registration{
+		vmStats(mgr, history, filter, audit),
+		vmTop(history, filter, audit),
+	}
+}
+
+func vmStats(mgr VMManager, history *StatsHistory, filter *safety.Filter, audit *safety.AuditLogger) tools.Registration {
+	tool := mcp.NewTool("vm_stats",
+		mcp.WithDescription("Get a virtual machine's resource usage: its live counters (CPU time, assigned and guest-used memory, "+
+			"per-disk read/write bytes and requests, per-interface rx/tx) plus CPU %, memory, disk throughput, IOPS and network rates "+
+			"sampled in the background over a recent window, with averages and maxima."),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("VM name"),
+		),
+		mcp.WithString("window",
+			mcp.Description("How far back to look, as a duration (default 30m; limited by the configured retention)"),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		name := req.GetString("name", "")
+		windowArg := req.GetString("window", "")
+		params := map[string]any{"name": name, "window": windowArg}
+
+		if !filter.IsAllowed(name) {
+			tools.LogAudit(audit, "vm_stats", params, "denied", start)
+			return tools.ErrorResult(fmt.Sprintf("access to VM %q is not allowed", name)), nil
+		}
+		window, err := stats.ParseWindow(windowArg, history.Retention())
+		if err != nil {
+			return tools.ErrorResult(err.Error()), nil
+		}
+
+		current, err := mgr.GetVMStats(ctx, name)
+		if err != nil {
+			tools.LogAudit(audit, "vm_stats", params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+		result := StatsResult{Window: window.String(), Current: current}
+		result.LastSample, result.LastError = history.Status()
+		if h, ok := history.VM(name, start.Add(-window)); ok {
+			result.VMStatsHistory = *h
+		} else {
+			result.Summary.Name = name
+		}
+
+		tools.LogAudit(audit, "vm_stats", params, "ok", start)
+		return tools.JSONResult(result), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
+
+func vmTop(history *StatsHistory, filter *safety.Filter, audit *safety.AuditLogger) tools.Registration {
+	tool := mcp.NewTool("vm_top",
+		mcp.WithDescription("Rank running virtual machines by resource usage over a recent window, from stats sampled in the background: "+
+			"\"which VMs used the most CPU, memory, disk or network in the last 30 minutes\". "+
+			"Memory is ranked by average guest usage, everything else by average rate."),
+		mcp.WithString("metric",
+			mcp.Description("cpu (default), memory, disk, iops or network"),
+		),
+		mcp.WithString("window",
+			mcp.Description("How far back to look, as a duration (default 30m; limited by the configured retention)"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description(fmt.Sprintf("Number of VMs to return (default %d)", stats.DefaultTopLimit)),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		metric := req.GetString("metric", StatsMetricCPU)
+		windowArg := req.GetString("window", "")
+		limit := req.GetInt("limit", stats.DefaultTopLimit)
+		params := map[string]any{"metric": metric, "window": windowArg, "limit": limit}
+
+		window, err := stats.ParseWindow(windowArg, history.Retention())
+		if err != nil {
+			return tools.ErrorResult(err.Error()), nil
+		}
+		if limit <= 0 {
+			limit = stats.DefaultTopLimit
+		}
+
+		vms, err := history.Top(metric, start.Add(-window), limit, filter.IsAllowed)
+		if err != nil {
+			return tools.ErrorResult(err.Error()), nil
+		}
+		lastRun, lastErr := history.Status()
+
+		tools.LogAudit(audit, "vm_top", params, "ok", start)
+		return tools.JSONResult(TopResult{
+			Metric:     metric,
+			Window:     window.String(),
+			LastSample: lastRun,
+			LastError:  lastErr,
+			VMs:        vms,
+		}), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
@@ -82,21 +82,77 @@ func Test_StubMethods_ReturnErrLibvirtNotCompiled(t *testing.T) {
 			name: "RestartVM",
 			call: func() error { return m.RestartVM(ctx, "") },
 		},
+		{
+			name: "SetAutostart",
+			call: func() error { return m.SetAutostart(ctx, "", true) },
+		},
+		{
+			name: "GetVMStats",
+			call: func() error { _, err := m.GetVMStats(ctx, ""); return err },
+		},
 		{
 			name: "CreateVM",
 			call: func() error { return m.CreateVM(ctx, "") },
 		},
+		{
+			name: "CreateDisk",
+			call: func() error { return m.CreateDisk(ctx, "", "", 0) },
+		},
+		{
+			name: "DeleteDisk",
+			call: func() error { return m.DeleteDisk(ctx, "") },
+		},
 		{
 			name: "DeleteVM",
 			call: func() error { return m.DeleteVM(ctx, "") },
 		},
+		{
+			name: "SetVMMemory",
+			call: func() error { _, err := m.SetVMMemory(ctx, "", 0, VMChangeBoth); return err },
+		},
+		{
+			name: "SetVMVCPUs",
+			call: func() error { _, err := m.SetVMVCPUs(ctx, "", 0, 0, VMChangeBoth); return err },
+		},
+		{
+			name: "AttachDisk",
+			call: func() error { _, err := m.AttachDisk(ctx, "", VMDiskSpec{}, VMChangeBoth); return err },
+		},
+		{
+			name: "DetachDisk",
+			call: func() error { _, err := m.DetachDisk(ctx, "", "", VMChangeBoth); return err },
+		},
+		{
+			name: "AttachNIC",
+			call: func() error { _, err := m.AttachNIC(ctx, "", VMNICSpec{}, VMChangeBoth); return err },
+		},
+		{
+			name: "DetachNIC",
+			call: func() error { _, err := m.DetachNIC(ctx, "", "", VMChangeBoth); return err },
+		},
+		{
+			name: "ChangeMedia",
+			call: func() error { _, err := m.ChangeMedia(ctx, "", "", "", VMChangeBoth); return err },
+		},
 		{
 			name: "ListSnapshots",
 			call: func() error { _, err := m.ListSnapshots(ctx, ""); return err },
 		},
+		{
+			name: "InspectSnapshot",
+			call: func() error { _, err := m.InspectSnapshot(ctx, "", ""); return err },
+		},
 		{
 			name: "CreateSnapshot",
-			call: func() error { return m.CreateSnapshot(ctx, "", "") },
+			call: func() error { return m.CreateSnapshot(ctx, "", "", SnapshotOptions{}) },
+		},
+		{
+			name: "RevertSnapshot",
+			call: func() error { return m.RevertSnapshot(ctx, "", "") },
+		},
+		{
+			name: "DeleteSnapshot",
+			call: func() error { return m.DeleteSnapshot(ctx, "", "") },
 		},
 	}
 
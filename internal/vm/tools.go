@@ -4,6 +4,7 @@ package vm
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jamesprial/unraid-mcp/internal/safety"
@@ -19,6 +20,16 @@ var DestructiveTools = []string{
 	"vm_restart",
 	"vm_create",
 	"vm_delete",
+	"vm_snapshot_revert",
+	"vm_snapshot_delete",
+	"vm_set_memory",
+	"vm_set_vcpus",
+	"vm_disk_attach",
+	"vm_disk_detach",
+	"vm_nic_attach",
+	"vm_nic_detach",
+	"vm_cdrom_insert",
+	"vm_cdrom_eject",
 }
 
 // VMTools returns a slice of tool registrations for all VM MCP tools.
@@ -39,10 +50,22 @@ func VMTools(
 		vmPause(mgr, filter, audit),
 		vmResume(mgr, filter, audit),
 		vmRestart(mgr, filter, confirm, audit),
-		vmCreate(mgr, confirm, audit),
+		vmCreate(mgr, filter, confirm, audit),
 		vmDelete(mgr, filter, confirm, audit),
+		vmAutostart(mgr, filter, audit),
 		vmSnapshotList(mgr, filter, audit),
+		vmSnapshotInspect(mgr, filter, audit),
 		vmSnapshotCreate(mgr, filter, audit),
+		vmSnapshotRevert(mgr, filter, confirm, audit),
+		vmSnapshotDelete(mgr, filter, confirm, audit),
+		vmSetMemory(mgr, filter, confirm, audit),
+		vmSetVCPUs(mgr, filter, confirm, audit),
+		vmDiskAttach(mgr, filter, confirm, audit),
+		vmDiskDetach(mgr, filter, confirm, audit),
+		vmNICAttach(mgr, filter, confirm, audit),
+		vmNICDetach(mgr, filter, confirm, audit),
+		vmCDROMInsert(mgr, filter, confirm, audit),
+		vmCDROMEject(mgr, filter, confirm, audit),
 	}
 }
 
@@ -160,9 +183,9 @@ func vmStop(mgr VMManager, filter *safety.Filter, confirm *safety.ConfirmationTr
 			return tools.ErrorResult(fmt.Sprintf("access to VM %q is not allowed", name)), nil
 		}
 
-		if !confirm.Confirm(token) {
+		if !confirm.Confirm(token, toolName, name, params) {
 			desc := fmt.Sprintf("This will gracefully shut down VM %q via ACPI.", name)
-			return tools.ConfirmPrompt(confirm, toolName, name, desc), nil
+			return tools.ConfirmPrompt(confirm, toolName, name, desc, params), nil
 		}
 
 		if err := mgr.StopVM(ctx, name); err != nil {
@@ -202,9 +225,9 @@ func vmForceStop(mgr VMManager, filter *safety.Filter, confirm *safety.Confirmat
 			return tools.ErrorResult(fmt.Sprintf("access to VM %q is not allowed", name)), nil
 		}
 
-		if !confirm.Confirm(token) {
+		if !confirm.Confirm(token, toolName, name, params) {
 			desc := fmt.Sprintf("This will FORCIBLY destroy VM %q immediately (like pulling the power cord). Data loss may occur.", name)
-			return tools.ConfirmPrompt(confirm, toolName, name, desc), nil
+			return tools.ConfirmPrompt(confirm, toolName, name, desc, params), nil
 		}
 
 		if err := mgr.ForceStopVM(ctx, name); err != nil {
@@ -306,9 +329,9 @@ func vmRestart(mgr VMManager, filter *safety.Filter, confirm *safety.Confirmatio
 			return tools.ErrorResult(fmt.Sprintf("access to VM %q is not allowed", name)), nil
 		}
 
-		if !confirm.Confirm(token) {
+		if !confirm.Confirm(token, toolName, name, params) {
 			desc := fmt.Sprintf("This will restart VM %q.", name)
-			return tools.ConfirmPrompt(confirm, toolName, name, desc), nil
+			return tools.ConfirmPrompt(confirm, toolName, name, desc, params), nil
 		}
 
 		if err := mgr.RestartVM(ctx, name); err != nil {
@@ -323,14 +346,76 @@ func vmRestart(mgr VMManager, filter *safety.Filter, confirm *safety.Confirmatio
 	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
 }
 
-func vmCreate(mgr VMManager, confirm *safety.ConfirmationTracker, audit *safety.AuditLogger) tools.Registration {
+// CreatePreview is the result of a vm_create dry run: the domain XML that
+// would be defined and the disk images that would be created for it.
+type CreatePreview struct {
+	XMLConfig string
+	NewDisks  []VMDiskSpec
+}
+
+func vmCreate(mgr VMManager, filter *safety.Filter, confirm *safety.ConfirmationTracker, audit *safety.AuditLogger) tools.Registration {
 	const toolName = "vm_create"
 
 	tool := mcp.NewTool(toolName,
-		mcp.WithDescription("Create a new virtual machine from an XML configuration. Requires confirmation."),
+		mcp.WithDescription("Create a new virtual machine, either from a spec (name, memory, CPUs, disks, ISOs, NICs, "+
+			"passthrough devices) rendered as Unraid-compatible libvirt XML, or from raw XML. "+
+			"New disk images are created in the VM's folder of the domains share. "+
+			"Use dry_run to review the generated XML first. Requires confirmation."),
+		mcp.WithString("name",
+			mcp.Description("VM name; required unless xml_config is given"),
+		),
+		mcp.WithString("os",
+			mcp.Description("Guest OS: linux (default) or windows; sets the Unraid template, clock and Hyper-V settings"),
+		),
+		mcp.WithString("memory",
+			mcp.Description("Memory (e.g. 4g, 8192m; default 1g)"),
+		),
+		mcp.WithNumber("vcpus",
+			mcp.Description("Number of vCPUs (default: cores x threads, or 1)"),
+		),
+		mcp.WithNumber("cores",
+			mcp.Description("CPU topology: cores (default: vcpus / threads)"),
+		),
+		mcp.WithNumber("threads",
+			mcp.Description("CPU topology: threads per core (default 1)"),
+		),
+		mcp.WithString("machine",
+			mcp.Description("Machine type: q35 (default), i440fx, or a versioned type such as pc-q35-9.2"),
+		),
+		mcp.WithString("bios",
+			mcp.Description("Firmware: ovmf (UEFI, default) or seabios"),
+		),
+		mcp.WithArray("disks",
+			mcp.Description("Disks as SIZE|PATH[=SIZE][:BUS[:FORMAT]]. A size creates a new image (vdiskN.img in the VM's folder unless a path is given); "+
+				"a path alone attaches an existing one. Bus virtio (default), sata, scsi, usb or ide; format raw (default) or qcow2. "+
+				"E.g. 30g, 64g:sata:qcow2, /mnt/disks/nvme/vm/vdisk1.img"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithArray("cdroms",
+			mcp.Description("ISO images to attach, at most 2 (e.g. /mnt/user/isos/debian.iso)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithArray("nics",
+			mcp.Description("Network interfaces as BRIDGE[:MODEL[:MAC]] (e.g. br0, br0:e1000). "+
+				"Model virtio-net (default), virtio, e1000, rtl8139 or vmxnet3"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithString("graphics",
+			mcp.Description("Display: vnc (default) or none (e.g. with a passed-through GPU)"),
+		),
+		mcp.WithArray("usb",
+			mcp.Description("Host USB devices to pass through as vendor:product IDs (e.g. 046d:c52b)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithArray("pci",
+			mcp.Description("Host PCI devices to pass through as [domain:]bus:slot.function (e.g. 01:00.0)"),
+			mcp.WithStringItems(),
+		),
 		mcp.WithString("xml_config",
-			mcp.Required(),
-			mcp.Description("Libvirt XML domain configuration"),
+			mcp.Description("Raw libvirt XML domain configuration, instead of a spec"),
+		),
+		mcp.WithBoolean("dry_run",
+			mcp.Description("Return the generated XML and the disks to create without creating anything (default: false)"),
 		),
 		mcp.WithString("confirmation_token",
 			mcp.Description("Confirmation token returned by a prior call to this tool"),
@@ -339,27 +424,180 @@ func vmCreate(mgr VMManager, confirm *safety.ConfirmationTracker, audit *safety.
 
 	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		start := time.Now()
-		xmlConfig := req.GetString("xml_config", "")
 		token := req.GetString("confirmation_token", "")
-		params := map[string]any{"xml_config_length": len(xmlConfig)}
+		xmlConfig := req.GetString("xml_config", "")
+		name := req.GetString("name", "")
+
+		switch {
+		case xmlConfig != "" && name != "":
+			return tools.ErrorResult("give either xml_config or a name with a spec, not both"), nil
+		case xmlConfig != "":
+			return createFromXML(ctx, mgr, filter, confirm, audit, xmlConfig, token, start), nil
+		case name == "":
+			return tools.ErrorResult("name is required unless xml_config is given"), nil
+		}
+
+		spec, params, err := createSpecFromRequest(req)
+		if err != nil {
+			return tools.ErrorResult(err.Error()), nil
+		}
+		if !filter.IsAllowed(spec.Name) {
+			tools.LogAudit(audit, toolName, params, "denied", start)
+			return tools.ErrorResult(fmt.Sprintf("creation of VM %q is not allowed", spec.Name)), nil
+		}
+		spec, err = spec.Normalize()
+		if err != nil {
+			return tools.ErrorResult(err.Error()), nil
+		}
+		xmlConfig, err = spec.DomainXML()
+		if err != nil {
+			return tools.ErrorResult(err.Error()), nil
+		}
+		newDisks := spec.NewDisks()
+
+		if req.GetBool("dry_run", false) {
+			return tools.JSONResult(CreatePreview{XMLConfig: xmlConfig, NewDisks: newDisks}), nil
+		}
+		if _, err := mgr.InspectVM(ctx, spec.Name); err == nil {
+			return tools.ErrorResult(fmt.Sprintf("vm %q already exists", spec.Name)), nil
+		}
 
-		if !confirm.Confirm(token) {
-			desc := "This will define a new virtual machine from the provided XML configuration."
-			return tools.ConfirmPrompt(confirm, toolName, "new-vm", desc), nil
+		// The token is bound to the generated XML and the new disks, so a
+		// confirmed definition cannot be swapped for a different one.
+		disks := make([]string, 0, len(newDisks))
+		for _, d := range newDisks {
+			disks = append(disks, fmt.Sprintf("%s (%s, %s)", d.Path, tools.FormatBytes(d.Size), d.Format))
+		}
+		confirmArgs := map[string]any{"xml_config": xmlConfig, "new_disks": disks}
+
+		if !confirm.Confirm(token, toolName, spec.Name, confirmArgs) {
+			desc := fmt.Sprintf("This will define virtual machine %q with %s of memory and %d vCPUs.",
+				spec.Name, tools.FormatBytes(spec.Memory*1024), spec.VCPUs)
+			if len(disks) > 0 {
+				desc += " New disk images: " + strings.Join(disks, ", ") + "."
+			}
+			return tools.ConfirmPrompt(confirm, toolName, spec.Name, desc, confirmArgs), nil
 		}
 
-		if err := mgr.CreateVM(ctx, xmlConfig); err != nil {
+		created := make([]string, 0, len(newDisks))
+		for _, d := range newDisks {
+			if err = mgr.CreateDisk(ctx, d.Path, d.Format, d.Size); err != nil {
+				break
+			}
+			created = append(created, d.Path)
+		}
+		if err == nil {
+			err = mgr.CreateVM(ctx, xmlConfig)
+		}
+		if err != nil {
+			// Leave nothing behind for a VM that was never defined.
+			err = removeDisks(ctx, mgr, created, err)
 			tools.LogAudit(audit, toolName, params, "error: "+err.Error(), start)
 			return tools.ErrorResult(err.Error()), nil
 		}
 
 		tools.LogAudit(audit, toolName, params, "ok", start)
-		return mcp.NewToolResultText("virtual machine created successfully"), nil
+		return mcp.NewToolResultText(fmt.Sprintf("virtual machine %q created successfully", spec.Name)), nil
 	}
 
 	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
 }
 
+// removeDisks deletes the images a failed create or attach made and returns
+// cause, noting any image that could not be removed. The cleanup runs even
+// if ctx has been cancelled.
+func removeDisks(ctx context.Context, mgr VMManager, paths []string, cause error) error {
+	ctx = context.WithoutCancel(ctx)
+	for _, path := range paths {
+		if err := mgr.DeleteDisk(ctx, path); err != nil {
+			cause = fmt.Errorf("%w; the new image %s was left behind: %v", cause, path, err)
+		}
+	}
+	return cause
+}
+
+// createFromXML defines a VM from raw XML supplied to vm_create.
+func createFromXML(ctx context.Context, mgr VMManager, filter *safety.Filter, confirm *safety.ConfirmationTracker, audit *safety.AuditLogger, xmlConfig, token string, start time.Time) *mcp.CallToolResult {
+	const toolName = "vm_create"
+
+	name, err := domainName(xmlConfig)
+	if err != nil {
+		return tools.ErrorResult(err.Error())
+	}
+	params := map[string]any{"name": name, "xml_config_length": len(xmlConfig)}
+	if !filter.IsAllowed(name) {
+		tools.LogAudit(audit, toolName, params, "denied", start)
+		return tools.ErrorResult(fmt.Sprintf("creation of VM %q is not allowed", name))
+	}
+
+	// The token is bound to the full XML, not just its length, so a
+	// confirmed definition cannot be swapped for a different one.
+	confirmArgs := map[string]any{"xml_config": xmlConfig}
+
+	if !confirm.Confirm(token, toolName, name, confirmArgs) {
+		desc := fmt.Sprintf("This will define virtual machine %q from the provided XML configuration.", name)
+		return tools.ConfirmPrompt(confirm, toolName, name, desc, confirmArgs)
+	}
+
+	if err := mgr.CreateVM(ctx, xmlConfig); err != nil {
+		tools.LogAudit(audit, toolName, params, "error: "+err.Error(), start)
+		return tools.ErrorResult(err.Error())
+	}
+
+	tools.LogAudit(audit, toolName, params, "ok", start)
+	return mcp.NewToolResultText(fmt.Sprintf("virtual machine %q created successfully", name))
+}
+
+// createSpecFromRequest builds a VMCreateSpec from the vm_create arguments,
+// and the parameters to audit.
+func createSpecFromRequest(req mcp.CallToolRequest) (VMCreateSpec, map[string]any, error) {
+	spec := VMCreateSpec{
+		Name:     req.GetString("name", ""),
+		OS:       req.GetString("os", ""),
+		VCPUs:    req.GetInt("vcpus", 0),
+		Cores:    req.GetInt("cores", 0),
+		Threads:  req.GetInt("threads", 0),
+		Machine:  req.GetString("machine", ""),
+		BIOS:     req.GetString("bios", ""),
+		CDROMs:   req.GetStringSlice("cdroms", nil),
+		Graphics: req.GetString("graphics", ""),
+		USB:      req.GetStringSlice("usb", nil),
+		PCI:      req.GetStringSlice("pci", nil),
+	}
+	memory := req.GetString("memory", "")
+	disks := req.GetStringSlice("disks", nil)
+	nics := req.GetStringSlice("nics", nil)
+	params := map[string]any{
+		"name":   spec.Name,
+		"memory": memory,
+		"vcpus":  spec.VCPUs,
+		"disks":  disks,
+		"cdroms": spec.CDROMs,
+		"nics":   nics,
+		"usb":    spec.USB,
+		"pci":    spec.PCI,
+	}
+
+	if memory != "" {
+		n, err := parseSize(memory)
+		if err != nil {
+			return spec, params, fmt.Errorf("memory: %w", err)
+		}
+		spec.Memory = n / 1024
+	}
+	for _, arg := range disks {
+		d, err := parseDiskArg(arg)
+		if err != nil {
+			return spec, params, err
+		}
+		spec.Disks = append(spec.Disks, d)
+	}
+	for _, arg := range nics {
+		spec.NICs = append(spec.NICs, parseNICArg(arg))
+	}
+	return spec, params, nil
+}
+
 func vmDelete(mgr VMManager, filter *safety.Filter, confirm *safety.ConfirmationTracker, audit *safety.AuditLogger) tools.Registration {
 	const toolName = "vm_delete"
 
@@ -385,9 +623,9 @@ func vmDelete(mgr VMManager, filter *safety.Filter, confirm *safety.Confirmation
 			return tools.ErrorResult(fmt.Sprintf("access to VM %q is not allowed", name)), nil
 		}
 
-		if !confirm.Confirm(token) {
+		if !confirm.Confirm(token, toolName, name, params) {
 			desc := fmt.Sprintf("This will permanently delete (undefine) VM %q. The disk images are NOT automatically deleted.", name)
-			return tools.ConfirmPrompt(confirm, toolName, name, desc), nil
+			return tools.ConfirmPrompt(confirm, toolName, name, desc, params), nil
 		}
 
 		if err := mgr.DeleteVM(ctx, name); err != nil {
@@ -402,6 +640,46 @@ func vmDelete(mgr VMManager, filter *safety.Filter, confirm *safety.Confirmation
 	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
 }
 
+func vmAutostart(mgr VMManager, filter *safety.Filter, audit *safety.AuditLogger) tools.Registration {
+	tool := mcp.NewTool("vm_autostart",
+		mcp.WithDescription("Enable or disable starting a virtual machine automatically when the host boots. "+
+			"vm_list reports the current setting of every VM."),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("VM name"),
+		),
+		mcp.WithBoolean("enabled",
+			mcp.Required(),
+			mcp.Description("true to start the VM at boot, false to leave it off"),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		name := req.GetString("name", "")
+		enabled := req.GetBool("enabled", false)
+		params := map[string]any{"name": name, "enabled": enabled}
+
+		if !filter.IsAllowed(name) {
+			tools.LogAudit(audit, "vm_autostart", params, "denied", start)
+			return tools.ErrorResult(fmt.Sprintf("access to VM %q is not allowed", name)), nil
+		}
+
+		if err := mgr.SetAutostart(ctx, name, enabled); err != nil {
+			tools.LogAudit(audit, "vm_autostart", params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+
+		tools.LogAudit(audit, "vm_autostart", params, "ok", start)
+		if enabled {
+			return mcp.NewToolResultText(fmt.Sprintf("autostart enabled for VM %q", name)), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("autostart disabled for VM %q", name)), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
+
 func vmSnapshotList(mgr VMManager, filter *safety.Filter, audit *safety.AuditLogger) tools.Registration {
 	tool := mcp.NewTool("vm_snapshot_list",
 		mcp.WithDescription("List all snapshots for a virtual machine."),
@@ -434,9 +712,47 @@ func vmSnapshotList(mgr VMManager, filter *safety.Filter, audit *safety.AuditLog
 	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
 }
 
+func vmSnapshotInspect(mgr VMManager, filter *safety.Filter, audit *safety.AuditLogger) tools.Registration {
+	tool := mcp.NewTool("vm_snapshot_inspect",
+		mcp.WithDescription("Get the details of a virtual machine snapshot: creation time, description, VM state, parent, disk-only vs. full and the disks it captured."),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("VM name"),
+		),
+		mcp.WithString("snapshot_name",
+			mcp.Required(),
+			mcp.Description("Snapshot name"),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		name := req.GetString("name", "")
+		snapName := req.GetString("snapshot_name", "")
+		params := map[string]any{"name": name, "snapshot_name": snapName}
+
+		if !filter.IsAllowed(name) {
+			tools.LogAudit(audit, "vm_snapshot_inspect", params, "denied", start)
+			return tools.ErrorResult(fmt.Sprintf("access to VM %q is not allowed", name)), nil
+		}
+
+		detail, err := mgr.InspectSnapshot(ctx, name, snapName)
+		if err != nil {
+			tools.LogAudit(audit, "vm_snapshot_inspect", params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+
+		tools.LogAudit(audit, "vm_snapshot_inspect", params, "ok", start)
+		return tools.JSONResult(detail), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
+
 func vmSnapshotCreate(mgr VMManager, filter *safety.Filter, audit *safety.AuditLogger) tools.Registration {
 	tool := mcp.NewTool("vm_snapshot_create",
-		mcp.WithDescription("Create a snapshot of a virtual machine."),
+		mcp.WithDescription("Create a snapshot of a virtual machine. By default a full snapshot (disks and, for a running VM, memory) is taken; "+
+			"disk_only captures just the disks as external overlay files."),
 		mcp.WithString("name",
 			mcp.Required(),
 			mcp.Description("VM name"),
@@ -445,20 +761,43 @@ func vmSnapshotCreate(mgr VMManager, filter *safety.Filter, audit *safety.AuditL
 			mcp.Required(),
 			mcp.Description("Name for the new snapshot"),
 		),
+		mcp.WithString("description",
+			mcp.Description("Free-form description stored with the snapshot"),
+		),
+		mcp.WithBoolean("disk_only",
+			mcp.Description("Capture only the disks, without guest memory (default false)"),
+		),
+		mcp.WithBoolean("quiesce",
+			mcp.Description("Freeze guest filesystems while the snapshot is taken; needs the QEMU guest agent and disk_only (default false)"),
+		),
 	)
 
 	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		start := time.Now()
 		name := req.GetString("name", "")
 		snapName := req.GetString("snapshot_name", "")
+		opts := SnapshotOptions{
+			Description: req.GetString("description", ""),
+			DiskOnly:    req.GetBool("disk_only", false),
+			Quiesce:     req.GetBool("quiesce", false),
+		}
 		params := map[string]any{"name": name, "snapshot_name": snapName}
+		if opts.Description != "" {
+			params["description"] = opts.Description
+		}
+		if opts.DiskOnly {
+			params["disk_only"] = true
+		}
+		if opts.Quiesce {
+			params["quiesce"] = true
+		}
 
 		if !filter.IsAllowed(name) {
 			tools.LogAudit(audit, "vm_snapshot_create", params, "denied", start)
 			return tools.ErrorResult(fmt.Sprintf("access to VM %q is not allowed", name)), nil
 		}
 
-		if err := mgr.CreateSnapshot(ctx, name, snapName); err != nil {
+		if err := mgr.CreateSnapshot(ctx, name, snapName, opts); err != nil {
 			tools.LogAudit(audit, "vm_snapshot_create", params, "error: "+err.Error(), start)
 			return tools.ErrorResult(err.Error()), nil
 		}
@@ -469,3 +808,97 @@ func vmSnapshotCreate(mgr VMManager, filter *safety.Filter, audit *safety.AuditL
 
 	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
 }
+
+func vmSnapshotRevert(mgr VMManager, filter *safety.Filter, confirm *safety.ConfirmationTracker, audit *safety.AuditLogger) tools.Registration {
+	const toolName = "vm_snapshot_revert"
+
+	tool := mcp.NewTool(toolName,
+		mcp.WithDescription("Revert a virtual machine to a snapshot. The VM's current disk and memory state is lost. Requires confirmation."),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("VM name"),
+		),
+		mcp.WithString("snapshot_name",
+			mcp.Required(),
+			mcp.Description("Snapshot to revert to"),
+		),
+		mcp.WithString("confirmation_token",
+			mcp.Description("Confirmation token returned by a prior call to this tool"),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		name := req.GetString("name", "")
+		snapName := req.GetString("snapshot_name", "")
+		token := req.GetString("confirmation_token", "")
+		params := map[string]any{"name": name, "snapshot_name": snapName}
+
+		if !filter.IsAllowed(name) {
+			tools.LogAudit(audit, toolName, params, "denied", start)
+			return tools.ErrorResult(fmt.Sprintf("access to VM %q is not allowed", name)), nil
+		}
+
+		if !confirm.Confirm(token, toolName, name, params) {
+			desc := fmt.Sprintf("This will revert VM %q to snapshot %q. Any changes made since the snapshot was taken are lost.", name, snapName)
+			return tools.ConfirmPrompt(confirm, toolName, name, desc, params), nil
+		}
+
+		if err := mgr.RevertSnapshot(ctx, name, snapName); err != nil {
+			tools.LogAudit(audit, toolName, params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+
+		tools.LogAudit(audit, toolName, params, "ok", start)
+		return mcp.NewToolResultText(fmt.Sprintf("VM %q reverted to snapshot %q", name, snapName)), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
+
+func vmSnapshotDelete(mgr VMManager, filter *safety.Filter, confirm *safety.ConfirmationTracker, audit *safety.AuditLogger) tools.Registration {
+	const toolName = "vm_snapshot_delete"
+
+	tool := mcp.NewTool(toolName,
+		mcp.WithDescription("Delete a virtual machine snapshot. Snapshots taken on top of it are kept. Requires confirmation."),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("VM name"),
+		),
+		mcp.WithString("snapshot_name",
+			mcp.Required(),
+			mcp.Description("Snapshot to delete"),
+		),
+		mcp.WithString("confirmation_token",
+			mcp.Description("Confirmation token returned by a prior call to this tool"),
+		),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		name := req.GetString("name", "")
+		snapName := req.GetString("snapshot_name", "")
+		token := req.GetString("confirmation_token", "")
+		params := map[string]any{"name": name, "snapshot_name": snapName}
+
+		if !filter.IsAllowed(name) {
+			tools.LogAudit(audit, toolName, params, "denied", start)
+			return tools.ErrorResult(fmt.Sprintf("access to VM %q is not allowed", name)), nil
+		}
+
+		if !confirm.Confirm(token, toolName, name, params) {
+			desc := fmt.Sprintf("This will permanently delete snapshot %q of VM %q.", snapName, name)
+			return tools.ConfirmPrompt(confirm, toolName, name, desc, params), nil
+		}
+
+		if err := mgr.DeleteSnapshot(ctx, name, snapName); err != nil {
+			tools.LogAudit(audit, toolName, params, "error: "+err.Error(), start)
+			return tools.ErrorResult(err.Error()), nil
+		}
+
+		tools.LogAudit(audit, toolName, params, "ok", start)
+		return mcp.NewToolResultText(fmt.Sprintf("snapshot %q of VM %q deleted", snapName, name)), nil
+	}
+
+	return tools.Registration{Tool: tool, Handler: server.ToolHandlerFunc(handler)}
+}
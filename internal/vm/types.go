@@ -24,7 +24,7 @@ type VM struct {
 	State     VMState
 	Memory    uint64 // KB
 	VCPUs     int
-	Autostart bool
+	Autostart bool // started by libvirt when the host boots
 }
 
 // VMDisk describes a disk attached to a virtual machine.
@@ -47,15 +47,135 @@ type VMDetail struct {
 	VM
 	XMLConfig string
 	Disks     []VMDisk
+	CDROMs    []VMDisk // Source is empty when no ISO is inserted
 	NICs      []VMNIC
 }
 
+// VMDiskStats holds the cumulative I/O counters of one VM disk.
+type VMDiskStats struct {
+	Name          string // target device, e.g. "vda"
+	ReadBytes     uint64
+	WriteBytes    uint64
+	ReadRequests  uint64
+	WriteRequests uint64
+}
+
+// VMNICStats holds the cumulative traffic counters of one VM interface.
+type VMNICStats struct {
+	Name      string // host-side device, e.g. "vnet0"
+	RxBytes   uint64
+	TxBytes   uint64
+	RxPackets uint64
+	TxPackets uint64
+}
+
+// VMStats is a reading of a VM's resource counters. CPU, disk and network
+// counters are cumulative since the VM was started.
+type VMStats struct {
+	Name            string
+	State           VMState
+	VCPUs           int
+	CPUTime         uint64 // nanoseconds used by all vCPUs
+	MemoryMax       uint64 // KB the balloon may grow to
+	MemoryActual    uint64 // KB currently assigned to the guest
+	MemoryUnused    uint64 // KB the guest reports unused; 0 without a balloon driver
+	MemoryAvailable uint64 // KB the guest sees; 0 without a balloon driver
+	MemoryRSS       uint64 // KB the VM's QEMU process occupies on the host
+	Disks           []VMDiskStats
+	NICs            []VMNICStats
+}
+
 // Snapshot holds metadata about a virtual machine snapshot.
 type Snapshot struct {
 	Name        string
 	Description string
 	CreatedAt   time.Time
-	State       string
+	State       string // VM state when taken; "disk-snapshot" for disk-only snapshots
+	Parent      string // snapshot this one was taken on top of, if any
+	DiskOnly    bool   // only the disks were captured, as external overlay files
+	Memory      bool   // guest memory was saved, so reverting resumes the VM
+	Current     bool   // the VM currently runs on top of this snapshot
+}
+
+// SnapshotDisk describes how a disk is captured by a snapshot.
+type SnapshotDisk struct {
+	Name     string // target device, e.g. "vda"
+	Snapshot string // "internal", "external" or "no"
+	Source   string // overlay file of an external snapshot
+}
+
+// SnapshotDetail holds the full details of a snapshot, including its XML
+// description.
+type SnapshotDetail struct {
+	Snapshot
+	Disks     []SnapshotDisk
+	XMLConfig string
+}
+
+// SnapshotOptions controls how CreateSnapshot captures a VM.
+type SnapshotOptions struct {
+	Description string
+	DiskOnly    bool // capture only the disks, without guest memory
+	Quiesce     bool // freeze guest filesystems first (needs the guest agent; disk-only)
+}
+
+// VMCreateSpec describes a virtual machine to define, in the terms of
+// Unraid's VM manager form. Zero values take that form's defaults; see
+// Normalize.
+type VMCreateSpec struct {
+	Name     string
+	OS       string // "linux" or "windows"; picks the template metadata, clock and Hyper-V settings
+	Memory   uint64 // KB
+	VCPUs    int    // defaults to Cores * Threads
+	Cores    int
+	Threads  int
+	Machine  string // "q35", "i440fx", or a versioned type such as "pc-q35-9.2"
+	BIOS     string // "ovmf" or "seabios"
+	UUID     string // derived from the spec when empty
+	Disks    []VMDiskSpec
+	CDROMs   []string // ISO image paths
+	NICs     []VMNICSpec
+	Graphics string   // "vnc" or "none"
+	USB      []string // host USB devices as vendor:product IDs, e.g. "046d:c52b"
+	PCI      []string // host PCI devices as [domain:]bus:slot.function, e.g. "01:00.0"
+}
+
+// VMDiskSpec describes a virtual disk of a VMCreateSpec.
+type VMDiskSpec struct {
+	Path   string // image file; defaults to vdiskN.img in the VM's folder of the domains share
+	Size   uint64 // bytes of a new image to create; 0 attaches an existing one
+	Bus    string // "virtio", "sata", "scsi", "usb" or "ide"
+	Format string // "raw" or "qcow2"
+}
+
+// VMNICSpec describes a network interface of a VMCreateSpec.
+type VMNICSpec struct {
+	Bridge string // host bridge, e.g. "br0"
+	Model  string // "virtio-net", "virtio", "e1000", "rtl8139" or "vmxnet3"
+	MAC    string // derived from the VM's UUID when empty
+}
+
+// VMChangeMode selects where a configuration change is applied.
+type VMChangeMode string
+
+const (
+	// VMChangeBoth applies a change to the running VM where the guest
+	// supports it, and to the VM's definition.
+	VMChangeBoth VMChangeMode = "both"
+	// VMChangeLive applies a change to the running VM only; it is lost when
+	// the VM stops.
+	VMChangeLive VMChangeMode = "live"
+	// VMChangePersistent applies a change to the VM's definition only; it
+	// takes effect when the VM next starts.
+	VMChangePersistent VMChangeMode = "persistent"
+)
+
+// VMChangeResult reports how a configuration change was applied.
+type VMChangeResult struct {
+	Live       bool   // applied to the running VM
+	Persistent bool   // saved in the VM's definition
+	Note       string // why a change was not applied live, when it was not
+	Diff       string // line diff of the domain XML before and after
 }
 
 // VMManager defines the interface for managing virtual machines.
@@ -68,8 +188,22 @@ type VMManager interface {
 	PauseVM(ctx context.Context, name string) error
 	ResumeVM(ctx context.Context, name string) error
 	RestartVM(ctx context.Context, name string) error
+	SetAutostart(ctx context.Context, name string, enabled bool) error
+	GetVMStats(ctx context.Context, name string) (*VMStats, error)
 	CreateVM(ctx context.Context, xmlConfig string) error
+	CreateDisk(ctx context.Context, path, format string, size uint64) error
+	DeleteDisk(ctx context.Context, path string) error
 	DeleteVM(ctx context.Context, name string) error
+	SetVMMemory(ctx context.Context, name string, memory uint64, mode VMChangeMode) (*VMChangeResult, error)
+	SetVMVCPUs(ctx context.Context, name string, vcpus, threads int, mode VMChangeMode) (*VMChangeResult, error)
+	AttachDisk(ctx context.Context, name string, disk VMDiskSpec, mode VMChangeMode) (*VMChangeResult, error)
+	DetachDisk(ctx context.Context, name, disk string, mode VMChangeMode) (*VMChangeResult, error)
+	AttachNIC(ctx context.Context, name string, nic VMNICSpec, mode VMChangeMode) (*VMChangeResult, error)
+	DetachNIC(ctx context.Context, name, mac string, mode VMChangeMode) (*VMChangeResult, error)
+	ChangeMedia(ctx context.Context, name, drive, iso string, mode VMChangeMode) (*VMChangeResult, error)
 	ListSnapshots(ctx context.Context, vmName string) ([]Snapshot, error)
-	CreateSnapshot(ctx context.Context, vmName, snapName string) error
+	InspectSnapshot(ctx context.Context, vmName, snapName string) (*SnapshotDetail, error)
+	CreateSnapshot(ctx context.Context, vmName, snapName string, opts SnapshotOptions) error
+	RevertSnapshot(ctx context.Context, vmName, snapName string) error
+	DeleteSnapshot(ctx context.Context, vmName, snapName string) error
 }